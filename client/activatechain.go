@@ -3,7 +3,9 @@ package client
 import (
 	"net/http"
 
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/webapi/model"
 	"github.com/iotaledger/wasp/packages/webapi/routes"
 )
 
@@ -16,3 +18,11 @@ func (c *WaspClient) ActivateChain(chainid coretypes.ChainID) error {
 func (c *WaspClient) DeactivateChain(chainid coretypes.ChainID) error {
 	return c.do(http.MethodPost, routes.DeactivateChain(chainid.String()), nil, nil)
 }
+
+// RotateCommittee sends a request to prepare the chain in the wasp node for the rotation to the next committee
+func (c *WaspClient) RotateCommittee(chainid coretypes.ChainID, nextCommitteeNodes []string, nextAddress *address.Address) error {
+	return c.do(http.MethodPost, routes.RotateCommittee(chainid.String()), &model.RotateCommittee{
+		NextCommitteeNodes: nextCommitteeNodes,
+		NextStateAddress:   model.NewAddress(nextAddress),
+	}, nil)
+}
@@ -1,6 +1,7 @@
 package chainclient
 
 import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
 
@@ -17,6 +18,9 @@ type Client struct {
 	WaspClient   *client.WaspClient
 	ChainID      coretypes.ChainID
 	SigScheme    signaturescheme.SignatureScheme
+	// ChainAddress is the address which holds the chain token. Requests are sent to it.
+	// nil means the address equal to the chain ID, i.e. the committee of the chain was never rotated
+	ChainAddress *address.Address
 }
 
 // New creates a new chainclient.Client
@@ -34,9 +38,31 @@ func New(
 	}
 }
 
+// Address returns the current address of the chain
+func (c *Client) Address() address.Address {
+	if c.ChainAddress == nil {
+		return address.Address(c.ChainID)
+	}
+	return *c.ChainAddress
+}
+
+// requestTarget is the target of the request to the contract. Requests are sent to the current address of the chain
+func (c *Client) requestTarget(contractHname coretypes.Hname) coretypes.ContractID {
+	return coretypes.NewContractID(coretypes.ChainID(c.Address()), contractHname)
+}
+
 type PostRequestParams struct {
-	Transfer coretypes.ColoredBalances
-	Args     requestargs.RequestArgs
+	Transfer  coretypes.ColoredBalances
+	Args      requestargs.RequestArgs
+	GasBudget int64
+	// Encrypt the arguments for the committee of the chain
+	Encrypt bool
+	// TimeLock in Unix seconds. The request is not processed before it
+	TimeLock int64
+	// BlockIndexLock is the minimal index of the block which may settle the request
+	BlockIndexLock uint32
+	// Deadline in Unix seconds. The request which is not processed before it is refunded
+	Deadline int64
 }
 
 // PostRequest sends a request transaction to the chain
@@ -49,16 +75,44 @@ func (c *Client) PostRequest(
 	if len(params) > 0 {
 		par = params[0]
 	}
+	if par.Encrypt {
+		var err error
+		if par.Args, err = c.EncryptArgs(par.Args); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.postRequestSection(apilib.RequestSectionParams{
+		TargetContractID: c.requestTarget(contractHname),
+		EntryPointCode:   entryPoint,
+		Args:             par.Args,
+	}, par)
+}
+
+// PostMultiCall sends the multi-call request transaction to the chain. The chain runs the calls in order
+// in one request context: either all of them succeed or state changes of all of them are rolled back.
+// The transfer of the request must cover transfers of all calls. Arguments of the params are ignored
+func (c *Client) PostMultiCall(calls []*sctransaction.RequestCall, params ...PostRequestParams) (*sctransaction.Transaction, error) {
+	par := PostRequestParams{}
+	if len(params) > 0 {
+		par = params[0]
+	}
+	return c.postRequestSection(apilib.RequestSectionParams{
+		TargetContractID: c.requestTarget(0),
+		Calls:            calls,
+	}, par)
+}
 
+func (c *Client) postRequestSection(sectPar apilib.RequestSectionParams, par PostRequestParams) (*sctransaction.Transaction, error) {
+	sectPar.Transfer = par.Transfer
+	sectPar.GasBudget = par.GasBudget
+	sectPar.TimeLock = par.TimeLock
+	sectPar.BlockIndexLock = par.BlockIndexLock
+	sectPar.Deadline = par.Deadline
 	return apilib.CreateRequestTransaction(apilib.CreateRequestTransactionParams{
-		Level1Client:    c.Level1Client,
-		SenderSigScheme: c.SigScheme,
-		RequestSectionParams: []apilib.RequestSectionParams{{
-			TargetContractID: coretypes.NewContractID(c.ChainID, contractHname),
-			EntryPointCode:   entryPoint,
-			Transfer:         par.Transfer,
-			Args:             par.Args,
-		}},
-		Post: true,
+		Level1Client:         c.Level1Client,
+		SenderSigScheme:      c.SigScheme,
+		RequestSectionParams: []apilib.RequestSectionParams{sectPar},
+		Post:                 true,
 	})
 }
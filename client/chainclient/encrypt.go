@@ -0,0 +1,54 @@
+package chainclient
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+// EncryptArgs encrypts request arguments for the committee of the chain.
+// Public key shares of the committee members are retrieved from the node. The node is not trusted:
+// the shares are checked to be the shares of the key of the chain address
+func (c *Client) EncryptArgs(args requestargs.RequestArgs) (requestargs.RequestArgs, error) {
+	addr := c.Address()
+	info, err := c.WaspClient.DKSharesGet(&addr)
+	if err != nil {
+		return nil, err
+	}
+	suite := pairing.NewSuiteBn256()
+	sharedPublic, err := decodePoint(suite, info.SharedPubKey)
+	if err != nil {
+		return nil, err
+	}
+	pubShares := make([]kyber.Point, len(info.PubKeyShares))
+	for i, s := range info.PubKeyShares {
+		if pubShares[i], err = decodePoint(suite, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := tcrypto.VerifyPublicShares(suite, &addr, sharedPublic, pubShares, info.Threshold); err != nil {
+		return nil, fmt.Errorf("wrong key shares of the committee: %v", err)
+	}
+	data, err := tcrypto.EncryptToShares(suite, pubShares, util.MustBytes(args))
+	if err != nil {
+		return nil, err
+	}
+	return requestargs.New(nil).AddEncrypted(data), nil
+}
+
+func decodePoint(suite kyber.Group, s string) (kyber.Point, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	ret := suite.Point()
+	if err := ret.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,11 @@
+package chainclient
+
+import (
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+)
+
+// RequestReceipt fetches the receipt of the request processed by the chain
+func (c *Client) RequestReceipt(reqID *coretypes.RequestID) (*receipts.RequestReceipt, error) {
+	return c.WaspClient.RequestReceipt(&c.ChainID, reqID)
+}
@@ -64,14 +64,14 @@ func (c *Client) FetchSCStatus(addCustomQueries func(query *statequery.Request))
 		Description:   description,
 		OwnerAddress:  res.Get(vmconst.VarNameOwnerAddress).MustAddress(),
 		MinimumReward: minReward,
-		SCAddress:     (address.Address)(c.ChainID),
+		SCAddress:     c.Address(),
 		Balance:       balance,
 		FetchedAt:     time.Now().UTC(),
 	}, res, nil
 }
 
 func (c *Client) FetchBalance() (map[balance.Color]int64, error) {
-	addr := (address.Address)(c.ChainID)
+	addr := c.Address()
 	outs, err := c.Level1Client.GetConfirmedAccountOutputs(&addr)
 	if err != nil {
 		return nil, err
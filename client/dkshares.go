@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/webapi/model"
 	"github.com/iotaledger/wasp/packages/webapi/routes"
 )
@@ -28,3 +29,19 @@ func (c *WaspClient) DKSharesGet(sharedAddress *address.Address) (*model.DKShare
 	err := c.do(http.MethodGet, routes.DKSharesGet(sharedAddressStr), nil, &response)
 	return &response, err
 }
+
+// DKSharesReshare reshares an existing DKShare to other nodes and returns its new state.
+// The address of the DKShare is preserved.
+func (c *WaspClient) DKSharesReshare(sharedAddress *address.Address, request *model.DKSharesReshareRequest) (*model.DKSharesInfo, error) {
+	var response model.DKSharesInfo
+	err := c.do(http.MethodPost, routes.DKSharesReshare(sharedAddress.String()), request, &response)
+	return &response, err
+}
+
+// DKSharesSignRotation returns the signature share of the node which confirms the rotation of the chain
+// to the address of the DKShare.
+func (c *WaspClient) DKSharesSignRotation(sharedAddress *address.Address, chainID *coretypes.ChainID) (*model.DKSharesSigShare, error) {
+	var response model.DKSharesSigShare
+	err := c.do(http.MethodPost, routes.DKSharesSignRotation(sharedAddress.String(), chainID.String()), nil, &response)
+	return &response, err
+}
@@ -1,6 +1,7 @@
 package multiclient
 
 import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/client"
 	"github.com/iotaledger/wasp/packages/coretypes"
 )
@@ -18,3 +19,10 @@ func (m *MultiClient) DeactivateChain(chainid coretypes.ChainID) error {
 		return w.DeactivateChain(chainid)
 	})
 }
+
+// RotateCommittee prepares the chain in all wasp nodes for the rotation to the next committee
+func (m *MultiClient) RotateCommittee(chainid coretypes.ChainID, nextCommitteeNodes []string, nextAddress *address.Address) error {
+	return m.Do(func(i int, w *client.WaspClient) error {
+		return w.RotateCommittee(chainid, nextCommitteeNodes, nextAddress)
+	})
+}
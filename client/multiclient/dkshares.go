@@ -1,8 +1,11 @@
 package multiclient
 
 import (
+	"encoding/base64"
+
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/client"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/webapi/model"
 )
 
@@ -16,3 +19,19 @@ func (m *MultiClient) DKSharesGet(sharedAddress *address.Address) ([]*model.DKSh
 	})
 	return ret, err
 }
+
+// DKSharesSignRotation collects signature shares of the nodes which confirm the rotation of the chain
+// to the address of the DKShare. Signature shares of the nodes which failed are nil.
+// It fails if less than 'quorum' nodes returned their signature shares
+func (m *MultiClient) DKSharesSignRotation(sharedAddress *address.Address, chainID *coretypes.ChainID, quorum int) ([][]byte, error) {
+	ret := make([][]byte, len(m.nodes))
+	err := m.DoWithQuorum(func(i int, w *client.WaspClient) error {
+		resp, err := w.DKSharesSignRotation(sharedAddress, chainID)
+		if err != nil {
+			return err
+		}
+		ret[i], err = base64.StdEncoding.DecodeString(resp.SigShare)
+		return err
+	}, quorum)
+	return ret, err
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+// This API is used to maintain the list of the peers trusted by the node.
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+)
+
+// PeeringSelfGet returns the identity of the node in the peering network.
+func (c *WaspClient) PeeringSelfGet() (*model.PeeringNodeIdentity, error) {
+	var response model.PeeringNodeIdentity
+	err := c.do(http.MethodGet, routes.PeeringSelfGet(), nil, &response)
+	return &response, err
+}
+
+// PeeringTrustedList returns the peers trusted by the node.
+func (c *WaspClient) PeeringTrustedList() ([]*model.PeeringTrustedNode, error) {
+	var response []*model.PeeringTrustedNode
+	err := c.do(http.MethodGet, routes.PeeringTrustedList(), nil, &response)
+	return response, err
+}
+
+// PeeringTrustedPost adds the peer to the trust list of the node, or renames an already trusted peer.
+func (c *WaspClient) PeeringTrustedPost(pubKey, name string) (*model.PeeringTrustedNode, error) {
+	var response model.PeeringTrustedNode
+	request := model.PeeringTrustedNode{PubKey: pubKey, Name: name}
+	err := c.do(http.MethodPost, routes.PeeringTrustedPost(), &request, &response)
+	return &response, err
+}
+
+// PeeringTrustedDelete removes the peer from the trust list of the node.
+func (c *WaspClient) PeeringTrustedDelete(pubKey string) (*model.PeeringTrustedNode, error) {
+	var response model.PeeringTrustedNode
+	err := c.do(http.MethodDelete, routes.PeeringTrustedDelete(url.PathEscape(pubKey)), nil, &response)
+	return &response, err
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+)
+
+// RequestReceipt fetches the receipt of the request processed by the chain
+func (c *WaspClient) RequestReceipt(chainID *coretypes.ChainID, reqID *coretypes.RequestID) (*receipts.RequestReceipt, error) {
+	res := &model.RequestReceipt{}
+	if err := c.do(http.MethodGet, routes.RequestReceipt(chainID.String(), reqID.Base58()), nil, res); err != nil {
+		return nil, err
+	}
+	return res.RequestReceipt()
+}
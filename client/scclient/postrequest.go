@@ -3,9 +3,21 @@ package scclient
 import (
 	"github.com/iotaledger/wasp/client/chainclient"
 	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 )
 
 func (c *SCClient) PostRequest(fname string, params ...chainclient.PostRequestParams) (*sctransaction.Transaction, error) {
 	return c.ChainClient.PostRequest(c.ContractHname, coretypes.Hn(fname), params...)
 }
+
+// NewCall creates the call of the function of the contract to be included into the multi-call request
+func (c *SCClient) NewCall(fname string, args dict.Dict, transfer coretypes.ColoredBalances) *sctransaction.RequestCall {
+	return sctransaction.NewRequestCall(c.ContractHname, coretypes.Hn(fname), args, transfer)
+}
+
+// PostMultiCall sends the multi-call request to the chain of the contract.
+// The calls may target any contracts of the chain
+func (c *SCClient) PostMultiCall(calls []*sctransaction.RequestCall, params ...chainclient.PostRequestParams) (*sctransaction.Transaction, error) {
+	return c.ChainClient.PostMultiCall(calls, params...)
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+)
+
+// ExportSnapshot takes the snapshot of the solid state of the chain in the wasp node
+func (c *WaspClient) ExportSnapshot(chainID *coretypes.ChainID) (*model.Snapshot, error) {
+	res := &model.Snapshot{}
+	if err := c.do(http.MethodGet, routes.Snapshot(chainID.String()), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ConfirmSnapshot records the exported snapshot as the latest snapshot of the chain in the wasp node.
+// Blocks older than the latest snapshot may be pruned by the node
+func (c *WaspClient) ConfirmSnapshot(chainID *coretypes.ChainID, blockIndex uint32) error {
+	return c.do(http.MethodPost, routes.ConfirmSnapshot(chainID.String()), &model.SnapshotConfirmation{BlockIndex: blockIndex}, nil)
+}
+
+// ImportSnapshot sends the encoded snapshot of the chain state to the wasp node. The chain must not be active in the node
+func (c *WaspClient) ImportSnapshot(chainID *coretypes.ChainID, data []byte) error {
+	return c.do(http.MethodPost, routes.Snapshot(chainID.String()), &model.Snapshot{Data: model.NewBytes(data)}, nil)
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/state/merkle"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+)
+
+// StateProof fetches the value of the key in the chain state together with the proof of inclusion
+// or non-inclusion. The proof must be verified by the caller against the state root committed
+// in the anchor transaction
+func (c *WaspClient) StateProof(chainID *coretypes.ChainID, key kv.Key) (*merkle.Proof, *model.StateProofResponse, error) {
+	res := &model.StateProofResponse{}
+	if err := c.do(http.MethodGet, routes.StateProof(chainID.String(), hex.EncodeToString([]byte(key))), nil, res); err != nil {
+		return nil, nil, err
+	}
+	proof, err := merkle.ProofFromBytes(res.Proof.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, res, nil
+}
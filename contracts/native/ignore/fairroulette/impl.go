@@ -217,7 +217,7 @@ func placeBet(ctx coretypes.Sandbox) error {
 		if ctx.PostRequest(coretypes.PostRequestParams{
 			TargetContractID: ctx.ContractID(),
 			EntryPoint:       RequestLockBets,
-			TimeLock:         uint32(period),
+			TimeLock:         int64(period),
 		}) {
 			ctx.Event(fmt.Sprintf("play deadline is set after %d seconds", period))
 		} else {
@@ -16,6 +16,7 @@ import (
 	"github.com/iotaledger/wasp/client"
 	"github.com/iotaledger/wasp/client/level1"
 	"github.com/iotaledger/wasp/client/multiclient"
+	"github.com/iotaledger/wasp/packages/chain/consensus/acs"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/packages/sctransaction/origin"
@@ -30,6 +31,7 @@ type CreateChainParams struct {
 	T                     uint16
 	OriginatorSigScheme   signaturescheme.SignatureScheme
 	Description           string
+	Consensus             string // consensus algorithm of the chain. Empty means registry.ConsensusLeader
 	Textout               io.Writer
 	Prefix                string
 }
@@ -44,6 +46,11 @@ func DeployChain(par CreateChainParams) (*coretypes.ChainID, *address.Address, *
 		textout = par.Textout
 	}
 	originatorAddr := par.OriginatorSigScheme.Address()
+	if par.Consensus == registry.ConsensusACS {
+		if err = acs.CheckQuorum(par.N, par.T); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
 	fmt.Fprint(textout, par.Prefix)
 	fmt.Fprintf(textout, "creating new chain. Owner address is %s. Parameters N = %d, T = %d\n",
@@ -116,6 +123,7 @@ func DeployChain(par CreateChainParams) (*coretypes.ChainID, *address.Address, *
 		ChainID:        chainID,
 		Color:          chainColor,
 		CommitteeNodes: par.CommitteePeeringHosts,
+		Consensus:      par.Consensus,
 	})
 
 	fmt.Fprint(textout, par.Prefix)
@@ -155,6 +163,7 @@ func DeployChain(par CreateChainParams) (*coretypes.ChainID, *address.Address, *
 		OwnerSignatureScheme: par.OriginatorSigScheme,
 		AllInputs:            allOuts,
 		Description:          par.Description,
+		Consensus:            par.Consensus,
 	})
 	if err != nil {
 		fmt.Fprintf(textout, "creating root init request.. FAILED: %v\n", err)
@@ -19,9 +19,13 @@ import (
 type RequestSectionParams struct {
 	TargetContractID coretypes.ContractID
 	EntryPointCode   coretypes.Hname
-	TimeLock         uint32
+	TimeLock         int64                     // Unix seconds. 0 means no timelock
+	BlockIndexLock   uint32                    // minimal index of the block which may settle the request
+	Deadline         int64                     // Unix seconds. The request is refunded if not processed before it
+	GasBudget        int64                     // 0 means default gas budget
 	Transfer         coretypes.ColoredBalances // should not not include request token. It is added automatically
 	Args             requestargs.RequestArgs
+	Calls            []*sctransaction.RequestCall // calls of the multi-call request. nil for the ordinary request
 }
 
 type CreateRequestTransactionParams struct {
@@ -48,7 +52,11 @@ func CreateRequestTransaction(par CreateRequestTransactionParams) (*sctransactio
 	for _, sectPar := range par.RequestSectionParams {
 		reqSect := sctransaction.NewRequestSectionByWallet(sectPar.TargetContractID, sectPar.EntryPointCode).
 			WithTimelock(sectPar.TimeLock).
-			WithTransfer(sectPar.Transfer)
+			WithBlockIndexLock(sectPar.BlockIndexLock).
+			WithDeadline(sectPar.Deadline).
+			WithGasBudget(sectPar.GasBudget).
+			WithTransfer(sectPar.Transfer).
+			WithCalls(sectPar.Calls...)
 
 		reqSect.WithArgs(sectPar.Args)
 
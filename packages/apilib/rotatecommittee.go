@@ -0,0 +1,310 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package apilib
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/client"
+	"github.com/iotaledger/wasp/client/level1"
+	"github.com/iotaledger/wasp/client/multiclient"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+type RotateCommitteeParams struct {
+	Node                  level1.Level1Client
+	ChainID               coretypes.ChainID
+	ChainColor            balance.Color
+	ChainAddress          *address.Address // current address of the chain. nil if equal to the chain ID
+	CommitteeApiHosts     []string
+	CommitteePeeringHosts []string
+	// the next committee may share nodes with the current one
+	NextCommitteeApiHosts     []string
+	NextCommitteePeeringHosts []string
+	T                         uint16
+	OwnerSigScheme            signaturescheme.SignatureScheme
+	Consensus                 string // consensus algorithm of the chain, the next committee runs the chain with it
+	Textout                   io.Writer
+	Prefix                    string
+}
+
+// RotateCommittee moves the chain to the next committee:
+// - runs DKG on the nodes of the next committee
+// - prepares the nodes of the current committee and activates the chain on the new nodes
+// - announces the rotation to the address of the next committee to the root contract
+// - collects the signature of the rotation by the next committee, which proves it holds the key of the address
+// - confirms the rotation with the signature. The root contract moves the chain to the address of the next committee
+// Returns the address of the next committee
+func RotateCommittee(par RotateCommitteeParams) (*address.Address, error) {
+	textout := ioutil.Discard
+	if par.Textout != nil {
+		textout = par.Textout
+	}
+	chainAddr := address.Address(par.ChainID)
+	if par.ChainAddress != nil {
+		chainAddr = *par.ChainAddress
+	}
+	fmt.Fprint(textout, par.Prefix)
+	fmt.Fprintf(textout, "rotating committee of the chain %s. Current address is %s\n", par.ChainID.String(), chainAddr.String())
+
+	// ----------- run DKG on the next committee
+	dkgInitiatorIndex := rand.Intn(len(par.NextCommitteeApiHosts))
+	dkShares, err := client.NewWaspClient(par.NextCommitteeApiHosts[dkgInitiatorIndex]).DKSharesPost(&model.DKSharesPostRequest{
+		PeerNetIDs:  par.NextCommitteePeeringHosts,
+		PeerPubKeys: nil,
+		Threshold:   par.T,
+		TimeoutMS:   60000, // 1 min
+	})
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "generating distributed key set.. FAILED: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprintf(textout, "generating distributed key set.. OK. Generated address = %s\n", dkShares.Address)
+	nextAddr, err := address.FromBase58(dkShares.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	// ----------- prepare the current committee
+	err = multiclient.New(par.CommitteeApiHosts).RotateCommittee(par.ChainID, par.NextCommitteePeeringHosts, &nextAddr)
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "preparing current committee nodes.. FAILED: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprint(textout, "preparing current committee nodes.. OK\n")
+
+	// ----------- put chain records to the new nodes and activate the chain
+	current := make(map[string]bool)
+	for _, host := range par.CommitteePeeringHosts {
+		current[host] = true
+	}
+	newApiHosts := make([]string, 0)
+	for i, host := range par.NextCommitteePeeringHosts {
+		if !current[host] {
+			newApiHosts = append(newApiHosts, par.NextCommitteeApiHosts[i])
+		}
+	}
+	if len(newApiHosts) > 0 {
+		newNodes := multiclient.New(newApiHosts)
+		err = newNodes.PutChainRecord(&registry.ChainRecord{
+			ChainID:        par.ChainID,
+			Color:          par.ChainColor,
+			CommitteeNodes: par.NextCommitteePeeringHosts,
+			StateAddress:   &nextAddr,
+			PeerNodes:      par.CommitteePeeringHosts,
+			Consensus:      par.Consensus,
+		})
+		if err == nil {
+			err = newNodes.ActivateChain(par.ChainID)
+		}
+		fmt.Fprint(textout, par.Prefix)
+		if err != nil {
+			fmt.Fprintf(textout, "activating chain on new nodes.. FAILED: %v\n", err)
+			return nil, err
+		}
+		fmt.Fprint(textout, "activating chain on new nodes.. OK\n")
+	}
+
+	// ----------- announce the rotation to the next committee
+	reqTx, err := postRootRequest(par, chainAddr, root.FuncRotateCommittee,
+		requestargs.New(nil).AddEncodeSimple(root.ParamChainAddress, codec.EncodeAddress(nextAddr)))
+	if err == nil {
+		reqID := coretypes.NewRequestID(reqTx.ID(), 0)
+		err = multiclient.New(par.CommitteeApiHosts).WaitUntilRequestProcessed(&par.ChainID, &reqID, 60*time.Second)
+	}
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "announcing the rotation.. FAILED: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprintf(textout, "announcing the rotation.. OK. Txid = %s\n", reqTx.ID().String())
+
+	// ----------- the next committee proves it holds the key of the next address
+	pubKey, signature, err := signRotation(par, dkShares, &nextAddr)
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "signing the rotation by the next committee.. FAILED: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprint(textout, "signing the rotation by the next committee.. OK\n")
+
+	// ----------- confirm the rotation, the chain is moved to the next committee
+	reqTx, err = postRootRequest(par, chainAddr, root.FuncConfirmRotation, requestargs.New(nil).
+		AddEncodeSimple(root.ParamPublicKey, pubKey).
+		AddEncodeSimple(root.ParamSignature, signature))
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "posting rotation confirmation request.. FAILED: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprintf(textout, "posting rotation confirmation request.. OK. Txid = %s\n", reqTx.ID().String())
+
+	// ---------- wait until the next committee takes over the chain
+	reqID := coretypes.NewRequestID(reqTx.ID(), 0)
+	err = multiclient.New(par.NextCommitteeApiHosts).WaitUntilRequestProcessed(&par.ChainID, &reqID, 60*time.Second)
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "waiting for the next committee.. FAILED: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprintf(textout, "chain %s has been moved to the next committee. Address: %s\n", par.ChainID.String(), nextAddr.String())
+	return &nextAddr, nil
+}
+
+// postRootRequest posts the request of the chain owner to the root contract of the chain at its current address
+func postRootRequest(par RotateCommitteeParams, chainAddr address.Address, funName string, args requestargs.RequestArgs) (*sctransaction.Transaction, error) {
+	return CreateRequestTransaction(CreateRequestTransactionParams{
+		Level1Client:    par.Node,
+		SenderSigScheme: par.OwnerSigScheme,
+		RequestSectionParams: []RequestSectionParams{{
+			TargetContractID: coretypes.NewContractID(coretypes.ChainID(chainAddr), root.Interface.Hname()),
+			EntryPointCode:   coretypes.Hn(funName),
+			Args:             args,
+		}},
+		Post:                true,
+		WaitForConfirmation: true,
+	})
+}
+
+// signRotation collects the signature shares of the rotation from the nodes of the next committee and
+// recovers the signature of the next address. Returns the shared public key and the signature
+func signRotation(par RotateCommitteeParams, dkShares *model.DKSharesInfo, nextAddr *address.Address) ([]byte, []byte, error) {
+	suite := pairing.NewSuiteBn256()
+	pubKey, err := base64.StdEncoding.DecodeString(dkShares.SharedPubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	sharedPublic := suite.G2().Point()
+	if err := sharedPublic.UnmarshalBinary(pubKey); err != nil {
+		return nil, nil, err
+	}
+	pubShares := make([]kyber.Point, len(dkShares.PubKeyShares))
+	for i, s := range dkShares.PubKeyShares {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		pubShares[i] = suite.G2().Point()
+		if err := pubShares[i].UnmarshalBinary(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := tcrypto.VerifyPublicShares(suite, nextAddr, sharedPublic, pubShares, dkShares.Threshold); err != nil {
+		return nil, nil, err
+	}
+	sigShares, err := multiclient.New(par.NextCommitteeApiHosts).DKSharesSignRotation(nextAddr, &par.ChainID, int(dkShares.Threshold))
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := tcrypto.RecoverSignature(suite, sharedPublic, pubShares, dkShares.Threshold,
+		root.RotationProofData(par.ChainID, *nextAddr), sigShares)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := sig.Bytes()
+	return pubKey, data[1+signaturescheme.BLSPublicKeySize:], nil
+}
+
+// ReshareCommittee moves the chain to the next committee by resharing the key of the chain, so the chain
+// stays at its address and no request to the root contract is needed:
+// - reshares the key of the current committee to the nodes of the next committee
+// - switches the chain to the next committee on the nodes of the current committee
+// - activates the chain on the new nodes
+// The next committee must keep at least one node of the current committee, which provides the state
+// of the chain to the new nodes
+func ReshareCommittee(par RotateCommitteeParams) error {
+	textout := ioutil.Discard
+	if par.Textout != nil {
+		textout = par.Textout
+	}
+	chainAddr := address.Address(par.ChainID)
+	if par.ChainAddress != nil {
+		chainAddr = *par.ChainAddress
+	}
+	current := make(map[string]bool)
+	for _, host := range par.CommitteePeeringHosts {
+		current[host] = true
+	}
+	newApiHosts := make([]string, 0)
+	for i, host := range par.NextCommitteePeeringHosts {
+		if !current[host] {
+			newApiHosts = append(newApiHosts, par.NextCommitteeApiHosts[i])
+		}
+	}
+	if len(newApiHosts) == len(par.NextCommitteeApiHosts) {
+		return fmt.Errorf("the next committee must keep at least one node of the current committee")
+	}
+	fmt.Fprint(textout, par.Prefix)
+	fmt.Fprintf(textout, "resharing the key of the chain %s. Address is %s\n", par.ChainID.String(), chainAddr.String())
+
+	// ----------- reshare the key from the current committee to the next committee
+	dkgInitiatorIndex := rand.Intn(len(par.CommitteeApiHosts))
+	dkShares, err := client.NewWaspClient(par.CommitteeApiHosts[dkgInitiatorIndex]).DKSharesReshare(&chainAddr, &model.DKSharesReshareRequest{
+		OldPeerNetIDs: par.CommitteePeeringHosts,
+		PeerNetIDs:    par.NextCommitteePeeringHosts,
+		Threshold:     par.T,
+		TimeoutMS:     60000, // 1 min
+	})
+	if err == nil && dkShares.Address != chainAddr.String() {
+		err = fmt.Errorf("the key was reshared to a different address %s", dkShares.Address)
+	}
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "resharing distributed key set.. FAILED: %v\n", err)
+		return err
+	}
+	fmt.Fprint(textout, "resharing distributed key set.. OK\n")
+
+	// ----------- switch the current committee to the next committee
+	err = multiclient.New(par.CommitteeApiHosts).RotateCommittee(par.ChainID, par.NextCommitteePeeringHosts, &chainAddr)
+	fmt.Fprint(textout, par.Prefix)
+	if err != nil {
+		fmt.Fprintf(textout, "switching current committee nodes.. FAILED: %v\n", err)
+		return err
+	}
+	fmt.Fprint(textout, "switching current committee nodes.. OK\n")
+
+	// ----------- put chain records to the new nodes and activate the chain
+	if len(newApiHosts) > 0 {
+		newNodes := multiclient.New(newApiHosts)
+		err = newNodes.PutChainRecord(&registry.ChainRecord{
+			ChainID:        par.ChainID,
+			Color:          par.ChainColor,
+			CommitteeNodes: par.NextCommitteePeeringHosts,
+			StateAddress:   par.ChainAddress,
+			Consensus:      par.Consensus,
+		})
+		if err == nil {
+			err = newNodes.ActivateChain(par.ChainID)
+		}
+		fmt.Fprint(textout, par.Prefix)
+		if err != nil {
+			fmt.Fprintf(textout, "activating chain on new nodes.. FAILED: %v\n", err)
+			return err
+		}
+		fmt.Fprint(textout, "activating chain on new nodes.. OK\n")
+	}
+	fmt.Fprint(textout, par.Prefix)
+	fmt.Fprintf(textout, "chain %s has been moved to the next committee. Address: %s\n", par.ChainID.String(), chainAddr.String())
+	return nil
+}
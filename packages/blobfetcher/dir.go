@@ -0,0 +1,36 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package blobfetcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+)
+
+// DirSource is a content-addressed directory: each blob is a file named by its hash in base58
+type DirSource struct {
+	dir string
+}
+
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+func (s *DirSource) Name() string {
+	return "dir:" + s.dir
+}
+
+func (s *DirSource) FetchBlob(h hashing.HashValue) ([]byte, bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, h.String()))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+// Package blobfetcher downloads data blobs, referenced by hashes in request arguments,
+// which are missing in the local blob cache
+package blobfetcher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+)
+
+// Source is a place data blobs can be downloaded from
+type Source interface {
+	// Name of the source, for logging
+	Name() string
+	// FetchBlob returns data of the blob. Returns false if the source doesn't have the blob
+	FetchBlob(h hashing.HashValue) ([]byte, bool, error)
+}
+
+// Fetcher is a blob cache which downloads missing blobs from sources.
+// GetBlob returns false for a missing blob and starts the download in the background.
+// The downloaded data is put into the underlying cache only if its hash matches the requested one
+type Fetcher struct {
+	cache      coretypes.BlobCacheFull
+	sources    []Source
+	log        *logger.Logger
+	mutex      sync.Mutex
+	inProgress map[hashing.HashValue]bool
+}
+
+// New creates a fetcher on top of the blob cache
+func New(cache coretypes.BlobCacheFull, log *logger.Logger, sources ...Source) *Fetcher {
+	return &Fetcher{
+		cache:      cache,
+		sources:    sources,
+		log:        log,
+		inProgress: make(map[hashing.HashValue]bool),
+	}
+}
+
+// GetBlob returns the blob from the cache. If the blob is missing, starts the download
+func (f *Fetcher) GetBlob(h hashing.HashValue) ([]byte, bool, error) {
+	data, ok, err := f.cache.GetBlob(h)
+	if err != nil || ok {
+		return data, ok, err
+	}
+	f.startFetch(h)
+	return nil, false, nil
+}
+
+func (f *Fetcher) HasBlob(h hashing.HashValue) (bool, error) {
+	return f.cache.HasBlob(h)
+}
+
+func (f *Fetcher) PutBlob(data []byte, ttl ...time.Duration) (hashing.HashValue, error) {
+	return f.cache.PutBlob(data, ttl...)
+}
+
+// PutVerified puts data into the cache if it is the blob with the hash
+func (f *Fetcher) PutVerified(h hashing.HashValue, data []byte) error {
+	if hashing.HashData(data) != h {
+		return fmt.Errorf("hash of the data doesn't match the blob hash %s", h.String())
+	}
+	_, err := f.cache.PutBlob(data)
+	return err
+}
+
+// Fetch downloads the blob from sources, one by one, until verified data is received
+func (f *Fetcher) Fetch(h hashing.HashValue) ([]byte, bool) {
+	for _, src := range f.sources {
+		data, ok, err := src.FetchBlob(h)
+		if err != nil {
+			f.log.Debugf("blob %s can't be fetched from %s: %v", h.String(), src.Name(), err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := f.PutVerified(h, data); err != nil {
+			f.log.Warnf("wrong blob received from %s: %v", src.Name(), err)
+			continue
+		}
+		f.log.Infof("blob %s fetched from %s. Size: %d bytes", h.String(), src.Name(), len(data))
+		return data, true
+	}
+	return nil, false
+}
+
+func (f *Fetcher) startFetch(h hashing.HashValue) {
+	if len(f.sources) == 0 {
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.inProgress[h] {
+		return
+	}
+	f.inProgress[h] = true
+	go func() {
+		f.Fetch(h)
+
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(f.inProgress, h)
+	}()
+}
@@ -0,0 +1,128 @@
+package blobfetcher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+	"github.com/stretchr/testify/require"
+)
+
+var blobData = []byte("data-data-data-data-data-data-data-data-data")
+
+type fakeSource struct {
+	data []byte
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) FetchBlob(h hashing.HashValue) ([]byte, bool, error) {
+	return s.data, s.data != nil, nil
+}
+
+func newFetcher(t *testing.T, sources ...Source) *Fetcher {
+	log := testutil.NewLogger(t)
+	reg := registry.NewRegistry(nil, log, dbprovider.NewInMemoryDBProvider(log))
+	return New(reg, log, sources...)
+}
+
+func TestFetchVerified(t *testing.T) {
+	h := hashing.HashData(blobData)
+	f := newFetcher(t, &fakeSource{data: []byte("wrong data")}, &fakeSource{data: blobData})
+
+	data, ok := f.Fetch(h)
+	require.True(t, ok)
+	require.EqualValues(t, blobData, data)
+	data, ok, err := f.GetBlob(h)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, blobData, data)
+
+	require.Error(t, f.PutVerified(hashing.RandomHash(nil), blobData))
+}
+
+func TestFetchInBackground(t *testing.T) {
+	h := hashing.HashData(blobData)
+	f := newFetcher(t, &fakeSource{data: blobData})
+
+	_, ok, err := f.GetBlob(h)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Eventually(t, func() bool {
+		ok, err := f.HasBlob(h)
+		return err == nil && ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFetchNotFound(t *testing.T) {
+	f := newFetcher(t, &fakeSource{data: []byte("wrong data")}, NewDirSource(t.TempDir()))
+	_, ok := f.Fetch(hashing.HashData(blobData))
+	require.False(t, ok)
+}
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	h := hashing.HashData(blobData)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, h.String()), blobData, 0644))
+
+	data, ok := newFetcher(t, NewDirSource(dir)).Fetch(h)
+	require.True(t, ok)
+	require.EqualValues(t, blobData, data)
+}
+
+func TestHTTPSource(t *testing.T) {
+	h := hashing.HashData(blobData)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != routes.GetBlob(h.String()) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(model.NewHTTPError(http.StatusNotFound, "Not found"))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(model.NewBlobData(blobData))
+	}))
+	defer srv.Close()
+
+	src := NewHTTPSource(srv.URL)
+	data, ok, err := src.FetchBlob(h)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, blobData, data)
+
+	_, ok, err = src.FetchBlob(hashing.RandomHash(nil))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestPeerSource(t *testing.T) {
+	h := hashing.HashData(blobData)
+	var src *PeerSource
+	src = NewPeerSource(func(hash hashing.HashValue) uint16 {
+		require.EqualValues(t, h, hash)
+		go src.Deliver(blobData)
+		return 1
+	}, time.Second)
+	data, ok, err := src.FetchBlob(h)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, blobData, data)
+	// nobody waits for the blob
+	require.False(t, src.Deliver(blobData))
+
+	// no response from peers
+	src = NewPeerSource(func(hashing.HashValue) uint16 { return 1 }, 10*time.Millisecond)
+	_, ok, err = src.FetchBlob(h)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
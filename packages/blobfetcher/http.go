@@ -0,0 +1,43 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package blobfetcher
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iotaledger/wasp/client"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+)
+
+const httpTimeout = 10 * time.Second
+
+// HTTPSource downloads blobs from the blob endpoint of the web API of a Wasp node
+type HTTPSource struct {
+	baseURL string
+	client  *client.WaspClient
+}
+
+func NewHTTPSource(baseURL string) *HTTPSource {
+	return &HTTPSource{
+		baseURL: baseURL,
+		client:  client.NewWaspClient(baseURL, http.Client{Timeout: httpTimeout}),
+	}
+}
+
+func (s *HTTPSource) Name() string {
+	return s.baseURL
+}
+
+func (s *HTTPSource) FetchBlob(h hashing.HashValue) ([]byte, bool, error) {
+	data, err := s.client.GetBlob(h)
+	if model.IsHTTPNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package blobfetcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+)
+
+// PeerSource downloads blobs from committee peers. The request is sent to peers by the send function,
+// which returns number of peers the request was sent to. Responses are passed back with Deliver
+type PeerSource struct {
+	send    func(h hashing.HashValue) uint16
+	timeout time.Duration
+	mutex   sync.Mutex
+	waiting map[hashing.HashValue]chan []byte
+}
+
+func NewPeerSource(send func(h hashing.HashValue) uint16, timeout time.Duration) *PeerSource {
+	return &PeerSource{
+		send:    send,
+		timeout: timeout,
+		waiting: make(map[hashing.HashValue]chan []byte),
+	}
+}
+
+func (s *PeerSource) Name() string {
+	return "peers"
+}
+
+// FetchBlob requests the blob from peers and waits for the first response until timeout
+func (s *PeerSource) FetchBlob(h hashing.HashValue) ([]byte, bool, error) {
+	ch := make(chan []byte, 1)
+	s.mutex.Lock()
+	s.waiting[h] = ch
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.waiting, h)
+	}()
+
+	if s.send(h) == 0 {
+		return nil, false, nil
+	}
+	select {
+	case data := <-ch:
+		return data, true, nil
+	case <-time.After(s.timeout):
+		return nil, false, nil
+	}
+}
+
+// Deliver passes the blob received from a peer to the waiting FetchBlob. The blob is matched by its hash.
+// Returns false if nobody is waiting for the blob
+func (s *PeerSource) Deliver(data []byte) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ch, ok := s.waiting[hashing.HashData(data)]
+	if !ok {
+		return false
+	}
+	select {
+	case ch <- data:
+	default:
+	}
+	return true
+}
@@ -32,6 +32,7 @@ type Chain interface {
 	InitTestRound()
 	HasQuorum() bool
 	PeerStatus() []*PeerStatus
+	SyncStatus() *SyncStatus
 	BlobCache() coretypes.BlobCache
 	//
 	SetReadyStateManager()
@@ -41,6 +42,8 @@ type Chain interface {
 	// requests
 	GetRequestProcessingStatus(*coretypes.RequestID) RequestProcessingStatus
 	EventRequestProcessed() *events.Event
+	// triggered with the new address when the chain is moved to another address by the rotation of the committee
+	EventStateAddressChanged() *events.Event
 	// chain processors
 	Processors() *processors.ProcessorCache
 }
@@ -56,6 +59,25 @@ func (p *PeerStatus) String() string {
 	return fmt.Sprintf("%+v", *p)
 }
 
+// SyncStatus is the progress of syncing the state of the chain from committee peers
+type SyncStatus struct {
+	Synced bool
+	// index of the solid state of the node
+	SolidIndex uint32
+	// largest state index evidenced by peers
+	TargetIndex uint32
+	// blocks applied to the state per second since the sync started
+	BlocksPerSecond float64
+	// number of blocks fetched and waiting to be applied
+	BlocksFetched int
+	// indices of peers which blocks were requested from
+	PeersUsed []uint16
+}
+
+func (s *SyncStatus) String() string {
+	return fmt.Sprintf("%+v", *s)
+}
+
 type RequestProcessingStatus int
 
 const (
@@ -68,11 +90,13 @@ type StateManager interface {
 	EvidenceStateIndex(idx uint32)
 	EventStateIndexPingPongMsg(msg *StateIndexPingPongMsg)
 	EventGetBlockMsg(msg *GetBlockMsg)
+	EventGetBlockRangeMsg(msg *GetBlockRangeMsg)
 	EventBlockHeaderMsg(msg *BlockHeaderMsg)
 	EventStateUpdateMsg(msg *StateUpdateMsg)
 	EventStateTransactionMsg(msg *StateTransactionMsg)
 	EventPendingBlockMsg(msg PendingBlockMsg)
 	EventTimerMsg(msg TimerTick)
+	SyncStatus() *SyncStatus
 	Close()
 }
 
@@ -86,6 +110,8 @@ type Operator interface {
 	EventSignedHashMsg(*SignedHashMsg)
 	EventNotifyFinalResultPostedMsg(*NotifyFinalResultPostedMsg)
 	EventTransactionInclusionLevelMsg(msg *TransactionInclusionLevelMsg)
+	EventBFTMsg(*BFTMsg)
+	EventACSMsg(*ACSMsg)
 	EventTimerMsg(TimerTick)
 	Close()
 	//
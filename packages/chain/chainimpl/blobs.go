@@ -0,0 +1,54 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package chainimpl
+
+import (
+	"time"
+
+	"github.com/iotaledger/wasp/packages/blobfetcher"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// initBlobFetcher makes blobs, missing in the blob cache of the node, to be downloaded from committee peers
+func (c *chainObj) initBlobFetcher() {
+	c.blobFetcher = c.blobProvider
+	cache, ok := c.blobProvider.(coretypes.BlobCacheFull)
+	if !ok {
+		return
+	}
+	c.peerBlobSource = blobfetcher.NewPeerSource(c.requestBlobFromPeers, chain.FetchBlobFromPeersTimeout)
+	c.blobFetcher = blobfetcher.New(cache, c.log, c.peerBlobSource)
+}
+
+func (c *chainObj) requestBlobFromPeers(h hashing.HashValue) uint16 {
+	msgData := util.MustBytes(&chain.GetBlobMsg{BlobHash: h})
+	return c.SendMsgToCommitteePeers(chain.MsgGetBlob, msgData, time.Now().UnixNano())
+}
+
+// getBlob responds to the peer with the blob if it is in the blob cache of the node
+func (c *chainObj) getBlob(msg *chain.GetBlobMsg) {
+	data, ok, err := c.blobProvider.GetBlob(msg.BlobHash)
+	if err != nil {
+		c.log.Errorf("getBlob: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if err := c.SendMsg(msg.SenderIndex, chain.MsgBlob, util.MustBytes(&chain.BlobMsg{Data: data})); err != nil {
+		c.log.Errorf("getBlob: %v", err)
+	}
+}
+
+func (c *chainObj) receiveBlob(msg *chain.BlobMsg) {
+	if c.peerBlobSource == nil {
+		return
+	}
+	if !c.peerBlobSource.Deliver(msg.Data) {
+		c.log.Debugf("unexpected blob received from peer #%d", msg.SenderIndex)
+	}
+}
@@ -14,13 +14,19 @@ import (
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/blobfetcher"
 	"github.com/iotaledger/wasp/packages/chain"
 	"github.com/iotaledger/wasp/packages/chain/consensus"
+	"github.com/iotaledger/wasp/packages/chain/consensus/acs"
 	"github.com/iotaledger/wasp/packages/chain/statemgr"
 	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/subrealm"
 	"github.com/iotaledger/wasp/packages/peering"
 	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
 	"go.uber.org/atomic"
 )
 
@@ -36,6 +42,8 @@ type chainObj struct {
 	onActivation                 func()
 	//
 	chainID         coretypes.ChainID
+	chainRecord     *registry.ChainRecord
+	address         address.Address
 	procset         *processors.ProcessorCache
 	color           balance.Color
 	peers           peering.GroupProvider
@@ -47,12 +55,15 @@ type chainObj struct {
 	operator        chain.Operator
 	isCommitteeNode atomic.Bool
 	//
-	eventRequestProcessed *events.Event
-	log                   *logger.Logger
-	netProvider           peering.NetworkProvider
-	peersAttachRef        interface{}
-	dksProvider           tcrypto.RegistryProvider
-	blobProvider          coretypes.BlobCache
+	eventRequestProcessed    *events.Event
+	eventStateAddressChanged *events.Event
+	log                      *logger.Logger
+	netProvider              peering.NetworkProvider
+	peersAttachRef           interface{}
+	dksProvider              tcrypto.RegistryProvider
+	blobProvider             coretypes.BlobCache
+	blobFetcher              coretypes.BlobCache
+	peerBlobSource           *blobfetcher.PeerSource
 }
 
 func requestIDCaller(handler interface{}, params ...interface{}) {
@@ -70,7 +81,7 @@ func newCommitteeObj(
 	var err error
 	log.Debugw("creating committee", "addr", chr.ChainID.String())
 
-	addr := address.Address(chr.ChainID)
+	addr := chr.Address()
 	if util.ContainsDuplicates(chr.CommitteeNodes) {
 		log.Errorf("can't create chain object for %s: chain record contains duplicate node addresses. Chain nodes: %+v",
 			addr.String(), chr.CommitteeNodes)
@@ -88,11 +99,22 @@ func newCommitteeObj(
 		)
 		return nil
 	}
+	if err := checkConsensusOfSolidState(chr); err != nil {
+		log.Errorf("can't create chain object for %s: %v", addr.String(), err)
+		return nil
+	}
+	if chr.ConsensusOrDefault() == registry.ConsensusACS {
+		if err := acs.CheckQuorum(dkshare.N, dkshare.T); err != nil {
+			log.Errorf("can't run the ACS consensus for %s: %v", addr.String(), err)
+			return nil
+		}
+	}
+	// committee nodes come first in the group, the rest are peers which only share the state
 	var peers peering.GroupProvider
-	if peers, err = netProvider.Group(chr.CommitteeNodes); err != nil {
+	if peers, err = netProvider.Group(chr.Peers()); err != nil {
 		log.Errorf(
 			"node %s failed to setup committee communication with %+v, reason=%+v",
-			netProvider.Self().NetID(), chr.CommitteeNodes, err,
+			netProvider.Self().NetID(), chr.Peers(), err,
 		)
 		return nil
 	}
@@ -101,17 +123,23 @@ func newCommitteeObj(
 		procset:      processors.MustNew(),
 		chMsg:        make(chan interface{}, 100),
 		chainID:      chr.ChainID,
+		chainRecord:  chr,
+		address:      addr,
 		color:        chr.Color,
 		peers:        peers,
 		onActivation: onActivation,
 		eventRequestProcessed: events.NewEvent(func(handler interface{}, params ...interface{}) {
 			handler.(func(_ coretypes.RequestID))(params[0].(coretypes.RequestID))
 		}),
+		eventStateAddressChanged: events.NewEvent(func(handler interface{}, params ...interface{}) {
+			handler.(func(_ address.Address))(params[0].(address.Address))
+		}),
 		log:          chainLog,
 		netProvider:  netProvider,
 		dksProvider:  dksProvider,
 		blobProvider: blobProvider,
 	}
+	ret.initBlobFetcher()
 	ret.peersAttachRef = peers.Attach(&ret.chainID, func(recv *peering.RecvEvent) {
 		ret.ReceiveMessage(recv.Msg)
 	})
@@ -121,7 +149,14 @@ func newCommitteeObj(
 	ret.quorum = dkshare.T
 
 	ret.stateMgr = statemgr.New(ret, ret.log)
-	ret.operator = consensus.NewOperator(ret, dkshare, ret.log)
+	switch chr.ConsensusOrDefault() {
+	case registry.ConsensusBFT:
+		ret.operator = consensus.NewBFTOperator(ret, dkshare, ret.log)
+	case registry.ConsensusACS:
+		ret.operator = consensus.NewACSOperator(ret, dkshare, ret.log)
+	default:
+		ret.operator = consensus.NewOperator(ret, dkshare, ret.log)
+	}
 	ret.isCommitteeNode.Store(true)
 	go func() {
 		for msg := range ret.chMsg {
@@ -147,6 +182,30 @@ func newCommitteeObj(
 	return ret
 }
 
+// checkConsensusOfSolidState checks the consensus of the chain record against the solid state of the chain,
+// if the node already has it. Otherwise the check is made on the state transitions
+func checkConsensusOfSolidState(chr *registry.ChainRecord) error {
+	solidState, _, ok, err := state.LoadSolidState(&chr.ChainID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	return checkConsensus(chr, solidState)
+}
+
+// checkConsensus checks if the chain record agrees with the consensus algorithm stored in the root contract
+// when the chain was initialized. All the committee nodes must run the same consensus
+func checkConsensus(chr *registry.ChainRecord, virtualState state.VirtualState) error {
+	rootState := subrealm.New(virtualState.Variables(), kv.Key(root.Interface.Hname().Bytes()))
+	consensus, initialized := root.GetConsensus(rootState)
+	if !initialized {
+		return nil
+	}
+	return chr.CheckConsensus(consensus)
+}
+
 // iAmInTheCommittee checks if NetIDs makes sense
 func iAmInTheCommittee(committeeNodes []string, n, index uint16, netProvider peering.NetworkProvider) bool {
 	if len(committeeNodes) != int(n) {
@@ -26,6 +26,12 @@ func (c *chainObj) dispatchMessage(msg interface{}) {
 		c.stateMgr.EventStateUpdateMsg(msgt)
 
 	case *chain.StateTransitionMsg:
+		if err := checkConsensus(c.chainRecord, msgt.VariableState); err != nil {
+			// the node would not agree with the rest of the committee
+			c.log.Errorf("dismissing the chain: %v", err)
+			c.Dismiss()
+			return
+		}
 		if c.operator != nil {
 			c.operator.EventStateTransitionMsg(msgt)
 		}
@@ -73,6 +79,12 @@ func (c *chainObj) dispatchMessage(msg interface{}) {
 	}
 }
 
+// isCommitteePeer returns false for peers which only share the state of the chain, e.g. the nodes of
+// the previous or the next committee while the committee is being rotated
+func (c *chainObj) isCommitteePeer(peerIndex uint16) bool {
+	return peerIndex < c.size
+}
+
 func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 
 	rdr := bytes.NewReader(msg.MsgData)
@@ -100,7 +112,7 @@ func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 
 		msgt.SenderIndex = msg.SenderIndex
 
-		if c.operator != nil {
+		if c.operator != nil && c.isCommitteePeer(msg.SenderIndex) {
 			c.operator.EventNotifyReqMsg(msgt)
 		}
 
@@ -114,7 +126,7 @@ func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 
 		msgt.SenderIndex = msg.SenderIndex
 
-		if c.operator != nil {
+		if c.operator != nil && c.isCommitteePeer(msg.SenderIndex) {
 			c.operator.EventNotifyFinalResultPostedMsg(msgt)
 		}
 
@@ -129,7 +141,7 @@ func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 		msgt.SenderIndex = msg.SenderIndex
 		msgt.Timestamp = msg.Timestamp
 
-		if c.operator != nil {
+		if c.operator != nil && c.isCommitteePeer(msg.SenderIndex) {
 			c.operator.EventStartProcessingBatchMsg(msgt)
 		}
 
@@ -144,10 +156,38 @@ func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 		msgt.SenderIndex = msg.SenderIndex
 		msgt.Timestamp = msg.Timestamp
 
-		if c.operator != nil {
+		if c.operator != nil && c.isCommitteePeer(msg.SenderIndex) {
 			c.operator.EventSignedHashMsg(msgt)
 		}
 
+	case chain.MsgBFT:
+		msgt := &chain.BFTMsg{}
+		if err := msgt.Read(rdr); err != nil {
+			c.log.Error(err)
+			return
+		}
+		c.stateMgr.EvidenceStateIndex(msgt.BlockIndex)
+
+		msgt.SenderIndex = msg.SenderIndex
+
+		if c.operator != nil && c.isCommitteePeer(msg.SenderIndex) {
+			c.operator.EventBFTMsg(msgt)
+		}
+
+	case chain.MsgACS:
+		msgt := &chain.ACSMsg{}
+		if err := msgt.Read(rdr); err != nil {
+			c.log.Error(err)
+			return
+		}
+		c.stateMgr.EvidenceStateIndex(msgt.BlockIndex)
+
+		msgt.SenderIndex = msg.SenderIndex
+
+		if c.operator != nil && c.isCommitteePeer(msg.SenderIndex) {
+			c.operator.EventACSMsg(msgt)
+		}
+
 	case chain.MsgGetBatch:
 		msgt := &chain.GetBlockMsg{}
 		if err := msgt.Read(rdr); err != nil {
@@ -159,6 +199,17 @@ func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 
 		c.stateMgr.EventGetBlockMsg(msgt)
 
+	case chain.MsgGetBlockRange:
+		msgt := &chain.GetBlockRangeMsg{}
+		if err := msgt.Read(rdr); err != nil {
+			c.log.Error(err)
+			return
+		}
+
+		msgt.SenderIndex = msg.SenderIndex
+
+		c.stateMgr.EventGetBlockRangeMsg(msgt)
+
 	case chain.MsgBatchHeader:
 		msgt := &chain.BlockHeaderMsg{}
 		if err := msgt.Read(rdr); err != nil {
@@ -181,6 +232,26 @@ func (c *chainObj) processPeerMessage(msg *peering.PeerMessage) {
 		msgt.SenderIndex = msg.SenderIndex
 		c.stateMgr.EventStateUpdateMsg(msgt)
 
+	case chain.MsgGetBlob:
+		msgt := &chain.GetBlobMsg{}
+		if err := msgt.Read(rdr); err != nil {
+			c.log.Error(err)
+			return
+		}
+
+		msgt.SenderIndex = msg.SenderIndex
+		c.getBlob(msgt)
+
+	case chain.MsgBlob:
+		msgt := &chain.BlobMsg{}
+		if err := msgt.Read(rdr); err != nil {
+			c.log.Error(err)
+			return
+		}
+
+		msgt.SenderIndex = msg.SenderIndex
+		c.receiveBlob(msgt)
+
 	case chain.MsgTestTrace:
 		msgt := &chain.TestTraceMsg{}
 		if err := msgt.Read(rdr); err != nil {
@@ -148,7 +148,7 @@ func (c *chainObj) Color() *balance.Color {
 }
 
 func (c *chainObj) Address() address.Address {
-	return address.Address(c.chainID)
+	return c.address
 }
 
 func (c *chainObj) Size() uint16 {
@@ -196,8 +196,15 @@ func (c *chainObj) SendMsgToCommitteePeers(msgType byte, msgData []byte, ts int6
 		MsgType:     msgType,
 		MsgData:     msgData,
 	}
-	c.peers.Broadcast(msg, false)
-	return uint16(len(c.peers.OtherNodes())) // TODO: [KP] Reconsider this, we cannot guaranty if they are actually sent.
+	numSent := uint16(0)
+	for i, peer := range c.committeePeers() {
+		if i == c.ownIndex || peer == nil {
+			continue
+		}
+		peer.SendMsg(msg)
+		numSent++
+	}
+	return numSent // TODO: [KP] Reconsider this, we cannot guaranty if they are actually sent.
 }
 
 // sends message to the peer seq[seqIndex]. If receives error, seqIndex = (seqIndex+1) % size and repeats
@@ -246,7 +253,13 @@ func (c *chainObj) NumPeers() uint16 {
 
 // first N peers are committee peers, the rest are access peers in any
 func (c *chainObj) committeePeers() map[uint16]peering.PeerSender {
-	return c.peers.AllNodes()
+	ret := make(map[uint16]peering.PeerSender)
+	for i, peer := range c.peers.AllNodes() {
+		if i < c.size {
+			ret[i] = peer
+		}
+	}
+	return ret
 }
 
 func (c *chainObj) HasQuorum() bool {
@@ -285,8 +298,12 @@ func (c *chainObj) PeerStatus() []*chain.PeerStatus {
 	return ret
 }
 
+func (c *chainObj) SyncStatus() *chain.SyncStatus {
+	return c.stateMgr.SyncStatus()
+}
+
 func (c *chainObj) BlobCache() coretypes.BlobCache {
-	return c.blobProvider
+	return c.blobFetcher
 }
 
 func (c *chainObj) GetRequestProcessingStatus(reqID *coretypes.RequestID) chain.RequestProcessingStatus {
@@ -315,3 +332,7 @@ func (c *chainObj) Processors() *processors.ProcessorCache {
 func (c *chainObj) EventRequestProcessed() *events.Event {
 	return c.eventRequestProcessed
 }
+
+func (c *chainObj) EventStateAddressChanged() *events.Event {
+	return c.eventStateAddressChanged
+}
@@ -0,0 +1,302 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package acs
+
+import (
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
+)
+
+// aba is the asynchronous binary agreement on the inclusion of the proposal of one node.
+// It runs in rounds (Mostefaoui, Moumen, Raynal with the additional conf step): the nodes exchange their
+// estimations, agree on the set of the values estimated by the correct nodes and flip the common coin.
+// The value is decided when it is the only value of the set and it is equal to the coin.
+// Otherwise the nodes continue with the next round, estimating the only value of the set or the coin.
+// The node which decided sends the term message and keeps running the rounds until a quorum of nodes decided,
+// the node which receives enough term messages decides without waiting for the coin
+type aba struct {
+	acs      *ACS
+	instance uint16
+
+	hasInput bool
+	est      bool
+	round    uint32
+	rounds   map[uint32]*abaRound
+
+	decided    bool
+	decision   bool
+	terms      map[uint16]bool
+	ownTerm    *message
+	terminated bool
+}
+
+type abaRound struct {
+	bvals    [2]map[uint16]bool
+	bvalSent [2]*message
+	// set of the values estimated by the correct nodes
+	binValues byte
+	aux       map[uint16]byte
+	ownAux    *message
+	conf      map[uint16]byte
+	ownConf   *message
+	// set of the values agreed in the round, 0 until it is known
+	vals       byte
+	coinShares map[uint16]tbdn.SigShare
+	ownCoin    *message
+	coin       *bool
+}
+
+func newABA(acs *ACS, instance uint16) *aba {
+	return &aba{
+		acs:      acs,
+		instance: instance,
+		rounds:   make(map[uint32]*abaRound),
+		terms:    make(map[uint16]bool),
+	}
+}
+
+func (a *aba) getRound(round uint32) *abaRound {
+	ret, ok := a.rounds[round]
+	if !ok {
+		ret = &abaRound{
+			bvals:      [2]map[uint16]bool{make(map[uint16]bool), make(map[uint16]bool)},
+			aux:        make(map[uint16]byte),
+			conf:       make(map[uint16]byte),
+			coinShares: make(map[uint16]tbdn.SigShare),
+		}
+		a.rounds[round] = ret
+	}
+	return ret
+}
+
+// input starts the agreement with the estimation of the node
+func (a *aba) input(b bool) {
+	if a.hasInput {
+		return
+	}
+	a.hasInput = true
+	a.est = b
+	a.sendBVal(a.getRound(a.round), b)
+}
+
+func (a *aba) receive(sender uint16, msg *message) {
+	if msg.kind == msgTypeTerm {
+		if msg.bits == mask(false) || msg.bits == mask(true) {
+			if _, ok := a.terms[sender]; !ok {
+				a.terms[sender] = msg.bits == mask(true)
+			}
+		}
+		return
+	}
+	if msg.round > a.round+maxRoundsAhead {
+		return
+	}
+	r := a.getRound(msg.round)
+	switch msg.kind {
+	case msgTypeBVal:
+		if msg.bits == mask(false) || msg.bits == mask(true) {
+			r.bvals[index(msg.bits == mask(true))][sender] = true
+		}
+	case msgTypeAux:
+		if _, ok := r.aux[sender]; !ok && (msg.bits == mask(false) || msg.bits == mask(true)) {
+			r.aux[sender] = msg.bits
+		}
+	case msgTypeConf:
+		if _, ok := r.conf[sender]; !ok && msg.bits > 0 && msg.bits <= mask(false)|mask(true) {
+			r.conf[sender] = msg.bits
+		}
+	case msgTypeCoin:
+		if _, ok := r.coinShares[sender]; !ok && a.acs.verifyCoinShare(sender, a.instance, msg.round, msg.sigShare) {
+			r.coinShares[sender] = msg.sigShare
+		}
+	}
+}
+
+// step runs the agreement as far as the messages received allow. Returns true if anything has changed
+func (a *aba) step() bool {
+	if a.terminated {
+		return false
+	}
+	progress := false
+	if !a.decided {
+		for _, b := range []bool{false, true} {
+			if a.countTerms(b) > int(a.acs.maxFaulty()) {
+				// at least one correct node decided
+				a.decide(b)
+				progress = true
+				break
+			}
+		}
+	}
+	if a.decided && a.countTerms(a.decision) >= int(a.acs.cfg.T) {
+		// all correct nodes decided or will decide from the term messages
+		a.terminated = true
+		return true
+	}
+	for a.hasInput && a.stepRound() {
+		progress = true
+	}
+	return progress
+}
+
+// stepRound runs the current round. Returns true if anything has changed
+func (a *aba) stepRound() bool {
+	f := int(a.acs.maxFaulty())
+	r := a.getRound(a.round)
+	progress := false
+	for _, b := range []bool{false, true} {
+		if r.bvalSent[index(b)] == nil && len(r.bvals[index(b)]) > f {
+			// the value is estimated by at least one correct node
+			a.sendBVal(r, b)
+			progress = true
+		}
+		if r.binValues&mask(b) == 0 && len(r.bvals[index(b)]) > 2*f {
+			r.binValues |= mask(b)
+			progress = true
+		}
+	}
+	if r.binValues == 0 {
+		return progress
+	}
+	if r.ownAux == nil {
+		w := a.est
+		if r.binValues&mask(w) == 0 {
+			w = !w
+		}
+		r.ownAux = &message{kind: msgTypeAux, instance: a.instance, round: a.round, bits: mask(w)}
+		r.aux[a.acs.cfg.Index] = r.ownAux.bits
+		a.acs.broadcast(r.ownAux)
+		progress = true
+	}
+	if r.ownConf == nil {
+		if _, ok := subsetQuorum(r.aux, r.binValues, a.acs.cfg.T); !ok {
+			return progress
+		}
+		r.ownConf = &message{kind: msgTypeConf, instance: a.instance, round: a.round, bits: r.binValues}
+		r.conf[a.acs.cfg.Index] = r.binValues
+		a.acs.broadcast(r.ownConf)
+		progress = true
+	}
+	if r.vals == 0 {
+		vals, ok := subsetQuorum(r.conf, r.binValues, a.acs.cfg.T)
+		if !ok {
+			return progress
+		}
+		r.vals = vals
+		progress = true
+	}
+	if r.ownCoin == nil {
+		// the coin is revealed only after the set of the values is fixed
+		sigShare, err := a.acs.cfg.Signer.SignShare(a.acs.coinData(a.instance, a.round))
+		if err != nil {
+			a.acs.log.Errorf("failed to sign the coin share: %v", err)
+			return progress
+		}
+		r.ownCoin = &message{kind: msgTypeCoin, instance: a.instance, round: a.round, sigShare: sigShare}
+		r.coinShares[a.acs.cfg.Index] = sigShare
+		a.acs.broadcast(r.ownCoin)
+		progress = true
+	}
+	if r.coin == nil {
+		coin, ok := a.acs.recoverCoin(r.coinShares, a.instance, a.round)
+		if !ok {
+			return progress
+		}
+		r.coin = &coin
+	}
+	// the round is over
+	if r.vals == mask(false) || r.vals == mask(true) {
+		b := r.vals == mask(true)
+		a.est = b
+		if b == *r.coin && !a.decided {
+			a.decide(b)
+		}
+	} else {
+		a.est = *r.coin
+	}
+	a.round++
+	a.sendBVal(a.getRound(a.round), a.est)
+	return true
+}
+
+func (a *aba) sendBVal(r *abaRound, b bool) {
+	if r.bvalSent[index(b)] != nil {
+		return
+	}
+	r.bvalSent[index(b)] = &message{kind: msgTypeBVal, instance: a.instance, round: a.round, bits: mask(b)}
+	r.bvals[index(b)][a.acs.cfg.Index] = true
+	a.acs.broadcast(r.bvalSent[index(b)])
+}
+
+func (a *aba) decide(b bool) {
+	a.decided = true
+	a.decision = b
+	a.acs.log.Debugf("inclusion of the proposal of #%d decided: %v in round %d", a.instance, b, a.round)
+	a.ownTerm = &message{kind: msgTypeTerm, instance: a.instance, bits: mask(b)}
+	a.terms[a.acs.cfg.Index] = b
+	a.acs.broadcast(a.ownTerm)
+	if !a.hasInput {
+		// the node keeps running the rounds until the agreement terminates
+		a.input(b)
+	}
+}
+
+func (a *aba) countTerms(b bool) int {
+	ret := 0
+	for _, v := range a.terms {
+		if v == b {
+			ret++
+		}
+	}
+	return ret
+}
+
+// resend repeats the messages of the node. All rounds are repeated, because the nodes
+// lagging behind need them. There are only a few rounds, each one ends with the probability of 1/2
+func (a *aba) resend() {
+	if a.ownTerm != nil {
+		a.acs.broadcast(a.ownTerm)
+	}
+	if a.terminated {
+		return
+	}
+	for round := uint32(0); round <= a.round; round++ {
+		r, ok := a.rounds[round]
+		if !ok {
+			continue
+		}
+		for _, msg := range []*message{r.bvalSent[0], r.bvalSent[1], r.ownAux, r.ownConf, r.ownCoin} {
+			if msg != nil {
+				a.acs.broadcast(msg)
+			}
+		}
+	}
+}
+
+// subsetQuorum checks if at least the quorum of nodes sent the values which are in the set.
+// Returns the union of those values
+func subsetQuorum(values map[uint16]byte, set byte, quorum uint16) (byte, bool) {
+	count := 0
+	union := byte(0)
+	for _, v := range values {
+		if v&set == v {
+			count++
+			union |= v
+		}
+	}
+	return union, count >= int(quorum)
+}
+
+func index(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// coinValue is the bit of the hash of the threshold signature, which is unpredictable until a quorum of shares is known
+func coinValue(signature []byte) bool {
+	h := hashing.HashData(signature)
+	return h[0]&1 == 1
+}
@@ -0,0 +1,364 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package acs
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	// messages are accepted for at most that many rounds of the binary agreement ahead of the current round
+	maxRoundsAhead = 32
+	// maximum number of messages of the next sessions kept until the agreement for the session is started
+	maxFutureMessages = 10000
+)
+
+// Signer is the threshold signature scheme of the committee, which provides the common coin.
+// It is implemented by tcrypto.DKShare
+type Signer interface {
+	SignShare(data []byte) (tbdn.SigShare, error)
+	VerifySigShare(data []byte, sigshare tbdn.SigShare) error
+	RecoverFullSignature(sigShares [][]byte, data []byte) (signaturescheme.Signature, error)
+}
+
+// Network delivers the messages of the node to the other nodes of the committee
+type Network interface {
+	// SendMsg sends the message to the node with the index
+	SendMsg(peerIndex uint16, data []byte)
+	// Broadcast sends the message to all the other nodes
+	Broadcast(data []byte)
+}
+
+// Config contains the parameters of the node
+type Config struct {
+	Index   uint16 // index of the node in the committee
+	N       uint16 // size of the committee
+	T       uint16 // quorum of the committee, i.e. the threshold of the committee key
+	Signer  Signer
+	Network Network
+	// ResendPeriod is the period the messages of the node are repeated with to overcome message loss
+	ResendPeriod time.Duration
+	Log          *logger.Logger
+}
+
+// ACS is the state of one node in the agreement on the common subset of the proposals of the nodes.
+// The agreement is identified by the height and the epoch, the epoch is the number of the agreement
+// at the same height. The ACS is not thread safe, all calls are expected from the same goroutine
+type ACS struct {
+	cfg Config
+	log *logger.Logger
+
+	started bool
+	session session
+	seed    hashing.HashValue
+	// true if the node proposed its value in the session
+	proposed bool
+	// true if any message of the session was received from other nodes
+	activated  bool
+	rbcs       []*rbc
+	abas       []*aba
+	output     map[uint16][]byte
+	nextResend time.Time
+
+	// messages of the next sessions
+	future []*receivedMsg
+	// final messages of the previous epoch, repeated for the nodes which have not finished it yet
+	prevEpoch []*message
+	prevFinal session
+}
+
+type receivedMsg struct {
+	sender uint16
+	data   []byte
+}
+
+// CheckQuorum checks the agreement can be run by the committee of n nodes with the quorum t.
+// The agreement tolerates n-t faulty nodes and requires n >= 3*(n-t)+1
+func CheckQuorum(n, t uint16) error {
+	if t > n || n < 3*(n-t)+1 {
+		return fmt.Errorf("the quorum %d is too small for the committee of %d nodes: at least %d is needed", t, n, n-(n-1)/3)
+	}
+	return nil
+}
+
+// New creates the node of the agreement. Returns an error if the quorum is too small (see CheckQuorum)
+func New(cfg Config) (*ACS, error) {
+	if err := CheckQuorum(cfg.N, cfg.T); err != nil {
+		return nil, err
+	}
+	return &ACS{
+		cfg: cfg,
+		log: cfg.Log,
+	}, nil
+}
+
+// Start starts the agreement for the height and the epoch. The seed must be the same for all nodes.
+// Messages of the earlier sessions are dropped, messages of the later sessions are kept until their session is started.
+// The stopped agreement is resumed if it is started for the same session again
+func (a *ACS) Start(height, epoch uint32, seed hashing.HashValue, now time.Time) {
+	s := session{height: height, epoch: epoch}
+	if a.rbcs != nil && a.session == s && a.seed == seed {
+		if !a.started {
+			a.started = true
+			a.replayFuture()
+			a.act()
+		}
+		return
+	}
+	a.prevEpoch = nil
+	if a.started && a.output != nil && height == a.session.height {
+		a.prevEpoch = a.finalMessages()
+		a.prevFinal = a.session
+	}
+	a.started = true
+	a.session = s
+	a.seed = seed
+	a.proposed = false
+	a.activated = false
+	a.rbcs = make([]*rbc, a.cfg.N)
+	a.abas = make([]*aba, a.cfg.N)
+	for i := range a.rbcs {
+		a.rbcs[i] = newRBC(a, uint16(i))
+		a.abas[i] = newABA(a, uint16(i))
+	}
+	a.output = nil
+	a.nextResend = now.Add(a.cfg.ResendPeriod)
+
+	a.log.Debugf("agreement started for height #%d, epoch %d", height, epoch)
+
+	a.replayFuture()
+	a.act()
+}
+
+// replayFuture processes the messages kept for the started session
+func (a *ACS) replayFuture() {
+	future := a.future
+	a.future = nil
+	for _, msg := range future {
+		a.receive(msg.sender, msg.data)
+	}
+}
+
+// Stop stops the agreement. Messages are kept until the agreement is started again
+func (a *ACS) Stop() {
+	a.started = false
+}
+
+// Height returns the height of the agreement
+func (a *ACS) Height() uint32 {
+	return a.session.height
+}
+
+// Epoch returns the epoch of the agreement
+func (a *ACS) Epoch() uint32 {
+	return a.session.epoch
+}
+
+// Proposed returns true if the node proposed its value in the current session
+func (a *ACS) Proposed() bool {
+	return a.proposed
+}
+
+// Activated returns true if other nodes already take part in the agreement. The node should propose its value
+// then, even if it has nothing to propose, so the agreement can terminate
+func (a *ACS) Activated() bool {
+	return a.activated
+}
+
+// Propose proposes the value of the node, the value must not be empty. Only the first proposal in the session counts
+func (a *ACS) Propose(value []byte) {
+	if !a.started || a.proposed {
+		return
+	}
+	a.proposed = true
+	a.rbcs[a.cfg.Index].propose(value)
+	a.act()
+}
+
+// Output returns the proposals agreed on by index of the proposer, nil if the agreement is not finished yet.
+// The output contains the proposals of at least T nodes, at least T-(N-T) of them are proposals of correct nodes
+func (a *ACS) Output() map[uint16][]byte {
+	return a.output
+}
+
+// ReceiveMessage processes the message from another node
+func (a *ACS) ReceiveMessage(sender uint16, data []byte) {
+	a.receive(sender, data)
+	a.act()
+}
+
+// Tick repeats the messages of the node
+func (a *ACS) Tick(now time.Time) {
+	if !a.started || now.Before(a.nextResend) {
+		return
+	}
+	a.nextResend = now.Add(a.cfg.ResendPeriod)
+	for _, msg := range a.prevEpoch {
+		a.cfg.Network.Broadcast(encodeMessage(a.prevFinal, msg))
+	}
+	for i := range a.rbcs {
+		a.rbcs[i].resend()
+		a.abas[i].resend()
+	}
+}
+
+// maxFaulty is the number of the nodes which may fail
+func (a *ACS) maxFaulty() uint16 {
+	return a.cfg.N - a.cfg.T
+}
+
+func (a *ACS) receive(sender uint16, data []byte) {
+	if sender >= a.cfg.N || sender == a.cfg.Index {
+		return
+	}
+	s, msg, err := decodeMessage(data)
+	if err != nil {
+		a.log.Warnf("wrong message from #%d: %v", sender, err)
+		return
+	}
+	if msg.instance >= a.cfg.N {
+		return
+	}
+	switch {
+	case s.before(a.session):
+		return
+	case !a.started || a.session.before(s):
+		if len(a.future) < maxFutureMessages {
+			a.future = append(a.future, &receivedMsg{sender: sender, data: data})
+		}
+		return
+	}
+	a.activated = true
+	switch msg.kind {
+	case msgTypeInit, msgTypeEcho, msgTypeReady:
+		a.rbcs[msg.instance].receive(sender, msg)
+	default:
+		a.abas[msg.instance].receive(sender, msg)
+	}
+}
+
+// act runs the agreement as far as the messages received allow
+func (a *ACS) act() {
+	if !a.started {
+		return
+	}
+	for progress := true; progress; {
+		progress = false
+		for i := range a.rbcs {
+			if a.rbcs[i].step() {
+				progress = true
+			}
+			if a.rbcs[i].delivered != nil && !a.abas[i].hasInput {
+				// vote for the inclusion of the delivered proposal
+				a.abas[i].input(true)
+				progress = true
+			}
+			if a.abas[i].step() {
+				progress = true
+			}
+		}
+		if a.countIncluded() >= int(a.cfg.T) {
+			// enough proposals are included, vote against the inclusion of the proposals not delivered yet
+			for i := range a.abas {
+				if !a.abas[i].hasInput {
+					a.abas[i].input(false)
+					progress = true
+				}
+			}
+		}
+	}
+	a.checkOutput()
+}
+
+func (a *ACS) countIncluded() int {
+	ret := 0
+	for _, ba := range a.abas {
+		if ba.decided && ba.decision {
+			ret++
+		}
+	}
+	return ret
+}
+
+// checkOutput finishes the agreement when all binary agreements are decided and all included proposals are delivered
+func (a *ACS) checkOutput() {
+	if a.output != nil {
+		return
+	}
+	for i, ba := range a.abas {
+		if !ba.decided || ba.decision && a.rbcs[i].delivered == nil {
+			return
+		}
+	}
+	a.output = make(map[uint16][]byte)
+	for i, ba := range a.abas {
+		if ba.decision {
+			a.output[uint16(i)] = a.rbcs[i].delivered
+		}
+	}
+	a.log.Debugf("common subset agreed for height #%d, epoch %d: %d proposals", a.session.height, a.session.epoch, len(a.output))
+}
+
+// finalMessages are the messages which let the nodes lagging behind finish the agreement
+func (a *ACS) finalMessages() []*message {
+	ret := make([]*message, 0)
+	for i := range a.rbcs {
+		for _, msg := range []*message{a.rbcs[i].ownEcho, a.rbcs[i].ownReady, a.abas[i].ownTerm} {
+			if msg != nil {
+				ret = append(ret, msg)
+			}
+		}
+	}
+	return ret
+}
+
+func (a *ACS) broadcast(msg *message) {
+	a.cfg.Network.Broadcast(encodeMessage(a.session, msg))
+}
+
+// coinData binds the coin to the round of the binary agreement of the session identified by the height, the epoch
+// and the seed
+func (a *ACS) coinData(instance uint16, round uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("acs coin")
+	_ = util.WriteUint32(&buf, a.session.height)
+	_ = util.WriteUint32(&buf, a.session.epoch)
+	buf.Write(a.seed[:])
+	_ = util.WriteUint16(&buf, instance)
+	_ = util.WriteUint32(&buf, round)
+	return buf.Bytes()
+}
+
+func (a *ACS) verifyCoinShare(sender, instance uint16, round uint32, sigShare tbdn.SigShare) bool {
+	// the share must be of the sender itself, VerifySigShare does not check it
+	if idx, err := sigShare.Index(); err != nil || idx != int(sender) {
+		return false
+	}
+	return a.cfg.Signer.VerifySigShare(a.coinData(instance, round), sigShare) == nil
+}
+
+// recoverCoin recovers the coin from a quorum of the shares
+func (a *ACS) recoverCoin(sigShares map[uint16]tbdn.SigShare, instance uint16, round uint32) (bool, bool) {
+	if len(sigShares) < int(a.cfg.T) {
+		return false, false
+	}
+	shares := make([][]byte, 0, len(sigShares))
+	for _, sigShare := range sigShares {
+		shares = append(shares, sigShare)
+	}
+	signature, err := a.cfg.Signer.RecoverFullSignature(shares, a.coinData(instance, round))
+	if err != nil {
+		a.log.Errorf("failed to recover the coin: %v", err)
+		return false, false
+	}
+	return coinValue(signature.Bytes()), true
+}
@@ -0,0 +1,380 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package acs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testResendPeriod = 50 * time.Millisecond
+	testTickPeriod   = 10 * time.Millisecond
+	testTimeout      = 60 * time.Second
+)
+
+type testCommittee struct {
+	*testutil.Committee
+	outputs chan *nodeOutput
+}
+
+// testNode runs the agreement of a correct node. It moves to the next height when the subset is agreed
+type testNode struct {
+	committee *testCommittee
+	index     uint16
+	acs       *ACS
+	heights   uint32
+	done      uint32 // number of the heights agreed
+}
+
+type nodeOutput struct {
+	index  uint16
+	height uint32
+	output map[uint16][]byte
+}
+
+type silentNetwork struct{}
+
+// recordingNetwork keeps the messages broadcast by the node
+type recordingNetwork struct {
+	broadcast []*message
+}
+
+func newTestCommittee(t *testing.T, n, quorum uint16, behavior testutil.PeeringNetBehavior) *testCommittee {
+	return &testCommittee{
+		Committee: testutil.NewCommittee(t, n, quorum, behavior),
+		outputs:   make(chan *nodeOutput, 1000),
+	}
+}
+
+func (c *testCommittee) config(index uint16, network Network) Config {
+	return Config{
+		Index:        index,
+		N:            c.N,
+		T:            c.DKShares[index].T,
+		Signer:       c.DKShares[index],
+		Network:      network,
+		ResendPeriod: testResendPeriod,
+		Log:          c.Log.Named(fmt.Sprintf("#%d", index)),
+	}
+}
+
+// startNodes starts the agreement of the correct nodes, which agree on the subsets of the number of heights.
+// All nodes are attached to the network before any of them starts sending
+func (c *testCommittee) startNodes(correct []uint16, heights uint32) {
+	for _, index := range correct {
+		a, err := New(c.config(index, c.Network(index)))
+		require.NoError(c.T, err)
+		c.AttachNode(index, &testNode{
+			committee: c,
+			index:     index,
+			acs:       a,
+			heights:   heights,
+		})
+	}
+	c.StartNodes(testTickPeriod)
+}
+
+// byzantineNode is a node which does not take part in the agreement, it is only used to sign the messages crafted by the test
+func (c *testCommittee) byzantineNode(index uint16) *ACS {
+	a, err := New(c.config(index, silentNetwork{}))
+	require.NoError(c.T, err)
+	a.Start(0, 0, testSeed(0), time.Now())
+	return a
+}
+
+// waitOutputs waits for the outputs of all correct nodes on all heights and checks they agree
+func (c *testCommittee) waitOutputs(correct []uint16, heights uint32) []map[uint16][]byte {
+	ret := make([]map[uint16][]byte, heights)
+	outputs := make(map[uint32]map[uint16]bool)
+	timeout := time.After(testTimeout)
+	for count := 0; count < len(correct)*int(heights); count++ {
+		select {
+		case o := <-c.outputs:
+			require.Less(c.T, o.height, heights)
+			if outputs[o.height] == nil {
+				outputs[o.height] = make(map[uint16]bool)
+			}
+			require.False(c.T, outputs[o.height][o.index], "node #%d agreed twice at height #%d", o.index, o.height)
+			outputs[o.height][o.index] = true
+			if ret[o.height] != nil {
+				require.EqualValues(c.T, ret[o.height], o.output, "outputs differ at height #%d", o.height)
+			}
+			ret[o.height] = o.output
+			require.GreaterOrEqual(c.T, len(o.output), int(c.DKShares[0].T))
+		case <-timeout:
+			c.T.Fatalf("only %d outputs of %d were produced", count, len(correct)*int(heights))
+		}
+	}
+	return ret
+}
+
+func (n *testNode) Start(now time.Time) {
+	n.acs.Start(0, 0, testSeed(0), now)
+	n.acs.Propose(proposedValue(n.index, 0))
+}
+
+func (n *testNode) ReceiveMessage(sender uint16, data []byte, _ time.Time) {
+	n.acs.ReceiveMessage(sender, data)
+}
+
+func (n *testNode) Tick(now time.Time) {
+	n.acs.Tick(now)
+}
+
+func (n *testNode) Progress() {
+	if out := n.acs.Output(); out != nil && n.acs.Height() == n.done {
+		n.committee.outputs <- &nodeOutput{index: n.index, height: n.done, output: out}
+		n.done++
+		if n.done < n.heights {
+			n.acs.Start(n.done, 0, testSeed(n.done), time.Now())
+			n.acs.Propose(proposedValue(n.index, n.done))
+		}
+	}
+}
+
+func (silentNetwork) SendMsg(uint16, []byte) {}
+
+func (silentNetwork) Broadcast([]byte) {}
+
+func (n *recordingNetwork) SendMsg(uint16, []byte) {}
+
+func (n *recordingNetwork) Broadcast(data []byte) {
+	_, msg, err := decodeMessage(data)
+	if err != nil {
+		panic(err)
+	}
+	n.broadcast = append(n.broadcast, msg)
+}
+
+func (n *recordingNetwork) readies(instance uint16) []hashing.HashValue {
+	ret := make([]hashing.HashValue, 0)
+	for _, msg := range n.broadcast {
+		if msg.kind == msgTypeReady && msg.instance == instance {
+			ret = append(ret, msg.hash)
+		}
+	}
+	return ret
+}
+
+func testSeed(height uint32) hashing.HashValue {
+	return hashing.HashStrings(fmt.Sprintf("seed of height #%d", height))
+}
+
+func proposedValue(index uint16, height uint32) []byte {
+	return []byte(fmt.Sprintf("value of #%d at height #%d", index, height))
+}
+
+// checkOutputs checks the subsets contain the values proposed by the nodes
+func checkOutputs(t *testing.T, outputs []map[uint16][]byte) {
+	for height, output := range outputs {
+		for index, value := range output {
+			require.EqualValues(t, proposedValue(index, uint32(height)), value)
+		}
+	}
+}
+
+func TestMessagesWriteRead(t *testing.T) {
+	msgs := []*message{
+		{kind: msgTypeInit, instance: 1, value: []byte("value")},
+		{kind: msgTypeEcho, instance: 2, value: []byte("value")},
+		{kind: msgTypeReady, instance: 3, hash: hashing.HashStrings("value")},
+		{kind: msgTypeBVal, instance: 4, round: 5, bits: mask(true)},
+		{kind: msgTypeAux, instance: 5, round: 6, bits: mask(false)},
+		{kind: msgTypeConf, instance: 6, round: 7, bits: mask(false) | mask(true)},
+		{kind: msgTypeCoin, instance: 7, round: 8, sigShare: []byte{0, 1, 2}},
+		{kind: msgTypeTerm, instance: 8, bits: mask(true)},
+	}
+	s := session{height: 42, epoch: 3}
+	for _, msg := range msgs {
+		back, msgBack, err := decodeMessage(encodeMessage(s, msg))
+		require.NoError(t, err)
+		require.EqualValues(t, s, back)
+		require.EqualValues(t, msg, msgBack)
+	}
+	_, _, err := decodeMessage(append(encodeMessage(s, msgs[0]), 0))
+	require.Error(t, err)
+	_, _, err = decodeMessage([]byte{msgTypeTerm + 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	require.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{N: 4, T: 2})
+	require.Error(t, err)
+	_, err = New(Config{N: 4, T: 5})
+	require.Error(t, err)
+	_, err = New(Config{N: 4, T: 3})
+	require.NoError(t, err)
+	_, err = New(Config{N: 1, T: 1})
+	require.NoError(t, err)
+}
+
+func TestReliableNet(t *testing.T) {
+	const heights = 3
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	c.startNodes(c.Indices(), heights)
+	checkOutputs(t, c.waitOutputs(c.Indices(), heights))
+}
+
+func TestUnreliableNet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	const heights = 3
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetUnreliable(
+		70,                                        // Delivered %
+		20,                                        // Duplicated %
+		10*time.Millisecond, 200*time.Millisecond, // Delays (from, till)
+		testutil.WithLevel(testutil.NewLogger(t), logger.LevelInfo, false),
+	))
+	c.startNodes(c.Indices(), heights)
+	checkOutputs(t, c.waitOutputs(c.Indices(), heights))
+}
+
+// TestSilentNodes checks the agreement terminates when the faulty nodes are crashed or partitioned.
+// The subset then consists of the proposals of all correct nodes
+func TestSilentNodes(t *testing.T) {
+	c := newTestCommittee(t, 7, 5, testutil.NewPeeringNetReliable())
+	correct := c.Indices(1, 4)
+	c.startNodes(correct, 1)
+	outputs := c.waitOutputs(correct, 1)
+	checkOutputs(t, outputs)
+	require.Len(t, outputs[0], len(correct))
+	for _, i := range correct {
+		require.Contains(t, outputs[0], i)
+	}
+}
+
+// TestEquivocatingProposer checks correct nodes agree when the proposer sends different proposals to different nodes
+func TestEquivocatingProposer(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	const byzantine = 0
+	correct := c.Indices(byzantine)
+	c.startNodes(correct, 1)
+	s := session{}
+	values := [][]byte{proposedValue(byzantine, 0), []byte("another value")}
+	for i, to := range [][]uint16{correct[:2], correct[2:]} {
+		c.SendTo(byzantine, encodeMessage(s, &message{kind: msgTypeInit, instance: byzantine, value: values[i]}), to...)
+	}
+	// the proposer also echoes the first value to everyone, so the value may be delivered
+	c.SendTo(byzantine, encodeMessage(s, &message{kind: msgTypeEcho, instance: byzantine, value: values[0]}), correct...)
+	outputs := c.waitOutputs(correct, 1)
+	checkOutputs(t, outputs)
+}
+
+// TestByzantineMessages checks the agreement is not disturbed by malformed messages, forged coin shares
+// and term messages of a byzantine node
+func TestByzantineMessages(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	const byzantine = 3
+	correct := c.Indices(byzantine)
+	c.startNodes(correct, 1)
+	byz := c.byzantineNode(byzantine)
+	s := session{}
+	c.SendTo(byzantine, []byte("garbage"), correct...)
+	c.SendTo(byzantine, encodeMessage(s, &message{kind: msgTypeTerm, instance: c.N, bits: mask(true)}), correct...)
+	c.SendTo(byzantine, encodeMessage(s, &message{kind: msgTypeBVal, instance: 0, round: 1 << 30, bits: mask(true)}), correct...)
+	for i := uint16(0); i < c.N; i++ {
+		// votes for the inclusion of the proposals which are never delivered
+		c.SendTo(byzantine, encodeMessage(s, &message{kind: msgTypeTerm, instance: i, bits: mask(i != byzantine)}), correct...)
+		for round := uint32(0); round < 3; round++ {
+			// the coin shares are signed by another node
+			sigShare, err := c.DKShares[0].SignShare(byz.coinData(i, round))
+			require.NoError(t, err)
+			c.SendTo(byzantine, encodeMessage(s, &message{kind: msgTypeCoin, instance: i, round: round, sigShare: sigShare}), correct...)
+		}
+	}
+	outputs := c.waitOutputs(correct, 1)
+	checkOutputs(t, outputs)
+}
+
+// TestCommonCoin checks the nodes recover the same coin from different quorums of shares
+// and reject the shares sent on behalf of other nodes
+func TestCommonCoin(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	nodes := make([]*ACS, c.N)
+	shares := make(map[uint16]tbdn.SigShare)
+	for i := range nodes {
+		nodes[i] = c.byzantineNode(uint16(i))
+	}
+	for round := uint32(0); round < 10; round++ {
+		for i := range nodes {
+			sigShare, err := c.DKShares[i].SignShare(nodes[i].coinData(1, round))
+			require.NoError(t, err)
+			require.True(t, nodes[0].verifyCoinShare(uint16(i), 1, round, sigShare))
+			require.False(t, nodes[0].verifyCoinShare(uint16(i+1)%c.N, 1, round, sigShare))
+			require.False(t, nodes[0].verifyCoinShare(uint16(i), 2, round, sigShare))
+			shares[uint16(i)] = sigShare
+		}
+		var coin *bool
+		for except := uint16(0); except < c.N; except++ {
+			quorum := make(map[uint16]tbdn.SigShare)
+			for i, sigShare := range shares {
+				if i != except {
+					quorum[i] = sigShare
+				}
+			}
+			v, ok := nodes[except].recoverCoin(quorum, 1, round)
+			require.True(t, ok)
+			if coin != nil {
+				require.EqualValues(t, *coin, v)
+			}
+			coin = &v
+		}
+		delete(shares, 0)
+		delete(shares, 1)
+		_, ok := nodes[0].recoverCoin(shares, 1, round)
+		require.False(t, ok)
+	}
+}
+
+// TestStopResume checks the stopped agreement is resumed for the same session and restarted for another one
+func TestStopResume(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	a := c.byzantineNode(0)
+	a.Propose(proposedValue(0, 0))
+	require.True(t, a.Proposed())
+	a.Stop()
+	a.Propose(proposedValue(0, 1))
+	a.Start(0, 0, testSeed(0), time.Now())
+	require.True(t, a.Proposed())
+	require.EqualValues(t, proposedValue(0, 0), a.rbcs[0].ownInit.value)
+	a.Start(0, 1, testSeed(0), time.Now())
+	require.False(t, a.Proposed())
+	require.EqualValues(t, 1, a.Epoch())
+}
+
+// TestReadyAmplification checks the node which receives only the ready messages of the reliable broadcast
+// becomes ready itself when more than f nodes are ready, and delivers the value as soon as it knows it
+func TestReadyAmplification(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	net := &recordingNetwork{}
+	a, err := New(c.config(0, net))
+	require.NoError(t, err)
+	a.Start(0, 0, testSeed(0), time.Now())
+	s := session{}
+	const proposer = 1
+	value := proposedValue(proposer, 0)
+	ready := encodeMessage(s, &message{kind: msgTypeReady, instance: proposer, hash: hashing.HashData(value)})
+
+	// a single ready message may come from the faulty node
+	a.ReceiveMessage(2, ready)
+	require.Empty(t, net.readies(proposer))
+	a.ReceiveMessage(3, ready)
+	require.EqualValues(t, []hashing.HashValue{hashing.HashData(value)}, net.readies(proposer))
+
+	// a quorum is ready, but the value is not known yet
+	a.ReceiveMessage(proposer, ready)
+	require.Nil(t, a.rbcs[proposer].delivered)
+	a.ReceiveMessage(2, encodeMessage(s, &message{kind: msgTypeEcho, instance: proposer, value: value}))
+	require.EqualValues(t, value, a.rbcs[proposer].delivered)
+	require.Len(t, net.readies(proposer), 1)
+}
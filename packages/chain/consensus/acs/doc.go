@@ -0,0 +1,20 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+// Package acs implements the asynchronous common subset agreement of the committee (as in HoneyBadgerBFT):
+// every node proposes a value and the correct nodes agree on the same subset of the proposals, which contains
+// the proposals of at least a quorum of nodes. Unlike the agreement driven by a leader, it does not depend
+// on timeouts and a single node can't censor the proposals of the others.
+//
+// The proposal of each node is distributed by the reliable broadcast (see rbc), so either all correct nodes
+// deliver the same proposal or none of them delivers anything, even if the proposer is byzantine. For every
+// proposal the nodes run the binary agreement (see aba) on its inclusion into the subset. A node votes
+// for the inclusion of the proposals it delivered and, when a quorum of proposals is included, votes against
+// the inclusion of the rest. The binary agreement is randomized by the common coin, which is the threshold
+// signature of the committee key (see tcrypto.DKShare) on the round, so it can't be predicted before a quorum
+// of nodes reveal their signature shares.
+//
+// The agreement is identified by the height and the epoch. The messages are repeated periodically, so the
+// protocol tolerates message loss. It is safe and terminates if at most N-T nodes are faulty, where N is
+// the size of the committee, T is the quorum and N >= 3(N-T)+1.
+package acs
@@ -0,0 +1,148 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package acs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// Types of the messages exchanged by the nodes.
+const (
+	// reliable broadcast of the proposals
+	msgTypeInit = byte(iota)
+	msgTypeEcho
+	msgTypeReady
+	// binary agreement on the inclusion of the proposals
+	msgTypeBVal
+	msgTypeAux
+	msgTypeConf
+	msgTypeCoin
+	msgTypeTerm
+)
+
+// message is a message of the reliable broadcast or of the binary agreement about the proposal of the node
+// with the index instance. Only the fields of the message type are used:
+//   - init, echo: value is the proposal
+//   - ready: hash is the hash of the proposal
+//   - bval, aux, term: bits is the mask of the binary value (see mask)
+//   - conf: bits is the mask of the set of the binary values
+//   - coin: sigShare is the signature share of the common coin of the round
+type message struct {
+	kind     byte
+	instance uint16
+	round    uint32
+	value    []byte
+	hash     hashing.HashValue
+	bits     byte
+	sigShare tbdn.SigShare
+}
+
+// session identifies the agreement the message belongs to
+type session struct {
+	height uint32
+	epoch  uint32
+}
+
+func (s session) before(other session) bool {
+	return s.height < other.height || s.height == other.height && s.epoch < other.epoch
+}
+
+// mask returns the set of binary values containing the value
+func mask(b bool) byte {
+	if b {
+		return 2
+	}
+	return 1
+}
+
+// encodeMessage puts the message into the envelope, which consists of the message type and
+// the session of the agreement the message belongs to.
+func encodeMessage(s session, msg *message) []byte {
+	var buf bytes.Buffer
+	_ = util.WriteByte(&buf, msg.kind)
+	_ = util.WriteUint32(&buf, s.height)
+	_ = util.WriteUint32(&buf, s.epoch)
+	_ = msg.Write(&buf)
+	return buf.Bytes()
+}
+
+func decodeMessage(data []byte) (session, *message, error) {
+	r := bytes.NewReader(data)
+	var s session
+	kind, err := util.ReadByte(r)
+	if err != nil {
+		return s, nil, err
+	}
+	if kind > msgTypeTerm {
+		return s, nil, fmt.Errorf("unknown message type %d", kind)
+	}
+	if err := util.ReadUint32(r, &s.height); err != nil {
+		return s, nil, err
+	}
+	if err := util.ReadUint32(r, &s.epoch); err != nil {
+		return s, nil, err
+	}
+	msg := &message{kind: kind}
+	if err := msg.Read(r); err != nil {
+		return s, nil, err
+	}
+	if r.Len() != 0 {
+		return s, nil, fmt.Errorf("%d unexpected bytes after the message", r.Len())
+	}
+	return s, msg, nil
+}
+
+func (m *message) Write(w io.Writer) error {
+	if err := util.WriteUint16(w, m.instance); err != nil {
+		return err
+	}
+	switch m.kind {
+	case msgTypeInit, msgTypeEcho:
+		return util.WriteBytes32(w, m.value)
+	case msgTypeReady:
+		_, err := w.Write(m.hash[:])
+		return err
+	case msgTypeTerm:
+		return util.WriteByte(w, m.bits)
+	}
+	if err := util.WriteUint32(w, m.round); err != nil {
+		return err
+	}
+	if m.kind == msgTypeCoin {
+		return util.WriteBytes16(w, m.sigShare)
+	}
+	return util.WriteByte(w, m.bits)
+}
+
+func (m *message) Read(r io.Reader) error {
+	var err error
+	if err = util.ReadUint16(r, &m.instance); err != nil {
+		return err
+	}
+	switch m.kind {
+	case msgTypeInit, msgTypeEcho:
+		m.value, err = util.ReadBytes32(r)
+		return err
+	case msgTypeReady:
+		return util.ReadHashValue(r, &m.hash)
+	case msgTypeTerm:
+		m.bits, err = util.ReadByte(r)
+		return err
+	}
+	if err = util.ReadUint32(r, &m.round); err != nil {
+		return err
+	}
+	if m.kind == msgTypeCoin {
+		m.sigShare, err = util.ReadBytes16(r)
+		return err
+	}
+	m.bits, err = util.ReadByte(r)
+	return err
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package acs
+
+import (
+	"github.com/iotaledger/wasp/packages/hashing"
+)
+
+// rbc is the reliable broadcast of the proposal of one node (Bracha's broadcast).
+// The proposer sends the value to all nodes, the nodes echo it to each other and, when enough
+// of them echoed the same value, send the ready message with its hash. The value is delivered
+// after a quorum of ready messages, so if any correct node delivers a value, all correct nodes deliver it,
+// even if the proposer sent different values to different nodes
+type rbc struct {
+	acs      *ACS
+	proposer uint16
+
+	echoes     map[hashing.HashValue]map[uint16]bool
+	echoValues map[hashing.HashValue][]byte
+	echoed     map[uint16]bool
+	readies    map[hashing.HashValue]map[uint16]bool
+	readied    map[uint16]bool
+
+	ownInit  *message
+	ownEcho  *message
+	ownReady *message
+
+	delivered []byte
+}
+
+func newRBC(acs *ACS, proposer uint16) *rbc {
+	return &rbc{
+		acs:        acs,
+		proposer:   proposer,
+		echoes:     make(map[hashing.HashValue]map[uint16]bool),
+		echoValues: make(map[hashing.HashValue][]byte),
+		echoed:     make(map[uint16]bool),
+		readies:    make(map[hashing.HashValue]map[uint16]bool),
+		readied:    make(map[uint16]bool),
+	}
+}
+
+// propose broadcasts the own proposal
+func (b *rbc) propose(value []byte) {
+	b.ownInit = &message{kind: msgTypeInit, instance: b.proposer, value: value}
+	b.acs.broadcast(b.ownInit)
+	b.receiveInit(value)
+}
+
+func (b *rbc) receive(sender uint16, msg *message) {
+	switch msg.kind {
+	case msgTypeInit:
+		if sender == b.proposer {
+			b.receiveInit(msg.value)
+		}
+	case msgTypeEcho:
+		b.addEcho(sender, msg.value)
+	case msgTypeReady:
+		b.addReady(sender, msg.hash)
+	}
+}
+
+// receiveInit echoes the first value received from the proposer
+func (b *rbc) receiveInit(value []byte) {
+	if b.ownEcho != nil {
+		return
+	}
+	b.ownEcho = &message{kind: msgTypeEcho, instance: b.proposer, value: value}
+	b.acs.broadcast(b.ownEcho)
+	b.addEcho(b.acs.cfg.Index, value)
+}
+
+func (b *rbc) addEcho(sender uint16, value []byte) {
+	if b.echoed[sender] {
+		return
+	}
+	b.echoed[sender] = true
+	h := hashing.HashData(value)
+	if _, ok := b.echoes[h]; !ok {
+		b.echoes[h] = make(map[uint16]bool)
+		b.echoValues[h] = value
+	}
+	b.echoes[h][sender] = true
+}
+
+func (b *rbc) addReady(sender uint16, h hashing.HashValue) {
+	if b.readied[sender] {
+		return
+	}
+	b.readied[sender] = true
+	if _, ok := b.readies[h]; !ok {
+		b.readies[h] = make(map[uint16]bool)
+	}
+	b.readies[h][sender] = true
+}
+
+// step sends the ready message when a quorum echoed the value or enough nodes are ready for it,
+// and delivers the value when a quorum is ready for it. Returns true if anything has changed
+func (b *rbc) step() bool {
+	if b.delivered != nil {
+		return false
+	}
+	progress := false
+	if b.ownReady == nil {
+		if h, ok := b.readyHash(); ok {
+			b.ownReady = &message{kind: msgTypeReady, instance: b.proposer, hash: h}
+			b.acs.broadcast(b.ownReady)
+			b.addReady(b.acs.cfg.Index, h)
+			progress = true
+		}
+	}
+	for h, readies := range b.readies {
+		value, ok := b.echoValues[h]
+		if ok && len(readies) > 2*int(b.acs.maxFaulty()) {
+			// the value is known from the echoes of the correct nodes
+			b.delivered = value
+			b.acs.log.Debugf("proposal of #%d delivered: %s", b.proposer, h.String())
+			return true
+		}
+	}
+	return progress
+}
+
+// readyHash returns the hash the node is ready for: the hash of the value echoed by a quorum or
+// the hash more than f nodes are ready for. In the latter case at least one correct node is ready for it,
+// so the node is ready too, even if it hasn't received any echo of the value
+func (b *rbc) readyHash() (hashing.HashValue, bool) {
+	for h, echoes := range b.echoes {
+		if len(echoes) >= int(b.acs.cfg.T) {
+			return h, true
+		}
+	}
+	for h, readies := range b.readies {
+		if len(readies) > int(b.acs.maxFaulty()) {
+			return h, true
+		}
+	}
+	return hashing.NilHash, false
+}
+
+// resend repeats the messages of the node
+func (b *rbc) resend() {
+	for _, msg := range []*message{b.ownInit, b.ownEcho, b.ownReady} {
+		if msg != nil {
+			b.acs.broadcast(msg)
+		}
+	}
+}
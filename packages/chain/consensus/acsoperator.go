@@ -0,0 +1,441 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package consensus
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/chain/consensus/acs"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/nodeconn"
+)
+
+// acsOperator is the consensus operator where every node of the committee proposes the requests it knows
+// and the committee agrees on the common subset of the proposals (see package acs). The batch is selected
+// from the agreed proposals deterministically (see selectACSBatch), so a slow or faulty node can't delay
+// or censor the requests. The agreed batch is run and signed by all nodes (see batchSigning)
+type acsOperator struct {
+	batchSigning
+
+	acs     *acs.ACS
+	running bool
+	// the next epoch of the agreement is not started before the time
+	nextEpoch time.Time
+	// unknown requests of the agreed batch are not fetched again before the time
+	nextFetch time.Time
+
+	eventACSMsgCh chan *chain.ACSMsg
+}
+
+// NewACSOperator creates the consensus operator which agrees on batches with the ACS protocol.
+// The quorum of the committee must be checked with acs.CheckQuorum
+func NewACSOperator(committee chain.Chain, dkshare *tcrypto.DKShare, log *logger.Logger) *acsOperator {
+	defer committee.SetReadyConsensus()
+
+	ret := &acsOperator{
+		batchSigning:  newBatchSigning(newOperator(committee, dkshare, log), chain.ACSResendPeriod),
+		eventACSMsgCh: make(chan *chain.ACSMsg),
+	}
+	var err error
+	ret.acs, err = acs.New(acs.Config{
+		Index:        ret.peerIndex(),
+		N:            ret.size(),
+		T:            ret.quorum(),
+		Signer:       dkshare,
+		Network:      ret,
+		ResendPeriod: chain.ACSResendPeriod,
+		Log:          ret.log.Named("acs"),
+	})
+	if err != nil {
+		ret.log.Panicf("NewACSOperator: %v", err)
+	}
+	go ret.recvLoop()
+	return ret
+}
+
+func (op *acsOperator) recvLoop() {
+	for {
+		select {
+		case msg, ok := <-op.eventStateTransitionMsgCh:
+			if ok {
+				op.eventStateTransitionMsg(msg)
+			}
+		case msg, ok := <-op.eventBalancesMsgCh:
+			if ok {
+				op.eventBalancesMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventRequestMsgCh:
+			if ok {
+				op.eventRequestMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventResultCalculatedCh:
+			if ok {
+				op.eventResultCalculated(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventSignedHashMsgCh:
+			if ok {
+				op.eventSignedHashMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventTransactionInclusionLevelMsgCh:
+			if ok {
+				op.eventTransactionInclusionLevelMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventACSMsgCh:
+			if ok {
+				op.eventACSMsg(msg)
+			}
+		case msg, ok := <-op.eventTimerMsgCh:
+			if ok {
+				op.eventTimerMsg(msg)
+			}
+		case <-op.closeCh:
+			return
+		}
+	}
+}
+
+// eventStateTransitionMsg starts the agreement on the batch for the new state
+func (op *acsOperator) eventStateTransitionMsg(msg *chain.StateTransitionMsg) {
+	op.setNewSCState(msg.AnchorTransaction, msg.VariableState, msg.Synchronized)
+	op.resetBatch()
+
+	vh := op.currentState.Hash()
+	op.log.Infof("STATE FOR ACS CONSENSUS #%d, synced: %v, tx: %s, state hash: %s, backlog: %d",
+		op.mustStateIndex(), msg.Synchronized, op.stateTx.ID().String(), vh.String(), len(op.requests))
+
+	// remove all processed requests from the local backlog
+	if err := op.deleteCompletedRequests(); err != nil {
+		op.log.Errorf("deleteCompletedRequests: %v", err)
+		return
+	}
+	switch {
+	case !op.controlsChainOutput():
+		// the chain was moved to another committee or is not moved yet to this committee
+		op.log.Infof("chain is controlled by address %s, not by the committee", op.stateTx.MustProperties().MustChainAddress().String())
+		op.stopAgreement()
+	case msg.Synchronized:
+		// the epoch is kept if the agreement for the state is already running
+		stateIndex := op.mustStateIndex()
+		epoch := uint32(0)
+		if op.acs.Height() == stateIndex {
+			epoch = op.acs.Epoch()
+		}
+		op.startAgreement(stateIndex, epoch)
+	default:
+		op.stopAgreement()
+	}
+	op.takeAction()
+}
+
+// EventNotifyReqMsg is ignored, the requests known to the nodes are proposed in the agreement
+func (op *acsOperator) EventNotifyReqMsg(_ *chain.NotifyReqMsg) {
+}
+
+// EventACSMsg message of the ACS protocol received from the peer
+func (op *acsOperator) EventACSMsg(msg *chain.ACSMsg) {
+	op.eventACSMsgCh <- msg
+}
+
+func (op *acsOperator) eventACSMsg(msg *chain.ACSMsg) {
+	op.acs.ReceiveMessage(msg.SenderIndex, msg.Data)
+	op.takeAction()
+}
+
+func (op *acsOperator) eventTimerMsg(msg chain.TimerTick) {
+	if msg%40 == 0 {
+		blockIndex, ok := op.blockIndex()
+		si := int32(-1)
+		if ok {
+			si = int32(blockIndex)
+		}
+		op.log.Infow("timer tick",
+			"#", msg,
+			"block index", si,
+			"req backlog", len(op.requests),
+			"epoch", op.acs.Epoch(),
+			"proposed", op.acs.Proposed(),
+			"agreed", op.acs.Output() != nil,
+			"batch", op.batch != nil,
+		)
+	}
+	op.takeAction()
+}
+
+// takeAction is called from timer ticks and when messages are received
+func (op *acsOperator) takeAction() {
+	op.solidifyRequestArgsIfNeeded()
+	op.proposeBatch()
+	op.acs.Tick(time.Now())
+	op.startCalculationsOfSelectedBatch()
+	op.resendSignedHash()
+	op.checkQuorumOfSignatures()
+	op.pullInclusionLevel()
+}
+
+// seed of the agreement for the state. The order of requests in the batch and the common coin are seeded by it
+func (op *acsOperator) seed() hashing.HashValue {
+	return hashing.HashValue(op.stateTx.ID())
+}
+
+func (op *acsOperator) startAgreement(stateIndex, epoch uint32) {
+	op.running = true
+	op.nextEpoch = time.Now().Add(chain.ACSEpochPeriod)
+	op.acs.Start(stateIndex, epoch, op.seed(), time.Now())
+}
+
+func (op *acsOperator) stopAgreement() {
+	op.running = false
+	op.acs.Stop()
+}
+
+// proposeBatch proposes the requests ready to be processed. The node which knows no requests proposes
+// the empty batch as soon as other nodes take part in the agreement, so the agreement can terminate
+func (op *acsOperator) proposeBatch() {
+	stateIndex, ok := op.blockIndex()
+	if !ok || !op.running || op.acs.Height() != stateIndex || op.acs.Proposed() || op.balances == nil {
+		return
+	}
+	reqs := op.requestCandidateList()
+	if len(reqs) == 0 && !op.acs.Activated() {
+		return
+	}
+	// timestamp must be max(local clock, prev timestamp+1)
+	ts := time.Now().UnixNano()
+	if prevTs := op.stateTx.MustState().Timestamp(); ts <= prevTs {
+		ts = prevTs + 1
+	}
+	op.log.Debugw("proposeBatch",
+		"state index", stateIndex,
+		"epoch", op.acs.Epoch(),
+		"reqs", idsShortStr(takeIds(reqs)),
+	)
+	op.acs.Propose(encodeBatchProposal(&chain.StartProcessingBatchMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: stateIndex,
+		},
+		Timestamp:      ts,
+		RequestIds:     takeIds(reqs),
+		FeeDestination: op.getFeeDestination(),
+		Balances:       op.balances,
+		ArgsRejected:   argsRejectedIds(reqs),
+	}))
+}
+
+// startCalculationsOfSelectedBatch runs the VM for the batch selected from the agreed proposals.
+// The requests of the batch may still be unknown to the node, then it fetches them and waits.
+// If no request is selected, the agreement is repeated in the next epoch
+func (op *acsOperator) startCalculationsOfSelectedBatch() {
+	output := op.acs.Output()
+	if output == nil || op.batch != nil || op.currentState == nil || op.acs.Height() != op.mustStateIndex() {
+		return
+	}
+	msg, leader := selectACSBatch(output, op.size()-op.quorum(), op.mustStateIndex(), op.stateTx.ID(),
+		op.stateTx.MustState().Timestamp())
+	if msg == nil {
+		if time.Now().Before(op.nextEpoch) {
+			return
+		}
+		op.log.Debugf("no requests agreed for state #%d in epoch %d, starting the next epoch", op.mustStateIndex(), op.acs.Epoch())
+		op.startAgreement(op.mustStateIndex(), op.acs.Epoch()+1)
+		return
+	}
+	msg.FeeDestination = op.getFeeDestination()
+	reqs, err := op.collectProcessableBatch(msg.RequestIds, msg.ArgsRejected)
+	if err != nil {
+		op.log.Debugf("agreed batch can't be processed yet: %v", err)
+		op.fetchUnknownRequests(msg.RequestIds)
+		return
+	}
+	op.startCalculationsOfAgreedBatch(leader, msg, reqs)
+	op.log.Infof("BATCH AGREED for state #%d in epoch %d, proposals: %d, leader: %d, batch hash: %s, reqs: %+v",
+		msg.BlockIndex, op.acs.Epoch(), len(output), leader, op.batch.batchHash.String(), idsShortStr(msg.RequestIds))
+}
+
+// fetchUnknownRequests requests the transactions of the requests unknown to the node from the goshimmer node.
+// The requests of the confirmed transactions are dispatched to the chain as if they were received from the address
+func (op *acsOperator) fetchUnknownRequests(reqIds []coretypes.RequestID) {
+	if time.Now().Before(op.nextFetch) {
+		return
+	}
+	op.nextFetch = time.Now().Add(chain.FetchRequestsPeriod)
+	fetched := make(map[valuetransaction.ID]bool)
+	for _, id := range reqIds {
+		req, ok := op.requestFromId(id)
+		if !ok || req == nil || req.hasMessage() || fetched[*id.TransactionID()] {
+			continue
+		}
+		fetched[*id.TransactionID()] = true
+		op.log.Debugf("fetching unknown request %s", id.Short())
+		if err := nodeconn.RequestConfirmedTransactionFromNode(id.TransactionID()); err != nil {
+			op.log.Debugf("fetching request %s: %v", id.Short(), err)
+		}
+	}
+}
+
+// SendMsg sends the message of the ACS protocol to the peer. Implements acs.Network
+func (op *acsOperator) SendMsg(peerIndex uint16, data []byte) {
+	if err := op.chain.SendMsg(peerIndex, chain.MsgACS, op.acsMsgData(data)); err != nil {
+		op.log.Debugf("sending ACS message to #%d: %v", peerIndex, err)
+	}
+}
+
+// Broadcast sends the message of the ACS protocol to all peers of the committee. Implements acs.Network
+func (op *acsOperator) Broadcast(data []byte) {
+	op.chain.SendMsgToCommitteePeers(chain.MsgACS, op.acsMsgData(data), time.Now().UnixNano())
+}
+
+func (op *acsOperator) acsMsgData(data []byte) []byte {
+	return util.MustBytes(&chain.ACSMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: op.acs.Height(),
+		},
+		Data: data,
+	})
+}
+
+// selectACSBatch selects the batch from the proposals agreed by the committee, where at most f nodes are faulty.
+// The selection only depends on the proposals and the state, so all correct nodes select the same batch:
+//   - the proposals which are not for the state are ignored
+//   - the request is selected if more than f proposals contain it, so at least one correct node knows the request
+//     and faulty nodes can't make the committee fetch requests which don't exist. The request known to more than f
+//     correct nodes is not censored. The nodes which don't know the request fetch it before they process the batch.
+//     Requests are ordered by their hash seeded by the state transaction
+//   - the arguments of the request are rejected if more than f nodes rejected them. The node checks the rejection
+//     itself before it processes the batch
+//   - the timestamp is the median of the proposed timestamps, but after the timestamp of the state
+//   - all balances are taken from the same proposal. The balances proposed by most nodes are taken,
+//     if more than f nodes proposed them. Otherwise no batch is selected
+//   - the leader of the batch is the first node which proposed the balances
+//
+// The fee destination is not selected. Returns nil if no request is selected
+func selectACSBatch(proposals map[uint16][]byte, f uint16, blockIndex uint32, stateTxID valuetransaction.ID, prevTimestamp int64) (*chain.StartProcessingBatchMsg, uint16) {
+	proposers := make([]uint16, 0, len(proposals))
+	for i := range proposals {
+		proposers = append(proposers, i)
+	}
+	sort.Slice(proposers, func(i, j int) bool {
+		return proposers[i] < proposers[j]
+	})
+	ret := &chain.StartProcessingBatchMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: blockIndex,
+		},
+		RequestIds:   make([]coretypes.RequestID, 0),
+		ArgsRejected: make([]coretypes.RequestID, 0),
+	}
+	proposedCount := make(map[coretypes.RequestID]uint16)
+	rejectedCount := make(map[coretypes.RequestID]uint16)
+	timestamps := make([]int64, 0, len(proposals))
+	balancesCount := make(map[string]uint16)
+	balancesLeader := make(map[string]uint16)
+	balancesByKey := make(map[string]map[valuetransaction.ID][]*balance.Balance)
+	for _, i := range proposers {
+		msg, err := decodeBatchProposal(proposals[i])
+		if err != nil || msg.BlockIndex != blockIndex {
+			continue
+		}
+		for _, id := range uniqueIds(msg.RequestIds) {
+			proposedCount[id]++
+			if proposedCount[id] == f+1 {
+				ret.RequestIds = append(ret.RequestIds, id)
+			}
+		}
+		for _, id := range uniqueIds(msg.ArgsRejected) {
+			rejectedCount[id]++
+		}
+		timestamps = append(timestamps, msg.Timestamp)
+		key := balancesMapKey(msg.Balances)
+		if _, ok := balancesCount[key]; !ok {
+			balancesLeader[key] = i
+			balancesByKey[key] = msg.Balances
+		}
+		balancesCount[key]++
+	}
+	if len(ret.RequestIds) == 0 {
+		return nil, 0
+	}
+	// the most frequent balances are taken, the smallest key on the tie
+	bestKey := ""
+	bestCount := uint16(0)
+	for key, count := range balancesCount {
+		if count > bestCount || count == bestCount && key < bestKey {
+			bestKey, bestCount = key, count
+		}
+	}
+	if bestCount <= f {
+		return nil, 0
+	}
+	ret.Balances = balancesByKey[bestKey]
+	sort.Slice(ret.RequestIds, func(i, j int) bool {
+		hi := hashing.HashData(stateTxID[:], ret.RequestIds[i][:])
+		hj := hashing.HashData(stateTxID[:], ret.RequestIds[j][:])
+		return bytes.Compare(hi[:], hj[:]) < 0
+	})
+	for _, id := range ret.RequestIds {
+		if rejectedCount[id] > f {
+			ret.ArgsRejected = append(ret.ArgsRejected, id)
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	ret.Timestamp = timestamps[len(timestamps)/2]
+	if ret.Timestamp <= prevTimestamp {
+		ret.Timestamp = prevTimestamp + 1
+	}
+	return ret, balancesLeader[bestKey]
+}
+
+func uniqueIds(ids []coretypes.RequestID) []coretypes.RequestID {
+	seen := make(map[coretypes.RequestID]bool)
+	ret := make([]coretypes.RequestID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+// balancesMapKey identifies the balances of all outputs
+func balancesMapKey(bals map[valuetransaction.ID][]*balance.Balance) string {
+	txids := make([]valuetransaction.ID, 0, len(bals))
+	for txid := range bals {
+		txids = append(txids, txid)
+	}
+	sort.Slice(txids, func(i, j int) bool {
+		return bytes.Compare(txids[i][:], txids[j][:]) < 0
+	})
+	var buf bytes.Buffer
+	for _, txid := range txids {
+		buf.Write(txid[:])
+		_ = util.WriteString16(&buf, balancesKey(bals[txid]))
+	}
+	return buf.String()
+}
+
+// balancesKey identifies the list of balances
+func balancesKey(bals []*balance.Balance) string {
+	var buf bytes.Buffer
+	for _, b := range bals {
+		buf.Write(b.Color[:])
+		_ = util.WriteInt64(&buf, b.Value)
+	}
+	return buf.String()
+}
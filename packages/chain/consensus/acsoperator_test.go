@@ -0,0 +1,150 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package consensus
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectACSBatch(t *testing.T) {
+	stateTxID := valuetransaction.RandomID()
+	reqs := make([]coretypes.RequestID, 4)
+	for i := range reqs {
+		reqs[i] = coretypes.NewRequestID(valuetransaction.RandomID(), uint16(i))
+	}
+	outputID := valuetransaction.RandomID()
+	bals := []*balance.Balance{balance.New(balance.ColorIOTA, 100)}
+	fakeBals := []*balance.Balance{balance.New(balance.ColorIOTA, 1000)}
+	proposal := func(blockIndex uint32, ts int64, bals []*balance.Balance, argsRejected []coretypes.RequestID, ids ...coretypes.RequestID) []byte {
+		return encodeBatchProposal(&chain.StartProcessingBatchMsg{
+			PeerMsgHeader: chain.PeerMsgHeader{
+				BlockIndex: blockIndex,
+			},
+			Timestamp:      ts,
+			RequestIds:     ids,
+			FeeDestination: coretypes.NewRandomAgentID(),
+			Balances:       map[valuetransaction.ID][]*balance.Balance{outputID: bals},
+			ArgsRejected:   argsRejected,
+		})
+	}
+	// 7 nodes, 2 of them faulty
+	proposals := map[uint16][]byte{
+		1: []byte("garbage"),
+		2: proposal(5, 20, bals, reqs[:1], reqs[0], reqs[1]),
+		3: proposal(4, 30, fakeBals, reqs[:1], reqs[0], reqs[2], reqs[3], reqs[3]),
+		4: proposal(5, 10, bals, reqs[:1], reqs[0], reqs[2], reqs[1]),
+		5: proposal(5, 40, fakeBals, reqs[:1], reqs[0], reqs[3], reqs[3]),
+		6: proposal(5, 1000, bals, nil, reqs[1], reqs[2]),
+	}
+	msg, leader := selectACSBatch(proposals, 2, 5, stateTxID, 0)
+	require.NotNil(t, msg)
+	require.EqualValues(t, 2, leader)
+	require.EqualValues(t, 5, msg.BlockIndex)
+	require.EqualValues(t, 40, msg.Timestamp)
+	// reqs[2] and reqs[3] are proposed by 2 nodes only, reqs[3] twice by the same node
+	require.ElementsMatch(t, reqs[:2], msg.RequestIds)
+	require.EqualValues(t, reqs[:1], msg.ArgsRejected)
+	require.True(t, equalBalances(map[valuetransaction.ID][]*balance.Balance{outputID: bals}, msg.Balances))
+
+	// the order of requests is seeded by the state transaction, not by the order of the proposals
+	again, _ := selectACSBatch(proposals, 2, 5, stateTxID, 0)
+	require.EqualValues(t, msg.RequestIds, again.RequestIds)
+
+	// the timestamp is after the timestamp of the state
+	msg, _ = selectACSBatch(proposals, 2, 5, stateTxID, 1000)
+	require.EqualValues(t, 1001, msg.Timestamp)
+
+	// requests are selected if proposed by more than f nodes
+	msg, leader = selectACSBatch(map[uint16][]byte{
+		0: proposal(5, 10, bals, nil, reqs[1]),
+		1: proposal(5, 10, fakeBals, nil, reqs[0]),
+		2: proposal(5, 10, bals, nil, reqs[0], reqs[1]),
+		3: proposal(5, 10, bals, nil, reqs[0]),
+		4: proposal(5, 10, bals, nil),
+	}, 2, 5, stateTxID, 0)
+	require.NotNil(t, msg)
+	require.EqualValues(t, 0, leader)
+	require.EqualValues(t, reqs[:1], msg.RequestIds)
+
+	// no request is proposed
+	msg, _ = selectACSBatch(map[uint16][]byte{
+		0: proposal(5, 10, bals, nil),
+		1: proposal(5, 10, bals, nil),
+		2: proposal(5, 10, bals, nil),
+	}, 1, 5, stateTxID, 0)
+	require.Nil(t, msg)
+
+	// balances of all outputs are taken from the same proposals, not combined from different ones
+	outputID2 := valuetransaction.RandomID()
+	outputs := func(bals1, bals2 []*balance.Balance) map[valuetransaction.ID][]*balance.Balance {
+		return map[valuetransaction.ID][]*balance.Balance{outputID: bals1, outputID2: bals2}
+	}
+	proposalOutputs := func(bals map[valuetransaction.ID][]*balance.Balance) []byte {
+		return encodeBatchProposal(&chain.StartProcessingBatchMsg{
+			PeerMsgHeader: chain.PeerMsgHeader{
+				BlockIndex: 5,
+			},
+			Timestamp:  10,
+			RequestIds: reqs[:1],
+			Balances:   bals,
+		})
+	}
+	proposals = map[uint16][]byte{
+		0: proposalOutputs(outputs(fakeBals, bals)),
+		1: proposalOutputs(outputs(bals, fakeBals)),
+		2: proposalOutputs(outputs(bals, bals)),
+	}
+	// none of the balances is proposed by more than f nodes
+	msg, _ = selectACSBatch(proposals, 1, 5, stateTxID, 0)
+	require.Nil(t, msg)
+
+	proposals[3] = proposalOutputs(outputs(fakeBals, bals))
+	msg, leader = selectACSBatch(proposals, 1, 5, stateTxID, 0)
+	require.NotNil(t, msg)
+	require.EqualValues(t, 0, leader)
+	require.True(t, equalBalances(outputs(fakeBals, bals), msg.Balances))
+}
+
+func TestSelectACSBatchByzantineProposer(t *testing.T) {
+	stateTxID := valuetransaction.RandomID()
+	bals := map[valuetransaction.ID][]*balance.Balance{
+		valuetransaction.RandomID(): {balance.New(balance.ColorIOTA, 100)},
+	}
+	known := coretypes.NewRequestID(valuetransaction.RandomID(), 0)
+	proposal := func(ids ...coretypes.RequestID) []byte {
+		return encodeBatchProposal(&chain.StartProcessingBatchMsg{
+			PeerMsgHeader: chain.PeerMsgHeader{
+				BlockIndex: 5,
+			},
+			Timestamp:  10,
+			RequestIds: ids,
+			Balances:   bals,
+		})
+	}
+	// the faulty node of 4 proposes many requests which don't exist, some of them many times
+	unknown := make([]coretypes.RequestID, 1000)
+	for i := range unknown {
+		unknown[i] = coretypes.NewRequestID(valuetransaction.RandomID(), uint16(i))
+	}
+	proposals := map[uint16][]byte{
+		0: proposal(known),
+		1: proposal(append(unknown, unknown...)...),
+		2: proposal(known),
+		3: proposal(),
+	}
+	msg, _ := selectACSBatch(proposals, 1, 5, stateTxID, 0)
+	require.NotNil(t, msg)
+	require.EqualValues(t, []coretypes.RequestID{known}, msg.RequestIds)
+
+	// without the requests of correct nodes nothing is selected, so nothing is fetched
+	delete(proposals, 0)
+	msg, _ = selectACSBatch(proposals, 1, 5, stateTxID, 0)
+	require.Nil(t, msg)
+}
@@ -126,6 +126,7 @@ func (op *operator) startCalculationsAsLeader() {
 		FeeDestination: rewardAddress,
 		Balances:       op.balances,
 		RequestIds:     reqIds,
+		ArgsRejected:   markArgsRejected(reqs),
 	})
 
 	// determine timestamp. Must be max(local clock, prev timestamp+1).
@@ -286,3 +287,9 @@ func (op *operator) setNewSCState(stateTx *sctransaction.Transaction, variableSt
 	op.resetLeader(stateTx.ID().Bytes())
 	op.adjustNotifications()
 }
+
+// controlsChainOutput returns true if the chain token is held by the address of the committee.
+// It is not the case before the chain is moved to the committee and after it is moved to the next one
+func (op *operator) controlsChainOutput() bool {
+	return *op.stateTx.MustProperties().MustChainAddress() == op.chain.Address()
+}
@@ -0,0 +1,345 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/goshimmer/dapps/waspconn/packages/waspconn"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/txutil"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/plugins/nodeconn"
+)
+
+// batchSigning is the part of the consensus operators which agree on the batch by a protocol of the whole committee
+// (see bftOperator and acsOperator). When the batch is agreed, all nodes run it and exchange the signature
+// shares of the result. Each node posts the transaction as soon as it has a quorum of the shares,
+// so a faulty node can't withhold the result.
+// Event handlers of batchSigning don't take actions, the operator takes them after each event
+type batchSigning struct {
+	*operator
+
+	// batch agreed for the current state. Nil if it is not agreed yet
+	batch *agreedBatch
+	// signature shares of the result received from peers, by peer index
+	signedHashes []*chain.SignedHashMsg
+	resendPeriod time.Duration
+}
+
+type agreedBatch struct {
+	blockIndex uint32
+	leader     uint16
+	timestamp  int64
+	batchHash  hashing.HashValue
+	// known when the calculations are finished
+	resultTx    *sctransaction.Transaction
+	essenceHash hashing.HashValue
+	// own signature share of the result, repeated to peers until the state changes
+	signedHashData []byte
+	nextResend     time.Time
+	finalized      bool
+}
+
+func newBatchSigning(op *operator, resendPeriod time.Duration) batchSigning {
+	return batchSigning{
+		operator:     op,
+		signedHashes: make([]*chain.SignedHashMsg, op.size()),
+		resendPeriod: resendPeriod,
+	}
+}
+
+// resetBatch forgets the batch agreed for the previous state
+func (op *batchSigning) resetBatch() {
+	if op.batch != nil && op.batch.blockIndex != op.mustStateIndex() {
+		op.batch = nil
+	}
+}
+
+// startCalculationsOfAgreedBatch runs the VM for the batch agreed for the current state
+func (op *batchSigning) startCalculationsOfAgreedBatch(leader uint16, msg *chain.StartProcessingBatchMsg, reqs []*request) {
+	op.batch = &agreedBatch{
+		blockIndex: msg.BlockIndex,
+		leader:     leader,
+		timestamp:  msg.Timestamp,
+		batchHash:  vm.BatchHash(msg.RequestIds, msg.Timestamp, leader),
+	}
+	op.runCalculationsAsync(runCalculationsParams{
+		requests:        reqs,
+		leaderPeerIndex: leader,
+		balances:        msg.Balances,
+		timestamp:       msg.Timestamp,
+		accrueFeesTo:    msg.FeeDestination,
+	})
+}
+
+func (op *batchSigning) eventBalancesMsg(msg chain.BalancesMsg) {
+	op.log.Debugf("EventBalancesMsg: balances arrived\n%s", txutil.BalancesToString(msg.Balances))
+	op.balances = msg.Balances
+}
+
+func (op *batchSigning) eventRequestMsg(reqMsg *chain.RequestMsg) {
+	op.log.Debugw("EventRequestMsg",
+		"reqid", reqMsg.RequestId().Short(),
+		"backlog req", len(op.requests),
+		"backlog notif", len(op.notificationsBacklog),
+		"free tokens attached", reqMsg.FreeTokens != nil,
+	)
+	if req, _ := op.requestFromMsg(reqMsg); req == nil {
+		op.log.Warnf("received already processed request id = %s", reqMsg.RequestId().Short())
+	}
+}
+
+// EventStartProcessingBatchMsg is ignored, the batch is agreed by the committee
+func (op *batchSigning) EventStartProcessingBatchMsg(_ *chain.StartProcessingBatchMsg) {
+}
+
+// eventResultCalculated signs the result of the agreed batch and sends the signature share to all peers
+func (op *batchSigning) eventResultCalculated(ctx *chain.VMResultMsg) {
+	if op.batch == nil || op.batch.resultTx != nil || ctx.Task.ResultBlock.StateIndex() != op.mustStateIndex()+1 {
+		// out of context. ignore
+		return
+	}
+	op.log.Debugw("eventResultCalculated",
+		"batch size", ctx.Task.ResultBlock.Size(),
+		"blockIndex", op.mustStateIndex(),
+	)
+
+	// inform own state manager about new result block. The state manager will start waiting
+	// from confirmation of it from the tangle
+	go func() {
+		op.chain.ReceiveMessage(chain.PendingBlockMsg{
+			Block: ctx.Task.ResultBlock,
+		})
+	}()
+
+	sigShare, err := op.dkshare.SignShare(ctx.Task.ResultTransaction.EssenceBytes())
+	if err != nil {
+		op.log.Errorf("error while signing transaction %v", err)
+		return
+	}
+	op.batch.resultTx = ctx.Task.ResultTransaction
+	op.batch.essenceHash = hashing.HashData(ctx.Task.ResultTransaction.EssenceBytes())
+	signedHash := &chain.SignedHashMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex:  op.batch.blockIndex,
+			SenderIndex: op.peerIndex(),
+		},
+		BatchHash:     op.batch.batchHash,
+		OrigTimestamp: op.batch.timestamp,
+		EssenceHash:   op.batch.essenceHash,
+		SigShare:      sigShare,
+	}
+	op.signedHashes[op.peerIndex()] = signedHash
+	op.batch.signedHashData = util.MustBytes(signedHash)
+
+	op.log.Debugw("sending signed result to peers",
+		"batchHash", op.batch.batchHash.String(),
+		"essenceHash", op.batch.essenceHash.String(),
+		"ts", op.batch.timestamp,
+	)
+	op.sendSignedHash()
+}
+
+// eventSignedHashMsg stores the signature share of the result of the peer
+func (op *batchSigning) eventSignedHashMsg(msg *chain.SignedHashMsg) {
+	op.log.Debugw("EventSignedHashMsg",
+		"sender", msg.SenderIndex,
+		"batch hash", msg.BatchHash.String(),
+		"essence hash", msg.EssenceHash.String(),
+		"ts", msg.OrigTimestamp,
+	)
+	if stateIndex, ok := op.blockIndex(); !ok || msg.BlockIndex != stateIndex || msg.SenderIndex >= op.size() {
+		// out of context
+		return
+	}
+	if prev := op.signedHashes[msg.SenderIndex]; prev != nil && prev.BlockIndex == msg.BlockIndex {
+		// repeated message
+		return
+	}
+	op.signedHashes[msg.SenderIndex] = msg
+}
+
+// EventNotifyFinalResultPostedMsg is ignored, each node posts the result itself
+func (op *batchSigning) EventNotifyFinalResultPostedMsg(_ *chain.NotifyFinalResultPostedMsg) {
+}
+
+func (op *batchSigning) eventTransactionInclusionLevelMsg(msg *chain.TransactionInclusionLevelMsg) {
+	op.log.Debugw("EventTransactionInclusionLevelMsg",
+		"txid", msg.TxId.String(),
+		"level", waspconn.InclusionLevelText(msg.Level),
+	)
+	if op.postedResultTxid == nil || *op.postedResultTxid != *msg.TxId {
+		return
+	}
+	switch msg.Level {
+	case waspconn.TransactionInclusionLevelBooked:
+		op.setNextPullInclusionStageDeadline()
+	case waspconn.TransactionInclusionLevelRejected:
+		op.log.Warnf("received 'rejected' for transaction %s", op.postedResultTxid.String())
+	}
+}
+
+func (op *batchSigning) sendSignedHash() {
+	op.chain.SendMsgToCommitteePeers(chain.MsgSignedHash, op.batch.signedHashData, time.Now().UnixNano())
+	op.batch.nextResend = time.Now().Add(op.resendPeriod)
+}
+
+// resendSignedHash repeats the own signature share until the state changes, so peers which missed it
+// can finalize the transaction too
+func (op *batchSigning) resendSignedHash() {
+	if op.batch == nil || op.batch.signedHashData == nil || time.Now().Before(op.batch.nextResend) {
+		return
+	}
+	op.sendSignedHash()
+}
+
+// checkQuorumOfSignatures finalizes the result transaction with the signature of the committee
+// and posts it as soon as a quorum of signature shares is collected
+func (op *batchSigning) checkQuorumOfSignatures() {
+	if op.batch == nil || op.batch.resultTx == nil || op.batch.finalized {
+		return
+	}
+	essence := op.batch.resultTx.EssenceBytes()
+	sigShares := make([][]byte, 0, op.size())
+	contributingPeers := make([]uint16, 0, op.size())
+	for i, msg := range op.signedHashes {
+		if msg == nil || msg.BlockIndex != op.batch.blockIndex {
+			continue
+		}
+		if msg.BatchHash != op.batch.batchHash || msg.EssenceHash != op.batch.essenceHash {
+			op.log.Warnf("wrong batch or essence hash from peer #%d", i)
+			op.signedHashes[i] = nil
+			continue
+		}
+		// the signature share must be of the peer itself, VerifySigShare does not check it
+		if idx, err := msg.SigShare.Index(); err != nil || idx != i {
+			op.log.Warnf("wrong signature share index from peer #%d", i)
+			op.signedHashes[i] = nil
+			continue
+		}
+		if err := op.dkshare.VerifySigShare(essence, msg.SigShare); err != nil {
+			op.log.Warnf("wrong signature from peer #%d: %v", i, err)
+			op.signedHashes[i] = nil
+			continue
+		}
+		sigShares = append(sigShares, msg.SigShare)
+		contributingPeers = append(contributingPeers, uint16(i))
+	}
+	if len(sigShares) < int(op.quorum()) {
+		return
+	}
+	finalSignature, err := op.dkshare.RecoverFullSignature(sigShares, essence)
+	if err != nil {
+		op.log.Errorf("RecoverFullSignature: %v", err)
+		return
+	}
+	if err := op.batch.resultTx.PutSignature(finalSignature); err != nil {
+		op.log.Errorf("something wrong while aggregating final signature: %v", err)
+		return
+	}
+	if _, err := op.batch.resultTx.Properties(); err != nil {
+		op.log.Panicf("internal error: invalid tx properties: %v\ndump tx: %s\ndump vtx: %s\n", err,
+			op.batch.resultTx.String(), op.batch.resultTx.Transaction.String())
+		return
+	}
+	txid := op.batch.resultTx.ID()
+	sh := op.batch.resultTx.MustState().StateHash()
+	op.log.Infof("FINALIZED RESULT. txid: %s, state index: #%d, state hash: %s, contributors: %+v",
+		txid.String(), op.batch.resultTx.MustState().BlockIndex(), sh.String(), contributingPeers)
+	op.batch.finalized = true
+
+	addr := op.chain.Address()
+	if err := nodeconn.PostTransactionToNode(op.batch.resultTx.Transaction, &addr, op.chain.OwnPeerIndex()); err != nil {
+		op.log.Warnf("PostTransactionToNode failed: %v", err)
+		return
+	}
+	op.log.Debugf("result transaction has been posted to node. txid: %s", txid.String())
+	op.setFinalizedTransaction(&txid)
+}
+
+// argsRejectedIds returns the ids of requests which arguments were not solidified before the deadline
+func argsRejectedIds(reqs []*request) []coretypes.RequestID {
+	ret := make([]coretypes.RequestID, 0)
+	for _, req := range reqs {
+		if !req.hasSolidArgs() {
+			ret = append(ret, req.reqId)
+		}
+	}
+	return ret
+}
+
+// encodeBatchProposal serializes the batch with the timestamp, which is not a part of the message
+func encodeBatchProposal(msg *chain.StartProcessingBatchMsg) []byte {
+	var buf bytes.Buffer
+	if err := util.WriteUint64(&buf, uint64(msg.Timestamp)); err != nil {
+		panic(err)
+	}
+	if err := msg.Write(&buf); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func decodeBatchProposal(data []byte) (*chain.StartProcessingBatchMsg, error) {
+	rdr := bytes.NewReader(data)
+	var ts uint64
+	if err := util.ReadUint64(rdr, &ts); err != nil {
+		return nil, err
+	}
+	ret := &chain.StartProcessingBatchMsg{}
+	if err := ret.Read(rdr); err != nil {
+		return nil, err
+	}
+	if rdr.Len() != 0 {
+		return nil, fmt.Errorf("unexpected %d bytes after the batch", rdr.Len())
+	}
+	ret.Timestamp = int64(ts)
+	return ret, nil
+}
+
+func equalIds(ids1, ids2 []coretypes.RequestID) bool {
+	if len(ids1) != len(ids2) {
+		return false
+	}
+	for i := range ids1 {
+		if ids1[i] != ids2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalBalances(bals1, bals2 map[valuetransaction.ID][]*balance.Balance) bool {
+	if len(bals1) != len(bals2) {
+		return false
+	}
+	for txid, b1 := range bals1 {
+		b2, ok := bals2[txid]
+		if !ok || !equalBalanceList(b1, b2) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalBalanceList(b1, b2 []*balance.Balance) bool {
+	if len(b1) != len(b2) {
+		return false
+	}
+	for i := range b1 {
+		if b1[i].Color != b2[i].Color || b1[i].Value != b2[i].Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bft implements a PBFT style byzantine fault tolerant agreement of the committee
+// on one value per height, e.g. the batch of requests for the next block of the chain.
+//
+// The agreement proceeds in views. The leader of the view is taken from the permutation
+// of the committee seeded by the seed of the height, so all replicas agree on the leaders.
+// The leader proposes the value, replicas vote to prepare it and, when a quorum prepared it,
+// vote to commit it. The value is decided when a quorum of replicas committed it.
+//
+// Votes are signature shares of the committee key (see tcrypto.DKShare). A quorum of the votes
+// is recovered into the threshold signature of the committee, which serves as the certificate:
+// the prepared certificate proves that the value was prepared in the view, the commit certificate
+// proves the decision to the replicas which missed the votes.
+//
+// When the view times out, the replica moves to the next view and sends the view change message
+// with the value it has prepared in the highest view, if any. The leader of the new view proposes
+// the value together with a quorum of view changes (the new view certificate). If any of them
+// carries a prepared value, the value prepared in the highest view must be proposed, so a value
+// which could have been decided in an earlier view is never replaced. A replica which sees that
+// enough replicas moved to higher views joins them without waiting for its own timeout.
+// The timeout is doubled with every view change.
+//
+// The replicas repeat their last messages periodically, so the protocol tolerates message loss.
+// The agreement is safe if at most 2T-N-1 replicas are byzantine, and it makes progress if at
+// most N-T replicas are faulty, where N is the size of the committee and T is the quorum.
+package bft
@@ -0,0 +1,269 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package bft
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// Types of the messages exchanged by the replicas.
+const (
+	msgTypeProposal = byte(iota)
+	msgTypePrepare
+	msgTypeCommit
+	msgTypeViewChange
+	msgTypeDecided
+)
+
+type message interface {
+	msgType() byte
+	Write(w io.Writer) error
+	Read(r io.Reader) error
+}
+
+// proposalMsg is sent by the leader of the view. Proposals of the views after the first one
+// are justified by a quorum of view change messages (the new view certificate).
+type proposalMsg struct {
+	view        uint32
+	value       []byte
+	viewChanges []*viewChangeMsg
+}
+
+// voteMsg is a prepare or a commit vote for the value proposed in the view.
+// The vote is a signature share of the committee key.
+type voteMsg struct {
+	kind     byte
+	view     uint32
+	hash     hashing.HashValue
+	sigShare tbdn.SigShare
+}
+
+// preparedCert proves the value was prepared in the view by a quorum of replicas.
+// The signature is the threshold signature recovered from the prepare votes.
+type preparedCert struct {
+	view      uint32
+	value     []byte
+	signature []byte
+}
+
+// viewChangeMsg is sent by a replica which moves to the view. It carries the value the replica
+// has prepared in the highest view, if any. The signature share covers the view and the prepared value.
+type viewChangeMsg struct {
+	view     uint32
+	prepared *preparedCert
+	sigShare tbdn.SigShare
+}
+
+// decidedMsg is sent by a replica which collected a quorum of commit votes. The signature is the
+// threshold signature recovered from the commit votes, so the message is a proof of the decision.
+type decidedMsg struct {
+	view      uint32
+	value     []byte
+	signature []byte
+}
+
+func (m *proposalMsg) msgType() byte {
+	return msgTypeProposal
+}
+
+func (m *voteMsg) msgType() byte {
+	return m.kind
+}
+
+func (m *viewChangeMsg) msgType() byte {
+	return msgTypeViewChange
+}
+
+func (m *decidedMsg) msgType() byte {
+	return msgTypeDecided
+}
+
+// encodeMessage puts the message into the envelope, which consists of the message type and
+// the height of the agreement the message belongs to.
+func encodeMessage(height uint32, msg message) []byte {
+	var buf bytes.Buffer
+	_ = util.WriteByte(&buf, msg.msgType())
+	_ = util.WriteUint32(&buf, height)
+	_ = msg.Write(&buf)
+	return buf.Bytes()
+}
+
+func decodeMessage(data []byte) (uint32, message, error) {
+	r := bytes.NewReader(data)
+	msgType, err := util.ReadByte(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var height uint32
+	if err := util.ReadUint32(r, &height); err != nil {
+		return 0, nil, err
+	}
+	var msg message
+	switch msgType {
+	case msgTypeProposal:
+		msg = &proposalMsg{}
+	case msgTypePrepare, msgTypeCommit:
+		msg = &voteMsg{kind: msgType}
+	case msgTypeViewChange:
+		msg = &viewChangeMsg{}
+	case msgTypeDecided:
+		msg = &decidedMsg{}
+	default:
+		return 0, nil, fmt.Errorf("unknown message type %d", msgType)
+	}
+	if err := msg.Read(r); err != nil {
+		return 0, nil, err
+	}
+	if r.Len() != 0 {
+		return 0, nil, fmt.Errorf("%d unexpected bytes after the message", r.Len())
+	}
+	return height, msg, nil
+}
+
+func (m *proposalMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, m.view); err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, m.value); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, uint16(len(m.viewChanges))); err != nil {
+		return err
+	}
+	for _, vc := range m.viewChanges {
+		if err := vc.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *proposalMsg) Read(r io.Reader) error {
+	var err error
+	if err = util.ReadUint32(r, &m.view); err != nil {
+		return err
+	}
+	if m.value, err = util.ReadBytes32(r); err != nil {
+		return err
+	}
+	var num uint16
+	if err = util.ReadUint16(r, &num); err != nil {
+		return err
+	}
+	m.viewChanges = make([]*viewChangeMsg, num)
+	for i := range m.viewChanges {
+		m.viewChanges[i] = &viewChangeMsg{}
+		if err = m.viewChanges[i].Read(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *voteMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, m.view); err != nil {
+		return err
+	}
+	if _, err := w.Write(m.hash[:]); err != nil {
+		return err
+	}
+	return util.WriteBytes16(w, m.sigShare)
+}
+
+func (m *voteMsg) Read(r io.Reader) error {
+	var err error
+	if err = util.ReadUint32(r, &m.view); err != nil {
+		return err
+	}
+	if err = util.ReadHashValue(r, &m.hash); err != nil {
+		return err
+	}
+	m.sigShare, err = util.ReadBytes16(r)
+	return err
+}
+
+func (c *preparedCert) hash() hashing.HashValue {
+	if c == nil {
+		return hashing.NilHash
+	}
+	var buf bytes.Buffer
+	_ = util.WriteUint32(&buf, c.view)
+	h := hashing.HashData(c.value)
+	buf.Write(h[:])
+	return hashing.HashData(buf.Bytes())
+}
+
+func (m *viewChangeMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, m.view); err != nil {
+		return err
+	}
+	if err := util.WriteBoolByte(w, m.prepared != nil); err != nil {
+		return err
+	}
+	if m.prepared != nil {
+		if err := util.WriteUint32(w, m.prepared.view); err != nil {
+			return err
+		}
+		if err := util.WriteBytes32(w, m.prepared.value); err != nil {
+			return err
+		}
+		if err := util.WriteBytes16(w, m.prepared.signature); err != nil {
+			return err
+		}
+	}
+	return util.WriteBytes16(w, m.sigShare)
+}
+
+func (m *viewChangeMsg) Read(r io.Reader) error {
+	var err error
+	if err = util.ReadUint32(r, &m.view); err != nil {
+		return err
+	}
+	var prepared bool
+	if err = util.ReadBoolByte(r, &prepared); err != nil {
+		return err
+	}
+	if prepared {
+		m.prepared = &preparedCert{}
+		if err = util.ReadUint32(r, &m.prepared.view); err != nil {
+			return err
+		}
+		if m.prepared.value, err = util.ReadBytes32(r); err != nil {
+			return err
+		}
+		if m.prepared.signature, err = util.ReadBytes16(r); err != nil {
+			return err
+		}
+	}
+	m.sigShare, err = util.ReadBytes16(r)
+	return err
+}
+
+func (m *decidedMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, m.view); err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, m.value); err != nil {
+		return err
+	}
+	return util.WriteBytes16(w, m.signature)
+}
+
+func (m *decidedMsg) Read(r io.Reader) error {
+	var err error
+	if err = util.ReadUint32(r, &m.view); err != nil {
+		return err
+	}
+	if m.value, err = util.ReadBytes32(r); err != nil {
+		return err
+	}
+	m.signature, err = util.ReadBytes16(r)
+	return err
+}
@@ -0,0 +1,727 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package bft
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	// messages are accepted for at most that many views ahead of the current view
+	maxViewsAhead = 32
+	// maximum number of messages of the next heights kept until the agreement for the height is started
+	maxFutureMessages = 1000
+	// the view timeout is doubled with every view change, but not more than that many times
+	maxTimeoutDoublings = 6
+)
+
+// Signer is the threshold signature scheme of the committee. It is implemented by tcrypto.DKShare
+type Signer interface {
+	SignShare(data []byte) (tbdn.SigShare, error)
+	VerifySigShare(data []byte, sigshare tbdn.SigShare) error
+	RecoverFullSignature(sigShares [][]byte, data []byte) (signaturescheme.Signature, error)
+	VerifyMasterSignature(data []byte, signature []byte) error
+}
+
+// Network delivers the messages of the replica to the other replicas of the committee
+type Network interface {
+	// SendMsg sends the message to the replica with the index
+	SendMsg(peerIndex uint16, data []byte)
+	// Broadcast sends the message to all the other replicas
+	Broadcast(data []byte)
+}
+
+// Application provides and checks the values the replicas agree on
+type Application interface {
+	// Propose returns the value the replica proposes when it is the leader of the view.
+	// Nil means there is nothing to agree on yet. The replica also calls it to find out if there is anything
+	// to agree on, so it must not change the state of the application
+	Propose() []byte
+	// Validate checks if the value proposed by the leader can be accepted. The replica does not vote for the value
+	// until it is valid. Values prepared by a quorum of replicas in an earlier view are not validated again
+	Validate(value []byte) error
+}
+
+// Config contains the parameters of the replica
+type Config struct {
+	Index       uint16 // index of the replica in the committee
+	N           uint16 // size of the committee
+	T           uint16 // quorum of the committee, i.e. the threshold of the committee key
+	Signer      Signer
+	Network     Network
+	Application Application
+	// ViewTimeout is the time the replica waits for the decision in the first view. It is doubled with every view change
+	ViewTimeout time.Duration
+	// ResendPeriod is the period the last messages of the replica are repeated with to overcome message loss
+	ResendPeriod time.Duration
+	Log          *logger.Logger
+}
+
+// Decision is the value the committee agreed on for the height
+type Decision struct {
+	Height uint32
+	View   uint32
+	// Leader is the index of the replica which proposed the value
+	Leader uint16
+	Value  []byte
+}
+
+// Replica is the state of one committee node in the agreement on the value for each height.
+// The replica is not thread safe, all calls are expected from the same goroutine
+type Replica struct {
+	cfg Config
+	log *logger.Logger
+
+	started     bool
+	height      uint32
+	seed        hashing.HashValue
+	leaders     []uint16
+	view        uint32
+	viewStarted time.Time
+	// the view timer runs only when there is something to agree on
+	active     bool
+	nextResend time.Time
+
+	proposals     map[uint32]*proposal
+	votes         map[voteKey]map[uint16]tbdn.SigShare
+	voters        map[voterKey]bool
+	viewChanges   map[uint32]map[uint16]*viewChangeMsg
+	ownPrepare    *voteMsg
+	ownCommit     *voteMsg
+	ownViewChange *viewChangeMsg
+	// the value prepared in the highest view
+	prepared *preparedCert
+	decided  *decidedMsg
+	decision *Decision
+
+	// messages of the next heights
+	future []*receivedMsg
+	// the decision of the previous height is sent to the replicas which are still agreeing on it
+	prevHeight  uint32
+	prevDecided []byte
+}
+
+type proposal struct {
+	*proposalMsg
+	hash hashing.HashValue
+	// the value was prepared in an earlier view, so it is not validated again
+	locked   bool
+	rejected bool
+}
+
+type voteKey struct {
+	kind byte
+	view uint32
+	hash hashing.HashValue
+}
+
+type voterKey struct {
+	kind   byte
+	view   uint32
+	sender uint16
+}
+
+type receivedMsg struct {
+	sender uint16
+	data   []byte
+}
+
+// New creates the replica. The agreement is started for each height by Start
+func New(cfg Config) *Replica {
+	return &Replica{
+		cfg: cfg,
+		log: cfg.Log,
+	}
+}
+
+// Start starts the agreement on the value for the height. The seed must be the same for all replicas,
+// it determines the order of the leaders of the views. Messages of the earlier heights are dropped,
+// messages of the later heights are kept until the agreement for their height is started
+func (r *Replica) Start(height uint32, seed hashing.HashValue, now time.Time) {
+	if r.started && r.height == height && r.seed == seed {
+		return
+	}
+	if r.started && r.decided != nil {
+		r.prevHeight = r.height
+		r.prevDecided = encodeMessage(r.height, r.decided)
+	}
+	r.started = true
+	r.height = height
+	r.seed = seed
+	r.leaders = util.NewPermutation16(r.cfg.N, seed[:]).GetArray()
+	r.view = 0
+	r.viewStarted = now
+	r.active = false
+	r.nextResend = now.Add(r.cfg.ResendPeriod)
+	r.proposals = make(map[uint32]*proposal)
+	r.votes = make(map[voteKey]map[uint16]tbdn.SigShare)
+	r.voters = make(map[voterKey]bool)
+	r.viewChanges = make(map[uint32]map[uint16]*viewChangeMsg)
+	r.ownPrepare = nil
+	r.ownCommit = nil
+	r.ownViewChange = nil
+	r.prepared = nil
+	r.decided = nil
+	r.decision = nil
+
+	r.log.Debugf("agreement started for height #%d, leaders: %+v", height, r.leaders)
+
+	future := r.future
+	r.future = nil
+	for _, msg := range future {
+		r.receive(msg.sender, msg.data, now)
+	}
+	r.act(now)
+}
+
+// Stop stops the agreement. Messages are kept until the agreement is started again
+func (r *Replica) Stop() {
+	r.started = false
+}
+
+// Height returns the height of the agreement
+func (r *Replica) Height() uint32 {
+	return r.height
+}
+
+// View returns the current view of the replica
+func (r *Replica) View() uint32 {
+	return r.view
+}
+
+// Leader returns the index of the leader of the current view
+func (r *Replica) Leader() uint16 {
+	return r.leader(r.view)
+}
+
+// Decision returns the value decided for the current height, nil if the value is not decided yet
+func (r *Replica) Decision() *Decision {
+	return r.decision
+}
+
+// ReceiveMessage processes the message from another replica
+func (r *Replica) ReceiveMessage(sender uint16, data []byte, now time.Time) {
+	r.receive(sender, data, now)
+	r.act(now)
+}
+
+// Tick changes the view when the current one times out and repeats the last messages of the replica
+func (r *Replica) Tick(now time.Time) {
+	if !r.started {
+		return
+	}
+	if r.decided == nil && r.active && now.After(r.viewDeadline()) {
+		r.log.Infof("view #%d of height #%d timed out, leader was #%d", r.view, r.height, r.Leader())
+		r.startViewChange(r.view+1, now)
+	}
+	r.act(now)
+	if now.After(r.nextResend) {
+		r.resend()
+		r.nextResend = now.Add(r.cfg.ResendPeriod)
+	}
+}
+
+func (r *Replica) leader(view uint32) uint16 {
+	return r.leaders[view%uint32(len(r.leaders))]
+}
+
+// maxFaulty is the number of the replicas which may fail without affecting the liveness
+func (r *Replica) maxFaulty() uint16 {
+	return r.cfg.N - r.cfg.T
+}
+
+func (r *Replica) viewDeadline() time.Time {
+	doublings := r.view
+	if doublings > maxTimeoutDoublings {
+		doublings = maxTimeoutDoublings
+	}
+	return r.viewStarted.Add(r.cfg.ViewTimeout << doublings)
+}
+
+func (r *Replica) activate(now time.Time) {
+	if r.active {
+		return
+	}
+	r.active = true
+	r.viewStarted = now
+}
+
+func (r *Replica) receive(sender uint16, data []byte, now time.Time) {
+	if sender >= r.cfg.N || sender == r.cfg.Index {
+		return
+	}
+	height, msg, err := decodeMessage(data)
+	if err != nil {
+		r.log.Warnf("wrong message from #%d: %v", sender, err)
+		return
+	}
+	switch {
+	case !r.started || height > r.height:
+		if (!r.started && height >= r.height || height > r.height) && len(r.future) < maxFutureMessages {
+			r.future = append(r.future, &receivedMsg{sender: sender, data: data})
+		}
+		return
+	case height < r.height:
+		if height == r.prevHeight && r.prevDecided != nil && msg.msgType() != msgTypeDecided {
+			// the sender is still agreeing on the previous height
+			r.cfg.Network.SendMsg(sender, r.prevDecided)
+		}
+		return
+	}
+	if r.decided != nil {
+		return
+	}
+	switch msg := msg.(type) {
+	case *proposalMsg:
+		r.receiveProposal(sender, msg, now)
+	case *voteMsg:
+		r.receiveVote(sender, msg, now)
+	case *viewChangeMsg:
+		r.receiveViewChange(sender, msg, now)
+	case *decidedMsg:
+		r.receiveDecided(sender, msg)
+	}
+}
+
+func (r *Replica) receiveProposal(sender uint16, msg *proposalMsg, now time.Time) {
+	if msg.view < r.view || msg.view > r.view+maxViewsAhead || len(msg.value) == 0 {
+		return
+	}
+	if sender != r.leader(msg.view) {
+		r.log.Warnf("proposal for view #%d from #%d, which is not the leader of the view", msg.view, sender)
+		return
+	}
+	hash := hashing.HashData(msg.value)
+	if p, ok := r.proposals[msg.view]; ok {
+		if p.hash != hash {
+			r.log.Warnf("conflicting proposals from the leader #%d of view #%d", sender, msg.view)
+		}
+		return
+	}
+	locked := false
+	if msg.view > 0 {
+		var err error
+		if locked, err = r.checkNewView(msg, hash); err != nil {
+			r.log.Warnf("invalid proposal for view #%d from #%d: %v", msg.view, sender, err)
+			return
+		}
+	}
+	r.proposals[msg.view] = &proposal{
+		proposalMsg: msg,
+		hash:        hash,
+		locked:      locked,
+	}
+	r.activate(now)
+	if msg.view > r.view {
+		// the proposal is justified by a quorum of view changes
+		r.enterView(msg.view, now)
+	}
+}
+
+// checkNewView checks the new view certificate of the proposal: it must consist of a quorum of valid view changes
+// to the view of the proposal and, if any of them carries a prepared value, the value prepared in the highest view
+// must be proposed. Returns true in the latter case
+func (r *Replica) checkNewView(msg *proposalMsg, hash hashing.HashValue) (bool, error) {
+	senders := make(map[uint16]bool)
+	var highest *preparedCert
+	for _, vc := range msg.viewChanges {
+		if vc.view != msg.view {
+			return false, fmt.Errorf("view change to view #%d in the certificate", vc.view)
+		}
+		sender, err := r.checkViewChange(vc)
+		if err != nil {
+			return false, err
+		}
+		if senders[sender] {
+			return false, fmt.Errorf("duplicate view change from #%d in the certificate", sender)
+		}
+		senders[sender] = true
+		if vc.prepared != nil && (highest == nil || vc.prepared.view > highest.view) {
+			highest = vc.prepared
+		}
+	}
+	if len(senders) < int(r.cfg.T) {
+		return false, fmt.Errorf("certificate contains %d view changes, quorum is %d", len(senders), r.cfg.T)
+	}
+	if highest == nil {
+		return false, nil
+	}
+	if hashing.HashData(highest.value) != hash {
+		return false, fmt.Errorf("the value prepared in view #%d is not proposed", highest.view)
+	}
+	return true, nil
+}
+
+// checkViewChange verifies the signature share of the view change and the certificate of the prepared value.
+// Returns the index of the signer
+func (r *Replica) checkViewChange(vc *viewChangeMsg) (uint16, error) {
+	idx, err := vc.sigShare.Index()
+	if err != nil {
+		return 0, err
+	}
+	if idx < 0 || idx >= int(r.cfg.N) {
+		return 0, fmt.Errorf("wrong signature share index %d", idx)
+	}
+	if vc.view == 0 {
+		return 0, fmt.Errorf("view change to view #0")
+	}
+	if err := r.cfg.Signer.VerifySigShare(r.dataToSign(msgTypeViewChange, vc.view, vc.prepared.hash()), vc.sigShare); err != nil {
+		return 0, fmt.Errorf("wrong signature of view change from #%d: %v", idx, err)
+	}
+	if vc.prepared != nil {
+		if vc.prepared.view >= vc.view {
+			return 0, fmt.Errorf("value prepared in view #%d is carried to view #%d", vc.prepared.view, vc.view)
+		}
+		data := r.dataToSign(msgTypePrepare, vc.prepared.view, hashing.HashData(vc.prepared.value))
+		if err := r.cfg.Signer.VerifyMasterSignature(data, vc.prepared.signature); err != nil {
+			return 0, fmt.Errorf("wrong certificate of the value prepared in view #%d: %v", vc.prepared.view, err)
+		}
+	}
+	return uint16(idx), nil
+}
+
+func (r *Replica) receiveVote(sender uint16, msg *voteMsg, now time.Time) {
+	if msg.view > r.view+maxViewsAhead {
+		return
+	}
+	voter := voterKey{kind: msg.kind, view: msg.view, sender: sender}
+	if r.voters[voter] {
+		// repeated or conflicting vote
+		return
+	}
+	idx, err := msg.sigShare.Index()
+	if err != nil || idx != int(sender) {
+		r.log.Warnf("vote from #%d is signed by #%d", sender, idx)
+		return
+	}
+	if err := r.cfg.Signer.VerifySigShare(r.dataToSign(msg.kind, msg.view, msg.hash), msg.sigShare); err != nil {
+		r.log.Warnf("wrong signature of the vote from #%d: %v", sender, err)
+		return
+	}
+	r.addVote(sender, msg)
+	r.activate(now)
+}
+
+func (r *Replica) addVote(sender uint16, msg *voteMsg) {
+	r.voters[voterKey{kind: msg.kind, view: msg.view, sender: sender}] = true
+	key := voteKey{kind: msg.kind, view: msg.view, hash: msg.hash}
+	if _, ok := r.votes[key]; !ok {
+		r.votes[key] = make(map[uint16]tbdn.SigShare)
+	}
+	r.votes[key][sender] = msg.sigShare
+}
+
+func (r *Replica) receiveViewChange(sender uint16, msg *viewChangeMsg, now time.Time) {
+	if msg.view < r.view || msg.view > r.view+maxViewsAhead {
+		return
+	}
+	if _, ok := r.viewChanges[msg.view][sender]; ok {
+		return
+	}
+	idx, err := r.checkViewChange(msg)
+	if err != nil {
+		r.log.Warnf("invalid view change from #%d: %v", sender, err)
+		return
+	}
+	if idx != sender {
+		r.log.Warnf("view change from #%d is signed by #%d", sender, idx)
+		return
+	}
+	r.addViewChange(sender, msg)
+	r.activate(now)
+	r.joinViewChange(now)
+}
+
+func (r *Replica) addViewChange(sender uint16, msg *viewChangeMsg) {
+	if _, ok := r.viewChanges[msg.view]; !ok {
+		r.viewChanges[msg.view] = make(map[uint16]*viewChangeMsg)
+	}
+	r.viewChanges[msg.view][sender] = msg
+}
+
+// joinViewChange moves the replica to a higher view if enough replicas moved there, so at least one of them is correct.
+// It prevents the replica from waiting for the timeout of the view the rest of the committee has already left
+func (r *Replica) joinViewChange(now time.Time) {
+	highest := make(map[uint16]uint32)
+	for view, vcs := range r.viewChanges {
+		if view <= r.view {
+			continue
+		}
+		for sender := range vcs {
+			if view > highest[sender] {
+				highest[sender] = view
+			}
+		}
+	}
+	if len(highest) < int(r.maxFaulty())+1 {
+		return
+	}
+	views := make([]uint32, 0, len(highest))
+	for _, view := range highest {
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		return views[i] > views[j]
+	})
+	r.log.Infof("joining view change of %d replicas", len(highest))
+	r.startViewChange(views[r.maxFaulty()], now)
+}
+
+func (r *Replica) receiveDecided(sender uint16, msg *decidedMsg) {
+	data := r.dataToSign(msgTypeCommit, msg.view, hashing.HashData(msg.value))
+	if err := r.cfg.Signer.VerifyMasterSignature(data, msg.signature); err != nil {
+		r.log.Warnf("wrong certificate of the decision from #%d: %v", sender, err)
+		return
+	}
+	r.setDecided(msg)
+}
+
+func (r *Replica) enterView(view uint32, now time.Time) {
+	r.view = view
+	r.viewStarted = now
+	r.ownPrepare = nil
+	r.ownCommit = nil
+	r.log.Infof("entered view #%d of height #%d, leader: #%d, I am the leader: %v",
+		view, r.height, r.Leader(), r.Leader() == r.cfg.Index)
+}
+
+// startViewChange moves the replica to the view and sends the view change message to other replicas
+func (r *Replica) startViewChange(view uint32, now time.Time) {
+	r.enterView(view, now)
+	sigShare, err := r.cfg.Signer.SignShare(r.dataToSign(msgTypeViewChange, view, r.prepared.hash()))
+	if err != nil {
+		r.log.Errorf("failed to sign view change: %v", err)
+		return
+	}
+	r.ownViewChange = &viewChangeMsg{
+		view:     view,
+		prepared: r.prepared,
+		sigShare: sigShare,
+	}
+	r.addViewChange(r.cfg.Index, r.ownViewChange)
+	r.broadcast(r.ownViewChange)
+}
+
+func (r *Replica) act(now time.Time) {
+	if !r.started || r.decided != nil {
+		return
+	}
+	if !r.active && r.cfg.Application.Propose() != nil {
+		r.activate(now)
+	}
+	r.propose()
+	r.prepare()
+	r.commit()
+	r.decide()
+}
+
+// propose sends the proposal of the view if the replica is the leader of the view.
+// In the views after the first one the leader waits for a quorum of view changes and proposes
+// the value prepared in the highest view, if any
+func (r *Replica) propose() {
+	if r.leader(r.view) != r.cfg.Index {
+		return
+	}
+	if _, ok := r.proposals[r.view]; ok {
+		return
+	}
+	msg := &proposalMsg{view: r.view}
+	locked := false
+	if r.view > 0 {
+		vcs := r.viewChanges[r.view]
+		if len(vcs) < int(r.cfg.T) {
+			return
+		}
+		senders := make([]uint16, 0, len(vcs))
+		for sender := range vcs {
+			senders = append(senders, sender)
+		}
+		sort.Slice(senders, func(i, j int) bool {
+			return senders[i] < senders[j]
+		})
+		var highest *preparedCert
+		for _, sender := range senders[:r.cfg.T] {
+			vc := vcs[sender]
+			msg.viewChanges = append(msg.viewChanges, vc)
+			if vc.prepared != nil && (highest == nil || vc.prepared.view > highest.view) {
+				highest = vc.prepared
+			}
+		}
+		if highest != nil {
+			msg.value = highest.value
+			locked = true
+		}
+	}
+	if msg.value == nil {
+		if msg.value = r.cfg.Application.Propose(); msg.value == nil {
+			return
+		}
+	}
+	r.proposals[r.view] = &proposal{
+		proposalMsg: msg,
+		hash:        hashing.HashData(msg.value),
+		locked:      locked,
+	}
+	r.log.Infof("proposing value %s in view #%d of height #%d, prepared earlier: %v",
+		r.proposals[r.view].hash.String(), r.view, r.height, locked)
+	r.broadcast(msg)
+}
+
+// prepare votes for the proposal of the current view once the value is valid
+func (r *Replica) prepare() {
+	p, ok := r.proposals[r.view]
+	if !ok || r.ownPrepare != nil {
+		return
+	}
+	if !p.locked {
+		if err := r.cfg.Application.Validate(p.value); err != nil {
+			if !p.rejected {
+				r.log.Debugf("proposal of view #%d is not accepted: %v", r.view, err)
+				p.rejected = true
+			}
+			return
+		}
+	}
+	r.ownPrepare = r.vote(msgTypePrepare, p.hash)
+}
+
+// commit votes for the commit of the proposal when a quorum of replicas prepared it
+func (r *Replica) commit() {
+	p, ok := r.proposals[r.view]
+	if !ok || r.ownPrepare == nil || r.ownCommit != nil {
+		return
+	}
+	data := r.dataToSign(msgTypePrepare, r.view, p.hash)
+	signature, ok := r.recoverSignature(r.votes[voteKey{kind: msgTypePrepare, view: r.view, hash: p.hash}], data)
+	if !ok {
+		return
+	}
+	r.prepared = &preparedCert{
+		view:      r.view,
+		value:     p.value,
+		signature: signature,
+	}
+	r.ownCommit = r.vote(msgTypeCommit, p.hash)
+}
+
+// decide decides on the value when a quorum of replicas committed it in any view
+func (r *Replica) decide() {
+	for key, sigShares := range r.votes {
+		if key.kind != msgTypeCommit || len(sigShares) < int(r.cfg.T) {
+			continue
+		}
+		p, ok := r.proposals[key.view]
+		if !ok || p.hash != key.hash {
+			continue
+		}
+		signature, ok := r.recoverSignature(sigShares, r.dataToSign(msgTypeCommit, key.view, key.hash))
+		if !ok {
+			continue
+		}
+		r.setDecided(&decidedMsg{
+			view:      key.view,
+			value:     p.value,
+			signature: signature,
+		})
+		r.broadcast(r.decided)
+		return
+	}
+}
+
+func (r *Replica) setDecided(msg *decidedMsg) {
+	r.decided = msg
+	r.decision = &Decision{
+		Height: r.height,
+		View:   msg.view,
+		Leader: r.leader(msg.view),
+		Value:  msg.value,
+	}
+	r.log.Infof("DECIDED value %s for height #%d in view #%d, leader: #%d",
+		hashing.HashData(msg.value).String(), r.height, msg.view, r.decision.Leader)
+}
+
+func (r *Replica) vote(kind byte, hash hashing.HashValue) *voteMsg {
+	sigShare, err := r.cfg.Signer.SignShare(r.dataToSign(kind, r.view, hash))
+	if err != nil {
+		r.log.Errorf("failed to sign the vote: %v", err)
+		return nil
+	}
+	msg := &voteMsg{
+		kind:     kind,
+		view:     r.view,
+		hash:     hash,
+		sigShare: sigShare,
+	}
+	r.addVote(r.cfg.Index, msg)
+	r.broadcast(msg)
+	return msg
+}
+
+// recoverSignature recovers the threshold signature from a quorum of the signature shares
+func (r *Replica) recoverSignature(sigShares map[uint16]tbdn.SigShare, data []byte) ([]byte, bool) {
+	if len(sigShares) < int(r.cfg.T) {
+		return nil, false
+	}
+	shares := make([][]byte, 0, len(sigShares))
+	for _, sigShare := range sigShares {
+		shares = append(shares, sigShare)
+	}
+	signature, err := r.cfg.Signer.RecoverFullSignature(shares, data)
+	if err != nil {
+		r.log.Errorf("failed to recover the signature: %v", err)
+		return nil, false
+	}
+	// the raw BLS signature is the tail of its representation in the value tangle
+	ret := signature.Bytes()
+	return ret[len(ret)-signaturescheme.BLSSignatureSize:], true
+}
+
+// resend repeats the last messages of the replica in the current view
+func (r *Replica) resend() {
+	if r.decided != nil {
+		r.broadcast(r.decided)
+		return
+	}
+	if !r.active {
+		return
+	}
+	p, proposed := r.proposals[r.view]
+	if proposed && r.leader(r.view) == r.cfg.Index {
+		r.broadcast(p.proposalMsg)
+	}
+	if !proposed && r.ownViewChange != nil && r.ownViewChange.view == r.view {
+		r.broadcast(r.ownViewChange)
+	}
+	if r.ownPrepare != nil {
+		r.broadcast(r.ownPrepare)
+	}
+	if r.ownCommit != nil {
+		r.broadcast(r.ownCommit)
+	}
+}
+
+func (r *Replica) broadcast(msg message) {
+	r.cfg.Network.Broadcast(encodeMessage(r.height, msg))
+}
+
+// dataToSign binds the vote to the agreement instance identified by the height and the seed
+func (r *Replica) dataToSign(kind byte, view uint32, hash hashing.HashValue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("bft")
+	_ = util.WriteByte(&buf, kind)
+	_ = util.WriteUint32(&buf, r.height)
+	buf.Write(r.seed[:])
+	_ = util.WriteUint32(&buf, view)
+	buf.Write(hash[:])
+	return buf.Bytes()
+}
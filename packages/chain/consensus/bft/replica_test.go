@@ -0,0 +1,373 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package bft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testViewTimeout  = 500 * time.Millisecond
+	testResendPeriod = 50 * time.Millisecond
+	testTickPeriod   = 10 * time.Millisecond
+	testTimeout      = 60 * time.Second
+)
+
+type testCommittee struct {
+	*testutil.Committee
+	decisions chan *nodeDecision
+}
+
+// testNode runs the replica of a correct node. It moves to the next height when the value is decided,
+// the seed of the next height is the hash of the decided value
+type testNode struct {
+	committee *testCommittee
+	index     uint16
+	replica   *Replica
+	seed      hashing.HashValue
+	heights   uint32
+	decided   uint32 // number of the heights decided
+}
+
+type nodeDecision struct {
+	*Decision
+	index uint16
+}
+
+// testApp proposes the value identifying the node and rejects values starting with "invalid"
+type testApp struct {
+	index   uint16
+	replica *Replica
+}
+
+func newTestCommittee(t *testing.T, n, quorum uint16, behavior testutil.PeeringNetBehavior) *testCommittee {
+	return &testCommittee{
+		Committee: testutil.NewCommittee(t, n, quorum, behavior),
+		decisions: make(chan *nodeDecision, 1000),
+	}
+}
+
+func (c *testCommittee) config(index uint16, app Application) Config {
+	return Config{
+		Index:        index,
+		N:            c.N,
+		T:            c.DKShares[index].T,
+		Signer:       c.DKShares[index],
+		Network:      c.Network(index),
+		Application:  app,
+		ViewTimeout:  testViewTimeout,
+		ResendPeriod: testResendPeriod,
+		Log:          c.Log.Named(fmt.Sprintf("#%d", index)),
+	}
+}
+
+// startNodes starts the replicas of the correct nodes, which agree on the values of the number of heights
+func (c *testCommittee) startNodes(correct []uint16, seed hashing.HashValue, heights uint32) {
+	for _, index := range correct {
+		app := &testApp{index: index}
+		app.replica = New(c.config(index, app))
+		c.AttachNode(index, &testNode{
+			committee: c,
+			index:     index,
+			replica:   app.replica,
+			seed:      seed,
+			heights:   heights,
+		})
+	}
+	c.StartNodes(testTickPeriod)
+}
+
+func (c *testCommittee) leaders(seed hashing.HashValue) []uint16 {
+	return util.NewPermutation16(c.N, seed[:]).GetArray()
+}
+
+// byzantineNode is a node which does not run the replica, the replica is only used to sign the messages crafted by the test
+func (c *testCommittee) byzantineNode(index uint16, seed hashing.HashValue) *Replica {
+	return &Replica{
+		cfg:     c.config(index, &testApp{index: index}),
+		seed:    seed,
+		leaders: c.leaders(seed),
+	}
+}
+
+func (c *testCommittee) sendTo(from uint16, height uint32, msg message, to ...uint16) {
+	c.SendTo(from, encodeMessage(height, msg), to...)
+}
+
+// waitDecisions waits for the decisions of all correct nodes on all heights and checks they agree
+func (c *testCommittee) waitDecisions(correct []uint16, heights uint32) [][]*Decision {
+	ret := make([][]*Decision, heights)
+	for i := range ret {
+		ret[i] = make([]*Decision, c.N)
+	}
+	timeout := time.After(testTimeout)
+	for count := 0; count < len(correct)*int(heights); count++ {
+		select {
+		case d := <-c.decisions:
+			require.Less(c.T, d.Height, heights)
+			require.Nil(c.T, ret[d.Height][d.index], "node #%d decided twice at height #%d", d.index, d.Height)
+			for _, other := range ret[d.Height] {
+				if other != nil {
+					require.EqualValues(c.T, other.Value, d.Value, "decisions differ at height #%d", d.Height)
+					require.EqualValues(c.T, other.Leader, d.Leader)
+				}
+			}
+			ret[d.Height][d.index] = d.Decision
+		case <-timeout:
+			c.T.Fatalf("only %d decisions of %d were made", count, len(correct)*int(heights))
+		}
+	}
+	return ret
+}
+
+func (n *testNode) Start(now time.Time) {
+	n.replica.Start(0, n.seed, now)
+}
+
+func (n *testNode) ReceiveMessage(sender uint16, data []byte, now time.Time) {
+	n.replica.ReceiveMessage(sender, data, now)
+}
+
+func (n *testNode) Tick(now time.Time) {
+	n.replica.Tick(now)
+}
+
+func (n *testNode) Progress() {
+	if d := n.replica.Decision(); d != nil && d.Height == n.decided {
+		n.committee.decisions <- &nodeDecision{Decision: d, index: n.index}
+		n.decided++
+		if n.decided < n.heights {
+			n.replica.Start(n.decided, hashing.HashData(d.Value), time.Now())
+		}
+	}
+}
+
+func (a *testApp) Propose() []byte {
+	return proposedValue(a.index, a.replica.Height())
+}
+
+func (a *testApp) Validate(value []byte) error {
+	if bytes.HasPrefix(value, []byte("invalid")) {
+		return errors.New("invalid value")
+	}
+	return nil
+}
+
+func proposedValue(index uint16, height uint32) []byte {
+	return []byte(fmt.Sprintf("value of #%d at height #%d", index, height))
+}
+
+// checkDecisions checks the correct nodes decided the values proposed by the leaders of the decided views
+func checkDecisions(t *testing.T, decisions [][]*Decision) {
+	for height, ds := range decisions {
+		for _, d := range ds {
+			if d != nil {
+				require.EqualValues(t, proposedValue(d.Leader, uint32(height)), d.Value)
+			}
+		}
+	}
+}
+
+func TestMessagesWriteRead(t *testing.T) {
+	msgs := []message{
+		&proposalMsg{view: 1, value: []byte("value"), viewChanges: []*viewChangeMsg{
+			{view: 1, sigShare: []byte{0, 1, 2}},
+			{view: 1, prepared: &preparedCert{view: 0, value: []byte("value"), signature: []byte{3}}, sigShare: []byte{0, 2, 2}},
+		}},
+		&voteMsg{kind: msgTypePrepare, view: 2, hash: hashing.HashStrings("value"), sigShare: []byte{0, 1, 2}},
+		&voteMsg{kind: msgTypeCommit, view: 3, hash: hashing.HashStrings("value"), sigShare: []byte{0, 1, 2}},
+		&viewChangeMsg{view: 4, sigShare: []byte{0, 1, 2}},
+		&decidedMsg{view: 5, value: []byte("value"), signature: []byte{4, 5}},
+	}
+	for _, msg := range msgs {
+		height, back, err := decodeMessage(encodeMessage(42, msg))
+		require.NoError(t, err)
+		require.EqualValues(t, 42, height)
+		require.EqualValues(t, msg, back)
+	}
+	_, _, err := decodeMessage(append(encodeMessage(42, msgs[0]), 0))
+	require.Error(t, err)
+}
+
+func TestReliableNet(t *testing.T) {
+	const heights = 3
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	seed := hashing.HashStrings("seed")
+	c.startNodes(c.Indices(), seed, heights)
+	checkDecisions(t, c.waitDecisions(c.Indices(), heights))
+}
+
+func TestUnreliableNet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	const heights = 3
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetUnreliable(
+		70,                                        // Delivered %
+		20,                                        // Duplicated %
+		10*time.Millisecond, 200*time.Millisecond, // Delays (from, till)
+		testutil.WithLevel(testutil.NewLogger(t), logger.LevelInfo, false),
+	))
+	seed := hashing.HashStrings("seed")
+	c.startNodes(c.Indices(), seed, heights)
+	checkDecisions(t, c.waitDecisions(c.Indices(), heights))
+}
+
+// TestSilentLeader checks the committee changes the view when the leader is crashed or partitioned
+func TestSilentLeader(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	seed := hashing.HashStrings("seed")
+	byzantine := c.leaders(seed)[0]
+	correct := c.Indices(byzantine)
+	c.startNodes(correct, seed, 1)
+	decisions := c.waitDecisions(correct, 1)
+	checkDecisions(t, decisions)
+	for _, d := range decisions[0] {
+		if d != nil {
+			require.NotEqualValues(t, byzantine, d.Leader)
+			require.Greater(t, d.View, uint32(0))
+		}
+	}
+}
+
+// TestEquivocatingLeader checks correct nodes agree when the leader sends different proposals
+// and votes to different nodes
+func TestEquivocatingLeader(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	seed := hashing.HashStrings("seed")
+	leader := c.leaders(seed)[0]
+	byzantine := c.byzantineNode(leader, seed)
+	correct := c.Indices(leader)
+	c.startNodes(correct, seed, 1)
+	values := [][]byte{proposedValue(leader, 0), []byte("another value")}
+	for i, to := range [][]uint16{correct[:2], correct[2:]} {
+		hash := hashing.HashData(values[i])
+		c.sendTo(leader, 0, &proposalMsg{view: 0, value: values[i]}, to...)
+		for _, kind := range []byte{msgTypePrepare, msgTypeCommit} {
+			sigShare, err := c.DKShares[leader].SignShare(byzantine.dataToSign(kind, 0, hash))
+			require.NoError(t, err)
+			c.sendTo(leader, 0, &voteMsg{kind: kind, view: 0, hash: hash, sigShare: sigShare}, to...)
+		}
+	}
+	decisions := c.waitDecisions(correct, 1)
+	for _, d := range decisions[0] {
+		if d != nil && d.Leader == leader {
+			// the value proposed by the byzantine leader to the majority may be decided
+			require.EqualValues(t, values[0], d.Value)
+		}
+	}
+}
+
+// TestInvalidProposal checks the committee changes the view when the leader proposes an invalid value
+func TestInvalidProposal(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	seed := hashing.HashStrings("seed")
+	leader := c.leaders(seed)[0]
+	correct := c.Indices(leader)
+	c.startNodes(correct, seed, 1)
+	c.sendTo(leader, 0, &proposalMsg{view: 0, value: []byte("invalid value")}, correct...)
+	decisions := c.waitDecisions(correct, 1)
+	checkDecisions(t, decisions)
+	for _, d := range decisions[0] {
+		if d != nil {
+			require.NotEqualValues(t, leader, d.Leader)
+		}
+	}
+}
+
+// TestForgedViewChange checks the proposal of the next view is rejected if its new view certificate is forged
+func TestForgedViewChange(t *testing.T) {
+	c := newTestCommittee(t, 4, 3, testutil.NewPeeringNetReliable())
+	seed := hashing.HashStrings("seed")
+	r := c.byzantineNode(c.leaders(seed)[1], seed)
+
+	vcs := make([]*viewChangeMsg, 0)
+	for i := uint16(0); i < c.N; i++ {
+		// the byzantine leader signs the view change for another view
+		sigShare, err := c.DKShares[i].SignShare(r.dataToSign(msgTypeViewChange, 2, hashing.NilHash))
+		require.NoError(t, err)
+		vcs = append(vcs, &viewChangeMsg{view: 1, sigShare: sigShare})
+	}
+	_, err := r.checkNewView(&proposalMsg{view: 1, value: []byte("value"), viewChanges: vcs}, hashing.HashStrings("value"))
+	require.Error(t, err)
+
+	for i := range vcs {
+		vcs[i].sigShare, err = c.DKShares[i].SignShare(r.dataToSign(msgTypeViewChange, 1, hashing.NilHash))
+		require.NoError(t, err)
+	}
+	_, err = r.checkNewView(&proposalMsg{view: 1, value: []byte("value"), viewChanges: vcs[:2]}, hashing.HashStrings("value"))
+	require.Error(t, err)
+	_, err = r.checkNewView(&proposalMsg{view: 1, value: []byte("value"), viewChanges: []*viewChangeMsg{vcs[0], vcs[1], vcs[1]}}, hashing.HashStrings("value"))
+	require.Error(t, err)
+	locked, err := r.checkNewView(&proposalMsg{view: 1, value: []byte("value"), viewChanges: vcs[:3]}, hashing.HashStrings("value"))
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	// the leader must propose the prepared value
+	prepared := &preparedCert{view: 0, value: []byte("prepared")}
+	sigShares := make([][]byte, 0)
+	for i := uint16(0); i < 3; i++ {
+		sigShare, err := c.DKShares[i].SignShare(r.dataToSign(msgTypePrepare, 0, hashing.HashData(prepared.value)))
+		require.NoError(t, err)
+		sigShares = append(sigShares, sigShare)
+	}
+	signature, err := c.DKShares[0].RecoverFullSignature(sigShares, r.dataToSign(msgTypePrepare, 0, hashing.HashData(prepared.value)))
+	require.NoError(t, err)
+	sigBytes := signature.Bytes()
+	prepared.signature = sigBytes[len(sigBytes)-signaturescheme.BLSSignatureSize:]
+	vcs[2].prepared = prepared
+	vcs[2].sigShare, err = c.DKShares[2].SignShare(r.dataToSign(msgTypeViewChange, 1, prepared.hash()))
+	require.NoError(t, err)
+	_, err = r.checkNewView(&proposalMsg{view: 1, value: []byte("value"), viewChanges: vcs[:3]}, hashing.HashStrings("value"))
+	require.Error(t, err)
+	locked, err = r.checkNewView(&proposalMsg{view: 1, value: prepared.value, viewChanges: vcs[:3]}, hashing.HashData(prepared.value))
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	// the prepared value can't be stripped from the view change by the leader
+	vcs[2].prepared = nil
+	_, err = r.checkNewView(&proposalMsg{view: 1, value: []byte("value"), viewChanges: vcs[:3]}, hashing.HashStrings("value"))
+	require.Error(t, err)
+}
+
+// TestByzantineLeaderUnreliableNet combines the equivocating leader with message loss and delays
+// in a committee of 7 nodes, where 2 of them are byzantine
+func TestByzantineLeaderUnreliableNet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+	c := newTestCommittee(t, 7, 5, testutil.NewPeeringNetUnreliable(
+		80,                                        // Delivered %
+		10,                                        // Duplicated %
+		10*time.Millisecond, 100*time.Millisecond, // Delays (from, till)
+		testutil.WithLevel(testutil.NewLogger(t), logger.LevelInfo, false),
+	))
+	seed := hashing.HashStrings("seed")
+	leader := c.leaders(seed)[0]
+	silent := c.leaders(seed)[1]
+	byzantine := c.byzantineNode(leader, seed)
+	correct := c.Indices(leader, silent)
+	c.startNodes(correct, seed, 1)
+	for i, to := range [][]uint16{correct[:2], correct[2:]} {
+		value := []byte(fmt.Sprintf("value %d", i))
+		hash := hashing.HashData(value)
+		c.sendTo(leader, 0, &proposalMsg{view: 0, value: value}, to...)
+		sigShare, err := c.DKShares[leader].SignShare(byzantine.dataToSign(msgTypePrepare, 0, hash))
+		require.NoError(t, err)
+		c.sendTo(leader, 0, &voteMsg{kind: msgTypePrepare, view: 0, hash: hash, sigShare: sigShare}, to...)
+	}
+	decisions := c.waitDecisions(correct, 1)
+	checkDecisions(t, decisions)
+}
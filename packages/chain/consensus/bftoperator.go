@@ -0,0 +1,332 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package consensus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/chain/consensus/bft"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// bftOperator is the consensus operator which agrees on the batch with the BFT protocol (see package bft)
+// instead of following the leader rotated on timeouts.
+// The backlog of requests is shared with the operator. Each node notifies all peers about the requests it knows,
+// so the leader of any view can propose the batch. The decided batch is run and signed by all nodes (see batchSigning)
+type bftOperator struct {
+	batchSigning
+
+	replica *bft.Replica
+
+	// requests the node notified the committee about
+	notifiedBlockIndex uint32
+	notifiedIds        []coretypes.RequestID
+	nextNotification   time.Time
+
+	eventBFTMsgCh chan *chain.BFTMsg
+}
+
+// NewBFTOperator creates the consensus operator which agrees on batches with the BFT protocol
+func NewBFTOperator(committee chain.Chain, dkshare *tcrypto.DKShare, log *logger.Logger) *bftOperator {
+	defer committee.SetReadyConsensus()
+
+	ret := &bftOperator{
+		batchSigning:  newBatchSigning(newOperator(committee, dkshare, log), chain.BFTResendPeriod),
+		eventBFTMsgCh: make(chan *chain.BFTMsg),
+	}
+	ret.replica = bft.New(bft.Config{
+		Index:        ret.peerIndex(),
+		N:            ret.size(),
+		T:            ret.quorum(),
+		Signer:       dkshare,
+		Network:      ret,
+		Application:  ret,
+		ViewTimeout:  chain.BFTViewTimeout,
+		ResendPeriod: chain.BFTResendPeriod,
+		Log:          ret.log.Named("bft"),
+	})
+	go ret.recvLoop()
+	return ret
+}
+
+func (op *bftOperator) recvLoop() {
+	for {
+		select {
+		case msg, ok := <-op.eventStateTransitionMsgCh:
+			if ok {
+				op.eventStateTransitionMsg(msg)
+			}
+		case msg, ok := <-op.eventBalancesMsgCh:
+			if ok {
+				op.eventBalancesMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventRequestMsgCh:
+			if ok {
+				op.eventRequestMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventNotifyReqMsgCh:
+			if ok {
+				op.eventNotifyReqMsg(msg)
+			}
+		case msg, ok := <-op.eventResultCalculatedCh:
+			if ok {
+				op.eventResultCalculated(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventSignedHashMsgCh:
+			if ok {
+				op.eventSignedHashMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventTransactionInclusionLevelMsgCh:
+			if ok {
+				op.eventTransactionInclusionLevelMsg(msg)
+				op.takeAction()
+			}
+		case msg, ok := <-op.eventBFTMsgCh:
+			if ok {
+				op.eventBFTMsg(msg)
+			}
+		case msg, ok := <-op.eventTimerMsgCh:
+			if ok {
+				op.eventTimerMsg(msg)
+			}
+		case <-op.closeCh:
+			return
+		}
+	}
+}
+
+// eventStateTransitionMsg starts the agreement on the batch for the new state
+func (op *bftOperator) eventStateTransitionMsg(msg *chain.StateTransitionMsg) {
+	op.setNewSCState(msg.AnchorTransaction, msg.VariableState, msg.Synchronized)
+	op.resetBatch()
+
+	vh := op.currentState.Hash()
+	op.log.Infof("STATE FOR BFT CONSENSUS #%d, synced: %v, tx: %s, state hash: %s, backlog: %d",
+		op.mustStateIndex(), msg.Synchronized, op.stateTx.ID().String(), vh.String(), len(op.requests))
+
+	// remove all processed requests from the local backlog
+	if err := op.deleteCompletedRequests(); err != nil {
+		op.log.Errorf("deleteCompletedRequests: %v", err)
+		return
+	}
+	switch {
+	case !op.controlsChainOutput():
+		// the chain was moved to another committee or is not moved yet to this committee
+		op.log.Infof("chain is controlled by address %s, not by the committee", op.stateTx.MustProperties().MustChainAddress().String())
+		op.replica.Stop()
+	case msg.Synchronized:
+		// the leaders of the views are seeded by the state transaction, so they are not known in advance
+		op.replica.Start(op.mustStateIndex(), hashing.HashValue(op.stateTx.ID()), time.Now())
+	default:
+		op.replica.Stop()
+	}
+	op.takeAction()
+}
+
+func (op *bftOperator) eventNotifyReqMsg(msg *chain.NotifyReqMsg) {
+	op.log.Debugw("EventNotifyReqMsg",
+		"reqIds", idsShortStr(msg.RequestIDs),
+		"sender", msg.SenderIndex,
+		"stateIdx", msg.BlockIndex,
+	)
+	op.storeNotification(msg)
+	op.markRequestsNotified([]*chain.NotifyReqMsg{msg})
+	op.takeAction()
+}
+
+// EventBFTMsg message of the BFT protocol received from the peer
+func (op *bftOperator) EventBFTMsg(msg *chain.BFTMsg) {
+	op.eventBFTMsgCh <- msg
+}
+
+func (op *bftOperator) eventBFTMsg(msg *chain.BFTMsg) {
+	op.replica.ReceiveMessage(msg.SenderIndex, msg.Data, time.Now())
+	op.takeAction()
+}
+
+func (op *bftOperator) eventTimerMsg(msg chain.TimerTick) {
+	if msg%40 == 0 {
+		blockIndex, ok := op.blockIndex()
+		si := int32(-1)
+		if ok {
+			si = int32(blockIndex)
+		}
+		op.log.Infow("timer tick",
+			"#", msg,
+			"block index", si,
+			"req backlog", len(op.requests),
+			"view", op.replica.View(),
+			"leader", op.replica.Leader(),
+			"decided", op.batch != nil,
+			"notif backlog", len(op.notificationsBacklog),
+		)
+	}
+	op.takeAction()
+}
+
+// takeAction is called from timer ticks and when messages are received
+func (op *bftOperator) takeAction() {
+	op.solidifyRequestArgsIfNeeded()
+	op.notifyCommittee()
+	op.replica.Tick(time.Now())
+	op.startCalculationsOfDecidedBatch()
+	op.resendSignedHash()
+	op.checkQuorumOfSignatures()
+	op.pullInclusionLevel()
+}
+
+// notifyCommittee sends the requests ready to be processed to all peers of the committee, so the leader of any
+// view can propose them. Notifications are sent when the list changes and repeated periodically
+func (op *bftOperator) notifyCommittee() {
+	stateIndex, ok := op.blockIndex()
+	if !ok {
+		return
+	}
+	reqIds := takeIds(op.requestCandidateList())
+	if len(reqIds) == 0 {
+		return
+	}
+	if stateIndex == op.notifiedBlockIndex && equalIds(reqIds, op.notifiedIds) && time.Now().Before(op.nextNotification) {
+		return
+	}
+	msgData := util.MustBytes(&chain.NotifyReqMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: stateIndex,
+		},
+		RequestIDs: reqIds,
+	})
+	op.log.Debugw("notifyCommittee",
+		"state index", stateIndex,
+		"reqs", idsShortStr(reqIds),
+	)
+	op.chain.SendMsgToCommitteePeers(chain.MsgNotifyRequests, msgData, time.Now().UnixNano())
+	op.notifiedBlockIndex = stateIndex
+	op.notifiedIds = reqIds
+	op.nextNotification = time.Now().Add(chain.BFTViewTimeout)
+}
+
+// startCalculationsOfDecidedBatch runs the VM for the decided batch. The requests of the batch may still be
+// unknown to the node if the decision was received from peers, then it waits for them
+func (op *bftOperator) startCalculationsOfDecidedBatch() {
+	decision := op.replica.Decision()
+	if decision == nil || op.currentState == nil || decision.Height != op.mustStateIndex() {
+		return
+	}
+	if op.batch != nil {
+		return
+	}
+	msg, err := decodeBatchProposal(decision.Value)
+	if err != nil {
+		op.log.Errorf("wrong decided batch: %v", err)
+		return
+	}
+	reqs, err := op.collectProcessableBatch(msg.RequestIds, msg.ArgsRejected)
+	if err != nil {
+		op.log.Debugf("decided batch can't be processed yet: %v", err)
+		return
+	}
+	op.startCalculationsOfAgreedBatch(decision.Leader, msg, reqs)
+	op.log.Infof("BATCH DECIDED for state #%d in view #%d, leader: %d, batch hash: %s, reqs: %+v",
+		decision.Height, decision.View, decision.Leader, op.batch.batchHash.String(), idsShortStr(msg.RequestIds))
+}
+
+// Propose proposes the batch of requests seen by a quorum of the committee. Implements bft.Application
+func (op *bftOperator) Propose() []byte {
+	stateIndex, ok := op.blockIndex()
+	if !ok || op.balances == nil {
+		return nil
+	}
+	reqs := op.selectRequestsToProcess()
+	if len(reqs) == 0 {
+		return nil
+	}
+	// timestamp must be max(local clock, prev timestamp+1)
+	ts := time.Now().UnixNano()
+	if prevTs := op.stateTx.MustState().Timestamp(); ts <= prevTs {
+		ts = prevTs + 1
+	}
+	return encodeBatchProposal(&chain.StartProcessingBatchMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: stateIndex,
+		},
+		Timestamp:      ts,
+		RequestIds:     takeIds(reqs),
+		FeeDestination: op.getFeeDestination(),
+		Balances:       op.balances,
+		ArgsRejected:   argsRejectedIds(reqs),
+	})
+}
+
+// Validate checks the batch proposed by the leader of the view. Implements bft.Application
+func (op *bftOperator) Validate(value []byte) error {
+	msg, err := decodeBatchProposal(value)
+	if err != nil {
+		return err
+	}
+	stateIndex, ok := op.blockIndex()
+	if !ok || msg.BlockIndex != stateIndex {
+		return fmt.Errorf("batch is out of context: block index #%d", msg.BlockIndex)
+	}
+	if len(msg.RequestIds) == 0 {
+		return fmt.Errorf("empty batch")
+	}
+	seen := make(map[coretypes.RequestID]bool)
+	for _, id := range msg.RequestIds {
+		if seen[id] {
+			return fmt.Errorf("duplicate request %s", id.Short())
+		}
+		seen[id] = true
+	}
+	if msg.Timestamp <= op.stateTx.MustState().Timestamp() {
+		return fmt.Errorf("timestamp is not after the timestamp of the state")
+	}
+	diff := time.Now().UnixNano() - msg.Timestamp
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > chain.MaxClockDifferenceAllowed.Nanoseconds() {
+		return fmt.Errorf("clock difference is too big: %d ns", diff)
+	}
+	if msg.FeeDestination != op.getFeeDestination() {
+		return fmt.Errorf("wrong fee destination %s", msg.FeeDestination.String())
+	}
+	if !equalBalances(msg.Balances, op.balances) {
+		return fmt.Errorf("balances of the chain address differ from the known ones")
+	}
+	if _, err := op.collectProcessableBatch(msg.RequestIds, msg.ArgsRejected); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SendMsg sends the message of the BFT protocol to the peer. Implements bft.Network
+func (op *bftOperator) SendMsg(peerIndex uint16, data []byte) {
+	if err := op.chain.SendMsg(peerIndex, chain.MsgBFT, op.bftMsgData(data)); err != nil {
+		op.log.Debugf("sending BFT message to #%d: %v", peerIndex, err)
+	}
+}
+
+// Broadcast sends the message of the BFT protocol to all peers of the committee. Implements bft.Network
+func (op *bftOperator) Broadcast(data []byte) {
+	op.chain.SendMsgToCommitteePeers(chain.MsgBFT, op.bftMsgData(data), time.Now().UnixNano())
+}
+
+func (op *bftOperator) bftMsgData(data []byte) []byte {
+	return util.MustBytes(&chain.BFTMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: op.replica.Height(),
+		},
+		Data: data,
+	})
+}
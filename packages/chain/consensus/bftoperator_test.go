@@ -0,0 +1,263 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package consensus
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/chain/consensus/bft"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/iotaledger/wasp/plugins/database"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+// testBFTNet delivers the messages of the replicas of the committee synchronously, in the order they are sent
+type testBFTNet struct {
+	replicas []*bft.Replica
+	queue    []testBFTMsg
+}
+
+type testBFTMsg struct {
+	from, to uint16
+	data     []byte
+}
+
+type testBFTEndpoint struct {
+	net   *testBFTNet
+	index uint16
+}
+
+// testBFTApp is the application of the peers of the operator under test. It accepts any value
+type testBFTApp struct {
+	value []byte
+}
+
+// newTestBFTOperator creates the BFT operator of the node #0 in the committee of 4 nodes, with the state #0.
+// The replicas of the other nodes propose the value of the application and accept any value
+func newTestBFTOperator(t *testing.T, peerValue []byte) (*bftOperator, *testBFTNet) {
+	log := testutil.NewLogger(t)
+	database.InitInMemory(log)
+	dkShares, err := testutil.NewDKShares(pairing.NewSuiteBn256(), 4, 3)
+	require.NoError(t, err)
+	chainID := coretypes.ChainID{1, 2, 3}
+	op := &bftOperator{
+		batchSigning: newBatchSigning(newOperator(&mockChain{chainID: chainID, size: 4}, dkShares[0], log), time.Second),
+	}
+	op.currentState = state.NewVirtualState(mapdb.NewMapDB(), &chainID)
+	vtx := valuetransaction.New(valuetransaction.NewInputs(), valuetransaction.NewOutputs(nil))
+	op.stateTx, err = sctransaction.NewTransaction(vtx, sctransaction.NewStateSection(sctransaction.NewStateSectionParams{
+		Timestamp: time.Now().Add(-time.Minute).UnixNano(),
+	}), nil)
+	require.NoError(t, err)
+
+	net := &testBFTNet{}
+	for i, dkShare := range dkShares {
+		var app bft.Application = &testBFTApp{value: peerValue}
+		if i == 0 {
+			app = op
+		}
+		net.replicas = append(net.replicas, bft.New(bft.Config{
+			Index:        uint16(i),
+			N:            4,
+			T:            3,
+			Signer:       dkShare,
+			Network:      &testBFTEndpoint{net: net, index: uint16(i)},
+			Application:  app,
+			ViewTimeout:  time.Minute,
+			ResendPeriod: time.Minute,
+			Log:          log.Named(fmt.Sprintf("bft%d", i)),
+		}))
+	}
+	op.replica = net.replicas[0]
+	return op, net
+}
+
+// start starts the agreement for the state #0 with the seed which makes the node #leader the leader of the first view
+func (n *testBFTNet) start(t *testing.T, leader uint16) {
+	for i := 0; i < 100; i++ {
+		seed := hashing.HashStrings(fmt.Sprintf("seed%d", i))
+		for _, r := range n.replicas {
+			r.Start(0, seed, time.Now())
+		}
+		if n.replicas[0].Leader() == leader {
+			n.deliver()
+			return
+		}
+		n.queue = nil
+	}
+	t.Fatalf("no seed makes #%d the leader", leader)
+}
+
+func (n *testBFTNet) deliver() {
+	for len(n.queue) > 0 {
+		msg := n.queue[0]
+		n.queue = n.queue[1:]
+		n.replicas[msg.to].ReceiveMessage(msg.from, msg.data, time.Now())
+	}
+}
+
+func (e *testBFTEndpoint) SendMsg(peerIndex uint16, data []byte) {
+	e.net.queue = append(e.net.queue, testBFTMsg{from: e.index, to: peerIndex, data: data})
+}
+
+func (e *testBFTEndpoint) Broadcast(data []byte) {
+	for i := range e.net.replicas {
+		if uint16(i) != e.index {
+			e.SendMsg(uint16(i), data)
+		}
+	}
+}
+
+func (a *testBFTApp) Propose() []byte {
+	return a.value
+}
+
+func (a *testBFTApp) Validate([]byte) error {
+	return nil
+}
+
+// newTestNotifiedRequest adds the request to the backlog of the operator as notified by the peers
+func newTestNotifiedRequest(t *testing.T, op *bftOperator, peers ...uint16) *request {
+	req := newTestRequest(t, op.operator, true, time.Now())
+	for _, i := range peers {
+		req.notifications[i] = true
+	}
+	return req
+}
+
+func testBalances() map[valuetransaction.ID][]*balance.Balance {
+	return map[valuetransaction.ID][]*balance.Balance{
+		{1}: {balance.New(balance.ColorIOTA, 100)},
+	}
+}
+
+func TestBFTPropose(t *testing.T) {
+	op, _ := newTestBFTOperator(t, nil)
+	req := newTestNotifiedRequest(t, op, 0, 1)
+
+	// balances are not known yet
+	require.Nil(t, op.Propose())
+	op.balances = testBalances()
+	// the request is seen only by 2 nodes
+	require.Nil(t, op.Propose())
+
+	req.notifications[2] = true
+	msg, err := decodeBatchProposal(op.Propose())
+	require.NoError(t, err)
+	require.EqualValues(t, 0, msg.BlockIndex)
+	require.EqualValues(t, []coretypes.RequestID{req.reqId}, msg.RequestIds)
+	require.Empty(t, msg.ArgsRejected)
+	require.Greater(t, msg.Timestamp, op.stateTx.MustState().Timestamp())
+	require.Equal(t, op.getFeeDestination(), msg.FeeDestination)
+	require.True(t, equalBalances(op.balances, msg.Balances))
+}
+
+func TestBFTValidate(t *testing.T) {
+	op, _ := newTestBFTOperator(t, nil)
+	op.balances = testBalances()
+	req := newTestNotifiedRequest(t, op, 0, 1, 2)
+	value := op.Propose()
+	require.NoError(t, op.Validate(value))
+
+	wrong := func(change func(msg *chain.StartProcessingBatchMsg)) []byte {
+		msg, err := decodeBatchProposal(value)
+		require.NoError(t, err)
+		change(msg)
+		return encodeBatchProposal(msg)
+	}
+	require.Error(t, op.Validate(value[:4]))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.BlockIndex = 1
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.RequestIds = nil
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.RequestIds = []coretypes.RequestID{req.reqId, req.reqId}
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.Timestamp = op.stateTx.MustState().Timestamp()
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.Timestamp = time.Now().Add(2 * chain.MaxClockDifferenceAllowed).UnixNano()
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.FeeDestination = coretypes.NewAgentIDFromContractID(coretypes.NewContractID(*op.chain.ID(), 0))
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.Balances = map[valuetransaction.ID][]*balance.Balance{
+			{1}: {balance.New(balance.ColorIOTA, 99)},
+		}
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.RequestIds = append(msg.RequestIds, coretypes.NewRequestID(valuetransaction.RandomID(), 0))
+	})))
+	require.Error(t, op.Validate(wrong(func(msg *chain.StartProcessingBatchMsg) {
+		msg.ArgsRejected = msg.RequestIds
+	})))
+}
+
+func TestBFTStartCalculationsOfDecidedBatch(t *testing.T) {
+	t.Run("own proposal", func(t *testing.T) {
+		op, net := newTestBFTOperator(t, nil)
+		op.balances = testBalances()
+		req := newTestNotifiedRequest(t, op, 0, 1, 2)
+
+		op.startCalculationsOfDecidedBatch()
+		require.Nil(t, op.batch)
+
+		net.start(t, 0)
+		decision := op.replica.Decision()
+		require.NotNil(t, decision)
+		require.EqualValues(t, 0, decision.Leader)
+
+		op.startCalculationsOfDecidedBatch()
+		require.NotNil(t, op.batch)
+		require.EqualValues(t, 0, op.batch.blockIndex)
+		require.EqualValues(t, 0, op.batch.leader)
+		msg, err := decodeBatchProposal(decision.Value)
+		require.NoError(t, err)
+		require.EqualValues(t, []coretypes.RequestID{req.reqId}, msg.RequestIds)
+		require.Equal(t, msg.Timestamp, op.batch.timestamp)
+
+		// the batch is started only once
+		batch := op.batch
+		op.startCalculationsOfDecidedBatch()
+		require.True(t, batch == op.batch)
+	})
+	t.Run("decided by peers", func(t *testing.T) {
+		// the peers decide on the batch with the request the node doesn't know yet
+		peerOp, _ := newTestBFTOperator(t, nil)
+		peerOp.balances = testBalances()
+		req := newTestNotifiedRequest(t, peerOp, 0, 1, 2)
+		value := peerOp.Propose()
+
+		op, net := newTestBFTOperator(t, value)
+		op.balances = testBalances()
+		net.start(t, 1)
+		decision := op.replica.Decision()
+		require.NotNil(t, decision)
+		require.EqualValues(t, 1, decision.Leader)
+
+		// the node waits for the request
+		op.startCalculationsOfDecidedBatch()
+		require.Nil(t, op.batch)
+
+		op.requests[req.reqId] = req
+		op.startCalculationsOfDecidedBatch()
+		require.NotNil(t, op.batch)
+		require.EqualValues(t, 1, op.batch.leader)
+	})
+}
@@ -33,13 +33,18 @@ func (op *operator) eventStateTransitionMsg(msg *chain.StateTransitionMsg) {
 		op.log.Errorf("deleteCompletedRequests: %v", err)
 		return
 	}
-	if msg.Synchronized {
+	switch {
+	case !op.controlsChainOutput():
+		// the chain was moved to another committee or is not moved yet to this committee
+		op.log.Infof("chain is controlled by address %s, not by the committee", op.stateTx.MustProperties().MustChainAddress().String())
+		op.setNextConsensusStage(consensusStageNoSync)
+	case msg.Synchronized:
 		if op.iAmCurrentLeader() {
 			op.setNextConsensusStage(consensusStageLeaderStarting)
 		} else {
 			op.setNextConsensusStage(consensusStageSubStarting)
 		}
-	} else {
+	default:
 		op.setNextConsensusStage(consensusStageNoSync)
 	}
 	op.takeAction()
@@ -133,12 +138,9 @@ func (op *operator) eventStartProcessingBatchMsg(msg *chain.StartProcessingBatch
 		)
 		return
 	}
-	numOrig := len(msg.RequestIds)
-	reqs := op.collectProcessableBatch(msg.RequestIds)
-	if len(reqs) != numOrig {
-		// some request were filtered out because not messages didn't reach the node yet.
-		// can't happen? Redundant? panic?
-		op.log.Warnf("node can't process the batch: some requests are not known to the node")
+	reqs, err := op.collectProcessableBatch(msg.RequestIds, msg.ArgsRejected)
+	if err != nil {
+		op.log.Warnf("node can't process the batch: %v", err)
 		return
 	}
 	// TODO remove
@@ -297,6 +299,14 @@ func (op *operator) eventTransactionInclusionLevelMsg(msg *chain.TransactionIncl
 	op.checkInclusionLevel(msg.TxId, msg.Level)
 }
 
+// EventBFTMsg is ignored, the messages of the BFT consensus are only processed by the BFT operator
+func (op *operator) EventBFTMsg(_ *chain.BFTMsg) {
+}
+
+// EventACSMsg is ignored, the messages of the ACS consensus are only processed by the ACS operator
+func (op *operator) EventACSMsg(_ *chain.ACSMsg) {
+}
+
 // EventTimerMsg timer tick
 func (op *operator) EventTimerMsg(msg chain.TimerTick) {
 	op.eventTimerMsgCh <- msg
@@ -331,7 +341,7 @@ func timelockedToString(reqs []*request) string {
 		return "[]"
 	}
 	ret := make([]string, len(reqs))
-	nowis := uint32(time.Now().Unix())
+	nowis := time.Now().Unix()
 	for i := range ret {
 		ret[i] = fmt.Sprintf("%s: %d (-%d)", reqs[i].reqId.Short(), reqs[i].timelock(), reqs[i].timelock()-nowis)
 	}
@@ -100,12 +100,25 @@ func (req *request) requestCode() coretypes.Hname {
 	return req.reqTx.Requests()[req.reqId.Index()].EntryPointCode()
 }
 
-func (req *request) timelock() uint32 {
+func (req *request) timelock() int64 {
 	return req.reqTx.Requests()[req.reqId.Index()].Timelock()
 }
 
 func (req *request) isTimeLocked(nowis time.Time) bool {
-	return req.timelock() > uint32(nowis.Unix())
+	return req.reqTx.Requests()[req.reqId.Index()].IsTimeLocked(nowis)
+}
+
+// isLocked returns true if the request can't be settled at the time by the block next to the current state:
+// it is time-locked or block index locked. Requests with the block index lock wait until the state is known
+func (op *operator) isLocked(req *request, nowis time.Time) bool {
+	if req.isTimeLocked(nowis) {
+		return true
+	}
+	stateIndex, ok := op.blockIndex()
+	if !ok {
+		return req.reqTx.Requests()[req.reqId.Index()].BlockIndexLock() != 0
+	}
+	return req.reqTx.Requests()[req.reqId.Index()].IsBlockIndexLocked(stateIndex + 1)
 }
 
 func (req *request) hasMessage() bool {
@@ -116,6 +129,11 @@ func (req *request) hasSolidArgs() bool {
 	return req.argsSolid
 }
 
+// isArgSolidificationExpired returns true if arguments of the request were not solidified before the deadline
+func (req *request) isArgSolidificationExpired(nowis time.Time) bool {
+	return !req.argsSolid && nowis.After(req.whenMsgReceived.Add(chain.ArgSolidificationDeadline))
+}
+
 func (op *operator) isRequestProcessed(reqid *coretypes.RequestID) bool {
 	processed, err := state.IsRequestCompleted(op.chain.ID(), reqid)
 	if err != nil {
@@ -181,7 +199,8 @@ func takeRefs(reqs []*request) []vm.RequestRefWithFreeTokens {
 				Tx:    reqs[i].reqTx,
 				Index: reqs[i].reqId.Index(),
 			},
-			FreeTokens: reqs[i].freeTokens,
+			FreeTokens:   reqs[i].freeTokens,
+			ArgsRejected: reqs[i].argsRejected,
 		}
 	}
 	return ret
@@ -35,13 +35,17 @@ func (op *operator) runCalculationsAsync(par runCalculationsParams) {
 		Processors:         op.chain.Processors(),
 		ChainID:            *op.chain.ID(),
 		Color:              *op.chain.Color(),
+		ChainAddress:       op.chain.Address(),
 		Entropy:            (hashing.HashValue)(op.stateTx.ID()),
 		Balances:           par.balances,
 		ValidatorFeeTarget: par.accrueFeesTo,
 		Requests:           takeRefs(par.requests),
 		Timestamp:          par.timestamp,
 		VirtualState:       op.currentState,
+		StateTransactionID: op.stateTx.ID(),
 		Log:                op.log,
+		Decryptor:          op.dkshare,
+		Parallelism:        chain.VMParallelism,
 	}
 	ctx.OnFinish = func(_ dict.Dict, _ error, vmError error) {
 		if vmError != nil {
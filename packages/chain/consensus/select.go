@@ -5,6 +5,7 @@
 package consensus
 
 import (
+	"fmt"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"sort"
 	"time"
@@ -54,14 +55,14 @@ func (op *operator) allRequests() []*request {
 
 // all requests from the backlog which:
 // - has known messages
-// - has solid arguments
-// - are not timelocked
+// - has solid arguments or the deadline of their solidification has passed
+// - are not timelocked or block index locked
 // sort by arrival time
 func (op *operator) requestCandidateList() []*request {
 	ret := op.allRequests()
 	nowis := time.Now()
 	ret = filterRequests(ret, func(r *request) bool {
-		return r.hasMessage() && !r.isTimeLocked(nowis) && r.hasSolidArgs()
+		return r.hasMessage() && !op.isLocked(r, nowis) && (r.hasSolidArgs() || r.isArgSolidificationExpired(nowis))
 	})
 	sort.Slice(ret, func(i, j int) bool {
 		return ret[i].whenMsgReceived.Before(ret[j].whenMsgReceived)
@@ -77,7 +78,7 @@ func (op *operator) requestsTimeLocked() []*request {
 		if req.reqTx == nil {
 			continue
 		}
-		if !req.isTimeLocked(nowis) {
+		if !op.isLocked(req, nowis) {
 			continue
 		}
 		ret = append(ret, req)
@@ -114,11 +115,59 @@ func (op *operator) filterRequestsNotSeenQuorumTimes(candidates []*request) []*r
 	return ret
 }
 
-func (op *operator) collectProcessableBatch(reqIds []coretypes.RequestID) []*request {
+// collectProcessableBatch takes requests of the batch proposed by the leader or agreed by the committee.
+// The node does not trust the arguments rejected by the batch, it checks the solidification deadline itself:
+// arguments of the request may be rejected only if they are not solid and the deadline has passed for the node too,
+// otherwise they must be solid. Returns an error if the node can't process the batch, at least not yet
+func (op *operator) collectProcessableBatch(reqIds, argsRejected []coretypes.RequestID) ([]*request, error) {
+	reqs := op.takeFromIds(reqIds)
+	if len(reqs) != len(reqIds) {
+		return nil, fmt.Errorf("some requests of the batch are already processed")
+	}
+	inBatch := make(map[coretypes.RequestID]bool)
+	for _, id := range reqIds {
+		inBatch[id] = true
+	}
+	rejected := make(map[coretypes.RequestID]bool)
+	for _, id := range argsRejected {
+		if !inBatch[id] {
+			return nil, fmt.Errorf("arguments of request %s are rejected, but the request is not in the batch", id.Short())
+		}
+		rejected[id] = true
+	}
 	nowis := time.Now()
-	return filterRequests(op.takeFromIds(reqIds), func(r *request) bool {
-		return r.hasMessage() && !r.isTimeLocked(nowis) && r.hasSolidArgs()
-	})
+	for _, r := range reqs {
+		switch {
+		case !r.hasMessage():
+			return nil, fmt.Errorf("request %s is not known to the node", r.reqId.Short())
+		case op.isLocked(r, nowis):
+			return nil, fmt.Errorf("request %s is locked", r.reqId.Short())
+		case rejected[r.reqId] && r.hasSolidArgs():
+			return nil, fmt.Errorf("arguments of request %s are rejected, but they are solid", r.reqId.Short())
+		case rejected[r.reqId] && !r.isArgSolidificationExpired(nowis):
+			return nil, fmt.Errorf("arguments of request %s are rejected before the solidification deadline", r.reqId.Short())
+		case !rejected[r.reqId] && !r.hasSolidArgs():
+			return nil, fmt.Errorf("arguments of request %s are not solid", r.reqId.Short())
+		}
+	}
+	for _, r := range reqs {
+		r.argsRejected = rejected[r.reqId]
+	}
+	return reqs, nil
+}
+
+// markArgsRejected marks requests of the batch which arguments are not solid yet.
+// Returns their ids
+func markArgsRejected(reqs []*request) []coretypes.RequestID {
+	ret := make([]coretypes.RequestID, 0)
+	for _, r := range reqs {
+		r.argsRejected = !r.hasSolidArgs()
+		if r.argsRejected {
+			r.log.Warnf("request arguments were not solidified before the deadline. The request will be rejected")
+			ret = append(ret, r.reqId)
+		}
+	}
+	return ret
 }
 
 func filterRequests(reqs []*request, fn func(r *request) bool) []*request {
@@ -0,0 +1,120 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package consensus
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/iotaledger/wasp/packages/vm/processors"
+	"github.com/iotaledger/wasp/plugins/database"
+	"github.com/stretchr/testify/require"
+)
+
+// mockChain is the chain of the operators under test. Methods not used by the tests are not implemented
+type mockChain struct {
+	chain.Chain
+	chainID coretypes.ChainID
+	size    uint16
+}
+
+func (c *mockChain) ID() *coretypes.ChainID {
+	return &c.chainID
+}
+
+func (c *mockChain) Size() uint16 {
+	return c.size
+}
+
+// Color is not a valid color of the chain, so the VM refuses to run the batches started by the tests
+func (c *mockChain) Color() *balance.Color {
+	return &balance.ColorIOTA
+}
+
+func (c *mockChain) Address() address.Address {
+	return address.Address(c.chainID)
+}
+
+func (c *mockChain) Processors() *processors.ProcessorCache {
+	return nil
+}
+
+func (c *mockChain) SendMsg(uint16, byte, []byte) error {
+	return nil
+}
+
+func (c *mockChain) SendMsgToCommitteePeers(byte, []byte, int64) uint16 {
+	return c.size - 1
+}
+
+// newTestOperator creates the operator of the node #0 in the committee of 4 nodes, with the state #0
+func newTestOperator(t *testing.T) *operator {
+	log := testutil.NewLogger(t)
+	database.InitInMemory(log)
+	chainID := coretypes.ChainID{1, 2, 3}
+	index := uint16(0)
+	op := newOperator(&mockChain{chainID: chainID, size: 4}, &tcrypto.DKShare{Index: &index, N: 4, T: 3}, log)
+	op.currentState = state.NewVirtualState(mapdb.NewMapDB(), &chainID)
+	return op
+}
+
+// newTestRequest adds the request, which message was received at the time, to the backlog of the operator
+func newTestRequest(t *testing.T, op *operator, argsSolid bool, whenMsgReceived time.Time) *request {
+	reqSection := sctransaction.NewRequestSection(0, coretypes.NewContractID(*op.chain.ID(), 0), coretypes.Hname(rand.Uint32()))
+	vtx := valuetransaction.New(valuetransaction.NewInputs(), valuetransaction.NewOutputs(nil))
+	tx, err := sctransaction.NewTransaction(vtx, nil, []*sctransaction.RequestSection{reqSection})
+	require.NoError(t, err)
+	req := op.newRequest(coretypes.NewRequestID(tx.ID(), 0))
+	req.reqTx = tx
+	req.argsSolid = argsSolid
+	req.whenMsgReceived = whenMsgReceived
+	op.requests[req.reqId] = req
+	return req
+}
+
+func TestCollectProcessableBatch(t *testing.T) {
+	op := newTestOperator(t)
+	now := time.Now()
+	expired := now.Add(-chain.ArgSolidificationDeadline - time.Second)
+	solid := newTestRequest(t, op, true, now)
+	notSolid := newTestRequest(t, op, false, now)
+	notSolidExpired := newTestRequest(t, op, false, expired)
+	unknownID := coretypes.NewRequestID(valuetransaction.RandomID(), 0)
+
+	reqs, err := op.collectProcessableBatch([]coretypes.RequestID{solid.reqId, notSolidExpired.reqId},
+		[]coretypes.RequestID{notSolidExpired.reqId})
+	require.NoError(t, err)
+	require.EqualValues(t, []*request{solid, notSolidExpired}, reqs)
+	require.False(t, solid.argsRejected)
+	require.True(t, notSolidExpired.argsRejected)
+
+	// solid arguments are not rejected
+	_, err = op.collectProcessableBatch([]coretypes.RequestID{solid.reqId}, []coretypes.RequestID{solid.reqId})
+	require.Error(t, err)
+	// arguments are not rejected before the deadline of the node itself
+	_, err = op.collectProcessableBatch([]coretypes.RequestID{notSolid.reqId}, []coretypes.RequestID{notSolid.reqId})
+	require.Error(t, err)
+	// arguments which are not solid must be rejected
+	_, err = op.collectProcessableBatch([]coretypes.RequestID{notSolidExpired.reqId}, nil)
+	require.Error(t, err)
+	_, err = op.collectProcessableBatch([]coretypes.RequestID{notSolid.reqId}, nil)
+	require.Error(t, err)
+	// only arguments of the requests in the batch are rejected
+	_, err = op.collectProcessableBatch([]coretypes.RequestID{solid.reqId}, []coretypes.RequestID{notSolidExpired.reqId})
+	require.Error(t, err)
+	// all requests must be known
+	_, err = op.collectProcessableBatch([]coretypes.RequestID{solid.reqId, unknownID}, nil)
+	require.Error(t, err)
+}
@@ -103,6 +103,9 @@ type request struct {
 	notifications []bool
 	// true if arguments were decoded/solidified already. If not, the request in not eligible for the batch
 	argsSolid bool
+	// true if arguments were not solidified before the deadline and the request is included in the batch.
+	// The VM rejects such request
+	argsRejected bool
 
 	log *logger.Logger
 }
@@ -110,7 +113,14 @@ type request struct {
 func NewOperator(committee chain.Chain, dkshare *tcrypto.DKShare, log *logger.Logger) *operator {
 	defer committee.SetReadyConsensus()
 
-	ret := &operator{
+	ret := newOperator(committee, dkshare, log)
+	ret.setNextConsensusStage(consensusStageNoSync)
+	go ret.recvLoop()
+	return ret
+}
+
+func newOperator(committee chain.Chain, dkshare *tcrypto.DKShare, log *logger.Logger) *operator {
+	return &operator{
 		chain:                               committee,
 		dkshare:                             dkshare,
 		requests:                            make(map[coretypes.RequestID]*request),
@@ -129,9 +139,6 @@ func NewOperator(committee chain.Chain, dkshare *tcrypto.DKShare, log *logger.Lo
 		eventTimerMsgCh:                     make(chan chain.TimerTick),
 		closeCh:                             make(chan bool),
 	}
-	ret.setNextConsensusStage(consensusStageNoSync)
-	go ret.recvLoop()
-	return ret
 }
 
 func (op *operator) Close() {
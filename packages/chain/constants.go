@@ -25,6 +25,16 @@ const (
 	// peer after some time
 	PeriodBetweenSyncMessages = 1 * time.Second
 
+	// maximum number of blocks ahead of the solid state which are fetched from peers in parallel while syncing.
+	// Fetched blocks wait in the window until they are applied to the state in order
+	SyncWindowSize = 64
+
+	// number of blocks requested from one peer in one GetBlockRange message
+	SyncBlockRangeSize = 8
+
+	// if blocks of the requested range are not received in time, they are requested from another peer
+	SyncBlockRangeTimeout = 5 * time.Second
+
 	// if pongs do not make a quorum, pings are repeated to all peer nodes
 	RepeatPingAfter = 5 * time.Second
 
@@ -37,4 +47,45 @@ const (
 
 	// check arg solidification period
 	CheckArgSolidificationEvery = 1 * time.Second
+
+	// time to wait for the missing blob from committee peers
+	FetchBlobFromPeersTimeout = 5 * time.Second
+
+	// default period after arrival of the request to solidify its arguments. After the deadline the request is rejected
+	DefaultArgSolidificationDeadline = 5 * time.Minute
+
+	// after the chain is moved to the next committee, nodes which are not in it keep the chain running
+	// for some time to provide blocks to the nodes of the next committee which are still syncing
+	RotationGracePeriod = 1 * time.Minute
+
+	// the BFT consensus moves to the next view if the batch is not decided in time.
+	// The timeout is doubled with every view change
+	BFTViewTimeout = 10 * time.Second
+
+	// period of repeating the last messages of the BFT consensus to overcome message loss
+	BFTResendPeriod = 1 * time.Second
+
+	// the ACS consensus starts the next epoch of the agreement not earlier than after the period
+	// if the agreed proposals contain no requests to process
+	ACSEpochPeriod = 1 * time.Second
+
+	// period of repeating the last messages of the ACS consensus to overcome message loss
+	ACSResendPeriod = 1 * time.Second
+
+	// the ACS consensus fetches the requests of the agreed batch which are unknown to the node from the goshimmer node.
+	// Fetching is repeated with the period until the requests arrive
+	FetchRequestsPeriod = 5 * time.Second
 )
+
+// ArgSolidificationDeadline is the period after arrival of the request to solidify its arguments.
+// It is set by the node configuration
+var ArgSolidificationDeadline = DefaultArgSolidificationDeadline
+
+// PruningKeepBlocks is the number of blocks kept before the latest state snapshot. Older blocks are deleted
+// by the state manager after each state transition. Negative value disables pruning.
+// It is set by the node configuration
+var PruningKeepBlocks = -1
+
+// VMParallelism is the number of requests of the batch the VM runs speculatively in parallel.
+// Values below 2 disable parallel execution. It is set by the node configuration
+var VMParallelism = 1
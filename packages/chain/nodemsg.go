@@ -38,6 +38,6 @@ func (reqMsg *RequestMsg) RequestBlock() *sctransaction.RequestSection {
 	return reqMsg.Requests()[reqMsg.Index]
 }
 
-func (reqMsg *RequestMsg) Timelock() uint32 {
+func (reqMsg *RequestMsg) Timelock() int64 {
 	return reqMsg.RequestBlock().Timelock()
 }
@@ -104,6 +104,14 @@ func (msg *StartProcessingBatchMsg) Write(w io.Writer) error {
 	if err := waspconn.WriteBalances(w, msg.Balances); err != nil {
 		return err
 	}
+	if err := util.WriteUint16(w, uint16(len(msg.ArgsRejected))); err != nil {
+		return err
+	}
+	for i := range msg.ArgsRejected {
+		if _, err := w.Write(msg.ArgsRejected[i][:]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -128,6 +136,15 @@ func (msg *StartProcessingBatchMsg) Read(r io.Reader) error {
 	if msg.Balances, err = waspconn.ReadBalances(r); err != nil {
 		return err
 	}
+	if err := util.ReadUint16(r, &size); err != nil {
+		return err
+	}
+	msg.ArgsRejected = make([]coretypes.RequestID, size)
+	for i := range msg.ArgsRejected {
+		if err := msg.ArgsRejected[i].Read(r); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -181,6 +198,20 @@ func (msg *GetBlockMsg) Read(r io.Reader) error {
 	return util.ReadUint32(r, &msg.BlockIndex)
 }
 
+func (msg *GetBlockRangeMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, msg.BlockIndex); err != nil {
+		return err
+	}
+	return util.WriteUint16(w, msg.Count)
+}
+
+func (msg *GetBlockRangeMsg) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &msg.BlockIndex); err != nil {
+		return err
+	}
+	return util.ReadUint16(r, &msg.Count)
+}
+
 func (msg *BlockHeaderMsg) Write(w io.Writer) error {
 	if err := util.WriteUint32(w, msg.BlockIndex); err != nil {
 		return err
@@ -191,6 +222,9 @@ func (msg *BlockHeaderMsg) Write(w io.Writer) error {
 	if _, err := w.Write(msg.AnchorTransactionID.Bytes()); err != nil {
 		return err
 	}
+	if _, err := w.Write(msg.EssenceHash[:]); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -204,6 +238,9 @@ func (msg *BlockHeaderMsg) Read(r io.Reader) error {
 	if _, err := r.Read(msg.AnchorTransactionID[:]); err != nil {
 		return err
 	}
+	if err := util.ReadHashValue(r, &msg.EssenceHash); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -234,6 +271,89 @@ func (msg *StateUpdateMsg) Read(r io.Reader) error {
 	return nil
 }
 
+func (msg *GetBlobMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, msg.BlockIndex); err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.BlobHash[:]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *GetBlobMsg) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &msg.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.ReadHashValue(r, &msg.BlobHash); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *BlobMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, msg.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, msg.Data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *BlobMsg) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &msg.BlockIndex); err != nil {
+		return err
+	}
+	var err error
+	if msg.Data, err = util.ReadBytes32(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *BFTMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, msg.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, msg.Data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *BFTMsg) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &msg.BlockIndex); err != nil {
+		return err
+	}
+	var err error
+	if msg.Data, err = util.ReadBytes32(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *ACSMsg) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, msg.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, msg.Data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (msg *ACSMsg) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &msg.BlockIndex); err != nil {
+		return err
+	}
+	var err error
+	if msg.Data, err = util.ReadBytes32(r); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (msg *TestTraceMsg) Write(w io.Writer) error {
 	if !util.ValidPermutation(msg.Sequence) {
 		panic(fmt.Sprintf("Write: wrong permutation %+v", msg.Sequence))
@@ -26,6 +26,11 @@ const (
 	MsgStateUpdate             = 6 + peering.FirstUserMsgCode
 	MsgBatchHeader             = 7 + peering.FirstUserMsgCode
 	MsgTestTrace               = 8 + peering.FirstUserMsgCode
+	MsgGetBlob                 = 9 + peering.FirstUserMsgCode
+	MsgBlob                    = 10 + peering.FirstUserMsgCode
+	MsgGetBlockRange           = 11 + peering.FirstUserMsgCode
+	MsgBFT                     = 12 + peering.FirstUserMsgCode
+	MsgACS                     = 13 + peering.FirstUserMsgCode
 )
 
 type TimerTick int
@@ -75,6 +80,9 @@ type StartProcessingBatchMsg struct {
 	FeeDestination coretypes.AgentID
 	// balances/outputs
 	Balances map[valuetransaction.ID][]*balance.Balance
+	// ids of requests of the batch which arguments were not solidified before the deadline.
+	// They are rejected by the VM
+	ArgsRejected []coretypes.RequestID
 }
 
 // after calculations the result peer responds to the start processing msg
@@ -98,12 +106,21 @@ type GetBlockMsg struct {
 	PeerMsgHeader
 }
 
+// request of the range of blocks from peer, starting from the BlockIndex. Used in fast sync process.
+// The peer responds with the BlockHeaderMsg and StateUpdateMsg messages for each block it has
+type GetBlockRangeMsg struct {
+	PeerMsgHeader
+	Count uint16
+}
+
 // the header of the block message sent by peers in the process of syncing
 // it is sent as a first message while syncing a batch
 type BlockHeaderMsg struct {
 	PeerMsgHeader
 	Size                uint16
 	AnchorTransactionID valuetransaction.ID
+	// essence hash of the block. The receiver checks it against the block reconstructed from state updates
+	EssenceHash hashing.HashValue
 }
 
 // state update sent to peer. Used in sync process, as part of batch
@@ -115,6 +132,30 @@ type StateUpdateMsg struct {
 	IndexInTheBlock uint16
 }
 
+// request of the data blob from peer. Used to solidify blob-referenced request arguments
+type GetBlobMsg struct {
+	PeerMsgHeader
+	BlobHash hashing.HashValue
+}
+
+// data blob sent to peer as a response to GetBlobMsg
+type BlobMsg struct {
+	PeerMsgHeader
+	Data []byte
+}
+
+// message of the BFT consensus protocol. The data is interpreted by the BFT consensus operator
+type BFTMsg struct {
+	PeerMsgHeader
+	Data []byte
+}
+
+// message of the ACS consensus protocol. The data is interpreted by the ACS consensus operator
+type ACSMsg struct {
+	PeerMsgHeader
+	Data []byte
+}
+
 // used for testing of the communications
 type TestTraceMsg struct {
 	PeerMsgHeader
@@ -20,11 +20,17 @@ import (
 func (sm *stateManager) takeAction() {
 	sm.sendPingsIfNeeded()
 	sm.notifyConsensusOnStateTransitionIfNeeded()
-	if sm.checkStateApproval() {
-		return
+	defer sm.updateSyncStatus()
+
+	// while syncing, the next block and its anchor transaction may be already fetched
+	for sm.checkStateApproval() {
+		if !sm.pushSyncedBlock() {
+			return
+		}
 	}
+	sm.pushSyncedBlock()
 	sm.requestStateTransactionIfNeeded()
-	sm.requestStateUpdateFromPeerIfNeeded()
+	sm.requestBlocksFromPeersIfNeeded()
 }
 
 func (sm *stateManager) notifyConsensusOnStateTransitionIfNeeded() {
@@ -87,6 +93,15 @@ func (sm *stateManager) checkStateApproval() bool {
 
 	// found a pending block which is approved by the nextStateTransaction
 
+	// the state root committed by the transaction must be the root of the Merkle tree of the state.
+	// Transactions written in the version without the state root don't commit to it
+	stateRoot := sm.nextStateTransaction.MustState().StateRoot()
+	if sm.nextStateTransaction.MustState().HasStateRoot() && stateRoot != pending.nextState.StateRoot() {
+		sm.log.Errorf("major inconsistency: state root %s in the tx %s doesn't match state root %s of the state",
+			stateRoot.String(), sm.nextStateTransaction.ID().String(), pending.nextState.StateRoot().String())
+		return false
+	}
+
 	if pending.block.StateTransactionID() == niltxid {
 		// not committed yet block. Link it to the transaction
 		pending.block.WithStateTransaction(sm.nextStateTransaction.ID())
@@ -110,10 +125,17 @@ func (sm *stateManager) checkStateApproval() bool {
 				return false
 			}
 		}
+		if sm.solidState != nil {
+			// evict processors of upgraded contracts at the same block on all nodes
+			if err := sm.chain.Processors().EvictReplaced(sm.solidState, pending.nextState, pending.block); err != nil {
+				sm.log.Errorf("failed to evict replaced processors: %v", err)
+			}
+		}
 		if err := pending.nextState.CommitToDb(pending.block); err != nil {
 			sm.log.Errorw("failed to save state at index #%d", pending.nextState.BlockIndex())
 			return false
 		}
+		sm.pruneBlocks()
 
 		if sm.solidState != nil {
 			sm.log.Infof("STATE TRANSITION TO #%d. Anchor transaction: %s, block size: %d",
@@ -133,16 +155,27 @@ func (sm *stateManager) checkStateApproval() bool {
 		sm.log.Infof("INITIAL STATE #%d LOADED FROM DB. State hash: %s, state txid: %s",
 			sm.solidState.BlockIndex(), varStateHash.String(), sm.nextStateTransaction.ID().String())
 	}
+	isOrigin := sm.solidState == nil
 	sm.solidStateValid = true
 	sm.solidState = pending.nextState
 
+	// the chain is moved to another address when the committee is rotated
+	prevAddress := sm.chain.Address()
+	if sm.approvingTransaction != nil {
+		prevAddress = *sm.approvingTransaction.MustProperties().MustChainAddress()
+	}
 	sm.approvingTransaction = sm.nextStateTransaction
+	if newAddress := *sm.approvingTransaction.MustProperties().MustChainAddress(); !isOrigin && newAddress != prevAddress {
+		sm.log.Infof("the chain was moved from address %s to %s at state #%d",
+			prevAddress.String(), newAddress.String(), sm.solidState.BlockIndex())
+		go sm.chain.EventStateAddressChanged().Trigger(newAddress)
+	}
 
 	// update state manager variables to the new state
 	sm.nextStateTransaction = nil
 	sm.pendingBlocks = make(map[hashing.HashValue]*pendingBlock) // clear pending batches
 	sm.permutation.Shuffle(varStateHash[:])
-	sm.syncMessageDeadline = time.Now() // if not synced then immediately
+	sm.cleanSyncWindow()
 	sm.consensusNotifiedOnStateTransition = false
 
 	// publish state transition
@@ -171,31 +204,6 @@ func (sm *stateManager) checkStateApproval() bool {
 	return true
 }
 
-func (sm *stateManager) requestStateUpdateFromPeerIfNeeded() {
-	if !sm.solidStateValid || sm.isSynchronized() {
-		// no need for more info when state is synced or solid state still needs validation by the anchor tx
-		return
-	}
-	// state is valid but not synced
-	if !sm.syncMessageDeadline.Before(time.Now()) {
-		// not time yet for the next message
-		return
-	}
-	// it is time to ask for the next state update to next peer in the permutation
-	data := util.MustBytes(&chain.GetBlockMsg{
-		PeerMsgHeader: chain.PeerMsgHeader{
-			BlockIndex: sm.solidState.BlockIndex() + 1,
-		},
-	})
-	// send messages until first without error
-	for i := uint16(0); i < sm.chain.Size(); i++ {
-		if err := sm.chain.SendMsg(sm.permutation.Next(), chain.MsgGetBatch, data); err == nil {
-			break
-		}
-		sm.syncMessageDeadline = time.Now().Add(chain.PeriodBetweenSyncMessages)
-	}
-}
-
 // index of evidenced state index is passed to record the largest one.
 // This is needed to check synchronization status.
 func (sm *stateManager) EvidenceStateIndex(stateIndex uint32) {
@@ -216,7 +224,6 @@ func (sm *stateManager) evidenceStateIndex(stateIndex uint32) {
 	}
 	switch {
 	case !sm.isSynchronized() && wasSynchronized:
-		sm.syncMessageDeadline = time.Now()
 		sm.log.Debugf("NOT SYNCED: current state index: %d, largest evidenced index: %d",
 			currStateIndex, sm.largestEvidencedStateIndex)
 	case sm.isSynchronized() && !wasSynchronized:
@@ -330,8 +337,8 @@ func (sm *stateManager) requestStateTransaction(pb *pendingBlock) {
 
 func (sm *stateManager) numPongs() uint16 {
 	ret := uint16(0)
-	for _, f := range sm.pingPong {
-		if f {
+	for i, f := range sm.pingPong {
+		if f && uint16(i) < sm.chain.Size() {
 			ret++
 		}
 	}
@@ -343,7 +350,9 @@ func (sm *stateManager) numPongsHasQuorum() bool {
 }
 
 func (sm *stateManager) pingPongReceived(senderIndex uint16) {
-	sm.pingPong[senderIndex] = true
+	if int(senderIndex) < len(sm.pingPong) {
+		sm.pingPong[senderIndex] = true
+	}
 }
 
 func (sm *stateManager) respondPongToPeer(targetPeerIndex uint16) {
@@ -376,3 +385,18 @@ func (sm *stateManager) sendPingsToCommitteePeers() {
 	sm.log.Debugf("sent pings to %d committee peers", numSent)
 	sm.deadlineForPongQuorum = time.Now().Add(chain.RepeatPingAfter)
 }
+
+// pruneBlocks deletes blocks older than the latest snapshot of the state if pruning is enabled by the node configuration
+func (sm *stateManager) pruneBlocks() {
+	if chain.PruningKeepBlocks < 0 {
+		return
+	}
+	n, err := state.PruneBlocks(sm.chain.ID(), uint32(chain.PruningKeepBlocks))
+	if err != nil {
+		sm.log.Errorf("failed to prune blocks: %v", err)
+		return
+	}
+	if n > 0 {
+		sm.log.Infof("pruned %d blocks older than the latest snapshot", n)
+	}
+}
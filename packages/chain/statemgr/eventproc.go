@@ -4,9 +4,12 @@
 package statemgr
 
 import (
+	"time"
+
 	"github.com/iotaledger/wasp/packages/chain"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/nodeconn"
 )
 
 // EventPingPongMsg reacts to the PinPong message
@@ -42,36 +45,64 @@ func (sm *stateManager) eventGetBlockMsg(msg *chain.GetBlockMsg) {
 		"sender index", msg.SenderIndex,
 		"block index", msg.BlockIndex,
 	)
-	block, err := state.LoadBlock(sm.chain.ID(), msg.BlockIndex)
+	sm.sendBlockToPeer(msg.SenderIndex, msg.BlockIndex)
+}
+
+// EventGetBlockRangeMsg is a request for the range of blocks while syncing
+func (sm *stateManager) EventGetBlockRangeMsg(msg *chain.GetBlockRangeMsg) {
+	sm.eventGetBlockRangeMsgCh <- msg
+}
+func (sm *stateManager) eventGetBlockRangeMsg(msg *chain.GetBlockRangeMsg) {
+	sm.log.Debugw("EventGetBlockRangeMsg",
+		"sender index", msg.SenderIndex,
+		"block index", msg.BlockIndex,
+		"count", msg.Count,
+	)
+	count := msg.Count
+	if count > chain.SyncBlockRangeSize {
+		count = chain.SyncBlockRangeSize
+	}
+	for i := uint32(0); i < uint32(count); i++ {
+		if !sm.sendBlockToPeer(msg.SenderIndex, msg.BlockIndex+i) {
+			return
+		}
+	}
+}
+
+// sendBlockToPeer sends the header and state updates of the block. Returns false if the block can't be sent
+func (sm *stateManager) sendBlockToPeer(peerIndex uint16, blockIndex uint32) bool {
+	block, err := state.LoadBlock(sm.chain.ID(), blockIndex)
 	if err != nil || block == nil {
 		// can't load block, can't respond
-		return
+		return false
 	}
 
-	sm.log.Debugf("EventGetBlockMsg for state index #%d --> peer %d", msg.BlockIndex, msg.SenderIndex)
+	sm.log.Debugf("sending block #%d --> peer %d", blockIndex, peerIndex)
 
-	err = sm.chain.SendMsg(msg.SenderIndex, chain.MsgBatchHeader, util.MustBytes(&chain.BlockHeaderMsg{
+	err = sm.chain.SendMsg(peerIndex, chain.MsgBatchHeader, util.MustBytes(&chain.BlockHeaderMsg{
 		PeerMsgHeader: chain.PeerMsgHeader{
-			BlockIndex: msg.BlockIndex,
+			BlockIndex: blockIndex,
 		},
 		Size:                block.Size(),
 		AnchorTransactionID: block.StateTransactionID(),
+		EssenceHash:         block.EssenceHash(),
 	}))
 	if err != nil {
-		return
+		return false
 	}
 	block.ForEach(func(batchIndex uint16, stateUpdate state.StateUpdate) bool {
-		err = sm.chain.SendMsg(msg.SenderIndex, chain.MsgStateUpdate, util.MustBytes(&chain.StateUpdateMsg{
+		err = sm.chain.SendMsg(peerIndex, chain.MsgStateUpdate, util.MustBytes(&chain.StateUpdateMsg{
 			PeerMsgHeader: chain.PeerMsgHeader{
-				BlockIndex: msg.BlockIndex,
+				BlockIndex: blockIndex,
 			},
 			StateUpdate:     stateUpdate,
 			IndexInTheBlock: batchIndex,
 		}))
 		sh := util.GetHashValue(stateUpdate)
-		sm.log.Debugw("EventGetBlockMsg: sending stateUpdate", "hash", sh.String())
+		sm.log.Debugw("sendBlockToPeer: sending stateUpdate", "hash", sh.String())
 		return true
 	})
+	return err == nil
 }
 
 // EventBlockHeaderMsg
@@ -84,22 +115,33 @@ func (sm *stateManager) eventBlockHeaderMsg(msg *chain.BlockHeaderMsg) {
 		"state index", msg.BlockIndex,
 		"size", msg.Size,
 		"state tx", msg.AnchorTransactionID.String(),
+		"essence hash", msg.EssenceHash.String(),
 	)
-	if sm.syncedBatch != nil &&
-		sm.syncedBatch.stateIndex == msg.BlockIndex &&
-		sm.syncedBatch.stateTxId == msg.AnchorTransactionID &&
-		len(sm.syncedBatch.stateUpdates) == int(msg.Size) {
+	if !sm.isInSyncWindow(msg.BlockIndex) {
+		return
+	}
+	sb, ok := sm.syncingBlocks[msg.BlockIndex]
+	if ok && sb.block != nil {
+		return // already fetched
+	}
+	if ok && sb.stateTxId == msg.AnchorTransactionID && sb.essenceHash == msg.EssenceHash &&
+		len(sb.stateUpdates) == int(msg.Size) {
 		return // no need to start from scratch
 	}
-	sm.syncedBatch = &syncedBatch{
-		stateIndex:   msg.BlockIndex,
+	deadline := time.Now().Add(chain.SyncBlockRangeTimeout)
+	if ok {
+		deadline = sb.deadline
+	}
+	sm.syncingBlocks[msg.BlockIndex] = &syncingBlock{
 		stateUpdates: make([]state.StateUpdate, msg.Size),
 		stateTxId:    msg.AnchorTransactionID,
+		essenceHash:  msg.EssenceHash,
+		deadline:     deadline,
 	}
 }
 
 // response to the state update msg.
-// It collects state updates while waiting for the anchoring state transaction
+// It collects state updates of blocks in the sync window. The block is complete when all state updates are received
 func (sm *stateManager) EventStateUpdateMsg(msg *chain.StateUpdateMsg) {
 	sm.eventStateUpdateMsgCh <- msg
 }
@@ -109,13 +151,11 @@ func (sm *stateManager) eventStateUpdateMsg(msg *chain.StateUpdateMsg) {
 		"state index", msg.BlockIndex,
 		"block index", msg.IndexInTheBlock,
 	)
-	if sm.syncedBatch == nil {
+	sb, ok := sm.syncingBlocks[msg.BlockIndex]
+	if !ok || sb.block != nil || sb.stateUpdates == nil {
 		return
 	}
-	if sm.syncedBatch.stateIndex != msg.BlockIndex {
-		return
-	}
-	if int(msg.IndexInTheBlock) >= len(sm.syncedBatch.stateUpdates) {
+	if int(msg.IndexInTheBlock) >= len(sb.stateUpdates) {
 		sm.log.Errorf("bad block index in the state update message")
 		return
 	}
@@ -123,35 +163,36 @@ func (sm *stateManager) eventStateUpdateMsg(msg *chain.StateUpdateMsg) {
 	sm.log.Debugf("EventStateUpdateMsg: receiving stateUpdate block index: %d hash: %s",
 		msg.IndexInTheBlock, sh.String())
 
-	sm.syncedBatch.stateUpdates[msg.IndexInTheBlock] = msg.StateUpdate
-	sm.syncedBatch.msgCounter++
+	if sb.stateUpdates[msg.IndexInTheBlock] == nil {
+		sb.msgCounter++
+	}
+	sb.stateUpdates[msg.IndexInTheBlock] = msg.StateUpdate
 
-	if int(sm.syncedBatch.msgCounter) < len(sm.syncedBatch.stateUpdates) {
+	if int(sb.msgCounter) < len(sb.stateUpdates) {
 		// some are missing
 		return
 	}
-	// check if whole block already received
-	for _, su := range sm.syncedBatch.stateUpdates {
-		if su == nil {
-			// some state updates are missing
-			return
-		}
-	}
 	// the whole block received
-	batch, err := state.NewBlock(sm.syncedBatch.stateUpdates)
+	block, err := state.NewBlock(sb.stateUpdates)
 	if err != nil {
 		sm.log.Errorf("failed to create block: %v", err)
-		sm.syncedBatch = nil
+		delete(sm.syncingBlocks, msg.BlockIndex)
+		return
+	}
+	block.WithBlockIndex(msg.BlockIndex).WithStateTransaction(sb.stateTxId)
+	if block.EssenceHash() != sb.essenceHash {
+		sm.log.Warnf("EventStateUpdateMsg: essence hash of the block #%d doesn't match the header. Will be requested again",
+			msg.BlockIndex)
+		delete(sm.syncingBlocks, msg.BlockIndex)
 		return
 	}
-	batch.WithBlockIndex(sm.syncedBatch.stateIndex).WithStateTransaction(sm.syncedBatch.stateTxId)
+	sm.log.Debugf("EventStateUpdateMsg: reconstructed block %s", block.String())
 
-	sm.log.Debugf("EventStateUpdateMsg: reconstructed block %s", batch.String())
+	sb.block = block
+	sb.stateUpdates = nil
+	// the anchor transaction is requested ahead, before the block is applied to the state
+	_ = nodeconn.RequestConfirmedTransactionFromNode(&sb.stateTxId)
 
-	sm.syncedBatch = nil
-	go sm.chain.ReceiveMessage(chain.PendingBlockMsg{
-		Block: batch,
-	})
 	sm.takeAction()
 }
 
@@ -184,6 +225,12 @@ func (sm *stateManager) eventStateTransactionMsg(msg *chain.StateTransactionMsg)
 	sm.evidenceStateIndex(stateBlock.BlockIndex())
 
 	if sm.solidStateValid {
+		if sb, ok := sm.syncingBlocks[stateBlock.BlockIndex()]; ok && stateBlock.BlockIndex() > sm.solidState.BlockIndex()+1 &&
+			sb.block != nil && sb.stateTxId == msg.ID() {
+			// anchor transaction of the fetched block ahead of the solid state. Kept until the block is applied
+			sm.syncTransactions[stateBlock.BlockIndex()] = msg.Transaction
+			return
+		}
 		if stateBlock.BlockIndex() != sm.solidState.BlockIndex()+1 {
 			sm.log.Debugf("skip state transaction: expected with state index #%d, got #%d, Txid: %s",
 				sm.solidState.BlockIndex()+1, stateBlock.BlockIndex(), msg.ID().String())
@@ -6,6 +6,7 @@
 package statemgr
 
 import (
+	"sync/atomic"
 	"time"
 
 	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
@@ -24,7 +25,8 @@ type stateManager struct {
 	// after that it is always true
 	solidStateValid bool
 
-	// flag pingPong[idx] if ping-pong message was received from the peer idx
+	// flag pingPong[idx] if ping-pong message was received from the peer idx.
+	// Only committee peers count towards the quorum
 	pingPong              []bool
 	deadlineForPongQuorum time.Time
 
@@ -53,11 +55,19 @@ type stateManager struct {
 	// falls behind the state of the smart contract, i.e. it is not synced
 	largestEvidencedStateIndex uint32
 
-	// the timeout deadline for sync inquiries
-	syncMessageDeadline time.Time
+	// blocks ahead of the solid state being fetched from peers while syncing: block index -> block.
+	// The window is bounded by chain.SyncWindowSize
+	syncingBlocks map[uint32]*syncingBlock
 
-	// current block being synced
-	syncedBatch *syncedBatch
+	// anchor transactions of fetched blocks received ahead of the state transition: block index -> transaction
+	syncTransactions map[uint32]*sctransaction.Transaction
+
+	// sync progress
+	syncStartTime  time.Time
+	syncStartIndex uint32
+	syncPeersUsed  map[uint16]bool
+	// last *chain.SyncStatus, read from other goroutines
+	syncStatus atomic.Value
 
 	// for the pseudo-random sequence of peers
 	permutation *util.Permutation16
@@ -69,6 +79,7 @@ type stateManager struct {
 	evidenceStateIndexCh         chan uint32
 	eventStateIndexPingPongMsgCh chan *chain.StateIndexPingPongMsg
 	eventGetBlockMsgCh           chan *chain.GetBlockMsg
+	eventGetBlockRangeMsgCh      chan *chain.GetBlockRangeMsg
 	eventBlockHeaderMsgCh        chan *chain.BlockHeaderMsg
 	eventStateUpdateMsgCh        chan *chain.StateUpdateMsg
 	eventStateTransactionMsgCh   chan *chain.StateTransactionMsg
@@ -77,11 +88,17 @@ type stateManager struct {
 	closeCh                      chan bool
 }
 
-type syncedBatch struct {
+type syncingBlock struct {
 	msgCounter   uint16
-	stateIndex   uint32
 	stateUpdates []state.StateUpdate
 	stateTxId    valuetransaction.ID
+	essenceHash  hashing.HashValue
+	// block reconstructed from state updates and checked against the essence hash. nil while being fetched
+	block state.Block
+	// true when the block was passed to pending blocks to be approved by the anchor transaction
+	pushed bool
+	// the block is requested again if not received or not approved before the deadline
+	deadline time.Time
 }
 
 type pendingBlock struct {
@@ -94,15 +111,26 @@ type pendingBlock struct {
 }
 
 func New(c chain.Chain, log *logger.Logger) chain.StateManager {
-	ret := &stateManager{
+	ret := newStateManager(c, log)
+	go ret.initLoadState()
+
+	return ret
+}
+
+func newStateManager(c chain.Chain, log *logger.Logger) *stateManager {
+	return &stateManager{
 		chain:                        c,
-		pingPong:                     make([]bool, c.Size()),
+		pingPong:                     make([]bool, c.NumPeers()),
 		pendingBlocks:                make(map[hashing.HashValue]*pendingBlock),
+		syncingBlocks:                make(map[uint32]*syncingBlock),
+		syncTransactions:             make(map[uint32]*sctransaction.Transaction),
+		syncPeersUsed:                make(map[uint16]bool),
 		permutation:                  util.NewPermutation16(c.NumPeers(), nil),
 		log:                          log.Named("s"),
 		evidenceStateIndexCh:         make(chan uint32),
 		eventStateIndexPingPongMsgCh: make(chan *chain.StateIndexPingPongMsg),
 		eventGetBlockMsgCh:           make(chan *chain.GetBlockMsg),
+		eventGetBlockRangeMsgCh:      make(chan *chain.GetBlockRangeMsg),
 		eventBlockHeaderMsgCh:        make(chan *chain.BlockHeaderMsg),
 		eventStateUpdateMsgCh:        make(chan *chain.StateUpdateMsg),
 		eventStateTransactionMsgCh:   make(chan *chain.StateTransactionMsg),
@@ -110,9 +138,6 @@ func New(c chain.Chain, log *logger.Logger) chain.StateManager {
 		eventTimerMsgCh:              make(chan chain.TimerTick),
 		closeCh:                      make(chan bool),
 	}
-	go ret.initLoadState()
-
-	return ret
 }
 
 func (sm *stateManager) Close() {
@@ -144,6 +169,10 @@ func (sm *stateManager) initLoadState() {
 			"state hash", h.String(),
 			"approving tx", txh.String(),
 		)
+		if idx, ok, err := state.GetSnapshotIndex(sm.chain.ID()); err == nil && ok && idx == sm.solidState.BlockIndex() {
+			sm.log.Infof("solid state #%d has been imported from the snapshot. Will be validated against the anchor transaction %s",
+				idx, txh.String())
+		}
 	} else {
 		// pre-origin state. Origin block is empty block.
 		// Will be waiting for the origin transaction to arrive
@@ -171,6 +200,10 @@ func (sm *stateManager) recvLoop() {
 			if ok {
 				sm.eventGetBlockMsg(msg)
 			}
+		case msg, ok := <-sm.eventGetBlockRangeMsgCh:
+			if ok {
+				sm.eventGetBlockRangeMsg(msg)
+			}
 		case msg, ok := <-sm.eventBlockHeaderMsgCh:
 			if ok {
 				sm.eventBlockHeaderMsg(msg)
@@ -0,0 +1,183 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package statemgr
+
+import (
+	"sort"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// Fast sync.
+// When the node falls behind, blocks ahead of the solid state are requested in ranges from several
+// committee peers at once. The number of blocks fetched ahead is bounded by chain.SyncWindowSize.
+// Each received block is checked against the essence hash in its header, then its anchor transaction
+// is requested from the IOTA node in advance.
+// Blocks are applied to the solid state strictly in order: the next block becomes the pending block,
+// which is approved by the state hash and the state root of its anchor transaction as usual
+
+// isInSyncWindow returns true if the block is among the blocks fetched ahead of the solid state
+func (sm *stateManager) isInSyncWindow(blockIndex uint32) bool {
+	if !sm.solidStateValid {
+		return false
+	}
+	solidIndex := sm.solidState.BlockIndex()
+	return blockIndex > solidIndex && blockIndex <= solidIndex+chain.SyncWindowSize
+}
+
+// requestBlocksFromPeersIfNeeded requests missing blocks of the sync window. Consecutive missing blocks
+// are requested in ranges, each range from the next peer in the permutation
+func (sm *stateManager) requestBlocksFromPeersIfNeeded() {
+	if !sm.solidStateValid || sm.isSynchronized() {
+		// no need for more info when state is synced or solid state still needs validation by the anchor tx
+		return
+	}
+	nowis := time.Now()
+	from := sm.solidState.BlockIndex() + 1
+	to := sm.largestEvidencedStateIndex
+	if to >= from+chain.SyncWindowSize {
+		to = from + chain.SyncWindowSize - 1
+	}
+	for idx := from; idx <= to; {
+		if !sm.needsBlockRequest(idx, nowis) {
+			idx++
+			continue
+		}
+		count := uint32(1)
+		for count < chain.SyncBlockRangeSize && idx+count <= to && sm.needsBlockRequest(idx+count, nowis) {
+			count++
+		}
+		if !sm.requestBlockRange(idx, uint16(count), nowis) {
+			return
+		}
+		idx += count
+	}
+}
+
+// needsBlockRequest returns false if the block was fetched or is being fetched and the deadline hasn't passed
+func (sm *stateManager) needsBlockRequest(blockIndex uint32, nowis time.Time) bool {
+	sb, ok := sm.syncingBlocks[blockIndex]
+	if !ok {
+		return true
+	}
+	if sb.block != nil && !sb.pushed {
+		// fetched, waiting in the window
+		return false
+	}
+	return !sb.deadline.After(nowis)
+}
+
+// requestBlockRange sends the request to the first peer in the permutation which accepts it
+func (sm *stateManager) requestBlockRange(from uint32, count uint16, nowis time.Time) bool {
+	data := util.MustBytes(&chain.GetBlockRangeMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{
+			BlockIndex: from,
+		},
+		Count: count,
+	})
+	for i := uint16(0); i < sm.chain.NumPeers(); i++ {
+		peer := sm.permutation.Next()
+		if err := sm.chain.SendMsg(peer, chain.MsgGetBlockRange, data); err != nil {
+			continue
+		}
+		sm.log.Debugf("requested blocks #%d..#%d from peer %d", from, from+uint32(count)-1, peer)
+		for idx := from; idx < from+uint32(count); idx++ {
+			sm.syncingBlocks[idx] = &syncingBlock{deadline: nowis.Add(chain.SyncBlockRangeTimeout)}
+		}
+		sm.syncPeersUsed[peer] = true
+		return true
+	}
+	return false
+}
+
+// pushSyncedBlock passes the fetched next block to pending blocks, together with its anchor
+// transaction if it has already arrived. Returns true if the block was pushed
+func (sm *stateManager) pushSyncedBlock() bool {
+	if !sm.solidStateValid {
+		return false
+	}
+	next := sm.solidState.BlockIndex() + 1
+	sb, ok := sm.syncingBlocks[next]
+	if !ok || sb.block == nil || sb.pushed {
+		return false
+	}
+	if !sm.addPendingBlock(sb.block) {
+		delete(sm.syncingBlocks, next)
+		return false
+	}
+	sb.pushed = true
+	// if the block is not approved in time, it is fetched again, possibly from another peer
+	sb.deadline = time.Now().Add(2 * chain.StateTransactionRequestTimeout)
+	if tx, ok := sm.syncTransactions[next]; ok && sm.nextStateTransaction == nil {
+		sm.nextStateTransaction = tx
+	}
+	delete(sm.syncTransactions, next)
+	return true
+}
+
+// cleanSyncWindow removes blocks and transactions which are not ahead of the solid state anymore
+func (sm *stateManager) cleanSyncWindow() {
+	for idx := range sm.syncingBlocks {
+		if !sm.isInSyncWindow(idx) {
+			delete(sm.syncingBlocks, idx)
+		}
+	}
+	for idx := range sm.syncTransactions {
+		if !sm.isInSyncWindow(idx) {
+			delete(sm.syncTransactions, idx)
+		}
+	}
+}
+
+// updateSyncStatus stores the sync progress to be read by SyncStatus
+func (sm *stateManager) updateSyncStatus() {
+	ret := &chain.SyncStatus{
+		Synced:      sm.isSynchronized(),
+		TargetIndex: sm.largestEvidencedStateIndex,
+		PeersUsed:   make([]uint16, 0, len(sm.syncPeersUsed)),
+	}
+	if sm.solidState != nil {
+		ret.SolidIndex = sm.solidState.BlockIndex()
+	}
+	if ret.Synced || !sm.solidStateValid {
+		if ret.Synced && !sm.syncStartTime.IsZero() {
+			sm.log.Infof("synced to the state #%d: %d blocks in %v from %d peer(s)", ret.SolidIndex,
+				ret.SolidIndex-sm.syncStartIndex, time.Since(sm.syncStartTime).Round(time.Millisecond), len(sm.syncPeersUsed))
+		}
+		sm.syncStartTime = time.Time{}
+		sm.syncPeersUsed = make(map[uint16]bool)
+		sm.syncStatus.Store(ret)
+		return
+	}
+	if sm.syncStartTime.IsZero() {
+		sm.syncStartTime = time.Now()
+		sm.syncStartIndex = ret.SolidIndex
+	}
+	if elapsed := time.Since(sm.syncStartTime).Seconds(); elapsed > 0 {
+		ret.BlocksPerSecond = float64(ret.SolidIndex-sm.syncStartIndex) / elapsed
+	}
+	for _, sb := range sm.syncingBlocks {
+		if sb.block != nil {
+			ret.BlocksFetched++
+		}
+	}
+	for peer := range sm.syncPeersUsed {
+		ret.PeersUsed = append(ret.PeersUsed, peer)
+	}
+	sort.Slice(ret.PeersUsed, func(i, j int) bool {
+		return ret.PeersUsed[i] < ret.PeersUsed[j]
+	})
+	sm.syncStatus.Store(ret)
+}
+
+// SyncStatus returns the sync progress. It is safe to call from any goroutine
+func (sm *stateManager) SyncStatus() *chain.SyncStatus {
+	ret, ok := sm.syncStatus.Load().(*chain.SyncStatus)
+	if !ok {
+		return &chain.SyncStatus{}
+	}
+	return ret
+}
@@ -0,0 +1,326 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package statemgr
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/iotaledger/wasp/plugins/database"
+	"github.com/stretchr/testify/require"
+)
+
+const testCommitteeSize = 4
+
+// peerMsg is the message sent by the node to the peer
+type peerMsg struct {
+	target  uint16
+	msgType byte
+	data    []byte
+}
+
+// mockChain is the chain of the node in the simulated committee. Messages sent to peers are collected.
+// Methods not used by the tests are not implemented
+type mockChain struct {
+	chain.Chain
+	chainID  coretypes.ChainID
+	ownIndex uint16
+	sent     []*peerMsg
+}
+
+func (c *mockChain) ID() *coretypes.ChainID {
+	return &c.chainID
+}
+
+func (c *mockChain) Size() uint16 {
+	return testCommitteeSize
+}
+
+func (c *mockChain) NumPeers() uint16 {
+	return testCommitteeSize
+}
+
+func (c *mockChain) Quorum() uint16 {
+	return testCommitteeSize - (testCommitteeSize-1)/3
+}
+
+func (c *mockChain) HasQuorum() bool {
+	return true
+}
+
+func (c *mockChain) ReceiveMessage(_ interface{}) {
+}
+
+func (c *mockChain) SendMsg(targetPeerIndex uint16, msgType byte, msgData []byte) error {
+	if targetPeerIndex == c.ownIndex || targetPeerIndex >= testCommitteeSize {
+		return fmt.Errorf("SendMsg: wrong peer index")
+	}
+	c.sent = append(c.sent, &peerMsg{target: targetPeerIndex, msgType: msgType, data: msgData})
+	return nil
+}
+
+// takeSent returns messages sent since the last call
+func (c *mockChain) takeSent() []*peerMsg {
+	ret := c.sent
+	c.sent = nil
+	return ret
+}
+
+// testBlock returns the block #index of the test chain. Each block consists of 2 state updates
+func testBlock(t *testing.T, index uint32) state.Block {
+	txid := (valuetransaction.ID)(hashing.HashStrings(fmt.Sprintf("tx %d", index)))
+	stateUpdates := make([]state.StateUpdate, 2)
+	for i := range stateUpdates {
+		reqid := coretypes.NewRequestID(txid, uint16(i))
+		stateUpdates[i] = state.NewStateUpdate(&reqid).WithTimestamp(int64(index*2) + int64(i))
+		stateUpdates[i].Mutations().Add(buffered.NewMutationSet(kv.Key(fmt.Sprintf("k%d.%d", index, i)), []byte{byte(index)}))
+	}
+	block, err := state.NewBlock(stateUpdates)
+	require.NoError(t, err)
+	block.WithBlockIndex(index).WithStateTransaction(txid)
+	return block
+}
+
+// newTestNode creates the state manager of the node with the validated solid state after blocks #0..#numBlocks-1.
+// Each node has its own chain ID, so it has its own partition of the database
+func newTestNode(t *testing.T, ownIndex uint16, numBlocks uint32) (*stateManager, *mockChain) {
+	log := testutil.NewLogger(t)
+	database.InitInMemory(log)
+	c := &mockChain{ownIndex: ownIndex}
+	rand.Read(c.chainID[:])
+	vs := state.NewVirtualState(database.GetPartition(&c.chainID), &c.chainID)
+	for i := uint32(0); i < numBlocks; i++ {
+		block := testBlock(t, i)
+		require.NoError(t, vs.ApplyBlock(block))
+		require.NoError(t, vs.CommitToDb(block))
+	}
+	sm := newStateManager(c, log)
+	sm.solidState = vs
+	sm.solidStateValid = true
+	sm.largestEvidencedStateIndex = vs.BlockIndex()
+	// state indices of all peers are evidenced, no pings are sent
+	for i := range sm.pingPong {
+		sm.pingPong[i] = true
+	}
+	return sm, c
+}
+
+// deliver passes the message from the peer to the node the same way the chain dispatches it
+func deliver(t *testing.T, sm *stateManager, senderIndex uint16, msg *peerMsg) {
+	rdr := bytes.NewReader(msg.data)
+	switch msg.msgType {
+	case chain.MsgGetBlockRange:
+		msgt := &chain.GetBlockRangeMsg{}
+		require.NoError(t, msgt.Read(rdr))
+		msgt.SenderIndex = senderIndex
+		sm.eventGetBlockRangeMsg(msgt)
+	case chain.MsgBatchHeader:
+		msgt := &chain.BlockHeaderMsg{}
+		require.NoError(t, msgt.Read(rdr))
+		sm.evidenceStateIndex(msgt.BlockIndex)
+		msgt.SenderIndex = senderIndex
+		sm.eventBlockHeaderMsg(msgt)
+	case chain.MsgStateUpdate:
+		msgt := &chain.StateUpdateMsg{}
+		require.NoError(t, msgt.Read(rdr))
+		sm.evidenceStateIndex(msgt.BlockIndex)
+		msgt.SenderIndex = senderIndex
+		sm.eventStateUpdateMsg(msgt)
+	default:
+		t.Fatalf("unexpected message type %d", msg.msgType)
+	}
+}
+
+// requestedRanges returns the ranges of blocks requested by the node: peer index -> [from, to]
+func requestedRanges(t *testing.T, msgs []*peerMsg) map[uint16][2]uint32 {
+	ret := make(map[uint16][2]uint32)
+	for _, msg := range msgs {
+		require.EqualValues(t, chain.MsgGetBlockRange, msg.msgType)
+		msgt := &chain.GetBlockRangeMsg{}
+		require.NoError(t, msgt.Read(bytes.NewReader(msg.data)))
+		_, ok := ret[msg.target]
+		require.False(t, ok, "more than one range requested from peer %d", msg.target)
+		ret[msg.target] = [2]uint32{msgt.BlockIndex, msgt.BlockIndex + uint32(msgt.Count) - 1}
+	}
+	return ret
+}
+
+func TestSyncFromPeersInParallel(t *testing.T) {
+	const numBlocks = 3 + 2*chain.SyncBlockRangeSize + 4
+	sm, c := newTestNode(t, 0, 3)
+	peers := make(map[uint16]*stateManager)
+	peerChains := make(map[uint16]*mockChain)
+	for i := uint16(1); i < testCommitteeSize; i++ {
+		peers[i], peerChains[i] = newTestNode(t, i, numBlocks)
+	}
+	sm.evidenceStateIndex(numBlocks - 1)
+	sm.takeAction()
+
+	// missing blocks are requested in ranges, each range from another peer
+	ranges := requestedRanges(t, c.takeSent())
+	require.Len(t, ranges, testCommitteeSize-1)
+	covered := make(map[uint32]bool)
+	for _, r := range ranges {
+		require.True(t, r[1]-r[0] < chain.SyncBlockRangeSize)
+		for idx := r[0]; idx <= r[1]; idx++ {
+			covered[idx] = true
+		}
+	}
+	require.Len(t, covered, numBlocks-3)
+	for idx := uint32(3); idx < numBlocks; idx++ {
+		require.True(t, covered[idx], "block #%d is not requested", idx)
+	}
+	// nothing is requested again while the ranges are being fetched
+	sm.takeAction()
+	require.Empty(t, c.takeSent())
+
+	// the peers serve the ranges in parallel, each sends the header and state updates of the blocks in its range
+	for peer, r := range ranges {
+		deliver(t, peers[peer], 0, &peerMsg{target: peer, msgType: chain.MsgGetBlockRange, data: rangeMsgData(r)})
+	}
+	for peer, r := range ranges {
+		served := peerChains[peer].takeSent()
+		require.Len(t, served, int(r[1]-r[0]+1)*3)
+		for _, msg := range served {
+			require.EqualValues(t, 0, msg.target)
+			deliver(t, sm, peer, msg)
+		}
+	}
+	for idx := uint32(4); idx < numBlocks; idx++ {
+		sb, ok := sm.syncingBlocks[idx]
+		require.True(t, ok)
+		require.NotNil(t, sb.block)
+		require.EqualValues(t, testBlock(t, idx).EssenceHash(), sb.block.EssenceHash())
+	}
+	// the next block is passed to pending blocks to be approved by its anchor transaction
+	require.True(t, sm.syncingBlocks[3].pushed)
+	require.Len(t, sm.pendingBlocks, 1)
+	for _, pb := range sm.pendingBlocks {
+		require.EqualValues(t, 3, pb.block.StateIndex())
+		require.EqualValues(t, 3, pb.nextState.BlockIndex())
+	}
+	status := sm.SyncStatus()
+	require.False(t, status.Synced)
+	require.EqualValues(t, numBlocks-1, status.TargetIndex)
+	require.EqualValues(t, numBlocks-3, status.BlocksFetched)
+	require.Len(t, status.PeersUsed, testCommitteeSize-1)
+	require.Empty(t, c.takeSent())
+}
+
+func rangeMsgData(r [2]uint32) []byte {
+	var buf bytes.Buffer
+	msg := &chain.GetBlockRangeMsg{
+		PeerMsgHeader: chain.PeerMsgHeader{BlockIndex: r[0]},
+		Count:         uint16(r[1] - r[0] + 1),
+	}
+	if err := msg.Write(&buf); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestServeBlockRange(t *testing.T) {
+	const numBlocks = 2 + chain.SyncBlockRangeSize
+	peer, peerChain := newTestNode(t, 1, numBlocks)
+
+	// the range is limited by the maximum size of the range
+	deliver(t, peer, 0, &peerMsg{msgType: chain.MsgGetBlockRange, data: rangeMsgData([2]uint32{2, 2 * chain.SyncBlockRangeSize})})
+	served := peerChain.takeSent()
+	require.Len(t, served, chain.SyncBlockRangeSize*3)
+	// the range is served up to the last block of the node
+	deliver(t, peer, 2, &peerMsg{msgType: chain.MsgGetBlockRange, data: rangeMsgData([2]uint32{numBlocks - 2, numBlocks + 2})})
+	require.Len(t, peerChain.takeSent(), 2*3)
+
+	sm, c := newTestNode(t, 0, 2)
+	sm.evidenceStateIndex(numBlocks - 1)
+	sm.takeAction()
+	c.takeSent()
+	for _, msg := range served {
+		require.EqualValues(t, 0, msg.target)
+		deliver(t, sm, 1, msg)
+	}
+	for idx := uint32(3); idx < numBlocks; idx++ {
+		require.NotNil(t, sm.syncingBlocks[idx].block)
+	}
+	require.True(t, sm.syncingBlocks[2].pushed)
+}
+
+func TestSyncEssenceHashMismatch(t *testing.T) {
+	sm, c := newTestNode(t, 0, 3)
+	peer, peerChain := newTestNode(t, 1, 6)
+	sm.evidenceStateIndex(5)
+	sm.takeAction()
+	ranges := requestedRanges(t, c.takeSent())
+	require.Len(t, ranges, 1)
+	for target, r := range ranges {
+		require.EqualValues(t, [2]uint32{3, 5}, r)
+		// the peer is faulty: the header of the block #4 doesn't commit to its state updates
+		deliver(t, peer, 0, &peerMsg{target: target, msgType: chain.MsgGetBlockRange, data: rangeMsgData(r)})
+	}
+	for _, msg := range peerChain.takeSent() {
+		if msg.msgType == chain.MsgBatchHeader {
+			hdr := &chain.BlockHeaderMsg{}
+			require.NoError(t, hdr.Read(bytes.NewReader(msg.data)))
+			if hdr.BlockIndex == 4 {
+				hdr.EssenceHash = hashing.RandomHash(nil)
+				var buf bytes.Buffer
+				require.NoError(t, hdr.Write(&buf))
+				msg.data = buf.Bytes()
+			}
+		}
+		deliver(t, sm, 1, msg)
+	}
+	// the block with the wrong essence hash is dropped, other blocks are kept
+	require.Nil(t, sm.syncingBlocks[4].block)
+	require.NotNil(t, sm.syncingBlocks[5].block)
+	require.True(t, sm.syncingBlocks[3].pushed)
+
+	// the dropped block is requested again as soon as the next block arrives
+	ranges = requestedRanges(t, c.takeSent())
+	require.Len(t, ranges, 1)
+	for _, r := range ranges {
+		require.EqualValues(t, [2]uint32{4, 4}, r)
+	}
+}
+
+func TestSyncTimeoutRequestsAgain(t *testing.T) {
+	sm, c := newTestNode(t, 0, 3)
+	sm.evidenceStateIndex(2 + chain.SyncBlockRangeSize + 2)
+	sm.takeAction()
+	first := requestedRanges(t, c.takeSent())
+	require.Len(t, first, 2)
+
+	// the peers don't respond before the deadline
+	for _, sb := range sm.syncingBlocks {
+		require.True(t, sb.deadline.After(time.Now()))
+		sb.deadline = time.Now().Add(-time.Millisecond)
+	}
+	sm.takeAction()
+	second := requestedRanges(t, c.takeSent())
+	require.Len(t, second, 2)
+	// the same ranges are requested from the next peers in the permutation
+	firstRanges := make(map[[2]uint32]uint16)
+	for peer, r := range first {
+		firstRanges[r] = peer
+	}
+	for peer, r := range second {
+		prevPeer, ok := firstRanges[r]
+		require.True(t, ok)
+		require.NotEqual(t, prevPeer, peer)
+	}
+	for _, sb := range sm.syncingBlocks {
+		require.True(t, sb.deadline.After(time.Now()))
+	}
+}
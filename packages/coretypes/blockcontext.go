@@ -0,0 +1,23 @@
+package coretypes
+
+import (
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+)
+
+// BlockContext provides read-only access to the metadata of the current block
+// and to the historical state of the smart contract
+type BlockContext interface {
+	// BlockIndex is the index of the block the call is executed in.
+	// For views called from outside of the chain it is the index of the latest block
+	BlockIndex() uint32
+	// PrevStateHash is the hash of the state the current block is applied to.
+	// For views called from outside of the chain it is the hash of the latest state
+	PrevStateHash() hashing.HashValue
+	// StateTransactionID is the ID of the anchor transaction of the state with PrevStateHash
+	StateTransactionID() valuetransaction.ID
+	// GetStateAt returns the value of the key in the state of the smart contract as it was
+	// at the end of the block with the given index. The block must be already committed
+	GetStateAt(blockIndex uint32, key kv.Key) ([]byte, error)
+}
@@ -26,6 +26,8 @@ type ContractFunctionInterface struct {
 	Name        string
 	Handler     Handler
 	ViewHandler ViewHandler
+	// Roles are the roles required to call the full entry point. The caller must be assigned at least one of them
+	Roles []string
 }
 
 // Funcs declares init entry point and a list of full and view entry points
@@ -71,6 +73,12 @@ func ViewFunc(name string, handler ViewHandler) ContractFunctionInterface {
 	}
 }
 
+// WithRoles declares roles required to call the full entry point
+func (f ContractFunctionInterface) WithRoles(roles ...string) ContractFunctionInterface {
+	f.Roles = roles
+	return f
+}
+
 type Handler func(ctx coretypes.Sandbox) (dict.Dict, error)
 type ViewHandler func(ctx coretypes.SandboxView) (dict.Dict, error)
 
@@ -130,6 +138,10 @@ func (f *ContractFunctionInterface) CallView(ctx coretypes.SandboxView) (dict.Di
 	return ret, err
 }
 
+func (f *ContractFunctionInterface) RequiredRoles() []string {
+	return f.Roles
+}
+
 func (f *ContractFunctionInterface) IsView() bool {
 	return f.ViewHandler != nil
 }
@@ -6,5 +6,9 @@ package coretypes
 import "errors"
 
 var (
-	ErrWrongDataLength = errors.New("wrong data length")
+	ErrWrongDataLength    = errors.New("wrong data length")
+	ErrGasBudgetExceeded  = errors.New("gas budget exceeded")
+	ErrRequestTimeout     = errors.New("request timed out")
+	ErrEntryPointNotFound = errors.New("entry point not found")
+	ErrArgsNotSolid       = errors.New("request arguments were not solidified before the deadline")
 )
@@ -0,0 +1,23 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package coretypes
+
+// gas budgets of the request
+const (
+	// DefaultGasBudget is the budget of the request which doesn't declare one
+	DefaultGasBudget = int64(1000000)
+	// MaxGasBudget is the upper limit of the budget the request may declare
+	MaxGasBudget = int64(100000000)
+)
+
+// EffectiveGasBudget returns the gas budget the VM gives to the request which declares the budget
+func EffectiveGasBudget(budget int64) int64 {
+	if budget <= 0 {
+		return DefaultGasBudget
+	}
+	if budget > MaxGasBudget {
+		return MaxGasBudget
+	}
+	return budget
+}
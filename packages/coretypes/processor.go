@@ -25,6 +25,13 @@ type EntryPoint interface {
 	CallView(ctx SandboxView) (dict.Dict, error)
 }
 
+// EntryPointWithRoles is implemented by entry points which declare roles required to call them.
+// The VM rejects the call if the caller is not assigned at least one of the roles in the access control list
+// of the contract
+type EntryPointWithRoles interface {
+	RequiredRoles() []string
+}
+
 var ErrWrongTypeEntryPoint = fmt.Errorf("wrong type of entry point")
 
 // nilEntryPoint is the entry point implementation which does nothing when called
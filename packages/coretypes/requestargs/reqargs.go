@@ -9,7 +9,8 @@ import (
 	"io"
 )
 
-// TODO: extend '*' option in RequestArgs with download options (web, IPFS)
+// Blobs referenced with the '*' option are taken from the blob cache of the node.
+// Missing blobs are downloaded by the 'blobfetcher' from committee peers and other configured sources
 
 // RequestArgs encodes request parameters taking into account hashes of data blobs
 type RequestArgs dict.Dict
@@ -69,6 +70,19 @@ func (a RequestArgs) AddAsBlobRef(name kv.Key, data []byte) hashing.HashValue {
 	return h
 }
 
+// AddEncrypted adds encoded RequestArgs encrypted for the committee of the chain.
+// Encrypted arguments are not solidified, they are decrypted by the VM
+func (a RequestArgs) AddEncrypted(data []byte) RequestArgs {
+	a["#"] = data
+	return a
+}
+
+// Encrypted returns encrypted arguments, if any
+func (a RequestArgs) Encrypted() ([]byte, bool) {
+	ret, ok := a["#"]
+	return ret, ok
+}
+
 func (a RequestArgs) AddEncodeSimpleMany(d dict.Dict) RequestArgs {
 	for k, v := range d {
 		a.AddEncodeSimple(k, v)
@@ -108,7 +122,8 @@ func (a RequestArgs) Read(r io.Reader) error {
 // SolidifyRequestArguments decodes RequestArgs.
 // each value treated according to the value of the first byte:
 //  - if the value is '*' the data is a content reference. First 32 bytes always treated as data hash.
-//    The rest (if any) is a content address. It will be treated by a downloader
+//    The rest (if any) is a content address. It is not used: blobs are resolved by their hashes
+//  - if the value is '#' the data is encrypted arguments. They are skipped
 //  - otherwise it is a raw data
 func (a RequestArgs) SolidifyRequestArguments(reg coretypes.BlobCache) (dict.Dict, bool, error) {
 	ret := dict.New()
@@ -123,6 +138,9 @@ func (a RequestArgs) SolidifyRequestArguments(reg coretypes.BlobCache) (dict.Dic
 			err = fmt.Errorf("wrong request argument key '%s'", key)
 			return false
 		}
+		if d[0] == '#' {
+			return true
+		}
 		if d[0] != '*' {
 			ret.Set(kv.Key(d[1:]), value)
 			return true
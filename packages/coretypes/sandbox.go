@@ -28,6 +28,9 @@ type Sandbox interface {
 	State() kv.KVStore
 	// DeployContract deploys contract on the same chain. 'initParams' are passed to the 'init' entry point
 	DeployContract(programHash hashing.HashValue, name string, description string, initParams dict.Dict) error
+	// RemoveContract removes the contract from the chain: its balance is moved to 'balanceTarget' and its state is deleted.
+	// Only the chain owner is authorized to remove contracts
+	RemoveContract(contract Hname, balanceTarget AgentID) error
 	// Call calls the entry point of the contract with parameters and transfer.
 	// If the entry point is full entry point, transfer tokens are moved between caller's and
 	// target contract's accounts (if enough). If the entry point is view, 'transfer' has no effect
@@ -43,6 +46,8 @@ type Sandbox interface {
 	MintedSupply() int64
 	// GetTimestamp return current timestamp of the context
 	GetTimestamp() int64
+	// Block provides read-only access to the block metadata and to the historical state
+	Block() BlockContext
 	// GetEntropy 32 random bytes based on the hash of the current state transaction
 	GetEntropy() hashing.HashValue // 32 bytes of deterministic and unpredictably random data
 	// Balances returns colored balances owned by the smart contract
@@ -53,12 +58,17 @@ type Sandbox interface {
 	Balance(col balance.Color) int64
 	// TransferToAddress send tokens to the L1 ledger address
 	TransferToAddress(addr address.Address, transfer ColoredBalances) bool
-	// PostRequest sends cross-chain request
+	// PostRequest sends cross-chain request. If the callback is specified, the result of the request
+	// is posted back to the calling contract
 	PostRequest(par PostRequestParams) bool
 	// Log interface provides local logging on the machine. It also includes Panicf methods which logs and panics
 	Log() LogInterface
 	// Event publishes "vmmsg" message through Publisher on nanomsg. It also logs locally, but it is not the same thing
 	Event(msg string)
+	// GasBurn charges gas to the budget of the request. Panics with ErrGasBudgetExceeded when the budget is exhausted
+	GasBurn(gas int64)
+	// GasBudget returns the amount of gas which remains in the budget of the request
+	GasBudget() int64
 	//
 	Utils() Utils
 }
@@ -67,7 +77,24 @@ type Sandbox interface {
 type PostRequestParams struct {
 	TargetContractID ContractID
 	EntryPoint       Hname
-	TimeLock         uint32
+	TimeLock         int64
+	GasBudget        int64
 	Params           dict.Dict
 	Transfer         ColoredBalances
+	// Callback is the entry point of the sending contract, which receives the result of the request
+	// when it is processed by the target chain. 0 means no callback
+	Callback Hname
+	// Timeout in seconds after the TimeLock (or after the current time if TimeLock is 0). If the request
+	// is not processed by then, the callback receives ErrRequestTimeout. 0 means no timeout.
+	// Only effective together with the Callback
+	Timeout uint32
 }
+
+// Parameters of the callback request. Besides them, the callback receives the result of the request
+// and the transfer refunded in case of failure
+const (
+	// ParamCallbackRequestID is the ID of the request, which the callback reports about
+	ParamCallbackRequestID = "$requestID"
+	// ParamCallbackError is the error message if the request has failed or timed out
+	ParamCallbackError = "$error"
+)
@@ -18,6 +18,8 @@ type SandboxView interface {
 	ContractID() ContractID
 	// GetTimestamp return timestamp of the current state
 	GetTimestamp() int64
+	// Block provides read-only access to the block metadata and to the historical state
+	Block() BlockContext
 	// Params of the current call
 	Params() dict.Dict
 	// State immutable k/v store of the current call (in the context of the smart contract)
@@ -28,6 +30,10 @@ type SandboxView interface {
 	Balances() ColoredBalances
 	// Log interface provides local logging on the machine. It includes Panicf method
 	Log() LogInterface
+	// GasBurn charges gas to the budget of the request, if the view is called in the context of a request
+	GasBurn(gas int64)
+	// GasBudget returns the amount of gas which remains in the budget of the request
+	GasBudget() int64
 	//
 	Utils() Utils
 }
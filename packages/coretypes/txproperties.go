@@ -19,6 +19,9 @@ type SCTransactionProperties interface {
 	IsOrigin() bool
 	// chain ID of the state section or panic if not a state transaction
 	MustChainID() *ChainID
+	// address which holds the chain token or panic if not a state transaction.
+	// It is the alias of the chain ID unless the committee of the chain was rotated
+	MustChainAddress() *address.Address
 	// color of the state section or panic if not a state transaction
 	MustStateColor() *balance.Color
 	// number of minted tokens which are not request tokens
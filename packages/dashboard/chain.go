@@ -62,6 +62,7 @@ func handleChain(c echo.Context) error {
 		result.Committee.NumPeers = chain.NumPeers()
 		result.Committee.HasQuorum = chain.HasQuorum()
 		result.Committee.PeerStatus = chain.PeerStatus()
+		result.SyncStatus = chain.SyncStatus()
 		result.RootInfo, err = fetchRootInfo(chain)
 		if err != nil {
 			return err
@@ -138,6 +139,7 @@ type ChainTemplateParams struct {
 		HasQuorum  bool
 		PeerStatus []*chain.PeerStatus
 	}
+	SyncStatus *chain.SyncStatus
 }
 
 const tplChain = `
@@ -177,7 +179,7 @@ const tplChain = `
 				<dl>
 				{{range $_, $c := $rootinfo.Contracts}}
 					<dt><a href="{{ uri "chainContract" $chainid $c.Hname }}"><tt>{{trim 30 $c.Name}}</tt></a></dt>
-					<dd><tt>{{trim 50 $c.Description}}</tt></dd>
+					<dd><tt>{{trim 50 $c.Description}}</tt>{{if $c.Status}} ({{$c.Status}}){{end}}</dd>
 				{{end}}
 				</dl>
 			</div>
@@ -231,6 +233,20 @@ const tplChain = `
 				</dl>
 			</div>
 
+			<div class="card fluid">
+				<h3 class="section">Sync</h3>
+				<dl>
+					<dt>Synced</dt><dd><tt>{{.SyncStatus.Synced}}</tt></dd>
+					<dt>Solid state index</dt><dd><tt>{{.SyncStatus.SolidIndex}}</tt></dd>
+					<dt>Target state index</dt><dd><tt>{{.SyncStatus.TargetIndex}}</tt></dd>
+					{{if not .SyncStatus.Synced}}
+						<dt>Blocks per second</dt><dd><tt>{{printf "%.2f" .SyncStatus.BlocksPerSecond}}</tt></dd>
+						<dt>Blocks fetched ahead</dt><dd><tt>{{.SyncStatus.BlocksFetched}}</tt></dd>
+						<dt>Peers used</dt><dd><tt>{{.SyncStatus.PeersUsed}}</tt></dd>
+					{{end}}
+				</dl>
+			</div>
+
 			<div class="card fluid">
 				<h3 class="section">Committee</h3>
 				<dl>
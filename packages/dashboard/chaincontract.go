@@ -103,6 +103,7 @@ const tplChainContract = `
 				<dt>Name</dt><dd><tt>{{trim 50 $c.Name}}</tt></dd>
 				<dt>Hname</dt><dd><tt>{{.Hname}}</tt></dd>
 				<dt>Description</dt><dd><tt>{{trim 50 $c.Description}}</tt></dd>
+				<dt>Status</dt><dd><tt>{{$c.Status}}</tt></dd>
 				<dt>Program hash</dt><dd><tt>{{$c.ProgramHash.String}}</tt></dd>
 				{{if $c.HasCreator}}<dt>Creator</dt><dd>{{ template "agentid" (args $chainid $c.Creator) }}</dd>{{end}}
 				<dt>Owner fee</dt><dd>
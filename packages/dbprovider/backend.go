@@ -0,0 +1,115 @@
+package dbprovider
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/iotaledger/goshimmer/packages/database"
+	"github.com/iotaledger/hive.go/kvstore"
+)
+
+// names of the storage engines
+const (
+	EngineBadger = "badger"
+	EngineBolt   = "bolt"
+	EngineMemory = "memory"
+)
+
+// Backend is the embedded storage engine the node keeps its data in.
+// Realms of the stores created by the backend are prefixes of keys, so the realm of the partition
+// contains all sub-realms of it, the same way for all engines
+type Backend interface {
+	database.DB
+	// Engine returns the name of the storage engine
+	Engine() string
+	// Snapshot returns the consistent read-only view of the realm of the store at the moment.
+	// The snapshot must be released as soon as possible, because it may hold back writes and garbage collection
+	Snapshot(realm kvstore.Realm) (Snapshot, error)
+	// Compact runs the full compaction of the storage, if the engine supports it
+	Compact() error
+}
+
+// Snapshot is the consistent read-only view of the realm of the store
+type Snapshot interface {
+	// Get returns kvstore.ErrKeyNotFound if the key doesn't exist
+	Get(key kvstore.Key) (kvstore.Value, error)
+	// Iterate iterates over key/values with the prefix in the ascending order of keys
+	Iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error
+	// IterateRange iterates over key/values with keys from 'from' (inclusive) to 'to' (exclusive)
+	// in the ascending order of keys. Nil 'to' means no upper bound
+	IterateRange(from, to kvstore.Key, f kvstore.IteratorKeyValueConsumerFunc) error
+	// Release releases resources held by the snapshot
+	Release()
+}
+
+// NewBackend opens the persistent storage engine in the directory
+func NewBackend(engine, dir string) (Backend, error) {
+	switch engine {
+	case EngineBadger:
+		return newBadgerBackend(dir)
+	case EngineBolt:
+		return newBoltBackend(dir)
+	case EngineMemory:
+		return NewMemoryBackend(), nil
+	}
+	return nil, fmt.Errorf("unknown storage engine '%s'", engine)
+}
+
+// realmKey returns the key with the realm prefix
+func realmKey(realm kvstore.Realm, key kvstore.Key) []byte {
+	ret := make([]byte, 0, len(realm)+len(key))
+	ret = append(ret, realm...)
+	return append(ret, key...)
+}
+
+// copySnapshot is the snapshot made by copying all key/values of the realm. It is used by the in-memory engine
+type copySnapshot struct {
+	keys   []string
+	values map[string][]byte
+}
+
+func newCopySnapshot(store kvstore.KVStore) (*copySnapshot, error) {
+	ret := &copySnapshot{values: make(map[string][]byte)}
+	err := store.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		ret.keys = append(ret.keys, string(key))
+		ret.values[string(key)] = append([]byte{}, value...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ret.keys)
+	return ret, nil
+}
+
+func (s *copySnapshot) Get(key kvstore.Key) (kvstore.Value, error) {
+	ret, ok := s.values[string(key)]
+	if !ok {
+		return nil, kvstore.ErrKeyNotFound
+	}
+	return ret, nil
+}
+
+func (s *copySnapshot) Iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error {
+	for i := sort.SearchStrings(s.keys, string(prefix)); i < len(s.keys); i++ {
+		if !bytes.HasPrefix([]byte(s.keys[i]), prefix) || !f([]byte(s.keys[i]), s.values[s.keys[i]]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *copySnapshot) IterateRange(from, to kvstore.Key, f kvstore.IteratorKeyValueConsumerFunc) error {
+	for i := sort.SearchStrings(s.keys, string(from)); i < len(s.keys); i++ {
+		if to != nil && s.keys[i] >= string(to) || !f([]byte(s.keys[i]), s.values[s.keys[i]]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *copySnapshot) Release() {
+	s.keys = nil
+	s.values = nil
+}
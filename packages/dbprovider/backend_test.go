@@ -0,0 +1,214 @@
+package dbprovider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func forEachBackend(t *testing.T, f func(t *testing.T, b Backend)) {
+	for _, engine := range []string{EngineBadger, EngineBolt, EngineMemory} {
+		t.Run(engine, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "wasp-"+engine)
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			b, err := NewBackend(engine, dir)
+			require.NoError(t, err)
+			defer b.Close()
+			require.EqualValues(t, engine, b.Engine())
+			f(t, b)
+		})
+	}
+}
+
+func countKeys(t *testing.T, store kvstore.KVStore, prefix []byte) int {
+	ret := 0
+	err := store.IterateKeys(prefix, func(kvstore.Key) bool {
+		ret++
+		return true
+	})
+	require.NoError(t, err)
+	return ret
+}
+
+func TestBackendRealms(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b Backend) {
+		partition := b.NewStore().WithRealm([]byte("chain"))
+		sub := partition.WithRealm(append(partition.Realm(), 'v'))
+		require.NoError(t, partition.Set([]byte("a"), []byte("1")))
+		require.NoError(t, sub.Set([]byte("x"), []byte("2")))
+		require.NoError(t, sub.Set([]byte("y"), nil))
+
+		// the sub-realm is the prefix in the partition
+		v, err := partition.Get([]byte("vx"))
+		require.NoError(t, err)
+		require.EqualValues(t, "2", v)
+		has, err := sub.Has([]byte("y"))
+		require.NoError(t, err)
+		require.True(t, has)
+		_, err = sub.Get([]byte("z"))
+		require.Equal(t, kvstore.ErrKeyNotFound, err)
+		require.EqualValues(t, 3, countKeys(t, partition, kvstore.EmptyPrefix))
+		require.EqualValues(t, 3, countKeys(t, b.NewStore(), []byte("chain")))
+
+		require.NoError(t, partition.DeletePrefix([]byte("v")))
+		require.EqualValues(t, 0, countKeys(t, sub, kvstore.EmptyPrefix))
+		require.EqualValues(t, 1, countKeys(t, partition, kvstore.EmptyPrefix))
+		require.NoError(t, partition.Delete([]byte("a")))
+		require.EqualValues(t, 0, countKeys(t, partition, kvstore.EmptyPrefix))
+	})
+}
+
+func TestBackendBatched(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b Backend) {
+		store := b.NewStore().WithRealm([]byte("r"))
+		require.NoError(t, store.Set([]byte("del"), []byte("1")))
+
+		batch := store.Batched()
+		for i := 0; i < 2500; i++ {
+			require.NoError(t, batch.Set([]byte(fmt.Sprintf("k%05d", i)), []byte{byte(i)}))
+		}
+		require.NoError(t, batch.Delete([]byte("del")))
+		require.EqualValues(t, 1, countKeys(t, store, kvstore.EmptyPrefix))
+		require.NoError(t, batch.Commit())
+		require.EqualValues(t, 2500, countKeys(t, store, kvstore.EmptyPrefix))
+
+		// the iteration sees the state of the store at its start, the concurrent writes are not visible
+		done := make(chan error, 1)
+		seen := make(map[string]bool)
+		err := store.Iterate([]byte("k"), func(key kvstore.Key, value kvstore.Value) bool {
+			if len(seen) == 0 {
+				go func() {
+					batch := store.Batched()
+					_ = batch.Set([]byte("k99999"), []byte{1})
+					_ = batch.Delete([]byte("k02000"))
+					done <- batch.Commit()
+				}()
+			}
+			seen[string(key)] = true
+			return true
+		})
+		require.NoError(t, err)
+		require.NoError(t, <-done)
+		require.Len(t, seen, 2500)
+		require.True(t, seen["k02000"])
+		require.EqualValues(t, 2500, countKeys(t, store, []byte("k")))
+
+		require.NoError(t, store.Clear())
+		require.EqualValues(t, 0, countKeys(t, store, kvstore.EmptyPrefix))
+	})
+}
+
+func TestBackendSnapshot(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b Backend) {
+		store := b.NewStore().WithRealm([]byte("r"))
+		for i := 0; i < 10; i++ {
+			require.NoError(t, store.Set([]byte{byte(i)}, []byte{byte(i)}))
+		}
+		require.NoError(t, b.NewStore().WithRealm([]byte("other")).Set([]byte{5}, []byte{100}))
+
+		snapshot, err := b.Snapshot([]byte("r"))
+		require.NoError(t, err)
+		defer snapshot.Release()
+
+		// changes after the snapshot are not visible in it
+		require.NoError(t, store.Set([]byte{3}, []byte{33}))
+		require.NoError(t, store.Delete([]byte{4}))
+		v, err := snapshot.Get([]byte{3})
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{3}, v)
+		v, err = snapshot.Get([]byte{4})
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{4}, v)
+		_, err = snapshot.Get([]byte{10})
+		require.Equal(t, kvstore.ErrKeyNotFound, err)
+
+		var keys []byte
+		err = snapshot.IterateRange([]byte{2}, []byte{6}, func(key kvstore.Key, value kvstore.Value) bool {
+			require.EqualValues(t, key, value)
+			keys = append(keys, key...)
+			return true
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{2, 3, 4, 5}, keys)
+
+		keys = nil
+		err = snapshot.IterateRange([]byte{7}, nil, func(key kvstore.Key, _ kvstore.Value) bool {
+			keys = append(keys, key...)
+			return true
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{7, 8, 9}, keys)
+
+		keys = nil
+		err = snapshot.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, _ kvstore.Value) bool {
+			keys = append(keys, key...)
+			return len(keys) < 3
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{0, 1, 2}, keys)
+
+		// the store of the snapshot is read-only, realms are prefixes within the snapshot
+		snapshotStore := NewSnapshotStore(snapshot)
+		require.EqualValues(t, 10, countKeys(t, snapshotStore, kvstore.EmptyPrefix))
+		v, err = snapshotStore.WithRealm([]byte{4}).Get(kvstore.EmptyPrefix)
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{4}, v)
+		require.Equal(t, ErrReadOnly, snapshotStore.Set([]byte{3}, []byte{3}))
+		require.Error(t, snapshotStore.Batched().Commit())
+
+		require.NoError(t, b.Compact())
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	from := NewMemoryBackend()
+	for i := 0; i < 25000; i++ {
+		require.NoError(t, from.NewStore().WithRealm([]byte{byte(i % 3)}).Set([]byte(fmt.Sprintf("%d", i)), []byte{byte(i)}))
+	}
+	forEachBackend(t, func(t *testing.T, to Backend) {
+		n, err := Migrate(from, to)
+		require.NoError(t, err)
+		require.EqualValues(t, 25000, n)
+		require.EqualValues(t, 25000, countKeys(t, to.NewStore(), kvstore.EmptyPrefix))
+		v, err := to.NewStore().WithRealm([]byte{1}).Get([]byte("7"))
+		require.NoError(t, err)
+		require.EqualValues(t, []byte{7}, v)
+
+		// the target must be empty
+		_, err = Migrate(from, to)
+		require.Error(t, err)
+	})
+}
+
+func TestRunCompaction(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b Backend) {
+		dbp := NewDBProviderWithBackend(b, testutil.NewLogger(t))
+		store := dbp.GetPartition(&coretypes.NilChainID)
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, store.Set([]byte(fmt.Sprintf("%d", i)), []byte{byte(i)}))
+		}
+
+		shutdownSignal := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			dbp.RunCompaction(10*time.Millisecond, shutdownSignal)
+			close(done)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		close(shutdownSignal)
+		<-done
+		require.EqualValues(t, 1000, countKeys(t, store, kvstore.EmptyPrefix))
+
+		// the compaction is disabled
+		dbp.RunCompaction(0, make(chan struct{}))
+	})
+}
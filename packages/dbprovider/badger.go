@@ -0,0 +1,152 @@
+package dbprovider
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/dgraph-io/badger/v2/options"
+	"github.com/iotaledger/hive.go/kvstore"
+	badgerstore "github.com/iotaledger/hive.go/kvstore/badger"
+)
+
+const badgerValueLogGCDiscardRatio = 0.1
+
+// badgerBackend is the LSM tree engine. The realm is the prefix of the key
+type badgerBackend struct {
+	db *badger.DB
+}
+
+// newBadgerBackend opens badger with the same options as the goshimmer database
+func newBadgerBackend(dir string) (*badgerBackend, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create DB directory: %w", err)
+	}
+	opts := badger.DefaultOptions(dir)
+
+	opts.Logger = nil
+	opts.SyncWrites = false
+	opts.TableLoadingMode = options.MemoryMap
+	opts.ValueLogLoadingMode = options.MemoryMap
+	opts.CompactL0OnClose = false
+	opts.KeepL0InMemory = false
+	opts.VerifyValueChecksum = false
+	opts.ZSTDCompressionLevel = 1
+	opts.Compression = options.None
+	opts.MaxCacheSize = 50000000
+	opts.EventLogging = false
+
+	if runtime.GOOS == "windows" {
+		opts = opts.WithTruncate(true)
+	}
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not open DB: %w", err)
+	}
+	return &badgerBackend{db: db}, nil
+}
+
+func (b *badgerBackend) Engine() string {
+	return EngineBadger
+}
+
+func (b *badgerBackend) NewStore() kvstore.KVStore {
+	return badgerstore.New(b.db)
+}
+
+func (b *badgerBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *badgerBackend) RequiresGC() bool {
+	return true
+}
+
+func (b *badgerBackend) GC() error {
+	if err := b.db.RunValueLogGC(badgerValueLogGCDiscardRatio); err != nil {
+		return err
+	}
+	// trigger the go garbage collector to release the used memory
+	runtime.GC()
+	return nil
+}
+
+// Compact merges all levels of the LSM tree and rewrites value log files until nothing can be discarded
+func (b *badgerBackend) Compact() error {
+	if err := b.db.Flatten(runtime.NumCPU()); err != nil {
+		return err
+	}
+	for {
+		err := b.db.RunValueLogGC(badgerValueLogGCDiscardRatio)
+		if err == badger.ErrNoRewrite || err == badger.ErrRejected {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *badgerBackend) Snapshot(realm kvstore.Realm) (Snapshot, error) {
+	return &badgerSnapshot{
+		txn:   b.db.NewTransaction(false),
+		realm: append([]byte{}, realm...),
+	}, nil
+}
+
+// badgerSnapshot is the read-only transaction of badger
+type badgerSnapshot struct {
+	txn   *badger.Txn
+	realm []byte
+}
+
+func (s *badgerSnapshot) Get(key kvstore.Key) (kvstore.Value, error) {
+	item, err := s.txn.Get(realmKey(s.realm, key))
+	if err == badger.ErrKeyNotFound {
+		return nil, kvstore.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+func (s *badgerSnapshot) Iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error {
+	return s.iterate(realmKey(s.realm, prefix), realmKey(s.realm, prefix), nil, f)
+}
+
+func (s *badgerSnapshot) IterateRange(from, to kvstore.Key, f kvstore.IteratorKeyValueConsumerFunc) error {
+	var toKey []byte
+	if to != nil {
+		toKey = realmKey(s.realm, to)
+	}
+	return s.iterate(s.realm, realmKey(s.realm, from), toKey, f)
+}
+
+func (s *badgerSnapshot) iterate(prefix, from, to []byte, f kvstore.IteratorKeyValueConsumerFunc) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := s.txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Seek(from); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		if to != nil && bytes.Compare(item.Key(), to) >= 0 {
+			return nil
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if !f(item.KeyCopy(nil)[len(s.realm):], value) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *badgerSnapshot) Release() {
+	s.txn.Discard()
+}
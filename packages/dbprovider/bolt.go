@@ -0,0 +1,343 @@
+package dbprovider
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	boltFileName = "wasp.db"
+	// boltInitialMmapSize is the size of the memory map of the database file. The write transaction which
+	// grows the file beyond the mapped size remaps it and waits for all read transactions to finish,
+	// so snapshots and iterations would block commits. Only the address space is reserved
+	boltInitialMmapSize = 1 << 30
+)
+
+// all realms are kept in one bucket
+var boltBucket = []byte("wasp")
+
+// boltBackend is the B+tree engine. Unlike the bolt store of hive.go, the realm is the prefix of the key
+// in the one bucket, so the realm contains all sub-realms of it, the same way as in badger.
+// Bolt reuses freed pages and never shrinks the file, so there is nothing to compact or collect
+type boltBackend struct {
+	db *bbolt.DB
+}
+
+func newBoltBackend(dir string) (*boltBackend, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create DB directory: %w", err)
+	}
+	db, err := bbolt.Open(filepath.Join(dir, boltFileName), 0600, &bbolt.Options{
+		Timeout:         time.Second,
+		InitialMmapSize: boltInitialMmapSize,
+		FreelistType:    bbolt.FreelistMapType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not open DB: %w", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &boltBackend{db: db}, nil
+}
+
+func (b *boltBackend) Engine() string {
+	return EngineBolt
+}
+
+func (b *boltBackend) NewStore() kvstore.KVStore {
+	return &boltStore{db: b.db}
+}
+
+func (b *boltBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *boltBackend) RequiresGC() bool {
+	return false
+}
+
+func (b *boltBackend) GC() error {
+	return nil
+}
+
+func (b *boltBackend) Compact() error {
+	return nil
+}
+
+func (b *boltBackend) Snapshot(realm kvstore.Realm) (Snapshot, error) {
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &boltSnapshot{tx: tx, realm: append([]byte{}, realm...)}, nil
+}
+
+// boltStore implements kvstore.KVStore
+type boltStore struct {
+	db                           *bbolt.DB
+	realm                        []byte
+	accessCallback               kvstore.AccessCallback
+	accessCallbackCommandsFilter kvstore.Command
+}
+
+func (s *boltStore) AccessCallback(callback kvstore.AccessCallback, commandsFilter ...kvstore.Command) {
+	s.accessCallbackCommandsFilter = 0
+	if len(commandsFilter) == 0 {
+		s.accessCallbackCommandsFilter = kvstore.AllCommands
+	}
+	for _, filterCommand := range commandsFilter {
+		s.accessCallbackCommandsFilter |= filterCommand
+	}
+	s.accessCallback = callback
+}
+
+func (s *boltStore) access(command kvstore.Command, parameters ...[]byte) {
+	if s.accessCallback != nil && (command == kvstore.ShutdownCommand || s.accessCallbackCommandsFilter.HasBits(command)) {
+		s.accessCallback(command, parameters...)
+	}
+}
+
+func (s *boltStore) WithRealm(realm kvstore.Realm) kvstore.KVStore {
+	return &boltStore{
+		db:    s.db,
+		realm: append([]byte{}, realm...),
+	}
+}
+
+func (s *boltStore) Realm() kvstore.Realm {
+	return append([]byte{}, s.realm...)
+}
+
+func (s *boltStore) Shutdown() {
+	s.access(kvstore.ShutdownCommand)
+}
+
+// iterate reads key/values with the prefix in one read transaction, so the consumer sees the consistent
+// state of the store. The consumer shouldn't write to the same database: the write transaction which
+// grows the database beyond boltInitialMmapSize waits for the read transaction to finish
+func (s *boltStore) iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error {
+	fullPrefix := realmKey(s.realm, prefix)
+	return s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.Seek(fullPrefix); k != nil && bytes.HasPrefix(k, fullPrefix); k, v = c.Next() {
+			// memory of bolt is only valid during the transaction
+			if !f(append([]byte{}, k[len(s.realm):]...), append([]byte{}, v...)) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) Iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error {
+	s.access(kvstore.IterateCommand, prefix)
+	return s.iterate(prefix, f)
+}
+
+func (s *boltStore) IterateKeys(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyConsumerFunc) error {
+	s.access(kvstore.IterateKeysCommand, prefix)
+	return s.iterate(prefix, func(key kvstore.Key, _ kvstore.Value) bool {
+		return f(key)
+	})
+}
+
+func (s *boltStore) Clear() error {
+	s.access(kvstore.ClearCommand)
+	return s.deletePrefix(kvstore.EmptyPrefix)
+}
+
+func (s *boltStore) Get(key kvstore.Key) (kvstore.Value, error) {
+	s.access(kvstore.GetCommand, key)
+	var ret []byte
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket(boltBucket).Get(realmKey(s.realm, key)); v != nil {
+			ret = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, kvstore.ErrKeyNotFound
+	}
+	return ret, nil
+}
+
+func (s *boltStore) Set(key kvstore.Key, value kvstore.Value) error {
+	s.access(kvstore.SetCommand, key, value)
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(realmKey(s.realm, key), boltValue(value))
+	})
+}
+
+func (s *boltStore) Has(key kvstore.Key) (bool, error) {
+	s.access(kvstore.HasCommand, key)
+	var ret bool
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		ret = tx.Bucket(boltBucket).Get(realmKey(s.realm, key)) != nil
+		return nil
+	})
+	return ret, err
+}
+
+func (s *boltStore) Delete(key kvstore.Key) error {
+	s.access(kvstore.DeleteCommand, key)
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(realmKey(s.realm, key))
+	})
+}
+
+func (s *boltStore) DeletePrefix(prefix kvstore.KeyPrefix) error {
+	s.access(kvstore.DeletePrefixCommand, prefix)
+	return s.deletePrefix(prefix)
+}
+
+func (s *boltStore) deletePrefix(prefix kvstore.KeyPrefix) error {
+	fullPrefix := realmKey(s.realm, prefix)
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		// keys can't be deleted while moving the cursor
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.Seek(fullPrefix); k != nil && bytes.HasPrefix(k, fullPrefix); k, _ = c.Next() {
+			keys = append(keys, append([]byte{}, k...))
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Batched collects mutations and writes all of them in one transaction
+func (s *boltStore) Batched() kvstore.BatchedMutations {
+	return &boltBatchedMutations{store: s}
+}
+
+func (s *boltStore) Flush() error {
+	return s.db.Sync()
+}
+
+// Close does nothing, the database is closed by the backend
+func (s *boltStore) Close() error {
+	return nil
+}
+
+// bolt can't store nil value
+func boltValue(value []byte) []byte {
+	if value == nil {
+		return []byte{}
+	}
+	return value
+}
+
+type boltMutation struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+type boltBatchedMutations struct {
+	store     *boltStore
+	mutations []boltMutation
+}
+
+func (b *boltBatchedMutations) Set(key kvstore.Key, value kvstore.Value) error {
+	b.mutations = append(b.mutations, boltMutation{
+		key:   realmKey(b.store.realm, key),
+		value: append([]byte{}, value...),
+	})
+	return nil
+}
+
+func (b *boltBatchedMutations) Delete(key kvstore.Key) error {
+	b.mutations = append(b.mutations, boltMutation{
+		key:    realmKey(b.store.realm, key),
+		delete: true,
+	})
+	return nil
+}
+
+func (b *boltBatchedMutations) Cancel() {
+	b.mutations = nil
+}
+
+func (b *boltBatchedMutations) Commit() error {
+	err := b.store.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, m := range b.mutations {
+			var err error
+			if m.delete {
+				err = bucket.Delete(m.key)
+			} else {
+				err = bucket.Put(m.key, boltValue(m.value))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	b.mutations = nil
+	return err
+}
+
+// boltSnapshot is the read-only transaction of bolt. Pages freed while the transaction is open are not reused
+// and the database can't grow beyond boltInitialMmapSize until it is closed, so the snapshot must be released soon
+type boltSnapshot struct {
+	tx    *bbolt.Tx
+	realm []byte
+}
+
+func (s *boltSnapshot) Get(key kvstore.Key) (kvstore.Value, error) {
+	v := s.tx.Bucket(boltBucket).Get(realmKey(s.realm, key))
+	if v == nil {
+		return nil, kvstore.ErrKeyNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+func (s *boltSnapshot) Iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error {
+	fullPrefix := realmKey(s.realm, prefix)
+	return s.iterate(fullPrefix, fullPrefix, nil, f)
+}
+
+func (s *boltSnapshot) IterateRange(from, to kvstore.Key, f kvstore.IteratorKeyValueConsumerFunc) error {
+	var toKey []byte
+	if to != nil {
+		toKey = realmKey(s.realm, to)
+	}
+	return s.iterate(s.realm, realmKey(s.realm, from), toKey, f)
+}
+
+func (s *boltSnapshot) iterate(prefix, from, to []byte, f kvstore.IteratorKeyValueConsumerFunc) error {
+	c := s.tx.Bucket(boltBucket).Cursor()
+	for k, v := c.Seek(from); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if to != nil && bytes.Compare(k, to) >= 0 {
+			return nil
+		}
+		if !f(append([]byte{}, k[len(s.realm):]...), append([]byte{}, v...)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *boltSnapshot) Release() {
+	_ = s.tx.Rollback()
+}
@@ -1,7 +1,6 @@
 package dbprovider
 
 import (
-	"github.com/iotaledger/goshimmer/packages/database"
 	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/hive.go/timeutil"
@@ -12,36 +11,34 @@ import (
 
 type DBProvider struct {
 	log             *logger.Logger
-	db              database.DB
+	backend         Backend
 	store           kvstore.KVStore
 	partitions      map[coretypes.ChainID]kvstore.KVStore
 	partitionsMutex *sync.RWMutex
 }
 
-func newDBProvider(db database.DB, log *logger.Logger) *DBProvider {
+// NewDBProviderWithBackend creates the provider on top of the opened storage engine
+func NewDBProviderWithBackend(backend Backend, log *logger.Logger) *DBProvider {
 	return &DBProvider{
 		log:             log,
-		db:              db,
-		store:           db.NewStore(),
+		backend:         backend,
+		store:           backend.NewStore(),
 		partitions:      make(map[coretypes.ChainID]kvstore.KVStore),
 		partitionsMutex: &sync.RWMutex{},
 	}
 }
 
 func NewInMemoryDBProvider(log *logger.Logger) *DBProvider {
-	db, err := database.NewMemDB()
-	if err != nil {
-		log.Fatal(err)
-	}
-	return newDBProvider(db, log)
+	return NewDBProviderWithBackend(NewMemoryBackend(), log)
 }
 
-func NewPersistentDBProvider(dbDir string, log *logger.Logger) *DBProvider {
-	db, err := database.NewDB(dbDir)
+// NewPersistentDBProvider opens the database in the directory with the storage engine
+func NewPersistentDBProvider(engine, dbDir string, log *logger.Logger) *DBProvider {
+	backend, err := NewBackend(engine, dbDir)
 	if err != nil {
 		log.Fatal(err)
 	}
-	return newDBProvider(db, log)
+	return NewDBProviderWithBackend(backend, log)
 }
 
 // GetPartition returns a Partition, which is a KVStore prefixed with the chain ID.
@@ -65,22 +62,59 @@ func (dbp *DBProvider) GetRegistryPartition() kvstore.KVStore {
 	return dbp.GetPartition(&coretypes.NilChainID)
 }
 
+// GetPartitionSnapshot returns the consistent read-only view of the partition of the chain.
+// It must be released after use
+func (dbp *DBProvider) GetPartitionSnapshot(chainID *coretypes.ChainID) (Snapshot, error) {
+	return dbp.backend.Snapshot(chainID[:])
+}
+
+// Engine returns the name of the storage engine
+func (dbp *DBProvider) Engine() string {
+	return dbp.backend.Engine()
+}
+
+// Compact runs the full compaction of the database
+func (dbp *DBProvider) Compact() error {
+	dbp.log.Infof("Compacting the database...")
+	if err := dbp.backend.Compact(); err != nil {
+		return err
+	}
+	dbp.log.Infof("Compacting the database... done")
+	return nil
+}
+
 func (dbp *DBProvider) Close() {
 	dbp.log.Infof("Syncing database to disk...")
-	if err := dbp.db.Close(); err != nil {
+	if err := dbp.backend.Close(); err != nil {
 		dbp.log.Errorf("Failed to flush the database: %s", err)
 	}
 	dbp.log.Infof("Syncing database to disk... done")
 }
 
 func (dbp *DBProvider) RunGC(shutdownSignal <-chan struct{}) {
-	if !dbp.db.RequiresGC() {
+	if !dbp.backend.RequiresGC() {
 		return
 	}
 	// run the garbage collection with the given interval
 	timeutil.NewTicker(func() {
-		if err := dbp.db.GC(); err != nil {
+		if err := dbp.backend.GC(); err != nil {
 			dbp.log.Warnf("Garbage collection failed: %s", err)
 		}
 	}, 5*time.Minute, shutdownSignal)
 }
+
+// RunCompaction runs the full compaction of the database with the given interval until the shutdown signal.
+// It returns after the running compaction is finished, so the database can be closed then.
+// The interval 0 disables the compaction
+func (dbp *DBProvider) RunCompaction(interval time.Duration, shutdownSignal <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	ticker := timeutil.NewTicker(func() {
+		if err := dbp.Compact(); err != nil {
+			dbp.log.Warnf("Compaction failed: %s", err)
+		}
+	}, interval, shutdownSignal)
+	<-shutdownSignal
+	ticker.WaitForGracefulShutdown()
+}
@@ -15,6 +15,12 @@ const (
 	ObjectTypeNodeIdentity
 	ObjectTypeBlobCache
 	ObjectTypeBlobCacheTTL
+	ObjectTypeMerkleNode
+	ObjectTypeMerkleRoot
+	ObjectTypeSnapshotIndex
+	ObjectTypePrunedIndex
+	ObjectTypeMerkleStale
+	ObjectTypeTrustedPeer
 )
 
 // MakeKey makes key within the partition. It consists to one byte for object type
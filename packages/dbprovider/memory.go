@@ -0,0 +1,44 @@
+package dbprovider
+
+import (
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+)
+
+// memoryBackend keeps everything in memory. Snapshots are copies of the realm
+type memoryBackend struct {
+	store kvstore.KVStore
+}
+
+// NewMemoryBackend creates the storage engine which doesn't persist data
+func NewMemoryBackend() Backend {
+	return &memoryBackend{store: mapdb.NewMapDB()}
+}
+
+func (b *memoryBackend) Engine() string {
+	return EngineMemory
+}
+
+func (b *memoryBackend) NewStore() kvstore.KVStore {
+	return b.store
+}
+
+func (b *memoryBackend) Close() error {
+	return nil
+}
+
+func (b *memoryBackend) RequiresGC() bool {
+	return false
+}
+
+func (b *memoryBackend) GC() error {
+	return nil
+}
+
+func (b *memoryBackend) Snapshot(realm kvstore.Realm) (Snapshot, error) {
+	return newCopySnapshot(b.store.WithRealm(realm))
+}
+
+func (b *memoryBackend) Compact() error {
+	return nil
+}
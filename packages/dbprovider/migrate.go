@@ -0,0 +1,57 @@
+package dbprovider
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/hive.go/kvstore"
+)
+
+// migrateBatchSize is the number of key/values written to the target in one batch
+const migrateBatchSize = 10000
+
+// Migrate copies all key/values of all realms from one storage engine to the other.
+// The target must be empty. It returns the number of copied key/values
+func Migrate(from, to Backend) (int, error) {
+	target := to.NewStore()
+	empty := true
+	err := target.IterateKeys(kvstore.EmptyPrefix, func(kvstore.Key) bool {
+		empty = false
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !empty {
+		return 0, fmt.Errorf("migrate: the target %s database is not empty", to.Engine())
+	}
+	count := 0
+	batch := target.Batched()
+	var batchErr error
+	err = from.NewStore().Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		if batchErr = batch.Set(key, value); batchErr != nil {
+			return false
+		}
+		count++
+		if count%migrateBatchSize == 0 {
+			if batchErr = batch.Commit(); batchErr != nil {
+				return false
+			}
+			batch = target.Batched()
+		}
+		return true
+	})
+	if err == nil {
+		err = batchErr
+	}
+	if err != nil {
+		batch.Cancel()
+		return 0, err
+	}
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	if err := target.Flush(); err != nil {
+		return 0, err
+	}
+	return count, to.Compact()
+}
@@ -0,0 +1,106 @@
+package dbprovider
+
+import (
+	"errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+)
+
+// ErrReadOnly is returned by the store of the snapshot on any write
+var ErrReadOnly = errors.New("the store of the snapshot is read-only")
+
+// snapshotStore is the read-only kvstore.KVStore on top of the snapshot, so the code which reads
+// the store can read the consistent view of it. The realm is the prefix of keys within the realm of the snapshot
+type snapshotStore struct {
+	snapshot Snapshot
+	realm    kvstore.Realm
+}
+
+// NewSnapshotStore returns the read-only store with the key/values of the snapshot.
+// The store can't be used after the snapshot is released
+func NewSnapshotStore(snapshot Snapshot) kvstore.KVStore {
+	return &snapshotStore{snapshot: snapshot}
+}
+
+func (s *snapshotStore) AccessCallback(_ kvstore.AccessCallback, _ ...kvstore.Command) {
+}
+
+func (s *snapshotStore) WithRealm(realm kvstore.Realm) kvstore.KVStore {
+	return &snapshotStore{snapshot: s.snapshot, realm: append([]byte{}, realm...)}
+}
+
+func (s *snapshotStore) Realm() kvstore.Realm {
+	return append([]byte{}, s.realm...)
+}
+
+func (s *snapshotStore) Shutdown() {
+}
+
+func (s *snapshotStore) Iterate(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyValueConsumerFunc) error {
+	return s.snapshot.Iterate(realmKey(s.realm, prefix), func(key kvstore.Key, value kvstore.Value) bool {
+		return f(key[len(s.realm):], value)
+	})
+}
+
+func (s *snapshotStore) IterateKeys(prefix kvstore.KeyPrefix, f kvstore.IteratorKeyConsumerFunc) error {
+	return s.Iterate(prefix, func(key kvstore.Key, _ kvstore.Value) bool {
+		return f(key)
+	})
+}
+
+func (s *snapshotStore) Clear() error {
+	return ErrReadOnly
+}
+
+func (s *snapshotStore) Get(key kvstore.Key) (kvstore.Value, error) {
+	return s.snapshot.Get(realmKey(s.realm, key))
+}
+
+func (s *snapshotStore) Set(_ kvstore.Key, _ kvstore.Value) error {
+	return ErrReadOnly
+}
+
+func (s *snapshotStore) Has(key kvstore.Key) (bool, error) {
+	_, err := s.Get(key)
+	if err == kvstore.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *snapshotStore) Delete(_ kvstore.Key) error {
+	return ErrReadOnly
+}
+
+func (s *snapshotStore) DeletePrefix(_ kvstore.KeyPrefix) error {
+	return ErrReadOnly
+}
+
+func (s *snapshotStore) Batched() kvstore.BatchedMutations {
+	return readOnlyBatchedMutations{}
+}
+
+func (s *snapshotStore) Flush() error {
+	return nil
+}
+
+func (s *snapshotStore) Close() error {
+	return nil
+}
+
+type readOnlyBatchedMutations struct{}
+
+func (readOnlyBatchedMutations) Set(_ kvstore.Key, _ kvstore.Value) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBatchedMutations) Delete(_ kvstore.Key) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBatchedMutations) Cancel() {
+}
+
+func (readOnlyBatchedMutations) Commit() error {
+	return ErrReadOnly
+}
@@ -8,6 +8,10 @@
 //
 // Implementation is based on <https://github.com/dedis/kyber/blob/master/share/dkg/rabin/dkg.go>
 // which is based on <https://link.springer.com/article/10.1007/s00145-006-0347-3>.
+//
+// Only the nodes trusted by a node (see peering.TrustedNetworkManager) can initiate
+// the DKG on it. The initiation messages are signed by the initiator, and the initiator
+// refuses to run the DKG with the peers it does not trust. The peer-to-peer messages
+// are only exchanged with the trusted peers, as the peering network refuses handshakes
+// from the untrusted ones.
 package dkg
-
-// TODO: Only authenticated nodes can initiate (and participate in?) the DKG.
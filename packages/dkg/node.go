@@ -23,14 +23,15 @@ import (
 type Node struct {
 	secKey      kyber.Scalar
 	pubKey      kyber.Point
-	suite       Suite                    // Cryptography to use.
-	netProvider peering.NetworkProvider  // Network to communicate through.
-	registry    tcrypto.RegistryProvider // Where to store the generated keys.
-	processes   map[string]*proc         // Only for introspection.
-	procLock    *sync.RWMutex            // To guard access to the process pool.
-	recvQueue   chan *peering.RecvEvent  // Incoming events processed async.
-	recvStopCh  chan bool                // To coordinate shutdown.
-	attachID    interface{}              // Peering attach ID
+	suite       Suite                         // Cryptography to use.
+	netProvider peering.NetworkProvider       // Network to communicate through.
+	trusted     peering.TrustedNetworkManager // Only the trusted nodes can initiate the DKG.
+	registry    tcrypto.RegistryProvider      // Where to store the generated keys.
+	processes   map[string]*proc              // Only for introspection.
+	procLock    *sync.RWMutex                 // To guard access to the process pool.
+	recvQueue   chan *peering.RecvEvent       // Incoming events processed async.
+	recvStopCh  chan bool                     // To coordinate shutdown.
+	attachID    interface{}                   // Peering attach ID
 	log         *logger.Logger
 }
 
@@ -41,6 +42,7 @@ func NewNode(
 	pubKey kyber.Point,
 	suite Suite,
 	netProvider peering.NetworkProvider,
+	trusted peering.TrustedNetworkManager,
 	registry tcrypto.RegistryProvider,
 	log *logger.Logger,
 ) *Node {
@@ -49,6 +51,7 @@ func NewNode(
 		pubKey:      pubKey,
 		suite:       suite,
 		netProvider: netProvider,
+		trusted:     trusted,
 		registry:    registry,
 		processes:   make(map[string]*proc),
 		procLock:    &sync.RWMutex{},
@@ -119,20 +122,29 @@ func (n *Node) GenerateDistributedKey(
 			}
 		}
 	}
+	for i := range peerPubs {
+		if err = n.isTrustedPeer(peerPubs[i]); err != nil {
+			return nil, invalidParams(err)
+		}
+	}
 	//
 	// Initialize the peers.
+	initMsg := &initiatorInitMsg{
+		dkgRef:       dkgID.String(), // It could be some other identifier.
+		peerNetIDs:   peerNetIDs,
+		peerPubs:     peerPubs,
+		initiatorPub: n.pubKey,
+		threshold:    threshold,
+		timeout:      timeout,
+		roundRetry:   roundRetry,
+	}
+	if err = signInitMsg(initMsg, n.secKey, n.suite); err != nil {
+		return nil, err
+	}
 	if err = n.exchangeInitiatorAcks(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, rabinStep0Initialize,
 		func(peerIdx uint16, peer peering.PeerSender) {
 			n.log.Debugf("Initiator sends step=%v command to %v", rabinStep0Initialize, peer.NetID())
-			peer.SendMsg(makePeerMessage(&dkgID, rabinStep0Initialize, &initiatorInitMsg{
-				dkgRef:       dkgID.String(), // It could be some other identifier.
-				peerNetIDs:   peerNetIDs,
-				peerPubs:     peerPubs,
-				initiatorPub: n.pubKey,
-				threshold:    threshold,
-				timeout:      timeout,
-				roundRetry:   roundRetry,
-			}))
+			peer.SendMsg(makePeerMessage(&dkgID, rabinStep0Initialize, initMsg))
 		},
 	); err != nil {
 		return nil, err
@@ -252,22 +264,51 @@ func (n *Node) recvLoop() {
 }
 
 // onInitMsg is a callback to handle the DKG initialization messages.
+// These are the messages initiating the DKG as well as the resharing procedures.
 func (n *Node) onInitMsg(recv *peering.RecvEvent) {
-	if recv.Msg.MsgType != initiatorInitMsgType {
-		return
-	}
 	var err error
 	var p *proc
-	req := initiatorInitMsg{}
-	if err = req.fromBytes(recv.Msg.MsgData, n.suite); err != nil {
-		n.log.Warnf("Dropping unknown message: %v", recv)
+	var dkgRef string
+	var step byte
+	var initMsg signedInitMsg
+	var initProc func() (*proc, error)
+	switch recv.Msg.MsgType {
+	case initiatorInitMsgType:
+		req := initiatorInitMsg{}
+		if err = req.fromBytes(recv.Msg.MsgData, n.suite); err != nil {
+			n.log.Warnf("Dropping unknown message: %v", recv)
+			return
+		}
+		dkgRef, step, initMsg = req.dkgRef, req.step, &req
+		initProc = func() (*proc, error) {
+			return onInitiatorInit(&recv.Msg.ChainID, &req, n)
+		}
+	case initiatorReshareMsgType:
+		req := initiatorReshareMsg{}
+		if err = req.fromBytes(recv.Msg.MsgData, n.suite); err != nil {
+			n.log.Warnf("Dropping unknown message: %v", recv)
+			return
+		}
+		dkgRef, step, initMsg = req.dkgRef, req.step, &req
+		initProc = func() (*proc, error) {
+			return onInitiatorReshare(&recv.Msg.ChainID, &req, n)
+		}
+	default:
+		return
+	}
+	if err = n.checkInitiator(recv.From, initMsg); err != nil {
+		n.log.Warnf("Refusing the DKG initiated via %v, reason=%v", recv.From.NetID(), err)
+		recv.From.SendMsg(makePeerMessage(&recv.Msg.ChainID, step, &initiatorStatusMsg{
+			error: err,
+		}))
+		return
 	}
 	n.procLock.RLock()
-	if _, ok := n.processes[req.dkgRef]; ok {
+	if _, ok := n.processes[dkgRef]; ok {
 		// To have idempotence for retries, we need to consider duplicate
 		// messages as success, if process is already created.
 		n.procLock.RUnlock()
-		recv.From.SendMsg(makePeerMessage(&recv.Msg.ChainID, req.step, &initiatorStatusMsg{
+		recv.From.SendMsg(makePeerMessage(&recv.Msg.ChainID, step, &initiatorStatusMsg{
 			error: nil,
 		}))
 		return
@@ -277,16 +318,37 @@ func (n *Node) onInitMsg(recv *peering.RecvEvent) {
 		// This part should be executed async, because it accesses the network again, and can
 		// be locked because of the naive implementation of `events.Event`. It locks on all the callbacks.
 		n.procLock.Lock()
-		if p, err = onInitiatorInit(&recv.Msg.ChainID, &req, n); err == nil {
+		if p, err = initProc(); err == nil {
 			n.processes[p.dkgRef] = p
 		}
 		n.procLock.Unlock()
-		recv.From.SendMsg(makePeerMessage(&recv.Msg.ChainID, req.step, &initiatorStatusMsg{
+		recv.From.SendMsg(makePeerMessage(&recv.Msg.ChainID, step, &initiatorStatusMsg{
 			error: err,
 		}))
 	}()
 }
 
+// checkInitiator ensures, that the DKG procedure is initiated by a trusted
+// node and the initiation message is signed by it.
+func (n *Node) checkInitiator(from peering.PeerSender, initMsg signedInitMsg) error {
+	initiatorPub := initMsg.initiator()
+	if fromPub := from.PubKey(); fromPub != nil && !fromPub.Equal(initiatorPub) {
+		return fmt.Errorf("the DKG init message is sent not by the initiator")
+	}
+	if err := verifyInitMsg(initMsg, n.suite); err != nil {
+		return fmt.Errorf("invalid signature of the DKG initiator: %v", err)
+	}
+	return n.isTrustedPeer(initiatorPub)
+}
+
+// isTrustedPeer checks, if the node is on the trust list. This node trusts itself.
+func (n *Node) isTrustedPeer(pubKey kyber.Point) error {
+	if n.pubKey.Equal(pubKey) {
+		return nil
+	}
+	return n.trusted.IsTrustedPeer(pubKey)
+}
+
 // Called by the DKG process on termination.
 func (n *Node) dropProcess(p *proc) bool {
 	n.procLock.Lock()
@@ -15,6 +15,7 @@ import (
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/dkg"
 	"github.com/iotaledger/wasp/packages/peering"
+	"github.com/iotaledger/wasp/packages/tcrypto"
 	"github.com/iotaledger/wasp/packages/testutil"
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/kyber/v3"
@@ -53,7 +54,7 @@ func TestBasic(t *testing.T) {
 	for i := range peerNetIDs {
 		registry := testutil.NewDkgRegistryProvider(suite)
 		dkgNodes[i] = dkg.NewNode(
-			peerSecs[i], peerPubs[i], suite, networkProviders[i], registry,
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registry,
 			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
 		)
 	}
@@ -104,7 +105,7 @@ func TestNoPubs(t *testing.T) {
 	for i := range peerNetIDs {
 		registry := testutil.NewDkgRegistryProvider(suite)
 		dkgNodes[i] = dkg.NewNode(
-			peerSecs[i], peerPubs[i], suite, networkProviders[i], registry,
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registry,
 			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
 		)
 	}
@@ -123,6 +124,59 @@ func TestNoPubs(t *testing.T) {
 	require.NotNil(t, dkShare.SharedPublic)
 }
 
+// TestUntrusted checks, if the DKG is refused for the peers not on the trust list.
+func TestUntrusted(t *testing.T) {
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	//
+	// Create a fake network and keys for the tests.
+	var timeout = 10 * time.Second
+	var threshold uint16 = 2
+	var peerCount uint16 = 3
+	var peerNetIDs []string = make([]string, peerCount)
+	var peerPubs []kyber.Point = make([]kyber.Point, len(peerNetIDs))
+	var peerSecs []kyber.Scalar = make([]kyber.Scalar, len(peerNetIDs))
+	var suite = pairing.NewSuiteBn256() // That's from the Pairing Adapter.
+	for i := range peerNetIDs {
+		peerPair := key.NewKeyPair(suite)
+		peerNetIDs[i] = fmt.Sprintf("P%02d", i)
+		peerSecs[i] = peerPair.Private
+		peerPubs[i] = peerPair.Public
+	}
+	var peeringNetwork *testutil.PeeringNetwork = testutil.NewPeeringNetwork(
+		peerNetIDs, peerPubs, peerSecs, 10000,
+		testutil.NewPeeringNetReliable(),
+		testutil.WithLevel(log, logger.LevelWarn, false),
+	)
+	var networkProviders []peering.NetworkProvider = peeringNetwork.NetworkProviders()
+	//
+	// The last node trusts all the others, but the others don't trust it.
+	var dkgNodes []*dkg.Node = make([]*dkg.Node, len(peerNetIDs))
+	for i := range peerNetIDs {
+		trusted := testutil.NewTrustedNetworkManager(peerPubs[:peerCount-1]...)
+		if i == int(peerCount-1) {
+			trusted = testutil.NewTrustedNetworkManager(peerPubs...)
+		}
+		dkgNodes[i] = dkg.NewNode(
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], trusted, testutil.NewDkgRegistryProvider(suite),
+			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
+		)
+	}
+	//
+	// The untrusted node is refused as the initiator.
+	_, err := dkgNodes[peerCount-1].GenerateDistributedKey(peerNetIDs, peerPubs, threshold, 100*time.Millisecond, 500*time.Millisecond, timeout)
+	require.NotNil(t, err)
+	//
+	// The trusted node refuses to initiate the DKG with the untrusted node.
+	_, err = dkgNodes[0].GenerateDistributedKey(peerNetIDs, peerPubs, threshold, 100*time.Millisecond, 500*time.Millisecond, timeout)
+	require.IsType(t, dkg.InvalidParamsError{}, err)
+	//
+	// The trusted nodes can run the DKG among them.
+	dkShare, err := dkgNodes[0].GenerateDistributedKey(peerNetIDs[:peerCount-1], peerPubs[:peerCount-1], threshold, 100*time.Millisecond, 500*time.Millisecond, timeout)
+	require.Nil(t, err)
+	require.NotNil(t, dkShare.Address)
+}
+
 // TestUnreliableNet checks, if DKG runs on an unreliable network.
 // See a NOTE in the test case bellow.
 func TestUnreliableNet(t *testing.T) {
@@ -163,7 +217,7 @@ func TestUnreliableNet(t *testing.T) {
 	for i := range peerNetIDs {
 		registry := testutil.NewDkgRegistryProvider(suite)
 		dkgNodes[i] = dkg.NewNode(
-			peerSecs[i], peerPubs[i], suite, networkProviders[i], registry,
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registry,
 			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
 		)
 	}
@@ -214,7 +268,7 @@ func TestLowN(t *testing.T) {
 		for i := range peerNetIDs {
 			registry := testutil.NewDkgRegistryProvider(suite)
 			dkgNodes[i] = dkg.NewNode(
-				peerSecs[i], peerPubs[i], suite, networkProviders[i], registry,
+				peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registry,
 				testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
 			)
 		}
@@ -233,3 +287,270 @@ func TestLowN(t *testing.T) {
 		require.NotNil(t, dkShare.SharedPublic)
 	}
 }
+
+// TestReshare checks, if a key is reshared to a partly different set of nodes
+// with a different threshold, keeping the shared public key and the address.
+func TestReshare(t *testing.T) {
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	//
+	// Create a fake network and keys for the tests.
+	var timeout = 100 * time.Second
+	var peerCount uint16 = 5
+	var peerNetIDs []string = make([]string, peerCount)
+	var peerPubs []kyber.Point = make([]kyber.Point, len(peerNetIDs))
+	var peerSecs []kyber.Scalar = make([]kyber.Scalar, len(peerNetIDs))
+	var suite = pairing.NewSuiteBn256() // NOTE: That's from the Pairing Adapter.
+	for i := range peerNetIDs {
+		peerPair := key.NewKeyPair(suite)
+		peerNetIDs[i] = fmt.Sprintf("P%02d", i)
+		peerSecs[i] = peerPair.Private
+		peerPubs[i] = peerPair.Public
+	}
+	var peeringNetwork *testutil.PeeringNetwork = testutil.NewPeeringNetwork(
+		peerNetIDs, peerPubs, peerSecs, 10000,
+		testutil.NewPeeringNetReliable(),
+		testutil.WithLevel(log, logger.LevelWarn, false),
+	)
+	var networkProviders []peering.NetworkProvider = peeringNetwork.NetworkProviders()
+	//
+	// Initialize the DKG subsystem in each node.
+	var dkgNodes []*dkg.Node = make([]*dkg.Node, len(peerNetIDs))
+	var registries []*testutil.DkgRegistryProvider = make([]*testutil.DkgRegistryProvider, len(peerNetIDs))
+	for i := range peerNetIDs {
+		registries[i] = testutil.NewDkgRegistryProvider(suite)
+		dkgNodes[i] = dkg.NewNode(
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registries[i],
+			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
+		)
+	}
+	//
+	// Generate the key on the first 4 nodes.
+	dkShare, err := dkgNodes[0].GenerateDistributedKey(
+		peerNetIDs[:4],
+		peerPubs[:4],
+		3,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.Nil(t, err)
+	//
+	// Reshare the key to the last 3 nodes.
+	reshared, err := dkgNodes[0].ReshareDistributedKey(
+		dkShare.Address,
+		peerNetIDs[:4],
+		peerPubs[:4],
+		peerNetIDs[2:],
+		nil, // NOTE: Should be taken from the peering node.
+		2,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.Nil(t, err)
+	require.EqualValues(t, *dkShare.Address, *reshared.Address)
+	require.True(t, dkShare.SharedPublic.Equal(reshared.SharedPublic))
+	require.EqualValues(t, 3, reshared.N)
+	require.EqualValues(t, 2, reshared.T)
+	//
+	// The nodes leaving the group have their shares retired, the shares are kept.
+	// The nodes staying in the group keep their old shares as retired too.
+	for i := 0; i < 2; i++ {
+		_, err = registries[i].LoadDKShare(dkShare.Address)
+		require.Error(t, err)
+	}
+	for i := 0; i < 5; i++ {
+		retired := 0
+		if i < 4 {
+			retired = 1
+		}
+		require.Len(t, registries[i].Retired[dkShare.Address.String()], retired)
+		require.Empty(t, registries[i].Pending)
+	}
+	//
+	// The new shares produce a valid signature of the same address.
+	data := []byte("some data to sign")
+	newShares := make([]*tcrypto.DKShare, 3)
+	for i := range newShares {
+		newShares[i], err = registries[i+2].LoadDKShare(dkShare.Address)
+		require.Nil(t, err)
+		require.EqualValues(t, i, *newShares[i].Index)
+		require.EqualValues(t, 3, newShares[i].N)
+		require.EqualValues(t, 2, newShares[i].T)
+	}
+	sigShares := make([][]byte, 0)
+	for _, i := range []int{0, 2} {
+		sigShare, err := newShares[i].SignShare(data)
+		require.Nil(t, err)
+		require.Nil(t, newShares[1].VerifySigShare(data, sigShare))
+		sigShares = append(sigShares, sigShare)
+	}
+	signature, err := newShares[1].RecoverFullSignature(sigShares, data)
+	require.Nil(t, err)
+	require.True(t, signature.IsValid(data))
+	require.EqualValues(t, *dkShare.Address, signature.Address())
+	//
+	// A node not holding the key cannot initiate the resharing.
+	_, err = dkgNodes[0].ReshareDistributedKey(
+		dkShare.Address,
+		peerNetIDs[2:],
+		nil,
+		peerNetIDs[:2],
+		nil,
+		2,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.IsType(t, dkg.InvalidParamsError{}, err)
+}
+
+// TestReshareLowN checks, if a key generated for a single node can be reshared.
+func TestReshareLowN(t *testing.T) {
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	//
+	// Create a fake network and keys for the tests.
+	var timeout = 100 * time.Second
+	var peerCount uint16 = 3
+	var peerNetIDs []string = make([]string, peerCount)
+	var peerPubs []kyber.Point = make([]kyber.Point, len(peerNetIDs))
+	var peerSecs []kyber.Scalar = make([]kyber.Scalar, len(peerNetIDs))
+	var suite = pairing.NewSuiteBn256() // NOTE: That's from the Pairing Adapter.
+	for i := range peerNetIDs {
+		peerPair := key.NewKeyPair(suite)
+		peerNetIDs[i] = fmt.Sprintf("P%02d", i)
+		peerSecs[i] = peerPair.Private
+		peerPubs[i] = peerPair.Public
+	}
+	var peeringNetwork *testutil.PeeringNetwork = testutil.NewPeeringNetwork(
+		peerNetIDs, peerPubs, peerSecs, 10000,
+		testutil.NewPeeringNetReliable(),
+		testutil.WithLevel(log, logger.LevelWarn, false),
+	)
+	var networkProviders []peering.NetworkProvider = peeringNetwork.NetworkProviders()
+	//
+	// Initialize the DKG subsystem in each node.
+	var dkgNodes []*dkg.Node = make([]*dkg.Node, len(peerNetIDs))
+	var registries []*testutil.DkgRegistryProvider = make([]*testutil.DkgRegistryProvider, len(peerNetIDs))
+	for i := range peerNetIDs {
+		registries[i] = testutil.NewDkgRegistryProvider(suite)
+		dkgNodes[i] = dkg.NewNode(
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registries[i],
+			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
+		)
+	}
+	dkShare, err := dkgNodes[0].GenerateDistributedKey(
+		peerNetIDs[:1],
+		peerPubs[:1],
+		1,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.Nil(t, err)
+	reshared, err := dkgNodes[0].ReshareDistributedKey(
+		dkShare.Address,
+		peerNetIDs[:1],
+		peerPubs[:1],
+		peerNetIDs[1:],
+		peerPubs[1:],
+		2,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.Nil(t, err)
+	require.EqualValues(t, *dkShare.Address, *reshared.Address)
+	_, err = registries[0].LoadDKShare(dkShare.Address)
+	require.Error(t, err)
+	for i := 1; i < 3; i++ {
+		newShare, err := registries[i].LoadDKShare(dkShare.Address)
+		require.Nil(t, err)
+		require.EqualValues(t, i-1, *newShare.Index)
+	}
+}
+
+// failingCommitRegistry fails to commit the reshared key share.
+type failingCommitRegistry struct {
+	*testutil.DkgRegistryProvider
+}
+
+func (r *failingCommitRegistry) SavePendingDKShare(dkShare *tcrypto.DKShare) error {
+	return fmt.Errorf("failed to commit the share of %v", dkShare.Address)
+}
+
+// TestReshareNotCommitted checks, if the old shares are kept in use, when
+// one of the new peers fails to commit its new share.
+func TestReshareNotCommitted(t *testing.T) {
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	//
+	// Create a fake network and keys for the tests.
+	var timeout = 10 * time.Second
+	var peerCount uint16 = 4
+	var peerNetIDs []string = make([]string, peerCount)
+	var peerPubs []kyber.Point = make([]kyber.Point, len(peerNetIDs))
+	var peerSecs []kyber.Scalar = make([]kyber.Scalar, len(peerNetIDs))
+	var suite = pairing.NewSuiteBn256() // NOTE: That's from the Pairing Adapter.
+	for i := range peerNetIDs {
+		peerPair := key.NewKeyPair(suite)
+		peerNetIDs[i] = fmt.Sprintf("P%02d", i)
+		peerSecs[i] = peerPair.Private
+		peerPubs[i] = peerPair.Public
+	}
+	var peeringNetwork *testutil.PeeringNetwork = testutil.NewPeeringNetwork(
+		peerNetIDs, peerPubs, peerSecs, 10000,
+		testutil.NewPeeringNetReliable(),
+		testutil.WithLevel(log, logger.LevelWarn, false),
+	)
+	var networkProviders []peering.NetworkProvider = peeringNetwork.NetworkProviders()
+	//
+	// Initialize the DKG subsystem in each node. The last node fails to commit the reshared share.
+	var dkgNodes []*dkg.Node = make([]*dkg.Node, len(peerNetIDs))
+	var registries []*testutil.DkgRegistryProvider = make([]*testutil.DkgRegistryProvider, len(peerNetIDs))
+	for i := range peerNetIDs {
+		registries[i] = testutil.NewDkgRegistryProvider(suite)
+		var registry tcrypto.RegistryProvider = registries[i]
+		if i == len(peerNetIDs)-1 {
+			registry = &failingCommitRegistry{registries[i]}
+		}
+		dkgNodes[i] = dkg.NewNode(
+			peerSecs[i], peerPubs[i], suite, networkProviders[i], testutil.NewTrustedNetworkManager(peerPubs...), registry,
+			testutil.WithLevel(log.With("NetID", peerNetIDs[i]), logger.LevelDebug, false),
+		)
+	}
+	dkShare, err := dkgNodes[0].GenerateDistributedKey(
+		peerNetIDs[:3],
+		peerPubs[:3],
+		2,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.Nil(t, err)
+	_, err = dkgNodes[0].ReshareDistributedKey(
+		dkShare.Address,
+		peerNetIDs[:3],
+		peerPubs[:3],
+		peerNetIDs[1:],
+		peerPubs[1:],
+		2,
+		1*time.Second,
+		2*time.Second,
+		timeout,
+	)
+	require.Error(t, err)
+	//
+	// The old shares are still in use, nothing is retired.
+	for i := 0; i < 3; i++ {
+		oldShare, err := registries[i].LoadDKShare(dkShare.Address)
+		require.Nil(t, err)
+		require.EqualValues(t, i, *oldShare.Index)
+		require.EqualValues(t, 3, oldShare.N)
+		require.Empty(t, registries[i].Retired)
+	}
+	_, err = registries[3].LoadDKShare(dkShare.Address)
+	require.Error(t, err)
+}
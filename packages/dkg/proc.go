@@ -54,6 +54,7 @@ type proc struct {
 	log          *logger.Logger              // A logger to use.
 	myNetID      string                      // Just to make logging easier.
 	steps        map[byte]*procStep          // All the steps for the procedure.
+	reshare      *reshareState               // Only set, if that's a resharing procedure.
 }
 
 func onInitiatorInit(dkgID *coretypes.ChainID, msg *initiatorInitMsg, node *Node) (*proc, error) {
@@ -160,7 +161,7 @@ func (p *proc) processLoop(timeout time.Duration, doneCh chan map[uint16]*peerin
 	for {
 		select {
 		case recv := <-p.peerMsgCh:
-			if isDkgInitProcRecvMsg(recv.Msg.MsgType) || isDkgRabinRoundMsg(recv.Msg.MsgType) || isDkgRabinEchoMsg(recv.Msg.MsgType) {
+			if isDkgInitProcRecvMsg(recv.Msg.MsgType) || isDkgRoundMsg(recv.Msg.MsgType) || isDkgEchoMsg(recv.Msg.MsgType) {
 				step := readDkgMessageStep(recv.Msg.MsgData)
 				if s := p.steps[step]; s != nil {
 					s.recv(recv)
@@ -715,11 +716,11 @@ func (s *procStep) run() {
 					recv.From.SendMsg(s.initResp)
 					continue
 				}
-				if isDkgRabinEchoMsg(recv.Msg.MsgType) {
+				if isDkgEchoMsg(recv.Msg.MsgType) {
 					// Do not respond to echo messages, a resend loop will be initiated otherwise.
 					continue
 				}
-				if isDkgRabinRoundMsg(recv.Msg.MsgType) {
+				if isDkgRoundMsg(recv.Msg.MsgType) {
 					// Resend the peer messages as echo messages, because we don't need the responses anymore.
 					s.sendEcho(recv)
 					continue
@@ -737,6 +738,7 @@ func (s *procStep) run() {
 						s.log.Errorf("Step failed to make round messages, reason=%v", err)
 						s.sentMsgs = make(map[uint16]*peering.PeerMessage) // No messages will be sent on error.
 						s.markDone(makePeerMessage(s.proc.dkgID, s.step, &initiatorStatusMsg{error: err}))
+						return
 					}
 					for i := range s.sentMsgs {
 						sendPeer := s.proc.netGroup.AllNodes()[i]
@@ -749,12 +751,12 @@ func (s *procStep) run() {
 				})
 				continue
 			}
-			if isDkgRabinRoundMsg(recv.Msg.MsgType) || isDkgRabinEchoMsg(recv.Msg.MsgType) {
+			if isDkgRoundMsg(recv.Msg.MsgType) || isDkgEchoMsg(recv.Msg.MsgType) {
 				// in the current step we consider echo messages as ordinary round messages,
 				// because it is possible that we have requested for them.
 				if s.recvMsgs[recv.Msg.SenderIndex] == nil {
 					s.recvMsgs[recv.Msg.SenderIndex] = recv.Msg
-				} else if s.sentMsgs != nil && isDkgRabinRoundMsg(recv.Msg.MsgType) {
+				} else if s.sentMsgs != nil && isDkgRoundMsg(recv.Msg.MsgType) {
 					// If that's a repeated message from the peer, maybe our message has been
 					// lost, so we repeat it as an echo, to avoid resend loops.
 					s.sendEcho(recv)
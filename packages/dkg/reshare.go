@@ -0,0 +1,657 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package dkg
+
+//
+// This file contains the resharing of an existing distributed key.
+// The key shares are moved from the current holders (the old peers)
+// to a possibly different set of nodes (the new peers), possibly with
+// a different threshold. The shared public key and therefore the shared
+// address stay the same. The procedure is run in the lock-step fashion
+// by the initiator, as the DKG procedure itself.
+//
+// Implementation is based on <https://github.com/dedis/kyber/blob/master/share/dkg/pedersen/dkg.go>,
+// which implements <https://www.cs.cmu.edu/~wing/publications/Wong-Wing02b.pdf>.
+//
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/peering"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	pedersen_dkg "go.dedis.ch/kyber/v3/share/dkg/pedersen"
+	"go.dedis.ch/kyber/v3/sign/bdn"
+)
+
+const (
+	reshareStep0Initialize         = byte(0)
+	reshareStep1SendDeals          = byte(1)
+	reshareStep2SendResponses      = byte(2)
+	reshareStep3SendJustifications = byte(3)
+	reshareStep4MakeShares         = byte(4)
+	reshareStep5CommitNewShares    = byte(5)
+	reshareStep6SwitchShares       = byte(6)
+)
+
+// ReshareDistributedKey takes all the required parameters from the node and initiates
+// the resharing of an existing distributed key from the old peers to the new peers.
+// The old and the new peers can overlap. The new key shares are committed as pending
+// and activated only after all the new peers have committed them. The old key shares
+// are then kept as retired in the registries of the old peers. The shared public key
+// and the address are preserved.
+// This function is executed on the initiator node, which must hold a share of the key.
+func (n *Node) ReshareDistributedKey(
+	sharedAddress *address.Address,
+	oldPeerNetIDs []string,
+	oldPeerPubs []kyber.Point,
+	newPeerNetIDs []string,
+	newPeerPubs []kyber.Point,
+	threshold uint16,
+	roundRetry time.Duration, // Retry for Peer <-> Peer communication.
+	stepRetry time.Duration, // Retry for Initiator -> Peer communication.
+	timeout time.Duration, // Timeout for the entire procedure.
+) (*tcrypto.DKShare, error) {
+	n.log.Infof(
+		"Starting new resharing procedure for %v, initiator=%v, oldPeers=%+v, newPeers=%+v",
+		sharedAddress, n.netProvider.Self().NetID(), oldPeerNetIDs, newPeerNetIDs,
+	)
+	var err error
+	var newPeerCount = uint16(len(newPeerNetIDs))
+	//
+	// Some validation for the parameters.
+	if len(oldPeerNetIDs) < 1 || util.ContainsDuplicates(oldPeerNetIDs) {
+		return nil, invalidParams(fmt.Errorf("wrong resharing parameters: invalid list of old peers %+v", oldPeerNetIDs))
+	}
+	if newPeerCount < 2 || util.ContainsDuplicates(newPeerNetIDs) {
+		return nil, invalidParams(fmt.Errorf("wrong resharing parameters: invalid list of new peers %+v", newPeerNetIDs))
+	}
+	if threshold < 2 || threshold > newPeerCount || threshold < newPeerCount/2+1 {
+		// The same rules as for the DKG, see GenerateDistributedKey.
+		return nil, invalidParams(fmt.Errorf("wrong resharing parameters: N = %d, T = %d", newPeerCount, threshold))
+	}
+	if oldPeerPubs != nil && len(oldPeerPubs) != len(oldPeerNetIDs) || newPeerPubs != nil && len(newPeerPubs) != len(newPeerNetIDs) {
+		return nil, invalidParams(errors.New("wrong resharing parameters: inconsistent NetIDs and public keys of the peers"))
+	}
+	var oldShare *tcrypto.DKShare
+	if oldShare, err = n.registry.LoadDKShare(sharedAddress); err != nil {
+		return nil, invalidParams(fmt.Errorf("the initiator must hold a share of the key %v: %v", sharedAddress, err))
+	}
+	if int(oldShare.N) != len(oldPeerNetIDs) {
+		return nil, invalidParams(fmt.Errorf("the key %v is shared by %d peers, %d old peers given", sharedAddress, oldShare.N, len(oldPeerNetIDs)))
+	}
+	//
+	// Setup network connections.
+	peerNetIDs := reshareGroupNetIDs(oldPeerNetIDs, newPeerNetIDs)
+	var netGroup peering.GroupProvider
+	if netGroup, err = n.netProvider.Group(peerNetIDs); err != nil {
+		return nil, err
+	}
+	defer netGroup.Close()
+	dkgID := coretypes.NewRandomChainID()
+	recvCh := make(chan *peering.RecvEvent, len(peerNetIDs)*2)
+	attachID := n.netProvider.Attach(&dkgID, func(recv *peering.RecvEvent) {
+		recvCh <- recv
+	})
+	defer n.netProvider.Detach(attachID)
+	rTimeout := stepRetry
+	gTimeout := timeout
+	if oldPeerPubs == nil || newPeerPubs == nil {
+		// Take the public keys from the peering network, if they were not specified.
+		groupPubs := make(map[string]kyber.Point, len(peerNetIDs))
+		for _, n := range netGroup.AllNodes() {
+			if err = n.Await(timeout); err != nil {
+				return nil, err
+			}
+			if groupPubs[n.NetID()] = n.PubKey(); groupPubs[n.NetID()] == nil {
+				return nil, fmt.Errorf("Have no public key for %v", n.NetID())
+			}
+		}
+		if oldPeerPubs == nil {
+			oldPeerPubs = make([]kyber.Point, len(oldPeerNetIDs))
+			for i := range oldPeerNetIDs {
+				oldPeerPubs[i] = groupPubs[oldPeerNetIDs[i]]
+			}
+		}
+		if newPeerPubs == nil {
+			newPeerPubs = make([]kyber.Point, len(newPeerNetIDs))
+			for i := range newPeerNetIDs {
+				newPeerPubs[i] = groupPubs[newPeerNetIDs[i]]
+			}
+		}
+	}
+	for _, peerPubs := range [][]kyber.Point{oldPeerPubs, newPeerPubs} {
+		for i := range peerPubs {
+			if err = n.isTrustedPeer(peerPubs[i]); err != nil {
+				return nil, invalidParams(err)
+			}
+		}
+	}
+	//
+	// Initialize the peers.
+	initMsg := &initiatorReshareMsg{
+		dkgRef:        dkgID.String(), // It could be some other identifier.
+		sharedAddress: sharedAddress,
+		oldNetIDs:     oldPeerNetIDs,
+		oldPubs:       oldPeerPubs,
+		newNetIDs:     newPeerNetIDs,
+		newPubs:       newPeerPubs,
+		publicCommits: reshareCommits(oldShare),
+		initiatorPub:  n.pubKey,
+		oldThreshold:  oldShare.T,
+		threshold:     threshold,
+		timeout:       timeout,
+		roundRetry:    roundRetry,
+	}
+	if err = signInitMsg(initMsg, n.secKey, n.suite); err != nil {
+		return nil, err
+	}
+	if err = n.exchangeInitiatorAcks(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, reshareStep0Initialize,
+		func(peerIdx uint16, peer peering.PeerSender) {
+			n.log.Debugf("Initiator sends step=%v command to %v", reshareStep0Initialize, peer.NetID())
+			peer.SendMsg(makePeerMessage(&dkgID, reshareStep0Initialize, initMsg))
+		},
+	); err != nil {
+		return nil, err
+	}
+	//
+	// Perform the resharing steps, each step in parallel, all steps sequentially.
+	if err = n.exchangeInitiatorStep(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, &dkgID, reshareStep1SendDeals); err != nil {
+		return nil, err
+	}
+	if err = n.exchangeInitiatorStep(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, &dkgID, reshareStep2SendResponses); err != nil {
+		return nil, err
+	}
+	if err = n.exchangeInitiatorStep(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, &dkgID, reshareStep3SendJustifications); err != nil {
+		return nil, err
+	}
+	//
+	// Now get the public keys of the new shares.
+	// The old peers, not holding the key anymore, only acknowledge the step.
+	pubShareResponses := map[uint16]*initiatorPubShareMsg{}
+	if err = n.exchangeInitiatorMsgs(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, reshareStep4MakeShares,
+		func(peerIdx uint16, peer peering.PeerSender) {
+			n.log.Debugf("Initiator sends step=%v command to %v", reshareStep4MakeShares, peer.NetID())
+			peer.SendMsg(makePeerMessage(&dkgID, reshareStep4MakeShares, &initiatorStepMsg{}))
+		},
+		func(recv *peering.RecvEvent, initMsg initiatorMsg) (bool, error) {
+			switch msg := initMsg.(type) {
+			case *initiatorPubShareMsg:
+				pubShareResponses[recv.Msg.SenderIndex] = msg
+				return true, nil
+			case *initiatorStatusMsg:
+				return true, nil
+			default:
+				n.log.Errorf("unexpected message type instead of initiatorPubShareMsg: %V", msg)
+				return false, errors.New("unexpected message type instead of initiatorPubShareMsg")
+			}
+		},
+	); err != nil {
+		return nil, err
+	}
+	publicShares := make([]kyber.Point, newPeerCount)
+	for i := range newPeerNetIDs {
+		var groupIndex uint16
+		if groupIndex, err = netGroup.PeerIndexByNetID(newPeerNetIDs[i]); err != nil {
+			return nil, err
+		}
+		pubShareResponse, ok := pubShareResponses[groupIndex]
+		if !ok {
+			return nil, fmt.Errorf("new peer %v has not generated a key share", newPeerNetIDs[i])
+		}
+		if *sharedAddress != *pubShareResponse.sharedAddress || !oldShare.SharedPublic.Equal(pubShareResponse.sharedPublic) {
+			return nil, fmt.Errorf("new peer %v has generated a share of a different key", newPeerNetIDs[i])
+		}
+		var pubShareBytes []byte
+		if pubShareBytes, err = pubShareResponse.publicShare.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		if err = bdn.Verify(n.suite, pubShareResponse.publicShare, pubShareBytes, pubShareResponse.signature); err != nil {
+			return nil, err
+		}
+		publicShares[i] = pubShareResponse.publicShare
+	}
+	n.log.Debugf("Reshared SharedAddress=%v, SharedPublic=%v", sharedAddress, oldShare.SharedPublic)
+	//
+	// Commit the new keys to persistent storage as pending. The nodes switch to the new
+	// shares and the old shares are retired only after all the new peers have committed
+	// their new shares, so a failed resharing leaves the old shares in use.
+	if err = n.exchangeInitiatorAcks(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, reshareStep5CommitNewShares,
+		func(peerIdx uint16, peer peering.PeerSender) {
+			n.log.Debugf("Initiator sends step=%v command to %v", reshareStep5CommitNewShares, peer.NetID())
+			peer.SendMsg(makePeerMessage(&dkgID, reshareStep5CommitNewShares, &initiatorDoneMsg{
+				pubShares: publicShares,
+			}))
+		},
+	); err != nil {
+		return nil, err
+	}
+	if err = n.exchangeInitiatorStep(netGroup, netGroup.AllNodes(), recvCh, rTimeout, gTimeout, &dkgID, reshareStep6SwitchShares); err != nil {
+		return nil, err
+	}
+	dkShare := tcrypto.DKShare{
+		Address:       sharedAddress,
+		N:             newPeerCount,
+		T:             threshold,
+		Index:         nil, // Not meaningful in this case.
+		SharedPublic:  oldShare.SharedPublic,
+		PublicCommits: nil, // Not meaningful in this case.
+		PublicShares:  publicShares,
+		PrivateShare:  nil, // Not meaningful in this case.
+	}
+	return &dkShare, nil
+}
+
+// reshareGroupNetIDs returns all the peers participating in the resharing:
+// the old peers followed by the new peers, which are not old peers.
+func reshareGroupNetIDs(oldNetIDs, newNetIDs []string) []string {
+	ret := make([]string, 0, len(oldNetIDs)+len(newNetIDs))
+	ret = append(ret, oldNetIDs...)
+	for _, netID := range newNetIDs {
+		if reshareIndexOf(oldNetIDs, netID) < 0 {
+			ret = append(ret, netID)
+		}
+	}
+	return ret
+}
+
+func reshareIndexOf(netIDs []string, netID string) int {
+	for i := range netIDs {
+		if netIDs[i] == netID {
+			return i
+		}
+	}
+	return -1
+}
+
+// reshareCommits returns the coefficients of the public polynomial of the key.
+// A key generated for a single node has no commits, its polynomial is a constant.
+func reshareCommits(dkShare *tcrypto.DKShare) []kyber.Point {
+	if len(dkShare.PublicCommits) == 0 {
+		return []kyber.Point{dkShare.SharedPublic}
+	}
+	return dkShare.PublicCommits
+}
+
+//
+// Stands for the resharing specific state of the DKG procedure instance.
+//
+type reshareState struct {
+	sharedAddress *address.Address
+	oldShare      *tcrypto.DKShare               // Nil, if this node does not hold a share of the key.
+	publicCommits []kyber.Point                  // Public polynomial of the key.
+	newIndex      int                            // Index of this node in the new peers, -1 if not a new peer.
+	newN          uint16                         // Number of the new peers.
+	newPeers      map[uint16]int                 // Index in the new peers by the group index.
+	impl          *pedersen_dkg.DistKeyGenerator // The cryptographic implementation to use.
+}
+
+func onInitiatorReshare(dkgID *coretypes.ChainID, msg *initiatorReshareMsg, node *Node) (*proc, error) {
+	log := node.log.With("dkgID", dkgID.String())
+	var err error
+
+	if len(msg.oldPubs) != len(msg.oldNetIDs) || len(msg.newPubs) != len(msg.newNetIDs) || len(msg.publicCommits) == 0 {
+		return nil, errors.New("inconsistent resharing parameters")
+	}
+	var netGroup peering.GroupProvider
+	if netGroup, err = node.netProvider.Group(reshareGroupNetIDs(msg.oldNetIDs, msg.newNetIDs)); err != nil {
+		return nil, err
+	}
+	var nodeIndex uint16
+	if nodeIndex, err = netGroup.PeerIndex(node.netProvider.Self()); err != nil {
+		return nil, err
+	}
+	myNetID := node.netProvider.Self().NetID()
+	reshare := reshareState{
+		sharedAddress: msg.sharedAddress,
+		publicCommits: msg.publicCommits,
+		newIndex:      reshareIndexOf(msg.newNetIDs, myNetID),
+		newN:          uint16(len(msg.newNetIDs)),
+		newPeers:      make(map[uint16]int),
+	}
+	for i := range msg.newNetIDs {
+		var groupIndex uint16
+		if groupIndex, err = netGroup.PeerIndexByNetID(msg.newNetIDs[i]); err != nil {
+			return nil, err
+		}
+		reshare.newPeers[groupIndex] = i
+	}
+	dkgConfig := pedersen_dkg.Config{
+		Suite:        node.suite,
+		Longterm:     node.secKey,
+		OldNodes:     msg.oldPubs,
+		NewNodes:     msg.newPubs,
+		Threshold:    int(msg.threshold),
+		OldThreshold: int(msg.oldThreshold),
+	}
+	if oldIndex := reshareIndexOf(msg.oldNetIDs, myNetID); oldIndex >= 0 {
+		//
+		// The old peers issue the deals for the new peers based on their current shares.
+		if reshare.oldShare, err = node.registry.LoadDKShare(msg.sharedAddress); err != nil {
+			return nil, err
+		}
+		if *reshare.oldShare.Index != uint16(oldIndex) || int(reshare.oldShare.N) != len(msg.oldNetIDs) || reshare.oldShare.T != msg.oldThreshold {
+			return nil, fmt.Errorf("the key share of %v does not correspond to the old peers", msg.sharedAddress)
+		}
+		if !reshareCommitsEqual(reshareCommits(reshare.oldShare), msg.publicCommits) {
+			return nil, fmt.Errorf("the public polynomial of %v does not correspond to the key share", msg.sharedAddress)
+		}
+		dkgConfig.Share = &pedersen_dkg.DistKeyShare{
+			Commits: msg.publicCommits,
+			Share:   &share.PriShare{I: oldIndex, V: reshare.oldShare.PrivateShare},
+		}
+	} else {
+		dkgConfig.PublicCoeffs = msg.publicCommits
+	}
+	if reshare.impl, err = pedersen_dkg.NewDistKeyHandler(&dkgConfig); err != nil {
+		return nil, err
+	}
+	p := proc{
+		dkgRef:       msg.dkgRef,
+		dkgID:        dkgID,
+		node:         node,
+		nodeIndex:    nodeIndex,
+		initiatorPub: msg.initiatorPub,
+		threshold:    msg.threshold,
+		roundRetry:   msg.roundRetry,
+		netGroup:     netGroup,
+		dkgLock:      &sync.RWMutex{},
+		peerMsgCh:    make(chan *peering.RecvEvent, len(netGroup.AllNodes())),
+		log:          log,
+		myNetID:      myNetID,
+		reshare:      &reshare,
+	}
+	p.log.Infof("Starting resharing Peer process at %v for DkgID=%v", p.myNetID, p.dkgID.String())
+	stepsStart := make(chan map[uint16]*peering.PeerMessage)
+	p.steps = make(map[byte]*procStep)
+	p.steps[reshareStep1SendDeals] = newProcStep(reshareStep1SendDeals, &p,
+		stepsStart,
+		p.reshareStep1SendDealsMakeSent,
+		p.reshareMakeAckResp,
+	)
+	p.steps[reshareStep2SendResponses] = newProcStep(reshareStep2SendResponses, &p,
+		p.steps[reshareStep1SendDeals].doneCh,
+		p.reshareStep2SendResponsesMakeSent,
+		p.reshareMakeAckResp,
+	)
+	p.steps[reshareStep3SendJustifications] = newProcStep(reshareStep3SendJustifications, &p,
+		p.steps[reshareStep2SendResponses].doneCh,
+		p.reshareStep3SendJustificationsMakeSent,
+		p.reshareMakeAckResp,
+	)
+	p.steps[reshareStep4MakeShares] = newProcStep(reshareStep4MakeShares, &p,
+		p.steps[reshareStep3SendJustifications].doneCh,
+		p.reshareStep4MakeSharesMakeSent,
+		p.reshareStep4MakeSharesMakeResp,
+	)
+	p.steps[reshareStep5CommitNewShares] = newProcStep(reshareStep5CommitNewShares, &p,
+		p.steps[reshareStep4MakeShares].doneCh,
+		p.reshareStep5CommitNewSharesMakeSent,
+		p.reshareMakeAckResp,
+	)
+	p.steps[reshareStep6SwitchShares] = newProcStep(reshareStep6SwitchShares, &p,
+		p.steps[reshareStep5CommitNewShares].doneCh,
+		p.reshareStep6SwitchSharesMakeSent,
+		p.reshareMakeAckResp,
+	)
+	go p.processLoop(msg.timeout, p.steps[reshareStep6SwitchShares].doneCh)
+	p.attachID = p.netGroup.Attach(dkgID, p.onPeerMessage)
+	stepsStart <- make(map[uint16]*peering.PeerMessage)
+	return &p, nil
+}
+
+func reshareCommitsEqual(a, b []kyber.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *proc) reshareMakeAckResp(step byte, initRecv *peering.RecvEvent, recvMsgs map[uint16]*peering.PeerMessage) (*peering.PeerMessage, error) {
+	return makePeerMessage(p.dkgID, step, &initiatorStatusMsg{error: nil}), nil
+}
+
+//
+// reshareStep1SendDeals
+//
+// The old peers send the deals to the new peers, all the other
+// messages are empty, because all the peers take part in all the rounds.
+//
+func (p *proc) reshareStep1SendDealsMakeSent(step byte, initRecv *peering.RecvEvent, prevMsgs map[uint16]*peering.PeerMessage) (map[uint16]*peering.PeerMessage, error) {
+	var err error
+	p.dkgLock.Lock()
+	var deals map[int]*pedersen_dkg.Deal
+	if deals, err = p.reshare.impl.Deals(); err != nil {
+		p.dkgLock.Unlock()
+		p.log.Errorf("Deals -> %+v", err)
+		return nil, err
+	}
+	p.dkgLock.Unlock()
+	sentMsgs := make(map[uint16]*peering.PeerMessage)
+	for i := range p.netGroup.AllNodes() {
+		if i == p.nodeIndex {
+			continue
+		}
+		var deal *pedersen_dkg.Deal
+		if newIndex, ok := p.reshare.newPeers[i]; ok {
+			deal = deals[newIndex]
+		}
+		sentMsgs[i] = makePeerMessage(p.dkgID, step, &reshareDealMsg{
+			deal: deal,
+		})
+	}
+	return sentMsgs, nil
+}
+
+//
+// reshareStep2SendResponses
+//
+func (p *proc) reshareStep2SendResponsesMakeSent(step byte, initRecv *peering.RecvEvent, prevMsgs map[uint16]*peering.PeerMessage) (map[uint16]*peering.PeerMessage, error) {
+	var err error
+	//
+	// Process the received deals and produce responses.
+	ourResponses := []*pedersen_dkg.Response{}
+	for i := range prevMsgs {
+		peerDealMsg := reshareDealMsg{}
+		if err = peerDealMsg.fromBytes(prevMsgs[i].MsgData); err != nil {
+			return nil, err
+		}
+		if peerDealMsg.deal == nil {
+			continue
+		}
+		if p.reshare.newIndex < 0 {
+			return nil, fmt.Errorf("unexpected deal from %v for a peer, which is not a new peer", i)
+		}
+		var r *pedersen_dkg.Response
+		p.dkgLock.Lock()
+		if r, err = p.reshare.impl.ProcessDeal(peerDealMsg.deal); err != nil {
+			p.dkgLock.Unlock()
+			p.log.Errorf("ProcessDeal(%v) -> %+v", i, err)
+			return nil, err
+		}
+		p.dkgLock.Unlock()
+		ourResponses = append(ourResponses, r)
+	}
+	//
+	// Produce the sent messages.
+	sentMsgs := make(map[uint16]*peering.PeerMessage)
+	for i := range prevMsgs { // Use peerIdx from the previous round.
+		sentMsgs[i] = makePeerMessage(p.dkgID, step, &reshareResponseMsg{
+			responses: ourResponses,
+		})
+	}
+	return sentMsgs, nil
+}
+
+//
+// reshareStep3SendJustifications
+//
+func (p *proc) reshareStep3SendJustificationsMakeSent(step byte, initRecv *peering.RecvEvent, prevMsgs map[uint16]*peering.PeerMessage) (map[uint16]*peering.PeerMessage, error) {
+	var err error
+	//
+	// Process the received responses and produce justifications.
+	ourJustifications := []*pedersen_dkg.Justification{}
+	for i := range prevMsgs {
+		peerResponseMsg := reshareResponseMsg{}
+		if err = peerResponseMsg.fromBytes(prevMsgs[i].MsgData); err != nil {
+			return nil, fmt.Errorf("Response: decoding failed: %v", err)
+		}
+		for _, r := range peerResponseMsg.responses {
+			var j *pedersen_dkg.Justification
+			p.dkgLock.Lock()
+			if j, err = p.reshare.impl.ProcessResponse(r); err != nil {
+				p.dkgLock.Unlock()
+				p.log.Errorf("ProcessResponse(%v) -> %+v", i, err)
+				return nil, err
+			}
+			p.dkgLock.Unlock()
+			if j != nil {
+				ourJustifications = append(ourJustifications, j)
+			}
+		}
+	}
+	//
+	// Produce the sent messages.
+	sentMsgs := make(map[uint16]*peering.PeerMessage)
+	for i := range prevMsgs { // Use peerIdx from the previous round.
+		sentMsgs[i] = makePeerMessage(p.dkgID, step, &reshareJustificationMsg{
+			justifications: ourJustifications,
+		})
+	}
+	return sentMsgs, nil
+}
+
+//
+// reshareStep4MakeShares
+//
+// Only the received justifications are processed here, no messages are sent
+// to other peers. The new shares are produced in the response to the initiator.
+//
+func (p *proc) reshareStep4MakeSharesMakeSent(step byte, initRecv *peering.RecvEvent, prevMsgs map[uint16]*peering.PeerMessage) (map[uint16]*peering.PeerMessage, error) {
+	var err error
+	if p.reshare.newIndex < 0 {
+		// The deals are only verified by the new peers.
+		return make(map[uint16]*peering.PeerMessage), nil
+	}
+	for i := range prevMsgs {
+		peerJustificationMsg := reshareJustificationMsg{}
+		if err = peerJustificationMsg.fromBytes(prevMsgs[i].MsgData, p.node.suite); err != nil {
+			return nil, fmt.Errorf("Justification: decoding failed: %v", err)
+		}
+		p.dkgLock.Lock()
+		for _, j := range peerJustificationMsg.justifications {
+			if err = p.reshare.impl.ProcessJustification(j); err != nil {
+				p.dkgLock.Unlock()
+				return nil, fmt.Errorf("Justification: processing failed: %v", err)
+			}
+		}
+		p.dkgLock.Unlock()
+	}
+	p.log.Debugf("All justifications processed.")
+	return make(map[uint16]*peering.PeerMessage), nil
+}
+func (p *proc) reshareStep4MakeSharesMakeResp(step byte, initRecv *peering.RecvEvent, recvMsgs map[uint16]*peering.PeerMessage) (*peering.PeerMessage, error) {
+	var err error
+	if p.reshare.newIndex < 0 {
+		return makePeerMessage(p.dkgID, step, &initiatorStatusMsg{error: nil}), nil
+	}
+	//
+	// Retrieve the reshared DistKeyShare.
+	p.dkgLock.Lock()
+	p.reshare.impl.SetTimeout()
+	if !p.reshare.impl.ThresholdCertified() {
+		p.dkgLock.Unlock()
+		return nil, fmt.Errorf("not enough deals certified")
+	}
+	var distKeyShare *pedersen_dkg.DistKeyShare
+	if distKeyShare, err = p.reshare.impl.DistKeyShare(); err != nil {
+		p.dkgLock.Unlock()
+		return nil, err
+	}
+	p.dkgLock.Unlock()
+	if !distKeyShare.Public().Equal(p.reshare.publicCommits[0]) {
+		return nil, errors.New("the reshared key differs from the original key")
+	}
+	//
+	// Save the needed info.
+	ownIndex := uint16(distKeyShare.PriShare().I)
+	publicShares := make([]kyber.Point, p.reshare.newN)
+	publicShares[ownIndex] = p.node.suite.Point().Mul(distKeyShare.PriShare().V, nil)
+	p.dkShare, err = tcrypto.NewDKShare(
+		ownIndex,                  // Index
+		p.reshare.newN,            // N
+		p.threshold,               // T
+		distKeyShare.Public(),     // SharedPublic
+		distKeyShare.Commits,      // PublicCommits
+		publicShares,              // PublicShares
+		distKeyShare.PriShare().V, // PrivateShare
+	)
+	if err != nil {
+		return nil, err
+	}
+	if *p.dkShare.Address != *p.reshare.sharedAddress {
+		return nil, errors.New("the reshared key has a different address")
+	}
+	p.log.Debugf("Key share reshared, shared public: %v.", p.dkShare.SharedPublic)
+	var pubShareMsg *initiatorPubShareMsg
+	if pubShareMsg, err = p.makeInitiatorPubShareMsg(step); err != nil {
+		return nil, err
+	}
+	return makePeerMessage(p.dkgID, step, pubShareMsg), nil
+}
+
+//
+// reshareStep5CommitNewShares
+//
+func (p *proc) reshareStep5CommitNewSharesMakeSent(step byte, initRecv *peering.RecvEvent, prevMsgs map[uint16]*peering.PeerMessage) (map[uint16]*peering.PeerMessage, error) {
+	var err error
+	if p.reshare.newIndex < 0 {
+		return make(map[uint16]*peering.PeerMessage), nil
+	}
+	var doneMsg = initiatorDoneMsg{}
+	if err = doneMsg.fromBytes(initRecv.Msg.MsgData, p.node.suite); err != nil {
+		p.log.Warnf("Dropping message, failed to decode: %v", initRecv)
+		return nil, err
+	}
+	if p.dkShare == nil {
+		return nil, errors.New("there is no dkShare to commit")
+	}
+	p.dkShare.PublicShares = doneMsg.pubShares // Store public shares of all the other peers.
+	// The new share is used only when all the new peers have committed their shares.
+	if err = p.node.registry.SavePendingDKShare(p.dkShare); err != nil {
+		return nil, err
+	}
+	return make(map[uint16]*peering.PeerMessage), nil
+}
+
+//
+// reshareStep6SwitchShares
+//
+func (p *proc) reshareStep6SwitchSharesMakeSent(step byte, initRecv *peering.RecvEvent, prevMsgs map[uint16]*peering.PeerMessage) (map[uint16]*peering.PeerMessage, error) {
+	if p.reshare.newIndex >= 0 {
+		// The old share of the node, if any, is retired by the activation of the new one.
+		if err := p.node.registry.ActivatePendingDKShare(p.reshare.sharedAddress); err != nil {
+			return nil, err
+		}
+		p.log.Infof("Reshared key share of %v activated.", p.reshare.sharedAddress)
+	} else if p.reshare.oldShare != nil {
+		if err := p.node.registry.RetireDKShare(p.reshare.sharedAddress); err != nil {
+			return nil, err
+		}
+		p.log.Infof("Key share of %v retired.", p.reshare.sharedAddress)
+	}
+	return make(map[uint16]*peering.PeerMessage), nil
+}
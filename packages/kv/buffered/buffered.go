@@ -12,13 +12,18 @@ import (
 
 // BufferedKVStore represents a KVStore backed by a database. Writes are cached in-memory as
 // a MutationSequence; reads are delegated to the backing database when not cached.
+// Values read from the database are cached until the mutations are cleared
 type BufferedKVStore interface {
 	kv.KVStore
 
 	// the uncommitted mutations
 	Mutations() MutationSequence
+	// ClearMutations is called after the mutations are written to the database. It also drops the read cache
 	ClearMutations()
+	// Clone copies the mutations. The clone starts with the empty read cache
 	Clone() BufferedKVStore
+	// TrackReads records all keys and prefixes read from the store to the set. Tracking is stopped with nil
+	TrackReads(reads *KeySet)
 
 	// only for testing!
 	DangerouslyDumpToDict() dict.Dict
@@ -46,20 +51,37 @@ func asDBError(e error) error {
 type bufferedKVStore struct {
 	db        kvstore.KVStore
 	mutations MutationSequence
+	// nil if reads are not cached
+	cache *readCache
+	// nil if reads are not tracked
+	reads *KeySet
 }
 
 func NewBufferedKVStore(db kvstore.KVStore) BufferedKVStore {
 	return &bufferedKVStore{
 		db:        db,
 		mutations: NewMutationSequence(),
+		cache:     newReadCache(db),
 	}
 }
 
-func (b *bufferedKVStore) Clone() BufferedKVStore {
+// NewUncachedBufferedKVStore creates the store which reads the database each time the key is not in the mutations
+func NewUncachedBufferedKVStore(db kvstore.KVStore) BufferedKVStore {
 	return &bufferedKVStore{
+		db:        db,
+		mutations: NewMutationSequence(),
+	}
+}
+
+func (b *bufferedKVStore) Clone() BufferedKVStore {
+	ret := &bufferedKVStore{
 		db:        b.db,
 		mutations: b.mutations.Clone(),
 	}
+	if b.cache != nil {
+		ret.cache = newReadCache(b.db)
+	}
+	return ret
 }
 
 func (b *bufferedKVStore) Mutations() MutationSequence {
@@ -68,6 +90,25 @@ func (b *bufferedKVStore) Mutations() MutationSequence {
 
 func (b *bufferedKVStore) ClearMutations() {
 	b.mutations = NewMutationSequence()
+	if b.cache != nil {
+		b.cache = newReadCache(b.db)
+	}
+}
+
+func (b *bufferedKVStore) TrackReads(reads *KeySet) {
+	b.reads = reads
+}
+
+func (b *bufferedKVStore) trackRead(key kv.Key) {
+	if b.reads != nil {
+		b.reads.Add(key)
+	}
+}
+
+func (b *bufferedKVStore) trackReadPrefix(prefix kv.Key) {
+	if b.reads != nil {
+		b.reads.AddPrefix(prefix)
+	}
 }
 
 // iterates over all key-value pairs in KVStore
@@ -122,11 +163,20 @@ func (b *bufferedKVStore) Del(key kv.Key) {
 	b.mutations.Add(NewMutationDel(key))
 }
 
+func (b *bufferedKVStore) DelPrefix(prefix kv.Key) {
+	b.mutations.Add(NewMutationDelPrefix(prefix))
+}
+
 func (b *bufferedKVStore) Get(key kv.Key) ([]byte, error) {
+	b.trackRead(key)
 	mut := b.mutations.Latest(key)
 	if mut != nil {
 		return mut.Value(), nil
 	}
+	if b.cache != nil {
+		v, err := b.cache.get(key)
+		return v, asDBError(err)
+	}
 	v, err := b.db.Get(kvstore.Key(key))
 	if err == kvstore.ErrKeyNotFound {
 		return nil, nil
@@ -139,10 +189,15 @@ func (b *bufferedKVStore) MustGet(key kv.Key) []byte {
 }
 
 func (b *bufferedKVStore) Has(key kv.Key) (bool, error) {
+	b.trackRead(key)
 	mut := b.mutations.Latest(key)
 	if mut != nil {
 		return mut.Value() != nil, nil
 	}
+	if b.cache != nil {
+		v, err := b.cache.has(key)
+		return v, asDBError(err)
+	}
 	v, err := b.db.Has(kvstore.Key(key))
 	return v, asDBError(err)
 }
@@ -152,14 +207,15 @@ func (b *bufferedKVStore) MustHas(key kv.Key) bool {
 }
 
 func (b *bufferedKVStore) Iterate(prefix kv.Key, f func(key kv.Key, value []byte) bool) error {
-	seen, done := b.mutations.IterateValues(prefix, f)
+	b.trackReadPrefix(prefix)
+	_, done := b.mutations.IterateValues(prefix, f)
 	if done {
 		return nil
 	}
 	return b.db.Iterate([]byte(prefix), func(key kvstore.Key, value kvstore.Value) bool {
 		k := kv.Key(key)
-		_, ok := seen[k]
-		if ok {
+		if b.mutations.Latest(k) != nil {
+			// already seen or deleted
 			return true
 		}
 		return f(k, value)
@@ -171,7 +227,8 @@ func (b *bufferedKVStore) MustIterate(prefix kv.Key, f func(key kv.Key, value []
 }
 
 func (b *bufferedKVStore) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
-	seen, done := b.mutations.IterateValues(prefix, func(key kv.Key, value []byte) bool {
+	b.trackReadPrefix(prefix)
+	_, done := b.mutations.IterateValues(prefix, func(key kv.Key, value []byte) bool {
 		return f(key)
 	})
 	if done {
@@ -179,8 +236,8 @@ func (b *bufferedKVStore) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) er
 	}
 	return b.db.IterateKeys([]byte(prefix), func(key kvstore.Key) bool {
 		k := kv.Key(key)
-		_, ok := seen[k]
-		if ok {
+		if b.mutations.Latest(k) != nil {
+			// already seen or deleted
 			return true
 		}
 		return f(k)
@@ -190,3 +247,65 @@ func (b *bufferedKVStore) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) er
 func (b *bufferedKVStore) MustIterateKeys(prefix kv.Key, f func(key kv.Key) bool) {
 	kv.MustIterateKeys(b, prefix, f)
 }
+
+// IterateRange iterates over the range in ascending order of keys.
+// The database is not assumed to be ordered, so all key/value pairs in the range are loaded into memory first
+func (b *bufferedKVStore) IterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	b.trackReadPrefix(kv.RangePrefix(from, to))
+	return IterateRangeOver(b.mutations, from, to, false, b.iterateDb(kv.RangePrefix(from, to)), f)
+}
+
+func (b *bufferedKVStore) MustIterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRange(b, from, to, f)
+}
+
+// IterateRangeReverse iterates over the range in descending order of keys
+func (b *bufferedKVStore) IterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	b.trackReadPrefix(kv.RangePrefix(from, to))
+	return IterateRangeOver(b.mutations, from, to, true, b.iterateDb(kv.RangePrefix(from, to)), f)
+}
+
+func (b *bufferedKVStore) MustIterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRangeReverse(b, from, to, f)
+}
+
+func (b *bufferedKVStore) iterateDb(prefix kv.Key) func(f func(key kv.Key, value []byte) bool) error {
+	return func(f func(key kv.Key, value []byte) bool) error {
+		err := b.db.Iterate([]byte(prefix), func(key kvstore.Key, value kvstore.Value) bool {
+			return f(kv.Key(key), value)
+		})
+		return asDBError(err)
+	}
+}
+
+// IterateRangeOver iterates in order of keys over the range [from, to) of the store with the mutations
+// applied on top of it. The store is represented by 'iterateBase', which may visit keys in any order
+// and keys outside of the range
+func IterateRangeOver(
+	mutations MutationSequence,
+	from, to kv.Key,
+	reverse bool,
+	iterateBase func(f func(key kv.Key, value []byte) bool) error,
+	f func(key kv.Key, value []byte) bool,
+) error {
+	values := dict.New()
+	err := iterateBase(func(key kv.Key, value []byte) bool {
+		if key.InRange(from, to) && mutations.Latest(key) == nil {
+			values.Set(key, value)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	mutations.IterateValues(kv.RangePrefix(from, to), func(key kv.Key, value []byte) bool {
+		if key.InRange(from, to) {
+			values.Set(key, value)
+		}
+		return true
+	})
+	if reverse {
+		return values.IterateRangeReverse(from, to, f)
+	}
+	return values.IterateRange(from, to, f)
+}
@@ -79,3 +79,120 @@ func TestBufferedKVStore(t *testing.T) {
 		m,
 	)
 }
+
+func TestBufferedKVStoreDelPrefix(t *testing.T) {
+	db := mapdb.NewMapDB()
+	_ = db.Set([]byte("a1"), []byte("v1"))
+	_ = db.Set([]byte("a2"), []byte("v2"))
+	_ = db.Set([]byte("b1"), []byte("v3"))
+
+	b := NewBufferedKVStore(db)
+	b.Set("a3", []byte("v4"))
+	b.DelPrefix("a")
+	b.Set("a2", []byte("v5"))
+
+	assert.Nil(t, b.MustGet("a1"))
+	assert.False(t, b.MustHas("a3"))
+	assert.Equal(t, []byte("v5"), b.MustGet("a2"))
+	assert.Equal(t, []byte("v3"), b.MustGet("b1"))
+
+	keys := make([]kv.Key, 0)
+	b.MustIterateKeys("a", func(key kv.Key) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.EqualValues(t, []kv.Key{"a2"}, keys)
+
+	assert.EqualValues(
+		t,
+		map[kv.Key][]byte{
+			"a2": []byte("v5"),
+			"b1": []byte("v3"),
+		},
+		b.DangerouslyDumpToDict(),
+	)
+}
+
+func TestBufferedKVStoreIterateRange(t *testing.T) {
+	db := mapdb.NewMapDB()
+	_ = db.Set([]byte("k1"), []byte("v1"))
+	_ = db.Set([]byte("k3"), []byte("v3"))
+	_ = db.Set([]byte("k5"), []byte("v5"))
+
+	b := NewBufferedKVStore(db)
+	b.Set("k2", []byte("v2"))
+	b.Set("k4", []byte("v4"))
+	b.Del("k3")
+
+	collect := func(iterate func(f func(key kv.Key, value []byte) bool)) []kv.Key {
+		keys := make([]kv.Key, 0)
+		iterate(func(key kv.Key, value []byte) bool {
+			keys = append(keys, key)
+			return true
+		})
+		return keys
+	}
+
+	keys := collect(func(f func(key kv.Key, value []byte) bool) { b.MustIterateRange("k2", "k5", f) })
+	assert.EqualValues(t, []kv.Key{"k2", "k4"}, keys)
+
+	keys = collect(func(f func(key kv.Key, value []byte) bool) { b.MustIterateRange("k", "", f) })
+	assert.EqualValues(t, []kv.Key{"k1", "k2", "k4", "k5"}, keys)
+
+	keys = collect(func(f func(key kv.Key, value []byte) bool) { b.MustIterateRangeReverse("k1", "k5", f) })
+	assert.EqualValues(t, []kv.Key{"k4", "k2", "k1"}, keys)
+
+	keys = collect(func(f func(key kv.Key, value []byte) bool) { kv.MustIterateAfter(b, "k", "k2", f) })
+	assert.EqualValues(t, []kv.Key{"k4", "k5"}, keys)
+}
+
+func TestBufferedKVStoreReadCache(t *testing.T) {
+	db := mapdb.NewMapDB()
+	_ = db.Set([]byte("a"), []byte("v1"))
+
+	b := NewBufferedKVStore(db)
+	assert.Equal(t, []byte("v1"), b.MustGet("a"))
+	assert.Nil(t, b.MustGet("b"))
+	assert.False(t, b.MustHas("c"))
+
+	// the database is not read again until the mutations are cleared
+	_ = db.Set([]byte("a"), []byte("v2"))
+	_ = db.Set([]byte("b"), []byte("v3"))
+	_ = db.Set([]byte("c"), []byte("v4"))
+	assert.Equal(t, []byte("v1"), b.MustGet("a"))
+	assert.Nil(t, b.MustGet("b"))
+	assert.False(t, b.MustHas("c"))
+
+	// the clone starts with the empty cache
+	assert.Equal(t, []byte("v2"), b.Clone().MustGet("a"))
+
+	b.ClearMutations()
+	assert.Equal(t, []byte("v2"), b.MustGet("a"))
+	assert.Equal(t, []byte("v3"), b.MustGet("b"))
+	assert.True(t, b.MustHas("c"))
+
+	u := NewUncachedBufferedKVStore(db)
+	assert.Equal(t, []byte("v2"), u.MustGet("a"))
+	_ = db.Set([]byte("a"), []byte("v5"))
+	assert.Equal(t, []byte("v5"), u.MustGet("a"))
+}
+
+func TestBufferedKVStoreTrackReads(t *testing.T) {
+	db := mapdb.NewMapDB()
+	_ = db.Set([]byte("a"), []byte("v1"))
+
+	b := NewBufferedKVStore(db)
+	b.Set("b", []byte("v2"))
+
+	reads := NewKeySet()
+	b.TrackReads(reads)
+	b.MustGet("a")
+	b.MustHas("b")
+	b.MustIterateKeys("c", func(kv.Key) bool { return true })
+	b.MustIterateRange("d1", "d5", func(kv.Key, []byte) bool { return true })
+	b.TrackReads(nil)
+	b.MustGet("e")
+
+	assert.EqualValues(t, []kv.Key{"a", "b"}, reads.Keys())
+	assert.EqualValues(t, []kv.Key{"c", "d"}, reads.Prefixes())
+}
@@ -0,0 +1,54 @@
+package buffered
+
+import (
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/kv"
+)
+
+// readCache is the layer between the BufferedKVStore and the database. It remembers the results of
+// Get and Has, which don't change while the mutations are not committed to the database.
+// The cache must be dropped each time the mutations are written to the database.
+// Iterations are not cached and always go to the database
+type readCache struct {
+	db     kvstore.KVStore
+	values map[kv.Key][]byte // nil value means the key doesn't exist in the database
+	exists map[kv.Key]bool
+}
+
+func newReadCache(db kvstore.KVStore) *readCache {
+	return &readCache{
+		db:     db,
+		values: make(map[kv.Key][]byte),
+		exists: make(map[kv.Key]bool),
+	}
+}
+
+func (c *readCache) get(key kv.Key) ([]byte, error) {
+	if v, ok := c.values[key]; ok {
+		return v, nil
+	}
+	v, err := c.db.Get(kvstore.Key(key))
+	if err == kvstore.ErrKeyNotFound {
+		c.values[key] = nil
+		c.exists[key] = false
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.values[key] = v
+	c.exists[key] = true
+	return v, nil
+}
+
+func (c *readCache) has(key kv.Key) (bool, error) {
+	if e, ok := c.exists[key]; ok {
+		return e, nil
+	}
+	e, err := c.db.Has(kvstore.Key(key))
+	if err != nil {
+		return false, err
+	}
+	c.exists[key] = e
+	return e, nil
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/iotaledger/wasp/packages/util"
 )
 
-// Mutation represents a single "set" or "del" operation over a KVStore
+// Mutation represents a single "set", "del" or "del prefix" operation over a KVStore
 type Mutation interface {
 	Read(io.Reader) error
 	Write(io.Writer) error
@@ -17,7 +17,7 @@ type Mutation interface {
 
 	ApplyTo(w kv.KVStoreWriter)
 
-	// Key returns the key that is mutated
+	// Key returns the key that is mutated, or the prefix of deleted keys
 	Key() kv.Key
 	// Value returns the value after the mutation (nil if deleted)
 	Value() []byte
@@ -37,11 +37,15 @@ type MutationSequence interface {
 
 	// Iterate over all mutations in order, even ones affecting the same key repeatedly
 	Iterate(func(mut Mutation) bool)
-	// Iterate over the latest mutation recorded for each key
+	// Iterate over the latest mutation recorded for each key which was set or deleted explicitly
 	IterateLatest(func(key kv.Key, mut Mutation) bool)
 	// Iterate over the latest value recorded for each non-deleted key
 	IterateValues(prefix kv.Key, f func(key kv.Key, value []byte) bool) (map[kv.Key]bool, bool)
+	// Iterate over prefixes deleted by the sequence, in order
+	IterateDeletedPrefixes(f func(prefix kv.Key) bool)
 
+	// Latest returns the latest mutation of the key or nil if the key is not affected by the sequence.
+	// If the key was deleted by prefix after its latest explicit mutation, the "del" mutation is returned
 	Latest(key kv.Key) Mutation
 
 	Add(mut Mutation)
@@ -52,17 +56,22 @@ type MutationSequence interface {
 const (
 	mutationMagicSet = iota
 	mutationMagicDel
+	mutationMagicDelPrefix
 )
 
 type mutationSequence struct {
-	muts        []Mutation
-	latestByKey map[kv.Key]*Mutation
+	muts []Mutation
+	// index of the latest "set" or "del" mutation of each key
+	latestByKey map[kv.Key]int
+	// indices of the "del prefix" mutations
+	delPrefixes []int
 }
 
 func NewMutationSequence() MutationSequence {
 	return &mutationSequence{
 		muts:        make([]Mutation, 0),
-		latestByKey: make(map[kv.Key]*Mutation),
+		latestByKey: make(map[kv.Key]int),
+		delPrefixes: make([]int, 0),
 	}
 }
 
@@ -124,8 +133,8 @@ func (ms *mutationSequence) Iterate(f func(mut Mutation) bool) {
 }
 
 func (ms *mutationSequence) IterateLatest(f func(kv.Key, Mutation) bool) {
-	for key, mut := range ms.latestByKey {
-		if !f(key, *mut) {
+	for key := range ms.latestByKey {
+		if !f(key, ms.Latest(key)) {
 			break
 		}
 	}
@@ -133,12 +142,12 @@ func (ms *mutationSequence) IterateLatest(f func(kv.Key, Mutation) bool) {
 
 func (ms *mutationSequence) IterateValues(prefix kv.Key, f func(key kv.Key, value []byte) bool) (map[kv.Key]bool, bool) {
 	seen := make(map[kv.Key]bool)
-	for key, mut := range ms.latestByKey {
+	for key := range ms.latestByKey {
 		if !key.HasPrefix(prefix) {
 			continue
 		}
 		seen[key] = true
-		v := (*mut).Value()
+		v := ms.Latest(key).Value()
 		if v != nil && !f(key, v) {
 			return seen, true
 		}
@@ -146,13 +155,25 @@ func (ms *mutationSequence) IterateValues(prefix kv.Key, f func(key kv.Key, valu
 	return seen, false
 }
 
+func (ms *mutationSequence) IterateDeletedPrefixes(f func(prefix kv.Key) bool) {
+	for _, i := range ms.delPrefixes {
+		if !f(ms.muts[i].Key()) {
+			break
+		}
+	}
+}
+
 func (ms *mutationSequence) Len() int {
 	return len(ms.muts)
 }
 
 func (ms *mutationSequence) Add(mut Mutation) {
 	ms.muts = append(ms.muts, mut)
-	ms.latestByKey[mut.Key()] = &mut
+	if mut.getMagic() == mutationMagicDelPrefix {
+		ms.delPrefixes = append(ms.delPrefixes, len(ms.muts)-1)
+		return
+	}
+	ms.latestByKey[mut.Key()] = len(ms.muts) - 1
 }
 
 func (ms *mutationSequence) ApplyTo(w kv.KVStoreWriter) {
@@ -162,19 +183,30 @@ func (ms *mutationSequence) ApplyTo(w kv.KVStoreWriter) {
 }
 
 func (ms *mutationSequence) Latest(key kv.Key) Mutation {
-	mut, ok := ms.latestByKey[key]
+	i, ok := ms.latestByKey[key]
+	if !ok {
+		i = -1
+	}
+	// only prefixes deleted after the latest explicit mutation matter
+	for j := len(ms.delPrefixes) - 1; j >= 0 && ms.delPrefixes[j] > i; j-- {
+		if key.HasPrefix(ms.muts[ms.delPrefixes[j]].Key()) {
+			return NewMutationDel(key)
+		}
+	}
 	if !ok {
 		return nil
 	}
-	return *mut
+	return ms.muts[i]
 }
 
 func (ms *mutationSequence) Clone() MutationSequence {
-	mapClone := make(map[kv.Key]*Mutation)
+	mapClone := make(map[kv.Key]int)
 	for k, v := range ms.latestByKey {
 		mapClone[k] = v
 	}
-	return &mutationSequence{muts: ms.muts[:], latestByKey: mapClone}
+	delPrefixesClone := make([]int, len(ms.delPrefixes))
+	copy(delPrefixesClone, ms.delPrefixes)
+	return &mutationSequence{muts: ms.muts[:], latestByKey: mapClone, delPrefixes: delPrefixesClone}
 }
 
 type mutationSet struct {
@@ -186,12 +218,18 @@ type mutationDel struct {
 	k kv.Key
 }
 
+type mutationDelPrefix struct {
+	prefix kv.Key
+}
+
 func newFromMagic(magic int) (Mutation, error) {
 	switch magic {
 	case mutationMagicSet:
 		return &mutationSet{}, nil
 	case mutationMagicDel:
 		return &mutationDel{}, nil
+	case mutationMagicDelPrefix:
+		return &mutationDelPrefix{}, nil
 	}
 	return nil, fmt.Errorf("Unknown mutation magic %d", magic)
 }
@@ -284,3 +322,40 @@ func (m *mutationDel) Value() []byte {
 func (m *mutationDel) ApplyTo(w kv.KVStoreWriter) {
 	w.Del(m.k)
 }
+
+func (m *mutationDelPrefix) getMagic() int {
+	return mutationMagicDelPrefix
+}
+
+func NewMutationDelPrefix(prefix kv.Key) *mutationDelPrefix {
+	return &mutationDelPrefix{prefix: prefix}
+}
+
+func (m *mutationDelPrefix) Write(w io.Writer) error {
+	return util.WriteBytes16(w, []byte(m.prefix))
+}
+
+func (m *mutationDelPrefix) Read(r io.Reader) error {
+	prefix, err := util.ReadBytes16(r)
+	if err != nil {
+		return err
+	}
+	m.prefix = kv.Key(prefix)
+	return nil
+}
+
+func (m *mutationDelPrefix) String() string {
+	return fmt.Sprintf("DEL_PREFIX %s", m.prefix)
+}
+
+func (m *mutationDelPrefix) Key() kv.Key {
+	return m.prefix
+}
+
+func (m *mutationDelPrefix) Value() []byte {
+	return nil
+}
+
+func (m *mutationDelPrefix) ApplyTo(w kv.KVStoreWriter) {
+	w.DelPrefix(m.prefix)
+}
@@ -51,3 +51,32 @@ func TestMutationSequenceMarshaling(t *testing.T) {
 
 	assert.EqualValues(t, util.GetHashValue(ms), util.GetHashValue(ms2))
 }
+
+func TestMutationSequenceDelPrefix(t *testing.T) {
+	ms := NewMutationSequence()
+	ms.Add(NewMutationSet("a1", []byte("v1")))
+	ms.Add(NewMutationSet("a2", []byte("v2")))
+	ms.Add(NewMutationDelPrefix("a"))
+	ms.Add(NewMutationSet("a2", []byte("v3")))
+
+	assert.Nil(t, ms.Latest("a1").Value())
+	assert.Equal(t, []byte("v3"), ms.Latest("a2").Value())
+	// not mutated explicitly, but deleted by prefix
+	assert.NotNil(t, ms.Latest("a3"))
+	assert.Nil(t, ms.Latest("b"))
+
+	var buf bytes.Buffer
+	err := ms.Write(&buf)
+	assert.NoError(t, err)
+
+	ms2 := NewMutationSequence()
+	err = ms2.Read(bytes.NewBuffer(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.EqualValues(t, util.GetHashValue(ms), util.GetHashValue(ms2))
+
+	vars := dict.New()
+	vars.Set("a4", []byte("v4"))
+	vars.Set("b", []byte("v5"))
+	ms2.ApplyTo(vars)
+	assert.EqualValues(t, dict.Dict{"a2": []byte("v3"), "b": []byte("v5")}, vars)
+}
@@ -0,0 +1,140 @@
+package buffered
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iotaledger/wasp/packages/kv"
+)
+
+// KeySet is a set of keys and key prefixes. The prefix stands for all keys starting with it
+type KeySet struct {
+	keys     map[kv.Key]struct{}
+	prefixes map[kv.Key]struct{}
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{
+		keys:     make(map[kv.Key]struct{}),
+		prefixes: make(map[kv.Key]struct{}),
+	}
+}
+
+func (s *KeySet) Add(key kv.Key) {
+	s.keys[key] = struct{}{}
+}
+
+func (s *KeySet) AddPrefix(prefix kv.Key) {
+	s.prefixes[prefix] = struct{}{}
+}
+
+// Contains returns true if the key is in the set or it starts with one of the prefixes in the set
+func (s *KeySet) Contains(key kv.Key) bool {
+	if _, ok := s.keys[key]; ok {
+		return true
+	}
+	for prefix := range s.prefixes {
+		if key.HasPrefix(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersects returns true if there is a key which belongs to both sets
+func (s *KeySet) Intersects(other *KeySet) bool {
+	for key := range s.keys {
+		if other.Contains(key) {
+			return true
+		}
+	}
+	for prefix := range s.prefixes {
+		for key := range other.keys {
+			if key.HasPrefix(prefix) {
+				return true
+			}
+		}
+		for otherPrefix := range other.prefixes {
+			if prefix.HasPrefix(otherPrefix) || otherPrefix.HasPrefix(prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *KeySet) IsEmpty() bool {
+	return len(s.keys) == 0 && len(s.prefixes) == 0
+}
+
+// Keys returns the keys of the set in ascending order
+func (s *KeySet) Keys() []kv.Key {
+	return sortedKeys(s.keys)
+}
+
+// Prefixes returns the prefixes of the set in ascending order
+func (s *KeySet) Prefixes() []kv.Key {
+	return sortedKeys(s.prefixes)
+}
+
+func (s *KeySet) String() string {
+	ret := make([]string, 0, len(s.keys)+len(s.prefixes))
+	for _, key := range s.Keys() {
+		ret = append(ret, fmt.Sprintf("%q", key))
+	}
+	for _, prefix := range s.Prefixes() {
+		ret = append(ret, fmt.Sprintf("%q*", prefix))
+	}
+	return "{" + strings.Join(ret, ", ") + "}"
+}
+
+func sortedKeys(m map[kv.Key]struct{}) []kv.Key {
+	ret := make([]kv.Key, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i] < ret[j]
+	})
+	return ret
+}
+
+// ReadWriteSet is the set of keys read and the set of keys written by one request.
+// Keys read after being written by the same request are not in the read set:
+// they don't depend on the state the request was run on
+type ReadWriteSet struct {
+	Reads  *KeySet
+	Writes *KeySet
+}
+
+func NewReadWriteSet() *ReadWriteSet {
+	return &ReadWriteSet{
+		Reads:  NewKeySet(),
+		Writes: NewKeySet(),
+	}
+}
+
+// AddWrites adds keys and prefixes mutated by the sequence to the write set
+func (rw *ReadWriteSet) AddWrites(mutations MutationSequence) {
+	mutations.IterateLatest(func(key kv.Key, _ Mutation) bool {
+		rw.Writes.Add(key)
+		return true
+	})
+	mutations.IterateDeletedPrefixes(func(prefix kv.Key) bool {
+		rw.Writes.AddPrefix(prefix)
+		return true
+	})
+}
+
+// ConflictsWith returns true if the requests can't be run on the same state in any order with the same result:
+// one of them writes a key the other one reads or writes
+func (rw *ReadWriteSet) ConflictsWith(other *ReadWriteSet) bool {
+	return rw.Writes.Intersects(other.Reads) ||
+		rw.Writes.Intersects(other.Writes) ||
+		rw.Reads.Intersects(other.Writes)
+}
+
+func (rw *ReadWriteSet) String() string {
+	return fmt.Sprintf("reads: %s, writes: %s", rw.Reads, rw.Writes)
+}
@@ -0,0 +1,69 @@
+package buffered
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySet(t *testing.T) {
+	s := NewKeySet()
+	assert.True(t, s.IsEmpty())
+	s.Add("ab")
+	s.AddPrefix("x")
+	assert.True(t, s.Contains("ab"))
+	assert.False(t, s.Contains("a"))
+	assert.True(t, s.Contains("x"))
+	assert.True(t, s.Contains("xyz"))
+	assert.Equal(t, `{"ab", "x"*}`, s.String())
+
+	other := NewKeySet()
+	other.Add("a")
+	assert.False(t, s.Intersects(other))
+	assert.False(t, other.Intersects(s))
+	other.AddPrefix("a")
+	assert.True(t, s.Intersects(other))
+	assert.True(t, other.Intersects(s))
+
+	other = NewKeySet()
+	other.AddPrefix("xy")
+	assert.True(t, s.Intersects(other))
+	assert.True(t, other.Intersects(s))
+}
+
+func TestReadWriteSetConflicts(t *testing.T) {
+	mutations := NewMutationSequence()
+	mutations.Add(NewMutationSet("a", []byte{1}))
+	mutations.Add(NewMutationDel("b"))
+	mutations.Add(NewMutationDelPrefix("c"))
+
+	rw1 := NewReadWriteSet()
+	rw1.Reads.Add("r")
+	rw1.AddWrites(mutations)
+	assert.EqualValues(t, []kv.Key{"a", "b"}, rw1.Writes.Keys())
+	assert.EqualValues(t, []kv.Key{"c"}, rw1.Writes.Prefixes())
+
+	// reads of the same key don't conflict
+	rw2 := NewReadWriteSet()
+	rw2.Reads.Add("r")
+	rw2.Writes.Add("s")
+	assert.False(t, rw1.ConflictsWith(rw2))
+	assert.False(t, rw2.ConflictsWith(rw1))
+
+	// read of the key written by the other request
+	rw2.Reads.Add("c1")
+	assert.True(t, rw1.ConflictsWith(rw2))
+	assert.True(t, rw2.ConflictsWith(rw1))
+
+	// write of the key read by the other request
+	rw3 := NewReadWriteSet()
+	rw3.Writes.Add("r")
+	assert.True(t, rw1.ConflictsWith(rw3))
+	assert.True(t, rw3.ConflictsWith(rw1))
+
+	// write of the same key
+	rw4 := NewReadWriteSet()
+	rw4.Writes.Add("a")
+	assert.True(t, rw1.ConflictsWith(rw4))
+}
@@ -60,12 +60,16 @@ func (a *ImmutableArray) getElemKey(idx uint16) kv.Key {
 
 func ArrayElemKey(name string, idx uint16) kv.Key {
 	var buf bytes.Buffer
-	buf.Write([]byte(name))
-	buf.WriteByte(arrayElemKeyCode)
+	buf.Write([]byte(arrayElemPrefix(name)))
 	_ = util.WriteUint16(&buf, idx)
 	return kv.Key(buf.Bytes())
 }
 
+// arrayElemPrefix is the common prefix of the keys of all array elements
+func arrayElemPrefix(name string) kv.Key {
+	return kv.Key(name) + kv.Key([]byte{arrayElemKeyCode})
+}
+
 // ArrayRangeKeys returns the KVStore keys for the items between [from, to) (`to` being not inclusive),
 // assuming it has `length` elements.
 func ArrayRangeKeys(name string, length uint16, from uint16, to uint16) []kv.Key {
@@ -155,15 +159,9 @@ func (a *Array) MustExtend(other *ImmutableArray) {
 	}
 }
 
-// TODO implement with DelPrefix
+// Erase deletes all elements of the array
 func (a *Array) Erase() error {
-	n, err := a.Len()
-	if err != nil {
-		return err
-	}
-	for i := uint16(0); i < n; i++ {
-		a.kvw.Del(a.getElemKey(i))
-	}
+	a.kvw.DelPrefix(arrayElemPrefix(a.name))
 	a.setSize(0)
 	return nil
 }
@@ -43,6 +43,20 @@ func TestBasicArray(t *testing.T) {
 	assert.EqualValues(t, arr.MustLen()+1, arr2.MustLen())
 }
 
+func TestArrayErase(t *testing.T) {
+	vars := dict.New()
+	arr := NewArray(vars, "testArray")
+	for i := 0; i < 10; i++ {
+		arr.MustPush([]byte("datum"))
+	}
+	arr.MustErase()
+	assert.EqualValues(t, 0, arr.MustLen())
+	assert.EqualValues(t, 0, len(vars))
+
+	arr.MustPush([]byte("datum"))
+	assert.EqualValues(t, 1, arr.MustLen())
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	vars := dict.New()
 	a1 := NewArray(vars, "test")
@@ -101,6 +101,21 @@ func TestIterate(t *testing.T) {
 	})
 }
 
+func TestMapErase(t *testing.T) {
+	vars := dict.New()
+	m := NewMap(vars, "testMap")
+	other := NewMap(vars, "otherMap")
+	for _, key := range []string{"k1", "k2", "k3"} {
+		m.MustSetAt([]byte(key), []byte("v"))
+		other.MustSetAt([]byte(key), []byte("v"))
+	}
+	m.Erase()
+	assert.Zero(t, m.MustLen())
+	assert.Nil(t, m.MustGetAt([]byte("k1")))
+	assert.EqualValues(t, 3, other.MustLen())
+	assert.EqualValues(t, 4, len(vars))
+}
+
 func TestMapConcurrentAccess(t *testing.T) {
 	vars := dict.New()
 	m1 := NewMap(vars, "testMap")
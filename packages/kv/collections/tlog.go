@@ -463,10 +463,10 @@ func (l *ImmutableTimestampedLog) findUpperIdx(ts int64, fromIdx, toIdx uint32)
 	return l.findUpperIdx(ts, fromIdx, middleIdx)
 }
 
-// TODO not finished with Erase
-
+// Erase deletes all records of the log
 func (l *TimestampedLog) Erase() {
-	panic("implement me")
+	l.kvw.DelPrefix(l.name + kv.Key([]byte{tslElemKeyCode}))
+	l.setSize(0)
 }
 
 func (sl *TimeSlice) FromToIndices() (uint32, uint32) {
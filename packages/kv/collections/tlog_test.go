@@ -109,3 +109,19 @@ func TestTlogBig(t *testing.T) {
 	assert.EqualValues(t, tl.MustLen(), tslice.NumPoints())
 	assert.EqualValues(t, tl.MustLen(), tslice.NumPoints())
 }
+
+func TestTlogErase(t *testing.T) {
+	vars := dict.New()
+	tl := NewTimestampedLog(vars, "testTlog")
+	nowis := time.Now().UnixNano()
+	for i := 0; i < 10; i++ {
+		tl.MustAppend(nowis+int64(i), []byte("datum"))
+	}
+	tl.Erase()
+	assert.Zero(t, tl.MustLen())
+	assert.Zero(t, len(vars))
+
+	// timestamps are not required to be consistent with the erased records
+	tl.MustAppend(nowis, []byte("datum"))
+	assert.EqualValues(t, 1, tl.MustLen())
+}
@@ -40,6 +40,16 @@ func (d Dict) MustIterateKeys(prefix kv.Key, f func(key kv.Key) bool) {
 	kv.MustIterateKeys(d, prefix, f)
 }
 
+// MustIterateRange iterates over key/value pairs in the range in ascending order of keys
+func (d Dict) MustIterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRange(d, from, to, f)
+}
+
+// MustIterateRangeReverse iterates over key/value pairs in the range in descending order of keys
+func (d Dict) MustIterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRangeReverse(d, from, to, f)
+}
+
 // New creates new
 func New() Dict {
 	return make(Dict)
@@ -146,6 +156,15 @@ func (d Dict) Del(key kv.Key) {
 	delete(d, key)
 }
 
+// DelPrefix removes all key/value pairs with the prefix
+func (d Dict) DelPrefix(prefix kv.Key) {
+	for k := range d {
+		if k.HasPrefix(prefix) {
+			delete(d, k)
+		}
+	}
+}
+
 // Has checks if key exist
 func (d Dict) Has(key kv.Key) (bool, error) {
 	_, ok := d[key]
@@ -178,6 +197,42 @@ func (d Dict) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
 	return nil
 }
 
+// IterateRange iterates over keys in the range [from, to) in ascending order
+func (d Dict) IterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	keys := d.rangeKeys(from, to)
+	for _, k := range keys {
+		if !f(k, d[k]) {
+			break
+		}
+	}
+	return nil
+}
+
+// IterateRangeReverse iterates over keys in the range [from, to) in descending order
+func (d Dict) IterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	keys := d.rangeKeys(from, to)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !f(keys[i], d[keys[i]]) {
+			break
+		}
+	}
+	return nil
+}
+
+// rangeKeys returns sorted keys in the range [from, to)
+func (d Dict) rangeKeys(from, to kv.Key) []kv.Key {
+	keys := make([]kv.Key, 0)
+	for k := range d {
+		if k.InRange(from, to) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
 // Get takes a value. Returns nil if key does not exist
 func (d Dict) Get(key kv.Key) ([]byte, error) {
 	return d[key], nil
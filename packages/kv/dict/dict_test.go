@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/stretchr/testify/assert"
 )
@@ -128,3 +129,26 @@ func TestJSONMarshaling(t *testing.T) {
 
 	assert.EqualValues(t, util.GetHashValue(vars1), util.GetHashValue(vars2))
 }
+
+func TestIterateRange(t *testing.T) {
+	vars := New()
+	for _, k := range []kv.Key{"k3", "k1", "k5", "k2", "x"} {
+		vars.Set(k, []byte(k))
+	}
+	keys := make([]kv.Key, 0)
+	vars.MustIterateRange("k2", "k5", func(key kv.Key, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.EqualValues(t, []kv.Key{"k2", "k3"}, keys)
+
+	keys = keys[:0]
+	vars.MustIterateRangeReverse("k", kv.PrefixEnd("k"), func(key kv.Key, value []byte) bool {
+		keys = append(keys, key)
+		return len(keys) < 3
+	})
+	assert.EqualValues(t, []kv.Key{"k5", "k3", "k2"}, keys)
+
+	vars.DelPrefix("k")
+	assert.EqualValues(t, []kv.Key{"x"}, vars.Keys())
+}
@@ -14,6 +14,33 @@ func (k Key) HasPrefix(prefix Key) bool {
 	return k[:len(prefix)] == prefix
 }
 
+// InRange returns true if the key belongs to the range [from, to). Empty 'to' means the range is unbounded
+func (k Key) InRange(from, to Key) bool {
+	return k >= from && (to == "" || k < to)
+}
+
+// PrefixEnd returns the smallest key which is greater than any key with the prefix,
+// so [prefix, PrefixEnd(prefix)) is the range of all keys with the prefix.
+// Returns empty key (unbounded) if there is no such key
+func PrefixEnd(prefix Key) Key {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			return prefix[:i] + Key([]byte{prefix[i] + 1})
+		}
+	}
+	return ""
+}
+
+// RangePrefix returns the longest prefix shared by all keys in the range [from, to).
+// It allows to narrow iteration over the range in stores which can only be iterated by prefix
+func RangePrefix(from, to Key) Key {
+	i := 0
+	for i < len(from) && i < len(to) && from[i] == to[i] {
+		i++
+	}
+	return from[:i]
+}
+
 // KVStore represents a key-value store
 // where both keys and values are arbitrary byte slices.
 type KVStore interface {
@@ -27,22 +54,26 @@ type KVStoreReader interface {
 	Has(key Key) (bool, error)
 	Iterate(prefix Key, f func(key Key, value []byte) bool) error
 	IterateKeys(prefix Key, f func(key Key) bool) error
+	// IterateRange iterates over the keys in the range [from, to) in ascending order of keys.
+	// Empty 'to' means the range is unbounded
+	IterateRange(from, to Key, f func(key Key, value []byte) bool) error
+	// IterateRangeReverse iterates over the keys in the range [from, to) in descending order of keys
+	IterateRangeReverse(from, to Key, f func(key Key, value []byte) bool) error
 
 	// MustGet returns the value, or nil if not found
 	MustGet(key Key) []byte
 	MustHas(key Key) bool
 	MustIterate(prefix Key, f func(key Key, value []byte) bool)
 	MustIterateKeys(prefix Key, f func(key Key) bool)
+	MustIterateRange(from, to Key, f func(key Key, value []byte) bool)
+	MustIterateRangeReverse(from, to Key, f func(key Key, value []byte) bool)
 }
 
 type KVStoreWriter interface {
 	Set(key Key, value []byte)
 	Del(key Key)
-
-	// TODO add DelPrefix(prefix []byte)
-	// deletes all keys with the prefix. Currently we don't have a possibility to iterate over keys
-	// and maybe we do not need one in the sandbox. However we need a possibility to efficiently clear arrays,
-	// dictionaries and timestamped logs
+	// DelPrefix deletes all keys with the prefix
+	DelPrefix(prefix Key)
 }
 
 func MustGet(kvs KVStore, key Key) []byte {
@@ -74,3 +105,35 @@ func MustIterateKeys(kvs KVStore, prefix Key, f func(key Key) bool) {
 		panic(err)
 	}
 }
+
+func MustIterateRange(kvs KVStoreReader, from, to Key, f func(key Key, value []byte) bool) {
+	err := kvs.IterateRange(from, to, f)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func MustIterateRangeReverse(kvs KVStoreReader, from, to Key, f func(key Key, value []byte) bool) {
+	err := kvs.IterateRangeReverse(from, to, f)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// IterateAfter iterates in ascending order over the keys with the prefix which are greater than 'after'.
+// It allows to resume the iteration after the last key seen. Empty 'after' means from the first key
+func IterateAfter(kvs KVStoreReader, prefix, after Key, f func(key Key, value []byte) bool) error {
+	from := prefix
+	if after != "" && after >= from {
+		// the smallest key greater than 'after'
+		from = after + "\x00"
+	}
+	return kvs.IterateRange(from, PrefixEnd(prefix), f)
+}
+
+func MustIterateAfter(kvs KVStoreReader, prefix, after Key, f func(key Key, value []byte) bool) {
+	err := IterateAfter(kvs, prefix, after, f)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -227,6 +227,28 @@ func (p *decoder) MustGetColor(key kv.Key, def ...balance.Color) balance.Color {
 	return ret
 }
 
+func (p *decoder) GetRequestID(key kv.Key, def ...coretypes.RequestID) (coretypes.RequestID, error) {
+	v, exists, err := codec.DecodeRequestID(p.kv.MustGet(key))
+	if err != nil {
+		return coretypes.RequestID{}, fmt.Errorf("GetRequestID: decoding parameter '%s': %v", key, err)
+	}
+	if exists {
+		return v, nil
+	}
+	if len(def) == 0 {
+		return coretypes.RequestID{}, fmt.Errorf("GetRequestID: mandatory parameter '%s' does not exist", key)
+	}
+	return def[0], nil
+}
+
+func (p *decoder) MustGetRequestID(key kv.Key, def ...coretypes.RequestID) coretypes.RequestID {
+	ret, err := p.GetRequestID(key, def...)
+	if err != nil {
+		p.panic(err)
+	}
+	return ret
+}
+
 // nil means does not exist
 func (p *decoder) GetBytes(key kv.Key, def ...[]byte) ([]byte, error) {
 	v := p.kv.MustGet(key)
@@ -21,6 +21,10 @@ func (s *subrealm) Del(key kv.Key) {
 	s.kv.Del(s.prefix + key)
 }
 
+func (s *subrealm) DelPrefix(prefix kv.Key) {
+	s.kv.DelPrefix(s.prefix + prefix)
+}
+
 // Get returns the value, or nil if not found
 func (s *subrealm) Get(key kv.Key) ([]byte, error) {
 	return s.kv.Get(s.prefix + key)
@@ -42,6 +46,29 @@ func (s *subrealm) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
 	})
 }
 
+func (s *subrealm) IterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	from, to = s.rangeOfRealm(from, to)
+	return s.kv.IterateRange(from, to, func(key kv.Key, value []byte) bool {
+		return f(key[len(s.prefix):], value)
+	})
+}
+
+func (s *subrealm) IterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	from, to = s.rangeOfRealm(from, to)
+	return s.kv.IterateRangeReverse(from, to, func(key kv.Key, value []byte) bool {
+		return f(key[len(s.prefix):], value)
+	})
+}
+
+// rangeOfRealm maps the range of the subrealm to the range of the underlying store.
+// The unbounded range is limited by the end of the subrealm
+func (s *subrealm) rangeOfRealm(from, to kv.Key) (kv.Key, kv.Key) {
+	if to == "" {
+		return s.prefix + from, kv.PrefixEnd(s.prefix)
+	}
+	return s.prefix + from, s.prefix + to
+}
+
 func (s *subrealm) MustGet(key kv.Key) []byte {
 	return kv.MustGet(s, key)
 }
@@ -57,3 +84,11 @@ func (s *subrealm) MustIterate(prefix kv.Key, f func(key kv.Key, value []byte) b
 func (s *subrealm) MustIterateKeys(prefix kv.Key, f func(key kv.Key) bool) {
 	kv.MustIterateKeys(s, prefix, f)
 }
+
+func (s *subrealm) MustIterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRange(s, from, to, f)
+}
+
+func (s *subrealm) MustIterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRangeReverse(s, from, to, f)
+}
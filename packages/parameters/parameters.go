@@ -15,6 +15,9 @@ const (
 
 	DatabaseDir      = "database.directory"
 	DatabaseInMemory = "database.inMemory"
+	DatabaseEngine   = "database.engine"
+
+	DatabaseCompactionInterval = "database.compactionInterval"
 
 	WebAPIBindAddress    = "webapi.bindAddress"
 	WebAPIAdminWhitelist = "webapi.adminWhitelist"
@@ -30,6 +33,14 @@ const (
 	PeeringPort    = "peering.port"
 
 	NanomsgPublisherPort = "nanomsg.port"
+
+	BlobFetcherHTTPSources = "blobfetcher.http"
+	BlobFetcherDirectory   = "blobfetcher.directory"
+	BlobFetcherDeadline    = "blobfetcher.deadline"
+
+	StatePruningKeepBlocks = "state.pruningKeepBlocks"
+
+	VMParallelism = "vm.parallelism"
 )
 
 func InitFlags() {
@@ -42,6 +53,8 @@ func InitFlags() {
 
 	flag.String(DatabaseDir, "waspdb", "path to the database folder")
 	flag.Bool(DatabaseInMemory, false, "whether the database is only kept in memory and not persisted")
+	flag.String(DatabaseEngine, "badger", "storage engine of the database: badger or bolt")
+	flag.Int(DatabaseCompactionInterval, 24, "hours between full compactions of the database. 0 disables the compaction")
 
 	flag.String(WebAPIBindAddress, "127.0.0.1:8080", "the bind address for the web API")
 	flag.StringSlice(WebAPIAdminWhitelist, []string{}, "IP whitelist for /adm wndpoints")
@@ -57,6 +70,14 @@ func InitFlags() {
 	flag.String(PeeringMyNetId, "127.0.0.1:4000", "node host address as it is recognized by other peers")
 
 	flag.Int(NanomsgPublisherPort, 5550, "the port for nanomsg even publisher")
+
+	flag.StringSlice(BlobFetcherHTTPSources, []string{}, "web API URLs of Wasp nodes to download missing blobs from")
+	flag.String(BlobFetcherDirectory, "", "content-addressed directory to take missing blobs from")
+	flag.Int(BlobFetcherDeadline, 300, "seconds to solidify arguments of the request before it is rejected")
+
+	flag.Int(StatePruningKeepBlocks, -1, "number of blocks to keep before the latest state snapshot, older blocks are pruned. -1 disables pruning")
+
+	flag.Int(VMParallelism, 0, "number of requests of the batch run by the VM in parallel. 0 means the number of CPUs, 1 runs requests one by one. Only calls to native contracts are run in parallel")
 }
 
 func GetBool(name string) bool {
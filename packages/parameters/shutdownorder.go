@@ -8,4 +8,5 @@ const (
 	PriorityDispatcher
 	PriorityWebAPI
 	PriorityBadgerGarbageCollection
+	PriorityDatabaseCompaction
 )
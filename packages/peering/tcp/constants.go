@@ -14,4 +14,6 @@ const (
 	dialTimeout  = 1 * time.Second
 	dialRetries  = 10
 	backoffDelay = 500 * time.Millisecond
+
+	handshakeNonceSize = 32
 )
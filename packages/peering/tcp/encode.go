@@ -11,6 +11,7 @@ import (
 	"github.com/iotaledger/wasp/packages/peering"
 	"github.com/iotaledger/wasp/packages/util"
 	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/bls"
 )
 
 // structure of the encoded PeerMessage:
@@ -103,29 +104,72 @@ func decodeMessage(data []byte) (*peering.PeerMessage, error) {
 	}
 }
 
+// handshakeMsg is signed by the sender. The nonce is a random challenge
+// generated by the sender for the connection, and the peerNonce is the
+// challenge of the receiver, if it is already known. Because each side
+// has to sign the challenge of the other, a handshake recorded on one
+// connection cannot be replayed on another.
 type handshakeMsg struct {
 	peeringID string      // Pair of peer NetIDs
 	srcNetID  string      // Their NetID
 	pubKey    kyber.Point // Our PubKey.
+	nonce     []byte      // Our challenge for the connection.
+	peerNonce []byte      // Their challenge, nil in the first message.
 }
 
-func (m *handshakeMsg) bytes() ([]byte, error) {
+func (m *handshakeMsg) bytes(secKey kyber.Scalar, suite Suite) ([]byte, error) {
 	var err error
-	var buf bytes.Buffer
-	if err = util.WriteString16(&buf, m.peeringID); err != nil {
+	//
+	// Payload.
+	var payloadBuf bytes.Buffer
+	if err = util.WriteString16(&payloadBuf, m.peeringID); err != nil {
+		return nil, err
+	}
+	if err = util.WriteString16(&payloadBuf, m.srcNetID); err != nil {
+		return nil, err
+	}
+	if err = util.WriteMarshaled(&payloadBuf, m.pubKey); err != nil {
+		return nil, err
+	}
+	if err = util.WriteBytes16(&payloadBuf, m.nonce); err != nil {
+		return nil, err
+	}
+	if err = util.WriteBytes16(&payloadBuf, m.peerNonce); err != nil {
+		return nil, err
+	}
+	var payload = payloadBuf.Bytes()
+	var signature []byte
+	if signature, err = bls.Sign(suite, secKey, payload); err != nil {
 		return nil, err
 	}
-	if err = util.WriteString16(&buf, m.srcNetID); err != nil {
+	//
+	// Signed frame.
+	var signedBuf bytes.Buffer
+	if err = util.WriteBytes16(&signedBuf, signature); err != nil {
 		return nil, err
 	}
-	if err = util.WriteMarshaled(&buf, m.pubKey); err != nil {
+	if err = util.WriteBytes16(&signedBuf, payload); err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	return signedBuf.Bytes(), nil
 }
-func handshakeMsgFromBytes(buf []byte, suite kyber.Group) (*handshakeMsg, error) {
+
+func handshakeMsgFromBytes(buf []byte, suite Suite) (*handshakeMsg, error) {
 	var err error
-	r := bytes.NewReader(buf)
+	//
+	// Signed frame.
+	rSigned := bytes.NewReader(buf)
+	var payload []byte
+	var signature []byte
+	if signature, err = util.ReadBytes16(rSigned); err != nil {
+		return nil, err
+	}
+	if payload, err = util.ReadBytes16(rSigned); err != nil {
+		return nil, err
+	}
+	//
+	// Payload.
+	r := bytes.NewReader(payload)
 	m := handshakeMsg{}
 	if m.peeringID, err = util.ReadString16(r); err != nil {
 		return nil, err
@@ -137,5 +181,16 @@ func handshakeMsgFromBytes(buf []byte, suite kyber.Group) (*handshakeMsg, error)
 	if err = util.ReadMarshaled(r, m.pubKey); err != nil {
 		return nil, err
 	}
+	if m.nonce, err = util.ReadBytes16(r); err != nil {
+		return nil, err
+	}
+	if m.peerNonce, err = util.ReadBytes16(r); err != nil {
+		return nil, err
+	}
+	//
+	// Verify the signature.
+	if err = bls.Verify(suite, m.pubKey, payload, signature); err != nil {
+		return nil, err
+	}
 	return &m, nil
 }
@@ -0,0 +1,47 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package tcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/pairing"
+	"go.dedis.ch/kyber/v3/util/key"
+)
+
+func TestHandshakeCodec(t *testing.T) {
+	var err error
+	suite := pairing.NewSuiteBn256()
+	pair := key.NewKeyPair(suite)
+	a := handshakeMsg{
+		peeringID: "a<b",
+		srcNetID:  "a",
+		pubKey:    pair.Public,
+		nonce:     []byte{1, 2, 3},
+		peerNonce: []byte{4, 5, 6},
+	}
+	var buf []byte
+	buf, err = a.bytes(pair.Private, suite)
+	require.Nil(t, err)
+	require.NotNil(t, buf)
+	//
+	// Correct message.
+	var b *handshakeMsg
+	b, err = handshakeMsgFromBytes(buf, suite)
+	require.Nil(t, err)
+	require.NotNil(t, b)
+	require.Equal(t, a.peeringID, b.peeringID)
+	require.Equal(t, a.srcNetID, b.srcNetID)
+	require.True(t, a.pubKey.Equal(b.pubKey))
+	require.Equal(t, a.nonce, b.nonce)
+	require.Equal(t, a.peerNonce, b.peerNonce)
+	//
+	// Damaged message.
+	buf[len(buf)-1] = buf[len(buf)-1] + 1
+	var c *handshakeMsg
+	c, err = handshakeMsgFromBytes(buf, suite)
+	require.NotNil(t, err)
+	require.Nil(t, c)
+}
@@ -27,13 +27,23 @@ type NetImpl struct {
 	events     *events.Event
 
 	nodeKeyPair *key.Pair
-	suite       kyber.Group
+	trusted     peering.TrustedNetworkManager // Only the trusted peers are connected.
+	suite       Suite
 	log         *logger.Logger
 }
 
 // NewNetworkProvider is a constructor for the TCP based
-// peering network implementation.
-func NewNetworkProvider(myNetID string, port int, nodeKeyPair *key.Pair, suite kyber.Group, log *logger.Logger) (*NetImpl, error) {
+// peering network implementation. Handshakes of the peers
+// not trusted by the trusted network manager are refused,
+// and the connections of the peers distrusted later are closed.
+func NewNetworkProvider(
+	myNetID string,
+	port int,
+	nodeKeyPair *key.Pair,
+	trusted peering.TrustedNetworkManager,
+	suite Suite,
+	log *logger.Logger,
+) (*NetImpl, error) {
 	if err := peering.CheckMyNetID(myNetID, port); err != nil {
 		// can't continue because NetID parameter is not correct
 		log.Panicf("checkMyNetworkID: '%v'. || Check the 'netid' parameter in config.json", err)
@@ -45,6 +55,7 @@ func NewNetworkProvider(myNetID string, port int, nodeKeyPair *key.Pair, suite k
 		peers:       make(map[string]*peer),
 		peersMutex:  &sync.RWMutex{},
 		nodeKeyPair: nodeKeyPair,
+		trusted:     trusted,
 		suite:       suite,
 		log:         log,
 	}
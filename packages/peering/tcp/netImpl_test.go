@@ -26,9 +26,11 @@ func TestBasic(t *testing.T) {
 	chain2 := coretypes.NewRandomChainID()
 	netIDs := []string{"localhost:9017", "localhost:9018", "localhost:9019"}
 	nodes := make([]peering.NetworkProvider, len(netIDs))
-	nodes[0], err0 = tcp.NewNetworkProvider(netIDs[0], 9017, key.NewKeyPair(suite), suite, log.Named("node0"))
-	nodes[1], err1 = tcp.NewNetworkProvider(netIDs[1], 9018, key.NewKeyPair(suite), suite, log.Named("node1"))
-	nodes[2], err2 = tcp.NewNetworkProvider(netIDs[2], 9019, key.NewKeyPair(suite), suite, log.Named("node2"))
+	nodeKeys := []*key.Pair{key.NewKeyPair(suite), key.NewKeyPair(suite), key.NewKeyPair(suite)}
+	trusted := testutil.NewTrustedNetworkManager(nodeKeys[0].Public, nodeKeys[1].Public, nodeKeys[2].Public)
+	nodes[0], err0 = tcp.NewNetworkProvider(netIDs[0], 9017, nodeKeys[0], trusted, suite, log.Named("node0"))
+	nodes[1], err1 = tcp.NewNetworkProvider(netIDs[1], 9018, nodeKeys[1], trusted, suite, log.Named("node1"))
+	nodes[2], err2 = tcp.NewNetworkProvider(netIDs[2], 9019, nodeKeys[2], trusted, suite, log.Named("node2"))
 	require.Nil(t, err0)
 	require.Nil(t, err1)
 	require.Nil(t, err2)
@@ -54,3 +56,50 @@ func TestBasic(t *testing.T) {
 
 	<-doneCh
 }
+
+func TestDistrustConnected(t *testing.T) {
+	suite := pairing.NewSuiteBn256()
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	recvCh := make(chan bool, 10)
+	chainID := coretypes.NewRandomChainID()
+	netIDs := []string{"localhost:9037", "localhost:9038"}
+	nodeKeys := []*key.Pair{key.NewKeyPair(suite), key.NewKeyPair(suite)}
+	trusted0 := testutil.NewTrustedNetworkManager(nodeKeys[1].Public)
+	trusted1 := testutil.NewTrustedNetworkManager(nodeKeys[0].Public)
+	node0, err := tcp.NewNetworkProvider(netIDs[0], 9037, nodeKeys[0], trusted0, suite, log.Named("node0"))
+	require.Nil(t, err)
+	node1, err := tcp.NewNetworkProvider(netIDs[1], 9038, nodeKeys[1], trusted1, suite, log.Named("node1"))
+	require.Nil(t, err)
+	go node0.Run(make(<-chan struct{}))
+	go node1.Run(make(<-chan struct{}))
+	node0.Attach(nil, func(recv *peering.RecvEvent) {
+		recvCh <- true
+	})
+	n0p1, err := node0.PeerByNetID(netIDs[1])
+	require.Nil(t, err)
+	n1p0, err := node1.PeerByNetID(netIDs[0])
+	require.Nil(t, err)
+	require.Nil(t, n0p1.Await(5*time.Second))
+	require.Nil(t, n1p0.Await(5*time.Second))
+	require.True(t, n0p1.PubKey().Equal(nodeKeys[1].Public))
+	require.True(t, n1p0.PubKey().Equal(nodeKeys[0].Public))
+
+	n1p0.SendMsg(&peering.PeerMessage{ChainID: chainID, MsgType: 125})
+	select {
+	case <-recvCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message from the trusted peer not received")
+	}
+	//
+	// Messages are dropped and the connection is closed after the peer is distrusted.
+	_, err = trusted0.DistrustPeer(nodeKeys[1].Public)
+	require.Nil(t, err)
+	n1p0.SendMsg(&peering.PeerMessage{ChainID: chainID, MsgType: 125})
+	select {
+	case <-recvCh:
+		t.Fatal("message from the distrusted peer received")
+	case <-time.After(time.Second):
+	}
+	require.False(t, n0p1.IsAlive())
+}
@@ -176,7 +176,7 @@ func (p *peer) runOutbound() {
 		return
 	}
 	p.peerconn = newPeeredConnection(conn, p.net, p)
-	if err := p.sendHandshake(); err != nil {
+	if err := p.peerconn.sendHandshake(p.peeringID(), nil); err != nil {
 		log.Errorf("error during sendHandshake: %v", err)
 		return
 	}
@@ -186,27 +186,6 @@ func (p *peer) runOutbound() {
 	p.closeConn()
 }
 
-// sends handshake message. It contains myNetID
-func (p *peer) sendHandshake() error {
-	var err error
-	msg := handshakeMsg{
-		peeringID: p.peeringID(),
-		srcNetID:  p.net.Self().NetID(),
-		pubKey:    p.net.nodeKeyPair.Public,
-	}
-	var msgData []byte
-	if msgData, err = msg.bytes(); err != nil {
-		return err
-	}
-	data := encodeMessage(&peering.PeerMessage{
-		MsgType: msgTypeHandshake,
-		MsgData: msgData,
-	}, time.Now().UnixNano())
-	_, err = p.peerconn.Write(data)
-	p.net.log.Debugf("sendHandshake '%s' --> '%s', id = %s", p.net.myNetID, p.remoteNetID, p.peeringID())
-	return err
-}
-
 func (p *peer) doSendMsg(msg *peering.PeerMessage) error {
 	if msg.MsgType < peering.FirstUserMsgCode {
 		return errors.New("reserved message code")
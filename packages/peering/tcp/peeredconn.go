@@ -4,13 +4,17 @@
 package tcp
 
 import (
+	"bytes"
+	"crypto/rand"
 	"net"
+	"time"
 
 	"github.com/iotaledger/goshimmer/dapps/waspconn/packages/chopper"
 	"github.com/iotaledger/goshimmer/packages/tangle"
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/netutil/buffconn"
 	"github.com/iotaledger/wasp/packages/peering"
+	"go.dedis.ch/kyber/v3"
 )
 
 // extension of BufferedConnection from hive.go
@@ -23,15 +27,23 @@ type peeredConnection struct {
 	net         *NetImpl
 	msgChopper  *chopper.Chopper
 	handshakeOk bool
+	nonce       []byte      // Our challenge, the peer has to sign it in its handshake.
+	inbound     *peer       // The inbound peer, which has not answered our challenge yet.
+	inboundPub  kyber.Point // The public key received in the first inbound handshake.
 }
 
 // creates new peered connection and attach event handlers for received data and closing
 func newPeeredConnection(conn net.Conn, net *NetImpl, peer *peer) *peeredConnection {
+	nonce := make([]byte, handshakeNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		net.log.Panicf("unable to generate the handshake nonce: %v", err)
+	}
 	c := &peeredConnection{
 		BufferedConnection: buffconn.NewBufferedConnection(conn, tangle.MaxMessageSize),
 		peer:               peer, // may be nil
 		net:                net,
 		msgChopper:         chopper.NewChopper(),
+		nonce:              nonce,
 	}
 	c.Events.ReceiveMessage.Attach(events.NewClosure(func(data []byte) {
 		c.receiveData(data)
@@ -70,7 +82,12 @@ func (c *peeredConnection) receiveData(data []byte) {
 	if c.peer != nil {
 		// it is peered but maybe not handshaked yet (can only be outbound)
 		if c.peer.handshakeOk {
-			// it is handshake-ed
+			// it is handshake-ed, but the peer could be distrusted since then.
+			if err = c.net.trusted.IsTrustedPeer(c.peer.remotePubKey); err != nil {
+				c.net.log.Warnf("closeConn the peer connection: peer %s is not trusted anymore: %v", c.peer.peeringID(), err)
+				c.peer.closeConn()
+				return
+			}
 			c.net.events.Trigger(&peering.RecvEvent{
 				From: c.peer,
 				Msg:  msg,
@@ -99,6 +116,8 @@ func (c *peeredConnection) receiveData(data []byte) {
 
 // receives handshake response from the outbound peer
 // assumes the connection is already peered (i can be only for outbound peers)
+// the response has to carry our challenge signed by the peer,
+// then we answer its challenge to finish the handshake
 func (c *peeredConnection) processHandShakeOutbound(msg *peering.PeerMessage) {
 	var err error
 	var hMsg *handshakeMsg
@@ -107,9 +126,20 @@ func (c *peeredConnection) processHandShakeOutbound(msg *peering.PeerMessage) {
 			"closeConn the peer connection: wrong handshake message from outbound peer %v, error: %v",
 			c.peer.peeringID(), err,
 		)
-		panic(err)
+		c.peer.closeConn()
+		return
 	}
 	c.net.log.Debugf("received handshake from outbound %s", hMsg.peeringID)
+	if err = c.net.trusted.IsTrustedPeer(hMsg.pubKey); err != nil {
+		c.net.log.Warnf("closeConn the peer connection: outbound peer %s is not trusted: %v", hMsg.peeringID, err)
+		c.peer.closeConn()
+		return
+	}
+	if !bytes.Equal(hMsg.peerNonce, c.nonce) {
+		c.net.log.Warnf("closeConn the peer connection: outbound peer %s has not signed our challenge", hMsg.peeringID)
+		c.peer.closeConn()
+		return
+	}
 	if hMsg.peeringID != c.peer.peeringID() {
 		c.net.log.Errorf(
 			"closeConn the peer connection: wrong handshake message from outbound peer: expected %s got '%s'",
@@ -120,6 +150,11 @@ func (c *peeredConnection) processHandShakeOutbound(msg *peering.PeerMessage) {
 			c.peer.closeConn()
 		}
 	} else {
+		if err = c.sendHandshake(c.peer.peeringID(), hMsg.nonce); err != nil {
+			c.net.log.Errorf("error while finishing the handshake: %v. Closing connection", err)
+			c.peer.closeConn()
+			return
+		}
 		c.net.log.Infof("CONNECTED WITH PEER %s (outbound)", hMsg.peeringID)
 		c.peer.remotePubKey = hMsg.pubKey
 		c.peer.handshakeOk = true
@@ -127,22 +162,51 @@ func (c *peeredConnection) processHandShakeOutbound(msg *peering.PeerMessage) {
 	}
 }
 
-// receives handshake from the inbound peer
-// links connection with the peer
-// sends response back to finish the handshake
+// receives handshakes from the inbound peer
+// the first one is answered with our challenge, the second one has to
+// carry our challenge signed by the peer, then the connection is linked
+// with the peer
 func (c *peeredConnection) processHandShakeInbound(msg *peering.PeerMessage) {
 	var err error
 	var hMsg *handshakeMsg
 	if hMsg, err = handshakeMsgFromBytes(msg.MsgData, c.net.suite); err != nil {
 		c.net.log.Errorf(
-			"closeConn the peer connection: wrong handshake message from outbound peer %v, error: %v",
-			c.peer.peeringID(), err,
+			"closeConn the peer connection: wrong handshake message from inbound peer %v, error: %v",
+			c.RemoteAddr(), err,
 		)
-		panic(err)
+		_ = c.Close()
+		return
 	}
 
 	c.net.log.Infof("received handshake from inbound id = %s, peers=%+v", hMsg.peeringID, c.net.peers)
 
+	if err = c.net.trusted.IsTrustedPeer(hMsg.pubKey); err != nil {
+		c.net.log.Warnf("inbound connection from untrusted peer id %s: %v. Closing..", hMsg.peeringID, err)
+		_ = c.Close()
+		return
+	}
+
+	if c.inbound != nil {
+		// that's an answer to our challenge
+		if !bytes.Equal(hMsg.peerNonce, c.nonce) || !hMsg.pubKey.Equal(c.inboundPub) || hMsg.peeringID != c.inbound.peeringID() {
+			c.net.log.Warnf("inbound peer id %s has not signed our challenge. Closing..", hMsg.peeringID)
+			_ = c.Close()
+			return
+		}
+		peer := c.inbound
+		c.peer = peer
+
+		peer.Lock()
+		peer.peerconn = c
+		peer.remotePubKey = hMsg.pubKey
+		peer.handshakeOk = true
+		peer.waitReady.Done()
+		peer.Unlock()
+
+		c.net.log.Infof("CONNECTED WITH PEER %s (inbound)", hMsg.peeringID)
+		return
+	}
+
 	c.net.peersMutex.RLock()
 	peer, ok := c.net.peers[hMsg.peeringID]
 	c.net.peersMutex.RUnlock()
@@ -152,19 +216,35 @@ func (c *peeredConnection) processHandShakeInbound(msg *peering.PeerMessage) {
 		_ = c.Close()
 		return
 	}
-	c.peer = peer
-
-	peer.Lock()
-	peer.peerconn = c
-	peer.remotePubKey = hMsg.pubKey
-	peer.handshakeOk = true
-	peer.waitReady.Done()
-	peer.Unlock()
+	c.inbound = peer
+	c.inboundPub = hMsg.pubKey
 
-	c.net.log.Infof("CONNECTED WITH PEER %s (inbound)", hMsg.peeringID)
-
-	if err := peer.sendHandshake(); err != nil {
+	if err := c.sendHandshake(peer.peeringID(), hMsg.nonce); err != nil {
 		c.net.log.Errorf("error while responding to handshake: %v. Closing connection", err)
 		_ = c.Close()
 	}
 }
+
+// sends a signed handshake message. It contains myNetID, our challenge
+// and the challenge of the peer, if it is already known.
+func (c *peeredConnection) sendHandshake(peeringID string, peerNonce []byte) error {
+	var err error
+	msg := handshakeMsg{
+		peeringID: peeringID,
+		srcNetID:  c.net.Self().NetID(),
+		pubKey:    c.net.nodeKeyPair.Public,
+		nonce:     c.nonce,
+		peerNonce: peerNonce,
+	}
+	var msgData []byte
+	if msgData, err = msg.bytes(c.net.nodeKeyPair.Private, c.net.suite); err != nil {
+		return err
+	}
+	data := encodeMessage(&peering.PeerMessage{
+		MsgType: msgTypeHandshake,
+		MsgData: msgData,
+	}, time.Now().UnixNano())
+	_, err = c.Write(data)
+	c.net.log.Debugf("sendHandshake '%s' --> '%s', id = %s", c.net.myNetID, c.RemoteAddr(), peeringID)
+	return err
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package peering
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/util"
+	"go.dedis.ch/kyber/v3"
+)
+
+// TrustedNetworkManager is used to maintain a list of the peers trusted by this node.
+// Only the trusted peers can pair with this node and initiate the DKG on it.
+type TrustedNetworkManager interface {
+	IsTrustedPeer(pubKey kyber.Point) error
+	TrustPeer(pubKey kyber.Point, name string) (*TrustedPeer, error)
+	DistrustPeer(pubKey kyber.Point) (*TrustedPeer, error)
+	TrustedPeers() ([]*TrustedPeer, error)
+}
+
+// TrustedPeer carries the public key of a trusted peer
+// along with a human readable name of it.
+type TrustedPeer struct {
+	PubKey kyber.Point
+	Name   string
+}
+
+// NewTrustedPeer is a constructor for the TrustedPeer.
+func NewTrustedPeer(pubKey kyber.Point, name string) *TrustedPeer {
+	return &TrustedPeer{
+		PubKey: pubKey,
+		Name:   name,
+	}
+}
+
+// TrustedPeerFromBytes decodes the TrustedPeer.
+func TrustedPeerFromBytes(buf []byte, suite kyber.Group) (*TrustedPeer, error) {
+	var err error
+	r := bytes.NewReader(buf)
+	tp := TrustedPeer{
+		PubKey: suite.Point(),
+	}
+	if err = util.ReadMarshaled(r, tp.PubKey); err != nil {
+		return nil, err
+	}
+	if tp.Name, err = util.ReadString16(r); err != nil {
+		return nil, err
+	}
+	return &tp, nil
+}
+
+// Bytes encodes the TrustedPeer.
+func (tp *TrustedPeer) Bytes() ([]byte, error) {
+	var err error
+	var buf bytes.Buffer
+	if err = util.WriteMarshaled(&buf, tp.PubKey); err != nil {
+		return nil, err
+	}
+	if err = util.WriteString16(&buf, tp.Name); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// String is used for logging.
+func (tp *TrustedPeer) String() string {
+	var pubKeyStr string
+	if pubKeyBytes, err := tp.PubKey.MarshalBinary(); err == nil {
+		pubKeyStr = base64.StdEncoding.EncodeToString(pubKeyBytes)
+	}
+	return fmt.Sprintf("TrustedPeer{Name=%s, PubKey=%s}", tp.Name, pubKeyStr)
+}
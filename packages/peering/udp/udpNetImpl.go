@@ -37,13 +37,23 @@ type NetImpl struct {
 	recvEvents  *events.Event
 	recvQueue   chan *peering.RecvEvent // A queue for received messages.
 	nodeKeyPair *key.Pair
+	trusted     peering.TrustedNetworkManager // Only the trusted peers are paired.
 	suite       Suite
 	log         *logger.Logger
 }
 
-// NewNetworkProvider is a constructor for the TCP based
-// peering network implementation.
-func NewNetworkProvider(myNetID string, port int, nodeKeyPair *key.Pair, suite Suite, log *logger.Logger) (*NetImpl, error) {
+// NewNetworkProvider is a constructor for the UDP based
+// peering network implementation. Handshakes of the peers
+// not trusted by the trusted network manager are refused,
+// and the messages of the peers distrusted later are dropped.
+func NewNetworkProvider(
+	myNetID string,
+	port int,
+	nodeKeyPair *key.Pair,
+	trusted peering.TrustedNetworkManager,
+	suite Suite,
+	log *logger.Logger,
+) (*NetImpl, error) {
 	var err error
 	if err = peering.CheckMyNetID(myNetID, port); err != nil {
 		// can't continue because NetID parameter is not correct
@@ -64,6 +74,7 @@ func NewNetworkProvider(myNetID string, port int, nodeKeyPair *key.Pair, suite S
 		recvEvents:  nil, // Initialized bellow.
 		recvQueue:   make(chan *peering.RecvEvent, recvQueueSize),
 		nodeKeyPair: nodeKeyPair,
+		trusted:     trusted,
 		suite:       suite,
 		log:         log,
 	}
@@ -262,6 +273,10 @@ func (n *NetImpl) receiveLoop(stopCh chan bool) {
 				n.log.Warnf("Error while decoding a UDP handshake, reason=%v", err)
 				continue
 			}
+			if err = n.trusted.IsTrustedPeer(h.pubKey); err != nil {
+				n.log.Warnf("Refusing a UDP handshake from %v (NetID=%v), reason=%v", peerUDPAddr, h.netID, err)
+				continue
+			}
 			n.peersLock.Lock()
 			if p, ok := n.peers[h.netID]; ok {
 				if oldUDPAddrStr, newUDPAddrStr := p.handleHandshake(h, peerUDPAddr); oldUDPAddrStr != newUDPAddrStr {
@@ -312,6 +327,11 @@ func (n *NetImpl) receiveUserMsg(msg *peering.PeerMessage, peerUDPAddr *net.UDPA
 	n.peersLock.RLock()
 	if p, ok := n.peersByAddr[remoteUDPAddrStr]; ok {
 		n.peersLock.RUnlock()
+		if err := p.checkTrusted(); err != nil {
+			// The peer could be distrusted after the handshake.
+			n.log.Warnf("Dropping received message from peer=%v, reason=%v", remoteUDPAddrStr, err)
+			return
+		}
 		p.noteReceived()
 		n.recvQueue <- &peering.RecvEvent{
 			From: p,
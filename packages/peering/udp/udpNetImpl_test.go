@@ -5,6 +5,7 @@ package udp_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/peering"
@@ -25,9 +26,11 @@ func TestUDPPeeringImpl(t *testing.T) {
 	chain2 := coretypes.NewRandomChainID()
 	netIDs := []string{"localhost:9017", "localhost:9018", "localhost:9019"}
 	nodes := make([]peering.NetworkProvider, len(netIDs))
-	nodes[0], err0 = udp.NewNetworkProvider(netIDs[0], 9017, key.NewKeyPair(suite), suite, log.Named("node0"))
-	nodes[1], err1 = udp.NewNetworkProvider(netIDs[1], 9018, key.NewKeyPair(suite), suite, log.Named("node1"))
-	nodes[2], err2 = udp.NewNetworkProvider(netIDs[2], 9019, key.NewKeyPair(suite), suite, log.Named("node2"))
+	nodeKeys := []*key.Pair{key.NewKeyPair(suite), key.NewKeyPair(suite), key.NewKeyPair(suite)}
+	trusted := testutil.NewTrustedNetworkManager(nodeKeys[0].Public, nodeKeys[1].Public, nodeKeys[2].Public)
+	nodes[0], err0 = udp.NewNetworkProvider(netIDs[0], 9017, nodeKeys[0], trusted, suite, log.Named("node0"))
+	nodes[1], err1 = udp.NewNetworkProvider(netIDs[1], 9018, nodeKeys[1], trusted, suite, log.Named("node1"))
+	nodes[2], err2 = udp.NewNetworkProvider(netIDs[2], 9019, nodeKeys[2], trusted, suite, log.Named("node2"))
 	require.Nil(t, err0)
 	require.Nil(t, err1)
 	require.Nil(t, err2)
@@ -52,3 +55,66 @@ func TestUDPPeeringImpl(t *testing.T) {
 
 	<-doneCh
 }
+
+func TestUDPPeeringUntrusted(t *testing.T) {
+	suite := pairing.NewSuiteBn256()
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	netIDs := []string{"localhost:9027", "localhost:9028"}
+	nodeKeys := []*key.Pair{key.NewKeyPair(suite), key.NewKeyPair(suite)}
+	// The first node trusts the second one, but not the other way around.
+	node0, err := udp.NewNetworkProvider(netIDs[0], 9027, nodeKeys[0], testutil.NewTrustedNetworkManager(nodeKeys[1].Public), suite, log.Named("node0"))
+	require.Nil(t, err)
+	node1, err := udp.NewNetworkProvider(netIDs[1], 9028, nodeKeys[1], testutil.NewTrustedNetworkManager(), suite, log.Named("node1"))
+	require.Nil(t, err)
+	go node0.Run(make(<-chan struct{}))
+	go node1.Run(make(<-chan struct{}))
+
+	n1p0, err := node1.PeerByNetID(netIDs[0])
+	require.Nil(t, err)
+	require.NotNil(t, n1p0.Await(2*time.Second))
+	require.Nil(t, n1p0.PubKey())
+}
+
+func TestUDPPeeringDistrusted(t *testing.T) {
+	suite := pairing.NewSuiteBn256()
+	log := testutil.NewLogger(t)
+	defer log.Sync()
+	recvCh := make(chan bool, 10)
+	chainID := coretypes.NewRandomChainID()
+	netIDs := []string{"localhost:9047", "localhost:9048"}
+	nodeKeys := []*key.Pair{key.NewKeyPair(suite), key.NewKeyPair(suite)}
+	trusted0 := testutil.NewTrustedNetworkManager(nodeKeys[1].Public)
+	node0, err := udp.NewNetworkProvider(netIDs[0], 9047, nodeKeys[0], trusted0, suite, log.Named("node0"))
+	require.Nil(t, err)
+	node1, err := udp.NewNetworkProvider(netIDs[1], 9048, nodeKeys[1], testutil.NewTrustedNetworkManager(nodeKeys[0].Public), suite, log.Named("node1"))
+	require.Nil(t, err)
+	go node0.Run(make(<-chan struct{}))
+	go node1.Run(make(<-chan struct{}))
+	node0.Attach(nil, func(recv *peering.RecvEvent) {
+		recvCh <- true
+	})
+	n0p1, err := node0.PeerByNetID(netIDs[1])
+	require.Nil(t, err)
+	n1p0, err := node1.PeerByNetID(netIDs[0])
+	require.Nil(t, err)
+	require.Nil(t, n0p1.Await(5*time.Second))
+	require.Nil(t, n1p0.Await(5*time.Second))
+
+	n1p0.SendMsg(&peering.PeerMessage{ChainID: chainID, MsgType: 125})
+	select {
+	case <-recvCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message from the trusted peer not received")
+	}
+	//
+	// Messages are dropped after the peer is distrusted.
+	_, err = trusted0.DistrustPeer(nodeKeys[1].Public)
+	require.Nil(t, err)
+	n1p0.SendMsg(&peering.PeerMessage{ChainID: chainID, MsgType: 125})
+	select {
+	case <-recvCh:
+		t.Fatal("message from the distrusted peer received")
+	case <-time.After(time.Second):
+	}
+}
@@ -140,6 +140,18 @@ func (p *peer) sendHandshake(respond bool) {
 	})
 }
 
+// checkTrusted returns an error, if the peer has not completed
+// the handshake yet or if it is not trusted anymore.
+func (p *peer) checkTrusted() error {
+	p.accessLock.RLock()
+	remotePubKey := p.remotePubKey
+	p.accessLock.RUnlock()
+	if remotePubKey == nil {
+		return errors.New("handshake not completed")
+	}
+	return p.net.trusted.IsTrustedPeer(remotePubKey)
+}
+
 func (p *peer) noteReceived() {
 	p.accessLock.Lock()
 	p.lastMsgRecv = time.Now()
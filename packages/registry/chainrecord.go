@@ -6,6 +6,7 @@ import (
 	"github.com/iotaledger/wasp/packages/dbprovider"
 	"io"
 
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/hive.go/kvstore"
 	"github.com/iotaledger/wasp/packages/coretypes"
@@ -15,6 +16,17 @@ import (
 	"github.com/mr-tron/base58"
 )
 
+// consensus algorithms the chain can be run with
+const (
+	// ConsensusLeader is the default consensus: the batch is proposed by the leader, which is rotated on timeouts
+	ConsensusLeader = "leader"
+	// ConsensusBFT is the byzantine fault tolerant consensus with signed votes and view changes
+	ConsensusBFT = "bft"
+	// ConsensusACS is the consensus where every node proposes requests and the committee agrees on the common subset
+	// of the proposals
+	ConsensusACS = "acs"
+)
+
 // ChainRecord is a minimum data needed to load a committee for the chain
 // it is up to the node (not smart contract) to check authorizations to create/update this record
 type ChainRecord struct {
@@ -22,6 +34,107 @@ type ChainRecord struct {
 	Color          balance.Color // origin tx hash
 	CommitteeNodes []string      // "host_addr:port"
 	Active         bool
+	// StateAddress is the shared address of the committee which holds the chain token.
+	// nil means the address equal to the chain ID, i.e. the committee was never rotated
+	StateAddress *address.Address
+	// PeerNodes are nodes which are not in the committee, but are peers of the chain, i.e. the state is
+	// synced from them. Usually the nodes of the previous committee
+	PeerNodes []string
+	// NextCommitteeNodes and NextStateAddress are the committee and its shared address the chain is being rotated to.
+	// The chain is switched to the next committee when it is moved to the NextStateAddress
+	NextCommitteeNodes []string
+	NextStateAddress   *address.Address
+	// Consensus is the consensus algorithm of the chain. Empty means ConsensusLeader
+	Consensus string
+}
+
+// IsValidConsensus returns true if the chain can be run with the consensus algorithm
+func IsValidConsensus(consensus string) bool {
+	return consensus == "" || consensus == ConsensusLeader || consensus == ConsensusBFT || consensus == ConsensusACS
+}
+
+// ConsensusOrDefault returns the consensus algorithm of the chain
+func (bd *ChainRecord) ConsensusOrDefault() string {
+	if bd.Consensus == "" {
+		return ConsensusLeader
+	}
+	return bd.Consensus
+}
+
+// CheckConsensus returns an error if the consensus algorithm stored in the state of the chain
+// differs from the one in the chain record. Empty means ConsensusLeader on both sides
+func (bd *ChainRecord) CheckConsensus(consensus string) error {
+	if consensus == "" {
+		consensus = ConsensusLeader
+	}
+	if consensus != bd.ConsensusOrDefault() {
+		return fmt.Errorf("the chain %s runs the '%s' consensus, but the chain record says '%s'",
+			bd.ChainID.String(), consensus, bd.ConsensusOrDefault())
+	}
+	return nil
+}
+
+// Address is the address which holds the chain token
+func (bd *ChainRecord) Address() address.Address {
+	if bd.StateAddress == nil {
+		return address.Address(bd.ChainID)
+	}
+	return *bd.StateAddress
+}
+
+// Peers returns all peers of the chain: the committee nodes first, then other peers and nodes of the next committee
+func (bd *ChainRecord) Peers() []string {
+	ret := make([]string, 0, len(bd.CommitteeNodes)+len(bd.PeerNodes)+len(bd.NextCommitteeNodes))
+	seen := make(map[string]bool)
+	for _, nodes := range [][]string{bd.CommitteeNodes, bd.PeerNodes, bd.NextCommitteeNodes} {
+		for _, node := range nodes {
+			if !seen[node] {
+				seen[node] = true
+				ret = append(ret, node)
+			}
+		}
+	}
+	return ret
+}
+
+// IsRotating returns true if the chain is being rotated to the next committee
+func (bd *ChainRecord) IsRotating() bool {
+	return bd.NextStateAddress != nil
+}
+
+// RotateToNextCommittee makes the next committee the committee of the chain when the chain was moved
+// to the address. The nodes of the previous committee stay peers of the chain.
+// Returns false if the chain was not being rotated to the address
+func (bd *ChainRecord) RotateToNextCommittee(addr address.Address) bool {
+	if bd.NextStateAddress == nil || *bd.NextStateAddress != addr {
+		return false
+	}
+	next := make(map[string]bool)
+	for _, node := range bd.NextCommitteeNodes {
+		next[node] = true
+	}
+	peers := make([]string, 0)
+	for _, node := range bd.Peers() {
+		if !next[node] {
+			peers = append(peers, node)
+		}
+	}
+	bd.CommitteeNodes = bd.NextCommitteeNodes
+	bd.PeerNodes = peers
+	bd.StateAddress = &addr
+	bd.NextCommitteeNodes = nil
+	bd.NextStateAddress = nil
+	return true
+}
+
+// InCommittee returns true if the node is in the committee of the chain
+func (bd *ChainRecord) InCommittee(netID string) bool {
+	for _, node := range bd.CommitteeNodes {
+		if node == netID {
+			return true
+		}
+	}
+	return false
 }
 
 func dbkeyChainRecord(chainID *coretypes.ChainID) []byte {
@@ -127,7 +240,19 @@ func (bd *ChainRecord) Write(w io.Writer) error {
 	if err := util.WriteBoolByte(w, bd.Active); err != nil {
 		return err
 	}
-	return nil
+	if err := writeOptionalAddress(w, bd.StateAddress); err != nil {
+		return err
+	}
+	if err := util.WriteStrings16(w, bd.PeerNodes); err != nil {
+		return err
+	}
+	if err := util.WriteStrings16(w, bd.NextCommitteeNodes); err != nil {
+		return err
+	}
+	if err := writeOptionalAddress(w, bd.NextStateAddress); err != nil {
+		return err
+	}
+	return util.WriteString16(w, bd.Consensus)
 }
 
 func (bd *ChainRecord) Read(r io.Reader) error {
@@ -144,12 +269,68 @@ func (bd *ChainRecord) Read(r io.Reader) error {
 	if err = util.ReadBoolByte(r, &bd.Active); err != nil {
 		return err
 	}
-	return nil
+	bd.StateAddress, err = readOptionalAddress(r)
+	if err == io.EOF {
+		// the record was stored before committees could be rotated
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if bd.PeerNodes, err = util.ReadStrings16(r); err != nil {
+		return err
+	}
+	if bd.NextCommitteeNodes, err = util.ReadStrings16(r); err != nil {
+		return err
+	}
+	if bd.NextStateAddress, err = readOptionalAddress(r); err != nil {
+		return err
+	}
+	bd.Consensus, err = util.ReadString16(r)
+	if err == io.EOF {
+		// the record was stored before the consensus could be selected
+		return nil
+	}
+	return err
+}
+
+func writeOptionalAddress(w io.Writer, addr *address.Address) error {
+	if err := util.WriteBoolByte(w, addr != nil); err != nil {
+		return err
+	}
+	if addr == nil {
+		return nil
+	}
+	_, err := w.Write(addr[:])
+	return err
+}
+
+func readOptionalAddress(r io.Reader) (*address.Address, error) {
+	var exists bool
+	if err := util.ReadBoolByte(r, &exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	ret := new(address.Address)
+	if _, err := io.ReadFull(r, ret[:]); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 func (bd *ChainRecord) String() string {
 	ret := "      Target: " + bd.ChainID.String() + "\n"
 	ret += "      Color: " + bd.Color.String() + "\n"
 	ret += fmt.Sprintf("      Committee nodes: %+v\n", bd.CommitteeNodes)
+	ret += "      State address: " + bd.Address().String() + "\n"
+	ret += "      Consensus: " + bd.ConsensusOrDefault() + "\n"
+	if len(bd.PeerNodes) > 0 {
+		ret += fmt.Sprintf("      Peer nodes: %+v\n", bd.PeerNodes)
+	}
+	if bd.IsRotating() {
+		ret += fmt.Sprintf("      Rotating to: %s, committee nodes: %+v\n", bd.NextStateAddress.String(), bd.NextCommitteeNodes)
+	}
 	return ret
 }
@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRecordWriteRead(t *testing.T) {
+	stateAddress := address.RandomOfType(address.VersionBLS)
+	nextAddress := address.RandomOfType(address.VersionBLS)
+	rec := &ChainRecord{
+		ChainID:            coretypes.NewRandomChainID(),
+		Color:              balance.Color{1, 2, 3},
+		CommitteeNodes:     []string{"a:1", "b:1"},
+		Active:             true,
+		StateAddress:       &stateAddress,
+		PeerNodes:          []string{"c:1"},
+		NextCommitteeNodes: []string{"b:1", "d:1"},
+		NextStateAddress:   &nextAddress,
+		Consensus:          ConsensusBFT,
+	}
+	back := new(ChainRecord)
+	require.NoError(t, back.Read(bytes.NewReader(util.MustBytes(rec))))
+	require.EqualValues(t, rec, back)
+	require.EqualValues(t, stateAddress, back.Address())
+	require.True(t, back.IsRotating())
+	require.EqualValues(t, []string{"a:1", "b:1", "c:1", "d:1"}, back.Peers())
+	require.EqualValues(t, ConsensusBFT, back.ConsensusOrDefault())
+}
+
+func TestChainRecordReadOld(t *testing.T) {
+	rec := &ChainRecord{
+		ChainID:        coretypes.NewRandomChainID(),
+		Color:          balance.Color{1, 2, 3},
+		CommitteeNodes: []string{"a:1", "b:1"},
+		Active:         true,
+	}
+	// the record as stored before committees could be rotated
+	var buf bytes.Buffer
+	require.NoError(t, rec.ChainID.Write(&buf))
+	buf.Write(rec.Color[:])
+	require.NoError(t, util.WriteStrings16(&buf, rec.CommitteeNodes))
+	require.NoError(t, util.WriteBoolByte(&buf, rec.Active))
+
+	back := new(ChainRecord)
+	require.NoError(t, back.Read(bytes.NewReader(buf.Bytes())))
+	require.EqualValues(t, rec, back)
+	require.EqualValues(t, address.Address(rec.ChainID), back.Address())
+	require.False(t, back.IsRotating())
+	require.EqualValues(t, ConsensusLeader, back.ConsensusOrDefault())
+}
+
+func TestChainRecordReadWithoutConsensus(t *testing.T) {
+	stateAddress := address.RandomOfType(address.VersionBLS)
+	rec := &ChainRecord{
+		ChainID:        coretypes.NewRandomChainID(),
+		Color:          balance.Color{1, 2, 3},
+		CommitteeNodes: []string{"a:1", "b:1"},
+		Active:         true,
+		StateAddress:   &stateAddress,
+		PeerNodes:      []string{"c:1"},
+		// read back as empty, not nil
+		NextCommitteeNodes: []string{},
+	}
+	// the record as stored before the consensus could be selected
+	data := util.MustBytes(rec)
+	data = data[:len(data)-2]
+
+	back := new(ChainRecord)
+	require.NoError(t, back.Read(bytes.NewReader(data)))
+	require.EqualValues(t, rec, back)
+	require.EqualValues(t, ConsensusLeader, back.ConsensusOrDefault())
+	require.True(t, IsValidConsensus(back.Consensus))
+	require.False(t, IsValidConsensus("pbft"))
+}
+
+func TestChainRecordRotateToNextCommittee(t *testing.T) {
+	nextAddress := address.RandomOfType(address.VersionBLS)
+	rec := &ChainRecord{
+		ChainID:        coretypes.NewRandomChainID(),
+		Color:          balance.Color{1, 2, 3},
+		CommitteeNodes: []string{"a:1", "b:1"},
+		Active:         true,
+	}
+	require.False(t, rec.RotateToNextCommittee(nextAddress))
+
+	rec.NextCommitteeNodes = []string{"b:1", "c:1"}
+	rec.NextStateAddress = &nextAddress
+	require.False(t, rec.RotateToNextCommittee(address.RandomOfType(address.VersionBLS)))
+	require.True(t, rec.RotateToNextCommittee(nextAddress))
+
+	require.EqualValues(t, nextAddress, rec.Address())
+	require.EqualValues(t, []string{"b:1", "c:1"}, rec.CommitteeNodes)
+	require.EqualValues(t, []string{"a:1"}, rec.PeerNodes)
+	require.False(t, rec.IsRotating())
+	require.True(t, rec.InCommittee("c:1"))
+	require.False(t, rec.InCommittee("a:1"))
+}
+
+func TestChainRecordCheckConsensus(t *testing.T) {
+	rec := &ChainRecord{
+		ChainID: coretypes.NewRandomChainID(),
+	}
+	require.NoError(t, rec.CheckConsensus(""))
+	require.NoError(t, rec.CheckConsensus(ConsensusLeader))
+	require.Error(t, rec.CheckConsensus(ConsensusBFT))
+
+	rec.Consensus = ConsensusACS
+	require.NoError(t, rec.CheckConsensus(ConsensusACS))
+	require.Error(t, rec.CheckConsensus(""))
+	require.Error(t, rec.CheckConsensus(ConsensusBFT))
+}
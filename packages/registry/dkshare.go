@@ -4,12 +4,15 @@
 package registry
 
 import (
+	"encoding/binary"
 	"fmt"
-	"github.com/iotaledger/wasp/packages/dbprovider"
+	"sort"
+	"time"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/dbprovider"
 	"github.com/iotaledger/wasp/packages/tcrypto"
-	"github.com/iotaledger/wasp/plugins/database"
 )
 
 // SaveDKShare implements dkg.RegistryProvider.
@@ -17,7 +20,7 @@ func (r *Impl) SaveDKShare(dkShare *tcrypto.DKShare) error {
 	var err error
 	var exists bool
 	dbKey := dbKeyForDKShare(dkShare.Address)
-	kvStore := database.GetRegistryPartition()
+	kvStore := r.dbProvider.GetRegistryPartition()
 	if exists, err = kvStore.Has(dbKey); err != nil {
 		return err
 	}
@@ -41,6 +44,133 @@ func (r *Impl) LoadDKShare(sharedAddress *address.Address) (*tcrypto.DKShare, er
 	return tcrypto.DKShareFromBytes(data, r.suite)
 }
 
+// SavePendingDKShare implements dkg.RegistryProvider.
+func (r *Impl) SavePendingDKShare(dkShare *tcrypto.DKShare) error {
+	var err error
+	var exists bool
+	kvStore := r.dbProvider.GetRegistryPartition()
+	if exists, err = kvStore.Has(dbKeyForDKShare(dkShare.Address)); err != nil {
+		return err
+	}
+	if exists {
+		var current *tcrypto.DKShare
+		if current, err = r.LoadDKShare(dkShare.Address); err != nil {
+			return err
+		}
+		if !current.SharedPublic.Equal(dkShare.SharedPublic) {
+			return fmt.Errorf("attempt to replace DK key share with a share of a different key")
+		}
+	}
+	var buf []byte
+	if buf, err = dkShare.Bytes(); err != nil {
+		return err
+	}
+	return kvStore.Set(dbKeyForPendingDKShare(dkShare.Address), buf)
+}
+
+// ActivatePendingDKShare implements dkg.RegistryProvider.
+// The pending share, the current share and the retired share are switched in one batch.
+func (r *Impl) ActivatePendingDKShare(sharedAddress *address.Address) error {
+	kvStore := r.dbProvider.GetRegistryPartition()
+	pending, err := kvStore.Get(dbKeyForPendingDKShare(sharedAddress))
+	if err == kvstore.ErrKeyNotFound {
+		return fmt.Errorf("pending DK key share not found for %s", sharedAddress.String())
+	}
+	if err != nil {
+		return err
+	}
+	current, err := kvStore.Get(dbKeyForDKShare(sharedAddress))
+	if err != nil && err != kvstore.ErrKeyNotFound {
+		return err
+	}
+	batch := kvStore.Batched()
+	if current != nil {
+		if err = batch.Set(dbKeyForRetiredDKShare(sharedAddress), current); err != nil {
+			batch.Cancel()
+			return err
+		}
+	}
+	if err = batch.Set(dbKeyForDKShare(sharedAddress), pending); err != nil {
+		batch.Cancel()
+		return err
+	}
+	if err = batch.Delete(dbKeyForPendingDKShare(sharedAddress)); err != nil {
+		batch.Cancel()
+		return err
+	}
+	return batch.Commit()
+}
+
+// RetireDKShare implements dkg.RegistryProvider.
+// The share is moved to the retired shares of the key, a pending share of the key is discarded.
+func (r *Impl) RetireDKShare(sharedAddress *address.Address) error {
+	kvStore := r.dbProvider.GetRegistryPartition()
+	current, err := kvStore.Get(dbKeyForDKShare(sharedAddress))
+	if err == kvstore.ErrKeyNotFound {
+		return fmt.Errorf("DK key share not found for %s", sharedAddress.String())
+	}
+	if err != nil {
+		return err
+	}
+	batch := kvStore.Batched()
+	if err = batch.Set(dbKeyForRetiredDKShare(sharedAddress), current); err != nil {
+		batch.Cancel()
+		return err
+	}
+	if err = batch.Delete(dbKeyForDKShare(sharedAddress)); err != nil {
+		batch.Cancel()
+		return err
+	}
+	if err = batch.Delete(dbKeyForPendingDKShare(sharedAddress)); err != nil {
+		batch.Cancel()
+		return err
+	}
+	return batch.Commit()
+}
+
+// LoadRetiredDKShares returns the retired shares of the key held by the node, the oldest first
+func (r *Impl) LoadRetiredDKShares(sharedAddress *address.Address) ([]*tcrypto.DKShare, error) {
+	retired := make(map[string][]byte)
+	prefix := dbprovider.MakeKey(dbprovider.ObjectTypeDistributedKeyData, sharedAddress.Bytes(), []byte(dkShareRetired))
+	err := r.dbProvider.GetRegistryPartition().Iterate(prefix, func(key kvstore.Key, value kvstore.Value) bool {
+		retired[string(key)] = value
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(retired))
+	for k := range retired {
+		keys = append(keys, k)
+	}
+	// keys end with the time of retirement
+	sort.Strings(keys)
+	ret := make([]*tcrypto.DKShare, len(keys))
+	for i, k := range keys {
+		if ret[i], err = tcrypto.DKShareFromBytes(retired[k], r.suite); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+const (
+	dkSharePending = "p"
+	dkShareRetired = "r"
+)
+
 func dbKeyForDKShare(sharedAddress *address.Address) []byte {
 	return dbprovider.MakeKey(dbprovider.ObjectTypeDistributedKeyData, sharedAddress.Bytes())
 }
+
+func dbKeyForPendingDKShare(sharedAddress *address.Address) []byte {
+	return dbprovider.MakeKey(dbprovider.ObjectTypeDistributedKeyData, sharedAddress.Bytes(), []byte(dkSharePending))
+}
+
+// dbKeyForRetiredDKShare returns the key of the share retired now. A node may retire several shares of the same key,
+// they are ordered by the time of retirement
+func dbKeyForRetiredDKShare(sharedAddress *address.Address) []byte {
+	var retiredAt [8]byte
+	binary.BigEndian.PutUint64(retiredAt[:], uint64(time.Now().UnixNano()))
+	return dbprovider.MakeKey(dbprovider.ObjectTypeDistributedKeyData, sharedAddress.Bytes(), []byte(dkShareRetired), retiredAt[:])
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+func TestReshareDKShare(t *testing.T) {
+	log := testutil.NewLogger(t)
+	suite := pairing.NewSuiteBn256()
+	reg := NewRegistry(suite, log, dbprovider.NewInMemoryDBProvider(log))
+
+	dkShares, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	reshared, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	addr := dkShares[0].Address
+	require.NoError(t, reg.SaveDKShare(dkShares[0]))
+	require.Error(t, reg.SaveDKShare(dkShares[1]))
+
+	// the pending share must be the share of the same key and it is not used until activated
+	other := *reshared[0]
+	other.Address = addr
+	require.Error(t, reg.SavePendingDKShare(&other))
+	pending := *dkShares[1]
+	require.NoError(t, reg.SavePendingDKShare(&pending))
+	current, err := reg.LoadDKShare(addr)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, *current.Index)
+
+	require.NoError(t, reg.ActivatePendingDKShare(addr))
+	current, err = reg.LoadDKShare(addr)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, *current.Index)
+	require.Error(t, reg.ActivatePendingDKShare(addr))
+	retired, err := reg.LoadRetiredDKShares(addr)
+	require.NoError(t, err)
+	require.Len(t, retired, 1)
+	require.EqualValues(t, 0, *retired[0].Index)
+
+	// the share is retired, it is kept but not loaded as the share of the key
+	require.NoError(t, reg.RetireDKShare(addr))
+	_, err = reg.LoadDKShare(addr)
+	require.Error(t, err)
+	require.Error(t, reg.RetireDKShare(addr))
+	retired, err = reg.LoadRetiredDKShares(addr)
+	require.NoError(t, err)
+	require.Len(t, retired, 2)
+	require.EqualValues(t, 1, *retired[1].Index)
+	require.True(t, retired[1].SharedPublic.Equal(dkShares[0].SharedPublic))
+
+	// a node receiving the share of the key for the first time
+	require.NoError(t, reg.SavePendingDKShare(reshared[2]))
+	require.NoError(t, reg.ActivatePendingDKShare(reshared[2].Address))
+	current, err = reg.LoadDKShare(reshared[2].Address)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, *current.Index)
+	retired, err = reg.LoadRetiredDKShares(reshared[2].Address)
+	require.NoError(t, err)
+	require.Len(t, retired, 0)
+}
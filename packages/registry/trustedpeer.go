@@ -0,0 +1,98 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/peering"
+	"go.dedis.ch/kyber/v3"
+)
+
+// IsTrustedPeer implements peering.TrustedNetworkManager.
+func (r *Impl) IsTrustedPeer(pubKey kyber.Point) error {
+	var err error
+	var dbKey []byte
+	if dbKey, err = dbKeyForTrustedPeer(pubKey); err != nil {
+		return err
+	}
+	var exists bool
+	if exists, err = r.dbProvider.GetRegistryPartition().Has(dbKey); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("peer with the public key %v is not trusted", pubKey)
+	}
+	return nil
+}
+
+// TrustPeer implements peering.TrustedNetworkManager.
+// The name of an already trusted peer is updated.
+func (r *Impl) TrustPeer(pubKey kyber.Point, name string) (*peering.TrustedPeer, error) {
+	var err error
+	var dbKey []byte
+	if dbKey, err = dbKeyForTrustedPeer(pubKey); err != nil {
+		return nil, err
+	}
+	tp := peering.NewTrustedPeer(pubKey, name)
+	var buf []byte
+	if buf, err = tp.Bytes(); err != nil {
+		return nil, err
+	}
+	if err = r.dbProvider.GetRegistryPartition().Set(dbKey, buf); err != nil {
+		return nil, err
+	}
+	r.log.Infof("Peer %v is trusted now.", tp)
+	return tp, nil
+}
+
+// DistrustPeer implements peering.TrustedNetworkManager.
+func (r *Impl) DistrustPeer(pubKey kyber.Point) (*peering.TrustedPeer, error) {
+	var err error
+	var dbKey []byte
+	if dbKey, err = dbKeyForTrustedPeer(pubKey); err != nil {
+		return nil, err
+	}
+	partition := r.dbProvider.GetRegistryPartition()
+	var buf []byte
+	if buf, err = partition.Get(dbKey); err != nil {
+		if err == kvstore.ErrKeyNotFound {
+			return nil, fmt.Errorf("peer with the public key %v is not trusted", pubKey)
+		}
+		return nil, err
+	}
+	var tp *peering.TrustedPeer
+	if tp, err = peering.TrustedPeerFromBytes(buf, r.suite); err != nil {
+		return nil, err
+	}
+	if err = partition.Delete(dbKey); err != nil {
+		return nil, err
+	}
+	r.log.Infof("Peer %v is not trusted anymore.", tp)
+	return tp, nil
+}
+
+// TrustedPeers implements peering.TrustedNetworkManager.
+func (r *Impl) TrustedPeers() ([]*peering.TrustedPeer, error) {
+	ret := make([]*peering.TrustedPeer, 0)
+	err := r.dbProvider.GetRegistryPartition().Iterate([]byte{dbprovider.ObjectTypeTrustedPeer}, func(key kvstore.Key, value kvstore.Value) bool {
+		if tp, err := peering.TrustedPeerFromBytes(value, r.suite); err == nil {
+			ret = append(ret, tp)
+		} else {
+			r.log.Warnf("corrupted trusted peer record, reason=%v", err)
+		}
+		return true
+	})
+	return ret, err
+}
+
+func dbKeyForTrustedPeer(pubKey kyber.Point) ([]byte, error) {
+	pubKeyBytes, err := pubKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return dbprovider.MakeKey(dbprovider.ObjectTypeTrustedPeer, pubKeyBytes), nil
+}
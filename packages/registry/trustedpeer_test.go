@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/pairing"
+	"go.dedis.ch/kyber/v3/util/key"
+)
+
+func TestTrustedPeers(t *testing.T) {
+	log := testutil.NewLogger(t)
+	suite := pairing.NewSuiteBn256()
+	reg := NewRegistry(suite, log, dbprovider.NewInMemoryDBProvider(log))
+
+	pub1 := key.NewKeyPair(suite).Public
+	pub2 := key.NewKeyPair(suite).Public
+	require.Error(t, reg.IsTrustedPeer(pub1))
+
+	_, err := reg.TrustPeer(pub1, "first")
+	require.NoError(t, err)
+	_, err = reg.TrustPeer(pub2, "second")
+	require.NoError(t, err)
+	_, err = reg.TrustPeer(pub2, "second, renamed")
+	require.NoError(t, err)
+	require.NoError(t, reg.IsTrustedPeer(pub1))
+	require.NoError(t, reg.IsTrustedPeer(pub2))
+
+	trusted, err := reg.TrustedPeers()
+	require.NoError(t, err)
+	require.Len(t, trusted, 2)
+	names := map[string]bool{}
+	for _, tp := range trusted {
+		names[tp.Name] = true
+	}
+	require.EqualValues(t, map[string]bool{"first": true, "second, renamed": true}, names)
+
+	tp, err := reg.DistrustPeer(pub1)
+	require.NoError(t, err)
+	require.True(t, tp.PubKey.Equal(pub1))
+	require.EqualValues(t, "first", tp.Name)
+	require.Error(t, reg.IsTrustedPeer(pub1))
+	require.NoError(t, reg.IsTrustedPeer(pub2))
+	_, err = reg.DistrustPeer(pub1)
+	require.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package sctransaction
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// RequestCall is one call of the multi-call request: the entry point of the contract on the target chain,
+// its parameters and the tokens transferred to the contract with the call
+type RequestCall struct {
+	Contract   coretypes.Hname
+	EntryPoint coretypes.Hname
+	Params     dict.Dict
+	Transfer   coretypes.ColoredBalances
+}
+
+func NewRequestCall(contract, entryPoint coretypes.Hname, params dict.Dict, transfer coretypes.ColoredBalances) *RequestCall {
+	if params == nil {
+		params = dict.New()
+	}
+	if transfer == nil {
+		transfer = cbalances.NewFromMap(nil)
+	}
+	return &RequestCall{
+		Contract:   contract,
+		EntryPoint: entryPoint,
+		Params:     params,
+		Transfer:   transfer,
+	}
+}
+
+func (c *RequestCall) String() string {
+	return fmt.Sprintf("[contract: %s, entry point: %s, params: %s, transfer: %s]",
+		c.Contract.String(), c.EntryPoint.String(), c.Params.String(), cbalances.Str(c.Transfer))
+}
+
+func (c *RequestCall) Clone() *RequestCall {
+	return NewRequestCall(c.Contract, c.EntryPoint, c.Params.Clone(), c.Transfer)
+}
+
+func (c *RequestCall) Write(w io.Writer) error {
+	if err := c.Contract.Write(w); err != nil {
+		return err
+	}
+	if err := c.EntryPoint.Write(w); err != nil {
+		return err
+	}
+	if err := c.Params.Write(w); err != nil {
+		return err
+	}
+	return cbalances.WriteColoredBalances(w, c.Transfer)
+}
+
+func (c *RequestCall) Read(r io.Reader) error {
+	if err := c.Contract.Read(r); err != nil {
+		return err
+	}
+	if err := c.EntryPoint.Read(r); err != nil {
+		return err
+	}
+	c.Params = dict.New()
+	if err := c.Params.Read(r); err != nil {
+		return err
+	}
+	var err error
+	c.Transfer, err = cbalances.ReadColoredBalance(r)
+	return err
+}
+
+// NewMultiCallRequestSection creates the request section which runs the calls in order in one request context.
+// Either all calls succeed and their state changes are committed or all of them are rolled back.
+// The request is targeted to the chain, the transfer of the request must cover transfers of all calls
+func NewMultiCallRequestSection(senderContractHname coretypes.Hname, chainID coretypes.ChainID, calls ...*RequestCall) *RequestSection {
+	return NewRequestSection(senderContractHname, coretypes.NewContractID(chainID, 0), 0).WithCalls(calls...)
+}
+
+// WithCalls makes the request the multi-call request
+func (req *RequestSection) WithCalls(calls ...*RequestCall) *RequestSection {
+	req.calls = calls
+	return req
+}
+
+// Calls returns calls of the multi-call request, nil for the ordinary request
+func (req *RequestSection) Calls() []*RequestCall {
+	return req.calls
+}
+
+// IsMultiCall returns true if the request runs a batch of calls
+func (req *RequestSection) IsMultiCall() bool {
+	return len(req.calls) > 0
+}
+
+func (req *RequestSection) writeCalls(w io.Writer) error {
+	if len(req.calls) > math.MaxUint16 {
+		return fmt.Errorf("too many calls in the multi-call request: %d", len(req.calls))
+	}
+	if err := util.WriteUint16(w, uint16(len(req.calls))); err != nil {
+		return err
+	}
+	for _, c := range req.calls {
+		if err := c.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (req *RequestSection) readCalls(r io.Reader) error {
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	req.calls = make([]*RequestCall, n)
+	for i := range req.calls {
+		req.calls[i] = &RequestCall{}
+		if err := req.calls[i].Read(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeMultiCallResults encodes results of the calls into one dictionary, the result of the multi-call request.
+// The result of the call with index i is stored under the 2 byte key i
+func EncodeMultiCallResults(results []dict.Dict) dict.Dict {
+	ret := dict.New()
+	for i, res := range results {
+		if res == nil {
+			res = dict.New()
+		}
+		ret.Set(kv.Key(util.Uint16To2Bytes(uint16(i))), util.MustBytes(res))
+	}
+	return ret
+}
+
+// DecodeMultiCallResults decodes results of the calls from the result of the multi-call request
+func DecodeMultiCallResults(res dict.Dict) ([]dict.Dict, error) {
+	ret := make([]dict.Dict, len(res))
+	for i := range ret {
+		data, ok := res[kv.Key(util.Uint16To2Bytes(uint16(i)))]
+		if !ok {
+			return nil, fmt.Errorf("result of the call #%d not found", i)
+		}
+		ret[i] = dict.New()
+		if err := ret[i].Read(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -57,6 +57,7 @@ type NewRootInitRequestTransactionParams struct {
 	ChainColor           balance.Color
 	ChainAddress         address.Address
 	Description          string
+	Consensus            string // consensus algorithm of the chain, stored in the root contract. Optional
 	OwnerSignatureScheme signaturescheme.SignatureScheme
 	AllInputs            map[valuetransaction.OutputID][]*balance.Balance
 }
@@ -77,6 +78,9 @@ func NewRootInitRequestTransaction(par NewRootInitRequestTransactionParams) (*sc
 	args.AddEncodeSimple(root.ParamChainColor, codec.EncodeColor(par.ChainColor))
 	args.AddEncodeSimple(root.ParamChainAddress, codec.EncodeAddress(par.ChainAddress))
 	args.AddEncodeSimple(root.ParamDescription, codec.EncodeString(par.Description))
+	if par.Consensus != "" {
+		args.AddEncodeSimple(root.ParamConsensus, codec.EncodeString(par.Consensus))
+	}
 	initRequest.WithArgs(args)
 
 	if err := txb.AddRequestSection(initRequest); err != nil {
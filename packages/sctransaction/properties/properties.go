@@ -32,7 +32,8 @@ type properties struct {
 	isOrigin bool
 	// if isState == true: chainID
 	chainID coretypes.ChainID
-	// chainAddress == chainID
+	// chainAddress is the address which holds the chain token.
+	// It is equal to the chainID unless the chain ID is written in the state section
 	chainAddress address.Address
 	// if isState == true: smart contract color
 	stateColor balance.Color
@@ -149,6 +150,12 @@ func (prop *properties) analyzeStateBlock(tx *sctransaction.Transaction) error {
 	if err != nil {
 		return err
 	}
+	if chainID, ok := stateSection.ChainID(); ok {
+		if prop.isOrigin {
+			return errors.New("origin transaction can't contain the chain ID in the state section")
+		}
+		prop.chainID = chainID
+	}
 	if prop.isOrigin {
 		prop.stateColor = balance.Color(prop.txid)
 	} else {
@@ -172,6 +179,9 @@ func (prop *properties) analyzeRequestBlocks(tx *sctransaction.Transaction) erro
 	// sum up transfers of requests by target chain
 	reqTransfersByTargetChain := make(map[coretypes.ChainID]map[balance.Color]int64)
 	for _, req := range tx.Requests() {
+		if req.GasBudget() < 0 {
+			return errors.New("request gas budget can't be negative")
+		}
 		chainid := req.Target().ChainID()
 		m, ok := reqTransfersByTargetChain[chainid]
 		if !ok {
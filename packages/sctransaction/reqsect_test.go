@@ -2,10 +2,17 @@ package sctransaction
 
 import (
 	"bytes"
+	"encoding/hex"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestWriteRead(t *testing.T) {
@@ -20,3 +27,137 @@ func TestWriteRead(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, buf1.Bytes(), buf.Bytes())
 }
+
+func TestWriteReadGasBudget(t *testing.T) {
+	cid := coretypes.NewContractID(coretypes.ChainID{}, root.Interface.Hname())
+	rsec := NewRequestSectionByWallet(cid, coretypes.EntryPointInit).WithGasBudget(12345)
+	var buf bytes.Buffer
+	err := rsec.Write(&buf)
+	require.NoError(t, err)
+	rsecBack := &RequestSection{}
+	err = rsecBack.Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.EqualValues(t, 12345, rsecBack.GasBudget())
+}
+
+func TestWriteReadCallback(t *testing.T) {
+	cid := coretypes.NewContractID(coretypes.ChainID{}, root.Interface.Hname())
+	reqid := coretypes.NewRequestID(valuetransaction.ID{1, 2, 3}, 4)
+	rsec := NewRequestSection(coretypes.Hn("sender"), cid, coretypes.EntryPointInit).
+		WithCallback(coretypes.Hn("callback")).
+		WithDeadline(12345).
+		WithReplyTo(reqid)
+	var buf bytes.Buffer
+	err := rsec.Write(&buf)
+	require.NoError(t, err)
+	rsecBack := &RequestSection{}
+	err = rsecBack.Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.EqualValues(t, coretypes.Hn("callback"), rsecBack.Callback())
+	require.EqualValues(t, 12345, rsecBack.Deadline())
+	require.EqualValues(t, reqid, *rsecBack.ReplyTo())
+}
+
+func TestWriteReadLocks(t *testing.T) {
+	cid := coretypes.NewContractID(coretypes.ChainID{}, root.Interface.Hname())
+	// beyond the range of uint32
+	timelock := time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+	rsec := NewRequestSectionByWallet(cid, coretypes.EntryPointInit).
+		WithTimelockUntil(timelock).
+		WithBlockIndexLock(5).
+		WithDeadlineAt(timelock.Add(time.Hour)).
+		WithGasBudget(12345)
+	var buf bytes.Buffer
+	err := rsec.Write(&buf)
+	require.NoError(t, err)
+	rsecBack := &RequestSection{}
+	err = rsecBack.Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.EqualValues(t, timelock.Unix(), rsecBack.Timelock())
+	require.EqualValues(t, 5, rsecBack.BlockIndexLock())
+	require.EqualValues(t, timelock.Add(time.Hour).Unix(), rsecBack.Deadline())
+	require.EqualValues(t, 12345, rsecBack.GasBudget())
+
+	require.True(t, rsecBack.IsTimeLocked(timelock.Add(-time.Second)))
+	require.False(t, rsecBack.IsTimeLocked(timelock))
+	require.True(t, rsecBack.IsBlockIndexLocked(4))
+	require.False(t, rsecBack.IsBlockIndexLocked(5))
+	require.False(t, rsecBack.IsExpired(timelock.Add(time.Hour)))
+	require.True(t, rsecBack.IsExpired(timelock.Add(time.Hour+time.Second)))
+}
+
+// baselineEncoded is the request section encoded by the original version of the package before the versions
+// were introduced: sender "sender", target "target" on the chain {1, 2, 3}, entry point "entry",
+// timelock 1000, argument "a" = {1, 2} and transfer of 10 iotas
+const baselineEncoded = "5775c0ab010203000000000000000000000000000000000000000000000000000000000000874c10b4e8030000bfa957560100000000000000010061020000000102010000000000000000000000000000000000000000000000000000000000000000000a00000000000000"
+
+func TestReadVersion0(t *testing.T) {
+	data, err := hex.DecodeString(baselineEncoded)
+	require.NoError(t, err)
+
+	rsec := &RequestSection{}
+	err = rsec.Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.EqualValues(t, coretypes.Hn("sender"), rsec.SenderContractHname())
+	require.EqualValues(t, coretypes.NewContractID(coretypes.ChainID{1, 2, 3}, coretypes.Hn("target")), rsec.Target())
+	require.EqualValues(t, coretypes.Hn("entry"), rsec.EntryPointCode())
+	require.EqualValues(t, 1000, rsec.Timelock())
+	require.EqualValues(t, 0, rsec.BlockIndexLock())
+	require.EqualValues(t, 0, rsec.GasBudget())
+	require.EqualValues(t, 0, rsec.Callback())
+	require.EqualValues(t, 0, rsec.Deadline())
+	require.Nil(t, rsec.ReplyTo())
+	require.EqualValues(t, 10, rsec.Transfer().Balance(balance.ColorIOTA))
+
+	// the section without new features is written in the version 0
+	var buf bytes.Buffer
+	require.NoError(t, rsec.Write(&buf))
+	require.EqualValues(t, data, buf.Bytes())
+
+	// any of the new features switches to the version 1
+	buf.Reset()
+	require.NoError(t, rsec.Clone().WithGasBudget(1).Write(&buf))
+	var marker uint32
+	require.NoError(t, util.ReadUint32(bytes.NewReader(buf.Bytes()[coretypes.HnameLength+coretypes.ContractIDLength:]), &marker))
+	require.EqualValues(t, requestSectionVersionMarker, marker)
+}
+
+func TestWriteReadMultiCall(t *testing.T) {
+	chainID := coretypes.ChainID{1, 2, 3}
+	params := dict.New()
+	params.Set("a", []byte{1})
+	rsec := NewMultiCallRequestSection(0, chainID,
+		NewRequestCall(coretypes.Hn("c1"), coretypes.Hn("f1"), params, cbalances.NewIotasOnly(5)),
+		NewRequestCall(coretypes.Hn("c2"), coretypes.Hn("f2"), nil, nil),
+	).WithTransfer(cbalances.NewIotasOnly(10))
+	require.True(t, rsec.IsMultiCall())
+	var buf bytes.Buffer
+	err := rsec.Write(&buf)
+	require.NoError(t, err)
+	rsecBack := &RequestSection{}
+	err = rsecBack.Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.True(t, rsecBack.IsMultiCall())
+	require.Len(t, rsecBack.Calls(), 2)
+	require.EqualValues(t, coretypes.Hn("c1"), rsecBack.Calls()[0].Contract)
+	require.EqualValues(t, coretypes.Hn("f1"), rsecBack.Calls()[0].EntryPoint)
+	require.EqualValues(t, []byte{1}, rsecBack.Calls()[0].Params.MustGet("a"))
+	require.EqualValues(t, 5, rsecBack.Calls()[0].Transfer.Balance(balance.ColorIOTA))
+	require.EqualValues(t, coretypes.Hn("c2"), rsecBack.Calls()[1].Contract)
+	require.True(t, rsecBack.Calls()[1].Params.IsEmpty())
+	require.EqualValues(t, 10, rsecBack.Transfer().Balance(balance.ColorIOTA))
+
+	var buf1 bytes.Buffer
+	require.NoError(t, rsecBack.Clone().Write(&buf1))
+	require.EqualValues(t, buf.Bytes(), buf1.Bytes())
+}
+
+func TestMultiCallResults(t *testing.T) {
+	res1 := dict.New()
+	res1.Set("x", []byte{42})
+	res, err := DecodeMultiCallResults(EncodeMultiCallResults([]dict.Dict{res1, nil}))
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.EqualValues(t, []byte{42}, res[0].MustGet("x"))
+	require.True(t, res[1].IsEmpty())
+}
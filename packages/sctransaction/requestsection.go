@@ -13,11 +13,20 @@ import (
 	"github.com/iotaledger/wasp/packages/util"
 )
 
-// FIXME timelock uint32 ref Year 2038 problem https://en.wikipedia.org/wiki/Year_2038_problem
-// signed int32 can store values uo to 03:14:07 UTC on 19 January 2038
-// But if we use uint32 we should extend the range twice, something like until 2092. Not a problem ???
-// other wise we can use values in 'timelock' field of the request block
-// counting from 2020.01.01 Then it would extend until 2140 or so
+// Request sections are encoded in one of three versions:
+//   - version 0 is the original one with the uint32 timelock only
+//   - version 1 adds the 64-bit timelock, the block index lock, the gas budget, the callback, the deadline and
+//     the ID of the request replied to. It is marked by the 0xFFFFFFFF in place of the uint32 timelock
+//     of the version 0, followed by the version byte
+//   - version 2 is the version 1 followed by the calls of the multi-call request after the transfer
+//
+// The version 0 is written whenever it can represent the section, so sections without new features
+// are encoded the same way as before
+const (
+	requestSectionVersionMarker = uint32(0xFFFFFFFF)
+	requestSectionVersion1      = byte(1)
+	requestSectionVersion2      = byte(2)
+)
 
 type RequestSection struct {
 	// senderAddress contract index
@@ -33,7 +42,13 @@ type RequestSection struct {
 	// specified moment. It is guaranteed that timestamp of the state transaction which
 	// settles the request is greater or equal to the request timelock.
 	// 0 timelock naturally means it has no effect
-	timelock uint32
+	timelock int64
+	// blockIndexLock is the minimal index of the block which may settle the request.
+	// 0 means no lock
+	blockIndexLock uint32
+	// gasBudget is the maximum amount of gas the request may burn in the VM.
+	// 0 means the default budget of the VM is in effect
+	gasBudget int64
 	// request arguments, not decoded yet wrt blobRefs
 	args requestargs.RequestArgs
 	// decoded args, if not nil. If nil, it means it wasn't
@@ -41,6 +56,22 @@ type RequestSection struct {
 	solidArgs dict.Dict
 	// all tokens transferred with the request EXCEPT the 1 minted request token
 	transfer coretypes.ColoredBalances
+	// callback is the entry point of the sender contract which receives the result of the request.
+	// 0 means no callback
+	callback coretypes.Hname
+	// deadline (expiry) in Unix seconds. The request which is not processed by the deadline fails with the timeout
+	// and the transfer is refunded.
+	// For the callback request it is the deadline of the original request: non-zero means the timeout
+	// request is pending on the chain of the sender.
+	// 0 means no deadline
+	deadline int64
+	// replyTo is the ID of the request, the result or the timeout of which is delivered by the callback request.
+	// Transaction ID is zero for the timeout request, which is posted in the same transaction as the request.
+	// nil if it is not a callback request
+	replyTo *coretypes.RequestID
+	// calls of the multi-call request, which are run by the VM in order instead of the entry point of the target.
+	// nil if it is not a multi-call request
+	calls []*RequestCall
 }
 
 type RequestRef struct {
@@ -65,6 +96,10 @@ func NewRequestSectionByWallet(targetContract coretypes.ContractID, entryPointCo
 }
 
 func (req *RequestSection) String() string {
+	if req.IsMultiCall() {
+		return fmt.Sprintf("[[sender contract: %s, target: %s, calls: %v]]",
+			req.senderContractHname.String(), req.targetContractID.String(), req.calls)
+	}
 	return fmt.Sprintf("[[sender contract: %s, target: %s, entry point: '%s', args: %s]]",
 		req.senderContractHname.String(), req.targetContractID.String(), req.entryPoint.String(), req.args.String())
 }
@@ -75,8 +110,21 @@ func (req *RequestSection) Clone() *RequestSection {
 	}
 	ret := NewRequestSection(req.senderContractHname, req.targetContractID, req.entryPoint).
 		WithTimelock(req.timelock).
-		WithTransfer(req.transfer)
+		WithBlockIndexLock(req.blockIndexLock).
+		WithGasBudget(req.gasBudget).
+		WithTransfer(req.transfer).
+		WithCallback(req.callback).
+		WithDeadline(req.deadline)
+	if req.replyTo != nil {
+		ret.WithReplyTo(*req.replyTo)
+	}
 	ret.args = req.args.Clone()
+	if req.calls != nil {
+		ret.calls = make([]*RequestCall, len(req.calls))
+		for i, c := range req.calls {
+			ret.calls[i] = c.Clone()
+		}
+	}
 	return ret
 }
 
@@ -88,12 +136,33 @@ func (req *RequestSection) Target() coretypes.ContractID {
 	return req.targetContractID
 }
 
+// WithTarget sets the target contract. The chain of the target is the address the request is sent to
+func (req *RequestSection) WithTarget(target coretypes.ContractID) *RequestSection {
+	req.targetContractID = target
+	return req
+}
+
 // WithArgs sets encoded args
 func (req *RequestSection) WithArgs(args requestargs.RequestArgs) *RequestSection {
 	req.args = args
 	return req
 }
 
+// ArgsSize returns the size of the encoded args in bytes.
+// For the multi-call request it includes the size of the encoded calls
+func (req *RequestSection) ArgsSize() int {
+	ret := len(util.MustBytes(req.args))
+	for _, c := range req.calls {
+		ret += len(util.MustBytes(c))
+	}
+	return ret
+}
+
+// EncryptedArgs returns arguments encrypted for the committee, if any. They are decrypted by the VM
+func (req *RequestSection) EncryptedArgs() ([]byte, bool) {
+	return req.args.Encrypted()
+}
+
 // SolidArgs returns solid args if decoded already or nil otherwise
 func (req *RequestSection) SolidArgs() dict.Dict {
 	return req.solidArgs
@@ -119,19 +188,66 @@ func (req *RequestSection) EntryPointCode() coretypes.Hname {
 	return req.entryPoint
 }
 
-func (req *RequestSection) Timelock() uint32 {
+func (req *RequestSection) Timelock() int64 {
 	return req.timelock
 }
 
+func (req *RequestSection) BlockIndexLock() uint32 {
+	return req.blockIndexLock
+}
+
+func (req *RequestSection) GasBudget() int64 {
+	return req.gasBudget
+}
+
 func (req *RequestSection) Transfer() coretypes.ColoredBalances {
 	return req.transfer
 }
 
-func (req *RequestSection) WithTimelock(tl uint32) *RequestSection {
+func (req *RequestSection) Callback() coretypes.Hname {
+	return req.callback
+}
+
+func (req *RequestSection) Deadline() int64 {
+	return req.deadline
+}
+
+// ReplyTo returns ID of the request the callback request replies to, or nil if it is not a callback request
+func (req *RequestSection) ReplyTo() *coretypes.RequestID {
+	return req.replyTo
+}
+
+func (req *RequestSection) WithTimelock(tl int64) *RequestSection {
 	req.timelock = tl
 	return req
 }
 
+// WithBlockIndexLock sets the minimal index of the block which may settle the request
+func (req *RequestSection) WithBlockIndexLock(blockIndex uint32) *RequestSection {
+	req.blockIndexLock = blockIndex
+	return req
+}
+
+func (req *RequestSection) WithGasBudget(gasBudget int64) *RequestSection {
+	req.gasBudget = gasBudget
+	return req
+}
+
+func (req *RequestSection) WithCallback(callback coretypes.Hname) *RequestSection {
+	req.callback = callback
+	return req
+}
+
+func (req *RequestSection) WithDeadline(deadline int64) *RequestSection {
+	req.deadline = deadline
+	return req
+}
+
+func (req *RequestSection) WithReplyTo(reqid coretypes.RequestID) *RequestSection {
+	req.replyTo = &reqid
+	return req
+}
+
 func (req *RequestSection) WithTransfer(transfer coretypes.ColoredBalances) *RequestSection {
 	if transfer == nil {
 		transfer = cbalances.NewFromMap(nil)
@@ -141,7 +257,27 @@ func (req *RequestSection) WithTransfer(transfer coretypes.ColoredBalances) *Req
 }
 
 func (req *RequestSection) WithTimelockUntil(deadline time.Time) *RequestSection {
-	return req.WithTimelock(uint32(deadline.Unix()))
+	return req.WithTimelock(deadline.Unix())
+}
+
+// WithDeadlineAt sets the deadline (expiry) of the request
+func (req *RequestSection) WithDeadlineAt(deadline time.Time) *RequestSection {
+	return req.WithDeadline(deadline.Unix())
+}
+
+// IsTimeLocked returns true if the request can't be processed at the time
+func (req *RequestSection) IsTimeLocked(nowis time.Time) bool {
+	return req.timelock > nowis.Unix()
+}
+
+// IsBlockIndexLocked returns true if the request can't be settled by the block with the index
+func (req *RequestSection) IsBlockIndexLocked(blockIndex uint32) bool {
+	return req.blockIndexLock > blockIndex
+}
+
+// IsExpired returns true if the request is processed after its deadline
+func (req *RequestSection) IsExpired(nowis time.Time) bool {
+	return req.deadline != 0 && nowis.Unix() > req.deadline
 }
 
 // encoding
@@ -153,8 +289,14 @@ func (req *RequestSection) Write(w io.Writer) error {
 	if err := req.targetContractID.Write(w); err != nil {
 		return err
 	}
-	if err := util.WriteUint32(w, req.timelock); err != nil {
-		return err
+	if req.isVersion0() {
+		if err := util.WriteUint32(w, uint32(req.timelock)); err != nil {
+			return err
+		}
+	} else {
+		if err := req.writeVersion1(w); err != nil {
+			return err
+		}
 	}
 	if err := req.entryPoint.Write(w); err != nil {
 		return err
@@ -165,6 +307,9 @@ func (req *RequestSection) Write(w io.Writer) error {
 	if err := cbalances.WriteColoredBalances(w, req.transfer); err != nil {
 		return err
 	}
+	if req.IsMultiCall() {
+		return req.writeCalls(w)
+	}
 	return nil
 }
 
@@ -175,7 +320,8 @@ func (req *RequestSection) Read(r io.Reader) error {
 	if err := req.targetContractID.Read(r); err != nil {
 		return err
 	}
-	if err := util.ReadUint32(r, &req.timelock); err != nil {
+	version, err := req.readVersioned(r)
+	if err != nil {
 		return err
 	}
 	if err := req.entryPoint.Read(r); err != nil {
@@ -185,13 +331,112 @@ func (req *RequestSection) Read(r io.Reader) error {
 	if err := req.args.Read(r); err != nil {
 		return err
 	}
-	var err error
 	if req.transfer, err = cbalances.ReadColoredBalance(r); err != nil {
 		return err
 	}
+	req.calls = nil
+	if version == requestSectionVersion2 {
+		return req.readCalls(r)
+	}
+	return nil
+}
+
+// isVersion0 returns true if the section can be encoded in the version 0, i.e. it uses none of the fields
+// added after the version 0
+func (req *RequestSection) isVersion0() bool {
+	return !req.IsMultiCall() && req.blockIndexLock == 0 &&
+		req.timelock >= 0 && req.timelock < int64(requestSectionVersionMarker) &&
+		req.gasBudget == 0 && req.callback == 0 && req.deadline == 0 && req.replyTo == nil
+}
+
+// writeVersion1 writes the version marker followed by the fields of the version 1 which precede the entry point.
+// The version 2 is written for the multi-call request
+func (req *RequestSection) writeVersion1(w io.Writer) error {
+	if err := util.WriteUint32(w, requestSectionVersionMarker); err != nil {
+		return err
+	}
+	version := requestSectionVersion1
+	if req.IsMultiCall() {
+		version = requestSectionVersion2
+	}
+	if err := util.WriteByte(w, version); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, req.timelock); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, req.blockIndexLock); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, req.gasBudget); err != nil {
+		return err
+	}
+	if err := req.callback.Write(w); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, req.deadline); err != nil {
+		return err
+	}
+	if err := util.WriteBoolByte(w, req.replyTo != nil); err != nil {
+		return err
+	}
+	if req.replyTo != nil {
+		return req.replyTo.Write(w)
+	}
 	return nil
 }
 
+// readVersioned reads the fields which precede the entry point in any of the versions and returns the version
+func (req *RequestSection) readVersioned(r io.Reader) (byte, error) {
+	var timelock uint32
+	if err := util.ReadUint32(r, &timelock); err != nil {
+		return 0, err
+	}
+	req.blockIndexLock = 0
+	req.gasBudget = 0
+	req.callback = 0
+	req.deadline = 0
+	req.replyTo = nil
+	if timelock != requestSectionVersionMarker {
+		// version 0
+		req.timelock = int64(timelock)
+		return 0, nil
+	}
+	version, err := util.ReadByte(r)
+	if err != nil {
+		return 0, err
+	}
+	if version != requestSectionVersion1 && version != requestSectionVersion2 {
+		return 0, fmt.Errorf("unsupported version of the request section: %d", version)
+	}
+	if err := util.ReadInt64(r, &req.timelock); err != nil {
+		return 0, err
+	}
+	if err := util.ReadUint32(r, &req.blockIndexLock); err != nil {
+		return 0, err
+	}
+	if err := util.ReadInt64(r, &req.gasBudget); err != nil {
+		return 0, err
+	}
+	if err := req.callback.Read(r); err != nil {
+		return 0, err
+	}
+	if err := util.ReadInt64(r, &req.deadline); err != nil {
+		return 0, err
+	}
+	var isReply bool
+	if err := util.ReadBoolByte(r, &isReply); err != nil {
+		return 0, err
+	}
+	if isReply {
+		req.replyTo = &coretypes.RequestID{}
+		if err := req.replyTo.Read(r); err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
 // request ref
 
 func (ref *RequestRef) RequestSection() *RequestSection {
@@ -216,7 +461,7 @@ func (ref *RequestRef) SenderContractID() (ret coretypes.ContractID, err error)
 		err = fmt.Errorf("request wasn't sent by the smart contract: %s", ref.RequestID().String())
 		return
 	}
-	ret = coretypes.NewContractID((coretypes.ChainID)(*ref.SenderAddress()), ref.SenderContractHname())
+	ret = coretypes.NewContractID(*ref.Tx.MustProperties().MustChainID(), ref.SenderContractHname())
 	return
 }
 
@@ -3,11 +3,26 @@ package sctransaction
 import (
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/util"
 	"io"
 )
 
+// State sections are encoded in one of three versions:
+//   - version 0 is the original one, starting with the color of the chain. It has no state root
+//   - version 1 also contains the state root after the state hash.
+//     It is marked by balance.ColorIOTA in place of the color, which is never the color of a chain,
+//     followed by the version byte
+//   - version 2 is the version 1 followed by the chain ID. It is written only when the chain is controlled
+//     by an address other than the chain ID, i.e. after the committee of the chain was rotated
+//
+// The version 0 is written whenever it can represent the section
+const (
+	stateSectionVersion1 = byte(1)
+	stateSectionVersion2 = byte(2)
+)
+
 // StateSection of the SC transaction. Represents SC state update
 // previous state block can be determined by the chain transfer of the SC token in the UTXO part of the
 // transaction
@@ -22,13 +37,22 @@ type StateSection struct {
 	timestamp int64
 	// stateHash is hash of the state it is locked in the transaction
 	stateHash hashing.HashValue
+	// stateRoot is the root of the Merkle tree of the state variables.
+	// It is used to verify proofs of inclusion of variables into the state.
+	// hashing.NilHash if the section has no state root
+	stateRoot hashing.HashValue
+	// chainID of the chain if it is not the alias of the address holding the chain token. nil otherwise
+	chainID *coretypes.ChainID
 }
 
 type NewStateSectionParams struct {
 	Color      balance.Color
 	BlockIndex uint32
 	StateHash  hashing.HashValue
+	StateRoot  hashing.HashValue
 	Timestamp  int64
+	// ChainID is only set if the chain token is not held by the address equal to the chain ID
+	ChainID *coretypes.ChainID
 }
 
 func NewStateSection(par NewStateSectionParams) *StateSection {
@@ -36,7 +60,9 @@ func NewStateSection(par NewStateSectionParams) *StateSection {
 		color:      par.Color,
 		blockIndex: par.BlockIndex,
 		stateHash:  par.StateHash,
+		stateRoot:  par.StateRoot,
 		timestamp:  par.Timestamp,
+		chainID:    par.ChainID,
 	}
 }
 
@@ -48,7 +74,9 @@ func (sb *StateSection) Clone() *StateSection {
 		Color:      sb.color,
 		BlockIndex: sb.blockIndex,
 		StateHash:  sb.stateHash,
+		StateRoot:  sb.stateRoot,
 		Timestamp:  sb.timestamp,
+		ChainID:    sb.chainID,
 	})
 }
 
@@ -72,6 +100,34 @@ func (sb *StateSection) StateHash() hashing.HashValue {
 	return sb.stateHash
 }
 
+func (sb *StateSection) StateRoot() hashing.HashValue {
+	return sb.stateRoot
+}
+
+// HasStateRoot returns false if the section has no state root, e.g. it was written in the version 0
+func (sb *StateSection) HasStateRoot() bool {
+	return sb.stateRoot != hashing.NilHash
+}
+
+// ChainID returns the chain ID written in the section. It is false if the chain ID is the alias
+// of the address which holds the chain token
+func (sb *StateSection) ChainID() (coretypes.ChainID, bool) {
+	if sb.chainID == nil {
+		return coretypes.ChainID{}, false
+	}
+	return *sb.chainID, true
+}
+
+func (sb *StateSection) WithChainID(chainID *coretypes.ChainID) *StateSection {
+	sb.chainID = chainID
+	return sb
+}
+
+func (sb *StateSection) WithStateRoot(root hashing.HashValue) *StateSection {
+	sb.stateRoot = root
+	return sb
+}
+
 func (sb *StateSection) WithStateParams(stateIndex uint32, h hashing.HashValue, ts int64) *StateSection {
 	sb.blockIndex = stateIndex
 	sb.stateHash = h
@@ -82,6 +138,15 @@ func (sb *StateSection) WithStateParams(stateIndex uint32, h hashing.HashValue,
 // encoding
 
 func (sb *StateSection) Write(w io.Writer) error {
+	version := sb.version()
+	if version != 0 {
+		if _, err := w.Write(balance.ColorIOTA[:]); err != nil {
+			return err
+		}
+		if err := util.WriteByte(w, version); err != nil {
+			return err
+		}
+	}
 	if _, err := w.Write(sb.color[:]); err != nil {
 		return err
 	}
@@ -94,6 +159,15 @@ func (sb *StateSection) Write(w io.Writer) error {
 	if err := sb.stateHash.Write(w); err != nil {
 		return err
 	}
+	if version == 0 {
+		return nil
+	}
+	if err := sb.stateRoot.Write(w); err != nil {
+		return err
+	}
+	if version == stateSectionVersion2 {
+		return sb.chainID.Write(w)
+	}
 	return nil
 }
 
@@ -101,6 +175,19 @@ func (sb *StateSection) Read(r io.Reader) error {
 	if n, err := r.Read(sb.color[:]); err != nil || n != balance.ColorLength {
 		return fmt.Errorf("error while reading color: %v", err)
 	}
+	version := byte(0)
+	if sb.color == balance.ColorIOTA {
+		var err error
+		if version, err = util.ReadByte(r); err != nil {
+			return err
+		}
+		if version != stateSectionVersion1 && version != stateSectionVersion2 {
+			return fmt.Errorf("unsupported version of the state section: %d", version)
+		}
+		if n, err := r.Read(sb.color[:]); err != nil || n != balance.ColorLength {
+			return fmt.Errorf("error while reading color: %v", err)
+		}
+	}
 	if err := util.ReadUint32(r, &sb.blockIndex); err != nil {
 		return err
 	}
@@ -112,5 +199,28 @@ func (sb *StateSection) Read(r io.Reader) error {
 	if err := sb.stateHash.Read(r); err != nil {
 		return err
 	}
+	sb.stateRoot = hashing.NilHash
+	sb.chainID = nil
+	if version == 0 {
+		return nil
+	}
+	if err := sb.stateRoot.Read(r); err != nil {
+		return err
+	}
+	if version == stateSectionVersion2 {
+		sb.chainID = new(coretypes.ChainID)
+		return sb.chainID.Read(r)
+	}
 	return nil
 }
+
+// version returns the version the section is written in
+func (sb *StateSection) version() byte {
+	switch {
+	case sb.chainID != nil:
+		return stateSectionVersion2
+	case sb.HasStateRoot():
+		return stateSectionVersion1
+	}
+	return 0
+}
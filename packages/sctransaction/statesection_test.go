@@ -0,0 +1,78 @@
+package sctransaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateSectionWriteRead(t *testing.T) {
+	sect := NewStateSection(NewStateSectionParams{
+		Color:      balance.Color{1, 2, 3},
+		BlockIndex: 42,
+		StateHash:  hashing.HashStrings("state"),
+		StateRoot:  hashing.HashStrings("root"),
+		Timestamp:  12345,
+	})
+	var buf bytes.Buffer
+	require.NoError(t, sect.Write(&buf))
+	// the section with the state root is versioned
+	require.EqualValues(t, balance.ColorIOTA[:], buf.Bytes()[:balance.ColorLength])
+
+	back := &StateSection{}
+	require.NoError(t, back.Read(bytes.NewReader(buf.Bytes())))
+	require.EqualValues(t, sect, back)
+	_, ok := back.ChainID()
+	require.False(t, ok)
+}
+
+func TestStateSectionWriteReadChainID(t *testing.T) {
+	chainID := coretypes.NewRandomChainID()
+	sect := NewStateSection(NewStateSectionParams{
+		Color:      balance.Color{1, 2, 3},
+		BlockIndex: 42,
+		StateHash:  hashing.HashStrings("state"),
+		StateRoot:  hashing.HashStrings("root"),
+		Timestamp:  12345,
+		ChainID:    &chainID,
+	})
+	var buf bytes.Buffer
+	require.NoError(t, sect.Write(&buf))
+
+	back := &StateSection{}
+	require.NoError(t, back.Read(bytes.NewReader(buf.Bytes())))
+	require.EqualValues(t, sect, back)
+	backChainID, ok := back.ChainID()
+	require.True(t, ok)
+	require.EqualValues(t, chainID, backChainID)
+
+	var buf1 bytes.Buffer
+	require.NoError(t, back.Write(&buf1))
+	require.EqualValues(t, buf.Bytes(), buf1.Bytes())
+}
+
+// baselineStateSection is the state section encoded by the original version of the package before the versions
+// were introduced: color {1, 2, 3}, block index 42, timestamp 12345 and state hash of "state"
+const baselineStateSection = "01020300000000000000000000000000000000000000000000000000000000002a00000039300000000000000ced162a56b08dffb00f664b30b788b95dc961e36f5edc6ae67ba2d5261282f1"
+
+func TestStateSectionReadVersion0(t *testing.T) {
+	data, err := hex.DecodeString(baselineStateSection)
+	require.NoError(t, err)
+	back := &StateSection{}
+	require.NoError(t, back.Read(bytes.NewReader(data)))
+	require.EqualValues(t, balance.Color{1, 2, 3}, back.Color())
+	require.EqualValues(t, 42, back.BlockIndex())
+	require.EqualValues(t, 12345, back.Timestamp())
+	require.EqualValues(t, hashing.HashStrings("state"), back.StateHash())
+	require.False(t, back.HasStateRoot())
+
+	// the section without the state root is written in the version 0
+	var buf bytes.Buffer
+	require.NoError(t, back.Write(&buf))
+	require.EqualValues(t, data, buf.Bytes())
+}
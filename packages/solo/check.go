@@ -10,8 +10,11 @@ import (
 	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
 	"github.com/iotaledger/wasp/packages/vm/core/accounts"
 	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
 	"github.com/iotaledger/wasp/packages/vm/core/eventlog"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
 	"github.com/stretchr/testify/require"
 )
 
@@ -52,6 +55,27 @@ func (ch *Chain) CheckChain() {
 	require.EqualValues(ch.Env.T, eventlog.Interface.ProgramHash, chainlogRec.ProgramHash)
 	require.EqualValues(ch.Env.T, ch.OriginatorAgentID, chainlogRec.Creator)
 
+	blocklogRec, err := ch.FindContract(blocklog.Interface.Name)
+	require.NoError(ch.Env.T, err)
+	require.EqualValues(ch.Env.T, blocklog.Interface.Name, blocklogRec.Name)
+	require.EqualValues(ch.Env.T, blocklog.Interface.Description, blocklogRec.Description)
+	require.EqualValues(ch.Env.T, blocklog.Interface.ProgramHash, blocklogRec.ProgramHash)
+	require.EqualValues(ch.Env.T, ch.OriginatorAgentID, blocklogRec.Creator)
+
+	receiptsRec, err := ch.FindContract(receipts.Interface.Name)
+	require.NoError(ch.Env.T, err)
+	require.EqualValues(ch.Env.T, receipts.Interface.Name, receiptsRec.Name)
+	require.EqualValues(ch.Env.T, receipts.Interface.Description, receiptsRec.Description)
+	require.EqualValues(ch.Env.T, receipts.Interface.ProgramHash, receiptsRec.ProgramHash)
+	require.EqualValues(ch.Env.T, ch.OriginatorAgentID, receiptsRec.Creator)
+
+	schedulerRec, err := ch.FindContract(scheduler.Interface.Name)
+	require.NoError(ch.Env.T, err)
+	require.EqualValues(ch.Env.T, scheduler.Interface.Name, schedulerRec.Name)
+	require.EqualValues(ch.Env.T, scheduler.Interface.Description, schedulerRec.Description)
+	require.EqualValues(ch.Env.T, scheduler.Interface.ProgramHash, schedulerRec.ProgramHash)
+	require.EqualValues(ch.Env.T, ch.OriginatorAgentID, schedulerRec.Creator)
+
 	ch.CheckAccountLedger()
 }
 
@@ -71,3 +95,8 @@ func (ch *Chain) CheckAccountLedger() {
 func (ch *Chain) AssertAccountBalance(agentID coretypes.AgentID, col balance.Color, bal int64) {
 	require.EqualValues(ch.Env.T, bal, ch.GetAccountBalance(agentID).Balance(col))
 }
+
+// AssertFeesCharged asserts the fees charged for the last request processed by the VM
+func (ch *Chain) AssertFeesCharged(expected int64) {
+	require.EqualValues(ch.Env.T, expected, ch.LastFeesCharged())
+}
@@ -0,0 +1,41 @@
+package solo
+
+import (
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+	"go.dedis.ch/kyber/v3/util/key"
+)
+
+var committeeSuite = pairing.NewSuiteBn256()
+
+// committeeKey simulates the key share of the committee of one node.
+// It is used to encrypt and decrypt request arguments
+type committeeKey struct {
+	*key.Pair
+}
+
+func newCommitteeKey() committeeKey {
+	return committeeKey{key.NewKeyPair(committeeSuite)}
+}
+
+// Decrypt implements vm.Decryptor
+func (k committeeKey) Decrypt(data []byte) ([]byte, error) {
+	return tcrypto.DecryptWithShare(committeeSuite, 0, k.Private, []kyber.Point{k.Public}, data)
+}
+
+// WithEncryption makes arguments of the request encrypted for the committee of the chain.
+// Only the VM can see them in plain form
+func (r *CallParams) WithEncryption() *CallParams {
+	r.encrypt = true
+	return r
+}
+
+func (ch *Chain) encryptArgs(args requestargs.RequestArgs) requestargs.RequestArgs {
+	data, err := tcrypto.EncryptToShares(committeeSuite, []kyber.Point{ch.committeeKey.Public}, util.MustBytes(args))
+	require.NoError(ch.Env.T, err)
+	return requestargs.New(nil).AddEncrypted(data)
+}
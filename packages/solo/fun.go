@@ -14,16 +14,26 @@ import (
 	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
 	"github.com/iotaledger/wasp/packages/kv/codec"
 	"github.com/iotaledger/wasp/packages/kv/collections"
 	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/state/merkle"
+	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm/core/accounts"
 	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
 	"github.com/iotaledger/wasp/packages/vm/core/eventlog"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
 	"github.com/iotaledger/wasp/plugins/wasmtimevm"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
+	"math"
+	"sort"
+	"time"
 )
 
 // String is string representation for main parameters of the chain
@@ -235,6 +245,17 @@ func (ch *Chain) DeployContract(sigScheme signaturescheme.SignatureScheme, name
 	return err
 }
 
+// UpgradeContract replaces the program of the deployed contract with the given name by the 'programHash'.
+// The hname, the state and the balances of the contract remain the same. Only the creator of the contract
+// or the chain owner can upgrade it. Optional 'params' are passed to the 'migrate' entry point of the new program
+func (ch *Chain) UpgradeContract(sigScheme signaturescheme.SignatureScheme, name string, programHash hashing.HashValue, params ...interface{}) error {
+	par := []interface{}{root.ParamProgramHash, programHash, root.ParamHname, coretypes.Hn(name)}
+	par = append(par, params...)
+	req := NewCallParams(root.Interface.Name, root.FuncUpgradeContract, par...)
+	_, err := ch.PostRequestSync(req, sigScheme)
+	return err
+}
+
 // DeployWasmContract is syntactic sugar for uploading Wasm binary from file and
 // deploying the smart contract in one call
 func (ch *Chain) DeployWasmContract(sigScheme signaturescheme.SignatureScheme, name string, fname string, params ...interface{}) error {
@@ -378,6 +399,167 @@ func (ch *Chain) GetFeeInfo(contractName string) (balance.Color, int64, int64) {
 	return feeColor, ownerFee, validatorFee
 }
 
+// EstimateFee calls the view in the 'root' contract to estimate the maximum fee the request
+// will be charged when posted by the sigScheme. If sigScheme == nil, the originator is the sender.
+// The unused part of the gas fee is refunded after the request is run
+func (ch *Chain) EstimateFee(req *CallParams, sigScheme signaturescheme.SignatureScheme) int64 {
+	if sigScheme == nil {
+		sigScheme = ch.OriginatorSigScheme
+	}
+	ret, err := ch.CallView(root.Interface.Name, root.FuncEstimateFee,
+		root.ParamHname, req.target,
+		root.ParamEntryPoint, req.entryPoint,
+		root.ParamPayloadSize, len(util.MustBytes(req.args)),
+		root.ParamGasBudget, req.gasBudget,
+		root.ParamAgentID, coretypes.NewAgentIDFromAddress(sigScheme.Address()),
+	)
+	require.NoError(ch.Env.T, err)
+	fee, ok, err := codec.DecodeInt64(ret.MustGet(root.ParamFeeTotal))
+	require.NoError(ch.Env.T, err)
+	require.True(ch.Env.T, ok)
+	return fee
+}
+
+// LastFeesCharged returns the fees charged for the last request processed by the VM, as recorded in its receipt
+func (ch *Chain) LastFeesCharged() int64 {
+	info, err := ch.GetBlockInfo(ch.State.BlockIndex())
+	require.NoError(ch.Env.T, err)
+	require.NotEqualValues(ch.Env.T, 0, len(info.RequestIDs))
+	rec, err := ch.GetRequestReceipt(info.RequestIDs[len(info.RequestIDs)-1])
+	require.NoError(ch.Env.T, err)
+	require.NotNil(ch.Env.T, rec)
+	return rec.FeesCharged
+}
+
+// GetStateRoot returns the root of the Merkle tree of the chain state, committed in the anchor transaction
+func (ch *Chain) GetStateRoot() hashing.HashValue {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+	return ch.StateTx.MustState().StateRoot()
+}
+
+// GetStateProof returns the proof of inclusion of the key into the current state of the chain,
+// or the proof of non-inclusion if the key doesn't exist.
+// Keys of the contract state are prefixed with the hname of the contract
+func (ch *Chain) GetStateProof(key kv.Key) *merkle.Proof {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+	ret, err := ch.State.Proof(key)
+	require.NoError(ch.Env.T, err)
+	return ret
+}
+
+// LastGasBurned returns the amount of gas burned by the last request processed by the VM
+func (ch *Chain) LastGasBurned() int64 {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+	return ch.lastGasBurned
+}
+
+// LastReadWriteSet returns keys of the chain state read and written by the last request processed by the VM.
+// Keys of the contract state are prefixed with the hname of the contract
+func (ch *Chain) LastReadWriteSet() *buffered.ReadWriteSet {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+	return ch.lastReadWriteSet
+}
+
+// SetVMParallelism sets the number of requests of the batch the VM runs speculatively in parallel.
+// 1 means requests are run one by one. By default it is the number of CPUs
+func (ch *Chain) SetVMParallelism(n int) {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+	ch.vmParallelism = n
+}
+
+// GetBlockInfo calls the view in the 'blocklog' core smart contract to retrieve
+// the record of the block with the given index
+func (ch *Chain) GetBlockInfo(blockIndex uint32) (*blocklog.BlockInfo, error) {
+	res, err := ch.CallView(blocklog.Interface.Name, blocklog.FuncGetBlockInfo,
+		blocklog.ParamBlockIndex, blockIndex,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deco := kvdecoder.New(res, ch.Log)
+	ret := &blocklog.BlockInfo{
+		BlockIndex: uint32(deco.MustGetInt64(blocklog.ParamBlockIndex)),
+		Timestamp:  deco.MustGetInt64(blocklog.ParamTimestamp),
+	}
+	reqs := collections.NewArrayReadOnly(res, blocklog.ParamRequestIDs)
+	ret.RequestIDs = make([]coretypes.RequestID, reqs.MustLen())
+	for i := range ret.RequestIDs {
+		ret.RequestIDs[i], err = coretypes.NewRequestIDFromBytes(reqs.MustGetAt(uint16(i)))
+		require.NoError(ch.Env.T, err)
+	}
+	return ret, nil
+}
+
+// GetRequestReceipt calls the view in the 'receipts' core smart contract to retrieve
+// the receipt of the request. It returns nil if the request wasn't processed by the chain
+func (ch *Chain) GetRequestReceipt(reqid coretypes.RequestID) (*receipts.RequestReceipt, error) {
+	res, err := ch.CallView(receipts.Interface.Name, receipts.FuncGetRequestReceipt,
+		receipts.ParamRequestID, reqid,
+	)
+	if err != nil {
+		return nil, err
+	}
+	data := res.MustGet(receipts.ParamReceipt)
+	if data == nil {
+		return nil, nil
+	}
+	return receipts.DecodeRequestReceipt(data)
+}
+
+// GetSchedules calls the view in the 'scheduler' core smart contract to retrieve
+// the pending scheduled calls, ordered by ID. Finished schedules are deleted by the scheduler
+func (ch *Chain) GetSchedules() ([]*scheduler.Schedule, error) {
+	res, err := ch.CallView(scheduler.Interface.Name, scheduler.FuncGetSchedules)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*scheduler.Schedule, 0, len(res))
+	for _, data := range res {
+		s, err := scheduler.DecodeSchedule(data)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, s)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ID < ret[j].ID
+	})
+	return ret, nil
+}
+
+// RunScheduledCalls advances the logical clock by the step and waits until all requests unlocked
+// by the new time, including the 'tick' requests of the scheduler, are processed.
+// It returns the number of calls made during the step by the ID of the schedule, as recorded
+// in the event log of the scheduler. Schedules which were not called are not included
+func (ch *Chain) RunScheduledCalls(step time.Duration) map[uint32]uint32 {
+	from := ch.Env.LogicalTime().UnixNano() + 1
+	ch.Env.AdvanceClockBy(step)
+	ch.waitForBacklog(ch.unlockedBacklogLen)
+
+	res, err := ch.CallView(eventlog.Interface.Name, eventlog.FuncGetRecords,
+		eventlog.ParamContractHname, scheduler.Interface.Hname(),
+		eventlog.ParamFromTs, from,
+		eventlog.ParamMaxLastRecords, math.MaxInt32,
+	)
+	require.NoError(ch.Env.T, err)
+	recs := collections.NewArrayReadOnly(res, eventlog.ParamRecords)
+	ret := make(map[uint32]uint32)
+	for i := uint16(0); i < recs.MustLen(); i++ {
+		rec, err := collections.ParseRawLogRecord(recs.MustGetAt(i))
+		require.NoError(ch.Env.T, err)
+		var id uint32
+		if _, err := fmt.Sscanf(string(rec.Data), "[scheduled call] id: %d,", &id); err == nil {
+			ret[id]++
+		}
+	}
+	return ret
+}
+
 // GetEventLogRecords calls the view in the  'eventlog' core smart contract to retrieve
 // latest up to 50 records for a given smart contract.
 // It returns records as array in time-descending order.
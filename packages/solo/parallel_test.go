@@ -0,0 +1,261 @@
+package solo
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/contracts/native"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/iotaledger/wasp/packages/vm/statetxbuilder"
+	"github.com/iotaledger/wasp/packages/vm/vmcontext"
+	"github.com/stretchr/testify/require"
+)
+
+// test token contract with the balance of each agent in its own key, so transfers between
+// different pairs of agents are independent
+var tokenContract = &coreutil.ContractInterface{
+	Name:        "parallelToken",
+	Description: "Token contract for parallel execution tests",
+	ProgramHash: hashing.HashStrings("parallelToken"),
+}
+
+const (
+	tokenName         = "parallelToken"
+	tokenFuncMint     = "mint"
+	tokenFuncTransfer = "transfer"
+	tokenViewBalance  = "balance"
+	tokenParamAgentID = "a"
+	tokenParamAmount  = "n"
+)
+
+var registerTokenContractOnce sync.Once
+
+// registerTokenContract adds the processor of the token contract to the native processors.
+// It is called by the setup of each test, the processor is added only once
+func registerTokenContract() {
+	registerTokenContractOnce.Do(func() {
+		tokenContract.WithFunctions(func(ctx coretypes.Sandbox) (dict.Dict, error) { return nil, nil }, []coreutil.ContractFunctionInterface{
+			coreutil.Func(tokenFuncMint, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+				params := kvdecoder.New(ctx.Params(), ctx.Log())
+				agentID := params.MustGetAgentID(tokenParamAgentID)
+				addTokens(ctx.State(), agentID, params.MustGetInt64(tokenParamAmount))
+				return nil, nil
+			}),
+			coreutil.Func(tokenFuncTransfer, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+				params := kvdecoder.New(ctx.Params(), ctx.Log())
+				amount := params.MustGetInt64(tokenParamAmount)
+				if tokenBalance(ctx.State(), ctx.Caller()) < amount {
+					return nil, fmt.Errorf("not enough tokens")
+				}
+				addTokens(ctx.State(), ctx.Caller(), -amount)
+				addTokens(ctx.State(), params.MustGetAgentID(tokenParamAgentID), amount)
+				return nil, nil
+			}),
+			coreutil.ViewFunc(tokenViewBalance, func(ctx coretypes.SandboxView) (dict.Dict, error) {
+				params := kvdecoder.New(ctx.Params(), ctx.Log())
+				ret := dict.New()
+				ret.Set(tokenParamAmount, codec.EncodeInt64(tokenBalance(ctx.State(), params.MustGetAgentID(tokenParamAgentID))))
+				return ret, nil
+			}),
+		})
+		native.AddProcessor(tokenContract)
+	})
+}
+
+func tokenBalance(state kv.KVStoreReader, agentID coretypes.AgentID) int64 {
+	ret, _, _ := codec.DecodeInt64(state.MustGet(kv.Key(agentID[:])))
+	return ret
+}
+
+func addTokens(state kv.KVStore, agentID coretypes.AgentID, amount int64) {
+	state.Set(kv.Key(agentID[:]), codec.EncodeInt64(tokenBalance(state, agentID)+amount))
+}
+
+type tokenTest struct {
+	chain   *Chain
+	senders []signaturescheme.SignatureScheme
+}
+
+// setupTokenTest deploys the token contract and mints tokens for each sender
+func setupTokenTest(t *testing.T, numSenders int) *tokenTest {
+	registerTokenContract()
+	env := New(t, false, false)
+	ret := &tokenTest{chain: env.NewChain(nil, "chain1")}
+	require.NoError(t, ret.chain.DeployContract(nil, tokenName, tokenContract.ProgramHash))
+	for i := 0; i < numSenders; i++ {
+		sender := env.NewSignatureSchemeWithFunds()
+		ret.senders = append(ret.senders, sender)
+		_, err := ret.chain.PostRequestSync(NewCallParams(tokenName, tokenFuncMint,
+			tokenParamAgentID, coretypes.NewAgentIDFromAddress(sender.Address()),
+			tokenParamAmount, 100,
+		), nil)
+		require.NoError(t, err)
+	}
+	return ret
+}
+
+// transfer creates the request of the sender to transfer tokens. It is not run
+func (tt *tokenTest) transfer(t *testing.T, sender int, target coretypes.AgentID, amount int64) vm.RequestRefWithFreeTokens {
+	tx := tt.chain.RequestFromParamsToLedger(NewCallParams(tokenName, tokenFuncTransfer,
+		tokenParamAgentID, target,
+		tokenParamAmount, amount,
+	), tt.senders[sender])
+	return requestRef(t, tt.chain, tx)
+}
+
+// requestRef returns the request of the transaction with the solidified arguments
+func requestRef(t testing.TB, ch *Chain, tx *sctransaction.Transaction) vm.RequestRefWithFreeTokens {
+	ret := vm.RequestRefWithFreeTokens{RequestRef: sctransaction.RequestRef{Tx: tx}}
+	ok, err := ret.RequestSection().SolidifyArgs(ch.Env.registry)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return ret
+}
+
+func (tt *tokenTest) senderAgentID(sender int) coretypes.AgentID {
+	return coretypes.NewAgentIDFromAddress(tt.senders[sender].Address())
+}
+
+// runTask runs the batch on the current state of the chain without changing it
+func runTask(ch *Chain, batch []vm.RequestRefWithFreeTokens, entropy hashing.HashValue, parallelism int) *vm.VMTask {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+
+	task := ch.newVMTask(batch)
+	task.Entropy = entropy
+	task.Parallelism = parallelism
+	ch.runVMTask(task)
+	return task
+}
+
+// speculativeCallsTaken runs each request of the batch speculatively on the current state of the chain and then
+// runs the batch sequentially with the results of the speculative calls, the same way as the VM does.
+// Unlike the run of the task, it doesn't depend on how far the speculative runs get ahead of the sequential run.
+// It returns which results of the speculative calls were taken by the sequential run
+func speculativeCallsTaken(t *testing.T, ch *Chain, batch []vm.RequestRefWithFreeTokens) []bool {
+	ch.runVMMutex.Lock()
+	defer ch.runVMMutex.Unlock()
+
+	task := ch.newVMTask(batch)
+	txb, err := statetxbuilder.New(task.ChainID, task.ChainAddress, task.Color, task.Balances)
+	require.NoError(t, err)
+	specs := make([]*vmcontext.SpeculativeCall, len(batch))
+	entropy, timestamp := task.Entropy, task.Timestamp
+	for i := range batch {
+		specs[i] = vmcontext.RunSpeculatively(task, txb, i, uint16(i), entropy, timestamp+int64(i))
+		require.NotNil(t, specs[i])
+		entropy = hashing.HashData(entropy[:])
+	}
+	vmctx, err := vmcontext.NewVMContext(task, txb)
+	require.NoError(t, err)
+	ret := make([]bool, len(batch))
+	for i := range batch {
+		vmctx.RunTheRequest(batch[i], timestamp+int64(i), specs[i])
+		ret[i] = specs[i].Taken()
+	}
+	return ret
+}
+
+func TestParallelBatch(t *testing.T) {
+	tt := setupTokenTest(t, 8)
+	batch := make([]vm.RequestRefWithFreeTokens, 0)
+	recipients := make([]coretypes.AgentID, 8)
+	for i := range tt.senders {
+		recipients[i] = coretypes.NewAgentIDFromAddress(tt.chain.Env.NewSignatureScheme().Address())
+		batch = append(batch, tt.transfer(t, i, recipients[i], 10))
+	}
+	// conflicting requests: transfers to the sender of the previous request, to the same recipient
+	// and the transfer which fails unless the previous requests are run before
+	batch = append(batch,
+		tt.transfer(t, 1, tt.senderAgentID(0), 5),
+		tt.transfer(t, 2, recipients[0], 5),
+		tt.transfer(t, 0, recipients[0], 95),
+	)
+
+	// results of the independent transfers are taken, the conflicting transfers are made again
+	taken := speculativeCallsTaken(t, tt.chain, batch)
+	require.EqualValues(t, []bool{true, true, true, true, true, true, true, true, false, false, false}, taken)
+
+	// the result of the batch doesn't depend on the parallelism
+	entropy := hashing.RandomHash(nil)
+	seq := runTask(tt.chain, batch, entropy, 1)
+	require.EqualValues(t, 0, seq.ResultSpeculativeCalls)
+	for _, parallelism := range []int{2, 4, 16} {
+		par := runTask(tt.chain, batch, entropy, parallelism)
+		require.EqualValues(t, seq.ResultBlock.EssenceHash(), par.ResultBlock.EssenceHash())
+		require.EqualValues(t, seq.ResultTransaction.EssenceBytes(), par.ResultTransaction.EssenceBytes())
+		require.EqualValues(t, seq.ResultGasBurned, par.ResultGasBurned)
+		// conflicting requests are never taken from the speculative runs
+		require.LessOrEqual(t, par.ResultSpeculativeCalls, len(tt.senders))
+		for i := range seq.ResultReadWriteSets {
+			require.EqualValues(t, seq.ResultReadWriteSets[i].String(), par.ResultReadWriteSets[i].String())
+		}
+	}
+
+	tt.chain.SetVMParallelism(4)
+	_, err := tt.chain.runBatch(batch, "test")
+	require.NoError(t, err)
+	checkBalance := func(agentID coretypes.AgentID, expected int64) {
+		ret, err := tt.chain.CallView(tokenName, tokenViewBalance, tokenParamAgentID, agentID)
+		require.NoError(t, err)
+		d := kvdecoder.New(ret)
+		require.EqualValues(t, expected, d.MustGetInt64(tokenParamAmount))
+	}
+	checkBalance(tt.senderAgentID(0), 0)
+	checkBalance(tt.senderAgentID(1), 85)
+	checkBalance(tt.senderAgentID(2), 85)
+	checkBalance(recipients[0], 110)
+	checkBalance(recipients[7], 10)
+}
+
+const benchBatchSize = 64
+
+// benchmarkAccountsTransfers runs the batch of deposits to the 'accounts' contract, each one from its own sender
+// to its own recipient. All deposits change the account of the 'accounts' contract, so the sequential run
+// may have to make them again. The number of the results of the speculative calls taken per batch is reported
+func benchmarkAccountsTransfers(b *testing.B, parallelism int) {
+	env := New(b, false, false)
+	ch := env.NewChain(nil, "chain1")
+	batch := make([]vm.RequestRefWithFreeTokens, benchBatchSize)
+	for i := range batch {
+		recipient := coretypes.NewAgentIDFromAddress(env.NewSignatureScheme().Address())
+		tx := ch.RequestFromParamsToLedger(NewCallParams(accounts.Interface.Name, accounts.FuncDeposit,
+			accounts.ParamAgentID, recipient,
+		).WithTransfer(balance.ColorIOTA, 1), env.NewSignatureSchemeWithFunds())
+		batch[i] = requestRef(b, ch, tx)
+	}
+	entropy := hashing.RandomHash(nil)
+	speculativeCalls := 0
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		speculativeCalls += runTask(ch, batch, entropy, parallelism).ResultSpeculativeCalls
+	}
+	b.ReportMetric(float64(speculativeCalls)/float64(b.N), "speculative/op")
+}
+
+func BenchmarkAccountsTransfersSequential(b *testing.B) {
+	benchmarkAccountsTransfers(b, 1)
+}
+
+// BenchmarkAccountsTransfersParallel runs the batch with one speculative worker per CPU, at least 2
+func BenchmarkAccountsTransfersParallel(b *testing.B) {
+	parallelism := runtime.NumCPU()
+	if parallelism < 2 {
+		parallelism = 2
+	}
+	benchmarkAccountsTransfers(b, parallelism)
+}
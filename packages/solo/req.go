@@ -31,6 +31,12 @@ type CallParams struct {
 	transfer   coretypes.ColoredBalances
 	mint       map[address.Address]int64
 	args       requestargs.RequestArgs
+	gasBudget  int64
+	encrypt    bool
+	timelock   int64
+	blockLock  uint32
+	deadline   int64
+	calls      []*CallParams
 }
 
 func NewCallParamsFromDic(scName, funName string, par dict.Dict) *CallParams {
@@ -75,6 +81,26 @@ func NewCallParamsOptimized(scName, funName string, optSize int, params ...inter
 	return ret, retOptimized
 }
 
+// NewMultiCallParams creates parameters of the multi-call request, which runs the calls in order in one request context.
+// Either all calls succeed or state changes of all of them are rolled back.
+// The transfer of the request is the sum of transfers of the calls. It may be replaced by WithTransfers,
+// for example to cover fees. The part of the transfer not used by the calls is accrued to the sender on-chain.
+// The result of the request is decoded by sctransaction.DecodeMultiCallResults
+func NewMultiCallParams(calls ...*CallParams) *CallParams {
+	transfer := make(map[balance.Color]int64)
+	for _, c := range calls {
+		if c.transfer != nil {
+			c.transfer.AddToMap(transfer)
+		}
+	}
+	return &CallParams{
+		targetName: "multicall",
+		args:       requestargs.New(nil),
+		transfer:   cbalances.NewFromMap(transfer),
+		calls:      calls,
+	}
+}
+
 // WithTransfer is a shorthand for the most often used case where only
 // a single color is transferred by WithTransfers
 func (r *CallParams) WithTransfer(color balance.Color, amount int64) *CallParams {
@@ -99,6 +125,33 @@ func (r *CallParams) WithMinting(mint map[address.Address]int64) *CallParams {
 	return r
 }
 
+// WithGasBudget sets the maximum amount of gas the request may burn in the VM.
+// If the budget is exhausted, the request is aborted with coretypes.ErrGasBudgetExceeded.
+// The default budget is in effect if not set
+func (r *CallParams) WithGasBudget(gasBudget int64) *CallParams {
+	r.gasBudget = gasBudget
+	return r
+}
+
+// WithTimelock sets the moment of the logical clock before which the request is not processed
+func (r *CallParams) WithTimelock(ts time.Time) *CallParams {
+	r.timelock = ts.Unix()
+	return r
+}
+
+// WithBlockIndexLock sets the minimal index of the block which may settle the request
+func (r *CallParams) WithBlockIndexLock(blockIndex uint32) *CallParams {
+	r.blockLock = blockIndex
+	return r
+}
+
+// WithDeadline sets the moment of the logical clock after which the request is not processed.
+// The request processed after the deadline fails with coretypes.ErrRequestTimeout and the transfer is refunded
+func (r *CallParams) WithDeadline(ts time.Time) *CallParams {
+	r.deadline = ts.Unix()
+	return r
+}
+
 // makes map without hashing
 func toMap(params ...interface{}) map[string]interface{} {
 	par := make(map[string]interface{})
@@ -132,9 +185,19 @@ func (ch *Chain) RequestFromParamsToLedger(req *CallParams, sigScheme signatures
 	txb, err := txbuilder.NewFromOutputBalances(allOuts)
 	require.NoError(ch.Env.T, err)
 
-	reqSect := sctransaction.NewRequestSectionByWallet(coretypes.NewContractID(ch.ChainID, req.target), req.entryPoint).
+	args := req.args
+	if req.encrypt {
+		args = ch.encryptArgs(args)
+	}
+	// requests are sent to the current address of the chain
+	reqSect := sctransaction.NewRequestSectionByWallet(coretypes.NewContractID(coretypes.ChainID(ch.ChainAddress), req.target), req.entryPoint).
+		WithCalls(ch.requestCalls(req)...).
 		WithTransfer(req.transfer).
-		WithArgs(req.args)
+		WithGasBudget(req.gasBudget).
+		WithTimelock(req.timelock).
+		WithBlockIndexLock(req.blockLock).
+		WithDeadline(req.deadline).
+		WithArgs(args)
 
 	err = txb.AddRequestSection(reqSect)
 	require.NoError(ch.Env.T, err)
@@ -154,6 +217,21 @@ func (ch *Chain) RequestFromParamsToLedger(req *CallParams, sigScheme signatures
 	return tx
 }
 
+// requestCalls converts calls of the multi-call request to the calls of the request section
+func (ch *Chain) requestCalls(req *CallParams) []*sctransaction.RequestCall {
+	if len(req.calls) == 0 {
+		return nil
+	}
+	ret := make([]*sctransaction.RequestCall, len(req.calls))
+	for i, c := range req.calls {
+		params, ok, err := c.args.SolidifyRequestArguments(ch.Env.registry)
+		require.NoError(ch.Env.T, err)
+		require.True(ch.Env.T, ok)
+		ret[i] = sctransaction.NewRequestCall(c.target, c.entryPoint, params, c.transfer)
+	}
+	return ret
+}
+
 // PostRequestSync posts a request synchronously  sent by the test program to the smart contract on the same or another chain:
 //  - creates a request transaction with the request block on it. The sigScheme is used to
 //    sign the inputs of the transaction or OriginatorSigScheme is used if parameter is nil
@@ -174,6 +252,8 @@ func (ch *Chain) PostRequestSync(req *CallParams, sigScheme signaturescheme.Sign
 	return ret, err
 }
 
+// PostRequestSyncTx is like PostRequestSync but also returns the request transaction.
+// The transaction is returned even if the request fails, so its receipt can be retrieved
 func (ch *Chain) PostRequestSyncTx(req *CallParams, sigScheme signaturescheme.SignatureScheme) (*sctransaction.Transaction, dict.Dict, error) {
 	tx := ch.RequestFromParamsToLedger(req, sigScheme)
 
@@ -185,18 +265,30 @@ func (ch *Chain) PostRequestSyncTx(req *CallParams, sigScheme signaturescheme.Si
 	ch.reqCounter.Add(1)
 	ret, err := ch.runBatch([]vm.RequestRefWithFreeTokens{r}, "post")
 	if err != nil {
-		return nil, nil, err
+		return tx, nil, err
 	}
 	return tx, ret, nil
 }
 
+// PostRequestAsync posts a request to the backlog of the chain, like it is done by the real Wasp committee:
+// the request is processed by the chain in the background, when it is not locked by the timelock
+// or by the block index lock. Use WaitForEmptyBacklog to wait until it is processed
+func (ch *Chain) PostRequestAsync(req *CallParams, sigScheme signaturescheme.SignatureScheme) *sctransaction.Transaction {
+	tx := ch.RequestFromParamsToLedger(req, sigScheme)
+	reqID := coretypes.NewRequestID(tx.ID(), 0)
+	ch.Log.Infof("PostRequestAsync: %s::%s -- %s", req.targetName, req.epName, reqID.String())
+	ch.Env.EnqueueRequests(tx)
+	return tx
+}
+
 // callViewFull calls the view entry point of the smart contract
 // with params wrapped into the CallParams object. The transfer part, fs any, is ignored
 func (ch *Chain) callViewFull(req *CallParams) (dict.Dict, error) {
 	ch.runVMMutex.Lock()
 	defer ch.runVMMutex.Unlock()
 
-	vctx := viewcontext.New(ch.ChainID, ch.State.Variables(), ch.State.Timestamp(), ch.proc, ch.Log)
+	vctx := viewcontext.New(ch.ChainID, ch.State.Variables(), ch.State.Timestamp(), ch.proc, ch.Log).
+		WithBlockInfo(ch.State.BlockIndex(), ch.State.Hash(), ch.StateTx.ID())
 	a, ok, err := req.args.SolidifyRequestArguments(ch.Env.registry)
 	if err != nil || !ok {
 		return nil, fmt.Errorf("solo.internal error: can't solidify args")
@@ -215,7 +307,8 @@ func (ch *Chain) CallView(scName string, funName string, params ...interface{})
 	ch.runVMMutex.Lock()
 	defer ch.runVMMutex.Unlock()
 
-	vctx := viewcontext.New(ch.ChainID, ch.State.Variables(), ch.State.Timestamp(), ch.proc, ch.Log)
+	vctx := viewcontext.New(ch.ChainID, ch.State.Variables(), ch.State.Timestamp(), ch.proc, ch.Log).
+		WithBlockInfo(ch.State.BlockIndex(), ch.State.Hash(), ch.StateTx.ID())
 	return vctx.CallView(coretypes.Hn(scName), coretypes.Hn(funName), p)
 }
 
@@ -228,6 +321,11 @@ func (ch *Chain) CallView(scName string, funName string, params ...interface{})
 // Otherwise waiting is not necessary because all PostRequestSync calls by the test itself
 // are synchronous and are processed immediately
 func (ch *Chain) WaitForEmptyBacklog(maxWait ...time.Duration) {
+	ch.waitForBacklog(ch.backlogLen, maxWait...)
+}
+
+// waitForBacklog waits until the number of requests returned by the function becomes 0
+func (ch *Chain) waitForBacklog(backlogLen func() int, maxWait ...time.Duration) {
 	maxw := 5 * time.Second
 	var deadline time.Time
 	if len(maxWait) > 0 {
@@ -237,11 +335,11 @@ func (ch *Chain) WaitForEmptyBacklog(maxWait ...time.Duration) {
 	counter := 0
 	for {
 		if counter%40 == 0 {
-			ch.Log.Infof("backlog length = %d", ch.backlogLen())
+			ch.Log.Infof("backlog length = %d", backlogLen())
 		}
 		counter++
 		time.Sleep(200 * time.Millisecond)
-		if ch.backlogLen() > 0 {
+		if backlogLen() > 0 {
 			if time.Now().After(deadline) {
 				ch.Log.Warnf("exit due to timeout of max wait for %v", maxw)
 				return
@@ -250,7 +348,7 @@ func (ch *Chain) WaitForEmptyBacklog(maxWait ...time.Duration) {
 			emptyCounter := 0
 			for i := 0; i < 3; i++ {
 				time.Sleep(100 * time.Millisecond)
-				if ch.backlogLen() != 0 {
+				if backlogLen() != 0 {
 					break
 				}
 				emptyCounter++
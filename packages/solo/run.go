@@ -33,26 +33,57 @@ func (ch *Chain) runBatch(batch []vm.RequestRefWithFreeTokens, trace string) (di
 
 	ch.validateBatch(batch)
 
-	// solidify arguments
-	for _, reqRef := range batch {
-		if ok, err := reqRef.RequestSection().SolidifyArgs(ch.Env.registry); err != nil || !ok {
-			return nil, fmt.Errorf("solo inconsistency: failed to solidify request args")
+	// solidify arguments. Unlike the committee, 'solo' doesn't wait for missing blobs:
+	// the request is rejected immediately as if the deadline had passed
+	for i := range batch {
+		ok, err := batch[i].RequestSection().SolidifyArgs(ch.Env.registry)
+		if err != nil {
+			return nil, fmt.Errorf("solo inconsistency: failed to solidify request args: %v", err)
+		}
+		if !ok {
+			ch.Log.Warnf("request arguments of %s can't be solidified. The request will be rejected", batch[i].RequestID().Short())
+			batch[i].ArgsRejected = true
 		}
 	}
 
-	task := &vm.VMTask{
+	task := ch.newVMTask(batch)
+	callRes, callErr := ch.runVMTask(task)
+	ch.reqCounter.Add(int32(-len(task.Requests)))
+
+	ch.lastGasBurned = task.ResultGasBurned[len(task.ResultGasBurned)-1]
+	ch.lastReadWriteSet = task.ResultReadWriteSets[len(task.ResultReadWriteSets)-1]
+	task.ResultTransaction.Sign(ch.ChainSigScheme)
+
+	// check semantic validity of the transaction
+	_, err := task.ResultTransaction.Properties()
+	require.NoError(ch.Env.T, err)
+
+	ch.settleStateTransition(task.VirtualState, task.ResultBlock, task.ResultTransaction)
+	return callRes, callErr
+}
+
+// newVMTask creates the task to run the batch on the current state of the chain
+func (ch *Chain) newVMTask(batch []vm.RequestRefWithFreeTokens) *vm.VMTask {
+	return &vm.VMTask{
 		Processors:         ch.proc,
 		ChainID:            ch.ChainID,
 		Color:              ch.ChainColor,
+		ChainAddress:       ch.ChainAddress,
 		Entropy:            hashing.RandomHash(nil),
 		ValidatorFeeTarget: ch.ValidatorFeeTarget,
 		Balances:           waspconn.OutputsToBalances(ch.Env.utxoDB.GetAddressOutputs(ch.ChainAddress)),
 		Requests:           batch,
 		Timestamp:          ch.Env.LogicalTime().UnixNano(),
 		VirtualState:       ch.State.Clone(),
+		StateTransactionID: ch.StateTx.ID(),
 		Log:                ch.Log,
+		Decryptor:          ch.committeeKey,
+		Parallelism:        ch.vmParallelism,
 	}
-	var err error
+}
+
+// runVMTask runs the task on the VM and waits for the result. The state of the chain is not changed
+func (ch *Chain) runVMTask(task *vm.VMTask) (dict.Dict, error) {
 	var wg sync.WaitGroup
 	var callRes dict.Dict
 	var callErr error
@@ -60,22 +91,14 @@ func (ch *Chain) runBatch(batch []vm.RequestRefWithFreeTokens, trace string) (di
 		require.NoError(ch.Env.T, err)
 		callRes = callResult
 		callErr = callError
-		ch.reqCounter.Add(int32(-len(task.Requests)))
 		wg.Done()
 	}
 
 	wg.Add(1)
-	err = runvm.RunComputationsAsync(task)
+	err := runvm.RunComputationsAsync(task)
 	require.NoError(ch.Env.T, err)
 
 	wg.Wait()
-	task.ResultTransaction.Sign(ch.ChainSigScheme)
-
-	// check semantic validity of the transaction
-	_, err = task.ResultTransaction.Properties()
-	require.NoError(ch.Env.T, err)
-
-	ch.settleStateTransition(task.VirtualState, task.ResultBlock, task.ResultTransaction)
 	return callRes, callErr
 }
 
@@ -85,12 +108,25 @@ func (ch *Chain) settleStateTransition(newState state.VirtualState, block state.
 
 	err = newState.ApplyBlock(block)
 	require.NoError(ch.Env.T, err)
+	require.EqualValues(ch.Env.T, stateTx.MustState().StateRoot(), newState.StateRoot())
+
+	err = ch.proc.EvictReplaced(ch.State, newState, block)
+	require.NoError(ch.Env.T, err)
 
 	err = newState.CommitToDb(block)
 	require.NoError(ch.Env.T, err)
 
 	prevBlockIndex := ch.StateTx.MustState().BlockIndex()
 
+	if chainAddress := *stateTx.MustProperties().MustChainAddress(); chainAddress != ch.ChainAddress {
+		// the committee was rotated
+		require.True(ch.Env.T, ch.nextChainSigScheme != nil && ch.nextChainSigScheme.Address() == chainAddress)
+		ch.Log.Infof("committee rotated: the chain is moved from %s to %s", ch.ChainAddress, chainAddress)
+		ch.ChainSigScheme = ch.nextChainSigScheme
+		ch.ChainAddress = chainAddress
+		ch.nextChainSigScheme = nil
+	}
+
 	ch.StateTx = stateTx
 	ch.State = newState
 
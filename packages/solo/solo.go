@@ -5,6 +5,7 @@ package solo
 
 import (
 	"go.uber.org/atomic"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
 	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/sctransaction/origin"
@@ -43,7 +45,7 @@ const Saldo = int64(1337)
 // Solo is a structure which contains global parameters of the test: one per test instance
 type Solo struct {
 	// instance of the test
-	T           *testing.T
+	T           testing.TB
 	logger      *logger.Logger
 	utxoDB      *utxodb.UtxoDB
 	registry    coretypes.BlobCacheFull
@@ -73,12 +75,15 @@ type Chain struct {
 	// It is a default signature scheme in many of 'solo' calls which require private key.
 	OriginatorSigScheme signaturescheme.SignatureScheme
 
-	// ChainID is the ID of the chain (in this version alias of the ChainAddress)
+	// ChainID is the ID of the chain (in this version alias of the ChainAddress, until the committee is rotated)
 	ChainID coretypes.ChainID
 
 	// ChainAddress is the alias of ChainSigScheme.Address()
 	ChainAddress address.Address
 
+	// signature scheme of the committee the chain is being rotated to
+	nextChainSigScheme signaturescheme.SignatureScheme
+
 	// ChainColor is the color of the non-fungible token of the chain.
 	// It is equal to the hash of the origin transaction of the chain
 	ChainColor balance.Color
@@ -104,6 +109,16 @@ type Chain struct {
 	// processor cache
 	proc *processors.ProcessorCache
 
+	// key of the simulated committee, used to decrypt encrypted request arguments
+	committeeKey committeeKey
+
+	// gas burned by the last request processed by the VM
+	lastGasBurned int64
+	// keys read and written by the last request processed by the VM
+	lastReadWriteSet *buffered.ReadWriteSet
+	// number of requests of the batch run by the VM in parallel
+	vmParallelism int
+
 	// related to asynchronous backlog processing
 	runVMMutex   *sync.Mutex
 	reqCounter   atomic.Int32
@@ -120,7 +135,7 @@ var (
 // New creates an instance of the `solo` environment for the test instances.
 //   'debug' parameter 'true' means logging level is 'debug', otherwise 'info'
 //   'printStackTrace' controls printing stack trace in case of errors
-func New(t *testing.T, debug bool, printStackTrace bool) *Solo {
+func New(t testing.TB, debug bool, printStackTrace bool) *Solo {
 	doOnce.Do(func() {
 		glbLogger = testutil.NewLogger(t, "04:05.000")
 		if !debug {
@@ -189,6 +204,8 @@ func (env *Solo) NewChain(chainOriginator signaturescheme.SignatureScheme, name
 		State:               state.NewVirtualState(mapdb.NewMapDB(), &chainID),
 		proc:                processors.MustNew(),
 		Log:                 env.logger.Named(name),
+		committeeKey:        newCommitteeKey(),
+		vmParallelism:       runtime.NumCPU(),
 		//
 		runVMMutex:   &sync.Mutex{},
 		chInRequest:  make(chan sctransaction.RequestRef),
@@ -270,7 +287,7 @@ func (env *Solo) EnqueueRequests(tx *sctransaction.Transaction) {
 	defer env.glbMutex.RUnlock()
 
 	for chid, reqs := range reqRefByChain {
-		chain, ok := env.chains[chid]
+		chain, ok := env.chainByTarget(chid)
 		if !ok {
 			env.logger.Infof("dispatching requests. Unknown chain: %s", chid.String())
 			continue
@@ -282,6 +299,19 @@ func (env *Solo) EnqueueRequests(tx *sctransaction.Transaction) {
 	}
 }
 
+// chainByTarget finds the chain by the chain of the request target, which is the address of the chain
+func (env *Solo) chainByTarget(target coretypes.ChainID) (*Chain, bool) {
+	if ch, ok := env.chains[target]; ok {
+		return ch, true
+	}
+	for _, ch := range env.chains {
+		if ch.ChainAddress == address.Address(target) {
+			return ch, true
+		}
+	}
+	return nil, false
+}
+
 func (ch *Chain) readRequestsLoop() {
 	for r := range ch.chInRequest {
 		ch.addToBacklog(r)
@@ -296,25 +326,32 @@ func (ch *Chain) addToBacklog(r sctransaction.RequestRef) {
 	if tl == 0 {
 		ch.Log.Infof("added to backlog: %s len: %d", r.RequestID().String(), len(ch.backlog))
 	} else {
-		tlTime := time.Unix(int64(tl), 0)
+		tlTime := time.Unix(tl, 0)
 		ch.Log.Infof("added to backlog: %s. Time locked for: %v",
 			r.RequestID().Short(), tlTime.Sub(ch.Env.LogicalTime()))
 	}
 }
 
-// collateBatch selects requests which are not time locked
+// collateBatch selects requests which are not time locked and not block index locked.
+// Expired requests are selected too: the VM refunds them
 // returns batch and and 'remains unprocessed' flag
 func (ch *Chain) collateBatch() []vm.RequestRefWithFreeTokens {
+	// the batch is settled by the block next to the current state
+	ch.runVMMutex.Lock()
+	nextBlockIndex := ch.State.BlockIndex() + 1
+	ch.runVMMutex.Unlock()
+
 	ch.backlogMutex.Lock()
 	defer ch.backlogMutex.Unlock()
 
 	ret := make([]vm.RequestRefWithFreeTokens, 0)
 	remain := ch.backlog[:0]
 	for _, ref := range ch.backlog {
+		req := ref.RequestSection()
 		// using logical clock
-		if int64(ref.RequestSection().Timelock()) <= ch.Env.LogicalTime().Unix() {
-			if ref.RequestSection().Timelock() != 0 {
-				ch.Log.Infof("unlocked time-locked request %s", ref.RequestID().String())
+		if !req.IsTimeLocked(ch.Env.LogicalTime()) && !req.IsBlockIndexLocked(nextBlockIndex) {
+			if req.Timelock() != 0 || req.BlockIndexLock() != 0 {
+				ch.Log.Infof("unlocked locked request %s", ref.RequestID().String())
 			}
 			ret = append(ret, vm.RequestRefWithFreeTokens{RequestRef: ref})
 		} else {
@@ -344,3 +381,23 @@ func (ch *Chain) batchLoop() {
 func (ch *Chain) backlogLen() int {
 	return int(ch.reqCounter.Load())
 }
+
+// unlockedBacklogLen is a thread-safe function to return the number of requests in the backlog
+// which are not locked by time or by the block index
+func (ch *Chain) unlockedBacklogLen() int {
+	ch.runVMMutex.Lock()
+	nextBlockIndex := ch.State.BlockIndex() + 1
+	ch.runVMMutex.Unlock()
+
+	ch.backlogMutex.RLock()
+	defer ch.backlogMutex.RUnlock()
+
+	locked := 0
+	for _, ref := range ch.backlog {
+		req := ref.RequestSection()
+		if req.IsTimeLocked(ch.Env.LogicalTime()) || req.IsBlockIndexLocked(nextBlockIndex) {
+			locked++
+		}
+	}
+	return int(ch.reqCounter.Load()) - locked
+}
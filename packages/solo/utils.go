@@ -1,6 +1,7 @@
 package solo
 
 import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
@@ -27,3 +28,52 @@ func (ch *Chain) RevokeDeployPermission(sigScheme signaturescheme.SignatureSchem
 	_, err := ch.PostRequestSync(req, sigScheme)
 	return err
 }
+
+// RotateCommittee moves the chain to the address of the new BLS signature scheme, as if the new committee took over
+// the chain. The rotation is announced and then confirmed with the signature of the new signature scheme.
+// The anchor transaction of the block with the confirmation moves the chain to the new address, after that
+// the chain is controlled by the new signature scheme. Only the chain owner can rotate the committee
+func (ch *Chain) RotateCommittee(sigScheme signaturescheme.SignatureScheme, newChainSigScheme signaturescheme.SignatureScheme) error {
+	if err := ch.AnnounceRotation(sigScheme, newChainSigScheme.Address()); err != nil {
+		return err
+	}
+	return ch.ConfirmRotation(sigScheme, newChainSigScheme)
+}
+
+// AnnounceRotation announces the rotation of the chain to the address of the new committee
+func (ch *Chain) AnnounceRotation(sigScheme signaturescheme.SignatureScheme, nextAddress address.Address) error {
+	if sigScheme == nil {
+		sigScheme = ch.OriginatorSigScheme
+	}
+	req := NewCallParams(root.Interface.Name, root.FuncRotateCommittee, root.ParamChainAddress, nextAddress)
+	_, err := ch.PostRequestSync(req, sigScheme)
+	return err
+}
+
+// ConfirmRotation confirms the announced rotation with the signature of the new BLS signature scheme.
+// The chain is moved to the address of the new signature scheme
+func (ch *Chain) ConfirmRotation(sigScheme signaturescheme.SignatureScheme, newChainSigScheme signaturescheme.SignatureScheme) error {
+	if sigScheme == nil {
+		sigScheme = ch.OriginatorSigScheme
+	}
+	ch.runVMMutex.Lock()
+	ch.nextChainSigScheme = newChainSigScheme
+	ch.runVMMutex.Unlock()
+
+	pubKey, signature := splitBLSSignature(newChainSigScheme.Sign(root.RotationProofData(ch.ChainID, newChainSigScheme.Address())))
+	req := NewCallParams(root.Interface.Name, root.FuncConfirmRotation,
+		root.ParamPublicKey, pubKey,
+		root.ParamSignature, signature,
+	)
+	_, err := ch.PostRequestSync(req, sigScheme)
+	return err
+}
+
+// splitBLSSignature returns the public key and the signature of the BLS signature
+func splitBLSSignature(sig signaturescheme.Signature) ([]byte, []byte) {
+	data := sig.Bytes()
+	if len(data) < 1+signaturescheme.BLSPublicKeySize {
+		return nil, nil
+	}
+	return data[1 : 1+signaturescheme.BLSPublicKeySize], data[1+signaturescheme.BLSPublicKeySize:]
+}
@@ -0,0 +1,165 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+var (
+	ErrInconsistentValue = errors.New("merkle: value is inconsistent with the tree")
+	ErrInvalidProof      = errors.New("merkle: invalid proof")
+)
+
+// Proof is the proof of inclusion of the key/value pair into the tree with the known root,
+// or the proof of non-inclusion of the key. It can be verified without access to the tree
+type Proof struct {
+	Key kv.Key
+	// Value is the value of the key. Only meaningful if Included == true
+	Value []byte
+	// Included is true for the proof of inclusion and false for the proof of non-inclusion
+	Included bool
+	// hashes of the sibling subtrees along the path of the key, from the root down
+	Siblings []hashing.HashValue
+	// the proof of non-inclusion may end with the leaf of another key
+	HasOtherLeaf   bool
+	OtherKeyHash   hashing.HashValue
+	OtherValueHash hashing.HashValue
+}
+
+func ProofFromBytes(data []byte) (*Proof, error) {
+	ret := &Proof{}
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Verify checks the proof against the root of the tree
+func (p *Proof) Verify(root hashing.HashValue) error {
+	if len(p.Siblings) > hashing.HashSize*8 {
+		return ErrInvalidProof
+	}
+	kh := hashKey(p.Key)
+	var h hashing.HashValue
+	switch {
+	case p.Included:
+		h = leafHash(kh, hashing.HashData(p.Value))
+	case p.HasOtherLeaf:
+		if p.OtherKeyHash == kh {
+			return ErrInvalidProof
+		}
+		// the other leaf must be located on the path of the key
+		for i := range p.Siblings {
+			if bit(p.OtherKeyHash, i) != bit(kh, i) {
+				return ErrInvalidProof
+			}
+		}
+		h = leafHash(p.OtherKeyHash, p.OtherValueHash)
+	default:
+		h = hashing.NilHash
+	}
+	for i := len(p.Siblings) - 1; i >= 0; i-- {
+		if bit(kh, i) == 0 {
+			h = nodeHash(h, p.Siblings[i])
+		} else {
+			h = nodeHash(p.Siblings[i], h)
+		}
+	}
+	if h != root {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+func (p *Proof) String() string {
+	if p.Included {
+		return fmt.Sprintf("proof of inclusion of key '%s', depth %d", p.Key, len(p.Siblings))
+	}
+	return fmt.Sprintf("proof of non-inclusion of key '%s', depth %d", p.Key, len(p.Siblings))
+}
+
+func (p *Proof) Bytes() []byte {
+	return util.MustBytes(p)
+}
+
+func (p *Proof) Write(w io.Writer) error {
+	if err := util.WriteBytes16(w, []byte(p.Key)); err != nil {
+		return err
+	}
+	if err := util.WriteBoolByte(w, p.Included); err != nil {
+		return err
+	}
+	if p.Included {
+		if err := util.WriteBytes32(w, p.Value); err != nil {
+			return err
+		}
+	}
+	if err := util.WriteUint16(w, uint16(len(p.Siblings))); err != nil {
+		return err
+	}
+	for i := range p.Siblings {
+		if err := p.Siblings[i].Write(w); err != nil {
+			return err
+		}
+	}
+	if err := util.WriteBoolByte(w, p.HasOtherLeaf); err != nil {
+		return err
+	}
+	if p.HasOtherLeaf {
+		if err := p.OtherKeyHash.Write(w); err != nil {
+			return err
+		}
+		if err := p.OtherValueHash.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Proof) Read(r io.Reader) error {
+	key, err := util.ReadBytes16(r)
+	if err != nil {
+		return err
+	}
+	p.Key = kv.Key(key)
+	if err := util.ReadBoolByte(r, &p.Included); err != nil {
+		return err
+	}
+	p.Value = nil
+	if p.Included {
+		if p.Value, err = util.ReadBytes32(r); err != nil {
+			return err
+		}
+	}
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	if int(n) > hashing.HashSize*8 {
+		return ErrInvalidProof
+	}
+	p.Siblings = make([]hashing.HashValue, n)
+	for i := range p.Siblings {
+		if err := util.ReadHashValue(r, &p.Siblings[i]); err != nil {
+			return err
+		}
+	}
+	if err := util.ReadBoolByte(r, &p.HasOtherLeaf); err != nil {
+		return err
+	}
+	if p.HasOtherLeaf {
+		if err := util.ReadHashValue(r, &p.OtherKeyHash); err != nil {
+			return err
+		}
+		if err := util.ReadHashValue(r, &p.OtherValueHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package merkle
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// NodeStore is the persistent storage of the nodes of the tree.
+// The node is stored under the key which consists of the hash of the subtree and the version of the node,
+// so nodes of different versions of the tree never overwrite each other
+type NodeStore interface {
+	GetNode(key []byte) ([]byte, error)
+}
+
+// NodeKeySize is the size of the key of the stored node
+const NodeKeySize = hashing.HashSize + 4
+
+// stub is the content of the stored node, loaded once when the node is accessed first time
+type stub struct {
+	once sync.Once
+	node *node
+	err  error
+}
+
+// NewTreeFromStore restores the tree from the record returned by Commit. Nodes are loaded from the store
+// only when they are accessed, so restoring the tree and calculating its root doesn't read the store
+func NewTreeFromStore(store NodeStore, record []byte) (*Tree, error) {
+	r := bytes.NewReader(record)
+	root, err := readRef(r)
+	if err != nil {
+		return nil, err
+	}
+	var size uint64
+	if err := util.ReadUint64(r, &size); err != nil {
+		return nil, err
+	}
+	return &Tree{
+		root:  root,
+		gen:   nextGeneration(),
+		size:  int(size),
+		store: store,
+	}, nil
+}
+
+// Commit calls 'store' for each node of the tree which is not stored yet, assigning the version to it.
+// It returns the record from which NewTreeFromStore restores the tree and the keys of the nodes which
+// were stored before and are not in the tree anymore since the last commit.
+// Nodes are shared by all versions of the tree, so it is up to the caller to delete stale nodes
+// when previous versions of the tree are not needed anymore
+func (t *Tree) Commit(version uint32, store func(key, data []byte)) ([]byte, [][]byte) {
+	t.Root()
+	t.root.commit(version, store)
+	// stored nodes must be copied by the tree from now on
+	t.gen = nextGeneration()
+	stale := t.stale
+	t.stale = nil
+
+	var buf bytes.Buffer
+	writeRef(&buf, t.root)
+	_ = util.WriteUint64(&buf, uint64(t.size))
+	return buf.Bytes(), stale
+}
+
+// commit stores the subtree. Children are stored first, because the parent refers to their versions
+func (n *node) commit(version uint32, store func(key, data []byte)) {
+	if n == nil || n.stored {
+		return
+	}
+	n.left.commit(version, store)
+	n.right.commit(version, store)
+	n.stored = true
+	n.version = version
+	store(n.key(), n.bytes())
+}
+
+// key is the key of the stored node: the hash of the subtree followed by the version
+func (n *node) key() []byte {
+	h := n.subtreeHash()
+	return append(h[:len(h):len(h)], util.Uint32To4Bytes(n.version)...)
+}
+
+// bytes encodes the content of the node. Children are encoded by their keys
+func (n *node) bytes() []byte {
+	var buf bytes.Buffer
+	if n.leaf != nil {
+		buf.WriteByte(prefixLeaf)
+		_ = util.WriteBytes16(&buf, []byte(n.leaf.key))
+		buf.Write(n.leaf.valueHash[:])
+	} else {
+		buf.WriteByte(prefixNode)
+		writeRef(&buf, n.left)
+		writeRef(&buf, n.right)
+	}
+	return buf.Bytes()
+}
+
+// writeRef writes the key of the stored node. The empty subtree is written as hashing.NilHash
+func writeRef(buf *bytes.Buffer, n *node) {
+	if n == nil {
+		buf.Write(hashing.NilHash[:])
+		buf.Write(util.Uint32To4Bytes(0))
+		return
+	}
+	buf.Write(n.key())
+}
+
+// readRef reads the key of the stored node and returns the stub of the node
+func readRef(r *bytes.Reader) (*node, error) {
+	var h hashing.HashValue
+	if err := util.ReadHashValue(r, &h); err != nil {
+		return nil, err
+	}
+	var version uint32
+	if err := util.ReadUint32(r, &version); err != nil {
+		return nil, err
+	}
+	if h == hashing.NilHash {
+		return nil, nil
+	}
+	return &node{hash: &h, stored: true, version: version, stub: &stub{}}, nil
+}
+
+// load returns the node with the content loaded from the store.
+// The store of the committed tree must be consistent, so the failure to load the node is not recoverable
+func (t *Tree) load(n *node) *node {
+	if n == nil || n.stub == nil {
+		return n
+	}
+	n.stub.once.Do(func() {
+		n.stub.node, n.stub.err = t.loadNode(n)
+	})
+	if n.stub.err != nil {
+		panic(n.stub.err)
+	}
+	return n.stub.node
+}
+
+func (t *Tree) loadNode(n *node) (*node, error) {
+	if t.store == nil {
+		return nil, fmt.Errorf("merkle: node %s is not loaded and the tree has no store", n.hash.String())
+	}
+	data, err := t.store.GetNode(n.key())
+	if err != nil {
+		return nil, fmt.Errorf("merkle: failed to load node %s: %v", n.hash.String(), err)
+	}
+	ret := &node{hash: n.hash, stored: true, version: n.version}
+	r := bytes.NewReader(data)
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch prefix {
+	case prefixLeaf:
+		key, err := util.ReadBytes16(r)
+		if err != nil {
+			return nil, err
+		}
+		ret.leaf = &leaf{key: kv.Key(key), keyHash: hashKey(kv.Key(key))}
+		if err := util.ReadHashValue(r, &ret.leaf.valueHash); err != nil {
+			return nil, err
+		}
+	case prefixNode:
+		if ret.left, err = readRef(r); err != nil {
+			return nil, err
+		}
+		if ret.right, err = readRef(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("merkle: wrong encoding of node %s", n.hash.String())
+	}
+	if ret.subtreeHashUncached() != *n.hash {
+		return nil, fmt.Errorf("merkle: content of node %s doesn't match its hash", n.hash.String())
+	}
+	return ret, nil
+}
@@ -0,0 +1,331 @@
+// Package merkle implements the sparse Merkle tree which commits to all key/value pairs of the chain state.
+//
+// Leaves of the tree are placed at the path defined by the bits of the hash of the key.
+// A subtree which contains exactly one leaf is represented by the hash of that leaf and
+// the empty subtree is represented by hashing.NilHash, so the depth of the tree is logarithmic
+// to the number of keys on average
+package merkle
+
+import (
+	"sync/atomic"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+)
+
+// prefixes of the hashed data, to distinguish leaves from internal nodes
+const (
+	prefixLeaf = byte(0)
+	prefixNode = byte(1)
+)
+
+type leaf struct {
+	key       kv.Key
+	keyHash   hashing.HashValue
+	valueHash hashing.HashValue
+}
+
+// node of the tree is either the leaf or the internal node with at least two leaves in its subtrees.
+// The nil child is the empty subtree. Nodes are shared by the clones of the tree, so the node is only
+// changed in place by the tree of the same generation, other trees copy the node first
+type node struct {
+	gen         uint64
+	leaf        *leaf
+	left, right *node
+	// cached hash of the subtree, nil if not calculated yet
+	hash *hashing.HashValue
+	// stored is true if the node is in the store under its hash and version.
+	// Stored nodes are never changed
+	stored  bool
+	version uint32
+	// stub is not nil if the content of the node is not loaded from the store yet
+	stub *stub
+}
+
+// Tree is the sparse Merkle tree of the key/value pairs.
+// Only hashes of values are kept in the tree. Keys are kept to be able to delete them by prefix.
+// Hashes of the subtrees are cached, so only the paths changed since the last call are rehashed by Root.
+// The tree restored from the store loads its nodes when they are accessed first time.
+// The tree is not thread safe, but its clones may be used concurrently
+type Tree struct {
+	root  *node
+	gen   uint64
+	size  int
+	store NodeStore
+	// keys of the stored nodes replaced since the last commit
+	stale [][]byte
+}
+
+// generation is the source of unique generations of the trees
+var generation uint64
+
+func nextGeneration() uint64 {
+	return atomic.AddUint64(&generation, 1)
+}
+
+func NewTree() *Tree {
+	return &Tree{gen: nextGeneration()}
+}
+
+// Clone returns the copy of the tree. It takes constant time: nodes are shared by both trees
+// and copied when changed
+func (t *Tree) Clone() *Tree {
+	// all hashes are calculated before nodes are shared, so shared nodes are never written
+	t.Root()
+	t.gen = nextGeneration()
+	return &Tree{
+		root:  t.root,
+		gen:   nextGeneration(),
+		size:  t.size,
+		store: t.store,
+		stale: append([][]byte{}, t.stale...),
+	}
+}
+
+// Len returns the number of keys in the tree
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Set puts the key/value pair to the tree. Nil value means the key is deleted
+func (t *Tree) Set(key kv.Key, value []byte) {
+	if value == nil {
+		t.Del(key)
+		return
+	}
+	l := &leaf{key: key, keyHash: hashKey(key), valueHash: hashing.HashData(value)}
+	if vh, ok := t.get(l.keyHash); ok {
+		if vh == l.valueHash {
+			return
+		}
+	} else {
+		t.size++
+	}
+	t.root = t.set(t.root, l, 0)
+}
+
+// Del removes the key from the tree
+func (t *Tree) Del(key kv.Key) {
+	var ok bool
+	if t.root, ok = t.del(t.root, hashKey(key), 0); ok {
+		t.size--
+	}
+}
+
+// DelPrefix removes all keys with the prefix from the tree.
+// Keys are not indexed by the tree, so all nodes of the tree restored from the store are loaded.
+// If keys are known, it is cheaper to delete them one by one
+func (t *Tree) DelPrefix(prefix kv.Key) {
+	keys := make([]kv.Key, 0)
+	t.iterateLeaves(t.root, func(l *leaf) {
+		if l.key.HasPrefix(prefix) {
+			keys = append(keys, l.key)
+		}
+	})
+	for _, key := range keys {
+		t.Del(key)
+	}
+}
+
+// Root returns the root hash of the tree. The root of the empty tree is hashing.NilHash
+func (t *Tree) Root() hashing.HashValue {
+	return t.root.subtreeHash()
+}
+
+// Proof returns the proof of inclusion of the key/value pair in the tree or, if value is nil,
+// the proof of non-inclusion of the key.
+// It returns an error if the value is inconsistent with the tree
+func (t *Tree) Proof(key kv.Key, value []byte) (*Proof, error) {
+	kh := hashKey(key)
+	vh, included := t.get(kh)
+	if included != (value != nil) {
+		return nil, ErrInconsistentValue
+	}
+	if included && vh != hashing.HashData(value) {
+		return nil, ErrInconsistentValue
+	}
+	ret := &Proof{
+		Key:      key,
+		Value:    value,
+		Included: included,
+		Siblings: make([]hashing.HashValue, 0),
+	}
+	n := t.load(t.root)
+	for depth := 0; n != nil && n.leaf == nil; depth++ {
+		if bit(kh, depth) == 0 {
+			ret.Siblings = append(ret.Siblings, n.right.subtreeHash())
+			n = t.load(n.left)
+		} else {
+			ret.Siblings = append(ret.Siblings, n.left.subtreeHash())
+			n = t.load(n.right)
+		}
+	}
+	if !included && n != nil {
+		// the path of the key ends with the leaf of another key
+		ret.HasOtherLeaf = true
+		ret.OtherKeyHash = n.leaf.keyHash
+		ret.OtherValueHash = n.leaf.valueHash
+	}
+	return ret, nil
+}
+
+// get returns the hash of the value of the key
+func (t *Tree) get(kh hashing.HashValue) (hashing.HashValue, bool) {
+	n := t.load(t.root)
+	for depth := 0; n != nil && n.leaf == nil; depth++ {
+		if bit(kh, depth) == 0 {
+			n = t.load(n.left)
+		} else {
+			n = t.load(n.right)
+		}
+	}
+	if n == nil || n.leaf.keyHash != kh {
+		return hashing.NilHash, false
+	}
+	return n.leaf.valueHash, true
+}
+
+// mutable returns the node which can be changed by the tree: the node itself if it belongs to the tree
+// or its copy otherwise. The cached hash is reset
+func (t *Tree) mutable(n *node) *node {
+	if n.gen != t.gen {
+		t.replaced(n)
+		cp := *n
+		cp.gen = t.gen
+		cp.stored = false
+		n = &cp
+	}
+	n.hash = nil
+	return n
+}
+
+// replaced records the stored node which is not in the tree anymore
+func (t *Tree) replaced(n *node) {
+	if n.stored {
+		t.stale = append(t.stale, n.key())
+	}
+}
+
+// set puts the leaf to the subtree at the depth and returns the new subtree
+func (t *Tree) set(n *node, l *leaf, depth int) *node {
+	n = t.load(n)
+	switch {
+	case n == nil:
+		return &node{gen: t.gen, leaf: l}
+	case n.leaf != nil && n.leaf.keyHash == l.keyHash:
+		t.replaced(n)
+		return &node{gen: t.gen, leaf: l}
+	case n.leaf != nil:
+		return t.join(n, &node{gen: t.gen, leaf: l}, depth)
+	}
+	ret := t.mutable(n)
+	if bit(l.keyHash, depth) == 0 {
+		ret.left = t.set(ret.left, l, depth+1)
+	} else {
+		ret.right = t.set(ret.right, l, depth+1)
+	}
+	return ret
+}
+
+// join returns the subtree at the depth which contains two leaves with different key hashes
+func (t *Tree) join(a, b *node, depth int) *node {
+	ret := &node{gen: t.gen}
+	ba, bb := bit(a.leaf.keyHash, depth), bit(b.leaf.keyHash, depth)
+	switch {
+	case ba != bb && ba == 0:
+		ret.left, ret.right = a, b
+	case ba != bb:
+		ret.left, ret.right = b, a
+	case ba == 0:
+		ret.left = t.join(a, b, depth+1)
+	default:
+		ret.right = t.join(a, b, depth+1)
+	}
+	return ret
+}
+
+// del removes the key from the subtree at the depth. It returns the new subtree and true if the key was found
+func (t *Tree) del(n *node, kh hashing.HashValue, depth int) (*node, bool) {
+	n = t.load(n)
+	if n == nil {
+		return nil, false
+	}
+	if n.leaf != nil {
+		if n.leaf.keyHash == kh {
+			t.replaced(n)
+			return nil, true
+		}
+		return n, false
+	}
+	left, right := n.left, n.right
+	var ok bool
+	if bit(kh, depth) == 0 {
+		left, ok = t.del(left, kh, depth+1)
+	} else {
+		right, ok = t.del(right, kh, depth+1)
+	}
+	if !ok {
+		return n, false
+	}
+	// the subtree with one leaf is represented by the leaf
+	if left == nil && t.load(right).leaf != nil {
+		t.replaced(n)
+		return right, true
+	}
+	if right == nil && t.load(left).leaf != nil {
+		t.replaced(n)
+		return left, true
+	}
+	ret := t.mutable(n)
+	ret.left, ret.right = left, right
+	return ret, true
+}
+
+// subtreeHash returns the hash of the subtree, calculating it if not cached
+func (n *node) subtreeHash() hashing.HashValue {
+	if n == nil {
+		return hashing.NilHash
+	}
+	if n.hash == nil {
+		h := n.subtreeHashUncached()
+		n.hash = &h
+	}
+	return *n.hash
+}
+
+func (n *node) subtreeHashUncached() hashing.HashValue {
+	if n.leaf != nil {
+		return leafHash(n.leaf.keyHash, n.leaf.valueHash)
+	}
+	return nodeHash(n.left.subtreeHash(), n.right.subtreeHash())
+}
+
+func (t *Tree) iterateLeaves(n *node, f func(l *leaf)) {
+	n = t.load(n)
+	if n == nil {
+		return
+	}
+	if n.leaf != nil {
+		f(n.leaf)
+		return
+	}
+	t.iterateLeaves(n.left, f)
+	t.iterateLeaves(n.right, f)
+}
+
+func hashKey(key kv.Key) hashing.HashValue {
+	return hashing.HashData([]byte(key))
+}
+
+func leafHash(keyHash, valueHash hashing.HashValue) hashing.HashValue {
+	return hashing.HashData([]byte{prefixLeaf}, keyHash[:], valueHash[:])
+}
+
+func nodeHash(left, right hashing.HashValue) hashing.HashValue {
+	return hashing.HashData([]byte{prefixNode}, left[:], right[:])
+}
+
+// bit returns i-th bit of the hash, starting from the most significant bit of the first byte
+func bit(h hashing.HashValue, i int) byte {
+	return (h[i/8] >> (7 - uint(i%8))) & 1
+}
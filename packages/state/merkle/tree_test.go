@@ -0,0 +1,307 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTree(n int) *Tree {
+	t := NewTree()
+	for i := 0; i < n; i++ {
+		t.Set(kv.Key(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	return t
+}
+
+func TestEmptyTree(t *testing.T) {
+	tree := NewTree()
+	require.EqualValues(t, hashing.NilHash, tree.Root())
+
+	proof, err := tree.Proof("key", nil)
+	require.NoError(t, err)
+	require.False(t, proof.Included)
+	require.NoError(t, proof.Verify(tree.Root()))
+}
+
+func TestRootIndependentOfOrder(t *testing.T) {
+	tree1 := makeTree(100)
+	tree2 := NewTree()
+	for i := 99; i >= 0; i-- {
+		tree2.Set(kv.Key(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	tree2.Set("extra", []byte("extra"))
+	require.NotEqual(t, tree1.Root(), tree2.Root())
+
+	tree2.Del("extra")
+	require.EqualValues(t, tree1.Root(), tree2.Root())
+
+	tree2.Set("key5", nil)
+	require.EqualValues(t, 99, tree2.Len())
+	require.NotEqual(t, tree1.Root(), tree2.Root())
+}
+
+func TestProofOfInclusion(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 100} {
+		tree := makeTree(n)
+		root := tree.Root()
+		for i := 0; i < n; i++ {
+			key := kv.Key(fmt.Sprintf("key%d", i))
+			value := []byte(fmt.Sprintf("value%d", i))
+			proof, err := tree.Proof(key, value)
+			require.NoError(t, err)
+			require.True(t, proof.Included)
+			require.NoError(t, proof.Verify(root))
+
+			proof.Value = []byte("wrong")
+			require.Error(t, proof.Verify(root))
+		}
+	}
+}
+
+func TestProofOfNonInclusion(t *testing.T) {
+	for _, n := range []int{1, 2, 100} {
+		tree := makeTree(n)
+		root := tree.Root()
+		for i := n; i < n+50; i++ {
+			key := kv.Key(fmt.Sprintf("key%d", i))
+			proof, err := tree.Proof(key, nil)
+			require.NoError(t, err)
+			require.False(t, proof.Included)
+			require.NoError(t, proof.Verify(root))
+
+			proof.Included = true
+			proof.Value = []byte("fake")
+			require.Error(t, proof.Verify(root))
+		}
+	}
+}
+
+func TestProofInconsistentValue(t *testing.T) {
+	tree := makeTree(10)
+	_, err := tree.Proof("key1", []byte("wrong"))
+	require.Equal(t, ErrInconsistentValue, err)
+	_, err = tree.Proof("key1", nil)
+	require.Equal(t, ErrInconsistentValue, err)
+	_, err = tree.Proof("key100", []byte("value100"))
+	require.Equal(t, ErrInconsistentValue, err)
+}
+
+func TestProofBytes(t *testing.T) {
+	tree := makeTree(100)
+	root := tree.Root()
+
+	proof, err := tree.Proof("key7", []byte("value7"))
+	require.NoError(t, err)
+	back, err := ProofFromBytes(proof.Bytes())
+	require.NoError(t, err)
+	require.EqualValues(t, proof, back)
+	require.NoError(t, back.Verify(root))
+
+	proof, err = tree.Proof("key700", nil)
+	require.NoError(t, err)
+	back, err = ProofFromBytes(proof.Bytes())
+	require.NoError(t, err)
+	require.EqualValues(t, proof, back)
+	require.NoError(t, back.Verify(root))
+}
+
+func TestClone(t *testing.T) {
+	tree := makeTree(10)
+	root := tree.Root()
+	clone := tree.Clone()
+	clone.Set("key100", []byte("value100"))
+	require.EqualValues(t, root, tree.Root())
+	require.NotEqual(t, root, clone.Root())
+}
+
+func TestDelPrefix(t *testing.T) {
+	tree := makeTree(10)
+	tree.Set("other", []byte("value"))
+	tree.DelPrefix("key")
+	require.EqualValues(t, 1, tree.Len())
+
+	expected := NewTree()
+	expected.Set("other", []byte("value"))
+	require.EqualValues(t, expected.Root(), tree.Root())
+}
+
+func TestRootCompatible(t *testing.T) {
+	// roots calculated by the tree which rehashed all leaves on every call
+	expected := map[int]string{
+		1:    "ab42e738f43ab36689c03166a6792e7fcefb91e25b8ff4a4221ec5f560748662",
+		2:    "19e080fb700b5e1166db9ad885f635d0906569ef1de4f8b0ee81a88e3a7f489f",
+		3:    "76bab1d38af549805ed14c92de135eef38274c75a862fd2159a77852402460dd",
+		100:  "a2d1bf7a016d26722e46e08ddc736fd7cf8919b7167d49e32335b2a1d4dd34e2",
+		1000: "67b3732868c4ead190a993625323603bf72dd35075705ae2ff99c5f7d4721435",
+	}
+	for n, root := range expected {
+		r := makeTree(n).Root()
+		require.EqualValues(t, root, hex.EncodeToString(r[:]))
+	}
+}
+
+func TestIncrementalUpdates(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := NewTree()
+	values := make(map[kv.Key][]byte)
+	clones := make([]*Tree, 0)
+	roots := make([]hashing.HashValue, 0)
+	for i := 0; i < 2000; i++ {
+		key := kv.Key(fmt.Sprintf("key%d", rnd.Intn(300)))
+		if rnd.Intn(3) == 0 {
+			tree.Del(key)
+			delete(values, key)
+		} else {
+			value := []byte(fmt.Sprintf("value%d", rnd.Intn(5)))
+			tree.Set(key, value)
+			values[key] = value
+		}
+		if i%100 == 0 {
+			clones = append(clones, tree.Clone())
+			roots = append(roots, tree.Root())
+		}
+		if i%10 == 0 {
+			// the root of the updated tree is the same as the root of the tree built from scratch
+			expected := NewTree()
+			for k, v := range values {
+				expected.Set(k, v)
+			}
+			require.EqualValues(t, expected.Root(), tree.Root())
+			require.EqualValues(t, len(values), tree.Len())
+		}
+	}
+	// updates don't affect the clones
+	for i, clone := range clones {
+		require.EqualValues(t, roots[i], clone.Root())
+	}
+}
+
+// testStore is the node store which counts loaded nodes
+type testStore struct {
+	nodes map[string][]byte
+	loads int
+}
+
+func newTestStore() *testStore {
+	return &testStore{nodes: make(map[string][]byte)}
+}
+
+func (s *testStore) GetNode(key []byte) ([]byte, error) {
+	data, ok := s.nodes[string(key)]
+	if !ok {
+		return nil, fmt.Errorf("node not found")
+	}
+	s.loads++
+	return data, nil
+}
+
+func countNodes(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + countNodes(n.left) + countNodes(n.right)
+}
+
+func (s *testStore) commit(tree *Tree, version uint32) []byte {
+	record, stale := tree.Commit(version, func(key, data []byte) {
+		s.nodes[string(key)] = data
+	})
+	for _, key := range stale {
+		delete(s.nodes, string(key))
+	}
+	return record
+}
+
+func TestCommitRestore(t *testing.T) {
+	store := newTestStore()
+	tree := makeTree(1000)
+	record := store.commit(tree, 0)
+	require.EqualValues(t, countNodes(tree.root), len(store.nodes))
+
+	restored, err := NewTreeFromStore(store, record)
+	require.NoError(t, err)
+	require.EqualValues(t, tree.Root(), restored.Root())
+	require.EqualValues(t, 1000, restored.Len())
+	require.Zero(t, store.loads)
+
+	// only the path of the key is loaded
+	proof, err := restored.Proof("key7", []byte("value7"))
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(tree.Root()))
+	require.EqualValues(t, len(proof.Siblings)+1, store.loads)
+
+	restored.Set("key7", []byte("new"))
+	tree.Set("key7", []byte("new"))
+	require.EqualValues(t, tree.Root(), restored.Root())
+
+	// the empty tree
+	record = store.commit(NewTree(), 0)
+	restored, err = NewTreeFromStore(store, record)
+	require.NoError(t, err)
+	require.EqualValues(t, hashing.NilHash, restored.Root())
+}
+
+func TestCommitIncremental(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	store := newTestStore()
+	values := make(map[kv.Key][]byte)
+	record := store.commit(NewTree(), 0)
+	for version := uint32(1); version <= 20; version++ {
+		tree, err := NewTreeFromStore(store, record)
+		require.NoError(t, err)
+		for i := 0; i < 50; i++ {
+			key := kv.Key(fmt.Sprintf("key%d", rnd.Intn(300)))
+			if rnd.Intn(3) == 0 {
+				tree.Del(key)
+				delete(values, key)
+			} else {
+				value := []byte(fmt.Sprintf("value%d", rnd.Intn(5)))
+				tree.Set(key, value)
+				values[key] = value
+			}
+		}
+		if version%5 == 0 {
+			tree.DelPrefix("key1")
+			for k := range values {
+				if k.HasPrefix("key1") {
+					delete(values, k)
+				}
+			}
+		}
+		// stale nodes are deleted, so the store contains exactly the nodes of the last version
+		record = store.commit(tree, version)
+		expected := NewTree()
+		for k, v := range values {
+			expected.Set(k, v)
+		}
+		require.EqualValues(t, countNodes(expected.root), len(store.nodes))
+		restored, err := NewTreeFromStore(store, record)
+		require.NoError(t, err)
+		require.EqualValues(t, expected.Root(), restored.Root())
+		require.EqualValues(t, len(values), restored.Len())
+		for k, v := range values {
+			proof, err := restored.Proof(k, v)
+			require.NoError(t, err)
+			require.NoError(t, proof.Verify(expected.Root()))
+		}
+	}
+}
+
+func TestRestoreCorrupted(t *testing.T) {
+	store := newTestStore()
+	record := store.commit(makeTree(10), 0)
+	for key := range store.nodes {
+		store.nodes[key] = []byte{prefixLeaf, 0, 0}
+	}
+	restored, err := NewTreeFromStore(store, record)
+	require.NoError(t, err)
+	require.Panics(t, func() {
+		restored.Set("key1", []byte("value"))
+	})
+}
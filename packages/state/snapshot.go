@@ -0,0 +1,310 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/state/merkle"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/plugins/database"
+)
+
+// Snapshot is the full key/value set of the solid state of the chain at the block index together with
+// the hashes which commit to it and the last block. The block contains the ID of the anchor transaction, so
+// the node which imports the snapshot validates it against the tangle the same way it validates the state loaded from the database
+type Snapshot struct {
+	ChainID    coretypes.ChainID
+	BlockIndex uint32
+	Timestamp  int64
+	// hash of the state, the root of the Merkle chain of state updates since the origin
+	StateHash hashing.HashValue
+	// root of the sparse Merkle tree of all variables
+	StateRoot hashing.HashValue
+	// the block with the index BlockIndex
+	Block     Block
+	Variables dict.Dict
+}
+
+// Verify checks if the snapshot is consistent: the variables must match the state root and the block must be the last block of the state.
+// The state hash and the state root are validated against the anchor transaction when the state is loaded by the state manager
+func (s *Snapshot) Verify() error {
+	if s.Block == nil || s.Block.StateIndex() != s.BlockIndex {
+		return fmt.Errorf("snapshot: the block doesn't match block index #%d", s.BlockIndex)
+	}
+	if root := s.tree().Root(); root != s.StateRoot {
+		return fmt.Errorf("snapshot: state root %s doesn't match variables, expected %s", root.String(), s.StateRoot.String())
+	}
+	return nil
+}
+
+func (s *Snapshot) tree() *merkle.Tree {
+	ret := merkle.NewTree()
+	for k, v := range s.Variables {
+		ret.Set(k, v)
+	}
+	return ret
+}
+
+func (s *Snapshot) Write(w io.Writer) error {
+	if err := s.ChainID.Write(w); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, s.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, s.Timestamp); err != nil {
+		return err
+	}
+	if _, err := w.Write(s.StateHash[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(s.StateRoot[:]); err != nil {
+		return err
+	}
+	blockData, err := util.Bytes(s.Block)
+	if err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, blockData); err != nil {
+		return err
+	}
+	return s.Variables.Write(w)
+}
+
+func (s *Snapshot) Read(r io.Reader) error {
+	if err := s.ChainID.Read(r); err != nil {
+		return err
+	}
+	if err := util.ReadUint32(r, &s.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &s.Timestamp); err != nil {
+		return err
+	}
+	if err := util.ReadHashValue(r, &s.StateHash); err != nil {
+		return err
+	}
+	if err := util.ReadHashValue(r, &s.StateRoot); err != nil {
+		return err
+	}
+	blockData, err := util.ReadBytes32(r)
+	if err != nil {
+		return err
+	}
+	if s.Block, err = NewBlockFromBytes(blockData); err != nil {
+		return err
+	}
+	s.Variables = dict.New()
+	return s.Variables.Read(r)
+}
+
+func SnapshotFromBytes(data []byte) (*Snapshot, error) {
+	ret := new(Snapshot)
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ExportSnapshot takes the snapshot of the solid state of the chain from the consistent view of the database.
+// It has no side effects: the block index of the snapshot is recorded by ConfirmSnapshot
+func ExportSnapshot(chainID *coretypes.ChainID) (*Snapshot, error) {
+	dbSnapshot, err := database.GetPartitionSnapshot(chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSnapshot.Release()
+	return exportSnapshot(dbprovider.NewSnapshotStore(dbSnapshot), chainID)
+}
+
+func exportSnapshot(db kvstore.KVStore, chainID *coretypes.ChainID) (*Snapshot, error) {
+	vs, block, ok, err := loadSolidState(db, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("snapshot: solid state of the chain %s does not exist", chainID.String())
+	}
+	vars := dict.New()
+	err = vs.Variables().Iterate(kv.EmptyPrefix, func(key kv.Key, value []byte) bool {
+		vars.Set(key, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		ChainID:    *chainID,
+		BlockIndex: vs.BlockIndex(),
+		Timestamp:  vs.Timestamp(),
+		StateHash:  vs.Hash(),
+		StateRoot:  vs.StateRoot(),
+		Block:      block,
+		Variables:  vars,
+	}, nil
+}
+
+// ConfirmSnapshot records the block index of the exported snapshot as the latest snapshot of the chain,
+// once the snapshot is stored safely. Blocks older than the latest snapshot may be pruned by PruneBlocks
+func ConfirmSnapshot(chainID *coretypes.ChainID, blockIndex uint32) error {
+	return confirmSnapshot(getSCPartition(chainID), chainID, blockIndex)
+}
+
+func confirmSnapshot(db kvstore.KVStore, chainID *coretypes.ChainID, blockIndex uint32) error {
+	vs, _, ok, err := loadSolidState(db, chainID)
+	if err != nil {
+		return err
+	}
+	if !ok || vs.BlockIndex() < blockIndex {
+		return fmt.Errorf("snapshot: the state #%d of the chain %s does not exist", blockIndex, chainID.String())
+	}
+	snapshotIndex, ok, err := getIndex(db, dbprovider.ObjectTypeSnapshotIndex)
+	if err != nil {
+		return err
+	}
+	if ok && snapshotIndex > blockIndex {
+		return fmt.Errorf("snapshot: the later snapshot #%d of the chain %s is already confirmed", snapshotIndex, chainID.String())
+	}
+	return db.Set(dbprovider.MakeKey(dbprovider.ObjectTypeSnapshotIndex), util.Uint32To4Bytes(blockIndex))
+}
+
+// ImportSnapshot verifies the snapshot and replaces the solid state of the chain with it in one atomic write.
+// The chain must not be active. The snapshot is rejected if the node already has the same or a later state
+func ImportSnapshot(s *Snapshot) error {
+	return importSnapshot(getSCPartition(&s.ChainID), s)
+}
+
+func importSnapshot(db kvstore.KVStore, s *Snapshot) error {
+	if err := s.Verify(); err != nil {
+		return err
+	}
+	vs, _, ok, err := loadSolidState(db, &s.ChainID)
+	if err != nil {
+		return err
+	}
+	if ok && vs.BlockIndex() >= s.BlockIndex {
+		return fmt.Errorf("snapshot: the node already has the state #%d of the chain %s", vs.BlockIndex(), s.ChainID.String())
+	}
+	imported := NewVirtualState(db, &s.ChainID)
+	imported.blockIndex = s.BlockIndex
+	imported.timestamp = s.Timestamp
+	imported.stateHash = s.StateHash
+	imported.empty = false
+
+	blockIndexBin := util.Uint32To4Bytes(s.BlockIndex)
+	keys := [][]byte{
+		dbprovider.MakeKey(dbprovider.ObjectTypeSolidState),
+		dbkeyBatch(s.BlockIndex),
+		dbprovider.MakeKey(dbprovider.ObjectTypeSolidStateIndex),
+		dbprovider.MakeKey(dbprovider.ObjectTypeSnapshotIndex),
+		// blocks before the snapshot are not available
+		dbprovider.MakeKey(dbprovider.ObjectTypePrunedIndex),
+	}
+	values := [][]byte{util.MustBytes(imported), util.MustBytes(s.Block), blockIndexBin, blockIndexBin, blockIndexBin}
+	// variables of the old state which are not in the snapshot are deleted in the same batch
+	err = db.IterateKeys(dbprovider.MakeKey(dbprovider.ObjectTypeStateVariable), func(key kvstore.Key) bool {
+		if _, ok := s.Variables[kv.Key(key[1:])]; !ok {
+			keys = append(keys, append([]byte{}, key...))
+			values = append(values, nil)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for k, v := range s.Variables {
+		keys = append(keys, dbkeyStateVariable(k))
+		values = append(values, v)
+	}
+	// the Merkle tree of the old state is replaced by the tree of the snapshot. Old nodes have lower versions,
+	// so they never collide with the new ones
+	for _, objType := range []byte{dbprovider.ObjectTypeMerkleNode, dbprovider.ObjectTypeMerkleStale} {
+		err = db.IterateKeys(dbprovider.MakeKey(objType), func(key kvstore.Key) bool {
+			keys = append(keys, append([]byte{}, key...))
+			values = append(values, nil)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	treeRecord, _ := s.tree().Commit(s.BlockIndex, func(key, data []byte) {
+		keys = append(keys, dbkeyMerkleNode(key))
+		values = append(values, data)
+	})
+	keys = append(keys, dbprovider.MakeKey(dbprovider.ObjectTypeMerkleRoot))
+	values = append(values, treeRecord)
+	return util.DbSetMulti(db, keys, values)
+}
+
+// GetSnapshotIndex returns the block index of the latest snapshot of the chain exported or imported by the node
+func GetSnapshotIndex(chainID *coretypes.ChainID) (uint32, bool, error) {
+	return getIndex(getSCPartition(chainID), dbprovider.ObjectTypeSnapshotIndex)
+}
+
+// PruneBlocks deletes blocks older than the latest snapshot, except 'keepBlocks' blocks before it.
+// It returns the number of deleted blocks
+func PruneBlocks(chainID *coretypes.ChainID, keepBlocks uint32) (int, error) {
+	return pruneBlocks(getSCPartition(chainID), keepBlocks)
+}
+
+func pruneBlocks(db kvstore.KVStore, keepBlocks uint32) (int, error) {
+	snapshotIndex, ok, err := getIndex(db, dbprovider.ObjectTypeSnapshotIndex)
+	if err != nil || !ok || snapshotIndex <= keepBlocks {
+		return 0, err
+	}
+	prunedIndex, _, err := getIndex(db, dbprovider.ObjectTypePrunedIndex)
+	if err != nil {
+		return 0, err
+	}
+	// blocks before prunedIndex are already deleted
+	pruneTo := snapshotIndex - keepBlocks
+	if prunedIndex >= pruneTo {
+		return 0, nil
+	}
+	keys := make([][]byte, 0, pruneTo-prunedIndex+1)
+	values := make([][]byte, 0, pruneTo-prunedIndex+1)
+	for i := prunedIndex; i < pruneTo; i++ {
+		keys = append(keys, dbkeyBatch(i))
+		values = append(values, nil)
+		// nodes of the Merkle tree replaced by the block are only needed by the states before it
+		stale, err := db.Get(dbkeyMerkleStale(i))
+		if err == kvstore.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		for ; len(stale) >= merkle.NodeKeySize; stale = stale[merkle.NodeKeySize:] {
+			keys = append(keys, dbkeyMerkleNode(stale[:merkle.NodeKeySize]))
+			values = append(values, nil)
+		}
+		keys = append(keys, dbkeyMerkleStale(i))
+		values = append(values, nil)
+	}
+	keys = append(keys, dbprovider.MakeKey(dbprovider.ObjectTypePrunedIndex))
+	values = append(values, util.Uint32To4Bytes(pruneTo))
+	if err := util.DbSetMulti(db, keys, values); err != nil {
+		return 0, err
+	}
+	return int(pruneTo - prunedIndex), nil
+}
+
+func getIndex(db kvstore.KVStore, objType byte) (uint32, bool, error) {
+	data, err := db.Get(dbprovider.MakeKey(objType))
+	if err == kvstore.ErrKeyNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	ret, err := util.Uint32From4Bytes(data)
+	return ret, err == nil, err
+}
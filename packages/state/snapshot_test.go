@@ -0,0 +1,182 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/goshimmer/packages/database"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/stretchr/testify/require"
+)
+
+// commitBlocks commits blocks #0..#n-1, each one setting and deleting some variables
+func commitBlocks(t *testing.T, db kvstore.KVStore, chainID *coretypes.ChainID, n int) VirtualState {
+	vs := NewVirtualState(db, chainID)
+	for i := 0; i < n; i++ {
+		txid := (transaction.ID)(hashing.HashStrings(fmt.Sprintf("tx %d", i)))
+		reqid := coretypes.NewRequestID(txid, 0)
+		su := NewStateUpdate(&reqid)
+		su.Mutations().Add(buffered.NewMutationSet(kv.Key(fmt.Sprintf("k%d", i)), []byte{byte(i)}))
+		if i > 0 {
+			su.Mutations().Add(buffered.NewMutationDel(kv.Key(fmt.Sprintf("k%d", i-1))))
+		}
+		su.Mutations().Add(buffered.NewMutationSet("last", []byte{byte(i)}))
+		block, err := NewBlock([]StateUpdate{su})
+		require.NoError(t, err)
+		block.WithBlockIndex(uint32(i)).WithStateTransaction(txid)
+		require.NoError(t, vs.ApplyBlock(block))
+		require.NoError(t, vs.CommitToDb(block))
+	}
+	return vs
+}
+
+func newPartition(t *testing.T) kvstore.KVStore {
+	tmpdb, err := database.NewMemDB()
+	require.NoError(t, err)
+	return tmpdb.NewStore().WithRealm([]byte("2"))
+}
+
+func TestSnapshotExportImport(t *testing.T) {
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	db := newPartition(t)
+	vs := commitBlocks(t, db, &chainID, 5)
+
+	s, err := exportSnapshot(db, &chainID)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, s.BlockIndex)
+	require.EqualValues(t, vs.Hash(), s.StateHash)
+	require.Len(t, s.Variables, 2)
+	require.NoError(t, s.Verify())
+
+	// the export has no side effects, the snapshot is recorded when it is confirmed
+	_, ok, err := getIndex(db, dbprovider.ObjectTypeSnapshotIndex)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Error(t, confirmSnapshot(db, &chainID, 5))
+	require.NoError(t, confirmSnapshot(db, &chainID, 4))
+	idx, ok, err := getIndex(db, dbprovider.ObjectTypeSnapshotIndex)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, 4, idx)
+	require.Error(t, confirmSnapshot(db, &chainID, 3))
+
+	s2, err := SnapshotFromBytes(util.MustBytes(s))
+	require.NoError(t, err)
+	require.EqualValues(t, util.MustBytes(s), util.MustBytes(s2))
+
+	db2 := newPartition(t)
+	require.NoError(t, importSnapshot(db2, s2))
+	vs2, block2, ok, err := loadSolidState(db2, &chainID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, vs.BlockIndex(), vs2.BlockIndex())
+	require.EqualValues(t, vs.Hash(), vs2.Hash())
+	require.EqualValues(t, vs.Timestamp(), vs2.Timestamp())
+	require.EqualValues(t, vs.StateRoot(), vs2.StateRoot())
+	require.EqualValues(t, s.Block.StateTransactionID(), block2.StateTransactionID())
+
+	// the same state can't be imported again
+	require.Error(t, importSnapshot(db2, s2))
+
+	// variables of the older state are replaced
+	db3 := newPartition(t)
+	commitBlocks(t, db3, &chainID, 3)
+	require.NoError(t, importSnapshot(db3, s2))
+	s3, err := exportSnapshot(db3, &chainID)
+	require.NoError(t, err)
+	require.EqualValues(t, util.MustBytes(s), util.MustBytes(s3))
+}
+
+func TestSnapshotExportConsistent(t *testing.T) {
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	backend := dbprovider.NewMemoryBackend()
+	db := backend.NewStore().WithRealm(chainID[:])
+	commitBlocks(t, db, &chainID, 3)
+	dbSnapshot, err := backend.Snapshot(chainID[:])
+	require.NoError(t, err)
+	defer dbSnapshot.Release()
+
+	// blocks committed after the snapshot of the database are not exported
+	vs := commitBlocks(t, db, &chainID, 5)
+	require.EqualValues(t, 4, vs.BlockIndex())
+	s, err := exportSnapshot(dbprovider.NewSnapshotStore(dbSnapshot), &chainID)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, s.BlockIndex)
+	require.NoError(t, s.Verify())
+	require.EqualValues(t, []byte{2}, s.Variables.MustGet("k2"))
+}
+
+func TestSnapshotTampered(t *testing.T) {
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	db := newPartition(t)
+	commitBlocks(t, db, &chainID, 3)
+
+	s, err := exportSnapshot(db, &chainID)
+	require.NoError(t, err)
+	s.Variables.Set("last", []byte{100})
+	require.Error(t, s.Verify())
+	require.Error(t, importSnapshot(newPartition(t), s))
+
+	s, err = exportSnapshot(db, &chainID)
+	require.NoError(t, err)
+	s.BlockIndex++
+	require.Error(t, s.Verify())
+}
+
+func TestPruneBlocks(t *testing.T) {
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	db := newPartition(t)
+	commitBlocks(t, db, &chainID, 10)
+
+	// nothing is pruned before the first snapshot
+	n, err := pruneBlocks(db, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, n)
+
+	// the exported snapshot is not confirmed yet
+	_, err = exportSnapshot(db, &chainID)
+	require.NoError(t, err)
+	n, err = pruneBlocks(db, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, n)
+
+	require.NoError(t, confirmSnapshot(db, &chainID, 9))
+	n, err = pruneBlocks(db, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 7, n)
+	for i := uint32(0); i < 10; i++ {
+		has, err := db.Has(dbkeyBatch(i))
+		require.NoError(t, err)
+		require.Equal(t, i >= 7, has)
+	}
+	// already pruned
+	n, err = pruneBlocks(db, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, n)
+
+	// nodes of the Merkle tree replaced by the pruned blocks are deleted
+	for i := uint32(1); i < 10; i++ {
+		has, err := db.Has(dbkeyMerkleStale(i))
+		require.NoError(t, err)
+		require.Equal(t, i >= 7, has)
+	}
+
+	// the state is still loaded
+	vs, _, ok, err := loadSolidState(db, &chainID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, 9, vs.BlockIndex())
+	for _, key := range []kv.Key{"k9", "last"} {
+		proof, err := vs.Proof(key)
+		require.NoError(t, err)
+		require.True(t, proof.Included)
+		require.NoError(t, proof.Verify(vs.StateRoot()))
+	}
+}
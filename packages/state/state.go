@@ -11,6 +11,7 @@ import (
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/state/merkle"
 	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/plugins/database"
 )
@@ -23,6 +24,9 @@ type virtualState struct {
 	empty      bool
 	stateHash  hashing.HashValue
 	variables  buffered.BufferedKVStore
+	// Merkle tree of the variables. It is restored from the database together with the solid state
+	// or built from the variables on first use
+	tree *merkle.Tree
 }
 
 func NewVirtualState(db kvstore.KVStore, chainID *coretypes.ChainID) *virtualState {
@@ -50,6 +54,24 @@ func subRealm(db kvstore.KVStore, realm []byte) kvstore.KVStore {
 }
 
 func (vs *virtualState) Clone() VirtualState {
+	ret := &virtualState{
+		chainID:    vs.chainID,
+		db:         vs.db,
+		blockIndex: vs.blockIndex,
+		timestamp:  vs.timestamp,
+		empty:      vs.empty,
+		stateHash:  vs.stateHash,
+		variables:  vs.variables.Clone(),
+	}
+	if vs.tree != nil {
+		ret.tree = vs.tree.Clone()
+	}
+	return ret
+}
+
+// CloneWithoutTree is a cheaper clone for the state which only needs the variables.
+// The Merkle tree is not copied, it is rebuilt by the clone if needed
+func (vs *virtualState) CloneWithoutTree() VirtualState {
 	return &virtualState{
 		chainID:    vs.chainID,
 		db:         vs.db,
@@ -111,7 +133,11 @@ func (vs *virtualState) ApplyBlock(batch Block) error {
 
 // applies one state update. Doesn't change state index
 func (vs *virtualState) ApplyStateUpdate(stateUpd StateUpdate) {
-	stateUpd.Mutations().ApplyTo(vs.Variables())
+	if vs.tree != nil {
+		stateUpd.Mutations().ApplyTo(&stateWriter{vars: vs.Variables(), tree: vs.tree})
+	} else {
+		stateUpd.Mutations().ApplyTo(vs.Variables())
+	}
 	vs.timestamp = stateUpd.Timestamp()
 	vh := vs.Hash()
 	sh := util.GetHashValue(stateUpd)
@@ -123,6 +149,38 @@ func (vs *virtualState) Hash() hashing.HashValue {
 	return vs.stateHash
 }
 
+func (vs *virtualState) StateRoot() hashing.HashValue {
+	return vs.merkleTree().Root()
+}
+
+func (vs *virtualState) Proof(key kv.Key) (*merkle.Proof, error) {
+	value, err := vs.variables.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return vs.merkleTree().Proof(key, value)
+}
+
+// merkleTree builds the tree from all variables when called first time, unless the tree was restored from the database.
+// After that the tree is updated together with variables by each applied state update
+func (vs *virtualState) merkleTree() *merkle.Tree {
+	if vs.tree != nil {
+		return vs.tree
+	}
+	tree := merkle.NewTree()
+	if vs.db == nil {
+		// not backed by the database, all variables are in mutations
+		vs.variables.Mutations().ApplyTo(tree)
+	} else {
+		vs.variables.MustIterate(kv.EmptyPrefix, func(key kv.Key, value []byte) bool {
+			tree.Set(key, value)
+			return true
+		})
+	}
+	vs.tree = tree
+	return vs.tree
+}
+
 func (vs *virtualState) Write(w io.Writer) error {
 	if _, err := w.Write(util.Uint32To4Bytes(vs.blockIndex)); err != nil {
 		return err
@@ -180,17 +238,45 @@ func (vs *virtualState) CommitToDb(b Block) error {
 		values = append(values, []byte{0})
 	}
 
-	// store uncommitted mutations
+	// store uncommitted mutations. Nil value means the key is deleted
+	varUpdates := make(map[kv.Key][]byte)
+	varsDb := subRealm(vs.db, []byte{dbprovider.ObjectTypeStateVariable})
+	vs.variables.Mutations().IterateDeletedPrefixes(func(prefix kv.Key) bool {
+		err = varsDb.IterateKeys([]byte(prefix), func(k kvstore.Key) bool {
+			varUpdates[kv.Key(k)] = nil
+			return true
+		})
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
 	vs.variables.Mutations().IterateLatest(func(k kv.Key, mut buffered.Mutation) bool {
-		keys = append(keys, dbkeyStateVariable(k))
-
 		// if mutation is MutationDel, mut.Value() = nil and the key is deleted
-		values = append(values, mut.Value())
+		varUpdates[k] = mut.Value()
 		return true
 	})
+	for k, v := range varUpdates {
+		keys = append(keys, dbkeyStateVariable(k))
+		values = append(values, v)
+	}
+
+	// store new nodes of the Merkle tree. Nodes replaced by the block are deleted when the block is pruned
+	treeRecord, stale := vs.merkleTree().Commit(b.StateIndex(), func(key, data []byte) {
+		keys = append(keys, dbkeyMerkleNode(key))
+		values = append(values, data)
+	})
+	keys = append(keys, dbprovider.MakeKey(dbprovider.ObjectTypeMerkleRoot))
+	values = append(values, treeRecord)
+	if len(stale) > 0 {
+		keys = append(keys, dbkeyMerkleStale(b.StateIndex()))
+		values = append(values, bytes.Join(stale, nil))
+	}
 
 	err = util.DbSetMulti(vs.db, keys, values)
 	if err != nil {
+		// the tree considers its nodes stored, so it is built again and stored by the next commit
+		vs.tree = nil
 		return err
 	}
 	vs.variables.ClearMutations()
@@ -221,6 +307,17 @@ func loadSolidState(db kvstore.KVStore, chainID *coretypes.ChainID) (VirtualStat
 	if err = vs.Read(bytes.NewReader(values[0])); err != nil {
 		return nil, nil, false, fmt.Errorf("loading variable state: %v", err)
 	}
+	treeRecord, err := db.Get(dbprovider.MakeKey(dbprovider.ObjectTypeMerkleRoot))
+	switch {
+	case err == kvstore.ErrKeyNotFound:
+		// the state was committed before nodes of the tree were stored. The tree is built on first use
+	case err != nil:
+		return nil, nil, false, err
+	default:
+		if vs.tree, err = merkle.NewTreeFromStore(merkleStore{db}, treeRecord); err != nil {
+			return nil, nil, false, fmt.Errorf("loading Merkle tree: %v", err)
+		}
+	}
 
 	batch, err := NewBlockFromBytes(values[1])
 	if err != nil {
@@ -236,6 +333,14 @@ func dbkeyStateVariable(key kv.Key) []byte {
 	return dbprovider.MakeKey(dbprovider.ObjectTypeStateVariable, []byte(key))
 }
 
+func dbkeyMerkleNode(key []byte) []byte {
+	return dbprovider.MakeKey(dbprovider.ObjectTypeMerkleNode, key)
+}
+
+func dbkeyMerkleStale(blockIndex uint32) []byte {
+	return dbprovider.MakeKey(dbprovider.ObjectTypeMerkleStale, util.Uint32To4Bytes(blockIndex))
+}
+
 func dbkeyRequest(reqid *coretypes.RequestID) []byte {
 	return dbprovider.MakeKey(dbprovider.ObjectTypeProcessedRequestId, reqid[:])
 }
@@ -243,3 +348,37 @@ func dbkeyRequest(reqid *coretypes.RequestID) []byte {
 func IsRequestCompleted(addr *coretypes.ChainID, reqid *coretypes.RequestID) (bool, error) {
 	return getSCPartition(addr).Has(dbkeyRequest(reqid))
 }
+
+// merkleStore is the store of the nodes of the Merkle tree in the chain partition
+type merkleStore struct {
+	db kvstore.KVStore
+}
+
+func (s merkleStore) GetNode(key []byte) ([]byte, error) {
+	return s.db.Get(dbkeyMerkleNode(key))
+}
+
+// stateWriter applies mutations to the variables and to the Merkle tree together.
+// Keys deleted by prefix are found among the variables, so the tree doesn't need to load all its nodes
+type stateWriter struct {
+	vars buffered.BufferedKVStore
+	tree *merkle.Tree
+}
+
+func (w *stateWriter) Set(key kv.Key, value []byte) {
+	w.vars.Set(key, value)
+	w.tree.Set(key, value)
+}
+
+func (w *stateWriter) Del(key kv.Key) {
+	w.vars.Del(key)
+	w.tree.Del(key)
+}
+
+func (w *stateWriter) DelPrefix(prefix kv.Key) {
+	w.vars.MustIterateKeys(prefix, func(key kv.Key) bool {
+		w.tree.Del(key)
+		return true
+	})
+	w.vars.DelPrefix(prefix)
+}
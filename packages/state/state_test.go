@@ -1,12 +1,14 @@
 package state
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/goshimmer/packages/database"
 	"github.com/iotaledger/hive.go/kvstore/mapdb"
 	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/dbprovider"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/kv/buffered"
@@ -228,3 +230,203 @@ func TestCommit(t *testing.T) {
 	v, _ = partition.Get(dbkeyStateVariable(kv.Key([]byte("x"))))
 	assert.Nil(t, v)
 }
+
+func TestStateRoot(t *testing.T) {
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+
+	txid1 := (transaction.ID)(hashing.HashStrings("test string 1"))
+	reqid1 := coretypes.NewRequestID(txid1, 5)
+	su1 := NewStateUpdate(&reqid1)
+	su1.Mutations().Add(buffered.NewMutationSet("x", []byte{1}))
+	su1.Mutations().Add(buffered.NewMutationSet("y", []byte{2}))
+	batch1, err := NewBlock([]StateUpdate{su1})
+	assert.NoError(t, err)
+
+	vs1 := NewVirtualState(partition, &chainID)
+	assert.EqualValues(t, hashing.NilHash, vs1.StateRoot())
+	err = vs1.ApplyBlock(batch1)
+	assert.NoError(t, err)
+	root1 := vs1.StateRoot()
+	assert.NotEqual(t, hashing.NilHash, root1)
+
+	err = vs1.CommitToDb(batch1)
+	assert.NoError(t, err)
+
+	vs1_2, _, _, err := loadSolidState(partition, &chainID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, root1, vs1_2.StateRoot())
+
+	proof, err := vs1_2.Proof("x")
+	assert.NoError(t, err)
+	assert.True(t, proof.Included)
+	assert.EqualValues(t, []byte{1}, proof.Value)
+	assert.NoError(t, proof.Verify(root1))
+
+	proof, err = vs1_2.Proof("z")
+	assert.NoError(t, err)
+	assert.False(t, proof.Included)
+	assert.NoError(t, proof.Verify(root1))
+
+	reqid2 := coretypes.NewRequestID(txid1, 6)
+	su2 := NewStateUpdate(&reqid2)
+	su2.Mutations().Add(buffered.NewMutationDel("x"))
+	batch2, err := NewBlock([]StateUpdate{su2})
+	assert.NoError(t, err)
+	batch2.WithBlockIndex(1)
+
+	vs2 := vs1.Clone()
+	err = vs2.ApplyBlock(batch2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, root1, vs2.StateRoot())
+	assert.EqualValues(t, root1, vs1.StateRoot())
+
+	proof, err = vs2.Proof("x")
+	assert.NoError(t, err)
+	assert.False(t, proof.Included)
+	assert.NoError(t, proof.Verify(vs2.StateRoot()))
+	assert.Error(t, proof.Verify(root1))
+}
+
+func TestCommitDelPrefix(t *testing.T) {
+	tmpdb, _ := database.NewMemDB()
+	partition := tmpdb.NewStore().WithRealm([]byte("2"))
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+
+	txid1 := (transaction.ID)(hashing.HashStrings("test string 1"))
+	reqid1 := coretypes.NewRequestID(txid1, 5)
+	su1 := NewStateUpdate(&reqid1)
+	su1.Mutations().Add(buffered.NewMutationSet("a1", []byte{1}))
+	su1.Mutations().Add(buffered.NewMutationSet("a2", []byte{2}))
+	su1.Mutations().Add(buffered.NewMutationSet("b", []byte{3}))
+	batch1, err := NewBlock([]StateUpdate{su1})
+	assert.NoError(t, err)
+
+	vs1 := NewVirtualState(partition, &chainID)
+	err = vs1.ApplyBlock(batch1)
+	assert.NoError(t, err)
+	err = vs1.CommitToDb(batch1)
+	assert.NoError(t, err)
+
+	reqid2 := coretypes.NewRequestID(txid1, 6)
+	su2 := NewStateUpdate(&reqid2)
+	su2.Mutations().Add(buffered.NewMutationDelPrefix("a"))
+	su2.Mutations().Add(buffered.NewMutationSet("a2", []byte{4}))
+	batch2, err := NewBlock([]StateUpdate{su2})
+	assert.NoError(t, err)
+	batch2.WithBlockIndex(1)
+
+	err = vs1.ApplyBlock(batch2)
+	assert.NoError(t, err)
+	assert.Nil(t, vs1.Variables().MustGet("a1"))
+
+	// the tree is updated in the same way as the one rebuilt from variables
+	vs2 := NewVirtualState(partition, &chainID)
+	err = vs2.ApplyBlock(batch1)
+	assert.NoError(t, err)
+	root1 := vs2.StateRoot()
+	err = vs2.ApplyBlock(batch2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, root1, vs2.StateRoot())
+	assert.EqualValues(t, vs1.StateRoot(), vs2.StateRoot())
+
+	err = vs1.CommitToDb(batch2)
+	assert.NoError(t, err)
+
+	v, _ := partition.Get(dbkeyStateVariable("a1"))
+	assert.Nil(t, v)
+	v, _ = partition.Get(dbkeyStateVariable("a2"))
+	assert.Equal(t, []byte{4}, v)
+	v, _ = partition.Get(dbkeyStateVariable("b"))
+	assert.Equal(t, []byte{3}, v)
+}
+
+func TestMerkleTreeRestored(t *testing.T) {
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	db := mapdb.NewMapDB()
+	vs := NewVirtualState(db, &chainID)
+	runCounterBlocks(t, vs)
+
+	loaded, _, ok, err := loadSolidState(db, &chainID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// the tree is restored from the database, not built from the variables
+	assert.NotNil(t, loaded.(*virtualState).tree)
+	assert.EqualValues(t, vs.StateRoot(), loaded.StateRoot())
+	assert.EqualValues(t, NewVirtualState(db, &chainID).StateRoot(), loaded.StateRoot())
+
+	txid := (transaction.ID)(hashing.HashStrings("restored"))
+	reqid := coretypes.NewRequestID(txid, 0)
+	su := NewStateUpdate(&reqid)
+	su.Mutations().Add(buffered.NewMutationDelPrefix("k"))
+	su.Mutations().Add(buffered.NewMutationSet("k1", []byte{1}))
+	block, err := NewBlock([]StateUpdate{su})
+	assert.NoError(t, err)
+	block.WithBlockIndex(10)
+	assert.NoError(t, loaded.ApplyBlock(block))
+	assert.NoError(t, vs.ApplyBlock(block))
+	assert.EqualValues(t, vs.StateRoot(), loaded.StateRoot())
+	assert.NoError(t, loaded.CommitToDb(block))
+
+	loaded, _, _, err = loadSolidState(db, &chainID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, vs.StateRoot(), loaded.StateRoot())
+	assert.EqualValues(t, NewVirtualState(db, &chainID).StateRoot(), loaded.StateRoot())
+	proof, err := loaded.Proof("k1")
+	assert.NoError(t, err)
+	assert.True(t, proof.Included)
+	assert.NoError(t, proof.Verify(vs.StateRoot()))
+}
+
+// runs blocks of updates computed from the values read from the state and returns the state hashes.
+// Each block is committed to the database
+func runCounterBlocks(t *testing.T, vs *virtualState) []hashing.HashValue {
+	ret := make([]hashing.HashValue, 0)
+	for blockIndex := uint32(0); blockIndex < 10; blockIndex++ {
+		updates := make([]StateUpdate, 0)
+		for i := 0; i < 5; i++ {
+			txid := (transaction.ID)(hashing.HashStrings("counter"))
+			reqid := coretypes.NewRequestID(txid, uint16(int(blockIndex)*5+i))
+			su := NewStateUpdate(&reqid)
+
+			// reads values written by the previous blocks and by the previous updates of the block
+			counter, _, err := codec.DecodeInt64(vs.Variables().MustGet("counter"))
+			assert.NoError(t, err)
+			su.Mutations().Add(buffered.NewMutationSet("counter", codec.EncodeInt64(counter+1)))
+			key := kv.Key(fmt.Sprintf("k%d", counter%7))
+			if vs.Variables().MustHas(key) {
+				su.Mutations().Add(buffered.NewMutationDel(key))
+			} else {
+				su.Mutations().Add(buffered.NewMutationSet(key, codec.EncodeInt64(counter)))
+			}
+			if counter%11 == 10 {
+				su.Mutations().Add(buffered.NewMutationDelPrefix("k"))
+			}
+			vs.ApplyStateUpdate(su)
+			updates = append(updates, su)
+		}
+		block, err := NewBlock(updates)
+		assert.NoError(t, err)
+		block.WithBlockIndex(blockIndex)
+		vs.ApplyBlockIndex(blockIndex)
+		assert.NoError(t, vs.CommitToDb(block))
+		ret = append(ret, vs.Hash(), vs.StateRoot())
+	}
+	return ret
+}
+
+func TestReadCache(t *testing.T) {
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	vs1 := NewVirtualState(mapdb.NewMapDB(), &chainID)
+	hashes1 := runCounterBlocks(t, vs1)
+
+	db := mapdb.NewMapDB()
+	vs2 := NewVirtualState(db, &chainID)
+	vs2.variables = buffered.NewUncachedBufferedKVStore(subRealm(db, []byte{dbprovider.ObjectTypeStateVariable}))
+	hashes2 := runCounterBlocks(t, vs2)
+
+	assert.EqualValues(t, hashes2, hashes1)
+	assert.EqualValues(t, vs2.Variables().DangerouslyDumpToDict(), vs1.Variables().DangerouslyDumpToDict())
+	assert.EqualValues(t, codec.EncodeInt64(50), vs1.Variables().MustGet("counter"))
+}
@@ -6,7 +6,9 @@ import (
 	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/state/merkle"
 )
 
 // represents an interface to the mutable state of the smart contract
@@ -26,9 +28,16 @@ type VirtualState interface {
 	// return hash of the variable state. It is a root of the Merkle chain of all
 	// state updates starting from the origin
 	Hash() hashing.HashValue
+	// return root of the sparse Merkle tree of all variables of the state
+	StateRoot() hashing.HashValue
+	// return proof of inclusion of the variable into the state or, if it doesn't exist,
+	// the proof of non-inclusion. The proof is verified against the StateRoot
+	Proof(key kv.Key) (*merkle.Proof, error)
 	// the storage of variable/value pairs
 	Variables() buffered.BufferedKVStore
 	Clone() VirtualState
+	// clone without the Merkle tree of the variables
+	CloneWithoutTree() VirtualState
 	DangerouslyConvertToString() string
 }
 
@@ -5,6 +5,7 @@ package tcrypto
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
@@ -12,6 +13,7 @@ import (
 	"github.com/iotaledger/wasp/packages/tcrypto/tbdn"
 	"github.com/iotaledger/wasp/packages/util"
 	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
 	"go.dedis.ch/kyber/v3/share"
 	"go.dedis.ch/kyber/v3/sign/bdn"
 )
@@ -242,3 +244,46 @@ func (s *DKShare) RecoverFullSignature(sigShares [][]byte, data []byte) (signatu
 	}
 	return finalSignature, nil
 }
+
+// RecoverSignature recovers the signature of the shared key from the signature shares of the committee members.
+// Only the public key shares of the committee are needed, so the signature can be recovered by anyone who
+// collected the signature shares. Invalid signature shares are skipped.
+// returns signature as defined in the value Tangle
+func RecoverSignature(suite pairing.Suite, sharedPublic kyber.Point, publicShares []kyber.Point, t uint16, data []byte, sigShares [][]byte) (signaturescheme.Signature, error) {
+	n := len(publicShares)
+	if t == 0 || int(t) > n {
+		return nil, fmt.Errorf("wrong threshold %d of %d public key shares", t, n)
+	}
+	pubShares := make([]*share.PubShare, n)
+	for i := range publicShares {
+		pubShares[i] = &share.PubShare{I: i, V: publicShares[i]}
+	}
+	pubPoly, err := share.RecoverPubPoly(suite.G2(), pubShares[:t], int(t), n)
+	if err != nil {
+		return nil, err
+	}
+	valid := make([][]byte, 0, len(sigShares))
+	for _, sigShare := range sigShares {
+		if idx, err := tbdn.SigShare(sigShare).Index(); err != nil || idx < 0 || idx >= n {
+			continue
+		}
+		if tbdn.Verify(suite, pubPoly, data, sigShare) == nil {
+			valid = append(valid, sigShare)
+		}
+	}
+	if len(valid) < int(t) {
+		return nil, fmt.Errorf("not enough valid signature shares: %d, threshold is %d", len(valid), t)
+	}
+	signature, err := tbdn.Recover(suite, pubPoly, data, valid, int(t), n)
+	if err != nil {
+		return nil, err
+	}
+	if err := bdn.Verify(suite, sharedPublic, data, signature); err != nil {
+		return nil, fmt.Errorf("signature shares are not the shares of the shared key: %v", err)
+	}
+	pubKeyBin, err := sharedPublic.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return signaturescheme.NewBLSSignature(pubKeyBin, signature), nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package tcrypto_test
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+func TestRecoverSignature(t *testing.T) {
+	suite := pairing.NewSuiteBn256()
+	dkShares, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	other, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	data := []byte("data to sign")
+	dkShare := dkShares[0]
+
+	sigShares := make([][]byte, len(dkShares))
+	for i := range dkShares {
+		sigShares[i], err = dkShares[i].SignShare(data)
+		require.NoError(t, err)
+	}
+	sig, err := tcrypto.RecoverSignature(suite, dkShare.SharedPublic, dkShare.PublicShares, dkShare.T, data, sigShares[1:])
+	require.NoError(t, err)
+	require.True(t, sig.IsValid(data))
+	require.EqualValues(t, *dkShare.Address, sig.Address())
+
+	// the share of another key is skipped
+	otherShare, err := other[2].SignShare(data)
+	require.NoError(t, err)
+	sig, err = tcrypto.RecoverSignature(suite, dkShare.SharedPublic, dkShare.PublicShares, dkShare.T, data,
+		[][]byte{sigShares[0], sigShares[1], otherShare, sigShares[3]})
+	require.NoError(t, err)
+	require.True(t, sig.IsValid(data))
+
+	// less than the threshold of valid shares
+	_, err = tcrypto.RecoverSignature(suite, dkShare.SharedPublic, dkShare.PublicShares, dkShare.T, data,
+		[][]byte{sigShares[0], otherShare, sigShares[3]})
+	require.Error(t, err)
+	// shares of the other data
+	_, err = tcrypto.RecoverSignature(suite, dkShare.SharedPublic, dkShare.PublicShares, dkShare.T, []byte("other"), sigShares)
+	require.Error(t, err)
+}
@@ -0,0 +1,219 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package tcrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/util"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/util/random"
+)
+
+const symmetricKeySize = 32
+
+// ErrInconsistentEncryption is returned by DecryptWithShare if the data is not encrypted for all members
+// of the committee in the same way. Every member of the committee gets the same error then
+var ErrInconsistentEncryption = errors.New("data is not encrypted consistently for the committee")
+
+// EncryptToShares encrypts data so that only members of the committee can decrypt it.
+// The data is encrypted with a random symmetric key. The key is sealed for each member of the committee with
+// the secret shared with its public key share, derived from the same ephemeral key r.
+// r is sealed together with the symmetric key, so each member can check the key was sealed for all the others
+// in the same way. All members of the committee either decrypt the same data or fail
+func EncryptToShares(suite kyber.Group, publicShares []kyber.Point, data []byte) ([]byte, error) {
+	key := make([]byte, symmetricKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	r := suite.Scalar().Pick(random.New())
+	ephemeral := suite.Point().Mul(r, nil)
+	ephemeralBytes, err := ephemeral.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	rBytes, err := r.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := util.WriteUint16(&buf, uint16(len(publicShares))); err != nil {
+		return nil, err
+	}
+	if err := util.WriteBytes16(&buf, ephemeralBytes); err != nil {
+		return nil, err
+	}
+	for i, pub := range publicShares {
+		sealed, err := sealKey(suite.Point().Mul(r, pub), ephemeralBytes, uint16(i), append(key, rBytes...))
+		if err != nil {
+			return nil, err
+		}
+		if err := util.WriteBytes16(&buf, sealed); err != nil {
+			return nil, err
+		}
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	// the key is used only once, so the nonce can be constant
+	nonce := make([]byte, aead.NonceSize())
+	if err := util.WriteBytes32(&buf, aead.Seal(nil, nonce, data, nil)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptWithShare decrypts data encrypted by EncryptToShares with the private share of the committee member.
+// The public shares of all the members are needed to check the data was encrypted for them consistently
+func DecryptWithShare(suite kyber.Group, index uint16, privateShare kyber.Scalar, publicShares []kyber.Point, data []byte) ([]byte, error) {
+	r := bytes.NewReader(data)
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return nil, err
+	}
+	if int(n) != len(publicShares) || index >= n {
+		return nil, fmt.Errorf("data is not encrypted for the committee")
+	}
+	ephemeralBytes, err := util.ReadBytes16(r)
+	if err != nil {
+		return nil, err
+	}
+	sealedKeys := make([][]byte, n)
+	for i := range sealedKeys {
+		if sealedKeys[i], err = util.ReadBytes16(r); err != nil {
+			return nil, err
+		}
+	}
+	ciphertext, err := util.ReadBytes32(r)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral := suite.Point()
+	if err := ephemeral.UnmarshalBinary(ephemeralBytes); err != nil {
+		return nil, err
+	}
+	// from here on every member of the committee gets the same result
+	key, err := openKey(suite, suite.Point().Mul(privateShare, ephemeral), ephemeralBytes, index, sealedKeys[index])
+	if err != nil {
+		return nil, ErrInconsistentEncryption
+	}
+	rScalar := suite.Scalar()
+	if err := rScalar.UnmarshalBinary(key[symmetricKeySize:]); err != nil {
+		return nil, ErrInconsistentEncryption
+	}
+	if !suite.Point().Mul(rScalar, nil).Equal(ephemeral) {
+		return nil, ErrInconsistentEncryption
+	}
+	for i, pub := range publicShares {
+		sealed, err := sealKey(suite.Point().Mul(rScalar, pub), ephemeralBytes, uint16(i), key)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(sealed, sealedKeys[i]) {
+			return nil, ErrInconsistentEncryption
+		}
+	}
+	aead, err := newAEAD(key[:symmetricKeySize])
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext, nil)
+	if err != nil {
+		return nil, ErrInconsistentEncryption
+	}
+	return plain, nil
+}
+
+// Decrypt decrypts data encrypted by EncryptToShares with the private share of the node
+func (s *DKShare) Decrypt(data []byte) ([]byte, error) {
+	if s.suite == nil || s.Index == nil {
+		return nil, fmt.Errorf("the key share can't be used for decryption")
+	}
+	return DecryptWithShare(s.suite, *s.Index, s.PrivateShare, s.PublicShares, data)
+}
+
+// VerifyPublicShares checks the public key shares of the committee with the threshold t are the shares of
+// the shared public key and the shared public key is the one of the address
+func VerifyPublicShares(suite kyber.Group, addr *address.Address, sharedPublic kyber.Point, publicShares []kyber.Point, t uint16) error {
+	pubBytes, err := sharedPublic.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if address.FromBLSPubKey(pubBytes) != *addr {
+		return fmt.Errorf("the shared public key is not the key of the address %s", addr.String())
+	}
+	n := len(publicShares)
+	if t == 0 || int(t) > n {
+		return fmt.Errorf("wrong threshold %d of %d public key shares", t, n)
+	}
+	pubShares := make([]*share.PubShare, n)
+	for i := range publicShares {
+		pubShares[i] = &share.PubShare{I: i, V: publicShares[i]}
+	}
+	// the polynomial is recovered from the first t shares, the other shares must be on it too
+	pubPoly, err := share.RecoverPubPoly(suite, pubShares[:t], int(t), n)
+	if err != nil {
+		return err
+	}
+	if !pubPoly.Commit().Equal(sharedPublic) {
+		return fmt.Errorf("public key shares are not the shares of the shared public key")
+	}
+	for i := range pubShares {
+		if !pubPoly.Eval(i).V.Equal(publicShares[i]) {
+			return fmt.Errorf("public key share #%d is not the share of the shared public key", i)
+		}
+	}
+	return nil
+}
+
+// sealKey encrypts the key for the member of the committee with the secret shared with it.
+// The encryption is deterministic: the secret is used only once
+func sealKey(secret kyber.Point, ephemeralBytes []byte, index uint16, key []byte) ([]byte, error) {
+	aead, err := secretAEAD(secret, ephemeralBytes, index)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, aead.NonceSize()), key, nil), nil
+}
+
+func openKey(suite kyber.Group, secret kyber.Point, ephemeralBytes []byte, index uint16, sealed []byte) ([]byte, error) {
+	aead, err := secretAEAD(secret, ephemeralBytes, index)
+	if err != nil {
+		return nil, err
+	}
+	key, err := aead.Open(nil, make([]byte, aead.NonceSize()), sealed, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != symmetricKeySize+suite.ScalarLen() {
+		return nil, fmt.Errorf("wrong size of the key")
+	}
+	return key, nil
+}
+
+func secretAEAD(secret kyber.Point, ephemeralBytes []byte, index uint16) (cipher.AEAD, error) {
+	secretBytes, err := secret.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	h := hashing.HashData([]byte("wasp encrypt to shares"), ephemeralBytes, secretBytes, util.Uint16To2Bytes(index))
+	return newAEAD(h[:])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
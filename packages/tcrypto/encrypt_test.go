@@ -0,0 +1,69 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package tcrypto_test
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+func TestEncryptToShares(t *testing.T) {
+	suite := pairing.NewSuiteBn256()
+	dkShares, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	data := []byte("secret data")
+
+	encrypted, err := tcrypto.EncryptToShares(suite, dkShares[0].PublicShares, data)
+	require.NoError(t, err)
+	for _, dkShare := range dkShares {
+		decrypted, err := dkShare.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.EqualValues(t, data, decrypted)
+	}
+
+	// the key is sealed for another key instead of the share #1: no member decrypts the data
+	pubShares := append([]kyber.Point{}, dkShares[0].PublicShares...)
+	pubShares[1] = suite.Point().Pick(suite.RandomStream())
+	encrypted, err = tcrypto.EncryptToShares(suite, pubShares, data)
+	require.NoError(t, err)
+	for _, dkShare := range dkShares {
+		_, err := dkShare.Decrypt(encrypted)
+		require.Equal(t, tcrypto.ErrInconsistentEncryption, err)
+	}
+
+	// the data is encrypted for another committee
+	encrypted, err = tcrypto.EncryptToShares(suite, dkShares[0].PublicShares[:3], data)
+	require.NoError(t, err)
+	_, err = dkShares[0].Decrypt(encrypted)
+	require.Error(t, err)
+}
+
+func TestVerifyPublicShares(t *testing.T) {
+	suite := pairing.NewSuiteBn256()
+	dkShares, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	dkShare := dkShares[0]
+	require.NoError(t, tcrypto.VerifyPublicShares(suite, dkShare.Address, dkShare.SharedPublic, dkShare.PublicShares, dkShare.T))
+
+	other, err := testutil.NewDKShares(suite, 4, 3)
+	require.NoError(t, err)
+	// shares of another key
+	require.Error(t, tcrypto.VerifyPublicShares(suite, dkShare.Address, dkShare.SharedPublic, other[0].PublicShares, dkShare.T))
+	// the key of another address
+	require.Error(t, tcrypto.VerifyPublicShares(suite, dkShare.Address, other[0].SharedPublic, other[0].PublicShares, dkShare.T))
+	addr := address.RandomOfType(address.VersionBLS)
+	require.Error(t, tcrypto.VerifyPublicShares(suite, &addr, dkShare.SharedPublic, dkShare.PublicShares, dkShare.T))
+	// one share is replaced
+	pubShares := append([]kyber.Point{}, dkShare.PublicShares...)
+	pubShares[3] = suite.Point().Pick(suite.RandomStream())
+	require.Error(t, tcrypto.VerifyPublicShares(suite, dkShare.Address, dkShare.SharedPublic, pubShares, dkShare.T))
+	// the threshold is wrong
+	require.Error(t, tcrypto.VerifyPublicShares(suite, dkShare.Address, dkShare.SharedPublic, dkShare.PublicShares, 2))
+}
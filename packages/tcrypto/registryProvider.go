@@ -12,4 +12,13 @@ import (
 type RegistryProvider interface {
 	SaveDKShare(dkShare *DKShare) error
 	LoadDKShare(sharedAddress *address.Address) (*DKShare, error)
+	// SavePendingDKShare stores a reshared key share next to the current share of the same key, if any.
+	// The pending share is not used until it is activated. A previous pending share of the key is overwritten.
+	SavePendingDKShare(dkShare *DKShare) error
+	// ActivatePendingDKShare makes the pending share the current share of the key.
+	// The replaced share, if any, is kept as retired.
+	ActivatePendingDKShare(sharedAddress *address.Address) error
+	// RetireDKShare marks the share of the key retired on a node, which does not hold the key after resharing.
+	// The retired share is kept, but it is not the share of the key anymore.
+	RetireDKShare(sharedAddress *address.Address) error
 }
@@ -0,0 +1,182 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/peering"
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+// Committee is the committee of nodes for the tests of the consensus protocols.
+// The nodes share the distributed key of the committee and are connected by the mocked network.
+// Correct nodes are run by the protocol under test, messages of byzantine nodes are crafted by the test.
+type Committee struct {
+	T        *testing.T
+	N        uint16
+	DKShares []*tcrypto.DKShare
+	Groups   []peering.GroupProvider
+	ChainID  coretypes.ChainID
+	Log      *logger.Logger
+	nodes    map[uint16]*committeeNode
+	closeCh  chan bool
+}
+
+// CommitteeNode is the correct node of the committee, run by the protocol under test.
+// All methods are called from the goroutine of the node
+type CommitteeNode interface {
+	// Start starts the protocol, it is called once before other methods
+	Start(now time.Time)
+	// ReceiveMessage handles the message received from the peer
+	ReceiveMessage(sender uint16, data []byte, now time.Time)
+	// Tick is called periodically, so the node can resend its messages and handle timeouts
+	Tick(now time.Time)
+	// Progress is called after each message and tick, so the node can check the outcome of the protocol
+	Progress()
+}
+
+type committeeNode struct {
+	node   CommitteeNode
+	recvCh chan *committeeMsg
+}
+
+type committeeMsg struct {
+	sender uint16
+	data   []byte
+}
+
+// CommitteeNetwork sends the messages of the node to the peers of the committee. It implements the network
+// interface of the consensus protocols
+type CommitteeNetwork struct {
+	committee *Committee
+	index     uint16
+}
+
+// NewCommittee creates the committee of n nodes with the quorum. The nodes are stopped when the test finishes
+func NewCommittee(t *testing.T, n, quorum uint16, behavior PeeringNetBehavior) *Committee {
+	log := WithLevel(NewLogger(t), logger.LevelInfo, false)
+	dkShares, err := NewDKShares(pairing.NewSuiteBn256(), n, quorum)
+	require.NoError(t, err)
+
+	peerNetIDs := make([]string, n)
+	peerPubs := make([]kyber.Point, n)
+	peerSecs := make([]kyber.Scalar, n)
+	peerSuite := edwards25519.NewBlakeSHA256Ed25519()
+	for i := range peerNetIDs {
+		peerNetIDs[i] = fmt.Sprintf("node%d", i)
+		peerSecs[i] = peerSuite.Scalar().Pick(peerSuite.RandomStream())
+		peerPubs[i] = peerSuite.Point().Mul(peerSecs[i], nil)
+	}
+	network := NewPeeringNetwork(peerNetIDs, peerPubs, peerSecs, 10000, behavior, log.Named("net"))
+	t.Cleanup(behavior.Close)
+
+	ret := &Committee{
+		T:        t,
+		N:        n,
+		DKShares: dkShares,
+		Groups:   make([]peering.GroupProvider, n),
+		ChainID:  coretypes.NewRandomChainID(),
+		Log:      log,
+		nodes:    make(map[uint16]*committeeNode),
+		closeCh:  make(chan bool),
+	}
+	t.Cleanup(func() { close(ret.closeCh) })
+	for i, netProvider := range network.NetworkProviders() {
+		ret.Groups[i], err = netProvider.Group(peerNetIDs)
+		require.NoError(t, err)
+	}
+	return ret
+}
+
+// Network returns the network of the node with the index
+func (c *Committee) Network(index uint16) *CommitteeNetwork {
+	return &CommitteeNetwork{committee: c, index: index}
+}
+
+// AttachNode attaches the correct node to the network. The received messages are queued until the node is started
+func (c *Committee) AttachNode(index uint16, node CommitteeNode) {
+	n := &committeeNode{
+		node:   node,
+		recvCh: make(chan *committeeMsg, 10000),
+	}
+	c.nodes[index] = n
+	c.Groups[index].Attach(&c.ChainID, func(recv *peering.RecvEvent) {
+		// the message is shared by the duplicates delivered by the unreliable network
+		n.recvCh <- &committeeMsg{sender: recv.Msg.SenderIndex, data: recv.Msg.MsgData}
+	})
+}
+
+// StartNodes starts the attached nodes, each one in its own goroutine
+func (c *Committee) StartNodes(tickPeriod time.Duration) {
+	for _, n := range c.nodes {
+		go c.runNode(n, tickPeriod)
+	}
+}
+
+func (c *Committee) runNode(n *committeeNode, tickPeriod time.Duration) {
+	ticker := time.NewTicker(tickPeriod)
+	defer ticker.Stop()
+	n.node.Start(time.Now())
+	n.node.Progress()
+	for {
+		select {
+		case recv := <-n.recvCh:
+			n.node.ReceiveMessage(recv.sender, recv.data, time.Now())
+		case <-ticker.C:
+			n.node.Tick(time.Now())
+		case <-c.closeCh:
+			return
+		}
+		n.node.Progress()
+	}
+}
+
+// SendTo sends the message on behalf of the node to the peers
+func (c *Committee) SendTo(from uint16, data []byte, to ...uint16) {
+	for _, peerIdx := range to {
+		c.Groups[from].SendMsgByIndex(peerIdx, c.peerMessage(from, data))
+	}
+}
+
+func (c *Committee) peerMessage(from uint16, data []byte) *peering.PeerMessage {
+	return &peering.PeerMessage{
+		ChainID:     c.ChainID,
+		SenderIndex: from,
+		Timestamp:   time.Now().UnixNano(),
+		MsgType:     peering.FirstUserMsgCode,
+		MsgData:     data,
+	}
+}
+
+// Indices returns the indices of the nodes of the committee except the listed ones
+func (c *Committee) Indices(except ...uint16) []uint16 {
+	ret := make([]uint16, 0, c.N)
+	for i := uint16(0); i < c.N; i++ {
+		excluded := false
+		for _, e := range except {
+			excluded = excluded || e == i
+		}
+		if !excluded {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+func (n *CommitteeNetwork) SendMsg(peerIndex uint16, data []byte) {
+	n.committee.SendTo(n.index, data, peerIndex)
+}
+
+func (n *CommitteeNetwork) Broadcast(data []byte) {
+	n.committee.Groups[n.index].Broadcast(n.committee.peerMessage(n.index, data), false)
+}
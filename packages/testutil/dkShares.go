@@ -0,0 +1,39 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"github.com/iotaledger/wasp/packages/tcrypto"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+)
+
+// NewDKShares creates the key shares of a committee of n nodes with the threshold t.
+// The key is dealt by a trusted dealer instead of the DKG, that's for tests only.
+func NewDKShares(suite tcrypto.Suite, n, t uint16) ([]*tcrypto.DKShare, error) {
+	priPoly := share.NewPriPoly(suite, int(t), nil, suite.RandomStream())
+	pubPoly := priPoly.Commit(nil)
+	_, publicCommits := pubPoly.Info()
+	priShares := priPoly.Shares(int(n))
+	publicShares := make([]kyber.Point, n)
+	for i := range publicShares {
+		publicShares[i] = suite.Point().Mul(priShares[i].V, nil)
+	}
+	dkShares := make([]*tcrypto.DKShare, n)
+	for i := range dkShares {
+		dkShare, err := tcrypto.NewDKShare(uint16(i), n, t, pubPoly.Commit(), publicCommits, publicShares, priShares[i].V)
+		if err != nil {
+			return nil, err
+		}
+		// the suite is only set when the share is read
+		dkShareBytes, err := dkShare.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if dkShares[i], err = tcrypto.DKShareFromBytes(dkShareBytes, suite); err != nil {
+			return nil, err
+		}
+	}
+	return dkShares, nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/testutil"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/pairing"
+)
+
+func TestNewDKShares(t *testing.T) {
+	data := []byte("data")
+	dkShares, err := testutil.NewDKShares(pairing.NewSuiteBn256(), 4, 3)
+	require.NoError(t, err)
+	require.Len(t, dkShares, 4)
+
+	sigShares := make([][]byte, 0)
+	for i, dkShare := range dkShares {
+		require.EqualValues(t, i, *dkShare.Index)
+		require.EqualValues(t, dkShares[0].Address, dkShare.Address)
+		sigShare, err := dkShare.SignShare(data)
+		require.NoError(t, err)
+		require.NoError(t, dkShares[0].VerifySigShare(data, sigShare))
+		sigShares = append(sigShares, sigShare)
+	}
+	signature, err := dkShares[0].RecoverFullSignature(sigShares[1:], data)
+	require.NoError(t, err)
+	require.True(t, signature.IsValid(data))
+	require.EqualValues(t, *dkShares[0].Address, signature.Address())
+}
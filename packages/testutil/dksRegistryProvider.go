@@ -12,15 +12,19 @@ import (
 
 // DkgRegistryProvider stands for a mock for dkg.RegistryProvider.
 type DkgRegistryProvider struct {
-	DB    map[string][]byte
-	Suite tcrypto.Suite
+	DB      map[string][]byte
+	Pending map[string][]byte   // Reshared shares, which are not activated yet.
+	Retired map[string][][]byte // Retired shares by the address, the oldest first.
+	Suite   tcrypto.Suite
 }
 
 // NewDkgRegistryProvider creates new mocked DKG registry provider.
 func NewDkgRegistryProvider(suite tcrypto.Suite) *DkgRegistryProvider {
 	return &DkgRegistryProvider{
-		DB:    map[string][]byte{},
-		Suite: suite,
+		DB:      map[string][]byte{},
+		Pending: map[string][]byte{},
+		Retired: map[string][][]byte{},
+		Suite:   suite,
 	}
 }
 
@@ -43,3 +47,49 @@ func (p *DkgRegistryProvider) LoadDKShare(sharedAddress *address.Address) (*tcry
 	}
 	return tcrypto.DKShareFromBytes(dkShareBytes, p.Suite)
 }
+
+// SavePendingDKShare implements dkg.RegistryProvider.
+func (p *DkgRegistryProvider) SavePendingDKShare(dkShare *tcrypto.DKShare) error {
+	var err error
+	if p.DB[dkShare.Address.String()] != nil {
+		var current *tcrypto.DKShare
+		if current, err = p.LoadDKShare(dkShare.Address); err != nil {
+			return err
+		}
+		if !current.SharedPublic.Equal(dkShare.SharedPublic) {
+			return fmt.Errorf("DKShare for %v has a different shared public key", dkShare.Address)
+		}
+	}
+	var dkShareBytes []byte
+	if dkShareBytes, err = dkShare.Bytes(); err != nil {
+		return err
+	}
+	p.Pending[dkShare.Address.String()] = dkShareBytes
+	return nil
+}
+
+// ActivatePendingDKShare implements dkg.RegistryProvider.
+func (p *DkgRegistryProvider) ActivatePendingDKShare(sharedAddress *address.Address) error {
+	var key = sharedAddress.String()
+	if p.Pending[key] == nil {
+		return fmt.Errorf("pending DKShare not found for %v", sharedAddress)
+	}
+	if p.DB[key] != nil {
+		p.Retired[key] = append(p.Retired[key], p.DB[key])
+	}
+	p.DB[key] = p.Pending[key]
+	delete(p.Pending, key)
+	return nil
+}
+
+// RetireDKShare implements dkg.RegistryProvider.
+func (p *DkgRegistryProvider) RetireDKShare(sharedAddress *address.Address) error {
+	var key = sharedAddress.String()
+	if p.DB[key] == nil {
+		return fmt.Errorf("DKShare not found for %v", sharedAddress)
+	}
+	p.Retired[key] = append(p.Retired[key], p.DB[key])
+	delete(p.DB, key)
+	delete(p.Pending, key)
+	return nil
+}
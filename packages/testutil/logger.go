@@ -14,7 +14,7 @@ import (
 )
 
 // NewLogger produces a logger adjusted for test cases.
-func NewLogger(t *testing.T, timeLayout ...string) *logger.Logger {
+func NewLogger(t testing.TB, timeLayout ...string) *logger.Logger {
 	// log, err := zap.NewDevelopment()
 	cfg := zap.NewDevelopmentConfig()
 	if len(timeLayout) > 0 {
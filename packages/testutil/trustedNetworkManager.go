@@ -0,0 +1,73 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/iotaledger/wasp/packages/peering"
+	"go.dedis.ch/kyber/v3"
+)
+
+// TrustedNetworkManager stands for a mock for peering.TrustedNetworkManager.
+type TrustedNetworkManager struct {
+	trusted map[string]*peering.TrustedPeer // By the public key.
+	lock    *sync.RWMutex
+}
+
+// NewTrustedNetworkManager creates new mocked trusted network manager,
+// trusting the specified public keys.
+func NewTrustedNetworkManager(pubKeys ...kyber.Point) *TrustedNetworkManager {
+	tnm := &TrustedNetworkManager{
+		trusted: make(map[string]*peering.TrustedPeer),
+		lock:    &sync.RWMutex{},
+	}
+	for i := range pubKeys {
+		_, _ = tnm.TrustPeer(pubKeys[i], fmt.Sprintf("peer-%d", i))
+	}
+	return tnm
+}
+
+// IsTrustedPeer implements peering.TrustedNetworkManager.
+func (tnm *TrustedNetworkManager) IsTrustedPeer(pubKey kyber.Point) error {
+	tnm.lock.RLock()
+	defer tnm.lock.RUnlock()
+	if _, ok := tnm.trusted[pubKey.String()]; !ok {
+		return fmt.Errorf("peer with the public key %v is not trusted", pubKey)
+	}
+	return nil
+}
+
+// TrustPeer implements peering.TrustedNetworkManager.
+func (tnm *TrustedNetworkManager) TrustPeer(pubKey kyber.Point, name string) (*peering.TrustedPeer, error) {
+	tnm.lock.Lock()
+	defer tnm.lock.Unlock()
+	tp := peering.NewTrustedPeer(pubKey, name)
+	tnm.trusted[pubKey.String()] = tp
+	return tp, nil
+}
+
+// DistrustPeer implements peering.TrustedNetworkManager.
+func (tnm *TrustedNetworkManager) DistrustPeer(pubKey kyber.Point) (*peering.TrustedPeer, error) {
+	tnm.lock.Lock()
+	defer tnm.lock.Unlock()
+	tp, ok := tnm.trusted[pubKey.String()]
+	if !ok {
+		return nil, fmt.Errorf("peer with the public key %v is not trusted", pubKey)
+	}
+	delete(tnm.trusted, pubKey.String())
+	return tp, nil
+}
+
+// TrustedPeers implements peering.TrustedNetworkManager.
+func (tnm *TrustedNetworkManager) TrustedPeers() ([]*peering.TrustedPeer, error) {
+	tnm.lock.RLock()
+	defer tnm.lock.RUnlock()
+	ret := make([]*peering.TrustedPeer, 0, len(tnm.trusted))
+	for _, tp := range tnm.trusted {
+		ret = append(ret, tp)
+	}
+	return ret, nil
+}
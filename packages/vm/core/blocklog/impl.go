@@ -0,0 +1,95 @@
+// 'blocklog' is a core contract on the chain. It keeps the record of each block of the chain:
+// its requests and timestamp, and the history of the chain state, which makes the
+// state of smart contracts as of earlier blocks accessible through the sandbox.
+// The history is kept for the latest blocks only, the number of them is set by the chain owner
+package blocklog
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	assert2 "github.com/iotaledger/wasp/packages/coretypes/assert"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/collections"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
+	ctx.Log().Debugf("blocklog.initialize.success hname = %s", Interface.Hname().String())
+	return nil, nil
+}
+
+// getBlockInfo returns the record of the block
+// Parameters:
+//  - ParamBlockIndex index of the block. Defaults to the latest block
+// Returns:
+//  - ParamBlockIndex, ParamTimestamp and array of request IDs ParamRequestIDs
+func getBlockInfo(ctx coretypes.SandboxView) (dict.Dict, error) {
+	latest, err := GetLatestBlockIndex(ctx.State())
+	if err != nil {
+		return nil, err
+	}
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	blockIndex, err := params.GetInt64(ParamBlockIndex, int64(latest))
+	if err != nil {
+		return nil, err
+	}
+	bi, err := GetBlockInfo(ctx.State(), uint32(blockIndex))
+	if err != nil {
+		return nil, err
+	}
+	if bi == nil {
+		return nil, fmt.Errorf("blocklog.getBlockInfo: block #%d not found", blockIndex)
+	}
+	ret := dict.New()
+	ret.Set(ParamBlockIndex, codec.EncodeInt64(int64(bi.BlockIndex)))
+	ret.Set(ParamTimestamp, codec.EncodeInt64(bi.Timestamp))
+	reqs := collections.NewArray(ret, ParamRequestIDs)
+	for i := range bi.RequestIDs {
+		reqs.MustPush(bi.RequestIDs[i][:])
+	}
+	return ret, nil
+}
+
+// getLatestBlockIndex returns index of the latest block recorded in the log
+// Returns:
+//  - ParamBlockIndex
+func getLatestBlockIndex(ctx coretypes.SandboxView) (dict.Dict, error) {
+	latest, err := GetLatestBlockIndex(ctx.State())
+	if err != nil {
+		return nil, err
+	}
+	ret := dict.New()
+	ret.Set(ParamBlockIndex, codec.EncodeInt64(int64(latest)))
+	return ret, nil
+}
+
+// setHistoryRetention sets the number of the latest blocks the state history is kept for.
+// History of older blocks is pruned gradually by the following blocks
+// Parameters:
+//  - ParamHistoryRetention int64 positive number of blocks
+func setHistoryRetention(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(ctx.Caller() == ctx.ChainOwnerID(), "blocklog.setHistoryRetention: not authorized")
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	retention := params.MustGetInt64(ParamHistoryRetention)
+	a.Require(retention > 0 && retention <= math.MaxUint32, "blocklog.setHistoryRetention: wrong retention")
+	ctx.State().Set(varHistoryRetention, util.Uint32To4Bytes(uint32(retention)))
+	return nil, nil
+}
+
+// getHistoryRetention returns the number of the latest blocks the state history is kept for
+// Returns:
+//  - ParamHistoryRetention
+func getHistoryRetention(ctx coretypes.SandboxView) (dict.Dict, error) {
+	retention, err := GetHistoryRetention(ctx.State())
+	if err != nil {
+		return nil, err
+	}
+	ret := dict.New()
+	ret.Set(ParamHistoryRetention, codec.EncodeInt64(int64(retention)))
+	return ret, nil
+}
@@ -0,0 +1,104 @@
+package blocklog
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	Name        = "blocklog"
+	description = "Block log Contract"
+)
+
+var (
+	Interface = &coreutil.ContractInterface{
+		Name:        Name,
+		Description: description,
+		ProgramHash: hashing.HashStrings(Name),
+	}
+)
+
+func init() {
+	Interface.WithFunctions(initialize, []coreutil.ContractFunctionInterface{
+		coreutil.ViewFunc(FuncGetBlockInfo, getBlockInfo),
+		coreutil.ViewFunc(FuncGetLatestBlockIndex, getLatestBlockIndex),
+		coreutil.Func(FuncSetHistoryRetention, setHistoryRetention),
+		coreutil.ViewFunc(FuncGetHistoryRetention, getHistoryRetention),
+	})
+}
+
+const (
+	// request parameters
+	ParamBlockIndex = "blockIndex"
+	ParamTimestamp  = "timestamp"
+	ParamRequestIDs = "requestIDs"
+	// number of the latest blocks the state history is kept for
+	ParamHistoryRetention = "historyRetention"
+
+	// function names
+	FuncGetBlockInfo        = "getBlockInfo"
+	FuncGetLatestBlockIndex = "getLatestBlockIndex"
+	FuncSetHistoryRetention = "setHistoryRetention"
+	FuncGetHistoryRetention = "getHistoryRetention"
+)
+
+// BlockInfo is the record of the block in the 'blocklog'
+type BlockInfo struct {
+	BlockIndex uint32
+	// timestamp of the first request in the block
+	Timestamp  int64
+	RequestIDs []coretypes.RequestID
+}
+
+func (bi *BlockInfo) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, bi.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, bi.Timestamp); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, uint16(len(bi.RequestIDs))); err != nil {
+		return err
+	}
+	for i := range bi.RequestIDs {
+		if err := bi.RequestIDs[i].Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bi *BlockInfo) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &bi.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &bi.Timestamp); err != nil {
+		return err
+	}
+	var n uint16
+	if err := util.ReadUint16(r, &n); err != nil {
+		return err
+	}
+	bi.RequestIDs = make([]coretypes.RequestID, n)
+	for i := range bi.RequestIDs {
+		if err := bi.RequestIDs[i].Read(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func EncodeBlockInfo(bi *BlockInfo) []byte {
+	return util.MustBytes(bi)
+}
+
+func DecodeBlockInfo(data []byte) (*BlockInfo, error) {
+	ret := new(BlockInfo)
+	err := ret.Read(bytes.NewReader(data))
+	return ret, err
+}
@@ -0,0 +1,269 @@
+package blocklog
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/collections"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	// map of block records: block index -> BlockInfo
+	varBlockRegistry = "b"
+	// index of the latest block recorded
+	varLatestBlockIndex = "l"
+	// history of each key modified in the chain state is kept under the hash of the key:
+	// range of indices of history records and records with the value of the key before it was modified in the block
+	varHistoryLenPrefix    = "n"
+	varHistoryRecordPrefix = "h"
+	// hashes of the keys recorded in the history by each block: number of keys and the hashes by index
+	varBlockKeysLenPrefix = "c"
+	varBlockKeysPrefix    = "k"
+	// number of the latest blocks the history is kept for
+	varHistoryRetention = "r"
+	// index of the next block, the history records of which are pruned
+	varHistoryPruned = "p"
+)
+
+const (
+	// DefaultHistoryRetention is the number of the latest blocks the history is kept for, if not set by the chain owner
+	DefaultHistoryRetention = uint32(10000)
+	// maximum number of blocks pruned by one request, so reducing the retention doesn't make one request expensive
+	maxPrunedBlocks = 10
+)
+
+func historyLenKey(keyHash hashing.HashValue) kv.Key {
+	return kv.Key(varHistoryLenPrefix + string(keyHash[:]))
+}
+
+func blockKeysLenKey(blockIndex uint32) kv.Key {
+	return kv.Key(varBlockKeysLenPrefix + string(util.Uint32To4Bytes(blockIndex)))
+}
+
+func blockKeyKey(blockIndex uint32, idx uint32) kv.Key {
+	return kv.Key(varBlockKeysPrefix + string(util.Uint32To4Bytes(blockIndex)) + string(util.Uint32To4Bytes(idx)))
+}
+
+func historyRecordKey(keyHash hashing.HashValue, idx uint32) kv.Key {
+	return kv.Key(varHistoryRecordPrefix + string(keyHash[:]) + string(util.Uint32To4Bytes(idx)))
+}
+
+// history record: index of the block which modified the key and the value of the key before the block
+func encodeHistoryRecord(blockIndex uint32, prevValue []byte) []byte {
+	ret := util.Uint32To4Bytes(blockIndex)
+	if prevValue == nil {
+		return append(ret, 0)
+	}
+	ret = append(ret, 1)
+	return append(ret, prevValue...)
+}
+
+func decodeHistoryRecord(data []byte) (uint32, []byte, error) {
+	if len(data) < 5 {
+		return 0, nil, fmt.Errorf("blocklog: wrong history record")
+	}
+	blockIndex := util.MustUint32From4Bytes(data[:4])
+	if data[4] == 0 {
+		return blockIndex, nil, nil
+	}
+	return blockIndex, data[5:], nil
+}
+
+// getHistoryRange returns the index of the first history record of the key which is not pruned and the number
+// of records ever made. The range is encoded as 4 bytes of the number of records while nothing is pruned
+func getHistoryRange(state kv.KVStoreReader, keyHash hashing.HashValue) (uint32, uint32, error) {
+	data, err := state.Get(historyLenKey(keyHash))
+	if err != nil || data == nil {
+		return 0, 0, err
+	}
+	switch len(data) {
+	case 4:
+		n, err := util.Uint32From4Bytes(data)
+		return 0, n, err
+	case 8:
+		return util.MustUint32From4Bytes(data[:4]), util.MustUint32From4Bytes(data[4:]), nil
+	}
+	return 0, 0, fmt.Errorf("blocklog: wrong history range")
+}
+
+func setHistoryRange(state kv.KVStore, keyHash hashing.HashValue, start, n uint32) {
+	switch {
+	case start == n:
+		// all records are pruned
+		state.Del(historyLenKey(keyHash))
+	case start == 0:
+		state.Set(historyLenKey(keyHash), util.Uint32To4Bytes(n))
+	default:
+		state.Set(historyLenKey(keyHash), append(util.Uint32To4Bytes(start), util.Uint32To4Bytes(n)...))
+	}
+}
+
+func getHistoryRecord(state kv.KVStoreReader, keyHash hashing.HashValue, idx uint32) (uint32, []byte, error) {
+	data, err := state.Get(historyRecordKey(keyHash, idx))
+	if err != nil {
+		return 0, nil, err
+	}
+	return decodeHistoryRecord(data)
+}
+
+// SaveRequest adds the request to the record of the block with the index
+func SaveRequest(state kv.KVStore, blockIndex uint32, timestamp int64, reqid coretypes.RequestID) {
+	registry := collections.NewMap(state, varBlockRegistry)
+	bi := &BlockInfo{
+		BlockIndex: blockIndex,
+		Timestamp:  timestamp,
+	}
+	if data := registry.MustGetAt(util.Uint32To4Bytes(blockIndex)); data != nil {
+		var err error
+		if bi, err = DecodeBlockInfo(data); err != nil {
+			panic(err)
+		}
+	}
+	bi.RequestIDs = append(bi.RequestIDs, reqid)
+	registry.MustSetAt(util.Uint32To4Bytes(blockIndex), EncodeBlockInfo(bi))
+	state.Set(varLatestBlockIndex, util.Uint32To4Bytes(blockIndex))
+}
+
+// SaveStateHistory records the value of the key before it is modified by the block with the index.
+// Only the first modification of the key in the block is recorded
+func SaveStateHistory(state kv.KVStore, blockIndex uint32, key kv.Key, prevValue []byte) {
+	keyHash := hashing.HashData([]byte(key))
+	start, n, err := getHistoryRange(state, keyHash)
+	if err != nil {
+		panic(err)
+	}
+	if n > start {
+		lastBlockIndex, _, err := getHistoryRecord(state, keyHash, n-1)
+		if err != nil {
+			panic(err)
+		}
+		if lastBlockIndex == blockIndex {
+			// already recorded in this block
+			return
+		}
+	}
+	state.Set(historyRecordKey(keyHash, n), encodeHistoryRecord(blockIndex, prevValue))
+	setHistoryRange(state, keyHash, start, n+1)
+
+	// the key is pruned together with the block
+	keysLen := mustGetUint32(state, blockKeysLenKey(blockIndex))
+	state.Set(blockKeyKey(blockIndex, keysLen), keyHash[:])
+	state.Set(blockKeysLenKey(blockIndex), util.Uint32To4Bytes(keysLen+1))
+}
+
+// GetHistoryRetention returns the number of the latest blocks the history is kept for
+func GetHistoryRetention(state kv.KVStoreReader) (uint32, error) {
+	data, err := state.Get(varHistoryRetention)
+	if err != nil || data == nil {
+		return DefaultHistoryRetention, err
+	}
+	return util.Uint32From4Bytes(data)
+}
+
+// PruneHistory removes the history records made by the blocks which fall out of the retention window
+// of the block with the index. At most maxPrunedBlocks blocks are pruned at once
+func PruneHistory(state kv.KVStore, blockIndex uint32) {
+	retention, err := GetHistoryRetention(state)
+	if err != nil {
+		panic(err)
+	}
+	next := mustGetUint32(state, varHistoryPruned)
+	pruned := 0
+	for ; pruned < maxPrunedBlocks && uint64(next)+uint64(retention) <= uint64(blockIndex); pruned++ {
+		pruneBlock(state, next)
+		next++
+	}
+	if pruned > 0 {
+		state.Set(varHistoryPruned, util.Uint32To4Bytes(next))
+	}
+}
+
+// pruneBlock removes the history records made by the block. They are the first records of the keys,
+// because the records of all earlier blocks are pruned already
+func pruneBlock(state kv.KVStore, blockIndex uint32) {
+	keysLen := mustGetUint32(state, blockKeysLenKey(blockIndex))
+	for i := uint32(0); i < keysLen; i++ {
+		keyHash, err := hashing.HashValueFromBytes(state.MustGet(blockKeyKey(blockIndex, i)))
+		if err != nil {
+			panic(err)
+		}
+		state.Del(blockKeyKey(blockIndex, i))
+		start, n, err := getHistoryRange(state, keyHash)
+		if err != nil {
+			panic(err)
+		}
+		if start == n {
+			continue
+		}
+		if bi, _, err := getHistoryRecord(state, keyHash, start); err != nil || bi != blockIndex {
+			continue
+		}
+		state.Del(historyRecordKey(keyHash, start))
+		setHistoryRange(state, keyHash, start+1, n)
+	}
+	state.Del(blockKeysLenKey(blockIndex))
+}
+
+func mustGetUint32(state kv.KVStoreReader, key kv.Key) uint32 {
+	data := state.MustGet(key)
+	if data == nil {
+		return 0
+	}
+	return util.MustUint32From4Bytes(data)
+}
+
+// GetStateAt returns the value of the key as it was at the end of the block with the index.
+// - 'state' is the state of the 'blocklog'
+// - 'chainState' is the current state of the chain, the key is the key in the chain state
+func GetStateAt(state kv.KVStoreReader, chainState kv.KVStoreReader, blockIndex uint32, key kv.Key) ([]byte, error) {
+	pruned := mustGetUint32(state, varHistoryPruned)
+	if blockIndex+1 < pruned {
+		return nil, fmt.Errorf("blocklog: history of the block #%d is pruned", blockIndex)
+	}
+	keyHash := hashing.HashData([]byte(key))
+	start, n, err := getHistoryRange(state, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	// find first modification of the key after the block. It records the value of the key at the end of the block
+	var errSearch error
+	idx := int(start) + sort.Search(int(n-start), func(i int) bool {
+		bi, _, err := getHistoryRecord(state, keyHash, start+uint32(i))
+		if err != nil {
+			errSearch = err
+			return true
+		}
+		return bi > blockIndex
+	})
+	if errSearch != nil {
+		return nil, errSearch
+	}
+	if idx == int(n) {
+		// the key wasn't modified after the block
+		return chainState.Get(key)
+	}
+	_, ret, err := getHistoryRecord(state, keyHash, uint32(idx))
+	return ret, err
+}
+
+// GetBlockInfo returns the record of the block or nil if it doesn't exist
+func GetBlockInfo(state kv.KVStoreReader, blockIndex uint32) (*BlockInfo, error) {
+	data, err := collections.NewMapReadOnly(state, varBlockRegistry).GetAt(util.Uint32To4Bytes(blockIndex))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return DecodeBlockInfo(data)
+}
+
+// GetLatestBlockIndex returns the index of the latest block recorded in the log
+func GetLatestBlockIndex(state kv.KVStoreReader) (uint32, error) {
+	data, err := state.Get(varLatestBlockIndex)
+	if err != nil || data == nil {
+		return 0, err
+	}
+	return util.Uint32From4Bytes(data)
+}
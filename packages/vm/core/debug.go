@@ -5,8 +5,11 @@ import (
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/vm/core/accounts"
 	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
 	"github.com/iotaledger/wasp/packages/vm/core/eventlog"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
 )
 
 func init() {
@@ -20,6 +23,9 @@ func printWellKnownHnames() {
 	fmt.Printf("    %10s: '%s'\n", accounts.Interface.Hname().String(), accounts.Interface.Name)
 	fmt.Printf("    %10s: '%s'\n", blob.Interface.Hname().String(), blob.Interface.Name)
 	fmt.Printf("    %10s: '%s'\n", eventlog.Interface.Hname().String(), eventlog.Interface.Name)
+	fmt.Printf("    %10s: '%s'\n", blocklog.Interface.Hname().String(), blocklog.Interface.Name)
+	fmt.Printf("    %10s: '%s'\n", receipts.Interface.Hname().String(), receipts.Interface.Name)
+	fmt.Printf("    %10s: '%s'\n", scheduler.Interface.Hname().String(), scheduler.Interface.Name)
 	fmt.Printf("    %10s: '%s'\n", coretypes.EntryPointInit.String(), coretypes.FuncInit)
 	fmt.Printf("--------------- well known hnames ------------------\n")
 }
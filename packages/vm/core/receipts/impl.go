@@ -0,0 +1,34 @@
+// 'receipts' is a core contract on the chain. It keeps the receipt of each request processed by the chain:
+// where the request was settled, the error it returned, the fees charged and, optionally, the result
+package receipts
+
+import (
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+)
+
+func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
+	ctx.Log().Debugf("receipts.initialize.success hname = %s", Interface.Hname().String())
+	return nil, nil
+}
+
+// getRequestReceipt returns the receipt of the request
+// Parameters:
+//  - ParamRequestID ID of the request
+// Returns:
+//  - ParamReceipt encoded RequestReceipt or empty result if the request wasn't processed by the chain
+func getRequestReceipt(ctx coretypes.SandboxView) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	reqid, err := params.GetRequestID(ParamRequestID)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := GetReceipt(ctx.State(), reqid)
+	if err != nil || rec == nil {
+		return nil, err
+	}
+	ret := dict.New()
+	ret.Set(ParamReceipt, EncodeRequestReceipt(rec))
+	return ret, nil
+}
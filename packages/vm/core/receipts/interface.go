@@ -0,0 +1,143 @@
+package receipts
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	Name        = "receipts"
+	description = "Request receipts Contract"
+)
+
+var (
+	Interface = &coreutil.ContractInterface{
+		Name:        Name,
+		Description: description,
+		ProgramHash: hashing.HashStrings(Name),
+	}
+)
+
+func init() {
+	Interface.WithFunctions(initialize, []coreutil.ContractFunctionInterface{
+		coreutil.ViewFunc(FuncGetRequestReceipt, getRequestReceipt),
+	})
+}
+
+const (
+	// request parameters
+	ParamRequestID = "requestID"
+	ParamReceipt   = "receipt"
+
+	// function names
+	FuncGetRequestReceipt = "getRequestReceipt"
+
+	// MaxResultSize is the maximum size of the encoded result of the request stored in the receipt.
+	// Larger results are not stored
+	MaxResultSize = 1024
+)
+
+// RequestReceipt is the record of the processed request
+type RequestReceipt struct {
+	RequestID coretypes.RequestID
+	// index of the block the request was settled in
+	BlockIndex uint32
+	// position of the request in the block
+	RequestIndex uint16
+	// error returned by the request or empty string if the request was successful
+	Error string
+	// fees charged for the request
+	FeeColor    balance.Color
+	FeesCharged int64
+	GasBurned   int64
+	// result returned by the request. nil if the result was empty or too large to be stored
+	Result dict.Dict
+}
+
+func (rec *RequestReceipt) Write(w io.Writer) error {
+	if err := rec.RequestID.Write(w); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, rec.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.WriteUint16(w, rec.RequestIndex); err != nil {
+		return err
+	}
+	if err := util.WriteBytes32(w, []byte(rec.Error)); err != nil {
+		return err
+	}
+	if _, err := w.Write(rec.FeeColor[:]); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, rec.FeesCharged); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, rec.GasBurned); err != nil {
+		return err
+	}
+	if err := util.WriteBoolByte(w, rec.Result != nil); err != nil {
+		return err
+	}
+	if rec.Result != nil {
+		if err := rec.Result.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rec *RequestReceipt) Read(r io.Reader) error {
+	if err := rec.RequestID.Read(r); err != nil {
+		return err
+	}
+	if err := util.ReadUint32(r, &rec.BlockIndex); err != nil {
+		return err
+	}
+	if err := util.ReadUint16(r, &rec.RequestIndex); err != nil {
+		return err
+	}
+	e, err := util.ReadBytes32(r)
+	if err != nil {
+		return err
+	}
+	rec.Error = string(e)
+	if err := util.ReadColor(r, &rec.FeeColor); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &rec.FeesCharged); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &rec.GasBurned); err != nil {
+		return err
+	}
+	var hasResult bool
+	if err := util.ReadBoolByte(r, &hasResult); err != nil {
+		return err
+	}
+	rec.Result = nil
+	if hasResult {
+		rec.Result = dict.New()
+		if err := rec.Result.Read(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func EncodeRequestReceipt(rec *RequestReceipt) []byte {
+	return util.MustBytes(rec)
+}
+
+func DecodeRequestReceipt(data []byte) (*RequestReceipt, error) {
+	ret := new(RequestReceipt)
+	err := ret.Read(bytes.NewReader(data))
+	return ret, err
+}
@@ -0,0 +1,50 @@
+package receipts
+
+import (
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/collections"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	// map of receipts: request ID -> RequestReceipt
+	varReceipts = "r"
+	// set of requests with the timeout, the result of which was delivered to the callback
+	// and the timeout request is not processed yet: request ID -> 0
+	varCallbacksDelivered = "c"
+)
+
+// SaveReceipt stores the receipt of the request. The result is only stored if it is not larger than MaxResultSize
+func SaveReceipt(state kv.KVStore, rec *RequestReceipt) {
+	if rec.Result != nil && (len(rec.Result) == 0 || len(util.MustBytes(rec.Result)) > MaxResultSize) {
+		rec.Result = nil
+	}
+	collections.NewMap(state, varReceipts).MustSetAt(rec.RequestID[:], EncodeRequestReceipt(rec))
+}
+
+// GetReceipt returns the receipt of the request or nil if the request wasn't processed by the chain
+func GetReceipt(state kv.KVStoreReader, reqid coretypes.RequestID) (*RequestReceipt, error) {
+	data, err := collections.NewMapReadOnly(state, varReceipts).GetAt(reqid[:])
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return DecodeRequestReceipt(data)
+}
+
+// MarkCallbackDelivered records that the result of the request with the timeout was delivered to the callback,
+// so the timeout request of the same request is ignored
+func MarkCallbackDelivered(state kv.KVStore, reqid coretypes.RequestID) {
+	collections.NewMap(state, varCallbacksDelivered).MustSetAt(reqid[:], []byte{0})
+}
+
+// TakeCallbackDelivered is called for the timeout request of the request. It returns true if the result
+// of the request was delivered to the callback before and deletes the record, which is not needed anymore
+func TakeCallbackDelivered(state kv.KVStore, reqid coretypes.RequestID) bool {
+	delivered := collections.NewMap(state, varCallbacksDelivered)
+	if !delivered.MustHasAt(reqid[:]) {
+		return false
+	}
+	delivered.MustDelAt(reqid[:])
+	return true
+}
@@ -0,0 +1,196 @@
+package root
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/collections"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+// FeeRefundPolicy defines what happens with the request which doesn't transfer enough tokens to cover the fee.
+// In any case the request is not run
+type FeeRefundPolicy int64
+
+const (
+	// FeeRefundAll: no fee is charged, the whole transfer is accrued to the sender. It is the default
+	FeeRefundAll = FeeRefundPolicy(iota)
+	// FeeRefundPartial: the fee tokens of the transfer are charged up to the fee, the rest is refunded to the sender
+	FeeRefundPartial
+)
+
+// FeePolicy is the fee policy in effect for the particular entry point of the smart contract.
+//  - fixed fee is OwnerFee + ValidatorFee, charged for every request
+//  - payload fee is FeePerByte multiplied by the size of the request arguments
+//  - gas fee is FeePerKiloGas for every started 1000 units of gas burned by the request
+// The owner fee goes to the chain owner, the rest goes to the validator
+type FeePolicy struct {
+	FeeColor      balance.Color
+	OwnerFee      int64
+	ValidatorFee  int64
+	FeePerByte    int64
+	FeePerKiloGas int64
+}
+
+// FixedFee returns the part of the fee which doesn't depend on the request
+func (p *FeePolicy) FixedFee() int64 {
+	return p.OwnerFee + p.ValidatorFee
+}
+
+// PayloadFee returns the fee for the arguments of the request of the given size in bytes
+func (p *FeePolicy) PayloadFee(size int) int64 {
+	return p.FeePerByte * int64(size)
+}
+
+// GasFee returns the fee for the gas burned by the request
+func (p *FeePolicy) GasFee(gas int64) int64 {
+	if p.FeePerKiloGas == 0 || gas <= 0 {
+		return 0
+	}
+	return (gas + 999) / 1000 * p.FeePerKiloGas
+}
+
+// GasCovered returns the maximum gas covered by the gas fee. It returns -1 if gas is free
+func (p *FeePolicy) GasCovered(gasFee int64) int64 {
+	if p.FeePerKiloGas == 0 {
+		return -1
+	}
+	return gasFee / p.FeePerKiloGas * 1000
+}
+
+// TotalFee returns the maximum fee of the request with the arguments of the given size and the gas budget
+func (p *FeePolicy) TotalFee(size int, gasBudget int64) int64 {
+	return p.FixedFee() + p.PayloadFee(size) + p.GasFee(gasBudget)
+}
+
+// IsEmpty returns true if the policy doesn't charge any fees
+func (p *FeePolicy) IsEmpty() bool {
+	return p.OwnerFee == 0 && p.ValidatorFee == 0 && p.FeePerByte == 0 && p.FeePerKiloGas == 0
+}
+
+// inheritFrom takes values which are not set in the policy from the other policy
+func (p *FeePolicy) inheritFrom(other *FeePolicy) {
+	if p.OwnerFee == 0 {
+		p.OwnerFee = other.OwnerFee
+	}
+	if p.ValidatorFee == 0 {
+		p.ValidatorFee = other.ValidatorFee
+	}
+	if p.FeePerByte == 0 {
+		p.FeePerByte = other.FeePerByte
+	}
+	if p.FeePerKiloGas == 0 {
+		p.FeePerKiloGas = other.FeePerKiloGas
+	}
+}
+
+// Write encodes the stored part of the fee policy. The fee color is chain-wide and is not stored
+func (p *FeePolicy) Write(w io.Writer) error {
+	if err := util.WriteInt64(w, p.OwnerFee); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, p.ValidatorFee); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, p.FeePerByte); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, p.FeePerKiloGas); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *FeePolicy) Read(r io.Reader) error {
+	if err := util.ReadInt64(r, &p.OwnerFee); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &p.ValidatorFee); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &p.FeePerByte); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &p.FeePerKiloGas); err != nil {
+		return err
+	}
+	return nil
+}
+
+func decodeFeePolicy(data []byte) (*FeePolicy, error) {
+	ret := &FeePolicy{}
+	if data == nil {
+		return ret, nil
+	}
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// feePolicyKey is the key of the fee policy of the chain (hname 0), of the contract (entry point 0)
+// or of the entry point of the contract
+func feePolicyKey(contract, entryPoint coretypes.Hname) []byte {
+	if entryPoint == 0 {
+		return contract.Bytes()
+	}
+	return append(contract.Bytes(), entryPoint.Bytes()...)
+}
+
+func getStoredFeePolicy(state kv.KVStoreReader, contract, entryPoint coretypes.Hname) *FeePolicy {
+	data := collections.NewMapReadOnly(state, VarFeePolicies).MustGetAt(feePolicyKey(contract, entryPoint))
+	ret, err := decodeFeePolicy(data)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func setStoredFeePolicy(state kv.KVStore, contract, entryPoint coretypes.Hname, p *FeePolicy) {
+	fees := collections.NewMap(state, VarFeePolicies)
+	if p.IsEmpty() {
+		fees.MustDelAt(feePolicyKey(contract, entryPoint))
+		return
+	}
+	fees.MustSetAt(feePolicyKey(contract, entryPoint), util.MustBytes(p))
+}
+
+// GetFeePolicy is an internal utility function which returns the fee policy in effect for the entry point
+// of the contract. Values which are not set (0) for the entry point are taken from the contract,
+// values not set for the contract are taken from the chain defaults.
+// It is called from within the 'root' contract as well as VMContext. It is not exposed to the sandbox
+func GetFeePolicy(state kv.KVStoreReader, rec *ContractRecord, entryPoint coretypes.Hname) *FeePolicy {
+	ret := &FeePolicy{}
+	if rec != nil {
+		contract := coretypes.Hn(rec.Name)
+		ret.inheritFrom(getStoredFeePolicy(state, contract, entryPoint))
+		ret.inheritFrom(getStoredFeePolicy(state, contract, 0))
+	}
+	// fixed fees of the contract and the chain are kept in the contract record and in the chain defaults
+	var ownerFee, validatorFee int64
+	ret.FeeColor, ownerFee, validatorFee = GetFeeInfoByContractRecord(state, rec)
+	ret.inheritFrom(&FeePolicy{OwnerFee: ownerFee, ValidatorFee: validatorFee})
+	ret.inheritFrom(getStoredFeePolicy(state, 0, 0))
+	return ret
+}
+
+// GetFeeRefundPolicy returns the chain-wide fee refund policy
+func GetFeeRefundPolicy(state kv.KVStoreReader) FeeRefundPolicy {
+	ret, _, err := codec.DecodeInt64(state.MustGet(VarFeeRefundPolicy))
+	if err != nil {
+		panic(err)
+	}
+	return FeeRefundPolicy(ret)
+}
+
+// IsFeeExempt returns if requests from the agent are not charged any fees. The chain owner is always exempt
+func IsFeeExempt(state kv.KVStoreReader, agentID coretypes.AgentID) bool {
+	if CheckAuthorizationByChainOwner(state, agentID) {
+		return true
+	}
+	return collections.NewMapReadOnly(state, VarFeeExempt).MustHasAt(agentID[:])
+}
@@ -3,21 +3,27 @@
 // - maintaining of core parameters of the chain
 // - maintaining (setting, delegating) chain owner ID
 // - maintaining (granting, revoking) smart contract deployment rights
-// - deployment of smart contracts on the chain and maintenance of contract registry
+// - maintaining access control lists (roles and guarded entry points) of smart contracts
+// - deployment and upgrade of smart contracts on the chain and maintenance of contract registry
 package root
 
 import (
 	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	assert2 "github.com/iotaledger/wasp/packages/coretypes/assert"
+	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/kv/codec"
 	"github.com/iotaledger/wasp/packages/kv/collections"
 	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
 	"github.com/iotaledger/wasp/packages/vm/core/accounts"
 	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
 	"github.com/iotaledger/wasp/packages/vm/core/eventlog"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
 )
 
 // initialize handles constructor, the "init" request. This is the first call to the chain
@@ -25,13 +31,14 @@ import (
 // - stores chain ID and chain description in the state
 // - sets state ownership to the caller
 // - creates record in the registry for the 'root' itself
-// - deploys other core contracts: 'accounts', 'blob', 'eventlog' by creating records in the registry and calling constructors
+// - deploys other core contracts: 'accounts', 'blob', 'eventlog', 'blocklog', 'receipts', 'scheduler' by creating records in the registry and calling constructors
 // Input:
 // - ParamChainID coretypes.ChainID. ID of the chain. Cannot be changed
 // - ParamChainColor balance.Color
 // - ParamChainAddress address.Address
 // - ParamDescription string defaults to "N/A"
 // - ParamFeeColor balance.Color fee color code. Defaults to IOTA color. It cannot be changed
+// - ParamConsensus string the consensus algorithm all committee nodes must run. Defaults to "". It cannot be changed
 func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
 	ctx.Log().Debugf("root.initialize.begin")
 	state := ctx.State()
@@ -48,6 +55,7 @@ func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
 	chainColor := params.MustGetColor(ParamChainColor)
 	chainAddress := params.MustGetAddress(ParamChainAddress)
 	chainDescription := params.MustGetString(ParamDescription, "N/A")
+	consensus := params.MustGetString(ParamConsensus, "")
 	feeColor := params.MustGetColor(ParamFeeColor, balance.ColorIOTA)
 	feeColorSet := feeColor != balance.ColorIOTA
 
@@ -72,6 +80,21 @@ func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
 	err = storeAndInitContract(ctx, &rec, nil)
 	a.Require(err == nil, "root.init.fail: %v", err)
 
+	// deploy blocklog
+	rec = NewContractRecord(blocklog.Interface, ctx.Caller())
+	err = storeAndInitContract(ctx, &rec, nil)
+	a.Require(err == nil, "root.init.fail: %v", err)
+
+	// deploy receipts
+	rec = NewContractRecord(receipts.Interface, ctx.Caller())
+	err = storeAndInitContract(ctx, &rec, nil)
+	a.Require(err == nil, "root.init.fail: %v", err)
+
+	// deploy scheduler
+	rec = NewContractRecord(scheduler.Interface, ctx.Caller())
+	err = storeAndInitContract(ctx, &rec, nil)
+	a.Require(err == nil, "root.init.fail: %v", err)
+
 	state.Set(VarStateInitialized, []byte{0xFF})
 	state.Set(VarChainID, codec.EncodeChainID(chainID))
 	state.Set(VarChainColor, codec.EncodeColor(chainColor))
@@ -81,10 +104,16 @@ func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
 	if feeColorSet {
 		state.Set(VarFeeColor, codec.EncodeColor(feeColor))
 	}
+	if consensus != "" {
+		state.Set(VarConsensus, codec.EncodeString(consensus))
+	}
 	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", Interface.Name, Interface.Hname().String())
 	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", blob.Interface.Name, blob.Interface.Hname().String())
 	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", accounts.Interface.Name, accounts.Interface.Hname().String())
 	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", eventlog.Interface.Name, eventlog.Interface.Hname().String())
+	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", blocklog.Interface.Name, blocklog.Interface.Hname().String())
+	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", receipts.Interface.Name, receipts.Interface.Hname().String())
+	ctx.Log().Debugf("root.initialize.deployed: '%s', hname = %s", scheduler.Interface.Name, scheduler.Interface.Hname().String())
 	ctx.Log().Debugf("root.initialize.success")
 	return nil, nil
 }
@@ -135,6 +164,98 @@ func deployContract(ctx coretypes.Sandbox) (dict.Dict, error) {
 	return nil, nil
 }
 
+// upgradeContract replaces the program of the deployed contract while keeping its hname, state and balances.
+// Only the creator of the contract or the chain owner can upgrade it. Core contracts can't be upgraded.
+// After the record is updated, calls the 'migrate' entry point of the new program, if it exists.
+// If the call to 'migrate' fails, the upgrade fails too
+// Inputs:
+// - ParamHname coretypes.Hname of the contract
+// - ParamProgramHash HashValue is a hash of the new program binary
+// - ParamDescription string new description of the contract. Optional, not changed if skipped
+func upgradeContract(ctx coretypes.Sandbox) (dict.Dict, error) {
+	ctx.Log().Debugf("root.upgradeContract.begin")
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	a := assert2.NewAssert(ctx.Log())
+
+	hname := params.MustGetHname(ParamHname)
+	progHash := params.MustGetHashValue(ParamProgramHash)
+	a.Require(!isCoreContract(hname), "root.upgradeContract: core contract can't be upgraded")
+
+	rec, err := FindContract(ctx.State(), hname)
+	a.Require(err == nil, "root.upgradeContract.fail: %v", err)
+	a.Require(ctx.Caller() == rec.Creator || CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()),
+		"root.upgradeContract: not authorized: %s", ctx.Caller())
+	a.Require(rec.Status != ContractStatusRemoved, "root.upgradeContract: contract '%s' is removed", rec.Name)
+	a.Require(rec.ProgramHash != progHash, "root.upgradeContract: the program is the same")
+
+	// pass to migrate function all params not consumed so far
+	migrateParams := dict.New()
+	for key, value := range ctx.Params() {
+		if key != ParamProgramHash && key != ParamHname && key != ParamDescription {
+			migrateParams.Set(key, value)
+		}
+	}
+	// calls to loads VM from binary to check if it loads successfully
+	err = ctx.DeployContract(progHash, "", "", nil)
+	a.Require(err == nil, "root.upgradeContract.fail: %v", err)
+
+	oldProgHash := rec.ProgramHash
+	rec.ProgramHash = progHash
+	rec.Description = params.MustGetString(ParamDescription, rec.Description)
+	err = storeAndMigrateContract(ctx, rec, migrateParams)
+	a.Require(err == nil, "root.upgradeContract.fail: %v", err)
+
+	ctx.Event(fmt.Sprintf("[upgrade] name: %s hname: %s, progHash: %s --> %s, dscr: '%s'",
+		rec.Name, hname, oldProgHash.String(), progHash.String(), rec.Description))
+	return nil, nil
+}
+
+// pauseContract pauses the contract. Requests to the paused contract are refunded to the sender minus fees,
+// calls to it from other contracts fail. Views of the paused contract can be called.
+// Only the chain owner can pause contracts. Core contracts can't be paused
+// Input:
+// - ParamHname coretypes.Hname of the contract
+func pauseContract(ctx coretypes.Sandbox) (dict.Dict, error) {
+	rec := mustChangeContractStatus(ctx, "root.pauseContract", ContractStatusPaused, ContractStatusActive)
+	ctx.Event(fmt.Sprintf("[pause] name: %s hname: %s", rec.Name, rec.Hname()))
+	return nil, nil
+}
+
+// resumeContract resumes the paused contract
+// Input:
+// - ParamHname coretypes.Hname of the contract
+func resumeContract(ctx coretypes.Sandbox) (dict.Dict, error) {
+	rec := mustChangeContractStatus(ctx, "root.resumeContract", ContractStatusActive, ContractStatusPaused)
+	ctx.Event(fmt.Sprintf("[resume] name: %s hname: %s", rec.Name, rec.Hname()))
+	return nil, nil
+}
+
+// removeContract removes the contract from the chain. The remaining balance of the contract is moved
+// to the designated agent, the state of the contract and its access control list are deleted.
+// The record of the contract remains in the registry with the 'removed' status, so the name can't be reused.
+// Only the chain owner can remove contracts. Core contracts can't be removed
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// - ParamAgentID coretypes.AgentID the target of the remaining balance. Defaults to the chain owner
+func removeContract(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	balanceTarget := params.MustGetAgentID(ParamAgentID, ctx.ChainOwnerID())
+
+	rec := mustChangeContractStatus(ctx, "root.removeContract", ContractStatusRemoved, ContractStatusActive, ContractStatusPaused)
+	hname := rec.Hname()
+	err := ctx.RemoveContract(hname, balanceTarget)
+	a.Require(err == nil, "root.removeContract.fail: %v", err)
+
+	state := ctx.State()
+	state.DelPrefix(aclRolesPrefix(hname))
+	state.DelPrefix(kv.Key(VarACLMembers) + kv.Key(hname.Bytes()))
+	state.DelPrefix(aclGuardsPrefix(hname))
+
+	ctx.Event(fmt.Sprintf("[remove] name: %s hname: %s, balance moved to: %s", rec.Name, hname, balanceTarget))
+	return nil, nil
+}
+
 // findContract view finds and returns encoded record of the contract
 // Input:
 // - ParamHname
@@ -175,6 +296,9 @@ func getChainInfo(ctx coretypes.SandboxView) (dict.Dict, error) {
 	ret.Set(VarFeeColor, codec.EncodeColor(info.FeeColor))
 	ret.Set(VarDefaultOwnerFee, codec.EncodeInt64(info.DefaultOwnerFee))
 	ret.Set(VarDefaultValidatorFee, codec.EncodeInt64(info.DefaultValidatorFee))
+	if info.Consensus != "" {
+		ret.Set(VarConsensus, codec.EncodeString(info.Consensus))
+	}
 
 	src := collections.NewMapReadOnly(ctx.State(), VarContractRegistry)
 	dst := collections.NewMap(ret, VarContractRegistry)
@@ -222,6 +346,68 @@ func claimChainOwnership(ctx coretypes.Sandbox) (dict.Dict, error) {
 	return nil, nil
 }
 
+// rotateCommittee announces the rotation of the chain to the new committee. The address of the new committee
+// is stored as the next address of the chain. The chain is moved to it only by the successful call
+// to confirmRotation, so the chain can't be moved to the address which is not controlled by the committee.
+// Announcing another address replaces the previous one.
+// Checks authorisation by the current owner
+// Input:
+// - ParamChainAddress address.Address the shared (BLS) address of the new committee
+func rotateCommittee(ctx coretypes.Sandbox) (dict.Dict, error) {
+	ctx.Log().Debugf("root.rotateCommittee.begin")
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "root.rotateCommittee: not authorized")
+
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	nextAddress := params.MustGetAddress(ParamChainAddress)
+	a.Require(nextAddress.Version() == address.VersionBLS, "root.rotateCommittee: not a shared address of a committee")
+	stateDecoder := kvdecoder.New(ctx.State(), ctx.Log())
+	currentAddress := stateDecoder.MustGetAddress(VarChainAddress)
+	a.Require(nextAddress != currentAddress, "root.rotateCommittee: the chain is already controlled by the address")
+
+	ctx.State().Set(VarChainAddressNext, codec.EncodeAddress(nextAddress))
+	ctx.Event(fmt.Sprintf("[rotate committee announced] %s --> %s", currentAddress.String(), nextAddress.String()))
+	ctx.Log().Debugf("root.rotateCommittee.success: rotation announced: %s --> %s",
+		currentAddress.String(), nextAddress.String())
+	return nil, nil
+}
+
+// confirmRotation moves the chain to the address announced by rotateCommittee.
+// The new committee proves it holds the shares of the key of the address by the signature of the data
+// returned by RotationProofData. The signature can be made only by the quorum of the new committee.
+// The anchor transaction of the block with the request sends the chain token and all tokens of the chain
+// to the new address. From the next block on the chain is run by the new committee.
+// Checks authorisation by the current owner
+// Input:
+// - ParamPublicKey []byte the shared public key of the new committee
+// - ParamSignature []byte the BLS signature of the new committee
+func confirmRotation(ctx coretypes.Sandbox) (dict.Dict, error) {
+	ctx.Log().Debugf("root.confirmRotation.begin")
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "root.confirmRotation: not authorized")
+
+	stateDecoder := kvdecoder.New(ctx.State(), ctx.Log())
+	currentAddress := stateDecoder.MustGetAddress(VarChainAddress)
+	nextAddress := stateDecoder.MustGetAddress(VarChainAddressNext, currentAddress)
+	a.Require(nextAddress != currentAddress, "root.confirmRotation: rotation is not announced")
+
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	pubKey := params.MustGetBytes(ParamPublicKey)
+	signature := params.MustGetBytes(ParamSignature)
+	signer, err := ctx.Utils().BLS().AddressFromPublicKey(pubKey)
+	a.RequireNoError(err)
+	a.Require(signer == nextAddress, "root.confirmRotation: the public key is not the key of the announced address")
+	a.Require(ctx.Utils().BLS().ValidSignature(RotationProofData(ctx.ContractID().ChainID(), nextAddress), pubKey, signature),
+		"root.confirmRotation: invalid signature of the new committee")
+
+	ctx.State().Set(VarChainAddress, codec.EncodeAddress(nextAddress))
+	ctx.State().Del(VarChainAddressNext)
+	ctx.Event(fmt.Sprintf("[rotate committee] %s --> %s", currentAddress.String(), nextAddress.String()))
+	ctx.Log().Debugf("root.confirmRotation.success: chain address changed: %s --> %s",
+		currentAddress.String(), nextAddress.String())
+	return nil, nil
+}
+
 // getFeeInfo returns fee information for the contract.
 // Input:
 // - ParamHname coretypes.Hname contract id
@@ -247,6 +433,7 @@ func getFeeInfo(ctx coretypes.SandboxView) (dict.Dict, error) {
 // Input:
 // - ParamOwnerFee int64 non-negative value of the owner fee. May be skipped, then it is not set
 // - ParamValidatorFee int64 non-negative value of the contract fee. May be skipped, then it is not set
+// - ParamFeeRefund int64 the FeeRefundPolicy of the chain. May be skipped, then it is not set
 func setDefaultFee(ctx coretypes.Sandbox) (dict.Dict, error) {
 	a := assert2.NewAssert(ctx.Log())
 	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "root.setDefaultFee: not authorized")
@@ -257,21 +444,18 @@ func setDefaultFee(ctx coretypes.Sandbox) (dict.Dict, error) {
 	ownerFeeSet := ownerFee >= 0
 	validatorFee := params.MustGetInt64(ParamValidatorFee, -1)
 	validatorFeeSet := validatorFee >= 0
+	refundPolicy := params.MustGetInt64(ParamFeeRefund, -1)
+	refundPolicySet := refundPolicy >= 0
 
-	a.Require(ownerFeeSet || validatorFeeSet, "root.setDefaultFee: wrong parameters")
+	a.Require(ownerFeeSet || validatorFeeSet || refundPolicySet, "root.setDefaultFee: wrong parameters")
+	a.Require(refundPolicy <= int64(FeeRefundPartial), "root.setDefaultFee: unknown fee refund policy")
 
-	if ownerFeeSet {
-		if ownerFee > 0 {
-			ctx.State().Set(VarDefaultOwnerFee, codec.EncodeInt64(ownerFee))
-		} else {
-			ctx.State().Del(VarDefaultOwnerFee)
-		}
-	}
-	if validatorFeeSet {
-		if validatorFee > 0 {
-			ctx.State().Set(VarDefaultValidatorFee, codec.EncodeInt64(validatorFee))
+	setDefaultFees(ctx.State(), ownerFee, validatorFee)
+	if refundPolicySet {
+		if refundPolicy > 0 {
+			ctx.State().Set(VarFeeRefundPolicy, codec.EncodeInt64(refundPolicy))
 		} else {
-			ctx.State().Del(VarDefaultValidatorFee)
+			ctx.State().Del(VarFeeRefundPolicy)
 		}
 	}
 	return nil, nil
@@ -310,6 +494,152 @@ func setContractFee(ctx coretypes.Sandbox) (dict.Dict, error) {
 	return nil, nil
 }
 
+// setFeePolicy sets the fee policy of the chain, of the smart contract or of its entry point.
+// Value 0 means the value of the entry point is taken from the contract and the value of
+// the contract is taken from the chain defaults
+// Input:
+// - ParamHname coretypes.Hname smart contract ID. May be skipped, then the chain default policy is set
+// - ParamEntryPoint coretypes.Hname entry point of the smart contract. May be skipped, then the contract policy is set
+// - ParamOwnerFee int64 non-negative value of the owner fee. May be skipped, then it is not set
+// - ParamValidatorFee int64 non-negative value of the validator fee. May be skipped, then it is not set
+// - ParamFeePerByte int64 non-negative fee per byte of the request arguments. May be skipped, then it is not set
+// - ParamFeePerKGas int64 non-negative fee per started 1000 units of gas. May be skipped, then it is not set
+func setFeePolicy(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "root.setFeePolicy: not authorized")
+
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	hname := params.MustGetHname(ParamHname, 0)
+	entryPoint := params.MustGetHname(ParamEntryPoint, 0)
+	a.Require(hname != 0 || entryPoint == 0, "root.setFeePolicy: entry point of unknown contract")
+
+	ownerFee := params.MustGetInt64(ParamOwnerFee, -1)
+	validatorFee := params.MustGetInt64(ParamValidatorFee, -1)
+	feePerByte := params.MustGetInt64(ParamFeePerByte, -1)
+	feePerKGas := params.MustGetInt64(ParamFeePerKGas, -1)
+	a.Require(ownerFee >= 0 || validatorFee >= 0 || feePerByte >= 0 || feePerKGas >= 0,
+		"root.setFeePolicy: wrong parameters")
+
+	policy := getStoredFeePolicy(ctx.State(), hname, entryPoint)
+	if feePerByte >= 0 {
+		policy.FeePerByte = feePerByte
+	}
+	if feePerKGas >= 0 {
+		policy.FeePerKiloGas = feePerKGas
+	}
+	switch {
+	case hname == 0:
+		setDefaultFees(ctx.State(), ownerFee, validatorFee)
+	case entryPoint == 0:
+		rec, err := FindContract(ctx.State(), hname)
+		if err != nil {
+			return nil, err
+		}
+		if ownerFee >= 0 {
+			rec.OwnerFee = ownerFee
+		}
+		if validatorFee >= 0 {
+			rec.ValidatorFee = validatorFee
+		}
+		collections.NewMap(ctx.State(), VarContractRegistry).MustSetAt(hname.Bytes(), EncodeContractRecord(rec))
+	default:
+		if _, err := FindContract(ctx.State(), hname); err != nil {
+			return nil, err
+		}
+		if ownerFee >= 0 {
+			policy.OwnerFee = ownerFee
+		}
+		if validatorFee >= 0 {
+			policy.ValidatorFee = validatorFee
+		}
+	}
+	setStoredFeePolicy(ctx.State(), hname, entryPoint, policy)
+	return nil, nil
+}
+
+// addFeeExempt exempts requests of the agent from fees
+// Input:
+//  - ParamAgentID coretypes.AgentID
+func addFeeExempt(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "root.addFeeExempt: not authorized")
+
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	agentID := params.MustGetAgentID(ParamAgentID)
+
+	collections.NewMap(ctx.State(), VarFeeExempt).MustSetAt(agentID[:], []byte{0xFF})
+	ctx.Event(fmt.Sprintf("[add fee exempt] agentID: %s", agentID))
+	return nil, nil
+}
+
+// removeFeeExempt removes the agent from the list of agents exempt from fees
+// Input:
+//  - ParamAgentID coretypes.AgentID
+func removeFeeExempt(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "root.removeFeeExempt: not authorized")
+
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	agentID := params.MustGetAgentID(ParamAgentID)
+
+	collections.NewMap(ctx.State(), VarFeeExempt).MustDelAt(agentID[:])
+	ctx.Event(fmt.Sprintf("[remove fee exempt] agentID: %s", agentID))
+	return nil, nil
+}
+
+// getFeeExempt view returns agents exempt from fees
+// Output:
+// - key is the agent ID for each agent exempt from fees
+func getFeeExempt(ctx coretypes.SandboxView) (dict.Dict, error) {
+	ret := dict.New()
+	collections.NewMapReadOnly(ctx.State(), VarFeeExempt).MustIterateKeys(func(elemKey []byte) bool {
+		ret.Set(kv.Key(elemKey), []byte{0xFF})
+		return true
+	})
+	return ret, nil
+}
+
+// estimateFee view returns the maximum fee the request to the entry point will be charged
+// Input:
+// - ParamHname coretypes.Hname contract id
+// - ParamEntryPoint coretypes.Hname entry point. May be skipped
+// - ParamPayloadSize int64 size of the encoded request arguments in bytes. May be skipped, then 0
+// - ParamGasBudget int64 gas budget of the request. May be skipped, then the default gas budget
+// - ParamAgentID coretypes.AgentID sender of the request. May be skipped
+// Output:
+// - ParamFeeColor balance.Color color of tokens accepted for fees
+// - ParamOwnerFee, ParamValidatorFee, ParamFeePerByte, ParamFeePerKGas int64 the fee policy in effect
+// - ParamFeeTotal int64 the total fee. The unused part of the gas fee is refunded after the request is run
+// Note: the fee of the request of the agent exempt from fees is 0
+func estimateFee(ctx coretypes.SandboxView) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params())
+	hname, err := params.GetHname(ParamHname)
+	if err != nil {
+		return nil, err
+	}
+	entryPoint := params.MustGetHname(ParamEntryPoint, 0)
+	payloadSize := params.MustGetInt64(ParamPayloadSize, 0)
+	gasBudget := coretypes.EffectiveGasBudget(params.MustGetInt64(ParamGasBudget, 0))
+
+	rec, err := FindContract(ctx.State(), hname)
+	if err != nil {
+		return nil, err
+	}
+	policy := GetFeePolicy(ctx.State(), rec, entryPoint)
+	total := policy.TotalFee(int(payloadSize), gasBudget)
+	if agentID, err := params.GetAgentID(ParamAgentID); err == nil && IsFeeExempt(ctx.State(), agentID) {
+		total = 0
+	}
+	ret := dict.New()
+	ret.Set(ParamFeeColor, codec.EncodeColor(policy.FeeColor))
+	ret.Set(ParamOwnerFee, codec.EncodeInt64(policy.OwnerFee))
+	ret.Set(ParamValidatorFee, codec.EncodeInt64(policy.ValidatorFee))
+	ret.Set(ParamFeePerByte, codec.EncodeInt64(policy.FeePerByte))
+	ret.Set(ParamFeePerKGas, codec.EncodeInt64(policy.FeePerKiloGas))
+	ret.Set(ParamFeeTotal, codec.EncodeInt64(total))
+	return ret, nil
+}
+
 // grantDeployPermission grants permission to deploy contracts
 // Input:
 //  - ParamDeployer coretypes.AgentID
@@ -339,3 +669,161 @@ func revokeDeployPermission(ctx coretypes.Sandbox) (dict.Dict, error) {
 	ctx.Event(fmt.Sprintf("[revoke deploy permission] from agentID: %s", deployer))
 	return nil, nil
 }
+
+// defineRole defines the role in the access control list of the contract.
+// Only the chain owner or the creator of the contract can change its access control list
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// - ParamRole string name of the role
+func defineRole(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	contract := params.MustGetHname(ParamHname)
+	role := params.MustGetString(ParamRole)
+	mustCheckACLManagement(ctx, a, contract)
+	a.Require(role != "", "root.defineRole: role name is empty")
+
+	ctx.State().Set(aclRoleKey(contract, role), codec.EncodeString(role))
+	ctx.Event(fmt.Sprintf("[define role] contract: %s, role: '%s'", contract, role))
+	return nil, nil
+}
+
+// removeRole removes the role from the access control list of the contract together with all its
+// members and guards of entry points by the role
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// - ParamRole string name of the role
+func removeRole(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	contract := params.MustGetHname(ParamHname)
+	role := params.MustGetString(ParamRole)
+	mustCheckACLManagement(ctx, a, contract)
+	mustBeDefinedRole(ctx, a, contract, role)
+
+	state := ctx.State()
+	state.Del(aclRoleKey(contract, role))
+	state.DelPrefix(aclMembersPrefix(contract, role))
+	guarded := make([]kv.Key, 0)
+	state.MustIterate(aclGuardsPrefix(contract), func(key kv.Key, value []byte) bool {
+		if string(value) == role {
+			guarded = append(guarded, key)
+		}
+		return true
+	})
+	for _, key := range guarded {
+		state.Del(key)
+	}
+	ctx.Event(fmt.Sprintf("[remove role] contract: %s, role: '%s'", contract, role))
+	return nil, nil
+}
+
+// assignRole assigns the defined role of the contract to the agent
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// - ParamRole string name of the role
+// - ParamAgentID coretypes.AgentID
+func assignRole(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	contract := params.MustGetHname(ParamHname)
+	role := params.MustGetString(ParamRole)
+	agentID := params.MustGetAgentID(ParamAgentID)
+	mustCheckACLManagement(ctx, a, contract)
+	mustBeDefinedRole(ctx, a, contract, role)
+
+	ctx.State().Set(aclMemberKey(contract, role, agentID), []byte{0xFF})
+	ctx.Event(fmt.Sprintf("[assign role] contract: %s, role: '%s', agentID: %s", contract, role, agentID))
+	return nil, nil
+}
+
+// revokeRole revokes the role of the contract from the agent
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// - ParamRole string name of the role
+// - ParamAgentID coretypes.AgentID
+func revokeRole(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	contract := params.MustGetHname(ParamHname)
+	role := params.MustGetString(ParamRole)
+	agentID := params.MustGetAgentID(ParamAgentID)
+	mustCheckACLManagement(ctx, a, contract)
+	mustBeDefinedRole(ctx, a, contract, role)
+
+	ctx.State().Del(aclMemberKey(contract, role, agentID))
+	ctx.Event(fmt.Sprintf("[revoke role] contract: %s, role: '%s', agentID: %s", contract, role, agentID))
+	return nil, nil
+}
+
+// setGuard guards the entry point of the contract by the role: only agents with the role can call it.
+// If the role is not specified, the guard is removed
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// - ParamEntryPoint coretypes.Hname of the entry point
+// - ParamRole string name of the role. Optional
+func setGuard(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	contract := params.MustGetHname(ParamHname)
+	entryPoint := params.MustGetHname(ParamEntryPoint)
+	role := params.MustGetString(ParamRole, "")
+	mustCheckACLManagement(ctx, a, contract)
+
+	if role == "" {
+		ctx.State().Del(aclGuardKey(contract, entryPoint))
+		ctx.Event(fmt.Sprintf("[remove guard] contract: %s, entry point: %s", contract, entryPoint))
+		return nil, nil
+	}
+	mustBeDefinedRole(ctx, a, contract, role)
+	ctx.State().Set(aclGuardKey(contract, entryPoint), codec.EncodeString(role))
+	ctx.Event(fmt.Sprintf("[set guard] contract: %s, entry point: %s, role: '%s'", contract, entryPoint, role))
+	return nil, nil
+}
+
+// getRoles view returns roles defined in the access control list of the contract
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// Output:
+// - for each role: key is the name of the role, value is the concatenation of agent IDs assigned the role
+func getRoles(ctx coretypes.SandboxView) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params())
+	contract, err := params.GetHname(ParamHname)
+	if err != nil {
+		return nil, err
+	}
+	state := ctx.State()
+	ret := dict.New()
+	state.MustIterate(aclRolesPrefix(contract), func(_ kv.Key, value []byte) bool {
+		role := string(value)
+		members := make([]byte, 0)
+		prefix := aclMembersPrefix(contract, role)
+		state.MustIterateKeys(prefix, func(key kv.Key) bool {
+			members = append(members, []byte(key)[len(prefix):]...)
+			return true
+		})
+		ret.Set(kv.Key(role), members)
+		return true
+	})
+	return ret, nil
+}
+
+// getGuards view returns guarded entry points of the contract
+// Input:
+// - ParamHname coretypes.Hname of the contract
+// Output:
+// - for each guarded entry point: key is the hname of the entry point, value is the name of the role
+func getGuards(ctx coretypes.SandboxView) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params())
+	contract, err := params.GetHname(ParamHname)
+	if err != nil {
+		return nil, err
+	}
+	ret := dict.New()
+	prefix := aclGuardsPrefix(contract)
+	ctx.State().MustIterate(prefix, func(key kv.Key, value []byte) bool {
+		ret.Set(key[len(prefix):], value)
+		return true
+	})
+	return ret, nil
+}
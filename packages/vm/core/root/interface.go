@@ -3,6 +3,7 @@ package root
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/wasp/packages/coretypes"
@@ -25,20 +26,40 @@ var (
 		ProgramHash: hashing.HashStrings(Name),
 	}
 	ErrContractNotFound = errors.New("smart contract not found")
+	ErrContractPaused   = errors.New("smart contract is paused")
+	ErrContractRemoved  = errors.New("smart contract is removed")
 )
 
 func init() {
 	Interface.WithFunctions(initialize, []coreutil.ContractFunctionInterface{
 		coreutil.Func(FuncDeployContract, deployContract),
+		coreutil.Func(FuncUpgradeContract, upgradeContract),
+		coreutil.Func(FuncPauseContract, pauseContract),
+		coreutil.Func(FuncResumeContract, resumeContract),
+		coreutil.Func(FuncRemoveContract, removeContract),
 		coreutil.ViewFunc(FuncFindContract, findContract),
 		coreutil.Func(FuncClaimChainOwnership, claimChainOwnership),
 		coreutil.Func(FuncDelegateChainOwnership, delegateChainOwnership),
+		coreutil.Func(FuncRotateCommittee, rotateCommittee),
+		coreutil.Func(FuncConfirmRotation, confirmRotation),
 		coreutil.ViewFunc(FuncGetChainInfo, getChainInfo),
 		coreutil.ViewFunc(FuncGetFeeInfo, getFeeInfo),
 		coreutil.Func(FuncSetDefaultFee, setDefaultFee),
 		coreutil.Func(FuncSetContractFee, setContractFee),
+		coreutil.Func(FuncSetFeePolicy, setFeePolicy),
+		coreutil.Func(FuncAddFeeExempt, addFeeExempt),
+		coreutil.Func(FuncRemoveFeeExempt, removeFeeExempt),
+		coreutil.ViewFunc(FuncGetFeeExempt, getFeeExempt),
+		coreutil.ViewFunc(FuncEstimateFee, estimateFee),
 		coreutil.Func(FuncGrantDeploy, grantDeployPermission),
 		coreutil.Func(FuncRevokeDeploy, revokeDeployPermission),
+		coreutil.Func(FuncDefineRole, defineRole),
+		coreutil.Func(FuncRemoveRole, removeRole),
+		coreutil.Func(FuncAssignRole, assignRole),
+		coreutil.Func(FuncRevokeRole, revokeRole),
+		coreutil.Func(FuncSetGuard, setGuard),
+		coreutil.ViewFunc(FuncGetRoles, getRoles),
+		coreutil.ViewFunc(FuncGetGuards, getGuards),
 	})
 }
 
@@ -48,14 +69,23 @@ const (
 	VarChainID               = "c"
 	VarChainColor            = "co"
 	VarChainAddress          = "ad"
+	VarChainAddressNext      = "an"
 	VarChainOwnerID          = "o"
 	VarFeeColor              = "f"
 	VarDefaultOwnerFee       = "do"
 	VarDefaultValidatorFee   = "dv"
+	VarFeePolicies           = "fp"
+	VarFeeExempt             = "fx"
+	VarFeeRefundPolicy       = "fr"
 	VarChainOwnerIDDelegated = "n"
 	VarContractRegistry      = "r"
 	VarDescription           = "d"
+	VarConsensus             = "cs"
 	VarDeployPermissions     = "dep"
+	// access control lists of contracts
+	VarACLRoles   = "al"
+	VarACLMembers = "am"
+	VarACLGuards  = "ag"
 )
 
 // param variables
@@ -72,21 +102,51 @@ const (
 	ParamFeeColor     = "$$feecolor$$"
 	ParamOwnerFee     = "$$ownerfee$$"
 	ParamValidatorFee = "$$validatorfee$$"
+	ParamFeePerByte   = "$$feeperbyte$$"
+	ParamFeePerKGas   = "$$feeperkgas$$"
+	ParamFeeRefund    = "$$feerefund$$"
+	ParamFeeTotal     = "$$feetotal$$"
+	ParamPayloadSize  = "$$payloadsize$$"
+	ParamGasBudget    = "$$gasbudget$$"
 	ParamDeployer     = "$$deployer$$"
+	ParamRole         = "$$role$$"
+	ParamAgentID      = "$$agentid$$"
+	ParamEntryPoint   = "$$entrypoint$$"
+	ParamPublicKey    = "$$pubkey$$"
+	ParamSignature    = "$$signature$$"
+	ParamConsensus    = "$$consensus$$"
 )
 
 // function names
 const (
 	FuncDeployContract         = "deployContract"
+	FuncUpgradeContract        = "upgradeContract"
+	FuncPauseContract          = "pauseContract"
+	FuncResumeContract         = "resumeContract"
+	FuncRemoveContract         = "removeContract"
 	FuncFindContract           = "findContract"
 	FuncGetChainInfo           = "getChainInfo"
 	FuncDelegateChainOwnership = "delegateChainOwnership"
 	FuncClaimChainOwnership    = "claimChainOwnership"
+	FuncRotateCommittee        = "rotateCommittee"
+	FuncConfirmRotation        = "confirmRotation"
 	FuncGetFeeInfo             = "getFeeInfo"
 	FuncSetDefaultFee          = "setDefaultFee"
 	FuncSetContractFee         = "setContractFee"
+	FuncSetFeePolicy           = "setFeePolicy"
+	FuncAddFeeExempt           = "addFeeExempt"
+	FuncRemoveFeeExempt        = "removeFeeExempt"
+	FuncGetFeeExempt           = "getFeeExempt"
+	FuncEstimateFee            = "estimateFee"
 	FuncGrantDeploy            = "grantDeployPermission"
 	FuncRevokeDeploy           = "revokeDeployPermission"
+	FuncDefineRole             = "defineRole"
+	FuncRemoveRole             = "removeRole"
+	FuncAssignRole             = "assignRole"
+	FuncRevokeRole             = "revokeRole"
+	FuncSetGuard               = "setGuard"
+	FuncGetRoles               = "getRoles"
+	FuncGetGuards              = "getGuards"
 )
 
 // ContractRecord is a structure which contains metadata of the deployed contract instance
@@ -110,6 +170,32 @@ type ContractRecord struct {
 	// The agentID of the entity which deployed the instance. It can be interpreted as
 	// an priviledged user of the instance, however it is up to the smart contract.
 	Creator coretypes.AgentID
+	// Status of the contract: active, paused or removed by the chain owner
+	Status ContractStatus
+}
+
+// ContractStatus is the status of the deployed contract
+type ContractStatus byte
+
+const (
+	// ContractStatusActive the contract processes requests and calls
+	ContractStatusActive = ContractStatus(iota)
+	// ContractStatusPaused requests and calls to the contract are rejected and refunded. Views can be called
+	ContractStatusPaused
+	// ContractStatusRemoved the state and the account of the contract are deleted. The record remains in the registry
+	ContractStatusRemoved
+)
+
+func (s ContractStatus) String() string {
+	switch s {
+	case ContractStatusActive:
+		return "active"
+	case ContractStatusPaused:
+		return "paused"
+	case ContractStatusRemoved:
+		return "removed"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(s))
 }
 
 // ChainInfo is an API structure which contains main properties of the chain in on place
@@ -122,6 +208,7 @@ type ChainInfo struct {
 	FeeColor            balance.Color
 	DefaultOwnerFee     int64
 	DefaultValidatorFee int64
+	Consensus           string
 }
 
 func (p *ContractRecord) Hname() coretypes.Hname {
@@ -148,6 +235,9 @@ func (p *ContractRecord) Write(w io.Writer) error {
 	if _, err := w.Write(p.Creator[:]); err != nil {
 		return err
 	}
+	if err := util.WriteByte(w, byte(p.Status)); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -171,6 +261,15 @@ func (p *ContractRecord) Read(r io.Reader) error {
 	if err := coretypes.ReadAgentID(r, &p.Creator); err != nil {
 		return err
 	}
+	status, err := util.ReadByte(r)
+	if err == io.EOF {
+		// the record was stored before the status was introduced
+		status, err = byte(ContractStatusActive), nil
+	}
+	if err != nil {
+		return err
+	}
+	p.Status = ContractStatus(status)
 	return nil
 }
 
@@ -194,6 +293,17 @@ func NewContractRecord(itf *coreutil.ContractInterface, creator coretypes.AgentI
 	return
 }
 
+// CheckActive returns error if the contract is paused or removed
+func (p *ContractRecord) CheckActive() error {
+	switch p.Status {
+	case ContractStatusActive:
+		return nil
+	case ContractStatusPaused:
+		return fmt.Errorf("%w: '%s'", ErrContractPaused, p.Name)
+	}
+	return fmt.Errorf("%w: '%s'", ErrContractRemoved, p.Name)
+}
+
 func (p *ContractRecord) HasCreator() bool {
 	return p.Creator != coretypes.AgentID{}
 }
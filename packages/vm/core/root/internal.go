@@ -2,13 +2,22 @@ package root
 
 import (
 	"fmt"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/wasp/packages/coretypes"
+	assert2 "github.com/iotaledger/wasp/packages/coretypes/assert"
+	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/kv/codec"
 	"github.com/iotaledger/wasp/packages/kv/collections"
 	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
+	"github.com/iotaledger/wasp/packages/vm/core/eventlog"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
 )
 
 // FindContract is an internal utility function which finds a contract in the KVStore
@@ -45,10 +54,22 @@ func MustGetChainInfo(state kv.KVStoreReader) ChainInfo {
 		FeeColor:            d.MustGetColor(VarFeeColor, balance.ColorIOTA),
 		DefaultOwnerFee:     d.MustGetInt64(VarDefaultOwnerFee, 0),
 		DefaultValidatorFee: d.MustGetInt64(VarDefaultValidatorFee, 0),
+		Consensus:           d.MustGetString(VarConsensus, ""),
 	}
 	return ret
 }
 
+// GetConsensus returns the consensus algorithm stored in the state when the chain was initialized.
+// The second value is false if the chain is not initialized yet
+// It is called by the node to check if its chain record agrees with the chain
+func GetConsensus(state kv.KVStoreReader) (string, bool) {
+	if state.MustGet(VarStateInitialized) == nil {
+		return "", false
+	}
+	d := kvdecoder.New(state)
+	return d.MustGetString(VarConsensus, ""), true
+}
+
 // GetFeeInfo is an internal utility function which returns fee info for the contract
 // It is called from within the 'root' contract as well as VMContext and viewcontext objects
 // It is not exposed to the sandbox
@@ -103,6 +124,20 @@ func GetDefaultFeeInfo(state kv.KVStoreReader) (balance.Color, int64, int64, err
 	return feeColor, defaultOwnerFee, defaultValidatorFee, nil
 }
 
+// setDefaultFees sets the chain default fees. Negative value means the fee is not changed
+func setDefaultFees(state kv.KVStore, ownerFee, validatorFee int64) {
+	if ownerFee > 0 {
+		state.Set(VarDefaultOwnerFee, codec.EncodeInt64(ownerFee))
+	} else if ownerFee == 0 {
+		state.Del(VarDefaultOwnerFee)
+	}
+	if validatorFee > 0 {
+		state.Set(VarDefaultValidatorFee, codec.EncodeInt64(validatorFee))
+	} else if validatorFee == 0 {
+		state.Del(VarDefaultValidatorFee)
+	}
+}
+
 // DecodeContractRegistry encodes the whole contract registry from the map into a Go map.
 func DecodeContractRegistry(contractRegistry *collections.ImmutableMap) (map[coretypes.Hname]*ContractRecord, error) {
 	ret := make(map[coretypes.Hname]*ContractRecord)
@@ -126,7 +161,32 @@ func DecodeContractRegistry(contractRegistry *collections.ImmutableMap) (map[cor
 	return ret, err
 }
 
-func CheckAuthorizationByChainOwner(state kv.KVStore, agentID coretypes.AgentID) bool {
+// ReplacedPrograms compares contract registries in two states of the 'root' contract and returns
+// program hashes of the contracts which were upgraded or removed and are not used by any other contract anymore
+func ReplacedPrograms(prevState, nextState kv.KVStoreReader) ([]hashing.HashValue, error) {
+	prevRegistry, err := DecodeContractRegistry(collections.NewMapReadOnly(prevState, VarContractRegistry))
+	if err != nil {
+		return nil, err
+	}
+	nextRegistry, err := DecodeContractRegistry(collections.NewMapReadOnly(nextState, VarContractRegistry))
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[hashing.HashValue]bool)
+	for _, rec := range nextRegistry {
+		used[rec.ProgramHash] = true
+	}
+	ret := make([]hashing.HashValue, 0)
+	for _, rec := range prevRegistry {
+		if !used[rec.ProgramHash] {
+			used[rec.ProgramHash] = true
+			ret = append(ret, rec.ProgramHash)
+		}
+	}
+	return ret, nil
+}
+
+func CheckAuthorizationByChainOwner(state kv.KVStoreReader, agentID coretypes.AgentID) bool {
 	currentOwner, _, err := codec.DecodeAgentID(state.MustGet(VarChainOwnerID))
 	if err != nil {
 		panic(err)
@@ -134,6 +194,13 @@ func CheckAuthorizationByChainOwner(state kv.KVStore, agentID coretypes.AgentID)
 	return currentOwner == agentID
 }
 
+// RotationProofData returns the data the new committee signs with its shared key to confirm the rotation
+// of the chain to its address
+func RotationProofData(chainID coretypes.ChainID, nextAddress address.Address) []byte {
+	h := hashing.HashData([]byte("wasp committee rotation"), chainID[:], nextAddress[:])
+	return h[:]
+}
+
 // storeAndInitContract internal utility function
 func storeAndInitContract(ctx coretypes.Sandbox, rec *ContractRecord, initParams dict.Dict) error {
 	hname := coretypes.Hn(rec.Name)
@@ -151,6 +218,57 @@ func storeAndInitContract(ctx coretypes.Sandbox, rec *ContractRecord, initParams
 	return err
 }
 
+// storeAndMigrateContract internal utility function. Stores the upgraded record of the contract and calls
+// its 'migrate' entry point. The missing 'migrate' entry point is not an error. Restores the previous record if the call fails
+func storeAndMigrateContract(ctx coretypes.Sandbox, rec *ContractRecord, migrateParams dict.Dict) error {
+	hname := coretypes.Hn(rec.Name)
+	contractRegistry := collections.NewMap(ctx.State(), VarContractRegistry)
+	prevRec := contractRegistry.MustGetAt(hname.Bytes())
+	contractRegistry.MustSetAt(hname.Bytes(), EncodeContractRecord(rec))
+	_, err := ctx.Call(hname, coretypes.EntryPointMigrate, migrateParams, nil)
+	if err == coretypes.ErrEntryPointNotFound {
+		return nil
+	}
+	if err != nil {
+		contractRegistry.MustSetAt(hname.Bytes(), prevRec)
+		err = fmt.Errorf("contract '%s'/%s: calling 'migrate': %v", rec.Name, hname.String(), err)
+	}
+	return err
+}
+
+// mustChangeContractStatus checks authorization of the chain owner and changes status of the contract
+// from one of 'from' statuses to 'to'. Returns the updated record
+func mustChangeContractStatus(ctx coretypes.Sandbox, fname string, to ContractStatus, from ...ContractStatus) *ContractRecord {
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()), "%s: not authorized", fname)
+
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	hname := params.MustGetHname(ParamHname)
+	a.Require(!isCoreContract(hname), "%s: core contract can't be %s", fname, to)
+
+	rec, err := FindContract(ctx.State(), hname)
+	a.Require(err == nil, "%s.fail: %v", fname, err)
+	statusOk := false
+	for _, st := range from {
+		statusOk = statusOk || rec.Status == st
+	}
+	a.Require(statusOk, "%s: contract '%s' is %s", fname, rec.Name, rec.Status)
+
+	rec.Status = to
+	collections.NewMap(ctx.State(), VarContractRegistry).MustSetAt(hname.Bytes(), EncodeContractRecord(rec))
+	return rec
+}
+
+// isCoreContract returns true if the contract is one of core contracts deployed by the root
+func isCoreContract(hname coretypes.Hname) bool {
+	switch hname {
+	case Interface.Hname(), blob.Interface.Hname(), accounts.Interface.Hname(), eventlog.Interface.Hname(),
+		blocklog.Interface.Hname(), receipts.Interface.Hname(), scheduler.Interface.Hname():
+		return true
+	}
+	return false
+}
+
 // isAuthorizedToDeploy checks if caller is authorized to deploy smart contract
 func isAuthorizedToDeploy(ctx coretypes.Sandbox) bool {
 	caller := ctx.Caller()
@@ -165,3 +283,79 @@ func isAuthorizedToDeploy(ctx coretypes.Sandbox) bool {
 
 	return collections.NewMap(ctx.State(), VarDeployPermissions).MustHasAt(caller[:])
 }
+
+// keys of the access control lists. Roles, members and guards of the contract are stored under
+// the prefix of the contract, so they can be iterated and deleted by prefix.
+// Roles are identified by the 32 byte hash of the name: 4 byte hnames of arbitrary role names may collide
+func aclRoleID(role string) []byte {
+	h := hashing.HashStrings(role)
+	return h[:]
+}
+
+func aclRolesPrefix(contract coretypes.Hname) kv.Key {
+	return kv.Key(VarACLRoles) + kv.Key(contract.Bytes())
+}
+
+func aclRoleKey(contract coretypes.Hname, role string) kv.Key {
+	return aclRolesPrefix(contract) + kv.Key(aclRoleID(role))
+}
+
+func aclMembersPrefix(contract coretypes.Hname, role string) kv.Key {
+	return kv.Key(VarACLMembers) + kv.Key(contract.Bytes()) + kv.Key(aclRoleID(role))
+}
+
+func aclMemberKey(contract coretypes.Hname, role string, agentID coretypes.AgentID) kv.Key {
+	return aclMembersPrefix(contract, role) + kv.Key(agentID[:])
+}
+
+func aclGuardsPrefix(contract coretypes.Hname) kv.Key {
+	return kv.Key(VarACLGuards) + kv.Key(contract.Bytes())
+}
+
+func aclGuardKey(contract coretypes.Hname, entryPoint coretypes.Hname) kv.Key {
+	return aclGuardsPrefix(contract) + kv.Key(entryPoint.Bytes())
+}
+
+// HasRole returns true if the agent is assigned the role in the access control list of the contract
+func HasRole(state kv.KVStoreReader, contract coretypes.Hname, role string, agentID coretypes.AgentID) bool {
+	return state.MustHas(aclMemberKey(contract, role, agentID))
+}
+
+// IsAuthorizedByACL checks if the caller is authorized to call the entry point by the access control list of the contract.
+// The caller must be assigned the role which guards the entry point, if any.
+// If the entry point declares required roles, the caller must be assigned at least one of them too.
+// It is called by the VM before the entry point is called. It is not exposed to the sandbox
+func IsAuthorizedByACL(state kv.KVStoreReader, contract, entryPoint coretypes.Hname, declaredRoles []string, caller coretypes.AgentID) bool {
+	guard, ok, err := codec.DecodeString(state.MustGet(aclGuardKey(contract, entryPoint)))
+	if err != nil {
+		panic(err)
+	}
+	if ok && !HasRole(state, contract, guard, caller) {
+		return false
+	}
+	if len(declaredRoles) == 0 {
+		return true
+	}
+	for _, role := range declaredRoles {
+		if HasRole(state, contract, role, caller) {
+			return true
+		}
+	}
+	return false
+}
+
+// mustCheckACLManagement checks if the caller can manage the access control list of the contract:
+// it must be the chain owner or the creator of the contract. The ACL of the 'root' itself can't be managed
+func mustCheckACLManagement(ctx coretypes.Sandbox, a assert2.Assert, contract coretypes.Hname) {
+	a.Require(contract != Interface.Hname(), "root: access control list of the root contract can't be changed")
+	rec, err := FindContract(ctx.State(), contract)
+	a.Require(err == nil, "root: %v", err)
+	a.Require(rec.Status != ContractStatusRemoved, "root: contract '%s' is removed", rec.Name)
+	a.Require(ctx.Caller() == rec.Creator || CheckAuthorizationByChainOwner(ctx.State(), ctx.Caller()),
+		"root: not authorized to change access control list of '%s': %s", rec.Name, ctx.Caller())
+}
+
+func mustBeDefinedRole(ctx coretypes.Sandbox, a assert2.Assert, contract coretypes.Hname, role string) {
+	a.Require(role != "", "root: role name is empty")
+	a.Require(ctx.State().MustHas(aclRoleKey(contract, role)), "root: role '%s' is not defined", role)
+}
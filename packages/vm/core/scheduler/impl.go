@@ -0,0 +1,160 @@
+// 'scheduler' is a core contract on the chain. It keeps one-shot and recurring calls of entry points of contracts
+// on the chain. The VM makes the calls which are due at the beginning of each block, on behalf of the scheduler.
+// Fees of the calls are paid from the funding account of the schedule.
+// Finished schedules are deleted, results of the calls are recorded in the event log of the scheduler.
+// The number of pending schedules of one agent is limited by MaxSchedulesPerOwner.
+// To make sure the block is produced when the call is due, the VM posts the time-locked 'tick' request
+// to the scheduler. The request token of the tick is paid from the account of the scheduler
+// or from the funding account of the schedule
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	assert2 "github.com/iotaledger/wasp/packages/coretypes/assert"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+func initialize(ctx coretypes.Sandbox) (dict.Dict, error) {
+	ctx.Log().Debugf("scheduler.initialize.success hname = %s", Interface.Hname().String())
+	return nil, nil
+}
+
+// schedule registers the call of the entry point of the contract.
+// A contract can schedule calls of its own entry points, paid from its own account.
+// The chain owner can schedule calls of any contract, paid from any account
+// Input:
+// - ParamContract coretypes.Hname the contract to call. Defaults to the calling contract
+// - ParamEntryPoint coretypes.Hname the entry point to call
+// - ParamParams encoded dict.Dict parameters of the call. Optional
+// - ParamStart int64 the time of the first call in Unix seconds. Defaults to the current time plus the interval
+// - ParamInterval int64 the interval between recurring calls in seconds. Defaults to 0, the one-shot call
+// - ParamMaxRuns int64 the maximum number of recurring calls. Defaults to 0, unlimited
+// - ParamFunding coretypes.AgentID the account which pays fees of the calls. Defaults to the caller
+// The caller can't have more than MaxSchedulesPerOwner pending schedules
+// Output:
+// - ParamID int64 the ID of the schedule
+func schedule(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	caller := ctx.Caller()
+	isOwner := caller == ctx.ChainOwnerID()
+	isLocalContract := !caller.IsAddress() && caller.MustContractID().ChainID() == ctx.ContractID().ChainID()
+	a.Require(isOwner || isLocalContract, "scheduler.schedule: only contracts of the chain and the chain owner can schedule calls")
+
+	var defaultContract coretypes.Hname
+	if isLocalContract {
+		defaultContract = caller.MustContractID().Hname()
+	}
+	contract := params.MustGetHname(ParamContract, defaultContract)
+	entryPoint := params.MustGetHname(ParamEntryPoint)
+	funding := params.MustGetAgentID(ParamFunding, caller)
+	if !isOwner {
+		a.Require(contract == defaultContract, "scheduler.schedule: contract can only schedule calls of itself")
+		a.Require(funding == caller, "scheduler.schedule: contract can only fund calls from its own account")
+	}
+	callParams := dict.New()
+	if data := params.MustGetBytes(ParamParams, nil); data != nil {
+		err := callParams.Read(bytes.NewReader(data))
+		a.Require(err == nil, "scheduler.schedule: wrong params: %v", err)
+	}
+	interval := params.MustGetInt64(ParamInterval, 0)
+	a.Require(interval >= 0, "scheduler.schedule: wrong interval")
+	maxRuns := params.MustGetInt64(ParamMaxRuns, 0)
+	a.Require(maxRuns >= 0, "scheduler.schedule: wrong max runs")
+	start := params.MustGetInt64(ParamStart, ctx.GetTimestamp()/int64(time.Second)+interval)
+	a.Require(start > 0, "scheduler.schedule: wrong start")
+	a.Require(OwnerCount(ctx.State(), caller) < MaxSchedulesPerOwner,
+		"scheduler.schedule: too many pending schedules, maximum is %d", MaxSchedulesPerOwner)
+
+	s := &Schedule{
+		ID:         nextID(ctx.State()),
+		Contract:   contract,
+		EntryPoint: entryPoint,
+		Params:     callParams,
+		Owner:      caller,
+		Funding:    funding,
+		NextRun:    start,
+		Interval:   interval,
+		MaxRuns:    uint32(maxRuns),
+	}
+	AddSchedule(ctx.State(), s)
+	ctx.Event(fmt.Sprintf("[schedule] id: %d, call: %s::%s, start: %d, interval: %d, max runs: %d",
+		s.ID, s.Contract, s.EntryPoint, s.NextRun, s.Interval, s.MaxRuns))
+
+	ret := dict.New()
+	ret.Set(ParamID, codec.EncodeInt64(int64(s.ID)))
+	return ret, nil
+}
+
+// cancel removes the schedule. Only the agent which registered the schedule and the chain owner can cancel it
+// Input:
+// - ParamID int64 the ID of the schedule
+func cancel(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	id := uint32(params.MustGetInt64(ParamID))
+	s, err := GetSchedule(ctx.State(), id)
+	a.RequireNoError(err)
+	a.Require(s != nil, "scheduler.cancel: schedule %d does not exist", id)
+	a.Require(ctx.Caller() == s.Owner || ctx.Caller() == ctx.ChainOwnerID(), "scheduler.cancel: not authorized")
+
+	DeleteSchedule(ctx.State(), s)
+	ctx.Event(fmt.Sprintf("[cancel] id: %d", id))
+	return nil, nil
+}
+
+// tick is the target of the time-locked request posted by the VM. The due calls are made
+// by the VM at the beginning of the block, so the entry point only marks the tick as consumed.
+// The VM posts the next tick after the request
+func tick(ctx coretypes.Sandbox) (dict.Dict, error) {
+	a := assert2.NewAssert(ctx.Log())
+	a.Require(ctx.Caller() == coretypes.NewAgentIDFromContractID(ctx.ContractID()), "scheduler.tick: not authorized")
+	if GetTick(ctx.State()) <= ctx.GetTimestamp()/int64(time.Second) {
+		SetTick(ctx.State(), 0)
+	}
+	ctx.Log().Debugf("scheduler.tick")
+	return nil, nil
+}
+
+// getSchedule returns the pending schedule
+// Input:
+// - ParamID int64 the ID of the schedule
+// Output:
+// - ParamSchedule encoded Schedule or empty result if the schedule does not exist or is finished
+func getSchedule(ctx coretypes.SandboxView) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	id, err := params.GetInt64(ParamID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := GetSchedule(ctx.State(), uint32(id))
+	if err != nil || s == nil {
+		return nil, err
+	}
+	ret := dict.New()
+	ret.Set(ParamSchedule, EncodeSchedule(s))
+	return ret, nil
+}
+
+// getSchedules returns pending schedules
+// Output:
+// - map of ID (4 bytes) -> encoded Schedule
+func getSchedules(ctx coretypes.SandboxView) (dict.Dict, error) {
+	schedules, err := GetSchedules(ctx.State())
+	if err != nil {
+		return nil, err
+	}
+	ret := dict.New()
+	for _, s := range schedules {
+		ret.Set(kv.Key(util.Uint32To4Bytes(s.ID)), EncodeSchedule(s))
+	}
+	return ret, nil
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"bytes"
+	"io"
+
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	Name        = "scheduler"
+	description = "Scheduler Contract"
+)
+
+var (
+	Interface = &coreutil.ContractInterface{
+		Name:        Name,
+		Description: description,
+		ProgramHash: hashing.HashStrings(Name),
+	}
+)
+
+func init() {
+	Interface.WithFunctions(initialize, []coreutil.ContractFunctionInterface{
+		coreutil.Func(FuncSchedule, schedule),
+		coreutil.Func(FuncCancel, cancel),
+		coreutil.Func(FuncTick, tick),
+		coreutil.ViewFunc(FuncGetSchedule, getSchedule),
+		coreutil.ViewFunc(FuncGetSchedules, getSchedules),
+	})
+}
+
+const (
+	// request parameters
+	ParamID         = "id"
+	ParamContract   = "contract"
+	ParamEntryPoint = "entryPoint"
+	ParamParams     = "params"
+	ParamStart      = "start"
+	ParamInterval   = "interval"
+	ParamMaxRuns    = "maxRuns"
+	ParamFunding    = "funding"
+	ParamSchedule   = "schedule"
+
+	// function names
+	FuncSchedule     = "schedule"
+	FuncCancel       = "cancel"
+	FuncTick         = "tick"
+	FuncGetSchedule  = "getSchedule"
+	FuncGetSchedules = "getSchedules"
+)
+
+// Schedule is the record of the scheduled call of the entry point of the contract on the same chain
+type Schedule struct {
+	ID uint32
+	// contract and entry point called by the scheduler
+	Contract   coretypes.Hname
+	EntryPoint coretypes.Hname
+	Params     dict.Dict
+	// agent which registered the schedule
+	Owner coretypes.AgentID
+	// on-chain account the fees of the calls are paid from
+	Funding coretypes.AgentID
+	// time of the next call in Unix seconds. 0 means the schedule is finished and it is deleted
+	NextRun int64
+	// interval between recurring calls in seconds. 0 means one-shot call
+	Interval int64
+	// maximum number of calls. 0 means unlimited
+	MaxRuns uint32
+	// number of calls made so far
+	Runs uint32
+	// number of calls in a row which couldn't be made
+	Missed uint32
+	// error returned by the last call or empty string if the last call was successful
+	LastError string
+}
+
+// CallRequestID returns the ID of the call of the schedule which is due at s.NextRun.
+// The call is an entry of the block same as the request, so its receipt and its record in the block log
+// are stored under this ID. The ID is unique, because the time of the next call changes after each call
+func CallRequestID(chainID *coretypes.ChainID, s *Schedule) coretypes.RequestID {
+	txid := valuetransaction.ID(hashing.HashData([]byte(Name), chainID[:], util.Uint32To4Bytes(s.ID), util.Uint64To8Bytes(uint64(s.NextRun))))
+	return coretypes.NewRequestID(txid, 0)
+}
+
+// IsFinished returns true if no more calls are scheduled
+func (s *Schedule) IsFinished() bool {
+	return s.NextRun == 0
+}
+
+func (s *Schedule) Write(w io.Writer) error {
+	if err := util.WriteUint32(w, s.ID); err != nil {
+		return err
+	}
+	if err := s.Contract.Write(w); err != nil {
+		return err
+	}
+	if err := s.EntryPoint.Write(w); err != nil {
+		return err
+	}
+	if err := s.Params.Write(w); err != nil {
+		return err
+	}
+	if _, err := w.Write(s.Owner[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(s.Funding[:]); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, s.NextRun); err != nil {
+		return err
+	}
+	if err := util.WriteInt64(w, s.Interval); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, s.MaxRuns); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, s.Runs); err != nil {
+		return err
+	}
+	if err := util.WriteUint32(w, s.Missed); err != nil {
+		return err
+	}
+	return util.WriteString16(w, s.LastError)
+}
+
+func (s *Schedule) Read(r io.Reader) error {
+	if err := util.ReadUint32(r, &s.ID); err != nil {
+		return err
+	}
+	if err := s.Contract.Read(r); err != nil {
+		return err
+	}
+	if err := s.EntryPoint.Read(r); err != nil {
+		return err
+	}
+	s.Params = dict.New()
+	if err := s.Params.Read(r); err != nil {
+		return err
+	}
+	if err := coretypes.ReadAgentID(r, &s.Owner); err != nil {
+		return err
+	}
+	if err := coretypes.ReadAgentID(r, &s.Funding); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &s.NextRun); err != nil {
+		return err
+	}
+	if err := util.ReadInt64(r, &s.Interval); err != nil {
+		return err
+	}
+	if err := util.ReadUint32(r, &s.MaxRuns); err != nil {
+		return err
+	}
+	if err := util.ReadUint32(r, &s.Runs); err != nil {
+		return err
+	}
+	if err := util.ReadUint32(r, &s.Missed); err != nil {
+		return err
+	}
+	var err error
+	s.LastError, err = util.ReadString16(r)
+	return err
+}
+
+func EncodeSchedule(s *Schedule) []byte {
+	return util.MustBytes(s)
+}
+
+func DecodeSchedule(data []byte) (*Schedule, error) {
+	ret := new(Schedule)
+	if err := ret.Read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,235 @@
+package scheduler
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/collections"
+	"github.com/iotaledger/wasp/packages/util"
+)
+
+const (
+	// map of pending schedules: ID -> Schedule. Finished schedules are deleted
+	varSchedules = "s"
+	// index of pending schedules ordered by the time of the next call:
+	// NextRun (8 bytes, big endian) | ID (4 bytes, big endian) -> 0xFF
+	varQueue = "q"
+	// time of the earliest next call in Unix seconds, 0 if there are no pending schedules
+	varNextRun = "n"
+	// map of the number of pending schedules by the owner: AgentID -> int64
+	varOwnerCount = "o"
+	// ID of the last registered schedule
+	varLastID = "i"
+	// time lock of the pending tick request in Unix seconds
+	varTick = "t"
+)
+
+const (
+	// MaxSchedulesPerOwner is the maximum number of pending schedules registered by one agent
+	MaxSchedulesPerOwner = 100
+	// MaxMissedCalls is the number of calls in a row which can't be made, e.g. because their fees can't be paid.
+	// The schedule is finished then
+	MaxMissedCalls = 10
+	// RetryInterval is the delay in seconds of the one-shot call which couldn't be made
+	RetryInterval = 60
+)
+
+func queueKey(nextRun int64, id uint32) kv.Key {
+	var buf [12]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(nextRun))
+	binary.BigEndian.PutUint32(buf[8:], id)
+	return kv.Key(varQueue) + kv.Key(buf[:])
+}
+
+func queueKeyID(key kv.Key) uint32 {
+	return binary.BigEndian.Uint32([]byte(key[len(key)-4:]))
+}
+
+// AddSchedule stores the new schedule
+func AddSchedule(state kv.KVStore, s *Schedule) {
+	collections.NewMap(state, varSchedules).MustSetAt(util.Uint32To4Bytes(s.ID), EncodeSchedule(s))
+	state.Set(queueKey(s.NextRun, s.ID), []byte{0xFF})
+	if next := NextRunTime(state); next == 0 || s.NextRun < next {
+		state.Set(varNextRun, codec.EncodeInt64(s.NextRun))
+	}
+	setOwnerCount(state, s.Owner, OwnerCount(state, s.Owner)+1)
+}
+
+// SaveSchedule stores the schedule after its call was due at the time 'prevRun'.
+// The finished schedule is deleted
+func SaveSchedule(state kv.KVStore, s *Schedule, prevRun int64) {
+	if s.IsFinished() {
+		deleteSchedule(state, s, prevRun)
+		return
+	}
+	collections.NewMap(state, varSchedules).MustSetAt(util.Uint32To4Bytes(s.ID), EncodeSchedule(s))
+	if s.NextRun == prevRun {
+		return
+	}
+	state.Del(queueKey(prevRun, s.ID))
+	state.Set(queueKey(s.NextRun, s.ID), []byte{0xFF})
+	updateNextRun(state)
+}
+
+// DeleteSchedule removes the schedule
+func DeleteSchedule(state kv.KVStore, s *Schedule) {
+	deleteSchedule(state, s, s.NextRun)
+}
+
+func deleteSchedule(state kv.KVStore, s *Schedule, prevRun int64) {
+	collections.NewMap(state, varSchedules).MustDelAt(util.Uint32To4Bytes(s.ID))
+	state.Del(queueKey(prevRun, s.ID))
+	updateNextRun(state)
+	setOwnerCount(state, s.Owner, OwnerCount(state, s.Owner)-1)
+}
+
+// updateNextRun stores the time of the earliest call from the index
+func updateNextRun(state kv.KVStore) {
+	next := int64(0)
+	state.MustIterateRange(kv.Key(varQueue), kv.PrefixEnd(kv.Key(varQueue)), func(key kv.Key, _ []byte) bool {
+		next = int64(binary.BigEndian.Uint64([]byte(key[len(varQueue) : len(varQueue)+8])))
+		return false
+	})
+	if next == 0 {
+		state.Del(varNextRun)
+		return
+	}
+	state.Set(varNextRun, codec.EncodeInt64(next))
+}
+
+// OwnerCount returns the number of pending schedules registered by the agent
+func OwnerCount(state kv.KVStoreReader, owner coretypes.AgentID) int64 {
+	ret, _, _ := codec.DecodeInt64(collections.NewMapReadOnly(state, varOwnerCount).MustGetAt(owner[:]))
+	return ret
+}
+
+func setOwnerCount(state kv.KVStore, owner coretypes.AgentID, count int64) {
+	m := collections.NewMap(state, varOwnerCount)
+	if count <= 0 {
+		m.MustDelAt(owner[:])
+		return
+	}
+	m.MustSetAt(owner[:], codec.EncodeInt64(count))
+}
+
+// GetSchedule returns the pending schedule or nil if it does not exist
+func GetSchedule(state kv.KVStoreReader, id uint32) (*Schedule, error) {
+	data, err := collections.NewMapReadOnly(state, varSchedules).GetAt(util.Uint32To4Bytes(id))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return DecodeSchedule(data)
+}
+
+// GetSchedules returns pending schedules ordered by ID
+func GetSchedules(state kv.KVStoreReader) ([]*Schedule, error) {
+	ret := make([]*Schedule, 0)
+	var err error
+	collections.NewMapReadOnly(state, varSchedules).MustIterate(func(_ []byte, value []byte) bool {
+		var s *Schedule
+		if s, err = DecodeSchedule(value); err != nil {
+			return false
+		}
+		ret = append(ret, s)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ID < ret[j].ID
+	})
+	return ret, nil
+}
+
+// DueSchedules returns schedules which are due at the time, ordered by the time of the call and by ID.
+// Only the due schedules are decoded, nothing is read from the index if no call is due
+func DueSchedules(state kv.KVStoreReader, nowis int64) ([]*Schedule, error) {
+	if next := NextRunTime(state); next == 0 || next > nowis {
+		return nil, nil
+	}
+	ids := make([]uint32, 0)
+	state.MustIterateRange(kv.Key(varQueue), queueKey(nowis+1, 0), func(key kv.Key, _ []byte) bool {
+		ids = append(ids, queueKeyID(key))
+		return true
+	})
+	ret := make([]*Schedule, 0, len(ids))
+	for _, id := range ids {
+		s, err := GetSchedule(state, id)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			ret = append(ret, s)
+		}
+	}
+	return ret, nil
+}
+
+// NextRun returns the earliest pending schedule or nil if there are none
+func NextRun(state kv.KVStoreReader) (*Schedule, error) {
+	if NextRunTime(state) == 0 {
+		return nil, nil
+	}
+	var ret *Schedule
+	var err error
+	state.MustIterateRange(kv.Key(varQueue), kv.PrefixEnd(kv.Key(varQueue)), func(key kv.Key, _ []byte) bool {
+		ret, err = GetSchedule(state, queueKeyID(key))
+		return false
+	})
+	return ret, err
+}
+
+// NextRunTime returns the time of the earliest call in Unix seconds or 0 if there are no pending schedules
+func NextRunTime(state kv.KVStoreReader) int64 {
+	ret, _, _ := codec.DecodeInt64(state.MustGet(varNextRun))
+	return ret
+}
+
+// GetTick returns the time lock of the pending tick request or 0 if there is none
+func GetTick(state kv.KVStoreReader) int64 {
+	ret, _, _ := codec.DecodeInt64(state.MustGet(varTick))
+	return ret
+}
+
+// SetTick stores the time lock of the posted tick request
+func SetTick(state kv.KVStore, timelock int64) {
+	state.Set(varTick, codec.EncodeInt64(timelock))
+}
+
+// Done updates the schedule after the call due at the time 'nowis'.
+// 'ran' is false if the call couldn't be made, for example, because its fees couldn't be paid. Then the one-shot
+// call is retried after RetryInterval and the recurring call is skipped. The schedule is finished after
+// MaxMissedCalls calls in a row couldn't be made
+func (s *Schedule) Done(ran bool, errStr string, nowis int64) {
+	s.LastError = errStr
+	if !ran {
+		s.Missed++
+		switch {
+		case s.Missed >= MaxMissedCalls:
+			s.NextRun = 0
+		case s.Interval == 0:
+			s.NextRun = nowis + RetryInterval
+		default:
+			s.NextRun += s.Interval
+		}
+		return
+	}
+	s.Runs++
+	s.Missed = 0
+	if s.Interval == 0 || (s.MaxRuns != 0 && s.Runs >= s.MaxRuns) {
+		s.NextRun = 0
+		return
+	}
+	s.NextRun += s.Interval
+}
+
+func nextID(state kv.KVStore) uint32 {
+	last, _, _ := codec.DecodeInt64(state.MustGet(varLastID))
+	last++
+	state.Set(varLastID, codec.EncodeInt64(last))
+	return uint32(last)
+}
@@ -0,0 +1,227 @@
+package testcore
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/contracts/native"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/vmcontext"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	aclName          = "acltest"
+	aclFuncOpen      = "open"
+	aclFuncAdmin     = "adminOnly"
+	aclFuncCallAdmin = "callAdminOnly"
+	aclRoleAdmin     = "admin"
+	aclRoleOperator  = "operator"
+)
+
+// aclContract is the test contract with one entry point which declares the required role
+var aclContract = &coreutil.ContractInterface{
+	Name:        "aclContract",
+	Description: "ACL test contract",
+	ProgramHash: hashing.HashStrings("aclContract"),
+}
+
+func init() {
+	noop := func(ctx coretypes.Sandbox) (dict.Dict, error) { return nil, nil }
+	aclContract.WithFunctions(noop, []coreutil.ContractFunctionInterface{
+		coreutil.Func(aclFuncOpen, noop),
+		coreutil.Func(aclFuncAdmin, noop).WithRoles(aclRoleAdmin),
+		coreutil.Func(aclFuncCallAdmin, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+			return ctx.Call(ctx.ContractID().Hname(), coretypes.Hn(aclFuncAdmin), nil, nil)
+		}),
+	})
+	native.AddProcessor(aclContract)
+}
+
+func setupACL(t *testing.T) (*solo.Solo, *solo.Chain) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	err := chain.DeployContract(nil, aclName, aclContract.ProgramHash)
+	require.NoError(t, err)
+	return env, chain
+}
+
+func postACL(chain *solo.Chain, sigScheme signaturescheme.SignatureScheme, funName string, params ...interface{}) error {
+	par := append([]interface{}{root.ParamHname, coretypes.Hn(aclName)}, params...)
+	_, err := chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, funName, par...), sigScheme)
+	return err
+}
+
+func callACL(chain *solo.Chain, sigScheme signaturescheme.SignatureScheme, funName string) error {
+	_, err := chain.PostRequestSync(solo.NewCallParams(aclName, funName), sigScheme)
+	return err
+}
+
+func TestACLDeclaredRole(t *testing.T) {
+	env, chain := setupACL(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	require.NoError(t, callACL(chain, user, aclFuncOpen))
+	err := callACL(chain, user, aclFuncAdmin)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), vmcontext.ErrNotAuthorized.Error())
+	// the chain owner is not assigned the role either
+	require.Error(t, callACL(chain, nil, aclFuncAdmin))
+
+	// the role can't be assigned before it is defined
+	require.Error(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, aclRoleAdmin, root.ParamAgentID, userAgentID))
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, aclRoleAdmin))
+	require.NoError(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, aclRoleAdmin, root.ParamAgentID, userAgentID))
+	require.NoError(t, callACL(chain, user, aclFuncAdmin))
+	require.Error(t, callACL(chain, nil, aclFuncAdmin))
+
+	require.NoError(t, postACL(chain, nil, root.FuncRevokeRole, root.ParamRole, aclRoleAdmin, root.ParamAgentID, userAgentID))
+	require.Error(t, callACL(chain, user, aclFuncAdmin))
+}
+
+func TestACLGuard(t *testing.T) {
+	env, chain := setupACL(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, aclRoleOperator))
+	require.NoError(t, postACL(chain, nil, root.FuncSetGuard,
+		root.ParamEntryPoint, coretypes.Hn(aclFuncOpen), root.ParamRole, aclRoleOperator))
+	require.Error(t, callACL(chain, user, aclFuncOpen))
+
+	require.NoError(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, aclRoleOperator, root.ParamAgentID, userAgentID))
+	require.NoError(t, callACL(chain, user, aclFuncOpen))
+
+	ret, err := chain.CallView(root.Interface.Name, root.FuncGetGuards, root.ParamHname, coretypes.Hn(aclName))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, len(ret))
+	require.EqualValues(t, aclRoleOperator, string(ret.MustGet(kv.Key(coretypes.Hn(aclFuncOpen).Bytes()))))
+
+	ret, err = chain.CallView(root.Interface.Name, root.FuncGetRoles, root.ParamHname, coretypes.Hn(aclName))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, len(ret))
+	require.EqualValues(t, userAgentID[:], ret.MustGet(aclRoleOperator))
+
+	// removing the guard opens the entry point
+	require.NoError(t, postACL(chain, nil, root.FuncSetGuard, root.ParamEntryPoint, coretypes.Hn(aclFuncOpen)))
+	require.NoError(t, postACL(chain, nil, root.FuncRevokeRole, root.ParamRole, aclRoleOperator, root.ParamAgentID, userAgentID))
+	require.NoError(t, callACL(chain, user, aclFuncOpen))
+}
+
+func TestACLRemoveRole(t *testing.T) {
+	env, chain := setupACL(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, aclRoleOperator))
+	require.NoError(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, aclRoleOperator, root.ParamAgentID, userAgentID))
+	require.NoError(t, postACL(chain, nil, root.FuncSetGuard,
+		root.ParamEntryPoint, coretypes.Hn(aclFuncOpen), root.ParamRole, aclRoleOperator))
+	require.Error(t, callACL(chain, nil, aclFuncOpen))
+
+	// removing the role removes its members and guards
+	require.NoError(t, postACL(chain, nil, root.FuncRemoveRole, root.ParamRole, aclRoleOperator))
+	require.NoError(t, callACL(chain, nil, aclFuncOpen))
+
+	ret, err := chain.CallView(root.Interface.Name, root.FuncGetRoles, root.ParamHname, coretypes.Hn(aclName))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, len(ret))
+	ret, err = chain.CallView(root.Interface.Name, root.FuncGetGuards, root.ParamHname, coretypes.Hn(aclName))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, len(ret))
+
+	// defined again, the role has no members
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, aclRoleOperator))
+	ret, err = chain.CallView(root.Interface.Name, root.FuncGetRoles, root.ParamHname, coretypes.Hn(aclName))
+	require.NoError(t, err)
+	require.EqualValues(t, 0, len(ret.MustGet(aclRoleOperator)))
+}
+
+func TestACLRolesWithCollidingHnames(t *testing.T) {
+	// the names have the same hname
+	const role1, role2 = "role83150", "role153104"
+	require.EqualValues(t, coretypes.Hn(role1), coretypes.Hn(role2))
+
+	env, chain := setupACL(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, role1))
+	// the other role is not defined
+	require.Error(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, role2, root.ParamAgentID, userAgentID))
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, role2))
+	require.NoError(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, role1, root.ParamAgentID, userAgentID))
+	require.NoError(t, postACL(chain, nil, root.FuncSetGuard,
+		root.ParamEntryPoint, coretypes.Hn(aclFuncOpen), root.ParamRole, role2))
+	// the member of one role is not the member of the other one
+	require.Error(t, callACL(chain, user, aclFuncOpen))
+
+	ret, err := chain.CallView(root.Interface.Name, root.FuncGetRoles, root.ParamHname, coretypes.Hn(aclName))
+	require.NoError(t, err)
+	require.EqualValues(t, 2, len(ret))
+	require.EqualValues(t, userAgentID[:], ret.MustGet(kv.Key(role1)))
+	require.EqualValues(t, 0, len(ret.MustGet(kv.Key(role2))))
+}
+
+func TestACLContractCaller(t *testing.T) {
+	_, chain := setupACL(t)
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(aclName)))
+
+	require.Error(t, callACL(chain, nil, aclFuncCallAdmin))
+	require.NoError(t, postACL(chain, nil, root.FuncDefineRole, root.ParamRole, aclRoleAdmin))
+	require.NoError(t, postACL(chain, nil, root.FuncAssignRole, root.ParamRole, aclRoleAdmin, root.ParamAgentID, contractAgentID))
+	require.NoError(t, callACL(chain, nil, aclFuncCallAdmin))
+}
+
+func TestACLManagementNotAuthorized(t *testing.T) {
+	env, chain := setupACL(t)
+	user := env.NewSignatureSchemeWithFunds()
+
+	require.Error(t, postACL(chain, user, root.FuncDefineRole, root.ParamRole, aclRoleAdmin))
+
+	// the ACL of the root can't be changed
+	req := solo.NewCallParams(root.Interface.Name, root.FuncDefineRole,
+		root.ParamHname, root.Interface.Hname(), root.ParamRole, aclRoleAdmin)
+	_, err := chain.PostRequestSync(req, nil)
+	require.Error(t, err)
+}
+
+func TestACLManagedByCreator(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	creator := env.NewSignatureSchemeWithFunds()
+	creatorAgentID := coretypes.NewAgentIDFromAddress(creator.Address())
+
+	req := solo.NewCallParams(root.Interface.Name, root.FuncGrantDeploy, root.ParamDeployer, creatorAgentID)
+	_, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+	err = chain.DeployContract(creator, aclName, aclContract.ProgramHash)
+	require.NoError(t, err)
+
+	require.NoError(t, postACL(chain, creator, root.FuncDefineRole, root.ParamRole, aclRoleAdmin))
+	require.NoError(t, postACL(chain, creator, root.FuncAssignRole, root.ParamRole, aclRoleAdmin, root.ParamAgentID, creatorAgentID))
+	require.NoError(t, callACL(chain, creator, aclFuncAdmin))
+}
+
+func TestACLRejectedCallRefunds(t *testing.T) {
+	env, chain := setupACL(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	req := solo.NewCallParams(aclName, aclFuncAdmin).WithTransfer(balance.ColorIOTA, 42)
+	_, err := chain.PostRequestSync(req, user)
+	require.Error(t, err)
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(aclName)))
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 0)
+	// the transfer is refunded, the request token is accrued to the on-chain account of the user
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-1)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1)
+}
@@ -0,0 +1,46 @@
+package testcore
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockLogBasic(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	res, err := chain.CallView(blocklog.Interface.Name, blocklog.FuncGetLatestBlockIndex)
+	require.NoError(t, err)
+	latest, _, err := codec.DecodeInt64(res.MustGet(blocklog.ParamBlockIndex))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, latest)
+
+	bi, err := chain.GetBlockInfo(1)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, bi.BlockIndex)
+	require.Len(t, bi.RequestIDs, 1) // root::init request
+
+	_, err = chain.GetBlockInfo(2)
+	require.Error(t, err)
+}
+
+func TestBlockLogRequests(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	req := solo.NewCallParams(root.Interface.Name, root.FuncSetDefaultFee, root.ParamOwnerFee, 10)
+	tx, _, err := chain.PostRequestSyncTx(req, nil)
+	require.NoError(t, err)
+
+	bi, err := chain.GetBlockInfo(chain.State.BlockIndex())
+	require.NoError(t, err)
+	require.EqualValues(t, chain.State.BlockIndex(), bi.BlockIndex)
+	require.EqualValues(t, chain.State.Timestamp(), bi.Timestamp)
+	require.Len(t, bi.RequestIDs, 1)
+	require.EqualValues(t, tx.ID(), *bi.RequestIDs[0].TransactionID())
+}
@@ -0,0 +1,139 @@
+package testcore
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+func postRoot(t *testing.T, chain *solo.Chain, funName string, params ...interface{}) {
+	_, err := chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, funName, params...), nil)
+	require.NoError(t, err)
+}
+
+func ownerBalance(chain *solo.Chain) int64 {
+	return chain.GetAccountBalance(chain.OriginatorAgentID).Balance(balance.ColorIOTA)
+}
+
+func TestFeePolicyEntryPoint(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	postRoot(t, chain, root.FuncSetFeePolicy,
+		root.ParamHname, coretypes.Hn(upgradeName),
+		root.ParamEntryPoint, coretypes.Hn(upgradeFuncSet),
+		root.ParamOwnerFee, 5,
+	)
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42)
+	require.EqualValues(t, 5, chain.EstimateFee(req, user))
+	// the chain owner is exempt from fees
+	require.EqualValues(t, 0, chain.EstimateFee(req, nil))
+	// the fee of other entry points is not affected
+	require.EqualValues(t, 0, chain.EstimateFee(solo.NewCallParams(upgradeName, upgradeFuncVersion), user))
+
+	before := ownerBalance(chain)
+	_, err := chain.PostRequestSync(req.WithTransfer(balance.ColorIOTA, 5), user)
+	require.NoError(t, err)
+	chain.AssertFeesCharged(5)
+	require.EqualValues(t, before+5, ownerBalance(chain))
+}
+
+func TestFeePolicyPayload(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	postRoot(t, chain, root.FuncSetFeePolicy,
+		root.ParamHname, coretypes.Hn(upgradeName),
+		root.ParamFeePerByte, 1,
+	)
+	short := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42)
+	long := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42, "extra", "some more bytes")
+	fee := chain.EstimateFee(short, user)
+	require.True(t, fee > 0)
+	require.True(t, chain.EstimateFee(long, user) > fee)
+
+	_, err := chain.PostRequestSync(short.WithTransfer(balance.ColorIOTA, fee), user)
+	require.NoError(t, err)
+	chain.AssertFeesCharged(fee)
+}
+
+func TestFeePolicyGas(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(upgradeName)))
+	// chain default policy
+	postRoot(t, chain, root.FuncSetFeePolicy, root.ParamFeePerKGas, 1)
+
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42).WithGasBudget(10000)
+	require.EqualValues(t, 10, chain.EstimateFee(req, user))
+
+	_, err := chain.PostRequestSync(req.WithTransfer(balance.ColorIOTA, 15), user)
+	require.NoError(t, err)
+	gasFee := (chain.LastGasBurned() + 999) / 1000
+	chain.AssertFeesCharged(gasFee)
+	// the unused part of the reserved gas fee is accrued to the sender, the rest of the transfer goes to the contract
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1+10-gasFee)
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 10+5)
+
+	// no tokens for gas
+	_, err = chain.PostRequestSync(solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42), user)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), coretypes.ErrGasBudgetExceeded.Error())
+}
+
+func TestFeeExempt(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+	postRoot(t, chain, root.FuncSetDefaultFee, root.ParamOwnerFee, 100)
+
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42)
+	_, err := chain.PostRequestSync(req, user)
+	require.Error(t, err)
+
+	postRoot(t, chain, root.FuncAddFeeExempt, root.ParamAgentID, userAgentID)
+	ret, err := chain.CallView(root.Interface.Name, root.FuncGetFeeExempt)
+	require.NoError(t, err)
+	require.True(t, ret.MustHas(kv.Key(userAgentID[:])))
+	require.EqualValues(t, 0, chain.EstimateFee(req, user))
+	_, err = chain.PostRequestSync(req, user)
+	require.NoError(t, err)
+	chain.AssertFeesCharged(0)
+
+	postRoot(t, chain, root.FuncRemoveFeeExempt, root.ParamAgentID, userAgentID)
+	_, err = chain.PostRequestSync(req, user)
+	require.Error(t, err)
+
+	// only the chain owner manages the list
+	_, err = chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, root.FuncAddFeeExempt,
+		root.ParamAgentID, userAgentID), user)
+	require.Error(t, err)
+}
+
+func TestFeeRefundPolicy(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+	postRoot(t, chain, root.FuncSetDefaultFee, root.ParamOwnerFee, 10)
+
+	// by default the whole transfer is accrued to the sender and the request is not run
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 43).WithTransfer(balance.ColorIOTA, 4)
+	_, err := chain.PostRequestSync(req, user)
+	require.Error(t, err)
+	chain.AssertFeesCharged(0)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1+4)
+	checkUpgradeVersion(t, chain, 1, "")
+
+	postRoot(t, chain, root.FuncSetDefaultFee, root.ParamFeeRefund, int64(root.FeeRefundPartial))
+	before := ownerBalance(chain)
+	_, err = chain.PostRequestSync(req, user)
+	require.Error(t, err)
+	chain.AssertFeesCharged(4)
+	require.EqualValues(t, before+4, ownerBalance(chain))
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1+4+1)
+	checkUpgradeVersion(t, chain, 1, "")
+}
@@ -0,0 +1,51 @@
+package testcore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/vmcontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasDefaultBudget(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	req := solo.NewCallParams(blob.Interface.Name, blob.FuncStoreBlob, "field", []byte("some data"))
+	_, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+
+	gas := chain.LastGasBurned()
+	require.True(t, gas > 0)
+	require.True(t, gas <= vmcontext.DefaultGasBudget)
+}
+
+func TestGasBudgetExceeded(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	data := []byte(strings.Repeat("x", 1000))
+	req := solo.NewCallParams(blob.Interface.Name, blob.FuncStoreBlob, "field", data).
+		WithGasBudget(500)
+	_, err := chain.PostRequestSync(req, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), coretypes.ErrGasBudgetExceeded.Error())
+	require.EqualValues(t, 500, chain.LastGasBurned())
+
+	hash := blob.MustGetBlobHash(dict.Dict{"field": data})
+	_, ok := chain.GetBlobInfo(hash)
+	require.False(t, ok)
+
+	// same request succeeds with enough gas
+	req = solo.NewCallParams(blob.Interface.Name, blob.FuncStoreBlob, "field", data).
+		WithGasBudget(100000)
+	_, err = chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+	_, ok = chain.GetBlobInfo(hash)
+	require.True(t, ok)
+}
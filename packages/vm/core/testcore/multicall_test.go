@@ -0,0 +1,172 @@
+package testcore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/contracts/native"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+// test contract which increments the counter and returns its new value
+var multiCallTest = &coreutil.ContractInterface{
+	Name:        "multiCallTest",
+	Description: "Multi-call test contract",
+	ProgramHash: hashing.HashStrings("multiCallTest"),
+}
+
+const (
+	multiCallTestName        = "multiCallTest"
+	multiCallTestFuncInc     = "inc"
+	multiCallTestFuncCounter = "getCounter"
+	multiCallTestParamFail   = "fail"
+	multiCallTestVarCounter  = "c"
+)
+
+func init() {
+	multiCallTest.WithFunctions(func(ctx coretypes.Sandbox) (dict.Dict, error) { return nil, nil }, []coreutil.ContractFunctionInterface{
+		coreutil.Func(multiCallTestFuncInc, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+			if ctx.Params().MustHas(multiCallTestParamFail) {
+				return nil, fmt.Errorf("inc failed")
+			}
+			counter, _, _ := codec.DecodeInt64(ctx.State().MustGet(multiCallTestVarCounter))
+			ctx.State().Set(multiCallTestVarCounter, codec.EncodeInt64(counter+1))
+			ret := dict.New()
+			ret.Set(multiCallTestVarCounter, codec.EncodeInt64(counter+1))
+			return ret, nil
+		}),
+		coreutil.ViewFunc(multiCallTestFuncCounter, func(ctx coretypes.SandboxView) (dict.Dict, error) {
+			counter, _, _ := codec.DecodeInt64(ctx.State().MustGet(multiCallTestVarCounter))
+			ret := dict.New()
+			ret.Set(multiCallTestVarCounter, codec.EncodeInt64(counter))
+			return ret, nil
+		}),
+	})
+	native.AddProcessor(multiCallTest)
+}
+
+func setupMultiCall(t *testing.T) (*solo.Solo, *solo.Chain) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	err := chain.DeployContract(nil, multiCallTestName, multiCallTest.ProgramHash)
+	require.NoError(t, err)
+	return env, chain
+}
+
+func incParams(params ...interface{}) *solo.CallParams {
+	return solo.NewCallParams(multiCallTestName, multiCallTestFuncInc, params...)
+}
+
+func checkMultiCallCounter(t *testing.T, chain *solo.Chain, expected int64) {
+	ret, err := chain.CallView(multiCallTestName, multiCallTestFuncCounter)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	require.EqualValues(t, expected, d.MustGetInt64(multiCallTestVarCounter, 0))
+}
+
+func TestMultiCall(t *testing.T) {
+	_, chain := setupMultiCall(t)
+	tx, ret, err := chain.PostRequestSyncTx(solo.NewMultiCallParams(incParams(), incParams(), incParams()), nil)
+	require.NoError(t, err)
+	checkMultiCallCounter(t, chain, 3)
+
+	results, err := sctransaction.DecodeMultiCallResults(ret)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for i, res := range results {
+		d := kvdecoder.New(res)
+		require.EqualValues(t, i+1, d.MustGetInt64(multiCallTestVarCounter))
+	}
+	rec, err := chain.GetRequestReceipt(coretypes.NewRequestID(tx.ID(), 0))
+	require.NoError(t, err)
+	require.EqualValues(t, "", rec.Error)
+	require.EqualValues(t, ret, rec.Result)
+
+	// the request is logged once to the event log of the called contract
+	require.EqualValues(t, 1, chain.GetEventLogNumRecords(multiCallTestName))
+}
+
+func TestMultiCallRollback(t *testing.T) {
+	_, chain := setupMultiCall(t)
+	_, err := chain.PostRequestSync(incParams(), nil)
+	require.NoError(t, err)
+
+	params := solo.NewMultiCallParams(incParams(), incParams(multiCallTestParamFail, 1), incParams())
+	tx, _, err := chain.PostRequestSyncTx(params, nil)
+	require.Error(t, err)
+	// none of the calls is committed
+	checkMultiCallCounter(t, chain, 1)
+
+	rec, err := chain.GetRequestReceipt(coretypes.NewRequestID(tx.ID(), 0))
+	require.NoError(t, err)
+	require.Contains(t, rec.Error, "call #1 failed")
+	require.Nil(t, rec.Result)
+}
+
+func TestMultiCallTransfer(t *testing.T) {
+	env, chain := setupMultiCall(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(multiCallTestName)))
+
+	params := solo.NewMultiCallParams(
+		incParams().WithTransfer(balance.ColorIOTA, 5),
+		solo.NewCallParams(accounts.Interface.Name, accounts.FuncDeposit).WithTransfer(balance.ColorIOTA, 7),
+	).WithTransfer(balance.ColorIOTA, 20)
+	_, err := chain.PostRequestSync(params, user)
+	require.NoError(t, err)
+	checkMultiCallCounter(t, chain, 1)
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 5)
+	// the deposit, the rest of the transfer and the request token
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 7+8+1)
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-20-1)
+}
+
+func TestMultiCallTransferNotCovered(t *testing.T) {
+	env, chain := setupMultiCall(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	params := solo.NewMultiCallParams(
+		incParams().WithTransfer(balance.ColorIOTA, 5),
+		incParams().WithTransfer(balance.ColorIOTA, 7),
+	).WithTransfer(balance.ColorIOTA, 10)
+	_, err := chain.PostRequestSync(params, user)
+	require.Error(t, err)
+	checkMultiCallCounter(t, chain, 0)
+	// the transfer is refunded to the address of the sender
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1)
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-1)
+}
+
+func TestMultiCallFees(t *testing.T) {
+	env, chain := setupMultiCall(t)
+	user := env.NewSignatureSchemeWithFunds()
+	postRoot(t, chain, root.FuncSetFeePolicy,
+		root.ParamHname, coretypes.Hn(multiCallTestName),
+		root.ParamEntryPoint, coretypes.Hn(multiCallTestFuncInc),
+		root.ParamOwnerFee, 2,
+	)
+	// fixed fees of the calls are summed up and charged in the fee color of the chain
+	before := ownerBalance(chain)
+	_, err := chain.PostRequestSync(solo.NewMultiCallParams(incParams(), incParams()).WithTransfer(balance.ColorIOTA, 4), user)
+	require.NoError(t, err)
+	chain.AssertFeesCharged(4)
+	require.EqualValues(t, before+4, ownerBalance(chain))
+	checkMultiCallCounter(t, chain, 2)
+
+	_, err = chain.PostRequestSync(solo.NewMultiCallParams(incParams(), incParams()).WithTransfer(balance.ColorIOTA, 3), user)
+	require.Error(t, err)
+	checkMultiCallCounter(t, chain, 2)
+}
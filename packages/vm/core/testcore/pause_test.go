@@ -0,0 +1,118 @@
+package testcore
+
+import (
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+func postContractStatus(chain *solo.Chain, sigScheme signaturescheme.SignatureScheme, funName string, params ...interface{}) error {
+	par := append([]interface{}{root.ParamHname, coretypes.Hn(upgradeName)}, params...)
+	_, err := chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, funName, par...), sigScheme)
+	return err
+}
+
+func checkContractStatus(t *testing.T, chain *solo.Chain, status root.ContractStatus) {
+	rec, err := chain.FindContract(upgradeName)
+	require.NoError(t, err)
+	require.EqualValues(t, status, rec.Status)
+}
+
+func TestPauseContract(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+	checkContractStatus(t, chain, root.ContractStatusActive)
+
+	require.NoError(t, postContractStatus(chain, nil, root.FuncPauseContract))
+	checkContractStatus(t, chain, root.ContractStatusPaused)
+	// can't pause twice
+	require.Error(t, postContractStatus(chain, nil, root.FuncPauseContract))
+
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 43).
+		WithTransfer(balance.ColorIOTA, 42)
+	_, err := chain.PostRequestSync(req, user)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), root.ErrContractPaused.Error())
+	// the transfer is refunded, the request token is accrued to the on-chain account of the user
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-1)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1)
+	// views of the paused contract are still available
+	checkUpgradeVersion(t, chain, 1, "")
+
+	require.NoError(t, postContractStatus(chain, nil, root.FuncResumeContract))
+	checkContractStatus(t, chain, root.ContractStatusActive)
+	_, err = chain.PostRequestSync(solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42), user)
+	require.NoError(t, err)
+	// can't resume the active contract
+	require.Error(t, postContractStatus(chain, nil, root.FuncResumeContract))
+}
+
+func TestRemoveContract(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	target := env.NewSignatureSchemeWithFunds()
+	targetAgentID := coretypes.NewAgentIDFromAddress(target.Address())
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(upgradeName)))
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 10)
+
+	require.NoError(t, postContractStatus(chain, nil, root.FuncPauseContract))
+	require.NoError(t, postContractStatus(chain, nil, root.FuncRemoveContract, root.ParamAgentID, targetAgentID))
+	checkContractStatus(t, chain, root.ContractStatusRemoved)
+
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 0)
+	chain.AssertAccountBalance(targetAgentID, balance.ColorIOTA, 10)
+	// the state partition of the contract is deleted
+	chain.State.Variables().MustIterateKeys(kv.Key(coretypes.Hn(upgradeName).Bytes()), func(key kv.Key) bool {
+		t.Fatalf("unexpected key %s", key)
+		return true
+	})
+
+	_, err := chain.PostRequestSync(solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 43), nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), root.ErrContractRemoved.Error())
+	_, err = chain.CallView(upgradeName, upgradeFuncVersion)
+	require.Error(t, err)
+
+	// removal is final
+	require.Error(t, postContractStatus(chain, nil, root.FuncResumeContract))
+	require.Error(t, postContractStatus(chain, nil, root.FuncRemoveContract))
+	require.Error(t, postContractStatus(chain, nil, root.FuncUpgradeContract, root.ParamProgramHash, upgradeV2.ProgramHash))
+}
+
+func TestRemoveContractToChainOwner(t *testing.T) {
+	_, chain := setupUpgrade(t)
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(upgradeName)))
+	before := chain.GetAccountBalance(chain.OriginatorAgentID).Balance(balance.ColorIOTA)
+
+	require.NoError(t, postContractStatus(chain, nil, root.FuncRemoveContract))
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 0)
+	// the request token of the chain owner is accrued too
+	chain.AssertAccountBalance(chain.OriginatorAgentID, balance.ColorIOTA, before+10+1)
+}
+
+func TestContractStatusNotAuthorized(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+
+	require.Error(t, postContractStatus(chain, user, root.FuncPauseContract))
+	require.Error(t, postContractStatus(chain, user, root.FuncRemoveContract))
+	checkContractStatus(t, chain, root.ContractStatusActive)
+}
+
+func TestContractStatusCoreContract(t *testing.T) {
+	_, chain := setupUpgrade(t)
+
+	_, err := chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, root.FuncPauseContract,
+		root.ParamHname, accounts.Interface.Hname()), nil)
+	require.Error(t, err)
+	_, err = chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, root.FuncRemoveContract,
+		root.ParamHname, accounts.Interface.Hname()), nil)
+	require.Error(t, err)
+}
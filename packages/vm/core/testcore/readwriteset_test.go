@@ -0,0 +1,33 @@
+package testcore
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteSet(t *testing.T) {
+	_, chain := setupMultiCall(t)
+	counterKey := kv.Key(coretypes.Hn(multiCallTestName).Bytes()) + multiCallTestVarCounter
+
+	_, err := chain.PostRequestSync(incParams(), nil)
+	require.NoError(t, err)
+	rw1 := chain.LastReadWriteSet()
+	require.True(t, rw1.Reads.Contains(counterKey))
+	require.True(t, rw1.Writes.Contains(counterKey))
+
+	// the failed request doesn't change the counter
+	_, err = chain.PostRequestSync(incParams(multiCallTestParamFail, 1), nil)
+	require.Error(t, err)
+	rw2 := chain.LastReadWriteSet()
+	require.False(t, rw2.Reads.Contains(counterKey))
+	require.False(t, rw2.Writes.Contains(counterKey))
+
+	_, err = chain.PostRequestSync(incParams(), nil)
+	require.NoError(t, err)
+	rw3 := chain.LastReadWriteSet()
+	require.True(t, rw3.ConflictsWith(rw1))
+	checkMultiCallCounter(t, chain, 2)
+}
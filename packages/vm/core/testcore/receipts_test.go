@@ -0,0 +1,82 @@
+package testcore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/blob"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptSuccess(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	req := solo.NewCallParams(root.Interface.Name, root.FuncSetContractFee,
+		root.ParamHname, blob.Interface.Hname(),
+		root.ParamOwnerFee, 1,
+	)
+	_, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+
+	user := env.NewSignatureSchemeWithFunds()
+	req = solo.NewCallParams(blob.Interface.Name, blob.FuncStoreBlob, "field", []byte("some data")).
+		WithTransfer(balance.ColorIOTA, 1)
+	tx, res, err := chain.PostRequestSyncTx(req, user)
+	require.NoError(t, err)
+
+	rec, err := chain.GetRequestReceipt(coretypes.NewRequestID(tx.ID(), 0))
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	require.EqualValues(t, coretypes.NewRequestID(tx.ID(), 0), rec.RequestID)
+	require.EqualValues(t, chain.State.BlockIndex(), rec.BlockIndex)
+	require.EqualValues(t, 0, rec.RequestIndex)
+	require.Empty(t, rec.Error)
+	require.EqualValues(t, balance.ColorIOTA, rec.FeeColor)
+	require.EqualValues(t, 1, rec.FeesCharged)
+	require.EqualValues(t, chain.LastGasBurned(), rec.GasBurned)
+	require.EqualValues(t, res, rec.Result)
+}
+
+func TestReceiptError(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	data := []byte(strings.Repeat("x", 1000))
+	req := solo.NewCallParams(blob.Interface.Name, blob.FuncStoreBlob, "field", data).
+		WithGasBudget(500)
+	tx, _, err := chain.PostRequestSyncTx(req, nil)
+	require.Error(t, err)
+
+	rec, err := chain.GetRequestReceipt(coretypes.NewRequestID(tx.ID(), 0))
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	require.EqualValues(t, coretypes.ErrGasBudgetExceeded.Error(), rec.Error)
+	require.EqualValues(t, 500, rec.GasBurned)
+	require.EqualValues(t, 0, rec.FeesCharged)
+	require.Nil(t, rec.Result)
+
+	req = solo.NewCallParams(root.Interface.Name, root.FuncGetChainInfo)
+	tx, _, err = chain.PostRequestSyncTx(req, nil)
+	require.Error(t, err)
+	rec, err = chain.GetRequestReceipt(coretypes.NewRequestID(tx.ID(), 0))
+	require.NoError(t, err)
+	require.Contains(t, rec.Error, "non-view entry point expected")
+}
+
+func TestReceiptNotFound(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	rec, err := chain.GetRequestReceipt(coretypes.RequestID{})
+	require.NoError(t, err)
+	require.Nil(t, rec)
+
+	_, err = chain.CallView("receipts", "getRequestReceipt", "requestID", codec.EncodeString("wrong"))
+	require.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package testcore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestArgsBlobRef(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	extra := bytes.Repeat([]byte("x"), 100)
+	req, blobs := solo.NewCallParamsOptimized(upgradeName, upgradeFuncSet, 0, upgradeParamValue, 43, "extra", extra)
+	require.EqualValues(t, 1, len(blobs))
+
+	// the referenced blob is not available: the request is rejected and the transfer refunded
+	_, err := chain.PostRequestSync(req.WithTransfer(balance.ColorIOTA, 42), user)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), coretypes.ErrArgsNotSolid.Error())
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-1)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 1)
+	checkUpgradeVersion(t, chain, 1, "")
+
+	for _, data := range blobs {
+		env.PutBlobDataIntoRegistry(data)
+	}
+	req, _ = solo.NewCallParamsOptimized(upgradeName, upgradeFuncSet, 0, upgradeParamValue, 43, "extra", extra)
+	_, err = chain.PostRequestSync(req, user)
+	require.NoError(t, err)
+	ret, err := chain.CallView(upgradeName, upgradeFuncVersion)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	require.EqualValues(t, 43, d.MustGetInt64(upgradeVarValue))
+}
+
+func TestRequestArgsEncrypted(t *testing.T) {
+	env, chain := setupUpgrade(t)
+	user := env.NewSignatureSchemeWithFunds()
+
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 43).WithEncryption()
+	tx, _, err := chain.PostRequestSyncTx(req, user)
+	require.NoError(t, err)
+	ret, err := chain.CallView(upgradeName, upgradeFuncVersion)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	require.EqualValues(t, 43, d.MustGetInt64(upgradeVarValue))
+
+	// the arguments are not visible on the ledger
+	_, ok := tx.Requests()[0].EncryptedArgs()
+	require.True(t, ok)
+	require.False(t, tx.Requests()[0].SolidArgs().MustHas(upgradeParamValue))
+}
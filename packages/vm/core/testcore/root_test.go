@@ -47,7 +47,7 @@ func TestGetInfo(t *testing.T) {
 	require.EqualValues(t, chain.ChainColor, info.ChainColor)
 	require.EqualValues(t, chain.ChainAddress, info.ChainAddress)
 	require.EqualValues(t, chain.OriginatorAgentID, info.ChainOwnerID)
-	require.EqualValues(t, 4, len(contracts))
+	require.EqualValues(t, 7, len(contracts))
 
 	_, ok := contracts[root.Interface.Hname()]
 	require.True(t, ok)
@@ -74,7 +74,7 @@ func TestDeployExample(t *testing.T) {
 
 	require.EqualValues(t, chain.ChainID, info.ChainID)
 	require.EqualValues(t, chain.OriginatorAgentID, info.ChainOwnerID)
-	require.EqualValues(t, 5, len(contracts))
+	require.EqualValues(t, 8, len(contracts))
 
 	_, ok := contracts[root.Interface.Hname()]
 	require.True(t, ok)
@@ -113,7 +113,7 @@ func TestDeployDouble(t *testing.T) {
 
 	require.EqualValues(t, chain.ChainID, info.ChainID)
 	require.EqualValues(t, chain.OriginatorAgentID, info.ChainOwnerID)
-	require.EqualValues(t, 5, len(contracts))
+	require.EqualValues(t, 8, len(contracts))
 
 	_, ok := contracts[root.Interface.Hname()]
 	require.True(t, ok)
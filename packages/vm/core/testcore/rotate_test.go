@@ -0,0 +1,137 @@
+package testcore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateCommitteeNotAuthorized(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	user := env.NewSignatureSchemeWithFunds()
+	newCommittee := signaturescheme.RandBLS()
+	err := chain.RotateCommittee(user, newCommittee)
+	require.Error(t, err)
+
+	// announced by the owner, confirmed by another agent
+	err = chain.AnnounceRotation(nil, newCommittee.Address())
+	require.NoError(t, err)
+	err = chain.ConfirmRotation(user, newCommittee)
+	require.Error(t, err)
+
+	info, _ := chain.GetInfo()
+	require.EqualValues(t, chain.ChainID, coretypes.ChainID(info.ChainAddress))
+	chain.CheckChain()
+}
+
+func TestRotateCommitteeNotConfirmed(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	newCommittee := signaturescheme.RandBLS()
+
+	// only shared addresses of committees are accepted
+	err := chain.AnnounceRotation(nil, env.NewSignatureScheme().Address())
+	require.Error(t, err)
+	// the rotation must be announced
+	err = chain.ConfirmRotation(nil, newCommittee)
+	require.Error(t, err)
+
+	err = chain.AnnounceRotation(nil, newCommittee.Address())
+	require.NoError(t, err)
+	// signed by another committee
+	err = chain.ConfirmRotation(nil, signaturescheme.RandBLS())
+	require.Error(t, err)
+	// the public key of the committee with the signature of another committee
+	other := signaturescheme.RandBLS().Sign(root.RotationProofData(chain.ChainID, newCommittee.Address())).Bytes()
+	own := newCommittee.Sign(root.RotationProofData(chain.ChainID, newCommittee.Address())).Bytes()
+	_, err = chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, root.FuncConfirmRotation,
+		root.ParamPublicKey, own[1:1+signaturescheme.BLSPublicKeySize],
+		root.ParamSignature, other[1+signaturescheme.BLSPublicKeySize:],
+	), nil)
+	require.Error(t, err)
+	// the signature of the other data
+	other = newCommittee.Sign(root.RotationProofData(coretypes.ChainID{}, newCommittee.Address())).Bytes()
+	_, err = chain.PostRequestSync(solo.NewCallParams(root.Interface.Name, root.FuncConfirmRotation,
+		root.ParamPublicKey, own[1:1+signaturescheme.BLSPublicKeySize],
+		root.ParamSignature, other[1+signaturescheme.BLSPublicKeySize:],
+	), nil)
+	require.Error(t, err)
+
+	// nothing is moved until the rotation is confirmed
+	info, _ := chain.GetInfo()
+	require.EqualValues(t, chain.ChainID, coretypes.ChainID(info.ChainAddress))
+	env.AssertAddressBalance(newCommittee.Address(), chain.ChainColor, 0)
+	chain.CheckChain()
+
+	// another announcement replaces the previous one
+	nextCommittee := signaturescheme.RandBLS()
+	err = chain.AnnounceRotation(nil, nextCommittee.Address())
+	require.NoError(t, err)
+	err = chain.ConfirmRotation(nil, newCommittee)
+	require.Error(t, err)
+	err = chain.ConfirmRotation(nil, nextCommittee)
+	require.NoError(t, err)
+	require.EqualValues(t, nextCommittee.Address(), chain.ChainAddress)
+	env.AssertAddressBalance(nextCommittee.Address(), chain.ChainColor, 1)
+	chain.CheckChain()
+}
+
+func TestRotateCommittee(t *testing.T) {
+	env, chain := setupScheduler(t)
+	oldAddress := chain.ChainAddress
+	chainID := chain.ChainID
+
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+	_, err := chain.PostRequestSync(solo.NewCallParams(accounts.Interface.Name, accounts.FuncDeposit).
+		WithTransfer(balance.ColorIOTA, 42), user)
+	require.NoError(t, err)
+	id, err := postSchedule(chain, scheduler.ParamInterval, 10)
+	require.NoError(t, err)
+
+	newCommittee := signaturescheme.RandBLS()
+	err = chain.RotateCommittee(nil, newCommittee)
+	require.NoError(t, err)
+
+	// the chain and all its tokens are moved to the new address
+	require.EqualValues(t, newCommittee.Address(), chain.ChainAddress)
+	require.EqualValues(t, newCommittee.Address(), *chain.StateTx.MustProperties().MustChainAddress())
+	require.EqualValues(t, chainID, *chain.StateTx.MustProperties().MustChainID())
+	env.AssertAddressBalance(oldAddress, chain.ChainColor, 0)
+	env.AssertAddressBalance(newCommittee.Address(), chain.ChainColor, 1)
+	require.Empty(t, env.GetAddressBalances(oldAddress))
+
+	info, _ := chain.GetInfo()
+	require.EqualValues(t, chainID, info.ChainID)
+	require.EqualValues(t, newCommittee.Address(), info.ChainAddress)
+	require.EqualValues(t, chain.GetTotalAssets().Balance(balance.ColorIOTA),
+		env.GetAddressBalance(newCommittee.Address(), balance.ColorIOTA))
+	chain.CheckAccountLedger()
+
+	// the chain is controlled by the new committee: requests and the requests the chain posts to itself are processed
+	_, err = chain.PostRequestSync(solo.NewCallParams(accounts.Interface.Name, accounts.FuncDeposit).
+		WithTransfer(balance.ColorIOTA, 8), user)
+	require.NoError(t, err)
+	// both deposits with their request tokens
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 42+1+8+1)
+	require.EqualValues(t, map[uint32]uint32{id: 1}, chain.RunScheduledCalls(10*time.Second))
+	checkCounter(t, chain, 1)
+
+	// the committee can be rotated again
+	err = chain.RotateCommittee(nil, signaturescheme.RandBLS())
+	require.NoError(t, err)
+	require.EqualValues(t, map[uint32]uint32{id: 1}, chain.RunScheduledCalls(10*time.Second))
+	checkCounter(t, chain, 2)
+	require.Empty(t, env.GetAddressBalances(newCommittee.Address()))
+	chain.CheckChain()
+}
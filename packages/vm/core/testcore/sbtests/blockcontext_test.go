@@ -0,0 +1,90 @@
+package sbtests
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
+	"github.com/iotaledger/wasp/packages/vm/core/testcore/sbtests/sbtestsc"
+	"github.com/stretchr/testify/require"
+)
+
+// the Wasm version of the contract doesn't implement the view
+func TestGetStateAt(t *testing.T) { run2(t, testGetStateAt, true) }
+func testGetStateAt(t *testing.T, w bool) {
+	_, chain := setupChain(t, nil)
+	setupTestSandboxSC(t, chain, nil, w)
+
+	// block index -> value of the counter at the end of the block
+	expected := map[uint32]int64{chain.State.BlockIndex(): 0}
+	req := solo.NewCallParams(SandboxSCName, sbtestsc.FuncIncCounter)
+	for i := 1; i <= 5; i++ {
+		_, err := chain.PostRequestSync(req, nil)
+		require.NoError(t, err)
+		expected[chain.State.BlockIndex()] = int64(i)
+	}
+	// blocks which don't modify the counter
+	req = solo.NewCallParams(SandboxSCName, sbtestsc.FuncDoNothing)
+	for i := 0; i < 2; i++ {
+		_, err := chain.PostRequestSync(req, nil)
+		require.NoError(t, err)
+		expected[chain.State.BlockIndex()] = 5
+	}
+
+	for blockIndex, counter := range expected {
+		ret, err := chain.CallView(SandboxSCName, sbtestsc.FuncGetCounterAt,
+			sbtestsc.ParamBlockIndex, blockIndex)
+		require.NoError(t, err)
+		deco := kvdecoder.New(ret, chain.Log)
+		require.EqualValues(t, counter, deco.MustGetInt64(sbtestsc.VarCounter))
+		require.EqualValues(t, chain.State.BlockIndex(), deco.MustGetInt64(sbtestsc.ParamBlockIndex))
+	}
+
+	_, err := chain.CallView(SandboxSCName, sbtestsc.FuncGetCounterAt,
+		sbtestsc.ParamBlockIndex, chain.State.BlockIndex()+1)
+	require.Error(t, err)
+}
+
+func TestGetStateAtPruned(t *testing.T) { run2(t, testGetStateAtPruned, true) }
+func testGetStateAtPruned(t *testing.T, w bool) {
+	_, chain := setupChain(t, nil)
+	setupTestSandboxSC(t, chain, nil, w)
+
+	req := solo.NewCallParams(blocklog.Interface.Name, blocklog.FuncSetHistoryRetention,
+		blocklog.ParamHistoryRetention, 3)
+	_, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+	ret, err := chain.CallView(blocklog.Interface.Name, blocklog.FuncGetHistoryRetention)
+	require.NoError(t, err)
+	deco := kvdecoder.New(ret, chain.Log)
+	require.EqualValues(t, 3, deco.MustGetInt64(blocklog.ParamHistoryRetention))
+
+	// block index -> value of the counter at the end of the block
+	expected := make(map[uint32]int64)
+	req = solo.NewCallParams(SandboxSCName, sbtestsc.FuncIncCounter)
+	for i := 1; i <= 6; i++ {
+		_, err := chain.PostRequestSync(req, nil)
+		require.NoError(t, err)
+		expected[chain.State.BlockIndex()] = int64(i)
+	}
+	latest := chain.State.BlockIndex()
+	for blockIndex, counter := range expected {
+		ret, err := chain.CallView(SandboxSCName, sbtestsc.FuncGetCounterAt,
+			sbtestsc.ParamBlockIndex, blockIndex)
+		if blockIndex+3 < latest {
+			// out of the retention window
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		deco := kvdecoder.New(ret, chain.Log)
+		require.EqualValues(t, counter, deco.MustGetInt64(sbtestsc.VarCounter))
+	}
+
+	// only the chain owner sets the retention
+	req = solo.NewCallParams(blocklog.Interface.Name, blocklog.FuncSetHistoryRetention,
+		blocklog.ParamHistoryRetention, 100)
+	_, err = chain.PostRequestSync(req, chain.Env.NewSignatureSchemeWithFunds())
+	require.Error(t, err)
+}
@@ -1,10 +1,15 @@
 package sbtests
 
 import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/kv/codec"
 	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
 	"github.com/iotaledger/wasp/packages/vm/core/testcore/sbtests/sbtestsc"
+	"github.com/iotaledger/wasp/packages/vm/vmcontext"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -102,3 +107,35 @@ func testCallFibonacciIndirect(t *testing.T, w bool) {
 	require.True(t, exists)
 	require.EqualValues(t, 1, r)
 }
+
+// removal of the contract deletes its state by prefix, the gas depends on the number of deleted keys
+func TestRemoveContractGas(t *testing.T) { run2(t, testRemoveContractGas) }
+func testRemoveContractGas(t *testing.T, w bool) {
+	// the same balance target is used for both chains, because it is logged
+	// in the event of the removal and the length of its encoding affects the gas
+	balanceTarget := coretypes.NewAgentIDFromAddress(address.Address{address.VersionED25519})
+	removeGas := func(keys int) int64 {
+		_, chain := setupChain(t, nil)
+		setupTestSandboxSC(t, chain, nil, w)
+		for i := 0; i < keys; i++ {
+			req := solo.NewCallParams(SandboxSCName, sbtestsc.FuncSetInt,
+				sbtestsc.ParamIntParamName, fmt.Sprintf("int%d", i),
+				sbtestsc.ParamIntParamValue, i)
+			_, err := chain.PostRequestSync(req, nil)
+			require.NoError(t, err)
+		}
+		req := solo.NewCallParams(root.Interface.Name, root.FuncRemoveContract,
+			root.ParamHname, coretypes.Hn(SandboxSCName),
+			root.ParamAgentID, balanceTarget)
+		tx, _, err := chain.PostRequestSyncTx(req, nil)
+		require.NoError(t, err)
+		rec, err := chain.GetRequestReceipt(coretypes.NewRequestID(tx.ID(), 0))
+		require.NoError(t, err)
+		return rec.GasBurned
+	}
+	gas1 := removeGas(1)
+	gas11 := removeGas(11)
+	// the event log records read during the call carry the request IDs, the length of their encoding
+	// varies by a few bytes, so the difference is only exact up to that
+	require.InDelta(t, 10*(vmcontext.GasPerIteration+vmcontext.GasPerStateWrite), gas11-gas1, float64(4*vmcontext.GasPerStateByte))
+}
@@ -0,0 +1,133 @@
+package sbtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/testcore/sbtests/sbtestsc"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCallback(t *testing.T, w bool) (*solo.Solo, *solo.Chain, *solo.Chain) {
+	env := solo.New(t, false, false)
+	chain1 := env.NewChain(nil, "ch1")
+	chain2 := env.NewChain(nil, "ch2")
+	setupTestSandboxSC(t, chain1, nil, w)
+	setupTestSandboxSC(t, chain2, nil, w)
+	return env, chain1, chain2
+}
+
+// postWithCallback posts request from the test contract on chain1 to the entry point of the test contract on
+// the target chain. The user pays 'amount' iotas for the transfer and 1 iota for each request token
+func postWithCallback(t *testing.T, chain1 *solo.Chain, targetChain coretypes.ChainID, ep string, amount, timeout int64) {
+	requestTokens := int64(1)
+	if timeout > 0 {
+		// the timeout request
+		requestTokens++
+	}
+	req := solo.NewCallParams(SandboxSCName, sbtestsc.FuncPostWithCallback,
+		sbtestsc.ParamChainID, targetChain,
+		sbtestsc.ParamHnameEP, coretypes.Hn(ep),
+		sbtestsc.ParamAmount, amount,
+		sbtestsc.ParamTimeout, timeout,
+	).WithTransfer(balance.ColorIOTA, amount+requestTokens)
+	_, err := chain1.PostRequestSync(req, nil)
+	require.NoError(t, err)
+}
+
+func getCallbackInfo(t *testing.T, chain *solo.Chain) dict.Dict {
+	ret, err := chain.CallView(SandboxSCName, sbtestsc.FuncGetCallbackInfo)
+	require.NoError(t, err)
+	return ret
+}
+
+func TestCallbackSuccess(t *testing.T) { run2(t, testCallbackSuccess, true) }
+func testCallbackSuccess(t *testing.T, w bool) {
+	_, chain1, chain2 := setupCallback(t, w)
+
+	postWithCallback(t, chain1, chain2.ChainID, sbtestsc.FuncChainOwnerIDFull, 5, 0)
+	chain2.WaitForEmptyBacklog()
+	chain1.WaitForEmptyBacklog()
+
+	ret := getCallbackInfo(t, chain1)
+	info := kvdecoder.New(ret)
+	require.EqualValues(t, 1, info.MustGetInt64(sbtestsc.VarCallbackCount))
+	require.EqualValues(t, "", info.MustGetString(sbtestsc.VarCallbackError, ""))
+	require.EqualValues(t, 0, info.MustGetInt64(sbtestsc.VarCallbackRefund, 0))
+	require.EqualValues(t, chain2.OriginatorAgentID[:], ret.MustGet(sbtestsc.ParamChainOwnerID))
+
+	// the callback reports about the request processed by chain2
+	rec, err := chain2.GetRequestReceipt(info.MustGetRequestID(sbtestsc.VarCallbackRequestID))
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	require.EqualValues(t, "", rec.Error)
+
+	contractAgentID2 := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain2.ChainID, sbtestsc.Interface.Hname()))
+	chain2.AssertAccountBalance(contractAgentID2, balance.ColorIOTA, 5)
+}
+
+func TestCallbackFailure(t *testing.T) { run2(t, testCallbackFailure, true) }
+func testCallbackFailure(t *testing.T, w bool) {
+	_, chain1, chain2 := setupCallback(t, w)
+
+	postWithCallback(t, chain1, chain2.ChainID, sbtestsc.FuncPanicFullEP, 5, 0)
+	chain2.WaitForEmptyBacklog()
+	chain1.WaitForEmptyBacklog()
+
+	ret := getCallbackInfo(t, chain1)
+	info := kvdecoder.New(ret)
+	require.EqualValues(t, 1, info.MustGetInt64(sbtestsc.VarCallbackCount))
+	require.Contains(t, info.MustGetString(sbtestsc.VarCallbackError), sbtestsc.MsgFullPanic)
+	require.Nil(t, ret.MustGet(sbtestsc.ParamChainOwnerID))
+	// the transfer is refunded with the callback
+	require.EqualValues(t, 5, info.MustGetInt64(sbtestsc.VarCallbackRefund, 0))
+
+	contractAgentID1 := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain1.ChainID, sbtestsc.Interface.Hname()))
+	contractAgentID2 := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain2.ChainID, sbtestsc.Interface.Hname()))
+	chain1.AssertAccountBalance(contractAgentID1, balance.ColorIOTA, 5)
+	chain2.AssertAccountBalance(contractAgentID2, balance.ColorIOTA, 0)
+	// the request token of the callback is paid from the account of the sender
+	chain2.AssertAccountBalance(contractAgentID1, balance.ColorIOTA, 0)
+}
+
+func TestCallbackTimeout(t *testing.T) { run2(t, testCallbackTimeout, true) }
+func testCallbackTimeout(t *testing.T, w bool) {
+	env, chain1, _ := setupCallback(t, w)
+
+	// requests to the unknown chain are never processed
+	unknownChain := coretypes.ChainID{1, 2, 3}
+	postWithCallback(t, chain1, unknownChain, sbtestsc.FuncChainOwnerIDFull, 5, 10)
+
+	info := kvdecoder.New(getCallbackInfo(t, chain1))
+	require.EqualValues(t, 0, info.MustGetInt64(sbtestsc.VarCallbackCount, 0))
+
+	env.AdvanceClockBy(11 * time.Second)
+	chain1.WaitForEmptyBacklog()
+
+	info = kvdecoder.New(getCallbackInfo(t, chain1))
+	require.EqualValues(t, 1, info.MustGetInt64(sbtestsc.VarCallbackCount))
+	require.EqualValues(t, coretypes.ErrRequestTimeout.Error(), info.MustGetString(sbtestsc.VarCallbackError, ""))
+	require.EqualValues(t, 0, info.MustGetInt64(sbtestsc.VarCallbackRefund, 0))
+}
+
+func TestCallbackBeforeTimeout(t *testing.T) { run2(t, testCallbackBeforeTimeout, true) }
+func testCallbackBeforeTimeout(t *testing.T, w bool) {
+	env, chain1, chain2 := setupCallback(t, w)
+
+	postWithCallback(t, chain1, chain2.ChainID, sbtestsc.FuncChainOwnerIDFull, 5, 10)
+	chain2.WaitForEmptyBacklog()
+	chain1.WaitForEmptyBacklog()
+
+	env.AdvanceClockBy(11 * time.Second)
+	chain1.WaitForEmptyBacklog()
+
+	// the timeout request arrives after the result and is ignored
+	info := kvdecoder.New(getCallbackInfo(t, chain1))
+	require.EqualValues(t, 1, info.MustGetInt64(sbtestsc.VarCallbackCount))
+	require.EqualValues(t, "", info.MustGetString(sbtestsc.VarCallbackError, ""))
+}
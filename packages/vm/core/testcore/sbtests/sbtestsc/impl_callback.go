@@ -0,0 +1,68 @@
+package sbtestsc
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+)
+
+// postWithCallback posts request to the entry point ParamHnameEP of the test contract on the chain ParamChainID,
+// with ParamAmount iotas and the callback to FuncCallback. Optional ParamTimeout is the timeout in seconds
+func postWithCallback(ctx coretypes.Sandbox) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	targetChain := params.MustGetChainID(ParamChainID)
+	hnameEP := params.MustGetHname(ParamHnameEP)
+	amount := params.MustGetInt64(ParamAmount, 0)
+	timeout := params.MustGetInt64(ParamTimeout, 0)
+
+	succ := ctx.PostRequest(coretypes.PostRequestParams{
+		TargetContractID: coretypes.NewContractID(targetChain, Interface.Hname()),
+		EntryPoint:       hnameEP,
+		Transfer: cbalances.NewFromMap(map[balance.Color]int64{
+			balance.ColorIOTA: amount,
+		}),
+		Callback: coretypes.Hn(FuncCallback),
+		Timeout:  uint32(timeout),
+	})
+	if !succ {
+		return nil, fmt.Errorf("failed to post request")
+	}
+	return nil, nil
+}
+
+// callback records the result of the request posted by postWithCallback
+func callback(ctx coretypes.Sandbox) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	reqid := params.MustGetRequestID(coretypes.ParamCallbackRequestID)
+	errStr := params.MustGetString(coretypes.ParamCallbackError, "")
+
+	state := kvdecoder.New(ctx.State(), ctx.Log())
+	count := state.MustGetInt64(VarCallbackCount, 0)
+	ctx.State().Set(VarCallbackCount, codec.EncodeInt64(count+1))
+	ctx.State().Set(VarCallbackRequestID, codec.EncodeRequestID(reqid))
+	ctx.State().Set(VarCallbackError, codec.EncodeString(errStr))
+	ctx.State().Set(VarCallbackRefund, codec.EncodeInt64(ctx.IncomingTransfer().Balance(balance.ColorIOTA)))
+	if owner := ctx.Params().MustGet(ParamChainOwnerID); owner != nil {
+		ctx.State().Set(ParamChainOwnerID, owner)
+	} else {
+		ctx.State().Del(ParamChainOwnerID)
+	}
+	return nil, nil
+}
+
+// getCallbackInfo returns what was recorded by the latest callback
+func getCallbackInfo(ctx coretypes.SandboxView) (dict.Dict, error) {
+	ret := dict.New()
+	for _, key := range []kv.Key{VarCallbackCount, VarCallbackRequestID, VarCallbackError, VarCallbackRefund, ParamChainOwnerID} {
+		if v := ctx.State().MustGet(key); v != nil {
+			ret.Set(key, v)
+		}
+	}
+	return ret, nil
+}
@@ -46,6 +46,25 @@ func getCounter(ctx coretypes.SandboxView) (dict.Dict, error) {
 	return ret, nil
 }
 
+// getCounterAt returns the value of the counter as of the end of the block ParamBlockIndex
+// and the index of the current block
+func getCounterAt(ctx coretypes.SandboxView) (dict.Dict, error) {
+	params := kvdecoder.New(ctx.Params(), ctx.Log())
+	blockIndex := params.MustGetInt64(ParamBlockIndex)
+	data, err := ctx.Block().GetStateAt(uint32(blockIndex), VarCounter)
+	if err != nil {
+		return nil, err
+	}
+	counter, _, err := codec.DecodeInt64(data)
+	if err != nil {
+		return nil, err
+	}
+	ret := dict.New()
+	ret.Set(VarCounter, codec.EncodeInt64(counter))
+	ret.Set(ParamBlockIndex, codec.EncodeInt64(int64(ctx.Block().BlockIndex())))
+	return ret, nil
+}
+
 func runRecursion(ctx coretypes.Sandbox) (dict.Dict, error) {
 	params := kvdecoder.New(ctx.Params(), ctx.Log())
 	depth := params.MustGetInt64(ParamIntParamValue)
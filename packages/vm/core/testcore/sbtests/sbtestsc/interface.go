@@ -43,12 +43,16 @@ func init() {
 		coreutil.Func(FuncSendToAddress, sendToAddress),
 
 		coreutil.Func(FuncWithdrawToChain, withdrawToChain),
+		coreutil.Func(FuncPostWithCallback, postWithCallback),
+		coreutil.Func(FuncCallback, callback),
+		coreutil.ViewFunc(FuncGetCallbackInfo, getCallbackInfo),
 		coreutil.Func(FuncCallOnChain, callOnChain),
 		coreutil.Func(FuncSetInt, setInt),
 		coreutil.ViewFunc(FuncGetInt, getInt),
 		coreutil.ViewFunc(FuncGetFibonacci, getFibonacci),
 		coreutil.Func(FuncIncCounter, incCounter),
 		coreutil.ViewFunc(FuncGetCounter, getCounter),
+		coreutil.ViewFunc(FuncGetCounterAt, getCounterAt),
 		coreutil.Func(FuncRunRecursion, runRecursion),
 
 		coreutil.Func(FuncPassTypesFull, passTypesFull),
@@ -86,6 +90,10 @@ const (
 
 	FuncWithdrawToChain = "withdrawToChain"
 
+	FuncPostWithCallback = "postWithCallback"
+	FuncCallback         = "callback"
+	FuncGetCallbackInfo  = "getCallbackInfo"
+
 	FuncDoNothing     = "doNothing"
 	FuncSendToAddress = "sendToAddress"
 	FuncJustView      = "justView"
@@ -95,6 +103,7 @@ const (
 	FuncGetInt       = "getInt"
 	FuncGetFibonacci = "fibonacci"
 	FuncGetCounter   = "getCounter"
+	FuncGetCounterAt = "getCounterAt"
 	FuncIncCounter   = "incCounter"
 	FuncRunRecursion = "runRecursion"
 
@@ -107,6 +116,10 @@ const (
 	VarSandboxCall          = "sandboxCall"
 	VarContractNameDeployed = "exampleDeployTR"
 	VarMintedSupply         = "mintedSupply"
+	VarCallbackCount        = "callbackCount"
+	VarCallbackRequestID    = "callbackRequestID"
+	VarCallbackError        = "callbackError"
+	VarCallbackRefund       = "callbackRefund"
 
 	// parameters
 	ParamFail            = "initFailParam"
@@ -121,6 +134,9 @@ const (
 	ParamIntParamValue   = "intParamValue"
 	ParamHnameContract   = "hnameContract"
 	ParamHnameEP         = "hnameEP"
+	ParamBlockIndex      = "blockIndex"
+	ParamAmount          = "amount"
+	ParamTimeout         = "timeout"
 
 	// error fragments for testing
 	MsgFullPanic         = "========== panic FULL ENTRY POINT ========="
@@ -0,0 +1,354 @@
+package testcore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/contracts/native"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+// test contract which counts the scheduled calls and schedules calls of itself
+var schedTest = &coreutil.ContractInterface{
+	Name:        "schedTest",
+	Description: "Scheduler test contract",
+	ProgramHash: hashing.HashStrings("schedTest"),
+}
+
+const (
+	schedTestName         = "schedTest"
+	schedTestFuncInc      = "inc"
+	schedTestFuncSchedule = "scheduleInc"
+	schedTestFuncCounter  = "getCounter"
+	schedTestParamFail    = "fail"
+	schedTestVarCounter   = "c"
+)
+
+func init() {
+	schedTest.WithFunctions(func(ctx coretypes.Sandbox) (dict.Dict, error) { return nil, nil }, []coreutil.ContractFunctionInterface{
+		coreutil.Func(schedTestFuncInc, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+			counter, _, _ := codec.DecodeInt64(ctx.State().MustGet(schedTestVarCounter))
+			ctx.State().Set(schedTestVarCounter, codec.EncodeInt64(counter+1))
+			if ctx.Params().MustHas(schedTestParamFail) {
+				return nil, fmt.Errorf("inc failed")
+			}
+			return nil, nil
+		}),
+		coreutil.Func(schedTestFuncSchedule, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+			par := dict.New()
+			par.Set(scheduler.ParamEntryPoint, codec.EncodeHname(coretypes.Hn(schedTestFuncInc)))
+			for _, p := range []string{scheduler.ParamStart, scheduler.ParamInterval, scheduler.ParamMaxRuns, scheduler.ParamFunding} {
+				if v := ctx.Params().MustGet(kv.Key(p)); v != nil {
+					par.Set(kv.Key(p), v)
+				}
+			}
+			return ctx.Call(scheduler.Interface.Hname(), coretypes.Hn(scheduler.FuncSchedule), par, nil)
+		}),
+		coreutil.ViewFunc(schedTestFuncCounter, func(ctx coretypes.SandboxView) (dict.Dict, error) {
+			counter, _, _ := codec.DecodeInt64(ctx.State().MustGet(schedTestVarCounter))
+			ret := dict.New()
+			ret.Set(schedTestVarCounter, codec.EncodeInt64(counter))
+			return ret, nil
+		}),
+	})
+	native.AddProcessor(schedTest)
+}
+
+func setupScheduler(t *testing.T) (*solo.Solo, *solo.Chain) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	err := chain.DeployContract(nil, schedTestName, schedTest.ProgramHash)
+	require.NoError(t, err)
+	return env, chain
+}
+
+func postSchedule(chain *solo.Chain, params ...interface{}) (uint32, error) {
+	par := append([]interface{}{
+		scheduler.ParamContract, coretypes.Hn(schedTestName),
+		scheduler.ParamEntryPoint, coretypes.Hn(schedTestFuncInc),
+	}, params...)
+	ret, err := chain.PostRequestSync(solo.NewCallParams(scheduler.Interface.Name, scheduler.FuncSchedule, par...), nil)
+	if err != nil {
+		return 0, err
+	}
+	d := kvdecoder.New(ret)
+	return uint32(d.MustGetInt64(scheduler.ParamID)), nil
+}
+
+func checkCounter(t *testing.T, chain *solo.Chain, expected int64) {
+	ret, err := chain.CallView(schedTestName, schedTestFuncCounter)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	require.EqualValues(t, expected, d.MustGetInt64(schedTestVarCounter, 0))
+}
+
+func getSchedule(t *testing.T, chain *solo.Chain, id uint32) *scheduler.Schedule {
+	ret, err := chain.CallView(scheduler.Interface.Name, scheduler.FuncGetSchedule, scheduler.ParamID, id)
+	require.NoError(t, err)
+	data := ret.MustGet(scheduler.ParamSchedule)
+	require.NotNil(t, data)
+	s, err := scheduler.DecodeSchedule(data)
+	require.NoError(t, err)
+	return s
+}
+
+func requireNoSchedule(t *testing.T, chain *solo.Chain, id uint32) {
+	ret, err := chain.CallView(scheduler.Interface.Name, scheduler.FuncGetSchedule, scheduler.ParamID, id)
+	require.NoError(t, err)
+	require.Nil(t, ret.MustGet(scheduler.ParamSchedule))
+}
+
+func TestScheduleOneShot(t *testing.T) {
+	env, chain := setupScheduler(t)
+	id, err := postSchedule(chain, scheduler.ParamStart, env.LogicalTime().Unix()+10)
+	require.NoError(t, err)
+
+	schedules, err := chain.GetSchedules()
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.EqualValues(t, id, schedules[0].ID)
+
+	require.Empty(t, chain.RunScheduledCalls(5*time.Second))
+	checkCounter(t, chain, 0)
+
+	require.EqualValues(t, map[uint32]uint32{id: 1}, chain.RunScheduledCalls(5*time.Second))
+	checkCounter(t, chain, 1)
+
+	// the schedule is finished and deleted, the result is in the event log
+	require.Empty(t, chain.RunScheduledCalls(time.Minute))
+	schedules, err = chain.GetSchedules()
+	require.NoError(t, err)
+	require.Empty(t, schedules)
+	requireNoSchedule(t, chain, id)
+	recs, err := chain.GetEventLogRecordsString(scheduler.Interface.Name)
+	require.NoError(t, err)
+	require.Contains(t, recs, fmt.Sprintf("[scheduled call] id: %d, call: %s::%s: Ok", id, coretypes.Hn(schedTestName), coretypes.Hn(schedTestFuncInc)))
+	require.Contains(t, recs, "Schedule finished after 1 calls")
+	chain.CheckChain()
+}
+
+func TestScheduleRecurring(t *testing.T) {
+	_, chain := setupScheduler(t)
+	id, err := postSchedule(chain, scheduler.ParamInterval, 10, scheduler.ParamMaxRuns, 3)
+	require.NoError(t, err)
+
+	require.EqualValues(t, map[uint32]uint32{id: 1}, chain.RunScheduledCalls(10*time.Second))
+	checkCounter(t, chain, 1)
+	// the missed calls are made one by one
+	require.EqualValues(t, map[uint32]uint32{id: 2}, chain.RunScheduledCalls(time.Minute))
+	checkCounter(t, chain, 3)
+
+	require.Empty(t, chain.RunScheduledCalls(time.Minute))
+	requireNoSchedule(t, chain, id)
+	recs, err := chain.GetEventLogRecordsString(scheduler.Interface.Name)
+	require.NoError(t, err)
+	require.Contains(t, recs, "Schedule finished after 3 calls")
+	chain.CheckChain()
+}
+
+func TestScheduleFailingCall(t *testing.T) {
+	_, chain := setupScheduler(t)
+	id, err := postSchedule(chain,
+		scheduler.ParamInterval, 10,
+		scheduler.ParamParams, util.MustBytes(dict.Dict{schedTestParamFail: []byte{1}}),
+	)
+	require.NoError(t, err)
+
+	require.EqualValues(t, map[uint32]uint32{id: 1}, chain.RunScheduledCalls(10*time.Second))
+	// the state update of the failed call is rolled back, the schedule continues
+	checkCounter(t, chain, 0)
+	s := getSchedule(t, chain, id)
+	require.False(t, s.IsFinished())
+	require.Contains(t, s.LastError, "inc failed")
+
+	recs, err := chain.GetEventLogRecordsString(scheduler.Interface.Name)
+	require.NoError(t, err)
+	require.Contains(t, recs, "inc failed")
+}
+
+func TestScheduleCancel(t *testing.T) {
+	env, chain := setupScheduler(t)
+	id, err := postSchedule(chain, scheduler.ParamInterval, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, map[uint32]uint32{id: 1}, chain.RunScheduledCalls(10*time.Second))
+
+	// only the owner of the schedule and the chain owner can cancel it
+	user := env.NewSignatureSchemeWithFunds()
+	req := solo.NewCallParams(scheduler.Interface.Name, scheduler.FuncCancel, scheduler.ParamID, id)
+	_, err = chain.PostRequestSync(req, user)
+	require.Error(t, err)
+	_, err = chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+
+	require.Empty(t, chain.RunScheduledCalls(time.Minute))
+	checkCounter(t, chain, 1)
+	schedules, err := chain.GetSchedules()
+	require.NoError(t, err)
+	require.Empty(t, schedules)
+}
+
+func TestScheduleNotAuthorized(t *testing.T) {
+	env, chain := setupScheduler(t)
+	user := env.NewSignatureSchemeWithFunds()
+	req := solo.NewCallParams(scheduler.Interface.Name, scheduler.FuncSchedule,
+		scheduler.ParamContract, coretypes.Hn(schedTestName),
+		scheduler.ParamEntryPoint, coretypes.Hn(schedTestFuncInc),
+	)
+	_, err := chain.PostRequestSync(req, user)
+	require.Error(t, err)
+
+	// the contract can't fund the calls from other accounts
+	req = solo.NewCallParams(schedTestName, schedTestFuncSchedule,
+		scheduler.ParamFunding, chain.OriginatorAgentID,
+	)
+	_, err = chain.PostRequestSync(req, nil)
+	require.Error(t, err)
+
+	// the tick request is only accepted from the scheduler itself
+	_, err = chain.PostRequestSync(solo.NewCallParams(scheduler.Interface.Name, scheduler.FuncTick), user)
+	require.Error(t, err)
+}
+
+func TestScheduleByContractWithFees(t *testing.T) {
+	_, chain := setupScheduler(t)
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(schedTestName)))
+	postRoot(t, chain, root.FuncSetFeePolicy,
+		root.ParamHname, coretypes.Hn(schedTestName),
+		root.ParamEntryPoint, coretypes.Hn(schedTestFuncInc),
+		root.ParamOwnerFee, 5,
+	)
+	// the contract schedules the calls of itself. The transfer funds the calls
+	req := solo.NewCallParams(schedTestName, schedTestFuncSchedule, scheduler.ParamInterval, 10).
+		WithTransfer(balance.ColorIOTA, 11)
+	ret, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	id := uint32(d.MustGetInt64(scheduler.ParamID))
+	s := getSchedule(t, chain, id)
+	require.EqualValues(t, contractAgentID, s.Owner)
+	require.EqualValues(t, contractAgentID, s.Funding)
+	// the request token of the first tick is paid by the contract
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 10)
+
+	before := ownerBalance(chain)
+	require.EqualValues(t, map[uint32]uint32{id: 2}, chain.RunScheduledCalls(20*time.Second))
+	checkCounter(t, chain, 2)
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 0)
+	require.EqualValues(t, before+10, ownerBalance(chain))
+
+	// the contract can't pay for more calls
+	require.Empty(t, chain.RunScheduledCalls(10*time.Second))
+	checkCounter(t, chain, 2)
+	s = getSchedule(t, chain, id)
+	require.EqualValues(t, 2, s.Runs)
+	require.EqualValues(t, 1, s.Missed)
+	require.Contains(t, s.LastError, "not enough fees")
+	chain.CheckChain()
+}
+
+func TestScheduleMissedCalls(t *testing.T) {
+	env, chain := setupScheduler(t)
+	postRoot(t, chain, root.FuncSetFeePolicy,
+		root.ParamHname, coretypes.Hn(schedTestName),
+		root.ParamEntryPoint, coretypes.Hn(schedTestFuncInc),
+		root.ParamOwnerFee, 5,
+	)
+	// the transfer pays the request token of the tick, but not the fees of the one-shot call
+	req := solo.NewCallParams(schedTestName, schedTestFuncSchedule, scheduler.ParamStart, env.LogicalTime().Unix()+10).
+		WithTransfer(balance.ColorIOTA, 2)
+	ret, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	id := uint32(d.MustGetInt64(scheduler.ParamID))
+
+	require.Empty(t, chain.RunScheduledCalls(10*time.Second))
+	s := getSchedule(t, chain, id)
+	require.EqualValues(t, 1, s.Missed)
+	require.Contains(t, s.LastError, "not enough fees")
+	recs, err := chain.GetEventLogRecordsString(scheduler.Interface.Name)
+	require.NoError(t, err)
+	require.Contains(t, recs, fmt.Sprintf("[scheduled call missed] id: %d", id))
+
+	// the call is retried until too many calls in a row are missed
+	for i := 1; i < scheduler.MaxMissedCalls; i++ {
+		require.Empty(t, chain.RunScheduledCalls(scheduler.RetryInterval*time.Second))
+	}
+	requireNoSchedule(t, chain, id)
+	checkCounter(t, chain, 0)
+	recs, err = chain.GetEventLogRecordsString(scheduler.Interface.Name)
+	require.NoError(t, err)
+	require.Contains(t, recs, fmt.Sprintf("Missed in a row: %d. Schedule finished after 0 calls", scheduler.MaxMissedCalls))
+	chain.CheckChain()
+}
+
+func TestScheduleLimit(t *testing.T) {
+	_, chain := setupScheduler(t)
+	ids := make([]uint32, scheduler.MaxSchedulesPerOwner)
+	for i := range ids {
+		var err error
+		ids[i], err = postSchedule(chain, scheduler.ParamInterval, 1000)
+		require.NoError(t, err)
+	}
+	_, err := postSchedule(chain, scheduler.ParamInterval, 1000)
+	require.Error(t, err)
+
+	// the cancelled schedule makes room for the new one
+	_, err = chain.PostRequestSync(solo.NewCallParams(scheduler.Interface.Name, scheduler.FuncCancel, scheduler.ParamID, ids[0]), nil)
+	require.NoError(t, err)
+	_, err = postSchedule(chain, scheduler.ParamInterval, 1000)
+	require.NoError(t, err)
+}
+
+func TestScheduledCallsAreBlockEntries(t *testing.T) {
+	_, chain := setupScheduler(t)
+	failID, err := postSchedule(chain,
+		scheduler.ParamInterval, 10,
+		scheduler.ParamParams, util.MustBytes(dict.Dict{schedTestParamFail: []byte{1}}),
+	)
+	require.NoError(t, err)
+	okID, err := postSchedule(chain, scheduler.ParamInterval, 10)
+	require.NoError(t, err)
+	callIDs := make(map[uint32]coretypes.RequestID)
+	for _, id := range []uint32{failID, okID} {
+		callIDs[id] = scheduler.CallRequestID(&chain.ChainID, getSchedule(t, chain, id))
+	}
+
+	require.EqualValues(t, map[uint32]uint32{failID: 1, okID: 1}, chain.RunScheduledCalls(10*time.Second))
+	// only the failed call is rolled back
+	checkCounter(t, chain, 1)
+
+	// each call has its own receipt
+	failRec, err := chain.GetRequestReceipt(callIDs[failID])
+	require.NoError(t, err)
+	require.NotNil(t, failRec)
+	require.Contains(t, failRec.Error, "inc failed")
+	okRec, err := chain.GetRequestReceipt(callIDs[okID])
+	require.NoError(t, err)
+	require.NotNil(t, okRec)
+	require.Empty(t, okRec.Error)
+	require.Greater(t, okRec.GasBurned, int64(0))
+
+	// the calls are the first entries of the block, followed by the tick request
+	require.Equal(t, failRec.BlockIndex, okRec.BlockIndex)
+	require.EqualValues(t, 0, failRec.RequestIndex)
+	require.EqualValues(t, 1, okRec.RequestIndex)
+	info, err := chain.GetBlockInfo(okRec.BlockIndex)
+	require.NoError(t, err)
+	require.Len(t, info.RequestIDs, 3)
+	require.EqualValues(t, callIDs[failID], info.RequestIDs[0])
+	require.EqualValues(t, callIDs[okID], info.RequestIDs[1])
+	chain.CheckChain()
+}
@@ -0,0 +1,27 @@
+package testcore
+
+import (
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateProof(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	stateRoot := chain.GetStateRoot()
+
+	key := kv.Key(root.Interface.Hname().Bytes()) + root.VarChainID
+	proof := chain.GetStateProof(key)
+	require.True(t, proof.Included)
+	require.EqualValues(t, codec.EncodeChainID(chain.ChainID), proof.Value)
+	require.NoError(t, proof.Verify(stateRoot))
+
+	proof = chain.GetStateProof(kv.Key(root.Interface.Hname().Bytes()) + "dummy")
+	require.False(t, proof.Included)
+	require.NoError(t, proof.Verify(stateRoot))
+}
@@ -0,0 +1,115 @@
+package testcore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/stretchr/testify/require"
+)
+
+func depositParams() *solo.CallParams {
+	return solo.NewCallParams(accounts.Interface.Name, accounts.FuncDeposit).WithTransfer(balance.ColorIOTA, 42)
+}
+
+func requireNotProcessed(t *testing.T, chain *solo.Chain, reqid coretypes.RequestID) {
+	// give the backlog loop of the chain some time
+	chain.WaitForEmptyBacklog(500 * time.Millisecond)
+	rec, err := chain.GetRequestReceipt(reqid)
+	require.NoError(t, err)
+	require.Nil(t, rec)
+}
+
+func TestTimelockEdge(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	timelock := time.Unix(env.LogicalTime().Unix()+10, 0)
+	tx := chain.PostRequestAsync(depositParams().WithTimelock(timelock), user)
+	reqid := coretypes.NewRequestID(tx.ID(), 0)
+
+	env.AdvanceClockTo(timelock.Add(-time.Nanosecond))
+	requireNotProcessed(t, chain, reqid)
+
+	env.AdvanceClockTo(timelock)
+	chain.WaitForEmptyBacklog()
+	rec, err := chain.GetRequestReceipt(reqid)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	require.EqualValues(t, "", rec.Error)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 43)
+}
+
+func TestBlockIndexLock(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	lock := chain.State.BlockIndex() + 3
+	tx := chain.PostRequestAsync(depositParams().WithBlockIndexLock(lock), user)
+	reqid := coretypes.NewRequestID(tx.ID(), 0)
+	requireNotProcessed(t, chain, reqid)
+
+	// the next block is lock-1
+	_, err := chain.PostRequestSync(solo.NewCallParams(accounts.Interface.Name, accounts.FuncDeposit), nil)
+	require.NoError(t, err)
+	requireNotProcessed(t, chain, reqid)
+
+	// the next block is lock
+	_, err = chain.PostRequestSync(solo.NewCallParams(accounts.Interface.Name, accounts.FuncDeposit), nil)
+	require.NoError(t, err)
+	chain.WaitForEmptyBacklog()
+	rec, err := chain.GetRequestReceipt(reqid)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	require.EqualValues(t, lock, rec.BlockIndex)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 43)
+}
+
+func TestDeadlineEdge(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	user := env.NewSignatureSchemeWithFunds()
+	userAgentID := coretypes.NewAgentIDFromAddress(user.Address())
+
+	deadline := time.Unix(env.LogicalTime().Unix()+10, 0)
+	// the last moment before the deadline expires
+	env.AdvanceClockTo(deadline.Add(time.Second - time.Nanosecond))
+	_, err := chain.PostRequestSync(depositParams().WithDeadline(deadline), user)
+	require.NoError(t, err)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 43)
+
+	// the clock was stepped by the block, the deadline has passed
+	_, err = chain.PostRequestSync(depositParams().WithDeadline(deadline), user)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), coretypes.ErrRequestTimeout.Error())
+	// the transfer is refunded, the request token is accrued to the on-chain account of the user
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-42-2)
+	chain.AssertAccountBalance(userAgentID, balance.ColorIOTA, 44)
+}
+
+func TestExpiredWhileTimeLocked(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	user := env.NewSignatureSchemeWithFunds()
+
+	deadline := time.Unix(env.LogicalTime().Unix()+10, 0)
+	// can't be processed before the deadline: the request is refunded when unlocked
+	tx := chain.PostRequestAsync(depositParams().WithTimelock(deadline.Add(time.Second)).WithDeadline(deadline), user)
+	reqid := coretypes.NewRequestID(tx.ID(), 0)
+	requireNotProcessed(t, chain, reqid)
+
+	env.AdvanceClockTo(deadline.Add(time.Second))
+	chain.WaitForEmptyBacklog()
+	rec, err := chain.GetRequestReceipt(reqid)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	require.EqualValues(t, coretypes.ErrRequestTimeout.Error(), rec.Error)
+	env.AssertAddressBalance(user.Address(), balance.ColorIOTA, solo.Saldo-1)
+}
@@ -0,0 +1,207 @@
+package testcore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/contracts/native"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/kv/kvdecoder"
+	"github.com/iotaledger/wasp/packages/solo"
+	"github.com/iotaledger/wasp/packages/vm/core/accounts"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/stretchr/testify/require"
+)
+
+// three versions of the same test contract. Version 2 migrates the state, migration of version 3 always fails
+var (
+	upgradeV1 = newUpgradeInterface(1)
+	upgradeV2 = newUpgradeInterface(2)
+	upgradeV3 = newUpgradeInterface(3)
+)
+
+const (
+	upgradeName        = "upgradable"
+	upgradeFuncSet     = "setValue"
+	upgradeFuncVersion = "getVersion"
+	upgradeParamValue  = "value"
+	upgradeVarValue    = "v"
+	upgradeVarVersion  = "ver"
+	upgradeVarMigrated = "m"
+)
+
+func newUpgradeInterface(version int64) *coreutil.ContractInterface {
+	name := fmt.Sprintf("upgradeV%d", version)
+	return &coreutil.ContractInterface{
+		Name:        name,
+		Description: fmt.Sprintf("Upgradable test contract, version %d", version),
+		ProgramHash: hashing.HashStrings(name),
+	}
+}
+
+func init() {
+	initFun := func(ctx coretypes.Sandbox) (dict.Dict, error) { return nil, nil }
+	setValue := func(ctx coretypes.Sandbox) (dict.Dict, error) {
+		ctx.State().Set(upgradeVarValue, ctx.Params().MustGet(upgradeParamValue))
+		return nil, nil
+	}
+	getVersion := func(version int64) coreutil.ViewHandler {
+		return func(ctx coretypes.SandboxView) (dict.Dict, error) {
+			ret := dict.New()
+			ret.Set(upgradeVarVersion, codec.EncodeInt64(version))
+			ctx.State().MustIterate("", func(key kv.Key, value []byte) bool {
+				ret.Set(key, value)
+				return true
+			})
+			return ret, nil
+		}
+	}
+	upgradeV1.WithFunctions(initFun, []coreutil.ContractFunctionInterface{
+		coreutil.Func(upgradeFuncSet, setValue),
+		coreutil.ViewFunc(upgradeFuncVersion, getVersion(1)),
+	})
+	upgradeV2.WithFunctions(initFun, []coreutil.ContractFunctionInterface{
+		coreutil.Func(upgradeFuncSet, setValue),
+		coreutil.ViewFunc(upgradeFuncVersion, getVersion(2)),
+		coreutil.Func(coretypes.FuncMigrate, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+			ctx.State().Set(upgradeVarMigrated, ctx.Params().MustGet(upgradeParamValue))
+			return nil, nil
+		}),
+	})
+	upgradeV3.WithFunctions(initFun, []coreutil.ContractFunctionInterface{
+		coreutil.ViewFunc(upgradeFuncVersion, getVersion(3)),
+		coreutil.Func(coretypes.FuncMigrate, func(ctx coretypes.Sandbox) (dict.Dict, error) {
+			ctx.State().Set(upgradeVarMigrated, codec.EncodeString("wrong"))
+			return nil, fmt.Errorf("migration failed")
+		}),
+	})
+	native.AddProcessor(upgradeV1)
+	native.AddProcessor(upgradeV2)
+	native.AddProcessor(upgradeV3)
+}
+
+func setupUpgrade(t *testing.T) (*solo.Solo, *solo.Chain) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+	err := chain.DeployContract(nil, upgradeName, upgradeV1.ProgramHash)
+	require.NoError(t, err)
+
+	req := solo.NewCallParams(upgradeName, upgradeFuncSet, upgradeParamValue, 42).
+		WithTransfer(balance.ColorIOTA, 10)
+	_, err = chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+	return env, chain
+}
+
+func checkUpgradeVersion(t *testing.T, chain *solo.Chain, version int64, migrated string) {
+	ret, err := chain.CallView(upgradeName, upgradeFuncVersion)
+	require.NoError(t, err)
+	d := kvdecoder.New(ret)
+	require.EqualValues(t, version, d.MustGetInt64(upgradeVarVersion))
+	require.EqualValues(t, 42, d.MustGetInt64(upgradeVarValue))
+	require.EqualValues(t, migrated, d.MustGetString(upgradeVarMigrated, ""))
+}
+
+func TestUpgradeContract(t *testing.T) {
+	_, chain := setupUpgrade(t)
+	checkUpgradeVersion(t, chain, 1, "")
+
+	err := chain.UpgradeContract(nil, upgradeName, upgradeV2.ProgramHash, upgradeParamValue, "migrated")
+	require.NoError(t, err)
+	checkUpgradeVersion(t, chain, 2, "migrated")
+
+	rec, err := chain.FindContract(upgradeName)
+	require.NoError(t, err)
+	require.EqualValues(t, upgradeV2.ProgramHash, rec.ProgramHash)
+	require.EqualValues(t, upgradeName, rec.Name)
+	require.EqualValues(t, chain.OriginatorAgentID, rec.Creator)
+
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(chain.ChainID, coretypes.Hn(upgradeName)))
+	chain.AssertAccountBalance(contractAgentID, balance.ColorIOTA, 10)
+
+	recs, err := chain.GetEventLogRecordsString(root.Interface.Name)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(recs, "[upgrade] name: "+upgradeName))
+
+	// back to the program without 'migrate'
+	err = chain.UpgradeContract(nil, upgradeName, upgradeV1.ProgramHash)
+	require.NoError(t, err)
+	checkUpgradeVersion(t, chain, 1, "migrated")
+}
+
+func TestUpgradeMigrateFail(t *testing.T) {
+	_, chain := setupUpgrade(t)
+
+	err := chain.UpgradeContract(nil, upgradeName, upgradeV3.ProgramHash)
+	require.Error(t, err)
+	checkUpgradeVersion(t, chain, 1, "")
+
+	rec, err := chain.FindContract(upgradeName)
+	require.NoError(t, err)
+	require.EqualValues(t, upgradeV1.ProgramHash, rec.ProgramHash)
+}
+
+func TestUpgradeSameProgram(t *testing.T) {
+	_, chain := setupUpgrade(t)
+
+	err := chain.UpgradeContract(nil, upgradeName, upgradeV1.ProgramHash)
+	require.Error(t, err)
+}
+
+func TestUpgradeNotAuthorized(t *testing.T) {
+	env, chain := setupUpgrade(t)
+
+	user := env.NewSignatureSchemeWithFunds()
+	err := chain.UpgradeContract(user, upgradeName, upgradeV2.ProgramHash)
+	require.Error(t, err)
+	checkUpgradeVersion(t, chain, 1, "")
+}
+
+func TestUpgradeByCreator(t *testing.T) {
+	env := solo.New(t, false, false)
+	chain := env.NewChain(nil, "chain1")
+
+	creator := env.NewSignatureSchemeWithFunds()
+	creatorAgentID := coretypes.NewAgentIDFromAddress(creator.Address())
+	req := solo.NewCallParams(root.Interface.Name, root.FuncGrantDeploy, root.ParamDeployer, creatorAgentID)
+	_, err := chain.PostRequestSync(req, nil)
+	require.NoError(t, err)
+
+	err = chain.DeployContract(creator, upgradeName, upgradeV1.ProgramHash)
+	require.NoError(t, err)
+	err = chain.UpgradeContract(creator, upgradeName, upgradeV2.ProgramHash)
+	require.NoError(t, err)
+
+	rec, err := chain.FindContract(upgradeName)
+	require.NoError(t, err)
+	require.EqualValues(t, upgradeV2.ProgramHash, rec.ProgramHash)
+}
+
+func TestUpgradeCoreContract(t *testing.T) {
+	_, chain := setupUpgrade(t)
+
+	err := chain.UpgradeContract(nil, accounts.Interface.Name, upgradeV2.ProgramHash)
+	require.Error(t, err)
+
+	rec, err := chain.FindContract(accounts.Interface.Name)
+	require.NoError(t, err)
+	require.EqualValues(t, accounts.Interface.ProgramHash, rec.ProgramHash)
+}
+
+func TestMigrateNotFromRoot(t *testing.T) {
+	_, chain := setupUpgrade(t)
+	err := chain.UpgradeContract(nil, upgradeName, upgradeV2.ProgramHash, upgradeParamValue, "migrated")
+	require.NoError(t, err)
+
+	req := solo.NewCallParams(upgradeName, coretypes.FuncMigrate, upgradeParamValue, "again")
+	_, err = chain.PostRequestSync(req, nil)
+	require.Error(t, err)
+	checkUpgradeVersion(t, chain, 2, "migrated")
+}
@@ -5,6 +5,10 @@ import (
 	"github.com/iotaledger/wasp/contracts/native"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/kv/subrealm"
+	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/vm/core"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
 	"sync"
@@ -102,3 +106,33 @@ func (cps *ProcessorCache) RemoveProcessor(h hashing.HashValue) {
 	defer cps.Unlock()
 	delete(cps.processors, h)
 }
+
+// EvictReplaced removes from the cache processors of the programs which were replaced in the contract registry
+// by the block, i.e. upgraded or removed contracts. It is called by every node when it commits the block,
+// so the processors are evicted at the same block on all nodes.
+// It must be called before the block is committed to the database, while 'prevState' is the state before the block
+func (cps *ProcessorCache) EvictReplaced(prevState, nextState state.VirtualState, block state.Block) error {
+	rootPrefix := kv.Key(root.Interface.Hname().Bytes())
+	rootUpdated := false
+	block.ForEach(func(_ uint16, stateUpdate state.StateUpdate) bool {
+		stateUpdate.Mutations().IterateLatest(func(key kv.Key, _ buffered.Mutation) bool {
+			rootUpdated = key.HasPrefix(rootPrefix)
+			return !rootUpdated
+		})
+		return !rootUpdated
+	})
+	if !rootUpdated {
+		return nil
+	}
+	replaced, err := root.ReplacedPrograms(
+		subrealm.New(prevState.Variables(), rootPrefix),
+		subrealm.New(nextState.Variables(), rootPrefix),
+	)
+	if err != nil {
+		return err
+	}
+	for _, progHash := range replaced {
+		cps.RemoveProcessor(progHash)
+	}
+	return nil
+}
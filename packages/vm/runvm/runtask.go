@@ -2,7 +2,7 @@ package runvm
 
 import (
 	"fmt"
-	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
 	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/vm/statetxbuilder"
 	"github.com/iotaledger/wasp/packages/vm/vmcontext"
@@ -20,14 +20,13 @@ func RunComputationsAsync(ctx *vm.VMTask) error {
 		return fmt.Errorf("RunComputationsAsync: must be at least 1 request")
 	}
 
-	txb, err := statetxbuilder.New(address.Address(ctx.ChainID), ctx.Color, ctx.Balances)
+	txb, err := statetxbuilder.New(ctx.ChainID, ctx.ChainAddress, ctx.Color, ctx.Balances)
 	if err != nil {
 		ctx.Log.Debugf("statetxbuilder.New: %v", err)
 		return err
 	}
 
-	// TODO 1 graceful shutdown of the running VM task (with daemon)
-	// TODO 2 timeout for VM. Gas limit
+	// TODO graceful shutdown of the running VM task (with daemon)
 
 	go runTask(ctx, txb)
 	return nil
@@ -40,35 +39,56 @@ func runTask(task *vm.VMTask, txb *statetxbuilder.Builder) {
 		"timestamp", task.Timestamp,
 		"state index", task.VirtualState.BlockIndex(),
 		"num req", len(task.Requests),
+		"parallelism", task.Parallelism,
 	)
 	vmctx, err := vmcontext.NewVMContext(task, txb)
 	if err != nil {
 		task.OnFinish(nil, nil, fmt.Errorf("runTask.createVMContext: %v", err))
 		return
 	}
+	// scheduled calls which are due at the timestamp of the batch are the first entries of the block
+	scheduled := vmctx.DueScheduledCalls(task.Timestamp)
+	// speculative runs start from the input transaction, before it is changed by the sequential run
+	spec := startSpeculativeRuns(task, txb, len(scheduled))
 
-	stateUpdates := make([]state.StateUpdate, 0, len(task.Requests))
+	numEntries := len(scheduled) + len(task.Requests)
+	stateUpdates := make([]state.StateUpdate, 0, numEntries)
+	task.ResultGasBurned = make([]int64, 0, numEntries)
+	task.ResultReadWriteSets = make([]*buffered.ReadWriteSet, 0, numEntries)
 	var lastResult dict.Dict
 	var lastErr error
 	var lastStateUpdate state.StateUpdate
 
-	// loop over the batch of requests and run each request on the VM.
-	// the result accumulates in the VMContext and in the list of stateUpdates
+	// the result of each entry accumulates in the VMContext and in the list of stateUpdates
 	timestamp := task.Timestamp
-	for _, reqRef := range task.Requests {
-		if reqRef.RequestSection().SolidArgs() == nil {
-			task.Log.Panicf("inconsistency: request args have not been solidified")
-		}
-		vmctx.RunTheRequest(reqRef, timestamp)
+	nextEntry := func() {
 		lastStateUpdate, lastResult, lastErr = vmctx.GetResult()
 
 		stateUpdates = append(stateUpdates, lastStateUpdate)
+		task.ResultGasBurned = append(task.ResultGasBurned, vmctx.GasBurned())
+		task.ResultReadWriteSets = append(task.ResultReadWriteSets, vmctx.ReadWriteSet())
 		if timestamp != 0 {
-			// increasing (nonempty) timestamp for 1 nanosecond for each request in the batch
+			// increasing (nonempty) timestamp for 1 nanosecond for each entry in the batch
 			// the reason is to provide a different timestamp for each VM call and remain deterministic
 			timestamp += 1
 		}
 	}
+	for _, s := range scheduled {
+		vmctx.RunScheduledCall(s, timestamp)
+		nextEntry()
+	}
+	// loop over the batch of requests and run each request on the VM.
+	for i, reqRef := range task.Requests {
+		if reqRef.RequestSection().SolidArgs() == nil && !reqRef.ArgsRejected {
+			task.Log.Panicf("inconsistency: request args have not been solidified")
+		}
+		specCall := spec.take(i)
+		vmctx.RunTheRequest(reqRef, timestamp, specCall)
+		if specCall.Taken() {
+			task.ResultSpeculativeCalls++
+		}
+		nextEntry()
+	}
 
 	// create block from state updates.
 	task.ResultBlock, err = state.NewBlock(stateUpdates)
@@ -88,6 +108,7 @@ func runTask(task *vm.VMTask, txb *statetxbuilder.Builder) {
 	task.ResultTransaction, err = vmctx.FinalizeTransactionEssence(
 		task.VirtualState.BlockIndex()+1,
 		stateHash,
+		vsClone.StateRoot(),
 		vsClone.Timestamp(),
 	)
 	if err != nil {
@@ -97,6 +118,7 @@ func runTask(task *vm.VMTask, txb *statetxbuilder.Builder) {
 	// Note: can't take tx ID!!
 	task.Log.Debugw("runTask OUT",
 		"batch size", task.ResultBlock.Size(),
+		"speculative calls", task.ResultSpeculativeCalls,
 		"block index", task.ResultBlock.StateIndex(),
 		"variable state hash", stateHash.String(),
 		"tx essence hash", hashing.HashData(task.ResultTransaction.EssenceBytes()).String(),
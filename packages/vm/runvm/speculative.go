@@ -0,0 +1,88 @@
+package runvm
+
+import (
+	"sync/atomic"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/statetxbuilder"
+	"github.com/iotaledger/wasp/packages/vm/vmcontext"
+)
+
+const (
+	specPending int32 = iota
+	specRunning
+	specSkipped
+)
+
+// speculativeRunner runs calls of requests of the batch speculatively on the input state
+// by task.Parallelism workers, ahead of the sequential run of the batch.
+// Only calls to native contracts are sped up: the speculative run of a call to a Wasm contract is aborted
+// and the call is made by the sequential run
+type speculativeRunner struct {
+	status  []int32 // specPending, specRunning or specSkipped for each request
+	results []chan *vmcontext.SpeculativeCall
+}
+
+// startSpeculativeRuns starts the workers. The transaction builder must be the input of the batch.
+// Requests follow 'numScheduled' scheduled calls in the block.
+// Returns nil if requests of the batch are not run in parallel
+func startSpeculativeRuns(task *vm.VMTask, txb *statetxbuilder.Builder, numScheduled int) *speculativeRunner {
+	if task.Parallelism < 2 || len(task.Requests) < 2 {
+		return nil
+	}
+	n := len(task.Requests)
+	ret := &speculativeRunner{
+		status:  make([]int32, n),
+		results: make([]chan *vmcontext.SpeculativeCall, n),
+	}
+	// entropy before each request and the timestamp of each request, same as in the sequential run
+	entropy := make([]hashing.HashValue, n)
+	timestamp := make([]int64, n)
+	entropy[0] = task.Entropy
+	timestamp[0] = task.Timestamp
+	for i := 0; i < numScheduled; i++ {
+		entropy[0] = hashing.HashData(entropy[0][:])
+		if timestamp[0] != 0 {
+			timestamp[0]++
+		}
+	}
+	for i := 1; i < n; i++ {
+		entropy[i] = hashing.HashData(entropy[i-1][:])
+		timestamp[i] = timestamp[i-1]
+		if timestamp[i] != 0 {
+			timestamp[i]++
+		}
+	}
+	jobs := make(chan int, n)
+	for i := range task.Requests {
+		ret.results[i] = make(chan *vmcontext.SpeculativeCall, 1)
+		jobs <- i
+	}
+	close(jobs)
+	input := txb.Clone()
+	for w := 0; w < task.Parallelism; w++ {
+		go func() {
+			for i := range jobs {
+				if !atomic.CompareAndSwapInt32(&ret.status[i], specPending, specRunning) {
+					// the sequential run is already there
+					continue
+				}
+				ret.results[i] <- vmcontext.RunSpeculatively(task, input, i, uint16(numScheduled+i), entropy[i], timestamp[i])
+			}
+		}()
+	}
+	return ret
+}
+
+// take returns the result of the speculative call of the request to the sequential run.
+// It waits for the speculative run in progress. If the run was not started yet, it never will, and nil is returned
+func (r *speculativeRunner) take(reqIndex int) *vmcontext.SpeculativeCall {
+	if r == nil {
+		return nil
+	}
+	if atomic.CompareAndSwapInt32(&r.status[reqIndex], specPending, specSkipped) {
+		return nil
+	}
+	return <-r.results[reqIndex]
+}
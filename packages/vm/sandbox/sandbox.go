@@ -48,6 +48,10 @@ func (s *sandbox) GetTimestamp() int64 {
 	return s.vmctx.Timestamp()
 }
 
+func (s *sandbox) Block() coretypes.BlockContext {
+	return s.vmctx.BlockContext()
+}
+
 func (s *sandbox) Params() dict.Dict {
 	return s.vmctx.Params()
 }
@@ -67,6 +71,11 @@ func (s *sandbox) DeployContract(programHash hashing.HashValue, name string, des
 }
 
 // Call calls an entry point of contract, passes parameters and funds
+// RemoveContract removes the contract, moves its balance to the target and deletes its state
+func (s *sandbox) RemoveContract(contract coretypes.Hname, balanceTarget coretypes.AgentID) error {
+	return s.vmctx.RemoveContract(contract, balanceTarget)
+}
+
 func (s *sandbox) Call(contractHname coretypes.Hname, entryPoint coretypes.Hname, params dict.Dict, transfer coretypes.ColoredBalances) (dict.Dict, error) {
 	return s.vmctx.Call(contractHname, entryPoint, params, transfer)
 }
@@ -99,7 +108,7 @@ func (s *sandbox) Log() coretypes.LogInterface {
 func (s *sandbox) Event(msg string) {
 	s.Log().Infof("eventlog::%s -> '%s'", s.vmctx.CurrentContractHname(), msg)
 	s.vmctx.StoreToEventLog(s.vmctx.CurrentContractHname(), []byte(msg))
-	s.vmctx.EventPublisher().Publish(msg)
+	s.vmctx.PublishEvent(msg)
 }
 
 func (s *sandbox) IncomingTransfer() coretypes.ColoredBalances {
@@ -113,3 +122,11 @@ func (s *sandbox) Balance(col balance.Color) int64 {
 func (s *sandbox) Balances() coretypes.ColoredBalances {
 	return s.vmctx.GetMyBalances()
 }
+
+func (s *sandbox) GasBurn(gas int64) {
+	s.vmctx.GasBurn(gas)
+}
+
+func (s *sandbox) GasBudget() int64 {
+	return s.vmctx.GasBudget()
+}
@@ -43,6 +43,10 @@ func (s sandboxView) GetTimestamp() int64 {
 	return s.vmctx.Timestamp()
 }
 
+func (s sandboxView) Block() coretypes.BlockContext {
+	return s.vmctx.BlockContext()
+}
+
 func (s sandboxView) Params() dict.Dict {
 	return s.vmctx.Params()
 }
@@ -66,3 +70,11 @@ func (s sandboxView) Balances() coretypes.ColoredBalances {
 func (s sandboxView) Log() coretypes.LogInterface {
 	return s.vmctx
 }
+
+func (s sandboxView) GasBurn(gas int64) {
+	s.vmctx.GasBurn(gas)
+}
+
+func (s sandboxView) GasBudget() int64 {
+	return s.vmctx.GasBudget()
+}
@@ -14,12 +14,15 @@ import (
 
 type Builder struct {
 	vtxb            *vtxBuilder
+	chainID         coretypes.ChainID
 	chainAddress    address.Address
 	stateSection    *sctransaction.StateSection
 	requestSections []*sctransaction.RequestSection
 }
 
-func New(chainAddress address.Address, chainColor balance.Color, addressBalances map[valuetransaction.ID][]*balance.Balance) (*Builder, error) {
+// New creates the builder of the state transaction of the chain which token is held by the chainAddress.
+// The chainAddress is the alias of the chainID unless the committee of the chain was rotated
+func New(chainID coretypes.ChainID, chainAddress address.Address, chainColor balance.Color, addressBalances map[valuetransaction.ID][]*balance.Balance) (*Builder, error) {
 	if chainColor == balance.ColorNew || chainColor == balance.ColorIOTA {
 		return nil, errors.New("statetxbuilder.New: wrong chain color")
 	}
@@ -29,17 +32,31 @@ func New(chainAddress address.Address, chainColor balance.Color, addressBalances
 	}
 	ret := &Builder{
 		vtxb:            vtxb,
+		chainID:         chainID,
 		chainAddress:    chainAddress,
 		stateSection:    sctransaction.NewStateSection(sctransaction.NewStateSectionParams{Color: chainColor}),
 		requestSections: make([]*sctransaction.RequestSection, 0),
 	}
+	ret.setStateSectionChainID()
 	err = vtxb.MoveTokens(ret.chainAddress, chainColor, 1)
 	return ret, err
 }
 
+// setStateSectionChainID writes the chain ID into the state section if it can't be
+// derived from the address of the chain token
+func (txb *Builder) setStateSectionChainID() {
+	if txb.chainAddress == address.Address(txb.chainID) {
+		txb.stateSection.WithChainID(nil)
+		return
+	}
+	chainID := txb.chainID
+	txb.stateSection.WithChainID(&chainID)
+}
+
 func (txb *Builder) Clone() *Builder {
 	ret := &Builder{
 		vtxb:            txb.vtxb.clone(),
+		chainID:         txb.chainID,
 		chainAddress:    txb.chainAddress,
 		stateSection:    txb.stateSection.Clone(),
 		requestSections: make([]*sctransaction.RequestSection, len(txb.requestSections)),
@@ -50,14 +67,42 @@ func (txb *Builder) Clone() *Builder {
 	return ret
 }
 
-func (txb *Builder) SetStateParams(stateIndex uint32, stateHash hashing.HashValue, timestamp int64) error {
-	txb.stateSection.WithStateParams(stateIndex, stateHash, timestamp)
+// ChainAddress is the address which will hold the chain token after the transaction
+func (txb *Builder) ChainAddress() address.Address {
+	return txb.chainAddress
+}
+
+// MoveChain moves the chain to the address of the new committee: the chain token, all tokens
+// owned by the chain and request tokens of requests to the chain are sent to the new address instead
+// of the current one. Requests which the chain posted to itself are retargeted to the new address
+func (txb *Builder) MoveChain(newAddress address.Address) {
+	if newAddress == txb.chainAddress {
+		return
+	}
+	oldTarget := coretypes.ChainID(txb.chainAddress)
+	txb.vtxb.moveOutputs(txb.chainAddress, newAddress)
+	txb.vtxb.reminderAddr = newAddress
+	txb.chainAddress = newAddress
+	txb.setStateSectionChainID()
+	for _, req := range txb.requestSections {
+		if req.Target().ChainID() == oldTarget {
+			req.WithTarget(coretypes.NewContractID(coretypes.ChainID(newAddress), req.Target().Hname()))
+		}
+	}
+}
+
+func (txb *Builder) SetStateParams(stateIndex uint32, stateHash, stateRoot hashing.HashValue, timestamp int64) error {
+	txb.stateSection.WithStateParams(stateIndex, stateHash, timestamp).WithStateRoot(stateRoot)
 	return nil
 }
 
 // AddRequestSectionWithTransfer adds request block with the request
 // token and adds respective outputs for the colored transfers
+// Requests to the chain itself are sent to the current address of the chain
 func (txb *Builder) AddRequestSection(req *sctransaction.RequestSection) error {
+	if req.Target().ChainID() == txb.chainID {
+		req.WithTarget(coretypes.NewContractID(coretypes.ChainID(txb.chainAddress), req.Target().Hname()))
+	}
 	targetAddr := address.Address(req.Target().ChainID())
 	var err error
 	if err = txb.vtxb.MintColor(targetAddr, balance.ColorIOTA, 1); err != nil {
@@ -70,6 +115,11 @@ func (txb *Builder) AddRequestSection(req *sctransaction.RequestSection) error {
 	return nil
 }
 
+// NumRequestSections returns the number of request sections added so far
+func (txb *Builder) NumRequestSections() int {
+	return len(txb.requestSections)
+}
+
 func (txb *Builder) TransferToAddress(targetAddr address.Address, transfer coretypes.ColoredBalances) error {
 	var err error
 	transfer.Iterate(func(col balance.Color, bal int64) bool {
@@ -5,6 +5,7 @@ import (
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/hive.go/crypto/ed25519"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
 	_ "github.com/iotaledger/wasp/packages/sctransaction/properties"
 	"github.com/stretchr/testify/require"
@@ -30,7 +31,7 @@ func TestBasic(t *testing.T) {
 			balance.New(balance.ColorIOTA, 5),
 		},
 	}
-	b, err := New(chAddr, col1, inps)
+	b, err := New(coretypes.ChainID(chAddr), chAddr, col1, inps)
 	require.NoError(t, err)
 
 	b.MustValidate()
@@ -62,7 +63,7 @@ func TestClone(t *testing.T) {
 			balance.New(balance.ColorIOTA, 5),
 		},
 	}
-	b, err := New(chAddr, col1, inps)
+	b, err := New(coretypes.ChainID(chAddr), chAddr, col1, inps)
 	require.NoError(t, err)
 
 	b.MustValidate()
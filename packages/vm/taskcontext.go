@@ -2,11 +2,13 @@ package vm
 
 import (
 	"bytes"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
 	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/state"
@@ -17,6 +19,13 @@ import (
 type RequestRefWithFreeTokens struct {
 	sctransaction.RequestRef
 	FreeTokens coretypes.ColoredBalances
+	// arguments of the request were not solidified before the deadline. The request is rejected
+	ArgsRejected bool
+}
+
+// Decryptor decrypts arguments of requests encrypted for the committee
+type Decryptor interface {
+	Decrypt(data []byte) ([]byte, error)
 }
 
 // task context (for batch of requests)
@@ -25,6 +34,8 @@ type VMTask struct {
 	// inputs (immutable)
 	ChainID coretypes.ChainID
 	Color   balance.Color
+	// address which holds the chain token. It is the alias of the ChainID unless the committee was rotated
+	ChainAddress address.Address
 	// deterministic source of entropy
 	Entropy            hashing.HashValue
 	Balances           map[valuetransaction.ID][]*balance.Balance
@@ -32,12 +43,26 @@ type VMTask struct {
 	Requests           []RequestRefWithFreeTokens
 	Timestamp          int64
 	VirtualState       state.VirtualState // input immutable
+	// ID of the transaction which anchors the input state
+	StateTransactionID valuetransaction.ID
 	Log                *logger.Logger
+	// decrypts encrypted arguments of requests with the key share of the node. Can be nil
+	Decryptor Decryptor
+	// number of requests run speculatively in parallel. Values below 2 mean requests are run one by one.
+	// Only calls to native contracts are run speculatively, calls to Wasm contracts are always run one by one
+	Parallelism int
 	// call when finished
 	OnFinish func(callResult dict.Dict, callError error, vmError error)
 	// outputs
 	ResultTransaction *sctransaction.Transaction
 	ResultBlock       state.Block
+	// gas burned by each entry of the block: the scheduled calls made before the requests, then the requests
+	ResultGasBurned []int64
+	// keys read and written by each entry of the block, in the same order as ResultGasBurned.
+	// Entries which don't conflict with each other can be run in any order
+	ResultReadWriteSets []*buffered.ReadWriteSet
+	// number of requests the result of the speculative call was taken for, instead of making the call again
+	ResultSpeculativeCalls int
 }
 
 // BatchHash is used to uniquely identify the VM task
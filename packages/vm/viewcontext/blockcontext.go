@@ -0,0 +1,41 @@
+package viewcontext
+
+import (
+	"fmt"
+
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
+)
+
+// blockContext gives the view access to the metadata of the latest block
+type blockContext struct {
+	vctx          *viewcontext
+	contractHname coretypes.Hname
+}
+
+func (s *sandboxview) Block() coretypes.BlockContext {
+	return blockContext{vctx: s.vctx, contractHname: s.contractHname}
+}
+
+func (b blockContext) BlockIndex() uint32 {
+	return b.vctx.blockIndex
+}
+
+func (b blockContext) PrevStateHash() hashing.HashValue {
+	return b.vctx.stateHash
+}
+
+func (b blockContext) StateTransactionID() valuetransaction.ID {
+	return b.vctx.stateTxID
+}
+
+func (b blockContext) GetStateAt(blockIndex uint32, key kv.Key) ([]byte, error) {
+	if blockIndex > b.vctx.blockIndex {
+		return nil, fmt.Errorf("GetStateAt: block #%d is not committed yet", blockIndex)
+	}
+	blocklogState := contractStateSubpartition(b.vctx.state, blocklog.Interface.Hname())
+	return blocklog.GetStateAt(blocklogState, b.vctx.state, blockIndex, kv.Key(b.contractHname.Bytes())+key)
+}
@@ -1,6 +1,8 @@
 package viewcontext
 
 import (
+	"math"
+
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	assert2 "github.com/iotaledger/wasp/packages/coretypes/assert"
@@ -96,3 +98,11 @@ func (s *sandboxview) ContractCreator() coretypes.AgentID {
 func (s *sandboxview) GetTimestamp() int64 {
 	return s.vctx.timestamp
 }
+
+// GasBurn does nothing: calls to views from outside of the chain are not metered
+func (s *sandboxview) GasBurn(_ int64) {
+}
+
+func (s *sandboxview) GasBudget() int64 {
+	return math.MaxInt64
+}
@@ -2,6 +2,7 @@ package viewcontext
 
 import (
 	"fmt"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/kv/buffered"
 
@@ -22,10 +23,14 @@ type viewcontext struct {
 	chainID    coretypes.ChainID
 	timestamp  int64
 	log        *logger.Logger
+	// the latest block
+	blockIndex uint32
+	stateHash  hashing.HashValue
+	stateTxID  valuetransaction.ID
 }
 
 func NewFromDB(chainID coretypes.ChainID, proc *processors.ProcessorCache) (*viewcontext, error) {
-	state_, _, ok, err := state.LoadSolidState(&chainID)
+	state_, block, ok, err := state.LoadSolidState(&chainID)
 
 	if err != nil {
 		return nil, err
@@ -33,7 +38,9 @@ func NewFromDB(chainID coretypes.ChainID, proc *processors.ProcessorCache) (*vie
 	if !ok {
 		return nil, fmt.Errorf("solid state not found for chain %s", chainID.String())
 	}
-	return New(chainID, state_.Variables(), state_.Timestamp(), proc, nil), nil
+	ret := New(chainID, state_.Variables(), state_.Timestamp(), proc, nil)
+	ret.WithBlockInfo(state_.BlockIndex(), state_.Hash(), block.StateTransactionID())
+	return ret, nil
 }
 
 func New(chainID coretypes.ChainID, state kv.KVStore, ts int64, proc *processors.ProcessorCache, logSet *logger.Logger) *viewcontext {
@@ -51,6 +58,14 @@ func New(chainID coretypes.ChainID, state kv.KVStore, ts int64, proc *processors
 	}
 }
 
+// WithBlockInfo sets the metadata of the latest block, available to views through the sandbox
+func (v *viewcontext) WithBlockInfo(blockIndex uint32, stateHash hashing.HashValue, stateTxID valuetransaction.ID) *viewcontext {
+	v.blockIndex = blockIndex
+	v.stateHash = stateHash
+	v.stateTxID = stateTxID
+	return v
+}
+
 // CallView in viewcontext implements own panic catcher.
 func (v *viewcontext) CallView(contractHname coretypes.Hname, epCode coretypes.Hname, params dict.Dict) (dict.Dict, error) {
 	var ret dict.Dict
@@ -77,6 +92,9 @@ func (v *viewcontext) mustCallView(contractHname coretypes.Hname, epCode coretyp
 	if err != nil {
 		return nil, fmt.Errorf("failed to find contract %s: %v", contractHname, err)
 	}
+	if contractRecord.Status == root.ContractStatusRemoved {
+		return nil, contractRecord.CheckActive()
+	}
 	proc, err := v.processors.GetOrCreateProcessor(contractRecord, func(programHash hashing.HashValue) (string, []byte, error) {
 		if vmtype, ok := processors.GetBuiltinProcessorType(programHash); ok {
 			return vmtype, nil, nil
@@ -23,6 +23,7 @@ func (vmctx *VMContext) GetMyBalances() coretypes.ColoredBalances {
 // TransferToAddress includes output of colored tokens into the transaction
 // i.e. it is a transfer of tokens from chain to layer 1 ledger
 func (vmctx *VMContext) TransferToAddress(targetAddr address.Address, transfer coretypes.ColoredBalances) bool {
+	vmctx.abortIfSpeculative()
 	privileged := vmctx.CurrentContractHname() == accounts.Interface.Hname()
 	fmt.Printf("TransferToAddress: %s privileged = %v\n", targetAddr.String(), privileged)
 	if !privileged {
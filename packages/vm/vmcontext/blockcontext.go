@@ -0,0 +1,92 @@
+package vmcontext
+
+import (
+	"fmt"
+	"sort"
+
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/kv/subrealm"
+	"github.com/iotaledger/wasp/packages/vm/core/blocklog"
+)
+
+type blockContext struct {
+	vmctx *VMContext
+}
+
+// BlockContext returns access to the metadata of the block being produced
+// and to the historical state of the current contract
+func (vmctx *VMContext) BlockContext() coretypes.BlockContext {
+	return blockContext{vmctx: vmctx}
+}
+
+func (b blockContext) BlockIndex() uint32 {
+	return b.vmctx.blockIndex
+}
+
+func (b blockContext) PrevStateHash() hashing.HashValue {
+	return b.vmctx.prevStateHash
+}
+
+func (b blockContext) StateTransactionID() valuetransaction.ID {
+	return b.vmctx.stateTxID
+}
+
+// GetStateAt reads the key of the current contract as of the end of the block.
+// The history kept by the 'blocklog' and the state before the current request are used,
+// so the value doesn't depend on the mutations made by the current request
+func (b blockContext) GetStateAt(blockIndex uint32, key kv.Key) ([]byte, error) {
+	if blockIndex >= b.vmctx.blockIndex {
+		return nil, fmt.Errorf("GetStateAt: block #%d is not committed yet", blockIndex)
+	}
+	b.vmctx.abortIfSpeculative()
+	b.vmctx.GasBurn(GasPerHistoryRead)
+	chainState := b.vmctx.virtualState.Variables()
+	blocklogState := subrealm.New(chainState, kv.Key(blocklog.Interface.Hname().Bytes()))
+	ret, err := blocklog.GetStateAt(blocklogState, chainState, blockIndex, kv.Key(b.vmctx.CurrentContractHname().Bytes())+key)
+	b.vmctx.GasBurn(GasPerStateByte * int64(len(ret)))
+	return ret, err
+}
+
+// saveToBlockLog records the request and the values of the keys modified by the request,
+// as they were before the block, to the 'blocklog'. The history of blocks out of the retention window is pruned
+func (vmctx *VMContext) saveToBlockLog() {
+	blocklogPrefix := kv.Key(blocklog.Interface.Hname().Bytes())
+	keys := make([]kv.Key, 0)
+	seen := make(map[kv.Key]bool)
+	addKey := func(key kv.Key) {
+		if !seen[key] && !key.HasPrefix(blocklogPrefix) {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	mutations := vmctx.stateUpdate.Mutations()
+	mutations.IterateLatest(func(key kv.Key, _ buffered.Mutation) bool {
+		addKey(key)
+		return true
+	})
+	// keys deleted by prefix are recorded one by one
+	mutations.IterateDeletedPrefixes(func(prefix kv.Key) bool {
+		vmctx.virtualState.Variables().MustIterateKeys(prefix, func(key kv.Key) bool {
+			addKey(key)
+			return true
+		})
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	vmctx.pushCallContext(blocklog.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	state := vmctx.State()
+	blocklog.PruneHistory(state, vmctx.blockIndex)
+	blocklog.SaveRequest(state, vmctx.blockIndex, vmctx.timestamp, vmctx.requestID)
+	for _, key := range keys {
+		blocklog.SaveStateHistory(state, vmctx.blockIndex, key, vmctx.virtualState.Variables().MustGet(key))
+	}
+}
@@ -12,19 +12,25 @@ import (
 
 var (
 	ErrContractNotFound   = errors.New("contract not found")
-	ErrEntryPointNotFound = errors.New("entry point not found")
+	ErrEntryPointNotFound = coretypes.ErrEntryPointNotFound
 	ErrProcessorNotFound  = errors.New("VM not found. Internal error")
 	ErrNotEnoughFees      = errors.New("not enough fees")
 	ErrWrongRequestToken  = errors.New("wrong request token")
+	ErrNotAuthorized      = errors.New("not authorized by the access control list of the contract")
 )
 
 // Call
 func (vmctx *VMContext) Call(targetContract coretypes.Hname, epCode coretypes.Hname, params dict.Dict, transfer coretypes.ColoredBalances) (dict.Dict, error) {
 	vmctx.log.Debugw("Call", "targetContract", targetContract, "epCode", epCode.String())
+	vmctx.GasBurn(GasPerCall)
 	rec, ok := vmctx.findContractByHname(targetContract)
 	if !ok {
 		return nil, ErrContractNotFound
 	}
+	// paused or removed contracts can only be called by the root contract, for example to upgrade them
+	if err := rec.CheckActive(); err != nil && vmctx.CurrentContractHname() != root.Interface.Hname() {
+		return nil, err
+	}
 	return vmctx.callByProgramHash(targetContract, epCode, params, transfer, rec.ProgramHash)
 }
 
@@ -33,6 +39,7 @@ func (vmctx *VMContext) callByProgramHash(targetContract coretypes.Hname, epCode
 	if err != nil {
 		return nil, err
 	}
+	vmctx.checkSpeculativeProcessor(proc)
 	ep, ok := proc.GetEntryPoint(epCode)
 	if !ok {
 		return nil, ErrEntryPointNotFound
@@ -50,16 +57,17 @@ func (vmctx *VMContext) callByProgramHash(targetContract coretypes.Hname, epCode
 
 		return ep.CallView(NewSandboxView(vmctx))
 	}
+	if err := vmctx.checkACL(targetContract, epCode, ep); err != nil {
+		return nil, err
+	}
 	if err := vmctx.pushCallContextWithTransfer(targetContract, params, transfer); err != nil {
 		return nil, err
 	}
 	defer vmctx.popCallContext()
 
-	// prevent calling 'init' not from root contract or not while initializing root
-	if epCode == coretypes.EntryPointInit && targetContract != root.Interface.Hname() {
-		if !vmctx.callerIsRoot() {
-			return nil, fmt.Errorf("attempt to callByProgramHash init not from the root contract")
-		}
+	// prevent calling 'init' or 'migrate' not from root contract or not while initializing root
+	if err := vmctx.checkRootOnlyEntryPoint(targetContract, epCode); err != nil {
+		return nil, err
 	}
 	return ep.Call(NewSandbox(vmctx))
 }
@@ -69,6 +77,7 @@ func (vmctx *VMContext) callNonViewByProgramHash(targetContract coretypes.Hname,
 	if err != nil {
 		return nil, err
 	}
+	vmctx.checkSpeculativeProcessor(proc)
 	ep, ok := proc.GetEntryPoint(epCode)
 	if !ok {
 		return nil, ErrEntryPointNotFound
@@ -78,20 +87,55 @@ func (vmctx *VMContext) callNonViewByProgramHash(targetContract coretypes.Hname,
 	if ep.IsView() {
 		return nil, fmt.Errorf("non-view entry point expected")
 	}
+	if err := vmctx.checkACL(targetContract, epCode, ep); err != nil {
+		return nil, err
+	}
 	if err := vmctx.pushCallContextWithTransfer(targetContract, params, transfer); err != nil {
 		return nil, err
 	}
 	defer vmctx.popCallContext()
 
-	// prevent calling 'init' not from root contract or not while initializing root
-	if epCode == coretypes.EntryPointInit && targetContract != root.Interface.Hname() {
-		if !vmctx.callerIsRoot() {
-			return nil, fmt.Errorf("attempt to callByProgramHash init not from the root contract")
-		}
+	// prevent calling 'init' or 'migrate' not from root contract or not while initializing root
+	if err := vmctx.checkRootOnlyEntryPoint(targetContract, epCode); err != nil {
+		return nil, err
 	}
 	return ep.Call(NewSandbox(vmctx))
 }
 
+// checkRootOnlyEntryPoint returns error if the 'init' or 'migrate' entry point is called not by the root contract
+func (vmctx *VMContext) checkRootOnlyEntryPoint(targetContract coretypes.Hname, epCode coretypes.Hname) error {
+	if targetContract == root.Interface.Hname() || vmctx.callerIsRoot() {
+		return nil
+	}
+	switch epCode {
+	case coretypes.EntryPointInit:
+		return fmt.Errorf("attempt to callByProgramHash init not from the root contract")
+	case coretypes.EntryPointMigrate:
+		return fmt.Errorf("attempt to callByProgramHash migrate not from the root contract")
+	}
+	return nil
+}
+
+// checkACL rejects the call before the processor runs if the caller is not authorized
+// by the access control list of the target contract. Calls from and to the root contract are not checked
+func (vmctx *VMContext) checkACL(targetContract coretypes.Hname, epCode coretypes.Hname, ep coretypes.EntryPoint) error {
+	if targetContract == root.Interface.Hname() {
+		return nil
+	}
+	caller := vmctx.nextCaller()
+	if !caller.IsAddress() && caller.MustContractID() == coretypes.NewContractID(vmctx.chainID, root.Interface.Hname()) {
+		return nil
+	}
+	var declaredRoles []string
+	if epWithRoles, ok := ep.(coretypes.EntryPointWithRoles); ok {
+		declaredRoles = epWithRoles.RequiredRoles()
+	}
+	if !vmctx.isAuthorizedByACL(targetContract, epCode, declaredRoles, caller) {
+		return fmt.Errorf("%w: caller %s, entry point %s::%s", ErrNotAuthorized, caller, targetContract, epCode)
+	}
+	return nil
+}
+
 func (vmctx *VMContext) callerIsRoot() bool {
 	caller := vmctx.Caller()
 	if caller.IsAddress() {
@@ -100,10 +144,6 @@ func (vmctx *VMContext) callerIsRoot() bool {
 	return caller.MustContractID().Hname() == root.Interface.Hname()
 }
 
-func (vmctx *VMContext) requesterIsChainOwner() bool {
-	return vmctx.chainOwnerID == vmctx.reqRef.SenderAgentID()
-}
-
 func (vmctx *VMContext) Params() dict.Dict {
 	return vmctx.getCallContext().params
 }
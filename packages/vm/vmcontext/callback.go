@@ -0,0 +1,145 @@
+package vmcontext
+
+import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	valuetransaction "github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+	"time"
+)
+
+// Callbacks of cross-chain requests.
+// The request with the callback is processed by the target chain as usual. After that the VM of the target
+// chain posts the callback request back to the sending contract, with the result or the error and
+// with the refunded transfer if the request has failed.
+// If the request has the timeout, the sending chain posts the time-locked timeout request to the
+// callback together with the request. The target chain fails the request if it is processed after the deadline.
+// The sending chain decides which of the two callback requests is delivered by its own time only:
+// the result is delivered if it is processed before the deadline, otherwise the timeout request is delivered.
+// The timeout request is time-locked to the deadline, so it is always processed after the result delivered
+// before the deadline and it removes the record of the delivered result from the state.
+// The late result is ignored even if the request was processed by the target chain before the deadline
+
+// postTimeoutRequest posts time-locked request to the callback of the current contract, which reports the timeout
+// of the request with the index in the same transaction. The request token is already paid
+func (vmctx *VMContext) postTimeoutRequest(callback coretypes.Hname, deadline int64, reqIndex uint16) bool {
+	args := requestargs.New(nil)
+	args.AddEncodeSimple(coretypes.ParamCallbackError, codec.EncodeString(coretypes.ErrRequestTimeout.Error()))
+	section := sctransaction.NewRequestSection(vmctx.CurrentContractHname(), vmctx.CurrentContractID(), callback).
+		WithTimelock(deadline).
+		WithDeadline(deadline).
+		WithReplyTo(coretypes.NewRequestID(valuetransaction.ID{}, reqIndex)).
+		WithArgs(args)
+	return vmctx.txBuilder.AddRequestSection(section) == nil
+}
+
+// hasCallback returns true if the result of the current request must be posted back to the sender
+func (vmctx *VMContext) hasCallback() bool {
+	req := vmctx.reqRef.RequestSection()
+	if req.Callback() == 0 || req.ReplyTo() != nil {
+		return false
+	}
+	// only contracts receive callbacks
+	_, err := vmctx.reqRef.SenderContractID()
+	return err == nil
+}
+
+// isRequestExpired returns true if the current request is processed after its deadline
+func (vmctx *VMContext) isRequestExpired() bool {
+	req := vmctx.reqRef.RequestSection()
+	if req.ReplyTo() != nil {
+		return false
+	}
+	return req.IsExpired(time.Unix(0, vmctx.timestamp))
+}
+
+// callbackReplyTo returns ID of the request the current callback request reports about
+func (vmctx *VMContext) callbackReplyTo() coretypes.RequestID {
+	ret := *vmctx.reqRef.RequestSection().ReplyTo()
+	if vmctx.isTimeoutRequest() {
+		// the timeout request is in the same transaction as the request
+		ret = coretypes.NewRequestID(vmctx.reqRef.Tx.ID(), ret.Index())
+	}
+	return ret
+}
+
+// mustDeliverCallbackOnce returns false if the current request is the callback request which must be ignored:
+// the result which arrives after the deadline or the timeout request of the request, the result of which
+// has been delivered before
+func (vmctx *VMContext) mustDeliverCallbackOnce() bool {
+	req := vmctx.reqRef.RequestSection()
+	if req.ReplyTo() == nil || req.Deadline() == 0 {
+		return true
+	}
+	vmctx.pushCallContext(receipts.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	if vmctx.isTimeoutRequest() {
+		return !receipts.TakeCallbackDelivered(vmctx.State(), vmctx.callbackReplyTo())
+	}
+	if !time.Unix(0, vmctx.timestamp).Before(time.Unix(req.Deadline(), 0)) {
+		return false
+	}
+	receipts.MarkCallbackDelivered(vmctx.State(), vmctx.callbackReplyTo())
+	return true
+}
+
+// isTimeoutRequest returns true if the current request is the timeout request posted together with the request
+func (vmctx *VMContext) isTimeoutRequest() bool {
+	return *vmctx.reqRef.RequestSection().ReplyTo().TransactionID() == (valuetransaction.ID{})
+}
+
+// callbackArgs adds the ID of the reported request to the arguments of the callback request
+func (vmctx *VMContext) callbackArgs(args dict.Dict) dict.Dict {
+	if vmctx.reqRef.RequestSection().ReplyTo() == nil {
+		return args
+	}
+	ret := args.Clone()
+	ret.Set(coretypes.ParamCallbackRequestID, codec.EncodeRequestID(vmctx.callbackReplyTo()))
+	return ret
+}
+
+// postCallbackRequest posts the result of the current request back to the sender, if the request has the callback.
+// The request token of the callback request is paid from the on-chain account of the sender,
+// which is credited with 1 iota by each request
+func (vmctx *VMContext) postCallbackRequest() {
+	if !vmctx.hasCallback() {
+		return
+	}
+	req := vmctx.reqRef.RequestSection()
+	refund := cbalances.NewFromMap(nil)
+	if vmctx.lastError != nil {
+		refund = vmctx.remainingAfterFees
+	}
+	if !vmctx.debitFromAccount(vmctx.reqRef.SenderAgentID(), cbalances.NewFromMap(map[balance.Color]int64{
+		balance.ColorIOTA: 1,
+	})) {
+		vmctx.log.Warnf("postCallbackRequest: not enough funds for request token. Callback of %s is not posted",
+			vmctx.reqRef.RequestID().Short())
+		if vmctx.lastError != nil {
+			vmctx.refundRemaining()
+		}
+		return
+	}
+	args := dict.New()
+	if vmctx.lastResult != nil {
+		args.Extend(vmctx.lastResult)
+	}
+	if vmctx.lastError != nil {
+		args.Set(coretypes.ParamCallbackError, codec.EncodeString(vmctx.lastError.Error()))
+	}
+	senderContractID, _ := vmctx.reqRef.SenderContractID()
+	section := sctransaction.NewRequestSection(vmctx.reqHname, senderContractID, req.Callback()).
+		WithTransfer(refund).
+		WithDeadline(req.Deadline()).
+		WithReplyTo(*vmctx.reqRef.RequestID()).
+		WithArgs(requestargs.New(nil).AddEncodeSimpleMany(args))
+	if err := vmctx.txBuilder.AddRequestSection(section); err != nil {
+		vmctx.log.Panicf("postCallbackRequest: %v", err)
+	}
+}
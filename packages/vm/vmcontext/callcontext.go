@@ -5,18 +5,17 @@ import (
 
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
 )
 
+// pushCallContextWithTransfer moves the transfer from the calling contract to the called one.
+// The transfer of the call from the request is accrued to the called contract before the call
 func (vmctx *VMContext) pushCallContextWithTransfer(contract coretypes.Hname, params dict.Dict, transfer coretypes.ColoredBalances) error {
-	if transfer != nil {
+	if transfer != nil && len(vmctx.callStack) > 0 {
 		agentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.ChainID(), contract))
-		if len(vmctx.callStack) == 0 {
-			vmctx.creditToAccount(agentID, transfer)
-		} else {
-			fromAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.ChainID(), vmctx.CurrentContractHname()))
-			if !vmctx.moveBetweenAccounts(fromAgentID, agentID, transfer) {
-				return fmt.Errorf("pushCallContextWithTransfer: transfer failed")
-			}
+		fromAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.ChainID(), vmctx.CurrentContractHname()))
+		if !vmctx.moveBetweenAccounts(fromAgentID, agentID, transfer) {
+			return fmt.Errorf("pushCallContextWithTransfer: transfer failed")
 		}
 	}
 	vmctx.pushCallContext(contract, params, transfer)
@@ -29,14 +28,8 @@ func (vmctx *VMContext) pushCallContext(contract coretypes.Hname, params dict.Di
 	if traceStack {
 		vmctx.log.Debugf("+++++++++++ PUSH %d, stack depth = %d", contract, len(vmctx.callStack))
 	}
-	var caller coretypes.AgentID
 	isRequestContext := len(vmctx.callStack) == 0
-	if isRequestContext {
-		// request context
-		caller = vmctx.reqRef.SenderAgentID()
-	} else {
-		caller = coretypes.NewAgentIDFromContractID(vmctx.CurrentContractID())
-	}
+	caller := vmctx.nextCaller()
 	if traceStack {
 		vmctx.log.Debugf("+++++++++++ PUSH %d, stack depth = %d caller = %s", contract, len(vmctx.callStack), caller.String())
 	}
@@ -49,6 +42,19 @@ func (vmctx *VMContext) pushCallContext(contract coretypes.Hname, params dict.Di
 	})
 }
 
+// nextCaller returns the caller of the call which is about to be pushed to the call stack
+func (vmctx *VMContext) nextCaller() coretypes.AgentID {
+	if len(vmctx.callStack) == 0 {
+		if vmctx.reqRef.Tx == nil {
+			// the scheduled call is made on behalf of the scheduler
+			return coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.chainID, scheduler.Interface.Hname()))
+		}
+		// request context
+		return vmctx.reqRef.SenderAgentID()
+	}
+	return coretypes.NewAgentIDFromContractID(vmctx.CurrentContractID())
+}
+
 func (vmctx *VMContext) popCallContext() {
 	if traceStack {
 		vmctx.log.Debugf("+++++++++++ POP @ depth %d", len(vmctx.callStack))
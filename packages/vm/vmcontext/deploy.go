@@ -1,6 +1,8 @@
 package vmcontext
 
 import (
+	"fmt"
+
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv/codec"
@@ -12,6 +14,7 @@ import (
 // - if called from 'root' contract only loads VM from binary
 // - otherwise calls 'root' contract 'DeployContract' entry point to do the job.
 func (vmctx *VMContext) DeployContract(programHash hashing.HashValue, name string, description string, initParams dict.Dict) error {
+	vmctx.abortIfSpeculative()
 	vmtype, programBinary, err := vmctx.getBinary(programHash)
 	if err != nil {
 		return err
@@ -32,3 +35,28 @@ func (vmctx *VMContext) DeployContract(programHash hashing.HashValue, name strin
 	_, err = vmctx.Call(root.Interface.Hname(), coretypes.Hn(root.FuncDeployContract), par, nil)
 	return err
 }
+
+// RemoveContract removes the contract from the chain
+// - if called from 'root' contract moves the balance of the contract to the target and deletes the state of the contract
+// - otherwise calls 'root' contract 'RemoveContract' entry point to do the job.
+func (vmctx *VMContext) RemoveContract(contract coretypes.Hname, balanceTarget coretypes.AgentID) error {
+	if vmctx.CurrentContractHname() != root.Interface.Hname() {
+		par := dict.New()
+		par.Set(root.ParamHname, codec.EncodeHname(contract))
+		par.Set(root.ParamAgentID, codec.EncodeAgentID(balanceTarget))
+		_, err := vmctx.Call(root.Interface.Hname(), coretypes.Hn(root.FuncRemoveContract), par, nil)
+		return err
+	}
+	vmctx.log.Debugf("vmcontext.RemoveContract: %s, balance moved to %s", contract, balanceTarget)
+
+	contractAgentID := coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.chainID, contract))
+	balances := vmctx.getAccountBalances(contractAgentID)
+	if balances.Len() > 0 && !vmctx.moveBetweenAccounts(contractAgentID, balanceTarget, balances) {
+		return fmt.Errorf("RemoveContract: failed to move balance of the contract %s", contract)
+	}
+	// the whole state partition of the contract
+	contractState := newStateWrapper(contract, vmctx.virtualState, vmctx.stateUpdate)
+	contractState.gasBurn = vmctx.GasBurn
+	contractState.DelPrefix("")
+	return nil
+}
@@ -0,0 +1,43 @@
+package vmcontext
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+)
+
+// requestArgs returns solid arguments of the current request.
+// Arguments encrypted for the committee are decrypted and added to them.
+// The encrypted arguments are never stored in plain form: they only exist in the params of the call
+func (vmctx *VMContext) requestArgs() (dict.Dict, error) {
+	req := vmctx.reqRef.RequestSection()
+	data, ok := req.EncryptedArgs()
+	if !ok {
+		return req.SolidArgs(), nil
+	}
+	if vmctx.decryptor == nil {
+		return nil, fmt.Errorf("encrypted request arguments can't be decrypted by the node")
+	}
+	plain, err := vmctx.decryptor.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt request arguments: %v", err)
+	}
+	args := requestargs.New(nil)
+	if err := args.Read(bytes.NewReader(plain)); err != nil {
+		return nil, fmt.Errorf("wrong encrypted request arguments: %v", err)
+	}
+	if _, ok := args.Encrypted(); ok || args.HasBlobRef() {
+		return nil, fmt.Errorf("encrypted request arguments can't contain blob references or encrypted arguments")
+	}
+	decrypted, _, err := args.SolidifyRequestArguments(nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := req.SolidArgs().Clone()
+	for k, v := range decrypted {
+		ret.Set(k, v)
+	}
+	return ret, nil
+}
@@ -0,0 +1,54 @@
+package vmcontext
+
+import (
+	"github.com/iotaledger/wasp/packages/coretypes"
+)
+
+// gas budgets of the request
+const (
+	DefaultGasBudget = coretypes.DefaultGasBudget
+	MaxGasBudget     = coretypes.MaxGasBudget
+)
+
+// gas prices of the operations of the VM. All of them are deterministic
+const (
+	GasPerCall        = int64(100)
+	GasPerPostRequest = int64(500)
+	GasPerStateRead   = int64(10)
+	GasPerStateWrite  = int64(50)
+	GasPerStateByte   = int64(1)
+	GasPerIteration   = int64(5)
+	GasPerHistoryRead = int64(50)
+	// the bookkeeping of the scheduled call: reading, updating and indexing the schedule
+	GasPerScheduledCall = int64(200)
+)
+
+// initGasBudget sets the gas budget of the current request
+func (vmctx *VMContext) initGasBudget(budget int64) {
+	vmctx.gasBudget = coretypes.EffectiveGasBudget(budget)
+	vmctx.gasBurned = 0
+}
+
+// GasBurn charges gas to the budget of the current request.
+// Gas is only metered while the request is being run by the processor.
+// It panics with coretypes.ErrGasBudgetExceeded when the budget is exhausted
+func (vmctx *VMContext) GasBurn(gas int64) {
+	if !vmctx.gasMetering || gas <= 0 {
+		return
+	}
+	vmctx.gasBurned += gas
+	if vmctx.gasBurned > vmctx.gasBudget {
+		vmctx.gasBurned = vmctx.gasBudget
+		panic(coretypes.ErrGasBudgetExceeded)
+	}
+}
+
+// GasBudget returns the gas which remains in the budget of the current request
+func (vmctx *VMContext) GasBudget() int64 {
+	return vmctx.gasBudget - vmctx.gasBurned
+}
+
+// GasBurned returns the gas burned by the current request so far
+func (vmctx *VMContext) GasBurned() int64 {
+	return vmctx.gasBurned
+}
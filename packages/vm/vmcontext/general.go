@@ -8,8 +8,8 @@ import (
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/sctransaction"
-	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/vm"
+	"time"
 )
 
 func (vmctx *VMContext) ChainID() coretypes.ChainID {
@@ -59,14 +59,29 @@ func (vmctx *VMContext) Entropy() hashing.HashValue {
 // PostRequest creates a request section in the transaction with specified parameters
 // The transfer not include 1 iota for the request token but includes node fee, if eny
 func (vmctx *VMContext) PostRequest(par coretypes.PostRequestParams) bool {
+	vmctx.abortIfSpeculative()
 	vmctx.log.Debugw("-- PostRequestSync",
 		"target", par.TargetContractID.String(),
 		"ep", par.EntryPoint.String(),
 		"transfer", cbalances.Str(par.Transfer),
 	)
+	vmctx.GasBurn(GasPerPostRequest)
+	var deadline int64
+	if par.Callback != 0 && par.Timeout > 0 {
+		deadline = vmctx.timestamp / int64(time.Second)
+		if par.TimeLock > deadline {
+			deadline = par.TimeLock
+		}
+		deadline += int64(par.Timeout)
+	}
+	// one more request token for the timeout request
+	numRequestTokens := int64(1)
+	if deadline != 0 {
+		numRequestTokens++
+	}
 	myAgentID := vmctx.MyAgentID()
 	if !vmctx.debitFromAccount(myAgentID, cbalances.NewFromMap(map[balance.Color]int64{
-		balance.ColorIOTA: 1,
+		balance.ColorIOTA: numRequestTokens,
 	})) {
 		vmctx.log.Debugf("-- PostRequestSync: not enough funds for request token")
 		return false
@@ -79,9 +94,19 @@ func (vmctx *VMContext) PostRequest(par coretypes.PostRequestParams) bool {
 	reqParams.AddEncodeSimpleMany(par.Params)
 	reqSection := sctransaction.NewRequestSection(vmctx.CurrentContractHname(), par.TargetContractID, par.EntryPoint).
 		WithTimelock(par.TimeLock).
+		WithGasBudget(par.GasBudget).
 		WithTransfer(par.Transfer).
+		WithCallback(par.Callback).
+		WithDeadline(deadline).
 		WithArgs(reqParams)
-	return vmctx.txBuilder.AddRequestSection(reqSection) == nil
+	reqIndex := vmctx.txBuilder.NumRequestSections()
+	if vmctx.txBuilder.AddRequestSection(reqSection) != nil {
+		return false
+	}
+	if deadline == 0 {
+		return true
+	}
+	return vmctx.postTimeoutRequest(par.Callback, deadline, uint16(reqIndex))
 }
 
 func (vmctx *VMContext) PostRequestToSelf(reqCode coretypes.Hname, params dict.Dict) bool {
@@ -93,7 +118,7 @@ func (vmctx *VMContext) PostRequestToSelf(reqCode coretypes.Hname, params dict.D
 }
 
 func (vmctx *VMContext) PostRequestToSelfWithDelay(entryPoint coretypes.Hname, args dict.Dict, delaySec uint32) bool {
-	timelock := util.NanoSecToUnixSec(vmctx.timestamp) + delaySec
+	timelock := vmctx.timestamp/int64(time.Second) + int64(delaySec)
 
 	return vmctx.PostRequest(coretypes.PostRequestParams{
 		TargetContractID: vmctx.CurrentContractID(),
@@ -108,9 +133,13 @@ func (vmctx *VMContext) EventPublisher() vm.ContractEventPublisher {
 }
 
 func (vmctx *VMContext) RequestID() coretypes.RequestID {
-	return *vmctx.reqRef.RequestID()
+	return vmctx.requestID
 }
 
 func (vmctx *VMContext) NumFreeMinted() int64 {
+	if vmctx.reqRef.Tx == nil {
+		// the scheduled call is not made by the request transaction
+		return 0
+	}
 	return vmctx.reqRef.Tx.MustProperties().NumFreeMintedTokens()
 }
@@ -5,9 +5,11 @@ import (
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv/codec"
 	"github.com/iotaledger/wasp/packages/vm/core/accounts"
 	"github.com/iotaledger/wasp/packages/vm/core/blob"
 	"github.com/iotaledger/wasp/packages/vm/core/eventlog"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
 	"github.com/iotaledger/wasp/packages/vm/core/root"
 	"github.com/iotaledger/wasp/packages/vm/processors"
 )
@@ -56,6 +58,13 @@ func (vmctx *VMContext) findContractByHname(contractHname coretypes.Hname) (*roo
 	return ret, true
 }
 
+func (vmctx *VMContext) isAuthorizedByACL(contract, entryPoint coretypes.Hname, declaredRoles []string, caller coretypes.AgentID) bool {
+	vmctx.pushCallContext(root.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	return root.IsAuthorizedByACL(vmctx.State(), contract, entryPoint, declaredRoles, caller)
+}
+
 func (vmctx *VMContext) mustGetChainInfo() root.ChainInfo {
 	vmctx.pushCallContext(root.Interface.Hname(), nil, nil)
 	defer vmctx.popCallContext()
@@ -63,11 +72,42 @@ func (vmctx *VMContext) mustGetChainInfo() root.ChainInfo {
 	return root.MustGetChainInfo(vmctx.State())
 }
 
-func (vmctx *VMContext) getFeeInfo() (balance.Color, int64, int64) {
+// moveChainIfRotated moves the chain to the address of the new committee if the batch has rotated the committee
+func (vmctx *VMContext) moveChainIfRotated() error {
+	vmctx.pushCallContext(root.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	chainAddress, ok, err := codec.DecodeAddress(vmctx.State().MustGet(root.VarChainAddress))
+	if err != nil || !ok {
+		// before the chain is initialized
+		return err
+	}
+	if chainAddress != vmctx.txBuilder.ChainAddress() {
+		vmctx.log.Infof("committee rotated: the chain is moved to the address %s", chainAddress.String())
+		vmctx.txBuilder.MoveChain(chainAddress)
+	}
+	return nil
+}
+
+// getFeePolicy returns the fee policy of the request. It returns an error if the fees of the
+// multi-call request can't be combined
+func (vmctx *VMContext) getFeePolicy() (*root.FeePolicy, root.FeeRefundPolicy, error) {
 	vmctx.pushCallContext(root.Interface.Hname(), nil, nil)
 	defer vmctx.popCallContext()
 
-	return root.GetFeeInfoByContractRecord(vmctx.State(), vmctx.contractRecord)
+	if req := vmctx.reqRef.RequestSection(); req.IsMultiCall() {
+		policy, err := multiCallFeePolicy(vmctx.State(), req.Calls())
+		return policy, root.GetFeeRefundPolicy(vmctx.State()), err
+	}
+	policy := root.GetFeePolicy(vmctx.State(), vmctx.contractRecord, vmctx.reqRef.RequestSection().EntryPointCode())
+	return policy, root.GetFeeRefundPolicy(vmctx.State()), nil
+}
+
+func (vmctx *VMContext) requesterIsFeeExempt() bool {
+	vmctx.pushCallContext(root.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	return root.IsFeeExempt(vmctx.State(), vmctx.reqRef.SenderAgentID())
 }
 
 func (vmctx *VMContext) getBinary(programHash hashing.HashValue) (string, []byte, error) {
@@ -88,6 +128,14 @@ func (vmctx *VMContext) getBalance(col balance.Color) int64 {
 	return accounts.GetBalance(vmctx.State(), vmctx.MyAgentID(), col)
 }
 
+func (vmctx *VMContext) getAccountBalances(agentID coretypes.AgentID) coretypes.ColoredBalances {
+	vmctx.pushCallContext(accounts.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	ret, _ := accounts.GetAccountBalances(vmctx.State(), agentID)
+	return cbalances.NewFromMap(ret)
+}
+
 func (vmctx *VMContext) getMyBalances() coretypes.ColoredBalances {
 	agentID := vmctx.MyAgentID()
 
@@ -117,3 +165,23 @@ func (vmctx *VMContext) StoreToEventLog(contract coretypes.Hname, data []byte) {
 	vmctx.log.Debugf("StoreToEventLog/%s: data: '%s'", contract.String(), string(data))
 	eventlog.AppendToLog(vmctx.State(), vmctx.timestamp, contract, data)
 }
+
+// saveReceipt stores the receipt of the current request to the 'receipts'
+func (vmctx *VMContext) saveReceipt() {
+	vmctx.pushCallContext(receipts.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	rec := &receipts.RequestReceipt{
+		RequestID:    vmctx.requestID,
+		BlockIndex:   vmctx.blockIndex,
+		RequestIndex: vmctx.requestIndex,
+		FeeColor:     vmctx.feePolicy.FeeColor,
+		FeesCharged:  vmctx.feesCharged,
+		GasBurned:    vmctx.gasBurned,
+		Result:       vmctx.lastResult,
+	}
+	if vmctx.lastError != nil {
+		rec.Error = vmctx.lastError.Error()
+	}
+	receipts.SaveReceipt(vmctx.State(), rec)
+}
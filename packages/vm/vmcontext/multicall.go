@@ -0,0 +1,146 @@
+package vmcontext
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+)
+
+// Multi-call requests.
+// The multi-call request runs its calls in order in the context of one request, each call on behalf of the sender.
+// The transfer of each call is taken from the transfer of the request, the rest of it is accrued to the sender on-chain.
+// If any of the calls fails, state changes of all calls are rolled back and the request is refunded minus fees.
+// The result of the request contains results of all calls, encoded by sctransaction.EncodeMultiCallResults
+
+// runMultiCall runs all calls of the multi-call request. Fees are already charged
+func (vmctx *VMContext) runMultiCall() {
+	if vmctx.isRequestExpired() {
+		vmctx.lastResult = nil
+		vmctx.lastError = coretypes.ErrRequestTimeout
+		vmctx.mustHandleFallback()
+		return
+	}
+	calls := vmctx.reqRef.RequestSection().Calls()
+	leftover, err := multiCallLeftover(vmctx.remainingAfterFees, calls)
+	if err != nil {
+		vmctx.lastResult = nil
+		vmctx.lastError = err
+		vmctx.mustHandleFallback()
+		return
+	}
+	// snapshot state baseline for rollback of all calls
+	snapshotTxBuilder := vmctx.txBuilder.Clone()
+	snapshotStateUpdate := vmctx.stateUpdate.Clone()
+
+	results := make([]dict.Dict, len(calls))
+	vmctx.lastError = nil
+	vmctx.callCatchingPanic(func() {
+		for i, c := range calls {
+			vmctx.log.Debugf("runMultiCall: %s -- call #%d %s\n", vmctx.reqRef.RequestID().String(), i, c.String())
+			if results[i], err = vmctx.callFromMultiCall(c); err != nil {
+				vmctx.lastResult = nil
+				vmctx.lastError = fmt.Errorf("call #%d failed: %v", i, err)
+				return
+			}
+		}
+	})
+
+	if vmctx.lastError != nil {
+		vmctx.txBuilder = snapshotTxBuilder
+		vmctx.stateUpdate = snapshotStateUpdate
+
+		vmctx.mustHandleFallback()
+		return
+	}
+	vmctx.lastResult = sctransaction.EncodeMultiCallResults(results)
+	if leftover.Len() > 0 {
+		vmctx.creditToAccount(vmctx.reqRef.SenderAgentID(), leftover)
+	}
+}
+
+// callFromMultiCall calls the non-view entry point of the call from the request context
+func (vmctx *VMContext) callFromMultiCall(c *sctransaction.RequestCall) (dict.Dict, error) {
+	rec, ok := vmctx.findContractByHname(c.Contract)
+	if !ok {
+		return nil, fmt.Errorf("smart contract '%s' does not exist", c.Contract)
+	}
+	if err := rec.CheckActive(); err != nil {
+		return nil, err
+	}
+	if c.Transfer != nil {
+		vmctx.creditToAccount(coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.chainID, c.Contract)), c.Transfer)
+	}
+	return vmctx.callNonViewByProgramHash(c.Contract, c.EntryPoint, c.Params, c.Transfer, rec.ProgramHash)
+}
+
+// multiCallLeftover returns the part of the transfer which remains after transfers of all calls
+// or error if the transfer doesn't cover them
+func multiCallLeftover(transfer coretypes.ColoredBalances, calls []*sctransaction.RequestCall) (coretypes.ColoredBalances, error) {
+	remaining := make(map[balance.Color]int64)
+	transfer.AddToMap(remaining)
+	for i, c := range calls {
+		if !c.Transfer.NonNegative() {
+			return nil, fmt.Errorf("wrong transfer of the call #%d: %s", i, cbalances.Str(c.Transfer))
+		}
+		c.Transfer.Iterate(func(col balance.Color, bal int64) bool {
+			remaining[col] -= bal
+			return true
+		})
+	}
+	ret := cbalances.NewFromMap(remaining)
+	if !ret.NonNegative() {
+		return nil, fmt.Errorf("transfer of the request %s doesn't cover transfers of the calls", cbalances.Str(transfer))
+	}
+	return ret, nil
+}
+
+// multiCallFeePolicy combines fee policies of the entry points of all calls:
+// fixed fees are summed up, fees per byte and per gas are the maximum of all calls.
+// All calls which charge fees must charge them in the same color, otherwise it returns an error
+func multiCallFeePolicy(state kv.KVStoreReader, calls []*sctransaction.RequestCall) (*root.FeePolicy, error) {
+	ret := &root.FeePolicy{FeeColor: balance.ColorIOTA}
+	colorSet := false
+	for i, c := range calls {
+		rec, _ := root.FindContract(state, c.Contract)
+		policy := root.GetFeePolicy(state, rec, c.EntryPoint)
+		if policy.IsEmpty() {
+			continue
+		}
+		switch {
+		case !colorSet:
+			ret.FeeColor = policy.FeeColor
+			colorSet = true
+		case policy.FeeColor != ret.FeeColor:
+			return nil, fmt.Errorf("fees of the call #%d are charged in color %s, fees of previous calls in color %s",
+				i, policy.FeeColor.String(), ret.FeeColor.String())
+		}
+		ret.OwnerFee += policy.OwnerFee
+		ret.ValidatorFee += policy.ValidatorFee
+		if policy.FeePerByte > ret.FeePerByte {
+			ret.FeePerByte = policy.FeePerByte
+		}
+		if policy.FeePerKiloGas > ret.FeePerKiloGas {
+			ret.FeePerKiloGas = policy.FeePerKiloGas
+		}
+	}
+	return ret, nil
+}
+
+// multiCallContracts returns contracts called by the multi-call request, each one once
+func multiCallContracts(calls []*sctransaction.RequestCall) []coretypes.Hname {
+	ret := make([]coretypes.Hname, 0, len(calls))
+	seen := make(map[coretypes.Hname]bool)
+	for _, c := range calls {
+		if !seen[c.Contract] {
+			seen[c.Contract] = true
+			ret = append(ret, c.Contract)
+		}
+	}
+	return ret
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
 	"github.com/iotaledger/wasp/packages/kv/dict"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/vm"
@@ -27,22 +28,38 @@ type VMContext struct {
 	txBuilder    *statetxbuilder.Builder // mutated
 	virtualState state.VirtualState      // mutated
 	log          *logger.Logger
+	decryptor    vm.Decryptor
+	// block related
+	blockIndex    uint32            // index of the block being produced
+	requestIndex  uint16            // index of the current request in the block
+	prevStateHash hashing.HashValue // hash of the input state
+	stateTxID     valuetransaction.ID
 	// fee related
 	validatorFeeTarget coretypes.AgentID // provided by validator
-	feeColor           balance.Color
-	ownerFee           int64
-	validatorFee       int64
+	feeRefundPolicy    root.FeeRefundPolicy
+	feePolicy          *root.FeePolicy // fee policy of the target of the current request
+	feePolicyErr       error           // the fee policy of the current request can't be determined
+	feesCharged        int64           // fees charged for the current request
+	gasFeeReserved     int64           // gas fee reserved from the transfer of the current request
 	// request context
 	remainingAfterFees coretypes.ColoredBalances
 	entropy            hashing.HashValue // mutates with each request
 	reqRef             vm.RequestRefWithFreeTokens
+	requestID          coretypes.RequestID // ID of the request or of the scheduled call, see scheduler.CallRequestID
 	reqHname           coretypes.Hname
 	contractRecord     *root.ContractRecord
 	timestamp          int64
 	stateUpdate        state.StateUpdate
+	readWriteSet       *buffered.ReadWriteSet // keys read from the state and written by the current request
+	gasBudget          int64
+	gasBurned          int64
+	gasMetering        bool      // gas is only metered while the processor runs
 	lastError          error     // mutated
 	lastResult         dict.Dict // mutated. Used only by 'solo'
 	callStack          []*callContext
+	// speculative run of the request, see speculative.go
+	speculative     bool
+	speculativeCall *SpeculativeCall
 }
 
 type callContext struct {
@@ -56,14 +73,18 @@ type callContext struct {
 // NewVMContext a constructor
 func NewVMContext(task *vm.VMTask, txb *statetxbuilder.Builder) (*VMContext, error) {
 	ret := &VMContext{
-		processors:   task.Processors,
-		chainID:      task.ChainID,
-		balances:     task.Balances,
-		txBuilder:    txb,
-		virtualState: task.VirtualState.Clone(),
-		log:          task.Log,
-		entropy:      task.Entropy,
-		callStack:    make([]*callContext, 0),
+		processors:    task.Processors,
+		chainID:       task.ChainID,
+		balances:      task.Balances,
+		txBuilder:     txb,
+		virtualState:  task.VirtualState.CloneWithoutTree(),
+		log:           task.Log,
+		decryptor:     task.Decryptor,
+		blockIndex:    task.VirtualState.BlockIndex() + 1,
+		prevStateHash: task.VirtualState.Hash(),
+		stateTxID:     task.StateTransactionID,
+		entropy:       task.Entropy,
+		callStack:     make([]*callContext, 0),
 	}
 	return ret, nil
 }
@@ -71,3 +92,8 @@ func NewVMContext(task *vm.VMTask, txb *statetxbuilder.Builder) (*VMContext, err
 func (vmctx *VMContext) GetResult() (state.StateUpdate, dict.Dict, error) {
 	return vmctx.stateUpdate, vmctx.lastResult, vmctx.lastError
 }
+
+// ReadWriteSet returns keys of the state read and written by the last request
+func (vmctx *VMContext) ReadWriteSet() *buffered.ReadWriteSet {
+	return vmctx.readWriteSet
+}
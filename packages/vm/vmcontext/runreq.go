@@ -16,43 +16,79 @@ import (
 // runTheRequest:
 // - handles request token
 // - processes reward logic
-func (vmctx *VMContext) RunTheRequest(reqRef vm.RequestRefWithFreeTokens, timestamp int64) {
+// The result of the speculative call of the request is taken instead of making the call, if it is still valid. Can be nil
+func (vmctx *VMContext) RunTheRequest(reqRef vm.RequestRefWithFreeTokens, timestamp int64, spec *SpeculativeCall) {
 	vmctx.initRequestContext(reqRef, timestamp)
 	vmctx.mustHandleRequestToken()
 
+	feesCovered := true
 	if !vmctx.isInitChainRequest() {
 		vmctx.mustGetBaseValues()
-		vmctx.mustHandleFees()
+		feesCovered = vmctx.mustHandleFees()
 	}
 	vmctx.mustHandleFreeTokens()
 	defer vmctx.finalizeRequestCall()
 
+	if !feesCovered {
+		// not enough fees, the request is not run
+		vmctx.lastResult = nil
+		return
+	}
+	if vmctx.reqRef.RequestSection().IsMultiCall() {
+		if vmctx.speculative {
+			return
+		}
+		vmctx.runMultiCall()
+		return
+	}
 	if vmctx.contractRecord == nil {
 		// sc does not exist, stop here
 		vmctx.lastResult = nil
 		vmctx.lastError = fmt.Errorf("smart contract '%s' does not exist", vmctx.reqHname)
 		return
 	}
+	if err := vmctx.contractRecord.CheckActive(); err != nil {
+		// paused or removed contract: the request is refunded minus fees
+		vmctx.lastResult = nil
+		vmctx.lastError = err
+		vmctx.mustHandleFallback()
+		return
+	}
+	if vmctx.reqRef.ArgsRejected {
+		// blobs referenced by the arguments were not downloaded in time. The request is refunded minus fees
+		vmctx.lastResult = nil
+		vmctx.lastError = coretypes.ErrArgsNotSolid
+		vmctx.mustHandleFallback()
+		return
+	}
+	if vmctx.isRequestExpired() {
+		vmctx.lastResult = nil
+		vmctx.lastError = coretypes.ErrRequestTimeout
+		vmctx.mustHandleFallback()
+		return
+	}
+	if !vmctx.mustDeliverCallbackOnce() {
+		// the other callback request of the same request was delivered. The refund remains with the contract
+		vmctx.lastResult = nil
+		vmctx.lastError = nil
+		vmctx.creditToAccount(coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.chainID, vmctx.reqHname)), vmctx.remainingAfterFees)
+		return
+	}
 	// snapshot state baseline for rollback in case of panic
 	snapshotTxBuilder := vmctx.txBuilder.Clone()
 	snapshotStateUpdate := vmctx.stateUpdate.Clone()
 
 	vmctx.lastError = nil
-	func() {
-		// panic catcher for the whole call from request to the VM
-		defer func() {
-			if r := recover(); r != nil {
-				vmctx.lastResult = nil
-				vmctx.lastError = fmt.Errorf("recovered from panic in VM: %v", r)
-				if dberr, ok := r.(buffered.DBError); ok {
-					// There was an error accessing the DB
-					// The world stops
-					vmctx.Panicf("DB error: %v", dberr)
-				}
-			}
-		}()
-		vmctx.mustCallFromRequest()
-	}()
+	// the transfer is accrued to the target contract before the call, so the call doesn't depend on the
+	// ledger changes made by other requests. It is not metered, same as the request token and fees
+	vmctx.creditToAccount(coretypes.NewAgentIDFromContractID(coretypes.NewContractID(vmctx.chainID, vmctx.reqHname)), vmctx.remainingAfterFees)
+	if vmctx.speculative {
+		vmctx.makeSpeculativeCall()
+		return
+	}
+	if !vmctx.takeSpeculativeCall(spec) {
+		vmctx.callCatchingPanic(vmctx.mustCallFromRequest)
+	}
 
 	if vmctx.lastError != nil {
 		// treating panic and error returned from request the same way
@@ -63,6 +99,32 @@ func (vmctx *VMContext) RunTheRequest(reqRef vm.RequestRefWithFreeTokens, timest
 	}
 }
 
+// callCatchingPanic runs the call from the request to the VM with gas metering.
+// The panic is turned into the error of the request
+func (vmctx *VMContext) callCatchingPanic(f func()) {
+	defer func() {
+		vmctx.gasMetering = false
+		if r := recover(); r != nil {
+			if r == errSpeculationAborted {
+				panic(r)
+			}
+			vmctx.lastResult = nil
+			vmctx.lastError = fmt.Errorf("recovered from panic in VM: %v", r)
+			if r == coretypes.ErrGasBudgetExceeded {
+				// the budget was exhausted. The request is aborted but fees are charged
+				vmctx.lastError = coretypes.ErrGasBudgetExceeded
+			}
+			if dberr, ok := r.(buffered.DBError); ok {
+				// There was an error accessing the DB
+				// The world stops
+				vmctx.Panicf("DB error: %v", dberr)
+			}
+		}
+	}()
+	vmctx.gasMetering = true
+	f()
+}
+
 // mustHandleRequestToken handles the request token
 // it will panic on inconsistency because consistency of the request token must be checked well before
 func (vmctx *VMContext) mustHandleRequestToken() {
@@ -82,46 +144,117 @@ func (vmctx *VMContext) mustHandleRequestToken() {
 	vmctx.log.Debugf("mustHandleFees: 1 request token accrued to the sender: %s\n", vmctx.reqRef.SenderAgentID())
 }
 
-// mustHandleFees:
-// - handles request token
-// - handles node fee, including fallback if not enough
-func (vmctx *VMContext) mustHandleFees() {
+// mustHandleFees charges fees according to the fee policy of the target entry point:
+// - the fixed fee and the payload fee are charged upfront
+// - the gas fee for the gas budget is reserved upfront and settled by mustSettleGasFee after the request is run.
+//   If the transfer doesn't cover the gas fee for the whole budget, the budget is reduced
+// It returns false if the transfer doesn't cover the fee or the fee policy of the request can't be determined.
+// Then the request must not be run
+func (vmctx *VMContext) mustHandleFees() bool {
 	transfer := vmctx.reqRef.RequestSection().Transfer()
-	totalFee := vmctx.ownerFee + vmctx.validatorFee
-	if totalFee == 0 || vmctx.requesterIsChainOwner() {
-		// no fees enabled or the caller is the chain owner
+	vmctx.remainingAfterFees = transfer
+	if vmctx.feePolicyErr != nil && !vmctx.requesterIsFeeExempt() {
+		// e.g. calls of the multi-call request charge fees in different colors. Everything is accrued to the sender
+		sender := vmctx.reqRef.SenderAgentID()
+		vmctx.creditToAccount(sender, transfer)
+		vmctx.lastError = fmt.Errorf("mustHandleFees: %v. Transfer accrued to %s", vmctx.feePolicyErr, sender.String())
+		vmctx.remainingAfterFees = cbalances.NewFromMap(nil)
+		return false
+	}
+	policy := vmctx.feePolicy
+	fee := policy.FixedFee() + policy.PayloadFee(vmctx.reqRef.RequestSection().ArgsSize())
+	if fee == 0 && policy.FeePerKiloGas == 0 || vmctx.requesterIsFeeExempt() || vmctx.isSchedulerTick() {
+		// no fees enabled, the sender is exempt from fees or the request is the tick of the scheduler
 		vmctx.log.Debugf("mustHandleFees: no fees charged\n")
-		vmctx.remainingAfterFees = transfer
-		return
+		return true
 	}
-	// handle fees
-	if transfer.Balance(vmctx.feeColor) < totalFee {
-		// TODO more sophisticated policy, for example taking fees to chain owner, the rest returned to sender
-		// fallback: not enough fees. Accrue everything to the sender
-		sender := vmctx.reqRef.SenderAgentID()
+	available := transfer.Balance(policy.FeeColor)
+	if available < fee {
+		vmctx.mustHandleFeeShortfall(fee, available)
+		return false
+	}
+	gasFee := policy.GasFee(vmctx.gasBudget)
+	if gasFee > available-fee {
+		gasFee = available - fee
+		if covered := policy.GasCovered(gasFee); covered < vmctx.gasBudget {
+			vmctx.gasBudget = covered
+		}
+	}
+	// the owner fee goes to the chain owner, the rest to the validator
+	vmctx.creditFees(policy.OwnerFee, fee-policy.OwnerFee)
+	vmctx.feesCharged = fee
+	vmctx.gasFeeReserved = gasFee
+	// subtract fees from the transfer
+	remaining := map[balance.Color]int64{
+		policy.FeeColor: -fee - gasFee,
+	}
+	transfer.AddToMap(remaining)
+	vmctx.remainingAfterFees = cbalances.NewFromMap(remaining)
+	return true
+}
+
+// mustHandleFeeShortfall handles the request which doesn't transfer enough tokens to cover the fee
+// according to the fee refund policy of the chain
+func (vmctx *VMContext) mustHandleFeeShortfall(fee, available int64) {
+	sender := vmctx.reqRef.SenderAgentID()
+	transfer := vmctx.reqRef.RequestSection().Transfer()
+	if vmctx.feeRefundPolicy != root.FeeRefundPartial || available == 0 {
+		// accrue everything to the sender
 		vmctx.creditToAccount(sender, transfer)
 		vmctx.lastError = fmt.Errorf("mustHandleFees: not enough fees for request %s. Transfer accrued to %s",
 			vmctx.reqRef.RequestID().Short(), sender.String())
 		vmctx.remainingAfterFees = cbalances.NewFromMap(nil)
 		return
 	}
-	// enough fees. Split between owner and validator
-	if vmctx.ownerFee > 0 {
+	// charge the fee tokens, the chain owner first. Refund the rest
+	ownerFee := vmctx.feePolicy.OwnerFee
+	if ownerFee > available {
+		ownerFee = available
+	}
+	vmctx.creditFees(ownerFee, available-ownerFee)
+	vmctx.feesCharged = available
+	remaining := map[balance.Color]int64{
+		vmctx.feePolicy.FeeColor: -available,
+	}
+	transfer.AddToMap(remaining)
+	vmctx.remainingAfterFees = cbalances.NewFromMap(remaining)
+	vmctx.lastError = fmt.Errorf("mustHandleFees: not enough fees for request %s. Charged %d of %d, the rest is refunded to %s",
+		vmctx.reqRef.RequestID().Short(), available, fee, sender.String())
+	vmctx.mustHandleFallback()
+}
+
+// mustSettleGasFee charges the gas fee for the gas burned by the request from the reserved gas fee.
+// The rest of the reserve is accrued to the sender on-chain
+func (vmctx *VMContext) mustSettleGasFee() {
+	if vmctx.gasFeeReserved == 0 {
+		return
+	}
+	fee := vmctx.feePolicy.GasFee(vmctx.gasBurned)
+	if fee > vmctx.gasFeeReserved {
+		fee = vmctx.gasFeeReserved
+	}
+	vmctx.creditFees(0, fee)
+	if vmctx.gasFeeReserved > fee {
+		vmctx.creditToAccount(vmctx.reqRef.SenderAgentID(), cbalances.NewFromMap(map[balance.Color]int64{
+			vmctx.feePolicy.FeeColor: vmctx.gasFeeReserved - fee,
+		}))
+	}
+	vmctx.feesCharged += fee
+	vmctx.gasFeeReserved = 0
+}
+
+// creditFees credits fees to the chain owner and to the validator
+func (vmctx *VMContext) creditFees(ownerFee, validatorFee int64) {
+	if ownerFee > 0 {
 		vmctx.creditToAccount(vmctx.ChainOwnerID(), cbalances.NewFromMap(map[balance.Color]int64{
-			vmctx.feeColor: vmctx.ownerFee,
+			vmctx.feePolicy.FeeColor: ownerFee,
 		}))
 	}
-	if vmctx.validatorFee > 0 {
+	if validatorFee > 0 {
 		vmctx.creditToAccount(vmctx.validatorFeeTarget, cbalances.NewFromMap(map[balance.Color]int64{
-			vmctx.feeColor: vmctx.validatorFee,
+			vmctx.feePolicy.FeeColor: validatorFee,
 		}))
 	}
-	// subtract fees from the transfer
-	remaining := map[balance.Color]int64{
-		vmctx.feeColor: -totalFee,
-	}
-	transfer.AddToMap(remaining)
-	vmctx.remainingAfterFees = cbalances.NewFromMap(remaining)
 }
 
 // mustHandleFreeTokens free tokens accrued to the chain owner
@@ -133,9 +266,17 @@ func (vmctx *VMContext) mustHandleFreeTokens() {
 }
 
 // mustHandleFallback all remaining tokens are:
+// -- if the request has the callback, refunded to the sender with the callback request
 // -- if sender is address, sent to that address
 // -- otherwise accrue to the sender on-chain
 func (vmctx *VMContext) mustHandleFallback() {
+	if vmctx.hasCallback() {
+		return
+	}
+	vmctx.refundRemaining()
+}
+
+func (vmctx *VMContext) refundRemaining() {
 	sender := vmctx.reqRef.SenderAgentID()
 	if sender.IsAddress() {
 		err := vmctx.txBuilder.TransferToAddress(sender.MustAddress(), vmctx.remainingAfterFees)
@@ -152,14 +293,26 @@ func (vmctx *VMContext) mustCallFromRequest() {
 	req := vmctx.reqRef.RequestSection()
 	vmctx.log.Debugf("mustCallFromRequest: %s -- %s\n", vmctx.reqRef.RequestID().String(), req.String())
 
+	args, err := vmctx.requestArgs()
+	if err != nil {
+		vmctx.lastResult = nil
+		vmctx.lastError = err
+		return
+	}
 	// calling only non vew entry points. Calling the view will trigger error and fallback
 	vmctx.lastResult, vmctx.lastError = vmctx.callNonViewByProgramHash(
-		vmctx.reqHname, req.EntryPointCode(), req.SolidArgs(), vmctx.remainingAfterFees, vmctx.contractRecord.ProgramHash)
+		vmctx.reqHname, req.EntryPointCode(), vmctx.callbackArgs(args), vmctx.remainingAfterFees, vmctx.contractRecord.ProgramHash)
 }
 
 func (vmctx *VMContext) finalizeRequestCall() {
+	if vmctx.speculative {
+		// only the call is run speculatively
+		return
+	}
+	vmctx.mustSettleGasFee()
+	vmctx.postCallbackRequest()
 	vmctx.mustRequestToEventLog(vmctx.lastError)
-	vmctx.virtualState.ApplyStateUpdate(vmctx.stateUpdate)
+	vmctx.finalizeBlockEntry()
 
 	vmctx.log.Debugw("runTheRequest OUT",
 		"reqId", vmctx.reqRef.RequestID().Short(),
@@ -167,6 +320,18 @@ func (vmctx *VMContext) finalizeRequestCall() {
 	)
 }
 
+// finalizeBlockEntry saves the receipt of the entry of the block, the request or the scheduled call,
+// and applies its state update to the state of the block
+func (vmctx *VMContext) finalizeBlockEntry() {
+	vmctx.saveReceipt()
+	vmctx.saveToBlockLog()
+	vmctx.mustPostSchedulerTick()
+	vmctx.virtualState.Variables().TrackReads(nil)
+	vmctx.readWriteSet.AddWrites(vmctx.stateUpdate.Mutations())
+	vmctx.virtualState.ApplyStateUpdate(vmctx.stateUpdate)
+	vmctx.requestIndex++
+}
+
 func (vmctx *VMContext) mustRequestToEventLog(err error) {
 	if err != nil {
 		vmctx.log.Error(err)
@@ -175,8 +340,14 @@ func (vmctx *VMContext) mustRequestToEventLog(err error) {
 	if err != nil {
 		e = err.Error()
 	}
-	msg := fmt.Sprintf("[req] %s: %s", vmctx.reqRef.RequestID().String(), e)
+	msg := fmt.Sprintf("[req] %s: %s. Gas burned: %d", vmctx.reqRef.RequestID().String(), e, vmctx.gasBurned)
 	vmctx.log.Infof("eventlog -> '%s'", msg)
+	if req := vmctx.reqRef.RequestSection(); req.IsMultiCall() {
+		for _, contract := range multiCallContracts(req.Calls()) {
+			vmctx.StoreToEventLog(contract, []byte(msg))
+		}
+		return
+	}
 	vmctx.StoreToEventLog(vmctx.reqHname, []byte(msg))
 }
 
@@ -187,22 +358,38 @@ func (vmctx *VMContext) mustGetBaseValues() {
 		vmctx.log.Panicf("initRequestContext: major inconsistency of chainID")
 	}
 	vmctx.chainOwnerID = info.ChainOwnerID
-	vmctx.feeColor, vmctx.ownerFee, vmctx.validatorFee = vmctx.getFeeInfo()
+	policy, refundPolicy, err := vmctx.getFeePolicy()
+	if err != nil {
+		// no fees are charged by the policy, the request is rejected by mustHandleFees
+		policy = &root.FeePolicy{FeeColor: balance.ColorIOTA}
+	}
+	vmctx.feePolicy, vmctx.feeRefundPolicy, vmctx.feePolicyErr = policy, refundPolicy, err
 }
 
 // initRequestContext initializes VMContext for request and returns  if contract exists
 func (vmctx *VMContext) initRequestContext(reqRef vm.RequestRefWithFreeTokens, timestamp int64) {
-	reqHname := reqRef.RequestSection().Target().Hname()
 	vmctx.reqRef = reqRef
-	vmctx.reqHname = reqHname
+	vmctx.reqHname = reqRef.RequestSection().Target().Hname()
+	vmctx.initBlockEntryContext(*reqRef.RequestID(), timestamp, reqRef.RequestSection().GasBudget())
+	vmctx.contractRecord, _ = vmctx.findContractByHname(vmctx.reqHname)
+}
 
+// initBlockEntryContext initializes VMContext for the entry of the block: the request or the scheduled call
+func (vmctx *VMContext) initBlockEntryContext(requestID coretypes.RequestID, timestamp int64, gasBudget int64) {
+	vmctx.requestID = requestID
 	vmctx.timestamp = timestamp
-	vmctx.stateUpdate = state.NewStateUpdate(reqRef.RequestID()).WithTimestamp(timestamp)
+	vmctx.stateUpdate = state.NewStateUpdate(&requestID).WithTimestamp(timestamp)
+	// reads of the state which are not served by the mutations of the request itself
+	vmctx.readWriteSet = buffered.NewReadWriteSet()
+	vmctx.virtualState.Variables().TrackReads(vmctx.readWriteSet.Reads)
 	vmctx.callStack = vmctx.callStack[:0]
 	vmctx.entropy = hashing.HashData(vmctx.entropy[:])
 	vmctx.remainingAfterFees = cbalances.NewFromMap(nil)
-
-	vmctx.contractRecord, _ = vmctx.findContractByHname(vmctx.reqHname)
+	vmctx.feePolicy = &root.FeePolicy{FeeColor: balance.ColorIOTA}
+	vmctx.feePolicyErr = nil
+	vmctx.feesCharged = 0
+	vmctx.gasFeeReserved = 0
+	vmctx.initGasBudget(gasBudget)
 }
 
 func (vmctx *VMContext) isInitChainRequest() bool {
@@ -210,9 +397,12 @@ func (vmctx *VMContext) isInitChainRequest() bool {
 	return s.Target().Hname() == root.Interface.Hname() && s.EntryPointCode() == coretypes.EntryPointInit
 }
 
-func (vmctx *VMContext) FinalizeTransactionEssence(blockIndex uint32, stateHash hashing.HashValue, timestamp int64) (*sctransaction.Transaction, error) {
+func (vmctx *VMContext) FinalizeTransactionEssence(blockIndex uint32, stateHash, stateRoot hashing.HashValue, timestamp int64) (*sctransaction.Transaction, error) {
+	if err := vmctx.moveChainIfRotated(); err != nil {
+		return nil, err
+	}
 	// add state block
-	err := vmctx.txBuilder.SetStateParams(blockIndex, stateHash, timestamp)
+	err := vmctx.txBuilder.SetStateParams(blockIndex, stateHash, stateRoot, timestamp)
 	if err != nil {
 		return nil, err
 	}
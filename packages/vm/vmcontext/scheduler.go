@@ -0,0 +1,216 @@
+package vmcontext
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/kv/subrealm"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/core/scheduler"
+)
+
+// Scheduled calls.
+// The calls registered in the 'scheduler' contract which are due at the timestamp of the block are made
+// by the VM on behalf of the scheduler before the requests of the block. Each call is a separate entry of the block,
+// same as the request: it has its own state update, its effects are rolled back if the call fails
+// and it has its own receipt and record in the block log under the ID returned by scheduler.CallRequestID.
+// After each entry the VM makes sure there is the time-locked 'tick' request to the scheduler,
+// which unlocks when the next call is due. It makes the leader produce the block even if there are
+// no other requests.
+// The scheduler keeps the time of the earliest call, so the checks after each entry read one value.
+// Schedules are only decoded when they are due. The bookkeeping of each call is charged as GasPerScheduledCall
+// to the funding account, together with the fees and the gas of the call
+
+// DueScheduledCalls returns the scheduled calls which are due at the timestamp of the block.
+// It is called before the first entry of the block, so the state of the scheduler is read from the virtual state
+func (vmctx *VMContext) DueScheduledCalls(timestamp int64) []*scheduler.Schedule {
+	schedulerState := subrealm.New(vmctx.virtualState.Variables(), kv.Key(scheduler.Interface.Hname().Bytes()))
+	due, err := scheduler.DueSchedules(schedulerState, timestamp/int64(time.Second))
+	if err != nil {
+		vmctx.log.Panicf("DueScheduledCalls: %v", err)
+	}
+	return due
+}
+
+// RunScheduledCall makes the scheduled call as the next entry of the block.
+// The result of the call, including the call which couldn't be made, is recorded in the receipt of the call
+// and in the event log of the scheduler
+func (vmctx *VMContext) RunScheduledCall(s *scheduler.Schedule, timestamp int64) {
+	vmctx.reqRef = vm.RequestRefWithFreeTokens{}
+	vmctx.reqHname = s.Contract
+	vmctx.initBlockEntryContext(scheduler.CallRequestID(&vmctx.chainID, s), timestamp, DefaultGasBudget)
+	vmctx.contractRecord, _ = vmctx.findContractByHname(s.Contract)
+	vmctx.chainOwnerID = vmctx.mustGetChainInfo().ChainOwnerID
+
+	vmctx.pushCallContext(scheduler.Interface.Hname(), nil, nil)
+	prevRun := s.NextRun
+	ran := vmctx.runScheduledCall(s)
+	errStr := ""
+	if vmctx.lastError != nil {
+		errStr = vmctx.lastError.Error()
+	}
+	s.Done(ran, errStr, timestamp/int64(time.Second))
+	scheduler.SaveSchedule(vmctx.State(), s, prevRun)
+
+	var msg string
+	if ran {
+		e := "Ok"
+		if vmctx.lastError != nil {
+			e = errStr
+		}
+		msg = fmt.Sprintf("[scheduled call] id: %d, call: %s::%s: %s. Gas burned: %d", s.ID, s.Contract, s.EntryPoint, e, vmctx.gasBurned)
+	} else {
+		msg = fmt.Sprintf("[scheduled call missed] id: %d, call: %s::%s: %s. Missed in a row: %d", s.ID, s.Contract, s.EntryPoint, errStr, s.Missed)
+	}
+	if s.IsFinished() {
+		msg += fmt.Sprintf(". Schedule finished after %d calls", s.Runs)
+	}
+	vmctx.log.Infof("eventlog -> '%s'", msg)
+	vmctx.StoreToEventLog(scheduler.Interface.Hname(), []byte(msg))
+	vmctx.popCallContext()
+
+	vmctx.finalizeBlockEntry()
+}
+
+// runScheduledCall charges fees from the funding account and makes the call.
+// It returns false if the call wasn't made. The result of the call is in lastResult and lastError
+func (vmctx *VMContext) runScheduledCall(s *scheduler.Schedule) bool {
+	vmctx.lastResult = nil
+	if vmctx.contractRecord == nil {
+		vmctx.lastError = ErrContractNotFound
+		return false
+	}
+	if vmctx.lastError = vmctx.contractRecord.CheckActive(); vmctx.lastError != nil {
+		return false
+	}
+	policy, ok := vmctx.chargeScheduledCallFees(s, vmctx.contractRecord)
+	if !ok {
+		vmctx.lastError = ErrNotEnoughFees
+		return false
+	}
+	// snapshot state baseline for rollback in case of panic
+	snapshotTxBuilder := vmctx.txBuilder.Clone()
+	snapshotStateUpdate := vmctx.stateUpdate.Clone()
+
+	func() {
+		defer func() {
+			vmctx.gasMetering = false
+			if r := recover(); r != nil {
+				vmctx.lastResult = nil
+				vmctx.lastError = fmt.Errorf("recovered from panic in VM: %v", r)
+				if r == coretypes.ErrGasBudgetExceeded {
+					vmctx.lastError = coretypes.ErrGasBudgetExceeded
+				}
+				if dberr, ok := r.(buffered.DBError); ok {
+					vmctx.Panicf("DB error: %v", dberr)
+				}
+			}
+		}()
+		vmctx.gasMetering = true
+		vmctx.GasBurn(GasPerScheduledCall)
+		vmctx.lastResult, vmctx.lastError = vmctx.Call(s.Contract, s.EntryPoint, s.Params, nil)
+	}()
+
+	if vmctx.lastError != nil {
+		vmctx.txBuilder = snapshotTxBuilder
+		vmctx.stateUpdate = snapshotStateUpdate
+	}
+	// the gas fee is charged in any case
+	if policy != nil {
+		if fee := policy.GasFee(vmctx.gasBurned); fee > 0 {
+			vmctx.moveBetweenAccounts(s.Funding, vmctx.validatorFeeTarget, cbalances.NewFromMap(map[balance.Color]int64{
+				policy.FeeColor: fee,
+			}))
+			vmctx.feesCharged += fee
+		}
+	}
+	return true
+}
+
+// chargeScheduledCallFees charges the fixed and the payload fee of the scheduled call from the funding account
+// and limits the gas budget by the gas fee the funding account can cover.
+// It returns the fee policy or nil if the funding account is exempt from fees
+func (vmctx *VMContext) chargeScheduledCallFees(s *scheduler.Schedule, rec *root.ContractRecord) (*root.FeePolicy, bool) {
+	policy, exempt := vmctx.scheduledCallFeePolicy(s, rec)
+	if exempt {
+		return nil, true
+	}
+	fee := policy.FixedFee() + policy.PayloadFee(len(util.MustBytes(s.Params)))
+	available := vmctx.getAccountBalances(s.Funding).Balance(policy.FeeColor)
+	if available < fee {
+		return nil, false
+	}
+	if policy.GasFee(vmctx.gasBudget) > available-fee {
+		if covered := policy.GasCovered(available - fee); covered < vmctx.gasBudget {
+			vmctx.gasBudget = covered
+		}
+	}
+	if policy.OwnerFee > 0 {
+		vmctx.moveBetweenAccounts(s.Funding, vmctx.ChainOwnerID(), cbalances.NewFromMap(map[balance.Color]int64{
+			policy.FeeColor: policy.OwnerFee,
+		}))
+	}
+	if fee > policy.OwnerFee {
+		vmctx.moveBetweenAccounts(s.Funding, vmctx.validatorFeeTarget, cbalances.NewFromMap(map[balance.Color]int64{
+			policy.FeeColor: fee - policy.OwnerFee,
+		}))
+	}
+	vmctx.feePolicy = policy
+	vmctx.feesCharged = fee
+	return policy, true
+}
+
+func (vmctx *VMContext) scheduledCallFeePolicy(s *scheduler.Schedule, rec *root.ContractRecord) (*root.FeePolicy, bool) {
+	vmctx.pushCallContext(root.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	return root.GetFeePolicy(vmctx.State(), rec, s.EntryPoint), root.IsFeeExempt(vmctx.State(), s.Funding)
+}
+
+// mustPostSchedulerTick posts the time-locked 'tick' request to the scheduler if the next scheduled call
+// is due before the pending tick request unlocks. The request token is paid by the scheduler or, if
+// it has no funds, by the funding account of the next call. The token returns to the scheduler
+// when the tick request is processed
+func (vmctx *VMContext) mustPostSchedulerTick() {
+	vmctx.pushCallContext(scheduler.Interface.Hname(), nil, nil)
+	defer vmctx.popCallContext()
+
+	nextRun := scheduler.NextRunTime(vmctx.State())
+	if nextRun == 0 {
+		return
+	}
+	if tick := scheduler.GetTick(vmctx.State()); tick != 0 && tick <= nextRun {
+		return
+	}
+	next, err := scheduler.NextRun(vmctx.State())
+	if err != nil || next == nil {
+		vmctx.log.Panicf("mustPostSchedulerTick: inconsistent schedules: %v", err)
+	}
+	requestToken := cbalances.NewFromMap(map[balance.Color]int64{balance.ColorIOTA: 1})
+	if !vmctx.debitFromAccount(vmctx.MyAgentID(), requestToken) && !vmctx.debitFromAccount(next.Funding, requestToken) {
+		vmctx.log.Warnf("mustPostSchedulerTick: not enough funds for the request token of the tick request")
+		return
+	}
+	section := sctransaction.NewRequestSection(scheduler.Interface.Hname(), vmctx.CurrentContractID(), coretypes.Hn(scheduler.FuncTick)).
+		WithTimelock(next.NextRun)
+	if err := vmctx.txBuilder.AddRequestSection(section); err != nil {
+		vmctx.log.Panicf("mustPostSchedulerTick: %v", err)
+	}
+	scheduler.SetTick(vmctx.State(), next.NextRun)
+}
+
+// isSchedulerTick returns true if the current request is the tick request posted by the VM
+func (vmctx *VMContext) isSchedulerTick() bool {
+	s := vmctx.reqRef.RequestSection()
+	sender, err := vmctx.reqRef.SenderContractID()
+	return err == nil && sender == coretypes.NewContractID(vmctx.chainID, scheduler.Interface.Hname()) &&
+		s.Target().Hname() == scheduler.Interface.Hname() && s.EntryPointCode() == coretypes.Hn(scheduler.FuncTick)
+}
@@ -0,0 +1,233 @@
+package vmcontext
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/cbalances"
+	"github.com/iotaledger/wasp/packages/coretypes/coreutil"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/vm"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/packages/vm/statetxbuilder"
+)
+
+// Speculative calls.
+// Requests of the batch may be run speculatively in parallel, each one on the input state of the batch.
+// Only the call of the request to the target contract is speculative. Request tokens, fees, callbacks,
+// receipts and logs change the same keys for all requests, so they are always handled by the sequential run.
+// When the sequential run comes to the call of the request, it takes the result of the speculative call
+// instead of making the call if the call would see exactly the same: the same context of the call and the same
+// values of all keys and prefixes read by the speculative call. Otherwise the call conflicts with
+// the preceding requests and it is made again. So the result is always the same as if requests were run one by one.
+// The speculative run is aborted if the call uses the processor which can't be run concurrently,
+// the transaction being built or the history of the state.
+// Only native processors (core and native contracts) are run speculatively. The Wasm processor keeps the sandbox
+// of the current call in the instance shared by all calls of the contract, so calls of Wasm contracts, including
+// calls to them from native contracts, are always made by the sequential run
+
+var errSpeculationAborted = errors.New("speculative run aborted")
+
+// SpeculativeCall is the result of the call of the request made on the input state of the batch
+type SpeculativeCall struct {
+	context   hashing.HashValue         // hash of everything the call depends on except the state
+	reads     map[kv.Key][]byte         // values of keys read by the call
+	prefixes  map[kv.Key]dict.Dict      // all key/values with the prefixes iterated by the call
+	mutations buffered.MutationSequence // mutations made by the call
+	result    dict.Dict
+	err       error
+	gasBurned int64
+	events    []heldEvent // events are published only when the result is taken
+	taken     bool
+}
+
+type heldEvent struct {
+	contractID coretypes.ContractID
+	msg        string
+}
+
+// RunSpeculatively runs the request with the index in the batch on the input state of the batch.
+// Entry index is the index of the request in the block, entropy is the entropy of the VM before the request,
+// timestamp is the timestamp of the request.
+// It returns nil if the call of the request was not made or the run was aborted
+func RunSpeculatively(task *vm.VMTask, txb *statetxbuilder.Builder, reqIndex int, entryIndex uint16, entropy hashing.HashValue, timestamp int64) (ret *SpeculativeCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			// the request will be run sequentially
+			ret = nil
+		}
+	}()
+	vmctx, err := NewVMContext(task, txb.Clone())
+	if err != nil {
+		return nil
+	}
+	vmctx.log = task.Log.Named("spec")
+	vmctx.speculative = true
+	vmctx.requestIndex = entryIndex
+	vmctx.entropy = entropy
+	vmctx.RunTheRequest(task.Requests[reqIndex], timestamp, nil)
+	return vmctx.speculativeCall
+}
+
+// abortIfSpeculative aborts the speculative run. It is called by operations which can't be run speculatively
+func (vmctx *VMContext) abortIfSpeculative() {
+	if vmctx.speculative {
+		panic(errSpeculationAborted)
+	}
+}
+
+// checkSpeculativeProcessor aborts the speculative run if the processor may not be run concurrently.
+// Only native processors are stateless, Wasm processors are not supported
+func (vmctx *VMContext) checkSpeculativeProcessor(proc coretypes.Processor) {
+	if _, ok := proc.(*coreutil.ContractInterface); !ok {
+		vmctx.abortIfSpeculative()
+	}
+}
+
+// PublishEvent publishes the event of the current contract. The event of the speculative call is held
+// until the result of the call is taken by the sequential run
+func (vmctx *VMContext) PublishEvent(msg string) {
+	if !vmctx.speculative {
+		vmctx.EventPublisher().Publish(msg)
+		return
+	}
+	if vmctx.speculativeCall != nil {
+		vmctx.speculativeCall.events = append(vmctx.speculativeCall.events, heldEvent{
+			contractID: vmctx.CurrentContractID(),
+			msg:        msg,
+		})
+	}
+}
+
+// makeSpeculativeCall makes the call of the request and records its result together with
+// everything the call has read from the state
+func (vmctx *VMContext) makeSpeculativeCall() {
+	// the state before the call is kept in the virtual state, so all reads of it are tracked
+	vars := vmctx.virtualState.Variables()
+	vars.TrackReads(nil)
+	vmctx.virtualState.ApplyStateUpdate(vmctx.stateUpdate)
+	vmctx.stateUpdate = state.NewStateUpdate(vmctx.reqRef.RequestID()).WithTimestamp(vmctx.timestamp)
+
+	spec := &SpeculativeCall{context: vmctx.callContextHash()}
+	vmctx.speculativeCall = spec
+	gasBurnedBefore := vmctx.gasBurned
+	reads := buffered.NewKeySet()
+	vars.TrackReads(reads)
+	vmctx.callCatchingPanic(vmctx.mustCallFromRequest)
+	vars.TrackReads(nil)
+
+	spec.mutations = vmctx.stateUpdate.Mutations()
+	spec.result = vmctx.lastResult
+	spec.err = vmctx.lastError
+	spec.gasBurned = vmctx.gasBurned - gasBurnedBefore
+	spec.reads = make(map[kv.Key][]byte)
+	for _, key := range reads.Keys() {
+		spec.reads[key] = vars.MustGet(key)
+	}
+	spec.prefixes = make(map[kv.Key]dict.Dict)
+	for _, prefix := range reads.Prefixes() {
+		values := dict.New()
+		vars.MustIterate(prefix, func(key kv.Key, value []byte) bool {
+			values.Set(key, value)
+			return true
+		})
+		spec.prefixes[prefix] = values
+	}
+}
+
+// takeSpeculativeCall takes the result of the speculative call instead of making the call.
+// It returns false if the call must be made because its context or the state it reads are not the same
+func (vmctx *VMContext) takeSpeculativeCall(spec *SpeculativeCall) bool {
+	if spec == nil || spec.context != vmctx.callContextHash() || !vmctx.readsSameState(spec) {
+		return false
+	}
+	if spec.err == nil {
+		spec.mutations.Iterate(func(mut buffered.Mutation) bool {
+			vmctx.stateUpdate.Mutations().Add(mut)
+			return true
+		})
+	}
+	vmctx.lastResult = spec.result
+	vmctx.lastError = spec.err
+	vmctx.gasBurned += spec.gasBurned
+	for _, e := range spec.events {
+		vm.NewContractEventPublisher(e.contractID, vmctx.log).Publish(e.msg)
+	}
+	spec.taken = true
+	return true
+}
+
+// Taken returns true if the result of the speculative call was taken by the sequential run
+func (spec *SpeculativeCall) Taken() bool {
+	return spec != nil && spec.taken
+}
+
+// callContextHash is the hash of the values, other than the state, which the call of the request depends on
+func (vmctx *VMContext) callContextHash() hashing.HashValue {
+	var buf bytes.Buffer
+	buf.Write(root.EncodeContractRecord(vmctx.contractRecord))
+	buf.Write(vmctx.chainOwnerID[:])
+	_ = cbalances.WriteColoredBalances(&buf, vmctx.remainingAfterFees)
+	_ = util.WriteInt64(&buf, vmctx.GasBudget())
+	buf.Write(vmctx.entropy[:])
+	_ = util.WriteInt64(&buf, vmctx.timestamp)
+	_ = util.WriteUint16(&buf, vmctx.requestIndex)
+	return hashing.HashData(buf.Bytes())
+}
+
+// readsSameState checks if keys and prefixes read by the speculative call have the same values
+// in the state the call would be made on now
+func (vmctx *VMContext) readsSameState(spec *SpeculativeCall) bool {
+	for key, value := range spec.reads {
+		if !sameValue(vmctx.currentValue(key), value) {
+			return false
+		}
+	}
+	for prefix, values := range spec.prefixes {
+		current := vmctx.currentValues(prefix)
+		if len(current) != len(values) {
+			return false
+		}
+		for key, value := range current {
+			if v, ok := values[key]; !ok || !sameValue(value, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// currentValue is the value of the key as seen by the call
+func (vmctx *VMContext) currentValue(key kv.Key) []byte {
+	if mut := vmctx.stateUpdate.Mutations().Latest(key); mut != nil {
+		return mut.Value()
+	}
+	return vmctx.virtualState.Variables().MustGet(key)
+}
+
+// currentValues are all key/values with the prefix as seen by the call
+func (vmctx *VMContext) currentValues(prefix kv.Key) dict.Dict {
+	mutations := vmctx.stateUpdate.Mutations()
+	ret := dict.New()
+	vmctx.virtualState.Variables().MustIterate(prefix, func(key kv.Key, value []byte) bool {
+		if mutations.Latest(key) == nil {
+			ret.Set(key, value)
+		}
+		return true
+	})
+	mutations.IterateValues(prefix, func(key kv.Key, value []byte) bool {
+		ret.Set(key, value)
+		return true
+	})
+	return ret
+}
+
+func sameValue(v1, v2 []byte) bool {
+	return (v1 == nil) == (v2 == nil) && bytes.Equal(v1, v2)
+}
@@ -12,6 +12,7 @@ type stateWrapper struct {
 	contractSubPartitionPrefix kv.Key
 	virtualState               state.VirtualState
 	stateUpdate                state.StateUpdate
+	gasBurn                    func(gas int64)
 }
 
 func newStateWrapper(contractHname coretypes.Hname, virtualState state.VirtualState, stateUpdate state.StateUpdate) stateWrapper {
@@ -27,15 +28,24 @@ func (s *stateWrapper) addContractSubPartition(key kv.Key) kv.Key {
 	return s.contractSubPartitionPrefix + key
 }
 
+func (s *stateWrapper) burn(gas int64) {
+	if s.gasBurn != nil {
+		s.gasBurn(gas)
+	}
+}
+
 func (vmctx *VMContext) stateWrapper() stateWrapper {
-	return newStateWrapper(
+	ret := newStateWrapper(
 		vmctx.CurrentContractHname(),
 		vmctx.virtualState,
 		vmctx.stateUpdate,
 	)
+	ret.gasBurn = vmctx.GasBurn
+	return ret
 }
 
 func (s stateWrapper) Has(name kv.Key) (bool, error) {
+	s.burn(GasPerStateRead)
 	name = s.addContractSubPartition(name)
 	mut := s.stateUpdate.Mutations().Latest(name)
 	if mut != nil {
@@ -46,53 +56,98 @@ func (s stateWrapper) Has(name kv.Key) (bool, error) {
 
 func (s stateWrapper) Iterate(prefix kv.Key, f func(kv.Key, []byte) bool) error {
 	prefix = s.addContractSubPartition(prefix)
-	seen, done := s.stateUpdate.Mutations().IterateValues(prefix, func(key kv.Key, value []byte) bool {
+	_, done := s.stateUpdate.Mutations().IterateValues(prefix, func(key kv.Key, value []byte) bool {
+		s.burn(GasPerIteration + GasPerStateByte*int64(len(value)))
 		return f(key[len(s.contractSubPartitionPrefix):], value)
 	})
 	if done {
 		return nil
 	}
 	return s.virtualState.Variables().Iterate(prefix, func(key kv.Key, value []byte) bool {
-		_, ok := seen[key]
-		if ok {
+		if s.stateUpdate.Mutations().Latest(key) != nil {
+			// already seen or deleted
 			return true
 		}
+		s.burn(GasPerIteration + GasPerStateByte*int64(len(value)))
 		return f(key[len(s.contractSubPartitionPrefix):], value)
 	})
 }
 
 func (s stateWrapper) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
 	prefix = s.addContractSubPartition(prefix)
-	seen, done := s.stateUpdate.Mutations().IterateValues(prefix, func(key kv.Key, value []byte) bool {
+	_, done := s.stateUpdate.Mutations().IterateValues(prefix, func(key kv.Key, value []byte) bool {
+		s.burn(GasPerIteration)
 		return f(key[len(s.contractSubPartitionPrefix):])
 	})
 	if done {
 		return nil
 	}
 	return s.virtualState.Variables().IterateKeys(prefix, func(key kv.Key) bool {
-		_, ok := seen[key]
-		if ok {
+		if s.stateUpdate.Mutations().Latest(key) != nil {
+			// already seen or deleted
 			return true
 		}
+		s.burn(GasPerIteration)
 		return f(key[len(s.contractSubPartitionPrefix):])
 	})
 }
 
+func (s stateWrapper) IterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	return s.iterateRange(from, to, false, f)
+}
+
+func (s stateWrapper) IterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	return s.iterateRange(from, to, true, f)
+}
+
+func (s stateWrapper) iterateRange(from, to kv.Key, reverse bool, f func(key kv.Key, value []byte) bool) error {
+	if to == "" {
+		to = kv.PrefixEnd(s.contractSubPartitionPrefix)
+	} else {
+		to = s.addContractSubPartition(to)
+	}
+	from = s.addContractSubPartition(from)
+	iterateBase := func(f func(key kv.Key, value []byte) bool) error {
+		return s.virtualState.Variables().IterateRange(from, to, f)
+	}
+	return buffered.IterateRangeOver(s.stateUpdate.Mutations(), from, to, reverse, iterateBase, func(key kv.Key, value []byte) bool {
+		s.burn(GasPerIteration + GasPerStateByte*int64(len(value)))
+		return f(key[len(s.contractSubPartitionPrefix):], value)
+	})
+}
+
 func (s stateWrapper) Get(name kv.Key) ([]byte, error) {
 	name = s.addContractSubPartition(name)
 	mut := s.stateUpdate.Mutations().Latest(name)
 	if mut != nil {
+		s.burn(GasPerStateRead + GasPerStateByte*int64(len(mut.Value())))
 		return mut.Value(), nil
 	}
-	return s.virtualState.Variables().Get(name)
+	ret, err := s.virtualState.Variables().Get(name)
+	s.burn(GasPerStateRead + GasPerStateByte*int64(len(ret)))
+	return ret, err
 }
 
 func (s stateWrapper) Del(name kv.Key) {
+	s.burn(GasPerStateWrite)
 	name = s.addContractSubPartition(name)
 	s.stateUpdate.Mutations().Add(buffered.NewMutationDel(name))
 }
 
+// DelPrefix deletes all keys with the prefix. Each key of the state deleted by the prefix is recorded
+// in the state history one by one, so it is charged as if the key was deleted by Del
+func (s stateWrapper) DelPrefix(prefix kv.Key) {
+	s.burn(GasPerStateWrite)
+	prefix = s.addContractSubPartition(prefix)
+	s.virtualState.Variables().MustIterateKeys(prefix, func(key kv.Key) bool {
+		s.burn(GasPerIteration + GasPerStateWrite)
+		return true
+	})
+	s.stateUpdate.Mutations().Add(buffered.NewMutationDelPrefix(prefix))
+}
+
 func (s stateWrapper) Set(name kv.Key, value []byte) {
+	s.burn(GasPerStateWrite + GasPerStateByte*int64(len(name)+len(value)))
 	name = s.addContractSubPartition(name)
 	s.stateUpdate.Mutations().Add(buffered.NewMutationSet(name, value))
 }
@@ -118,3 +173,11 @@ func (s stateWrapper) MustIterate(prefix kv.Key, f func(key kv.Key, value []byte
 func (s stateWrapper) MustIterateKeys(prefix kv.Key, f func(key kv.Key) bool) {
 	kv.MustIterateKeys(s, prefix, f)
 }
+
+func (s stateWrapper) MustIterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRange(s, from, to, f)
+}
+
+func (s stateWrapper) MustIterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	kv.MustIterateRangeReverse(s, from, to, f)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/iotaledger/hive.go/kvstore/mapdb"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/stretchr/testify/assert"
 )
@@ -77,3 +78,29 @@ func TestIterate(t *testing.T) {
 		return true
 	})
 }
+
+func TestTrackReads(t *testing.T) {
+	db := mapdb.NewMapDB()
+
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+
+	virtualState := state.NewVirtualState(db, &chainID)
+	stateUpdate := state.NewStateUpdate(nil)
+	hname := coretypes.Hn("test")
+
+	s := newStateWrapper(hname, virtualState, stateUpdate)
+	rw := buffered.NewReadWriteSet()
+	virtualState.Variables().TrackReads(rw.Reads)
+
+	// the value written by the request itself is not read from the state
+	s.Set("x", []byte{1})
+	_, err := s.Get("x")
+	assert.NoError(t, err)
+	_, err = s.Get("y")
+	assert.NoError(t, err)
+	rw.AddWrites(stateUpdate.Mutations())
+
+	prefix := kv.Key(hname.Bytes())
+	assert.EqualValues(t, []kv.Key{prefix + "y"}, rw.Reads.Keys())
+	assert.EqualValues(t, []kv.Key{prefix + "x"}, rw.Writes.Keys())
+}
@@ -0,0 +1,491 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmhost
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// GasPerInstruction is the gas price of one instruction of the Wasm code
+const GasPerInstruction = int64(1)
+
+// GasGlobalName is the name of the exported global of the instrumented Wasm code which holds the gas left
+const GasGlobalName = "__wasp_gas"
+
+const (
+	wasmSectionCustom = 0
+	wasmSectionImport = 2
+	wasmSectionGlobal = 6
+	wasmSectionExport = 7
+	wasmSectionCode   = 10
+	wasmDataCount     = 12
+
+	wasmExternGlobal = 0x03
+	wasmTypeI64      = 0x7e
+	wasmMutable      = 0x01
+)
+
+// wasmSectionOrder is the order in which the known sections must follow each other
+var wasmSectionOrder = map[byte]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9, wasmDataCount: 10, 10: 11, 11: 12}
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+type wasmSection struct {
+	id      byte
+	content []byte
+}
+
+// InstrumentGas adds the deterministic metering of the instructions to the Wasm code. The gas left is held by the
+// mutable i64 global exported as GasGlobalName. Each function charges the instructions of its body on entry and
+// each loop charges the instructions of its body on every iteration, where the instructions of the nested loops
+// are charged by the nested loops. Instructions skipped by branches are charged too, so the gas is the same on all
+// nodes and doesn't depend on the engine. The code traps with 'unreachable' when the gas left becomes negative
+func InstrumentGas(wasm []byte) ([]byte, error) {
+	if len(wasm) < len(wasmHeader) || !bytes.Equal(wasm[:len(wasmHeader)], wasmHeader) {
+		return nil, errors.New("InstrumentGas: not a Wasm binary of version 1")
+	}
+	r := &wasmReader{buf: wasm, pos: len(wasmHeader)}
+	sections := make([]*wasmSection, 0)
+	for !r.done() {
+		id := r.byte()
+		size := r.u32()
+		content := r.bytes(int(size))
+		if r.err != nil {
+			return nil, fmt.Errorf("InstrumentGas: wrong section: %v", r.err)
+		}
+		sections = append(sections, &wasmSection{id: id, content: content})
+	}
+
+	gasGlobal := uint32(0)
+	for _, id := range []byte{wasmSectionImport, wasmSectionGlobal} {
+		if s := findSection(sections, id); s != nil {
+			n, err := countGlobals(s)
+			if err != nil {
+				return nil, fmt.Errorf("InstrumentGas: %v", err)
+			}
+			gasGlobal += n
+		}
+	}
+
+	sections = ensureSection(sections, wasmSectionGlobal)
+	sections = ensureSection(sections, wasmSectionExport)
+	var out bytes.Buffer
+	out.Write(wasmHeader)
+	for _, s := range sections {
+		content := s.content
+		var err error
+		switch s.id {
+		case wasmSectionGlobal:
+			// the gas global is defined last, so the indices of other globals don't change
+			content, err = appendVecItem(content, []byte{wasmTypeI64, wasmMutable, 0x42, 0x00, 0x0b})
+		case wasmSectionExport:
+			var item bytes.Buffer
+			writeName(&item, GasGlobalName)
+			item.WriteByte(wasmExternGlobal)
+			writeU32(&item, gasGlobal)
+			content, err = appendVecItem(content, item.Bytes())
+		case wasmSectionCode:
+			content, err = instrumentCode(content, gasGlobal)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("InstrumentGas: %v", err)
+		}
+		out.WriteByte(s.id)
+		writeU32(&out, uint32(len(content)))
+		out.Write(content)
+	}
+	return out.Bytes(), nil
+}
+
+func findSection(sections []*wasmSection, id byte) *wasmSection {
+	for _, s := range sections {
+		if s.id == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// ensureSection adds the empty section in its place if it is missing
+func ensureSection(sections []*wasmSection, id byte) []*wasmSection {
+	if findSection(sections, id) != nil {
+		return sections
+	}
+	i := 0
+	for ; i < len(sections); i++ {
+		if sections[i].id != wasmSectionCustom && wasmSectionOrder[sections[i].id] > wasmSectionOrder[id] {
+			break
+		}
+	}
+	ret := make([]*wasmSection, 0, len(sections)+1)
+	ret = append(ret, sections[:i]...)
+	ret = append(ret, &wasmSection{id: id, content: []byte{0x00}})
+	return append(ret, sections[i:]...)
+}
+
+// countGlobals returns the number of globals imported by the import section or defined by the global section
+func countGlobals(s *wasmSection) (uint32, error) {
+	r := &wasmReader{buf: s.content}
+	n := r.u32()
+	if s.id == wasmSectionGlobal {
+		return n, r.err
+	}
+	ret := uint32(0)
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		r.bytes(int(r.u32()))
+		r.bytes(int(r.u32()))
+		switch kind := r.byte(); kind {
+		case 0x00:
+			r.u32()
+		case 0x01:
+			r.byte()
+			r.limits()
+		case 0x02:
+			r.limits()
+		case wasmExternGlobal:
+			r.byte()
+			r.byte()
+			ret++
+		default:
+			return 0, fmt.Errorf("wrong import kind %d", kind)
+		}
+	}
+	if r.err != nil {
+		return 0, fmt.Errorf("wrong import section: %v", r.err)
+	}
+	return ret, nil
+}
+
+// appendVecItem appends the encoded item to the vector which is the content of the section
+func appendVecItem(content, item []byte) ([]byte, error) {
+	r := &wasmReader{buf: content}
+	n := r.u32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	var buf bytes.Buffer
+	writeU32(&buf, n+1)
+	buf.Write(content[r.pos:])
+	buf.Write(item)
+	return buf.Bytes(), nil
+}
+
+func instrumentCode(content []byte, gasGlobal uint32) ([]byte, error) {
+	r := &wasmReader{buf: content}
+	n := r.u32()
+	var buf bytes.Buffer
+	writeU32(&buf, n)
+	for i := uint32(0); i < n; i++ {
+		body := r.bytes(int(r.u32()))
+		if r.err != nil {
+			return nil, fmt.Errorf("wrong code section: %v", r.err)
+		}
+		instrumented, err := instrumentBody(body, gasGlobal)
+		if err != nil {
+			return nil, fmt.Errorf("function #%d: %v", i, err)
+		}
+		writeU32(&buf, uint32(len(instrumented)))
+		buf.Write(instrumented)
+	}
+	if !r.done() {
+		return nil, errors.New("wrong code section: trailing bytes")
+	}
+	return buf.Bytes(), nil
+}
+
+type wasmInstr struct {
+	opcode     byte
+	start, end int
+}
+
+func instrumentBody(body []byte, gasGlobal uint32) ([]byte, error) {
+	r := &wasmReader{buf: body}
+	numLocals := r.u32()
+	for i := uint32(0); i < numLocals; i++ {
+		r.u32()
+		r.byte()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("wrong locals: %v", r.err)
+	}
+	localsEnd := r.pos
+	instrs := make([]wasmInstr, 0)
+	for !r.done() {
+		start := r.pos
+		opcode := r.byte()
+		if err := r.skipImmediates(opcode); err != nil {
+			return nil, err
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("wrong instruction at %d: %v", start, r.err)
+		}
+		instrs = append(instrs, wasmInstr{opcode: opcode, start: start, end: r.pos})
+	}
+
+	// the cost of the function body and of each loop body, without the nested loop bodies
+	costs := []int64{0}
+	loopCost := make(map[int]int)
+	// blocks which are open: the index of the cost of the loop, -1 for other blocks
+	open := make([]int, 0)
+	current := 0
+	for i, instr := range instrs {
+		costs[current]++
+		switch instr.opcode {
+		case 0x02, 0x04:
+			open = append(open, -1)
+		case 0x03:
+			costs = append(costs, 0)
+			loopCost[i] = len(costs) - 1
+			open = append(open, len(costs)-1)
+			current = len(costs) - 1
+		case 0x0b:
+			if len(open) == 0 {
+				// end of the function
+				break
+			}
+			open = open[:len(open)-1]
+			current = 0
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j] >= 0 {
+					current = open[j]
+					break
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(body[:localsEnd])
+	writeGasCharge(&buf, gasGlobal, costs[0])
+	for i, instr := range instrs {
+		buf.Write(body[instr.start:instr.end])
+		if c, ok := loopCost[i]; ok {
+			writeGasCharge(&buf, gasGlobal, costs[c])
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeGasCharge writes the code which subtracts the price of the instructions from the gas global
+// and traps if the gas left is negative
+func writeGasCharge(buf *bytes.Buffer, gasGlobal uint32, instructions int64) {
+	buf.WriteByte(0x23) // global.get
+	writeU32(buf, gasGlobal)
+	buf.WriteByte(0x42) // i64.const
+	writeS64(buf, instructions*GasPerInstruction)
+	buf.WriteByte(0x7d) // i64.sub
+	buf.WriteByte(0x24) // global.set
+	writeU32(buf, gasGlobal)
+	buf.WriteByte(0x23) // global.get
+	writeU32(buf, gasGlobal)
+	buf.Write([]byte{
+		0x42, 0x00, // i64.const 0
+		0x53,       // i64.lt_s
+		0x04, 0x40, // if
+		0x00, // unreachable
+		0x0b, // end
+	})
+}
+
+type wasmReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *wasmReader) done() bool {
+	return r.err != nil || r.pos >= len(r.buf)
+}
+
+func (r *wasmReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.buf) {
+		r.err = errors.New("unexpected end")
+		return 0
+	}
+	r.pos++
+	return r.buf[r.pos-1]
+}
+
+func (r *wasmReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.buf) {
+		r.err = errors.New("unexpected end")
+		return nil
+	}
+	r.pos += n
+	return r.buf[r.pos-n : r.pos]
+}
+
+func (r *wasmReader) u32() uint32 {
+	ret := uint32(0)
+	for shift := uint(0); shift < 35; shift += 7 {
+		b := r.byte()
+		ret |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return ret
+		}
+	}
+	if r.err == nil {
+		r.err = errors.New("wrong LEB128 encoding")
+	}
+	return 0
+}
+
+// skipLEB skips the signed or unsigned LEB128 encoded integer
+func (r *wasmReader) skipLEB() {
+	for i := 0; i < 10; i++ {
+		if r.byte()&0x80 == 0 {
+			return
+		}
+	}
+	if r.err == nil {
+		r.err = errors.New("wrong LEB128 encoding")
+	}
+}
+
+func (r *wasmReader) limits() {
+	flags := r.byte()
+	r.u32()
+	if flags&0x01 != 0 {
+		r.u32()
+	}
+}
+
+func (r *wasmReader) blockType() {
+	if r.pos < len(r.buf) {
+		switch r.buf[r.pos] {
+		case 0x40, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f:
+			r.pos++
+			return
+		}
+	}
+	// index of the function type
+	r.skipLEB()
+}
+
+// skipImmediates skips the immediate arguments of the instruction
+func (r *wasmReader) skipImmediates(opcode byte) error {
+	switch {
+	case opcode == 0x00 || opcode == 0x01 || opcode == 0x05 || opcode == 0x0b || opcode == 0x0f:
+		// unreachable, nop, else, end, return
+	case opcode >= 0x02 && opcode <= 0x04:
+		// block, loop, if
+		r.blockType()
+	case opcode == 0x0c || opcode == 0x0d || opcode == 0x10:
+		// br, br_if, call
+		r.u32()
+	case opcode == 0x0e:
+		// br_table
+		n := r.u32()
+		for i := uint32(0); i <= n && r.err == nil; i++ {
+			r.u32()
+		}
+	case opcode == 0x11:
+		// call_indirect
+		r.u32()
+		r.u32()
+	case opcode == 0x1a || opcode == 0x1b:
+		// drop, select
+	case opcode == 0x1c:
+		// select with types
+		r.bytes(int(r.u32()))
+	case opcode >= 0x20 && opcode <= 0x26:
+		// local, global and table access
+		r.u32()
+	case opcode >= 0x28 && opcode <= 0x3e:
+		// memory access: alignment and offset
+		r.u32()
+		r.u32()
+	case opcode == 0x3f || opcode == 0x40:
+		// memory.size, memory.grow
+		r.byte()
+	case opcode == 0x41 || opcode == 0x42:
+		// i32.const, i64.const
+		r.skipLEB()
+	case opcode == 0x43:
+		r.bytes(4)
+	case opcode == 0x44:
+		r.bytes(8)
+	case opcode >= 0x45 && opcode <= 0xc4:
+		// numeric instructions
+	case opcode == 0xd0:
+		// ref.null
+		r.byte()
+	case opcode == 0xd1:
+		// ref.is_null
+	case opcode == 0xd2:
+		// ref.func
+		r.u32()
+	case opcode == 0xfc:
+		return r.skipMiscImmediates()
+	default:
+		return fmt.Errorf("unsupported instruction 0x%02x at %d", opcode, r.pos-1)
+	}
+	return nil
+}
+
+// skipMiscImmediates skips the immediate arguments of the saturating truncation, bulk memory and table instructions
+func (r *wasmReader) skipMiscImmediates() error {
+	switch op := r.u32(); {
+	case op <= 7:
+		// saturating truncation
+	case op == 8:
+		// memory.init
+		r.u32()
+		r.byte()
+	case op == 9 || op == 13 || (op >= 15 && op <= 17):
+		// data.drop, elem.drop, table.grow, table.size, table.fill
+		r.u32()
+	case op == 10:
+		// memory.copy
+		r.byte()
+		r.byte()
+	case op == 11:
+		// memory.fill
+		r.byte()
+	case op == 12 || op == 14:
+		// table.init, table.copy
+		r.u32()
+		r.u32()
+	default:
+		return fmt.Errorf("unsupported instruction 0xfc %d at %d", op, r.pos)
+	}
+	return nil
+}
+
+func writeU32(buf *bytes.Buffer, v uint32) {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			buf.WriteByte(b)
+			return
+		}
+		buf.WriteByte(b | 0x80)
+	}
+}
+
+func writeS64(buf *bytes.Buffer, v int64) {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			buf.WriteByte(b)
+			return
+		}
+		buf.WriteByte(b | 0x80)
+	}
+}
+
+func writeName(buf *bytes.Buffer, name string) {
+	writeU32(buf, uint32(len(name)))
+	buf.WriteString(name)
+}
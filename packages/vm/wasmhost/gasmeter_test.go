@@ -0,0 +1,130 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmhost
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loopWasm is the binary of the module:
+//
+//	(module
+//	  (memory (export "memory") 1)
+//	  (func (export "loop") (loop br 0))
+//	  (func (export "nop")))
+var loopWasm = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	// types
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+	// functions
+	0x03, 0x03, 0x02, 0x00, 0x00,
+	// memory
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	// exports
+	0x07, 0x17, 0x03,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x04, 'l', 'o', 'o', 'p', 0x00, 0x00,
+	0x03, 'n', 'o', 'p', 0x00, 0x01,
+	// code
+	0x0a, 0x0c, 0x02,
+	0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b,
+	0x02, 0x00, 0x0b,
+}
+
+func gasCharge(gasGlobal uint32, instructions int64) []byte {
+	var buf bytes.Buffer
+	writeGasCharge(&buf, gasGlobal, instructions)
+	return buf.Bytes()
+}
+
+// sectionContent returns the content of the section of the Wasm binary
+func sectionContent(t *testing.T, wasm []byte, id byte) []byte {
+	r := &wasmReader{buf: wasm, pos: len(wasmHeader)}
+	for !r.done() {
+		sid := r.byte()
+		content := r.bytes(int(r.u32()))
+		if sid == id {
+			return content
+		}
+	}
+	require.NoError(t, r.err)
+	t.Fatalf("no section %d", id)
+	return nil
+}
+
+func TestInstrumentGas(t *testing.T) {
+	wasm, err := InstrumentGas(loopWasm)
+	require.NoError(t, err)
+
+	// the gas global is added and exported
+	require.Equal(t, []byte{0x01, wasmTypeI64, wasmMutable, 0x42, 0x00, 0x0b}, sectionContent(t, wasm, wasmSectionGlobal))
+	exports := sectionContent(t, wasm, wasmSectionExport)
+	require.EqualValues(t, 4, exports[0])
+	require.True(t, bytes.HasSuffix(exports, append([]byte{byte(len(GasGlobalName))}, append([]byte(GasGlobalName), wasmExternGlobal, 0x00)...)))
+
+	// the function charges the loop instruction and its end on entry, the loop charges its body on every iteration
+	var code bytes.Buffer
+	code.WriteByte(0x02)
+	loopBody := append([]byte{0x00}, gasCharge(0, 2)...)
+	loopBody = append(loopBody, 0x03, 0x40)
+	loopBody = append(loopBody, gasCharge(0, 2)...)
+	loopBody = append(loopBody, 0x0c, 0x00, 0x0b, 0x0b)
+	writeU32(&code, uint32(len(loopBody)))
+	code.Write(loopBody)
+	nopBody := append([]byte{0x00}, gasCharge(0, 1)...)
+	nopBody = append(nopBody, 0x0b)
+	writeU32(&code, uint32(len(nopBody)))
+	code.Write(nopBody)
+	require.Equal(t, code.Bytes(), sectionContent(t, wasm, wasmSectionCode))
+
+	// the instrumentation is deterministic
+	again, err := InstrumentGas(loopWasm)
+	require.NoError(t, err)
+	require.Equal(t, wasm, again)
+}
+
+func TestInstrumentGasImportedGlobal(t *testing.T) {
+	// (import "env" "g" (global i32)) is inserted before the functions
+	wasm := append([]byte{}, loopWasm[:14]...)
+	wasm = append(wasm, 0x02, 0x0a, 0x01, 0x03, 'e', 'n', 'v', 0x01, 'g', 0x03, 0x7f, 0x00)
+	wasm = append(wasm, loopWasm[14:]...)
+	instrumented, err := InstrumentGas(wasm)
+	require.NoError(t, err)
+	// the index of the gas global follows the imported global
+	exports := sectionContent(t, instrumented, wasmSectionExport)
+	require.EqualValues(t, 1, exports[len(exports)-1])
+	require.True(t, bytes.Contains(sectionContent(t, instrumented, wasmSectionCode), gasCharge(1, 1)))
+}
+
+func TestInstrumentGasWrongCode(t *testing.T) {
+	_, err := InstrumentGas([]byte("garbage"))
+	require.Error(t, err)
+	_, err = InstrumentGas(loopWasm[:len(loopWasm)-1])
+	require.Error(t, err)
+
+	// SIMD instructions are not supported
+	wasm := append([]byte{}, loopWasm...)
+	// br 0 of the loop is replaced
+	wasm[len(wasm)-7] = 0xfd
+	_, err = InstrumentGas(wasm)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported instruction 0xfd")
+}
+
+func TestInstrumentGasContracts(t *testing.T) {
+	files, err := filepath.Glob("../../../contracts/rust/*/test/*.wasm")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		wasm, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		_, err = InstrumentGas(wasm)
+		require.NoError(t, err, file)
+	}
+}
@@ -5,13 +5,23 @@ package wasmhost
 
 import (
 	"errors"
+	"math"
+
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/wasp/packages/coretypes"
 )
 
+// gas prices of the host calls made by the Wasm code
+const (
+	GasPerHostCall = int64(10)
+	GasPerHostByte = int64(1)
+)
+
 type WasmHost struct {
 	KvStoreHost
 	vm          WasmVM
+	gasBurner   func(gas int64)
+	gasBudget   func() int64
 	codeToFunc  map[uint32]string
 	funcToCode  map[string]uint32
 	funcToIndex map[string]int32
@@ -29,6 +39,27 @@ func (host *WasmHost) Init(null HostObject, root HostObject, log *logger.Logger)
 	host.funcToIndex = make(map[string]int32)
 }
 
+// BurnGas charges gas for a host call through the installed gas burner, if any
+func (host *WasmHost) BurnGas(gas int64) {
+	if host.gasBurner != nil {
+		host.gasBurner(gas)
+	}
+}
+
+// GasBudget returns the gas left in the budget of the caller. The gas is unlimited if no budget is installed
+func (host *WasmHost) GasBudget() int64 {
+	if host.gasBudget == nil {
+		return math.MaxInt64
+	}
+	return host.gasBudget()
+}
+
+// SetGasBurner installs the functions which charge gas to the budget of the caller and return the gas left in it
+func (host *WasmHost) SetGasBurner(burner func(gas int64), budget func() int64) {
+	host.gasBurner = burner
+	host.gasBudget = budget
+}
+
 func (host *WasmHost) FunctionFromCode(code uint32) string {
 	return host.codeToFunc[code]
 }
@@ -5,7 +5,9 @@ package wasmhost
 
 import (
 	"errors"
+
 	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/iotaledger/wasp/packages/coretypes"
 )
 
 type WasmTimeVM struct {
@@ -15,8 +17,14 @@ type WasmTimeVM struct {
 	memory   *wasmtime.Memory
 	module   *wasmtime.Module
 	store    *wasmtime.Store
+	// gas is the global of the instrumented Wasm code which holds the gas left (see InstrumentGas)
+	gas *wasmtime.Global
+	// gasSet is the gas left the global was set to
+	gasSet int64
 }
 
+// NewWasmTimeVM creates the Wasmtime based VM.
+// The Wasm code is instrumented on load, so every executed instruction is metered (see InstrumentGas)
 func NewWasmTimeVM() *WasmTimeVM {
 	vm := &WasmTimeVM{}
 	vm.store = wasmtime.NewStore(wasmtime.NewEngine())
@@ -28,6 +36,8 @@ func (vm *WasmTimeVM) LinkHost(impl WasmVM, host *WasmHost) error {
 	vm.WasmVmBase.LinkHost(impl, host)
 	err := vm.linker.DefineFunc("wasplib", "hostGetBytes",
 		func(objId int32, keyId int32, typeId int32, stringRef int32, size int32) int32 {
+			vm.gasPause()
+			defer vm.gasResume()
 			return vm.HostGetBytes(objId, keyId, typeId, stringRef, size)
 		})
 	if err != nil {
@@ -35,6 +45,8 @@ func (vm *WasmTimeVM) LinkHost(impl WasmVM, host *WasmHost) error {
 	}
 	err = vm.linker.DefineFunc("wasplib", "hostGetKeyId",
 		func(keyRef int32, size int32) int32 {
+			vm.gasPause()
+			defer vm.gasResume()
 			return vm.HostGetKeyId(keyRef, size)
 		})
 	if err != nil {
@@ -42,6 +54,8 @@ func (vm *WasmTimeVM) LinkHost(impl WasmVM, host *WasmHost) error {
 	}
 	err = vm.linker.DefineFunc("wasplib", "hostGetObjectId",
 		func(objId int32, keyId int32, typeId int32) int32 {
+			vm.gasPause()
+			defer vm.gasResume()
 			return vm.HostGetObjectId(objId, keyId, typeId)
 		})
 	if err != nil {
@@ -49,6 +63,8 @@ func (vm *WasmTimeVM) LinkHost(impl WasmVM, host *WasmHost) error {
 	}
 	err = vm.linker.DefineFunc("wasplib", "hostSetBytes",
 		func(objId int32, keyId int32, typeId int32, stringRef int32, size int32) {
+			vm.gasPause()
+			defer vm.gasResume()
 			vm.HostSetBytes(objId, keyId, typeId, stringRef, size)
 		})
 	if err != nil {
@@ -66,7 +82,10 @@ func (vm *WasmTimeVM) LinkHost(impl WasmVM, host *WasmHost) error {
 }
 
 func (vm *WasmTimeVM) LoadWasm(wasmData []byte) error {
-	var err error
+	wasmData, err := InstrumentGas(wasmData)
+	if err != nil {
+		return err
+	}
 	vm.module, err = wasmtime.NewModule(vm.store.Engine, wasmData)
 	if err != nil {
 		return err
@@ -83,6 +102,11 @@ func (vm *WasmTimeVM) LoadWasm(wasmData []byte) error {
 	if vm.memory == nil {
 		return errors.New("not a memory type")
 	}
+	gas := vm.instance.GetExport(GasGlobalName)
+	if gas == nil || gas.Global() == nil {
+		return errors.New("no gas global export")
+	}
+	vm.gas = gas.Global()
 	return nil
 }
 
@@ -91,8 +115,7 @@ func (vm *WasmTimeVM) RunFunction(functionName string) error {
 	if export == nil {
 		return errors.New("unknown export function: '" + functionName + "'")
 	}
-	_, err := export.Func().Call()
-	return err
+	return vm.call(export.Func())
 }
 
 func (vm *WasmTimeVM) RunScFunction(index int32) error {
@@ -101,11 +124,47 @@ func (vm *WasmTimeVM) RunScFunction(index int32) error {
 		return errors.New("unknown export function: 'on_call_entrypoint'")
 	}
 	frame := vm.PreCall()
-	_, err := export.Func().Call(index)
+	err := vm.call(export.Func(), index)
 	vm.PostCall(frame)
 	return err
 }
 
+// call calls the Wasm function with the gas left in the budget of the caller and charges the gas used.
+// Returns coretypes.ErrGasBudgetExceeded if the Wasm code has run out of gas
+func (vm *WasmTimeVM) call(f *wasmtime.Func, args ...interface{}) error {
+	vm.gasResume()
+	_, err := f.Call(args...)
+	if vm.gasPause() {
+		return coretypes.ErrGasBudgetExceeded
+	}
+	return err
+}
+
+// gasResume sets the gas global to the gas left in the budget of the caller before the Wasm code runs
+func (vm *WasmTimeVM) gasResume() {
+	vm.gasSet = vm.host.GasBudget()
+	if vm.gasSet < 0 {
+		vm.gasSet = 0
+	}
+	if err := vm.gas.Set(wasmtime.ValI64(vm.gasSet)); err != nil {
+		// the global is a mutable i64, so it never happens
+		panic(err)
+	}
+}
+
+// gasPause charges the gas used by the Wasm code since the gas global was set, before the host takes over.
+// The Wasm code which has run out of gas has used the whole gas left, then it returns true
+func (vm *WasmTimeVM) gasPause() bool {
+	left := vm.gas.Get().I64()
+	if left < 0 {
+		vm.host.BurnGas(vm.gasSet)
+		return true
+	}
+	vm.host.BurnGas(vm.gasSet - left)
+	vm.gasSet = left
+	return false
+}
+
 func (vm *WasmTimeVM) UnsafeMemory() []byte {
 	return vm.memory.UnsafeData()
 }
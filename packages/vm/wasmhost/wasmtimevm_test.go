@@ -0,0 +1,47 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package wasmhost
+
+import (
+	"testing"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/stretchr/testify/require"
+)
+
+const loopWat = `
+(module
+  (memory (export "memory") 1)
+  (func (export "loop") (loop br 0))
+  (func (export "nop")))
+`
+
+func TestWasmGasMetering(t *testing.T) {
+	wasm, err := wasmtime.Wat2Wasm(loopWat)
+	require.NoError(t, err)
+	vm := NewWasmTimeVM()
+	host := &WasmHost{}
+	budget := int64(1000)
+	host.SetGasBurner(func(gas int64) {
+		budget -= gas
+	}, func() int64 {
+		return budget
+	})
+	require.NoError(t, vm.LinkHost(vm, host))
+	require.NoError(t, vm.LoadWasm(wasm))
+
+	// the price of the instructions doesn't depend on the engine
+	require.NoError(t, vm.RunFunction("nop"))
+	require.EqualValues(t, 1000-GasPerInstruction, budget)
+
+	// the code which never calls the host runs out of gas and uses all the gas left
+	require.Equal(t, coretypes.ErrGasBudgetExceeded, vm.RunFunction("loop"))
+	require.EqualValues(t, 0, budget)
+	require.Equal(t, coretypes.ErrGasBudgetExceeded, vm.RunFunction("nop"))
+
+	budget = 1000
+	require.NoError(t, vm.RunFunction("nop"))
+	require.EqualValues(t, 1000-GasPerInstruction, budget)
+}
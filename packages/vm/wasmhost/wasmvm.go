@@ -46,6 +46,7 @@ func (vm *WasmVmBase) HostFdWrite(fd int32, iovs int32, size int32, written int3
 func (vm *WasmVmBase) HostGetBytes(objId int32, keyId int32, typeId int32, stringRef int32, size int32) int32 {
 	host := vm.host
 	host.TraceAll("HostGetBytes(o%d,k%d,t%d,r%d,s%d)", objId, keyId, typeId, stringRef, size)
+	host.BurnGas(GasPerHostCall)
 
 	// negative size means only check for existence
 	if size < 0 {
@@ -60,12 +61,14 @@ func (vm *WasmVmBase) HostGetBytes(objId int32, keyId int32, typeId int32, strin
 	if bytes == nil {
 		return -1
 	}
+	host.BurnGas(GasPerHostByte * int64(len(bytes)))
 	return vm.vmSetBytes(stringRef, size, bytes)
 }
 
 func (vm *WasmVmBase) HostGetKeyId(keyRef int32, size int32) int32 {
 	host := vm.host
 	host.TraceAll("HostGetKeyId(r%d,s%d)", keyRef, size)
+	host.BurnGas(GasPerHostCall)
 	// non-negative size means original key was a string
 	if size >= 0 {
 		bytes := vm.vmGetBytes(keyRef, size)
@@ -80,12 +83,14 @@ func (vm *WasmVmBase) HostGetKeyId(keyRef int32, size int32) int32 {
 func (vm *WasmVmBase) HostGetObjectId(objId int32, keyId int32, typeId int32) int32 {
 	host := vm.host
 	host.TraceAll("HostGetObjectId(o%d,k%d,t%d)", objId, keyId, typeId)
+	host.BurnGas(GasPerHostCall)
 	return host.GetObjectId(objId, keyId, typeId)
 }
 
 func (vm *WasmVmBase) HostSetBytes(objId int32, keyId int32, typeId int32, stringRef int32, size int32) {
 	host := vm.host
 	host.TraceAll("HostSetBytes(o%d,k%d,t%d,r%d,s%d)", objId, keyId, typeId, stringRef, size)
+	host.BurnGas(GasPerHostCall + GasPerHostByte*int64(size))
 	bytes := vm.vmGetBytes(stringRef, size)
 	host.SetBytes(objId, keyId, typeId, bytes)
 }
@@ -201,7 +201,7 @@ func (o *ScContext) processPost(bytes []byte) {
 		EntryPoint:       function,
 		Params:           params,
 		Transfer:         transfer,
-		TimeLock:         uint32(delay),
+		TimeLock:         delay,
 	})
 }
 
@@ -216,12 +216,7 @@ func (o *ScDict) SetBytes(keyId int32, typeId int32, bytes []byte) {
 
 	if keyId == wasmhost.KeyLength {
 		if o.kvStore != nil {
-			//TODO this goes wrong for state, should clear map tree instead
-			o.kvStore = dict.New()
-			//if (o.typeId & wasmhost.OBJTYPE_ARRAY) != 0 {
-			//	key := o.NestedKey()[1:]
-			//	o.kvStore.Del(kv.Key(key))
-			//}
+			o.clear()
 		}
 		o.objects = make(map[int32]int32)
 		o.length = 0
@@ -230,6 +225,17 @@ func (o *ScDict) SetBytes(keyId int32, typeId int32, bytes []byte) {
 	o.kvStore.Set(o.key(keyId, typeId), bytes)
 }
 
+// clear deletes all keys of the object, including the keys of nested objects and the array length
+func (o *ScDict) clear() {
+	if o.isRoot {
+		o.kvStore.DelPrefix(kv.EmptyPrefix)
+		return
+	}
+	key := o.NestedKey()[1:]
+	o.kvStore.Del(kv.Key(key))
+	o.kvStore.DelPrefix(kv.Key(key + "."))
+}
+
 func (o *ScDict) Suffix(keyId int32) string {
 	if (o.typeId & wasmhost.OBJTYPE_ARRAY) != 0 {
 		return fmt.Sprintf(".%d", keyId)
@@ -27,6 +27,10 @@ func (s ScViewState) Del(key kv.Key) {
 	s.ctxView.Log().Panicf("ScViewState.Del")
 }
 
+func (s ScViewState) DelPrefix(prefix kv.Key) {
+	s.ctxView.Log().Panicf("ScViewState.DelPrefix")
+}
+
 func (s ScViewState) Get(key kv.Key) ([]byte, error) {
 	return s.viewState.Get(key)
 }
@@ -43,6 +47,14 @@ func (s ScViewState) IterateKeys(prefix kv.Key, f func(key kv.Key) bool) error {
 	return s.viewState.IterateKeys(prefix, f)
 }
 
+func (s ScViewState) IterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	return s.viewState.IterateRange(from, to, f)
+}
+
+func (s ScViewState) IterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) error {
+	return s.viewState.IterateRangeReverse(from, to, f)
+}
+
 func (s ScViewState) MustGet(key kv.Key) []byte {
 	return s.viewState.MustGet(key)
 }
@@ -58,3 +70,11 @@ func (s ScViewState) MustIterate(prefix kv.Key, f func(key kv.Key, value []byte)
 func (s ScViewState) MustIterateKeys(prefix kv.Key, f func(key kv.Key) bool) {
 	s.viewState.MustIterateKeys(prefix, f)
 }
+
+func (s ScViewState) MustIterateRange(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	s.viewState.MustIterateRange(from, to, f)
+}
+
+func (s ScViewState) MustIterateRangeReverse(from, to kv.Key, f func(key kv.Key, value []byte) bool) {
+	s.viewState.MustIterateRangeReverse(from, to, f)
+}
@@ -38,6 +38,7 @@ func NewWasmProcessor(vm wasmhost.WasmVM, logger *logger.Logger) (*wasmProcessor
 	}
 	host.scContext = NewScContext(host)
 	host.Init(NewNullObject(&host.KvStoreHost), host.scContext, logger)
+	host.SetGasBurner(host.gasBurn, host.gasBudget)
 	host.SetExport(0x8fff, ViewCopyAllState)
 	return host, nil
 }
@@ -155,6 +156,28 @@ func (host *wasmProcessor) contractID() coretypes.ContractID {
 	return host.ctxView.ContractID()
 }
 
+// gasBurn charges gas for the Wasm code and the host calls to the request which is being run
+func (host *wasmProcessor) gasBurn(gas int64) {
+	if host.ctx != nil {
+		host.ctx.GasBurn(gas)
+		return
+	}
+	if host.ctxView != nil {
+		host.ctxView.GasBurn(gas)
+	}
+}
+
+// gasBudget returns the gas which remains in the budget of the request which is being run
+func (host *wasmProcessor) gasBudget() int64 {
+	if host.ctx != nil {
+		return host.ctx.GasBudget()
+	}
+	if host.ctxView != nil {
+		return host.ctxView.GasBudget()
+	}
+	return 0
+}
+
 func (host *wasmProcessor) log() coretypes.LogInterface {
 	if host.ctx != nil {
 		return host.ctx.Log()
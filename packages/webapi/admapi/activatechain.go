@@ -7,7 +7,9 @@ import (
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/registry"
+	"github.com/iotaledger/wasp/packages/util"
 	"github.com/iotaledger/wasp/packages/webapi/httperrors"
+	"github.com/iotaledger/wasp/packages/webapi/model"
 	"github.com/iotaledger/wasp/packages/webapi/routes"
 	"github.com/iotaledger/wasp/plugins/chains"
 	"github.com/labstack/echo/v4"
@@ -22,6 +24,15 @@ func addChainEndpoints(adm echoswagger.ApiGroup) {
 	adm.POST(routes.DeactivateChain(":chainID"), handleDeactivateChain).
 		AddParamPath("", "chainID", "ChainID (base58)").
 		SetSummary("Deactivate a chain")
+
+	example := model.RotateCommittee{
+		NextCommitteeNodes: []string{"wasp1:4000", "wasp2:4000"},
+		NextStateAddress:   model.NewAddress(&address.Address{1, 2, 3}),
+	}
+	adm.POST(routes.RotateCommittee(":chainID"), handleRotateCommittee).
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddParamBody(example, "RotateCommittee", "Next committee", true).
+		SetSummary("Prepare the chain for the rotation to the next committee")
 }
 
 func handleActivateChain(c echo.Context) error {
@@ -62,3 +73,56 @@ func handleDeactivateChain(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+// handleRotateCommittee adds the next committee to the chain record. The chain is restarted in order to
+// share the state with the nodes of the next committee. The chain is switched to the next committee when
+// the chain token is moved to the next address by the 'rotateCommittee' request to the root contract.
+// If the next address is the current address, the key of the chain was reshared to the next committee
+// and the chain is switched to it right away
+func handleRotateCommittee(c echo.Context) error {
+	chainID, err := coretypes.NewChainIDFromBase58(c.Param("chainID"))
+	if err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid chain id: %s", c.Param("chainID")))
+	}
+	var req model.RotateCommittee
+	if err := c.Bind(&req); err != nil {
+		return httperrors.BadRequest("Invalid request body")
+	}
+	nextAddress := req.NextStateAddress.Address()
+	if len(req.NextCommitteeNodes) == 0 || util.ContainsDuplicates(req.NextCommitteeNodes) {
+		return httperrors.BadRequest("Invalid list of committee nodes")
+	}
+	bd, err := registry.GetChainRecord(&chainID)
+	if err != nil {
+		return err
+	}
+	if bd == nil {
+		return httperrors.NotFound(fmt.Sprintf("ChainRecord not found: %s", chainID))
+	}
+	if bd.Address() == nextAddress {
+		// the key of the chain was reshared to the next committee, the chain stays at its address
+		if err := chains.SwitchToResharedCommittee(chainID, req.NextCommitteeNodes); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	}
+	bd, err = registry.UpdateChainRecord(&chainID, func(bd *registry.ChainRecord) bool {
+		bd.NextCommitteeNodes = req.NextCommitteeNodes
+		bd.NextStateAddress = &nextAddress
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("chain %s is being rotated to the committee %+v, address %s", chainID, req.NextCommitteeNodes, nextAddress)
+
+	if bd.Active {
+		if err := chains.DeactivateChain(bd); err != nil {
+			return err
+		}
+		if err := chains.ActivateChain(bd); err != nil {
+			return err
+		}
+	}
+	return c.NoContent(http.StatusOK)
+}
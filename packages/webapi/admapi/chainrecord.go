@@ -44,6 +44,9 @@ func handlePutChainRecord(c echo.Context) error {
 	}
 
 	bd := req.ChainRecord()
+	if !registry.IsValidConsensus(bd.Consensus) {
+		return httperrors.BadRequest(fmt.Sprintf("Unknown consensus: %s", bd.Consensus))
+	}
 
 	bd2, err := registry.GetChainRecord(&bd.ChainID)
 	if err != nil {
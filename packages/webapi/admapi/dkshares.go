@@ -12,8 +12,10 @@ import (
 	"time"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/coretypes"
 	dkg_pkg "github.com/iotaledger/wasp/packages/dkg"
 	"github.com/iotaledger/wasp/packages/tcrypto"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
 	"github.com/iotaledger/wasp/packages/webapi/httperrors"
 	"github.com/iotaledger/wasp/packages/webapi/model"
 	"github.com/iotaledger/wasp/packages/webapi/routes"
@@ -44,10 +46,28 @@ func addDKSharesEndpoints(adm echoswagger.ApiGroup) {
 		AddResponse(http.StatusOK, "DK shares info", infoExample, nil).
 		SetSummary("Generate a new distributed key")
 
+	reshareExample := model.DKSharesReshareRequest{
+		OldPeerNetIDs: []string{"wasp1:4000", "wasp2:4000", "wasp3:4000", "wasp4:4000"},
+		PeerNetIDs:    []string{"wasp3:4000", "wasp4:4000", "wasp5:4000", "wasp6:4000"},
+		Threshold:     3,
+		TimeoutMS:     10000,
+	}
+	adm.POST(routes.DKSharesReshare(":sharedAddress"), handleDKSharesReshare).
+		AddParamPath("", "sharedAddress", "Address of the DK share (base58)").
+		AddParamBody(reshareExample, "DKSharesReshareRequest", "Request parameters", true).
+		AddResponse(http.StatusOK, "DK shares info", infoExample, nil).
+		SetSummary("Reshare an existing distributed key to other nodes, keeping its address")
+
 	adm.GET(routes.DKSharesGet(":sharedAddress"), handleDKSharesGet).
 		AddParamPath("", "sharedAddress", "Address of the DK share (base58)").
 		AddResponse(http.StatusOK, "DK shares info", infoExample, nil).
 		SetSummary("Get distributed key properties")
+
+	adm.POST(routes.DKSharesSignRotation(":sharedAddress", ":chainID"), handleDKSharesSignRotation).
+		AddParamPath("", "sharedAddress", "Address of the DK share (base58)").
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddResponse(http.StatusOK, "Signature share", model.DKSharesSigShare{}, nil).
+		SetSummary("Sign the confirmation of the rotation of the chain to the address of the distributed key")
 }
 
 func handleDKSharesPost(c echo.Context) error {
@@ -64,19 +84,9 @@ func handleDKSharesPost(c echo.Context) error {
 		return httperrors.BadRequest("Inconsistent PeerNetIDs and PeerPubKeys.")
 	}
 
-	var peerPubKeys []kyber.Point = nil
-	if req.PeerPubKeys != nil {
-		peerPubKeys = make([]kyber.Point, len(req.PeerPubKeys))
-		for i := range req.PeerPubKeys {
-			peerPubKeys[i] = suite.Point()
-			b, err := base64.StdEncoding.DecodeString(req.PeerPubKeys[i])
-			if err != nil {
-				return httperrors.BadRequest(fmt.Sprintf("Invalid PeerPubKeys[%v]=%v", i, req.PeerPubKeys[i]))
-			}
-			if err = peerPubKeys[i].UnmarshalBinary(b); err != nil {
-				return httperrors.BadRequest(fmt.Sprintf("Invalid PeerPubKeys[%v]=%v", i, req.PeerPubKeys[i]))
-			}
-		}
+	var peerPubKeys []kyber.Point
+	if peerPubKeys, err = decodePeerPubKeys(suite, "PeerPubKeys", req.PeerPubKeys); err != nil {
+		return err
 	}
 
 	var dkShare *tcrypto.DKShare
@@ -102,6 +112,80 @@ func handleDKSharesPost(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+func handleDKSharesReshare(c echo.Context) error {
+	var req model.DKSharesReshareRequest
+	var err error
+
+	var suite = dkg.DefaultNode().GroupSuite()
+
+	var sharedAddress address.Address
+	if sharedAddress, err = address.FromBase58(c.Param("sharedAddress")); err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid shared address: %v", c.Param("sharedAddress")))
+	}
+	if err = c.Bind(&req); err != nil {
+		return httperrors.BadRequest("Invalid request body.")
+	}
+
+	if req.OldPeerPubKeys != nil && len(req.OldPeerNetIDs) != len(req.OldPeerPubKeys) {
+		return httperrors.BadRequest("Inconsistent OldPeerNetIDs and OldPeerPubKeys.")
+	}
+	if req.PeerPubKeys != nil && len(req.PeerNetIDs) != len(req.PeerPubKeys) {
+		return httperrors.BadRequest("Inconsistent PeerNetIDs and PeerPubKeys.")
+	}
+
+	var oldPeerPubKeys, peerPubKeys []kyber.Point
+	if oldPeerPubKeys, err = decodePeerPubKeys(suite, "OldPeerPubKeys", req.OldPeerPubKeys); err != nil {
+		return err
+	}
+	if peerPubKeys, err = decodePeerPubKeys(suite, "PeerPubKeys", req.PeerPubKeys); err != nil {
+		return err
+	}
+
+	var dkShare *tcrypto.DKShare
+	dkShare, err = dkg.DefaultNode().ReshareDistributedKey(
+		&sharedAddress,
+		req.OldPeerNetIDs,
+		oldPeerPubKeys,
+		req.PeerNetIDs,
+		peerPubKeys,
+		req.Threshold,
+		1*time.Second,
+		3*time.Second,
+		time.Duration(req.TimeoutMS)*time.Millisecond,
+	)
+	if err != nil {
+		if _, ok := err.(dkg_pkg.InvalidParamsError); ok {
+			return httperrors.BadRequest(err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	var response *model.DKSharesInfo
+	if response, err = makeDKSharesInfo(dkShare); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// decodePeerPubKeys decodes the optional base64 encoded public keys of the peers.
+func decodePeerPubKeys(suite kyber.Group, name string, encoded []string) ([]kyber.Point, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	peerPubKeys := make([]kyber.Point, len(encoded))
+	for i := range encoded {
+		peerPubKeys[i] = suite.Point()
+		b, err := base64.StdEncoding.DecodeString(encoded[i])
+		if err != nil {
+			return nil, httperrors.BadRequest(fmt.Sprintf("Invalid %v[%v]=%v", name, i, encoded[i]))
+		}
+		if err = peerPubKeys[i].UnmarshalBinary(b); err != nil {
+			return nil, httperrors.BadRequest(fmt.Sprintf("Invalid %v[%v]=%v", name, i, encoded[i]))
+		}
+	}
+	return peerPubKeys, nil
+}
+
 func handleDKSharesGet(c echo.Context) error {
 	var err error
 	var dkShare *tcrypto.DKShare
@@ -119,6 +203,30 @@ func handleDKSharesGet(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// handleDKSharesSignRotation returns the signature share of the data which confirms the rotation of the chain
+// to the address. The signature recovered from the shares of the quorum proves to the root contract that
+// the committee holds the shares of the key. Nothing but the rotation data is signed
+func handleDKSharesSignRotation(c echo.Context) error {
+	var err error
+	var dkShare *tcrypto.DKShare
+	var sharedAddress address.Address
+	if sharedAddress, err = address.FromBase58(c.Param("sharedAddress")); err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid shared address: %v", c.Param("sharedAddress")))
+	}
+	var chainID coretypes.ChainID
+	if chainID, err = coretypes.NewChainIDFromBase58(c.Param("chainID")); err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid chain id: %v", c.Param("chainID")))
+	}
+	if dkShare, err = registry.DefaultRegistry().LoadDKShare(&sharedAddress); err != nil {
+		return httperrors.NotFound(fmt.Sprintf("DK share not found: %s", sharedAddress))
+	}
+	sigShare, err := dkShare.SignShare(root.RotationProofData(chainID, sharedAddress))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, &model.DKSharesSigShare{SigShare: base64.StdEncoding.EncodeToString(sigShare)})
+}
+
 func makeDKSharesInfo(dkShare *tcrypto.DKShare) (*model.DKSharesInfo, error) {
 	var err error
 
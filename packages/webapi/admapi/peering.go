@@ -0,0 +1,144 @@
+// Copyright 2020 IOTA Stiftung
+// SPDX-License-Identifier: Apache-2.0
+
+package admapi
+
+// Endpoints for maintaining the list of the trusted peers.
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/iotaledger/wasp/packages/peering"
+	"github.com/iotaledger/wasp/packages/webapi/httperrors"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+	"github.com/iotaledger/wasp/plugins/dkg"
+	peering_plugin "github.com/iotaledger/wasp/plugins/peering"
+	"github.com/iotaledger/wasp/plugins/registry"
+	"github.com/labstack/echo/v4"
+	"github.com/pangpanglabs/echoswagger/v2"
+	"go.dedis.ch/kyber/v3"
+)
+
+func addPeeringEndpoints(adm echoswagger.ApiGroup) {
+	identityExample := model.PeeringNodeIdentity{
+		NetID:  "wasp1:4000",
+		PubKey: base64.StdEncoding.EncodeToString([]byte("key")),
+	}
+	trustedExample := model.PeeringTrustedNode{
+		PubKey: base64.StdEncoding.EncodeToString([]byte("key")),
+		Name:   "wasp1",
+	}
+
+	adm.GET(routes.PeeringSelfGet(), handlePeeringSelfGet).
+		AddResponse(http.StatusOK, "This node in the peering network", identityExample, nil).
+		SetSummary("Get the identity of this node in the peering network")
+
+	adm.GET(routes.PeeringTrustedList(), handlePeeringTrustedList).
+		AddResponse(http.StatusOK, "Trusted peers", []model.PeeringTrustedNode{trustedExample}, nil).
+		SetSummary("Get the list of the peers trusted by this node")
+
+	adm.POST(routes.PeeringTrustedPost(), handlePeeringTrustedPost).
+		AddParamBody(trustedExample, "PeeringTrustedNode", "Peer to trust", true).
+		AddResponse(http.StatusOK, "Trusted peer", trustedExample, nil).
+		SetSummary("Trust the peer, or rename an already trusted peer")
+
+	adm.DELETE(routes.PeeringTrustedDelete(":pubKey"), handlePeeringTrustedDelete).
+		AddParamPath("", "pubKey", "Public key of the trusted peer (base64, path-escaped)").
+		AddResponse(http.StatusOK, "Peer not trusted anymore", trustedExample, nil).
+		SetSummary("Remove the peer from the list of the trusted peers")
+}
+
+func handlePeeringSelfGet(c echo.Context) error {
+	self := peering_plugin.DefaultNetworkProvider().Self()
+	pubKeyBytes, err := self.PubKey().MarshalBinary()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, model.PeeringNodeIdentity{
+		NetID:  self.NetID(),
+		PubKey: base64.StdEncoding.EncodeToString(pubKeyBytes),
+	})
+}
+
+func handlePeeringTrustedList(c echo.Context) error {
+	trustedPeers, err := registry.DefaultRegistry().TrustedPeers()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	response := make([]*model.PeeringTrustedNode, len(trustedPeers))
+	for i := range trustedPeers {
+		if response[i], err = makePeeringTrustedNode(trustedPeers[i]); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func handlePeeringTrustedPost(c echo.Context) error {
+	var req model.PeeringTrustedNode
+	var err error
+	if err = c.Bind(&req); err != nil {
+		return httperrors.BadRequest("Invalid request body.")
+	}
+	var pubKey kyber.Point
+	if pubKey, err = decodePeerPubKey(req.PubKey); err != nil {
+		return err
+	}
+	var tp *peering.TrustedPeer
+	if tp, err = registry.DefaultRegistry().TrustPeer(pubKey, req.Name); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	var response *model.PeeringTrustedNode
+	if response, err = makePeeringTrustedNode(tp); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func handlePeeringTrustedDelete(c echo.Context) error {
+	var err error
+	var pubKeyStr string
+	if pubKeyStr, err = url.PathUnescape(c.Param("pubKey")); err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid pubKey=%v", c.Param("pubKey")))
+	}
+	var pubKey kyber.Point
+	if pubKey, err = decodePeerPubKey(pubKeyStr); err != nil {
+		return err
+	}
+	reg := registry.DefaultRegistry()
+	if err = reg.IsTrustedPeer(pubKey); err != nil {
+		return httperrors.NotFound(err.Error())
+	}
+	var tp *peering.TrustedPeer
+	if tp, err = reg.DistrustPeer(pubKey); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	var response *model.PeeringTrustedNode
+	if response, err = makePeeringTrustedNode(tp); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func decodePeerPubKey(encoded string) (kyber.Point, error) {
+	pubKeys, err := decodePeerPubKeys(dkg.DefaultNode().GroupSuite(), "PubKey", []string{encoded})
+	if err != nil {
+		return nil, err
+	}
+	return pubKeys[0], nil
+}
+
+func makePeeringTrustedNode(tp *peering.TrustedPeer) (*model.PeeringTrustedNode, error) {
+	pubKeyBytes, err := tp.PubKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &model.PeeringTrustedNode{
+		PubKey: base64.StdEncoding.EncodeToString(pubKeyBytes),
+		Name:   tp.Name,
+	}, nil
+}
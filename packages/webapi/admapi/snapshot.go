@@ -0,0 +1,119 @@
+package admapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/util"
+	"github.com/iotaledger/wasp/packages/webapi/httperrors"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+	"github.com/iotaledger/wasp/plugins/chains"
+	"github.com/labstack/echo/v4"
+	"github.com/pangpanglabs/echoswagger/v2"
+)
+
+func addSnapshotEndpoints(adm echoswagger.ApiGroup) {
+	example := model.Snapshot{
+		BlockIndex: 42,
+		StateHash:  model.NewHashValue(hashing.RandomHash(nil)),
+		Data:       model.NewBytes([]byte("snapshot data")),
+	}
+
+	adm.GET(routes.Snapshot(":chainID"), handleExportSnapshot).
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddResponse(http.StatusOK, "Snapshot of the solid state of the chain", example, nil).
+		SetSummary("Export the snapshot of the chain state").
+		SetDescription("The export has no side effects. Confirm the snapshot once it is stored safely")
+
+	adm.POST(routes.ConfirmSnapshot(":chainID"), handleConfirmSnapshot).
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddParamBody(model.SnapshotConfirmation{BlockIndex: 42}, "SnapshotConfirmation", "Block index of the exported snapshot", true).
+		SetSummary("Confirm the exported snapshot of the chain state").
+		SetDescription("Records the snapshot as the latest one. Blocks older than the latest snapshot may be pruned, if enabled by the node configuration")
+
+	adm.POST(routes.Snapshot(":chainID"), handleImportSnapshot).
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddParamBody(example, "Snapshot", "Snapshot of the chain state", true).
+		SetSummary("Import the snapshot of the chain state").
+		SetDescription("The chain must not be active. The imported state is validated against the anchor transaction when the chain is activated")
+}
+
+func parseChainID(c echo.Context) (coretypes.ChainID, error) {
+	scAddress, err := address.FromBase58(c.Param("chainID"))
+	if err != nil {
+		return coretypes.ChainID{}, httperrors.BadRequest(fmt.Sprintf("Invalid chain id: %s", c.Param("chainID")))
+	}
+	return (coretypes.ChainID)(scAddress), nil
+}
+
+func handleExportSnapshot(c echo.Context) error {
+	chainID, err := parseChainID(c)
+	if err != nil {
+		return err
+	}
+	if _, _, ok, err := state.LoadSolidState(&chainID); err != nil {
+		return err
+	} else if !ok {
+		return httperrors.NotFound(fmt.Sprintf("State not found for chain %s", chainID.String()))
+	}
+	snapshot, err := state.ExportSnapshot(&chainID)
+	if err != nil {
+		return err
+	}
+	data, err := util.Bytes(snapshot)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, &model.Snapshot{
+		BlockIndex: snapshot.BlockIndex,
+		StateHash:  model.NewHashValue(snapshot.StateHash),
+		Data:       model.NewBytes(data),
+	})
+}
+
+func handleConfirmSnapshot(c echo.Context) error {
+	chainID, err := parseChainID(c)
+	if err != nil {
+		return err
+	}
+	var req model.SnapshotConfirmation
+	if err := c.Bind(&req); err != nil {
+		return httperrors.BadRequest("Invalid request body")
+	}
+	if err := state.ConfirmSnapshot(&chainID, req.BlockIndex); err != nil {
+		return httperrors.BadRequest(err.Error())
+	}
+	log.Infof("confirmed snapshot of the state #%d of the chain %s", req.BlockIndex, chainID.String())
+	return c.NoContent(http.StatusOK)
+}
+
+func handleImportSnapshot(c echo.Context) error {
+	chainID, err := parseChainID(c)
+	if err != nil {
+		return err
+	}
+	var req model.Snapshot
+	if err := c.Bind(&req); err != nil {
+		return httperrors.BadRequest("Invalid request body")
+	}
+	snapshot, err := state.SnapshotFromBytes(req.Data.Bytes())
+	if err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid snapshot: %v", err))
+	}
+	if snapshot.ChainID != chainID {
+		return httperrors.BadRequest(fmt.Sprintf("Snapshot of the chain %s can't be imported to the chain %s", snapshot.ChainID.String(), chainID.String()))
+	}
+	if chains.GetChain(chainID) != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Chain %s is active. Deactivate it before importing the snapshot", chainID.String()))
+	}
+	if err := state.ImportSnapshot(snapshot); err != nil {
+		return httperrors.BadRequest(err.Error())
+	}
+	log.Infof("imported snapshot of the state #%d of the chain %s", snapshot.BlockIndex, chainID.String())
+	return c.NoContent(http.StatusOK)
+}
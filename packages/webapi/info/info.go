@@ -7,6 +7,7 @@ import (
 	"github.com/iotaledger/wasp/packages/webapi/model"
 	"github.com/iotaledger/wasp/packages/webapi/routes"
 	"github.com/iotaledger/wasp/plugins/banner"
+	"github.com/iotaledger/wasp/plugins/chains"
 	"github.com/iotaledger/wasp/plugins/peering"
 	"github.com/labstack/echo/v4"
 	"github.com/pangpanglabs/echoswagger/v2"
@@ -23,5 +24,23 @@ func handleInfo(c echo.Context) error {
 		Version:       banner.AppVersion,
 		NetworkId:     peering.DefaultNetworkProvider().Self().NetID(),
 		PublisherPort: parameters.GetInt(parameters.NanomsgPublisherPort),
+		Chains:        chainsSyncStatus(),
 	})
 }
+
+func chainsSyncStatus() []model.ChainSyncStatus {
+	ret := make([]model.ChainSyncStatus, 0)
+	for _, c := range chains.ActiveChains() {
+		s := c.SyncStatus()
+		ret = append(ret, model.ChainSyncStatus{
+			ChainID:         model.NewChainID(c.ID()),
+			Synced:          s.Synced,
+			SolidIndex:      s.SolidIndex,
+			TargetIndex:     s.TargetIndex,
+			BlocksPerSecond: s.BlocksPerSecond,
+			BlocksFetched:   s.BlocksFetched,
+			PeersUsed:       s.PeersUsed,
+		})
+	}
+	return ret
+}
@@ -1,30 +1,68 @@
 package model
 
 import (
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/registry"
 )
 
 type ChainRecord struct {
-	ChainID        ChainID  `swagger:"desc(ChainID (base58-encoded))"`
-	Color          Color    `swagger:"desc(Chain color (base58-encoded))"`
-	CommitteeNodes []string `swagger:"desc(List of committee nodes (network IDs))"`
-	Active         bool     `swagger:"desc(Whether or not the chain is active)"`
+	ChainID            ChainID  `swagger:"desc(ChainID (base58-encoded))"`
+	Color              Color    `swagger:"desc(Chain color (base58-encoded))"`
+	CommitteeNodes     []string `swagger:"desc(List of committee nodes (network IDs))"`
+	Active             bool     `swagger:"desc(Whether or not the chain is active)"`
+	StateAddress       *Address `json:",omitempty" swagger:"desc(Address of the committee which holds the chain token. Empty if equal to the chain ID)"`
+	PeerNodes          []string `json:",omitempty" swagger:"desc(List of nodes which are peers of the chain but not in the committee (network IDs))"`
+	NextCommitteeNodes []string `json:",omitempty" swagger:"desc(List of nodes of the committee the chain is being rotated to (network IDs))"`
+	NextStateAddress   *Address `json:",omitempty" swagger:"desc(Address of the committee the chain is being rotated to)"`
+	Consensus          string   `json:",omitempty" swagger:"desc(Consensus algorithm of the chain: leader or bft. Empty means leader)"`
 }
 
 func NewChainRecord(bd *registry.ChainRecord) *ChainRecord {
 	return &ChainRecord{
-		ChainID:        NewChainID(&bd.ChainID),
-		Color:          NewColor(&bd.Color),
-		CommitteeNodes: bd.CommitteeNodes[:],
-		Active:         bd.Active,
+		ChainID:            NewChainID(&bd.ChainID),
+		Color:              NewColor(&bd.Color),
+		CommitteeNodes:     bd.CommitteeNodes[:],
+		Active:             bd.Active,
+		StateAddress:       newOptionalAddress(bd.StateAddress),
+		PeerNodes:          bd.PeerNodes,
+		NextCommitteeNodes: bd.NextCommitteeNodes,
+		NextStateAddress:   newOptionalAddress(bd.NextStateAddress),
+		Consensus:          bd.Consensus,
 	}
 }
 
 func (bd *ChainRecord) ChainRecord() *registry.ChainRecord {
 	return &registry.ChainRecord{
-		ChainID:        bd.ChainID.ChainID(),
-		Color:          bd.Color.Color(),
-		CommitteeNodes: bd.CommitteeNodes[:],
-		Active:         bd.Active,
+		ChainID:            bd.ChainID.ChainID(),
+		Color:              bd.Color.Color(),
+		CommitteeNodes:     bd.CommitteeNodes[:],
+		Active:             bd.Active,
+		StateAddress:       bd.StateAddress.optionalAddress(),
+		PeerNodes:          bd.PeerNodes,
+		NextCommitteeNodes: bd.NextCommitteeNodes,
+		NextStateAddress:   bd.NextStateAddress.optionalAddress(),
+		Consensus:          bd.Consensus,
 	}
 }
+
+// RotateCommittee is the request to prepare the node for the rotation of the chain to the next committee
+type RotateCommittee struct {
+	NextCommitteeNodes []string `swagger:"desc(List of nodes of the next committee (network IDs))"`
+	NextStateAddress   Address  `swagger:"desc(Address of the next committee)"`
+}
+
+func newOptionalAddress(addr *address.Address) *Address {
+	if addr == nil {
+		return nil
+	}
+	ret := NewAddress(addr)
+	return &ret
+}
+
+func (a *Address) optionalAddress() *address.Address {
+	if a == nil {
+		return nil
+	}
+	ret := a.Address()
+	return &ret
+}
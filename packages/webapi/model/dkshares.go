@@ -16,3 +16,18 @@ type DKSharesInfo struct {
 	Threshold    uint16   `json:"threshold"`
 	PeerIndex    *uint16  `json:"peerIndex" swagger:"desc(Index of the node returning the share, if it is a member of the sharing group.)"`
 }
+
+// DKSharesReshareRequest is a POST request for resharing an existing DKShare to other nodes.
+type DKSharesReshareRequest struct {
+	OldPeerNetIDs  []string `json:"oldPeerNetIDs" swagger:"desc(NetIDs of the nodes currently sharing the key, in the order of their shares.)"`
+	OldPeerPubKeys []string `json:"oldPeerPubKeys" swagger:"desc(Optional, base64 encoded public keys of the nodes currently sharing the key.)"`
+	PeerNetIDs     []string `json:"peerNetIDs" swagger:"desc(NetIDs of the nodes receiving the new shares of the key.)"`
+	PeerPubKeys    []string `json:"peerPubKeys" swagger:"desc(Optional, base64 encoded public keys of the nodes receiving the new shares.)"`
+	Threshold      uint16   `json:"threshold" swagger:"desc(Should be =< len(PeerNetIDs))"`
+	TimeoutMS      uint16   `json:"timeoutMS" swagger:"desc(Timeout in milliseconds.)"`
+}
+
+// DKSharesSigShare is the signature share of the node, returned by the rotation proof endpoint.
+type DKSharesSigShare struct {
+	SigShare string `json:"sigShare" swagger:"desc(Signature share of the node (base64-encoded).)"`
+}
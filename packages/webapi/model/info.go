@@ -1,7 +1,18 @@
 package model
 
 type InfoResponse struct {
-	Version       string `swagger:"desc(Wasp version)"`
-	NetworkId     string `swagger:"desc('hostname:port'; uniquely identifies the node)"`
-	PublisherPort int    `swagger:"desc(Nanomsg port that exposes publisher messages)"`
+	Version       string            `swagger:"desc(Wasp version)"`
+	NetworkId     string            `swagger:"desc('hostname:port'; uniquely identifies the node)"`
+	PublisherPort int               `swagger:"desc(Nanomsg port that exposes publisher messages)"`
+	Chains        []ChainSyncStatus `swagger:"desc(Sync status of active chains)"`
+}
+
+type ChainSyncStatus struct {
+	ChainID         ChainID  `swagger:"desc(ChainID (base58-encoded))"`
+	Synced          bool     `swagger:"desc(Whether or not the state of the node is the latest state evidenced by peers)"`
+	SolidIndex      uint32   `swagger:"desc(Index of the solid state of the node)"`
+	TargetIndex     uint32   `swagger:"desc(Largest state index evidenced by peers)"`
+	BlocksPerSecond float64  `swagger:"desc(Blocks applied to the state per second since the sync started)"`
+	BlocksFetched   int      `swagger:"desc(Number of blocks fetched ahead and waiting to be applied)"`
+	PeersUsed       []uint16 `swagger:"desc(Indices of committee peers which blocks were requested from)"`
 }
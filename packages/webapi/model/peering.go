@@ -0,0 +1,13 @@
+package model
+
+// PeeringNodeIdentity describes the identity of a node in the peering network.
+type PeeringNodeIdentity struct {
+	NetID  string `json:"netID" swagger:"desc(NetID of the node, 'hostname:port'.)"`
+	PubKey string `json:"pubKey" swagger:"desc(Public key of the node (base64-encoded).)"`
+}
+
+// PeeringTrustedNode describes a peer trusted by the node.
+type PeeringTrustedNode struct {
+	PubKey string `json:"pubKey" swagger:"desc(Public key of the peer (base64-encoded).)"`
+	Name   string `json:"name" swagger:"desc(A human readable name of the peer.)"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+)
+
+type RequestReceipt struct {
+	RequestID    string    `swagger:"desc(Request ID (base58-encoded))"`
+	BlockIndex   uint32    `swagger:"desc(Index of the block the request was settled in)"`
+	RequestIndex uint16    `swagger:"desc(Position of the request in the block)"`
+	Error        string    `swagger:"desc(Error returned by the request. Empty if the request was successful)"`
+	FeeColor     Color     `swagger:"desc(Color of the fees (base58-encoded))"`
+	FeesCharged  int64     `swagger:"desc(Fees charged for the request)"`
+	GasBurned    int64     `swagger:"desc(Gas burned by the request)"`
+	Result       dict.Dict `swagger:"desc(Result returned by the request, if it was stored)"`
+}
+
+func NewRequestReceipt(rec *receipts.RequestReceipt) *RequestReceipt {
+	return &RequestReceipt{
+		RequestID:    rec.RequestID.Base58(),
+		BlockIndex:   rec.BlockIndex,
+		RequestIndex: rec.RequestIndex,
+		Error:        rec.Error,
+		FeeColor:     NewColor(&rec.FeeColor),
+		FeesCharged:  rec.FeesCharged,
+		GasBurned:    rec.GasBurned,
+		Result:       rec.Result,
+	}
+}
+
+func (r *RequestReceipt) RequestReceipt() (*receipts.RequestReceipt, error) {
+	reqid, err := coretypes.NewRequestIDFromBase58(r.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	return &receipts.RequestReceipt{
+		RequestID:    reqid,
+		BlockIndex:   r.BlockIndex,
+		RequestIndex: r.RequestIndex,
+		Error:        r.Error,
+		FeeColor:     r.FeeColor.Color(),
+		FeesCharged:  r.FeesCharged,
+		GasBurned:    r.GasBurned,
+		Result:       r.Result,
+	}, nil
+}
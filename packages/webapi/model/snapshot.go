@@ -0,0 +1,11 @@
+package model
+
+type Snapshot struct {
+	BlockIndex uint32    `swagger:"desc(Index of the last block of the state)"`
+	StateHash  HashValue `swagger:"desc(Hash of the state)"`
+	Data       Bytes     `swagger:"desc(Encoded snapshot of the state (base64))"`
+}
+
+type SnapshotConfirmation struct {
+	BlockIndex uint32 `swagger:"desc(Index of the last block of the exported snapshot)"`
+}
@@ -0,0 +1,10 @@
+package model
+
+type StateProofResponse struct {
+	StateIndex uint32    `swagger:"desc(Index of the state the proof refers to)"`
+	StateRoot  HashValue `swagger:"desc(Root of the Merkle tree of the state, committed in the anchor transaction)"`
+	StateTxId  ValueTxID `swagger:"desc(ID of the anchor transaction of the state)"`
+	Included   bool      `swagger:"desc(True if the key exists in the state)"`
+	Value      Bytes     `swagger:"desc(Value of the key (base64), if it exists)"`
+	Proof      Bytes     `swagger:"desc(Serialized proof of inclusion or non-inclusion (base64))"`
+}
@@ -0,0 +1,51 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/vm/core/receipts"
+	"github.com/iotaledger/wasp/packages/vm/viewcontext"
+	"github.com/iotaledger/wasp/packages/webapi/httperrors"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+	"github.com/labstack/echo/v4"
+	"github.com/pangpanglabs/echoswagger/v2"
+)
+
+func addRequestReceiptEndpoint(server echoswagger.ApiRouter) {
+	server.GET(routes.RequestReceipt(":chainID", ":reqID"), handleRequestReceipt).
+		SetSummary("Get the receipt of the request processed by the chain").
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddParamPath("", "reqID", "Request ID (base58)").
+		AddResponse(http.StatusOK, "Request receipt", model.RequestReceipt{}, nil)
+}
+
+func handleRequestReceipt(c echo.Context) error {
+	ch, reqID, err := parseParams(c)
+	if err != nil {
+		return err
+	}
+	vctx, err := viewcontext.NewFromDB(*ch.ID(), ch.Processors())
+	if err != nil {
+		return fmt.Errorf("Failed to create context: %v", err)
+	}
+	ret, err := vctx.CallView(receipts.Interface.Hname(), coretypes.Hn(receipts.FuncGetRequestReceipt),
+		codec.MakeDict(map[string]interface{}{
+			receipts.ParamRequestID: *reqID,
+		}))
+	if err != nil {
+		return err
+	}
+	data := ret.MustGet(receipts.ParamReceipt)
+	if data == nil {
+		return httperrors.NotFound(fmt.Sprintf("Receipt not found for request %s", reqID.Base58()))
+	}
+	rec, err := receipts.DecodeRequestReceipt(data)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, model.NewRequestReceipt(rec))
+}
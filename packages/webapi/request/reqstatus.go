@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/wasp/packages/chain"
 	"github.com/iotaledger/wasp/packages/coretypes"
@@ -28,6 +29,8 @@ func AddEndpoints(server echoswagger.ApiRouter) {
 		AddParamPath("", "chainID", "ChainID (base58)").
 		AddParamPath("", "reqID", "Request ID (base58)").
 		AddParamBody(model.WaitRequestProcessedParams{}, "Params", "Optional parameters", false)
+
+	addRequestReceiptEndpoint(server)
 }
 
 func handleRequestStatus(c echo.Context) error {
@@ -95,6 +98,10 @@ func parseParams(c echo.Context) (chain.Chain, *coretypes.RequestID, error) {
 		return nil, nil, httperrors.BadRequest(fmt.Sprintf("Invalid chain ID %+v: %s", c.Param("chainID"), err.Error()))
 	}
 	chain := chains.GetChain(chainID)
+	if chain == nil {
+		// requests target the current address of the chain
+		chain = chains.GetChainByAddress(address.Address(chainID))
+	}
 	if chain == nil {
 		return nil, nil, httperrors.NotFound(fmt.Sprintf("Chain not found: %+v", chainID.String()))
 	}
@@ -16,10 +16,18 @@ func WaitRequestProcessed(chainID string, reqID string) string {
 	return "/chain/" + chainID + "/request/" + reqID + "/wait"
 }
 
+func RequestReceipt(chainID string, reqID string) string {
+	return "/chain/" + chainID + "/request/" + reqID + "/receipt"
+}
+
 func StateQuery(chainID string) string {
 	return "/chain/" + chainID + "/state/query"
 }
 
+func StateProof(chainID string, key string) string {
+	return "/chain/" + chainID + "/state/proof/" + key
+}
+
 func PutBlob() string {
 	return "/blob/put"
 }
@@ -40,6 +48,18 @@ func DeactivateChain(chainID string) string {
 	return "/adm/chain/" + chainID + "/deactivate"
 }
 
+func RotateCommittee(chainID string) string {
+	return "/adm/chain/" + chainID + "/rotate"
+}
+
+func Snapshot(chainID string) string {
+	return "/adm/chain/" + chainID + "/snapshot"
+}
+
+func ConfirmSnapshot(chainID string) string {
+	return "/adm/chain/" + chainID + "/snapshot/confirm"
+}
+
 func ListChainRecords() string {
 	return "/adm/chainrecords"
 }
@@ -60,6 +80,14 @@ func DKSharesGet(sharedAddress string) string {
 	return "/adm/dks/" + sharedAddress
 }
 
+func DKSharesReshare(sharedAddress string) string {
+	return "/adm/dks/" + sharedAddress + "/reshare"
+}
+
+func DKSharesSignRotation(sharedAddress string, chainID string) string {
+	return "/adm/dks/" + sharedAddress + "/rotation/" + chainID
+}
+
 func DumpState(contractID string) string {
 	return "/adm/contract/" + contractID + "/dumpstate"
 }
@@ -67,3 +95,19 @@ func DumpState(contractID string) string {
 func Shutdown() string {
 	return "/adm/shutdown"
 }
+
+func PeeringSelfGet() string {
+	return "/adm/peering/self"
+}
+
+func PeeringTrustedList() string {
+	return "/adm/peering/trusted"
+}
+
+func PeeringTrustedPost() string {
+	return "/adm/peering/trusted"
+}
+
+func PeeringTrustedDelete(pubKey string) string {
+	return "/adm/peering/trusted/" + pubKey
+}
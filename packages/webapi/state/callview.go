@@ -27,6 +27,8 @@ func AddEndpoints(server echoswagger.ApiRouter) {
 		AddParamPath("getInfo", "fname", "Function name").
 		AddParamBody(dictExample, "params", "Parameters", false).
 		AddResponse(http.StatusOK, "Result", dictExample, nil)
+
+	addStateProofEndpoint(server)
 }
 
 func handleCallView(c echo.Context) error {
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/state"
+	"github.com/iotaledger/wasp/packages/webapi/httperrors"
+	"github.com/iotaledger/wasp/packages/webapi/model"
+	"github.com/iotaledger/wasp/packages/webapi/routes"
+	"github.com/iotaledger/wasp/plugins/chains"
+	"github.com/labstack/echo/v4"
+	"github.com/pangpanglabs/echoswagger/v2"
+)
+
+func addStateProofEndpoint(server echoswagger.ApiRouter) {
+	server.GET(routes.StateProof(":chainID", ":key"), handleStateProof).
+		SetSummary("Get the value of the key in the chain state together with the proof of inclusion (or non-inclusion)").
+		AddParamPath("", "chainID", "ChainID (base58)").
+		AddParamPath("", "key", "Key (hex)").
+		AddResponse(http.StatusOK, "Proof", model.StateProofResponse{}, nil)
+}
+
+func handleStateProof(c echo.Context) error {
+	chainID, err := coretypes.NewChainIDFromBase58(c.Param("chainID"))
+	if err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid chain ID: %+v", c.Param("chainID")))
+	}
+	key, err := hex.DecodeString(c.Param("key"))
+	if err != nil {
+		return httperrors.BadRequest(fmt.Sprintf("Invalid key: %+v", c.Param("key")))
+	}
+	if chains.GetChain(chainID) == nil {
+		return httperrors.NotFound(fmt.Sprintf("Chain not found: %s", chainID))
+	}
+
+	// TODO serialize access to solid state
+	vs, block, exist, err := state.LoadSolidState(&chainID)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return httperrors.NotFound(fmt.Sprintf("State not found for chain %s", chainID))
+	}
+	proof, err := vs.Proof(kv.Key(key))
+	if err != nil {
+		return err
+	}
+	txid := block.StateTransactionID()
+	return c.JSON(http.StatusOK, model.StateProofResponse{
+		StateIndex: vs.BlockIndex(),
+		StateRoot:  model.NewHashValue(vs.StateRoot()),
+		StateTxId:  model.NewValueTxID(&txid),
+		Included:   proof.Included,
+		Value:      model.NewBytes(proof.Value),
+		Proof:      model.NewBytes(proof.Bytes()),
+	})
+}
@@ -2,15 +2,20 @@ package chains
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
+	"time"
 
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/wasp/packages/coretypes"
 
 	"github.com/iotaledger/hive.go/daemon"
+	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/hive.go/node"
+	"github.com/iotaledger/wasp/packages/blobfetcher"
 	"github.com/iotaledger/wasp/packages/chain"
+	"github.com/iotaledger/wasp/packages/parameters"
 	registry_pkg "github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/plugins/nodeconn"
 	"github.com/iotaledger/wasp/plugins/peering"
@@ -24,6 +29,8 @@ var (
 
 	chains      = make(map[coretypes.ChainID]chain.Chain)
 	chainsMutex = &sync.RWMutex{}
+	// blob cache of the node which downloads missing blobs from configured sources. Created upon first activation
+	blobs *blobfetcher.Fetcher
 )
 
 func Init() *node.Plugin {
@@ -32,6 +39,24 @@ func Init() *node.Plugin {
 
 func configure(_ *node.Plugin) {
 	log = logger.NewLogger(PluginName)
+	chain.ArgSolidificationDeadline = time.Duration(parameters.GetInt(parameters.BlobFetcherDeadline)) * time.Second
+	chain.PruningKeepBlocks = parameters.GetInt(parameters.StatePruningKeepBlocks)
+	chain.VMParallelism = parameters.GetInt(parameters.VMParallelism)
+	if chain.VMParallelism <= 0 {
+		chain.VMParallelism = runtime.NumCPU()
+	}
+}
+
+// newBlobFetcher creates the blob cache on top of the registry with download sources from the configuration
+func newBlobFetcher() *blobfetcher.Fetcher {
+	sources := make([]blobfetcher.Source, 0)
+	if dir := parameters.GetString(parameters.BlobFetcherDirectory); dir != "" {
+		sources = append(sources, blobfetcher.NewDirSource(dir))
+	}
+	for _, url := range parameters.GetStringSlice(parameters.BlobFetcherHTTPSources) {
+		sources = append(sources, blobfetcher.NewHTTPSource(url))
+	}
+	return blobfetcher.New(registry.DefaultRegistry(), log.Named("blobs"), sources...)
 }
 
 func run(_ *node.Plugin) {
@@ -87,17 +112,23 @@ func ActivateChain(chr *registry_pkg.ChainRecord) error {
 		return fmt.Errorf("cannot activate chain for deactivated chain record")
 	}
 
-	_, ok := chains[chr.ChainID]
-	if ok {
+	c, ok := chains[chr.ChainID]
+	if ok && !c.IsDismissed() {
 		log.Debugf("chain is already active: %s", chr.ChainID.String())
 		return nil
 	}
 	// create new chain object
-	defaultRegistry := registry.DefaultRegistry()
-	c := chain.New(chr, log, peering.DefaultNetworkProvider(), defaultRegistry, defaultRegistry, func() {
-		nodeconn.Subscribe((address.Address)(chr.ChainID), chr.Color)
+	if blobs == nil {
+		blobs = newBlobFetcher()
+	}
+	chainID := chr.ChainID
+	c = chain.New(chr, log, peering.DefaultNetworkProvider(), registry.DefaultRegistry(), blobs, func() {
+		nodeconn.Subscribe(chr.Address(), chr.Color)
 	})
 	if c != nil {
+		c.EventStateAddressChanged().Attach(events.NewClosure(func(addr address.Address) {
+			moveChain(chainID, addr)
+		}))
 		chains[chr.ChainID] = c
 		log.Infof("activated chain:\n%s", chr.String())
 	} else {
@@ -117,6 +148,7 @@ func DeactivateChain(chr *registry_pkg.ChainRecord) error {
 		return nil
 	}
 	c.Dismiss()
+	nodeconn.Unsubscribe(c.Address())
 	log.Debugf("chain has been deactivated: %s", chr.ChainID.String())
 	return nil
 }
@@ -129,8 +161,116 @@ func GetChain(chainID coretypes.ChainID) chain.Chain {
 	ret, ok := chains[chainID]
 	if ok && ret.IsDismissed() {
 		delete(chains, chainID)
-		nodeconn.Unsubscribe((address.Address)(chainID))
+		nodeconn.Unsubscribe(ret.Address())
 		return nil
 	}
 	return ret
 }
+
+// GetChainByAddress returns active chain object which is controlled by the address or nil if it doesn't exist.
+// The address is the alias of the chain ID unless the committee of the chain was rotated
+func GetChainByAddress(addr address.Address) chain.Chain {
+	if ret := GetChain((coretypes.ChainID)(addr)); ret != nil && ret.Address() == addr {
+		return ret
+	}
+	chainsMutex.RLock()
+	defer chainsMutex.RUnlock()
+
+	for _, c := range chains {
+		if c.Address() == addr && !c.IsDismissed() {
+			return c
+		}
+	}
+	return nil
+}
+
+// ActiveChains returns all active chain objects
+func ActiveChains() []chain.Chain {
+	chainsMutex.RLock()
+	defer chainsMutex.RUnlock()
+
+	ret := make([]chain.Chain, 0, len(chains))
+	for _, c := range chains {
+		if !c.IsDismissed() {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// moveChain is called when the chain was moved to the address of the next committee.
+// The node switches the chain to the next committee if it is a member of it. Otherwise the chain is deactivated
+// after a grace period, during which the node provides blocks to the nodes of the next committee
+func moveChain(chainID coretypes.ChainID, addr address.Address) {
+	rotated := false
+	chr, err := registry_pkg.UpdateChainRecord(&chainID, func(bd *registry_pkg.ChainRecord) bool {
+		if rotated = bd.RotateToNextCommittee(addr); !rotated {
+			return false
+		}
+		bd.Active = bd.InCommittee(peering.DefaultNetworkProvider().Self().NetID())
+		return true
+	})
+	if err != nil {
+		log.Errorf("failed to move chain %s to address %s: %v", chainID.String(), addr.String(), err)
+		return
+	}
+	if !rotated {
+		log.Debugf("chain %s was moved to address %s, the node is not being rotated to it", chainID.String(), addr.String())
+		return
+	}
+	if !chr.Active {
+		log.Infof("chain %s was moved to the next committee. The node will be deactivated in %v",
+			chainID.String(), chain.RotationGracePeriod)
+		time.AfterFunc(chain.RotationGracePeriod, func() {
+			if err := DeactivateChain(chr); err != nil {
+				log.Errorf("cannot deactivate chain %s: %v", chainID.String(), err)
+			}
+		})
+		return
+	}
+	log.Infof("chain %s was moved to the next committee. Restarting the chain with the committee", chainID.String())
+	if err := DeactivateChain(chr); err != nil {
+		log.Errorf("cannot deactivate chain %s: %v", chainID.String(), err)
+		return
+	}
+	if err := ActivateChain(chr); err != nil {
+		log.Errorf("cannot activate chain %s: %v", chainID.String(), err)
+	}
+}
+
+// SwitchToResharedCommittee switches the chain to the next committee, which holds the key of the chain
+// reshared from the current committee, so the chain stays at its address. The chain is stopped before the
+// switch, because the key shares of the current committee are retired. The node is only restarted if it
+// is a member of the next committee, the nodes of the next committee sync the state from each other
+func SwitchToResharedCommittee(chainID coretypes.ChainID, nextCommitteeNodes []string) error {
+	chr, err := registry_pkg.GetChainRecord(&chainID)
+	if err != nil {
+		return err
+	}
+	if chr == nil {
+		return fmt.Errorf("chain record not found: %s", chainID.String())
+	}
+	if err := DeactivateChain(chr); err != nil {
+		return err
+	}
+	addr := chr.Address()
+	chr, err = registry_pkg.UpdateChainRecord(&chainID, func(bd *registry_pkg.ChainRecord) bool {
+		bd.NextCommitteeNodes = nextCommitteeNodes
+		bd.NextStateAddress = &addr
+		bd.RotateToNextCommittee(addr)
+		// the nodes leaving the committee do not run the chain anymore
+		bd.PeerNodes = nil
+		bd.Active = bd.Active && bd.InCommittee(peering.DefaultNetworkProvider().Self().NetID())
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if !chr.Active {
+		log.Infof("chain %s was switched to the reshared committee %+v, the node is not a member of it",
+			chainID.String(), nextCommitteeNodes)
+		return nil
+	}
+	log.Infof("chain %s was switched to the reshared committee %+v. Restarting the chain", chainID.String(), nextCommitteeNodes)
+	return ActivateChain(chr)
+}
@@ -0,0 +1,95 @@
+package database_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/transaction"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/dbprovider"
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+	"github.com/iotaledger/wasp/packages/kv/buffered"
+	"github.com/iotaledger/wasp/packages/state"
+)
+
+// benchmarks of committing blocks with many state updates to the database with different storage engines:
+//   go test -run XXX -bench Commit ./plugins/database
+
+const (
+	benchValueSize       = 100
+	benchUpdatesPerBlock = 100
+)
+
+func benchmarkCommit(b *testing.B, engine string, mutationsPerUpdate int) {
+	dir, err := ioutil.TempDir("", "wasp-bench-"+engine)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend, err := dbprovider.NewBackend(engine, dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer backend.Close()
+
+	chainID := coretypes.ChainID{1, 3, 3, 7}
+	vs := state.NewVirtualState(backend.NewStore().WithRealm(chainID[:]), &chainID)
+	value := make([]byte, benchValueSize)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		updates := make([]state.StateUpdate, benchUpdatesPerBlock)
+		for u := range updates {
+			txid := (transaction.ID)(hashing.HashStrings(fmt.Sprintf("tx %d %d", i, u)))
+			reqid := coretypes.NewRequestID(txid, 0)
+			updates[u] = state.NewStateUpdate(&reqid)
+			for m := 0; m < mutationsPerUpdate; m++ {
+				key := kv.Key(fmt.Sprintf("key %d %d %d", i, u, m))
+				updates[u].Mutations().Add(buffered.NewMutationSet(key, value))
+			}
+		}
+		block, err := state.NewBlock(updates)
+		if err != nil {
+			b.Fatal(err)
+		}
+		block.WithBlockIndex(uint32(i)).WithStateTransaction(transaction.ID{})
+		if err := vs.ApplyBlock(block); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := vs.CommitToDb(block); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCommitBadger10(b *testing.B) {
+	benchmarkCommit(b, dbprovider.EngineBadger, 10)
+}
+
+func BenchmarkCommitBolt10(b *testing.B) {
+	benchmarkCommit(b, dbprovider.EngineBolt, 10)
+}
+
+func BenchmarkCommitMemory10(b *testing.B) {
+	benchmarkCommit(b, dbprovider.EngineMemory, 10)
+}
+
+func BenchmarkCommitBadger100(b *testing.B) {
+	benchmarkCommit(b, dbprovider.EngineBadger, 100)
+}
+
+func BenchmarkCommitBolt100(b *testing.B) {
+	benchmarkCommit(b, dbprovider.EngineBolt, 100)
+}
+
+func BenchmarkCommitMemory100(b *testing.B) {
+	benchmarkCommit(b, dbprovider.EngineMemory, 100)
+}
@@ -1,4 +1,4 @@
-// Package database is a plugin that manages the badger database (e.g. garbage collection).
+// Package database is a plugin that manages the database (e.g. garbage collection).
 package database
 
 import (
@@ -8,6 +8,7 @@ import (
 	"github.com/iotaledger/wasp/packages/dbprovider"
 	"github.com/iotaledger/wasp/packages/parameters"
 	"sync"
+	"time"
 
 	"github.com/iotaledger/hive.go/daemon"
 	"github.com/iotaledger/hive.go/logger"
@@ -56,6 +57,13 @@ func run(_ *node.Plugin) {
 	if err != nil {
 		log.Errorf("failed to start as daemon: %s", err)
 	}
+	interval := time.Duration(parameters.GetInt(parameters.DatabaseCompactionInterval)) * time.Hour
+	err = daemon.BackgroundWorker(pluginName+"[Compaction]", func(shutdownSignal <-chan struct{}) {
+		dbProvider.RunCompaction(interval, shutdownSignal)
+	}, parameters.PriorityDatabaseCompaction)
+	if err != nil {
+		log.Errorf("failed to start as daemon: %s", err)
+	}
 }
 
 func GetInstance() *dbprovider.DBProvider {
@@ -63,13 +71,24 @@ func GetInstance() *dbprovider.DBProvider {
 	return dbProvider
 }
 
+// InitInMemory sets up the in-memory database without running the plugin, e.g. in tests of the components
+// which use the database. It has no effect if the database is already set up
+func InitInMemory(l *logger.Logger) {
+	doOnce.Do(func() {
+		log = l
+		dbProvider = dbprovider.NewInMemoryDBProvider(log)
+	})
+}
+
 func createInstance() {
 	if parameters.GetBool(parameters.DatabaseInMemory) {
 		log.Infof("IN MEMORY DATABASE")
 		dbProvider = dbprovider.NewInMemoryDBProvider(log)
 	} else {
 		dbDir := parameters.GetString(parameters.DatabaseDir)
-		dbProvider = dbprovider.NewPersistentDBProvider(dbDir, log)
+		engine := parameters.GetString(parameters.DatabaseEngine)
+		log.Infof("%s database in %s", engine, dbDir)
+		dbProvider = dbprovider.NewPersistentDBProvider(engine, dbDir, log)
 	}
 }
 
@@ -82,3 +101,9 @@ func GetPartition(chainID *coretypes.ChainID) kvstore.KVStore {
 func GetRegistryPartition() kvstore.KVStore {
 	return GetInstance().GetRegistryPartition()
 }
+
+// GetPartitionSnapshot returns the consistent read-only view of the partition of the chain.
+// It must be released after use
+func GetPartitionSnapshot(chainID *coretypes.ChainID) (dbprovider.Snapshot, error) {
+	return GetInstance().GetPartitionSnapshot(chainID)
+}
@@ -33,9 +33,40 @@ func dispatchState(tx *sctransaction.Transaction) {
 	})
 }
 
+// dispatchConfirmedRequests passes the requests of the confirmed transaction to the chains they target.
+// The consensus fetches the confirmed transactions of the agreed requests which are unknown to the node
+func dispatchConfirmedRequests(tx *sctransaction.Transaction) {
+	txProp := tx.MustProperties() // was parsed before
+	// the free tokens are attached to the first request to the address, as in the address update
+	attached := make(map[address.Address]bool)
+	for i, reqBlk := range tx.Requests() {
+		cmt := chains.GetChain(reqBlk.Target().ChainID())
+		if cmt == nil {
+			continue
+		}
+		addr := cmt.Address()
+		var freeTokens coretypes.ColoredBalances
+		if !attached[addr] {
+			attached[addr] = true
+			if freeTokens = txProp.FreeTokensForAddress(addr); freeTokens != nil && freeTokens.Len() == 0 {
+				freeTokens = nil
+			}
+		}
+		log.Debugw("dispatchConfirmedRequests",
+			"txid", tx.ID().String(),
+			"chainid", cmt.ID().String(),
+		)
+		cmt.ReceiveMessage(&chain.RequestMsg{
+			Transaction: tx,
+			Index:       (uint16)(i),
+			FreeTokens:  freeTokens,
+		})
+	}
+}
+
 func dispatchBalances(addr address.Address, bals map[valuetransaction.ID][]*balance.Balance) {
 	// pass to the committee by address
-	if cmt := chains.GetChain((coretypes.ChainID)(addr)); cmt != nil {
+	if cmt := chains.GetChainByAddress(addr); cmt != nil {
 		cmt.ReceiveMessage(chain.BalancesMsg{Balances: bals})
 	}
 }
@@ -43,7 +74,7 @@ func dispatchBalances(addr address.Address, bals map[valuetransaction.ID][]*bala
 func dispatchAddressUpdate(addr address.Address, balances map[valuetransaction.ID][]*balance.Balance, tx *sctransaction.Transaction) {
 	log.Debugw("dispatchAddressUpdate", "addr", addr.String())
 
-	cmt := chains.GetChain((coretypes.ChainID)(addr))
+	cmt := chains.GetChainByAddress(addr)
 	if cmt == nil {
 		log.Debugw("committee not found", "addr", addr.String())
 		// wrong addressee
@@ -57,7 +88,7 @@ func dispatchAddressUpdate(addr address.Address, balances map[valuetransaction.I
 	})
 
 	txProp := tx.MustProperties() // was parsed before
-	if txProp.IsState() && *txProp.MustChainID() == (coretypes.ChainID)(addr) {
+	if txProp.IsState() && *txProp.MustChainAddress() == addr {
 		// it is a state update to addr. Send it
 		cmt.ReceiveMessage(&chain.StateTransactionMsg{
 			Transaction: tx,
@@ -72,6 +103,7 @@ func dispatchAddressUpdate(addr address.Address, balances map[valuetransaction.I
 	if freeTokens != nil && freeTokens.Len() == 0 {
 		freeTokens = nil
 	}
+	// requests to the chain are sent to its current address
 	for i, reqBlk := range tx.Requests() {
 		if reqBlk.Target().ChainID() == (coretypes.ChainID)(addr) {
 			cmt.ReceiveMessage(&chain.RequestMsg{
@@ -86,7 +118,7 @@ func dispatchAddressUpdate(addr address.Address, balances map[valuetransaction.I
 
 func dispatchTxInclusionLevel(level byte, txid *valuetransaction.ID, addrs []address.Address) {
 	for _, addr := range addrs {
-		cmt := chains.GetChain((coretypes.ChainID)(addr))
+		cmt := chains.GetChainByAddress(addr)
 		if cmt == nil {
 			continue
 		}
@@ -89,6 +89,7 @@ func processNodeMsg(msg interface{}) {
 			return
 		}
 		dispatchState(tx)
+		dispatchConfirmedRequests(tx)
 
 	case *waspconn.WaspFromNodeAddressOutputsMsg:
 		dispatchBalances(msgt.Address, msgt.Balances)
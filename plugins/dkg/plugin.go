@@ -34,6 +34,7 @@ func Init(suite dkg_pkg.Suite) *hive_node.Plugin {
 			keyPair.Public,
 			suite,
 			peeringProvider,
+			registry, // The registry is the trusted network manager as well.
 			registry,
 			logger,
 		)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"os"
 	"time"
 
 	"github.com/iotaledger/goshimmer/client/wallet/packages/seed"
@@ -13,6 +14,7 @@ import (
 	"github.com/iotaledger/wasp/client/chainclient"
 	"github.com/iotaledger/wasp/client/multiclient"
 	"github.com/iotaledger/wasp/client/scclient"
+	"github.com/iotaledger/wasp/packages/apilib"
 	"github.com/iotaledger/wasp/packages/coretypes"
 	"github.com/iotaledger/wasp/packages/hashing"
 	"github.com/iotaledger/wasp/packages/kv"
@@ -32,7 +34,8 @@ type Chain struct {
 
 	CommitteeNodes []int
 	Quorum         uint16
-	Address        address.Address
+	// Address holds the chain token. It is the alias of the ChainID unless the committee was rotated
+	Address address.Address
 
 	ChainID coretypes.ChainID
 	Color   balance.Color
@@ -41,7 +44,7 @@ type Chain struct {
 }
 
 func (ch *Chain) ChainAddress() *address.Address {
-	r := address.Address(ch.ChainID)
+	r := ch.Address
 	return &r
 }
 
@@ -76,12 +79,14 @@ func (ch *Chain) OriginatorClient() *chainclient.Client {
 }
 
 func (ch *Chain) Client(sigScheme signaturescheme.SignatureScheme) *chainclient.Client {
-	return chainclient.New(
+	ret := chainclient.New(
 		ch.Cluster.Level1Client(),
 		ch.Cluster.WaspClient(ch.CommitteeNodes[0]),
 		ch.ChainID,
 		sigScheme,
 	)
+	ret.ChainAddress = ch.ChainAddress()
+	return ret
 }
 
 func (ch *Chain) SCClient(contractHname coretypes.Hname, sigScheme signaturescheme.SignatureScheme) *scclient.SCClient {
@@ -92,6 +97,54 @@ func (ch *Chain) CommitteeMultiClient() *multiclient.MultiClient {
 	return multiclient.New(ch.ApiHosts())
 }
 
+// RotateCommittee moves the chain to the committee of the nodes
+func (ch *Chain) RotateCommittee(committeeNodes []int, quorum uint16) error {
+	addr, err := apilib.RotateCommittee(apilib.RotateCommitteeParams{
+		Node:                      ch.Cluster.Level1Client(),
+		ChainID:                   ch.ChainID,
+		ChainColor:                ch.Color,
+		ChainAddress:              ch.ChainAddress(),
+		CommitteeApiHosts:         ch.ApiHosts(),
+		CommitteePeeringHosts:     ch.PeeringHosts(),
+		NextCommitteeApiHosts:     ch.Cluster.Config.ApiHosts(committeeNodes),
+		NextCommitteePeeringHosts: ch.Cluster.Config.PeeringHosts(committeeNodes),
+		T:                         quorum,
+		OwnerSigScheme:            ch.OriginatorSigScheme(),
+		Textout:                   os.Stdout,
+		Prefix:                    "[cluster] ",
+	})
+	if err != nil {
+		return err
+	}
+	ch.CommitteeNodes = committeeNodes
+	ch.Quorum = quorum
+	ch.Address = *addr
+	return nil
+}
+
+func (ch *Chain) ReshareCommittee(committeeNodes []int, quorum uint16) error {
+	err := apilib.ReshareCommittee(apilib.RotateCommitteeParams{
+		Node:                      ch.Cluster.Level1Client(),
+		ChainID:                   ch.ChainID,
+		ChainColor:                ch.Color,
+		ChainAddress:              ch.ChainAddress(),
+		CommitteeApiHosts:         ch.ApiHosts(),
+		CommitteePeeringHosts:     ch.PeeringHosts(),
+		NextCommitteeApiHosts:     ch.Cluster.Config.ApiHosts(committeeNodes),
+		NextCommitteePeeringHosts: ch.Cluster.Config.PeeringHosts(committeeNodes),
+		T:                         quorum,
+		OwnerSigScheme:            ch.OriginatorSigScheme(),
+		Textout:                   os.Stdout,
+		Prefix:                    "[cluster] ",
+	})
+	if err != nil {
+		return err
+	}
+	ch.CommitteeNodes = committeeNodes
+	ch.Quorum = quorum
+	return nil
+}
+
 func (ch *Chain) WithSCState(hname coretypes.Hname, f func(host string, blockIndex uint32, state dict.Dict) bool) bool {
 	pass := true
 	for i, host := range ch.ApiHosts() {
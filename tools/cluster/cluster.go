@@ -285,6 +285,11 @@ func (cluster *Cluster) Start(dataPath string) error {
 		return err
 	}
 
+	err = cluster.trustAll()
+	if err != nil {
+		return err
+	}
+
 	cluster.Started = true
 	return nil
 }
@@ -328,6 +333,31 @@ func (cluster *Cluster) start(dataPath string) error {
 	return nil
 }
 
+// trustAll makes all the wasp nodes of the cluster trust each other,
+// otherwise they refuse to pair and to run the DKG with each other.
+func (cluster *Cluster) trustAll() error {
+	allNodes := cluster.Config.AllNodes()
+	allPeers := make([]*model.PeeringNodeIdentity, len(allNodes))
+	for ni := range allNodes {
+		var err error
+		if allPeers[ni], err = cluster.WaspClient(allNodes[ni]).PeeringSelfGet(); err != nil {
+			return err
+		}
+	}
+	for ni := range allNodes {
+		for pi := range allPeers {
+			if ni == pi {
+				continue
+			}
+			if _, err := cluster.WaspClient(allNodes[ni]).PeeringTrustedPost(allPeers[pi].PubKey, allPeers[pi].NetID); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("[cluster] %d Wasp nodes trust each other\n", len(allNodes))
+	return nil
+}
+
 func (cluster *Cluster) startServer(command string, cwd string, name string, initOk chan<- bool, initOkMsg string) (*exec.Cmd, error) {
 	cmd := exec.Command(command)
 	cmd.Dir = cwd
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+	"github.com/iotaledger/wasp/client/chainclient"
+	"github.com/iotaledger/wasp/contracts/native/inccounter"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/kv/dict"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/tools/cluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateCommittee(t *testing.T) {
+	setup(t, "test_cluster")
+
+	chain, err := clu.DeployChain("chain to rotate", []int{0, 1, 2}, 2)
+	check(err, t)
+	oldAddress := chain.Address
+
+	name := "inncounter1"
+	hname := coretypes.Hn(name)
+	_, err = chain.DeployContract(name, inccounter.Interface.ProgramHash.String(), "inccounter", map[string]interface{}{
+		inccounter.VarCounter: 42,
+		root.ParamName:        name,
+	})
+	check(err, t)
+
+	err = requestFunds(clu, scOwnerAddr, "originator")
+	check(err, t)
+	increment(t, chain, hname)
+	checkRotatedCounter(t, chain, hname, 43)
+
+	// node 3 is new, node 0 leaves the committee
+	err = chain.RotateCommittee([]int{1, 2, 3}, 2)
+	check(err, t)
+	require.NotEqualValues(t, oldAddress, chain.Address)
+
+	if !clu.VerifyAddressBalances(&oldAddress, 0, map[balance.Color]int64{}, "old committee after rotation") {
+		t.Fail()
+	}
+	if !clu.VerifyAddressBalances(&chain.Address, 5, map[balance.Color]int64{
+		balance.ColorIOTA: 4,
+		chain.Color:       1,
+	}, "new committee after rotation") {
+		t.Fail()
+	}
+	checkRotatedCounter(t, chain, hname, 43)
+
+	// requests are sent to the new address of the chain and processed by the new committee
+	increment(t, chain, hname)
+	checkRotatedCounter(t, chain, hname, 44)
+
+	chain.WithSCState(root.Interface.Hname(), func(host string, blockIndex uint32, state dict.Dict) bool {
+		chid, _, _ := codec.DecodeChainID(state.MustGet(root.VarChainID))
+		require.EqualValues(t, chain.ChainID, chid)
+		addr, _, _ := codec.DecodeAddress(state.MustGet(root.VarChainAddress))
+		require.EqualValues(t, chain.Address, addr)
+		return true
+	})
+}
+
+func TestReshareCommittee(t *testing.T) {
+	setup(t, "test_cluster")
+
+	chain, err := clu.DeployChain("chain to reshare", []int{0, 1, 2}, 2)
+	check(err, t)
+	oldAddress := chain.Address
+
+	name := "inncounter1"
+	hname := coretypes.Hn(name)
+	_, err = chain.DeployContract(name, inccounter.Interface.ProgramHash.String(), "inccounter", map[string]interface{}{
+		inccounter.VarCounter: 42,
+		root.ParamName:        name,
+	})
+	check(err, t)
+
+	err = requestFunds(clu, scOwnerAddr, "originator")
+	check(err, t)
+	increment(t, chain, hname)
+	checkRotatedCounter(t, chain, hname, 43)
+
+	// node 3 is new, node 0 leaves the committee, the chain stays at its address
+	err = chain.ReshareCommittee([]int{1, 2, 3}, 2)
+	check(err, t)
+	require.EqualValues(t, oldAddress, chain.Address)
+	checkRotatedCounter(t, chain, hname, 43)
+
+	// requests are processed by the new committee
+	increment(t, chain, hname)
+	checkRotatedCounter(t, chain, hname, 44)
+}
+
+func increment(t *testing.T, chain *cluster.Chain, hname coretypes.Hname) {
+	reqTx, err := chain.Client(scOwner.SigScheme()).PostRequest(hname, coretypes.Hn(inccounter.FuncIncCounter),
+		chainclient.PostRequestParams{})
+	check(err, t)
+	err = chain.CommitteeMultiClient().WaitUntilAllRequestsProcessed(reqTx, 30*time.Second)
+	check(err, t)
+}
+
+func checkRotatedCounter(t *testing.T, chain *cluster.Chain, hname coretypes.Hname, expected int64) {
+	chain.WithSCState(hname, func(host string, blockIndex uint32, state dict.Dict) bool {
+		counterValue, _, _ := codec.DecodeInt64(state.MustGet(inccounter.VarCounter))
+		require.EqualValues(t, expected, counterValue)
+		return true
+	})
+}
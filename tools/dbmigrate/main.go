@@ -0,0 +1,52 @@
+// program copies the database of the Wasp node from one storage engine to the other.
+// The node must be stopped while the database is migrated
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/dbprovider"
+)
+
+func main() {
+	fromEngine := flag.String("from-engine", dbprovider.EngineBadger, "storage engine of the source database: badger or bolt")
+	fromDir := flag.String("from", "waspdb", "directory of the source database")
+	toEngine := flag.String("to-engine", dbprovider.EngineBolt, "storage engine of the target database: badger or bolt")
+	toDir := flag.String("to", "", "directory of the target database. It must not contain a database")
+	flag.Parse()
+
+	if *toDir == "" || *toDir == *fromDir {
+		fmt.Printf("Usage: dbmigrate -from-engine <engine> -from <dir> -to-engine <engine> -to <other dir>\n")
+		os.Exit(1)
+	}
+	if err := migrate(*fromEngine, *fromDir, *toEngine, *toDir); err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func migrate(fromEngine, fromDir, toEngine, toDir string) error {
+	from, err := dbprovider.NewBackend(fromEngine, fromDir)
+	if err != nil {
+		return fmt.Errorf("can't open source database: %v", err)
+	}
+	defer from.Close()
+
+	to, err := dbprovider.NewBackend(toEngine, toDir)
+	if err != nil {
+		return fmt.Errorf("can't open target database: %v", err)
+	}
+	defer to.Close()
+
+	fmt.Printf("migrating %s database %s to %s database %s\n", fromEngine, fromDir, toEngine, toDir)
+	start := time.Now()
+	count, err := dbprovider.Migrate(from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d key/values copied in %v\n", count, time.Since(start))
+	return nil
+}
@@ -0,0 +1,149 @@
+package chain
+
+import (
+	"os"
+	"strings"
+
+	"github.com/iotaledger/wasp/client/chainclient"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+	"github.com/iotaledger/wasp/tools/wasp-cli/util"
+)
+
+var aclSubcmds = map[string]func([]string){
+	"list":        aclListCmd,
+	"define-role": aclDefineRoleCmd,
+	"remove-role": aclRemoveRoleCmd,
+	"assign":      aclAssignCmd,
+	"revoke":      aclRevokeCmd,
+	"guard":       aclGuardCmd,
+}
+
+func aclCmd(args []string) {
+	if len(args) < 1 {
+		aclUsage()
+	}
+	subcmd, ok := aclSubcmds[args[0]]
+	if !ok {
+		aclUsage()
+	}
+	subcmd(args[1:])
+}
+
+func aclUsage() {
+	cmdNames := make([]string, 0)
+	for k := range aclSubcmds {
+		cmdNames = append(cmdNames, k)
+	}
+	log.Usage("%s chain acl [%s]\n", os.Args[0], strings.Join(cmdNames, "|"))
+}
+
+func aclListCmd(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: %s chain acl list <sc-name>", os.Args[0])
+	}
+	params := codec.MakeDict(map[string]interface{}{
+		root.ParamHname: coretypes.Hn(args[0]),
+	})
+	roles, err := SCClient(root.Interface.Hname()).CallView(root.FuncGetRoles, params)
+	log.Check(err)
+
+	header := []string{"role", "agentid"}
+	rows := make([][]string, 0)
+	for role, members := range roles {
+		if len(members) == 0 {
+			rows = append(rows, []string{string(role), ""})
+		}
+		for i := 0; i+coretypes.AgentIDLength <= len(members); i += coretypes.AgentIDLength {
+			agentID, err := coretypes.NewAgentIDFromBytes(members[i : i+coretypes.AgentIDLength])
+			log.Check(err)
+			rows = append(rows, []string{string(role), agentID.String()})
+		}
+	}
+	log.PrintTable(header, rows)
+
+	guards, err := SCClient(root.Interface.Hname()).CallView(root.FuncGetGuards, params)
+	log.Check(err)
+
+	header = []string{"entry point", "role"}
+	rows = make([][]string, 0, len(guards))
+	for ep, role := range guards {
+		hname, err := coretypes.NewHnameFromBytes([]byte(ep))
+		log.Check(err)
+		rows = append(rows, []string{hname.String(), string(role)})
+	}
+	log.PrintTable(header, rows)
+}
+
+func aclDefineRoleCmd(args []string) {
+	if len(args) != 2 {
+		log.Fatal("Usage: %s chain acl define-role <sc-name> <role>", os.Args[0])
+	}
+	postACLRequest(root.FuncDefineRole, args[0], map[string]interface{}{
+		root.ParamRole: args[1],
+	})
+}
+
+func aclRemoveRoleCmd(args []string) {
+	if len(args) != 2 {
+		log.Fatal("Usage: %s chain acl remove-role <sc-name> <role>", os.Args[0])
+	}
+	postACLRequest(root.FuncRemoveRole, args[0], map[string]interface{}{
+		root.ParamRole: args[1],
+	})
+}
+
+func aclAssignCmd(args []string) {
+	if len(args) != 3 {
+		log.Fatal("Usage: %s chain acl assign <sc-name> <role> <agentid>", os.Args[0])
+	}
+	agentID, err := coretypes.NewAgentIDFromString(args[2])
+	log.Check(err)
+	postACLRequest(root.FuncAssignRole, args[0], map[string]interface{}{
+		root.ParamRole:    args[1],
+		root.ParamAgentID: agentID,
+	})
+}
+
+func aclRevokeCmd(args []string) {
+	if len(args) != 3 {
+		log.Fatal("Usage: %s chain acl revoke <sc-name> <role> <agentid>", os.Args[0])
+	}
+	agentID, err := coretypes.NewAgentIDFromString(args[2])
+	log.Check(err)
+	postACLRequest(root.FuncRevokeRole, args[0], map[string]interface{}{
+		root.ParamRole:    args[1],
+		root.ParamAgentID: agentID,
+	})
+}
+
+func aclGuardCmd(args []string) {
+	if len(args) != 2 && len(args) != 3 {
+		log.Fatal("Usage: %s chain acl guard <sc-name> <func-name> [<role>]", os.Args[0])
+	}
+	params := map[string]interface{}{
+		root.ParamEntryPoint: coretypes.Hn(args[1]),
+	}
+	if len(args) == 3 {
+		// without the role the guard is removed
+		params[root.ParamRole] = args[2]
+	}
+	postACLRequest(root.FuncSetGuard, args[0], params)
+}
+
+func postACLRequest(funcName string, scName string, params map[string]interface{}) {
+	params[root.ParamHname] = coretypes.Hn(scName)
+	util.WithSCTransaction(func() (*sctransaction.Transaction, error) {
+		return Client().PostRequest(
+			root.Interface.Hname(),
+			coretypes.Hn(funcName),
+			chainclient.PostRequestParams{
+				Args: requestargs.New().AddEncodeSimpleMany(codec.MakeDict(params)),
+			},
+		)
+	})
+}
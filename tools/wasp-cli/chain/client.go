@@ -10,12 +10,17 @@ import (
 )
 
 func Client() *chainclient.Client {
-	return chainclient.New(
+	c := chainclient.New(
 		config.GoshimmerClient(),
 		config.WaspClient(),
 		GetCurrentChainID(),
 		wallet.Load().SignatureScheme(),
 	)
+	// requests are sent to the current address of the chain, which is known if the node has the chain record
+	if chain, err := config.WaspClient().GetChainRecord(GetCurrentChainID()); err == nil {
+		c.ChainAddress = chain.StateAddress
+	}
+	return c
 }
 
 func MultiClient() *multiclient.MultiClient {
@@ -14,6 +14,7 @@ func InitCommands(commands map[string]func([]string), flags *pflag.FlagSet) {
 	fs := pflag.NewFlagSet("chain", pflag.ExitOnError)
 	initDeployFlags(fs)
 	initUploadFlags(fs)
+	initPostRequestFlags(fs)
 	initAliasFlags(fs)
 	flags.AddFlagSet(fs)
 }
@@ -24,6 +25,9 @@ var subcmds = map[string]func([]string){
 	"info":            infoCmd,
 	"list-contracts":  listContractsCmd,
 	"deploy-contract": deployContractCmd,
+	"pause-contract":  pauseContractCmd,
+	"resume-contract": resumeContractCmd,
+	"remove-contract": removeContractCmd,
 	"list-accounts":   listAccountsCmd,
 	"balance":         balanceCmd,
 	"list-blobs":      listBlobsCmd,
@@ -32,8 +36,13 @@ var subcmds = map[string]func([]string){
 	"log":             logCmd,
 	"post-request":    postRequestCmd,
 	"call-view":       callViewCmd,
+	"request":         requestCmd,
 	"activate":        activateCmd,
 	"deactivate":      deactivateCmd,
+	"acl":             aclCmd,
+	"snapshot":        snapshotCmd,
+	"rotate":          rotateCmd,
+	"reshare":         reshareCmd,
 }
 
 func chainCmd(args []string) {
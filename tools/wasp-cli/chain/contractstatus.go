@@ -0,0 +1,58 @@
+package chain
+
+import (
+	"os"
+
+	"github.com/iotaledger/wasp/client/chainclient"
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/packages/coretypes/requestargs"
+	"github.com/iotaledger/wasp/packages/kv/codec"
+	"github.com/iotaledger/wasp/packages/sctransaction"
+	"github.com/iotaledger/wasp/packages/vm/core/root"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+	"github.com/iotaledger/wasp/tools/wasp-cli/util"
+)
+
+func pauseContractCmd(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: %s chain pause-contract <sc-name>", os.Args[0])
+	}
+	postContractStatusRequest(root.FuncPauseContract, args[0], nil)
+}
+
+func resumeContractCmd(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: %s chain resume-contract <sc-name>", os.Args[0])
+	}
+	postContractStatusRequest(root.FuncResumeContract, args[0], nil)
+}
+
+func removeContractCmd(args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		log.Fatal("Usage: %s chain remove-contract <sc-name> [<agentid>]", os.Args[0])
+	}
+	params := map[string]interface{}{}
+	if len(args) == 2 {
+		// the target of the remaining balance, defaults to the chain owner
+		agentID, err := coretypes.NewAgentIDFromString(args[1])
+		log.Check(err)
+		params[root.ParamAgentID] = agentID
+	}
+	postContractStatusRequest(root.FuncRemoveContract, args[0], params)
+}
+
+func postContractStatusRequest(funcName string, scName string, params map[string]interface{}) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	params[root.ParamHname] = coretypes.Hn(scName)
+	util.WithSCTransaction(func() (*sctransaction.Transaction, error) {
+		return Client().PostRequest(
+			root.Interface.Hname(),
+			coretypes.Hn(funcName),
+			chainclient.PostRequestParams{
+				Args: requestargs.New().AddEncodeSimpleMany(codec.MakeDict(params)),
+			},
+		)
+	})
+}
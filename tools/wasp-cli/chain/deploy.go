@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/iotaledger/wasp/packages/apilib"
+	"github.com/iotaledger/wasp/packages/registry"
 	"github.com/iotaledger/wasp/tools/wasp-cli/config"
 	"github.com/iotaledger/wasp/tools/wasp-cli/log"
 	"github.com/iotaledger/wasp/tools/wasp-cli/wallet"
@@ -13,11 +14,13 @@ import (
 var committee []int
 var quorum int
 var description string
+var consensus string
 
 func initDeployFlags(flags *pflag.FlagSet) {
 	flags.IntSliceVarP(&committee, "committee", "", []int{0, 1, 2, 3}, "committee indices")
 	flags.IntVarP(&quorum, "quorum", "", 3, "quorum")
 	flags.StringVarP(&description, "description", "", "", "description")
+	flags.StringVarP(&consensus, "consensus", "", registry.ConsensusLeader, "consensus algorithm: leader, bft or acs")
 }
 
 func deployCmd(args []string) {
@@ -31,6 +34,7 @@ func deployCmd(args []string) {
 		T:                     uint16(quorum),
 		OriginatorSigScheme:   wallet.Load().SignatureScheme(),
 		Description:           description,
+		Consensus:             consensus,
 		Textout:               os.Stdout,
 		Prefix:                "",
 	})
@@ -16,6 +16,15 @@ func infoCmd(args []string) {
 	log.Printf("Chain ID: %s\n", chain.ChainID)
 	log.Printf("Committee nodes: %+v\n", chain.CommitteeNodes)
 	log.Printf("Active: %v\n", chain.Active)
+	if chain.StateAddress != nil {
+		log.Printf("Committee address: %s\n", chain.StateAddress)
+	}
+	if len(chain.PeerNodes) > 0 {
+		log.Printf("Peer nodes: %+v\n", chain.PeerNodes)
+	}
+	if chain.IsRotating() {
+		log.Printf("Rotating to committee: %+v, address %s\n", chain.NextCommitteeNodes, chain.NextStateAddress)
+	}
 
 	if chain.Active {
 		info, err := SCClient(root.Interface.Hname()).CallView(root.FuncGetChainInfo, nil)
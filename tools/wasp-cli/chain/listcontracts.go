@@ -24,6 +24,7 @@ func listContractsCmd(args []string) {
 		"hname",
 		"name",
 		"description",
+		"status",
 		"proghash",
 		"creator",
 		"owner fee",
@@ -50,6 +51,7 @@ func listContractsCmd(args []string) {
 			hname.String(),
 			c.Name,
 			c.Description,
+			c.Status.String(),
 			c.ProgramHash.String(),
 			creator,
 			fmt.Sprintf("%d %s", ownerFee, feeColor),
@@ -9,8 +9,15 @@ import (
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/tools/wasp-cli/log"
 	"github.com/iotaledger/wasp/tools/wasp-cli/util"
+	"github.com/spf13/pflag"
 )
 
+var encryptArgs bool
+
+func initPostRequestFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&encryptArgs, "encrypt", "", false, "encrypt request arguments for the committee of the chain")
+}
+
 func postRequestCmd(args []string) {
 	if len(args) < 2 {
 		log.Fatal("Usage: %s chain post-request <name> <funcname> [params]", os.Args[0])
@@ -19,7 +26,8 @@ func postRequestCmd(args []string) {
 		return SCClient(coretypes.Hn(args[0])).PostRequest(
 			args[1],
 			chainclient.PostRequestParams{
-				Args: requestargs.New().AddEncodeSimpleMany(util.EncodeParams(args[2:])),
+				Args:    requestargs.New().AddEncodeSimpleMany(util.EncodeParams(args[2:])),
+				Encrypt: encryptArgs,
 			},
 		)
 	})
@@ -0,0 +1,37 @@
+package chain
+
+import (
+	"os"
+
+	"github.com/iotaledger/wasp/packages/coretypes"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+	"github.com/iotaledger/wasp/tools/wasp-cli/util"
+)
+
+func requestCmd(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: %s chain request <request-id>", os.Args[0])
+	}
+	reqID, err := coretypes.NewRequestIDFromBase58(args[0])
+	log.Check(err)
+
+	rec, err := Client().RequestReceipt(&reqID)
+	log.Check(err)
+
+	log.Printf("Request %s\n", rec.RequestID.String())
+	log.Printf("Block index: %d\n", rec.BlockIndex)
+	log.Printf("Position in the block: %d\n", rec.RequestIndex)
+	if rec.Error == "" {
+		log.Printf("Status: Ok\n")
+	} else {
+		log.Printf("Status: failed: %s\n", rec.Error)
+	}
+	log.Printf("Fees charged: %d %s\n", rec.FeesCharged, rec.FeeColor.String())
+	log.Printf("Gas burned: %d\n", rec.GasBurned)
+	if rec.Result != nil {
+		log.Printf("Result:\n")
+		util.PrintDictAsJson(rec.Result)
+	} else {
+		log.Printf("Result: not stored\n")
+	}
+}
@@ -0,0 +1,30 @@
+package chain
+
+import (
+	"os"
+
+	"github.com/iotaledger/wasp/packages/apilib"
+	"github.com/iotaledger/wasp/tools/wasp-cli/config"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+)
+
+// reshareCmd reshares the key of the current chain to the committee given by the --committee and --quorum flags
+func reshareCmd(args []string) {
+	chain, err := config.WaspClient().GetChainRecord(GetCurrentChainID())
+	log.Check(err)
+	current := chainCommittee()
+
+	err = apilib.ReshareCommittee(apilib.RotateCommitteeParams{
+		ChainID:                   chain.ChainID,
+		ChainColor:                chain.Color,
+		ChainAddress:              chain.StateAddress,
+		CommitteeApiHosts:         config.CommitteeApi(current),
+		CommitteePeeringHosts:     config.CommitteePeering(current),
+		NextCommitteeApiHosts:     config.CommitteeApi(committee),
+		NextCommitteePeeringHosts: config.CommitteePeering(committee),
+		T:                         uint16(quorum),
+		Consensus:                 chain.Consensus,
+		Textout:                   os.Stdout,
+	})
+	log.Check(err)
+}
@@ -0,0 +1,33 @@
+package chain
+
+import (
+	"os"
+
+	"github.com/iotaledger/wasp/packages/apilib"
+	"github.com/iotaledger/wasp/tools/wasp-cli/config"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+	"github.com/iotaledger/wasp/tools/wasp-cli/wallet"
+)
+
+// rotateCmd moves the current chain to the committee given by the --committee and --quorum flags
+func rotateCmd(args []string) {
+	chain, err := config.WaspClient().GetChainRecord(GetCurrentChainID())
+	log.Check(err)
+	current := chainCommittee()
+
+	_, err = apilib.RotateCommittee(apilib.RotateCommitteeParams{
+		Node:                      config.GoshimmerClient(),
+		ChainID:                   chain.ChainID,
+		ChainColor:                chain.Color,
+		ChainAddress:              chain.StateAddress,
+		CommitteeApiHosts:         config.CommitteeApi(current),
+		CommitteePeeringHosts:     config.CommitteePeering(current),
+		NextCommitteeApiHosts:     config.CommitteeApi(committee),
+		NextCommitteePeeringHosts: config.CommitteePeering(committee),
+		T:                         uint16(quorum),
+		Consensus:                 chain.Consensus,
+		OwnerSigScheme:            wallet.Load().SignatureScheme(),
+		Textout:                   os.Stdout,
+	})
+	log.Check(err)
+}
@@ -0,0 +1,59 @@
+package chain
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/iotaledger/wasp/tools/wasp-cli/config"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+)
+
+var snapshotSubcmds = map[string]func([]string){
+	"export": snapshotExportCmd,
+	"import": snapshotImportCmd,
+}
+
+func snapshotCmd(args []string) {
+	if len(args) < 1 {
+		snapshotUsage()
+	}
+	subcmd, ok := snapshotSubcmds[args[0]]
+	if !ok {
+		snapshotUsage()
+	}
+	subcmd(args[1:])
+}
+
+func snapshotUsage() {
+	cmdNames := make([]string, 0)
+	for k := range snapshotSubcmds {
+		cmdNames = append(cmdNames, k)
+	}
+	log.Usage("%s chain snapshot [%s]\n", os.Args[0], strings.Join(cmdNames, "|"))
+}
+
+func snapshotExportCmd(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: %s chain snapshot export <filename>", os.Args[0])
+	}
+	chainID := GetCurrentChainID()
+	snapshot, err := config.WaspClient().ExportSnapshot(&chainID)
+	log.Check(err)
+	log.Check(ioutil.WriteFile(args[0], snapshot.Data.Bytes(), 0644))
+	// older blocks may be pruned by the node only after the snapshot is written
+	log.Check(config.WaspClient().ConfirmSnapshot(&chainID, snapshot.BlockIndex))
+	log.Printf("Snapshot of the state #%d of chain %s written to %s. State hash: %s\n",
+		snapshot.BlockIndex, chainID, args[0], snapshot.StateHash)
+}
+
+func snapshotImportCmd(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: %s chain snapshot import <filename>", os.Args[0])
+	}
+	data, err := ioutil.ReadFile(args[0])
+	log.Check(err)
+	chainID := GetCurrentChainID()
+	log.Check(config.WaspClient().ImportSnapshot(&chainID, data))
+	log.Printf("Snapshot imported to chain %s. Activate the chain to sync from the snapshot\n", chainID)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/iotaledger/wasp/tools/wasp-cli/config"
 	"github.com/iotaledger/wasp/tools/wasp-cli/decode"
 	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+	"github.com/iotaledger/wasp/tools/wasp-cli/peering"
 	"github.com/iotaledger/wasp/tools/wasp-cli/wallet"
 	"github.com/spf13/pflag"
 )
@@ -35,6 +36,7 @@ func main() {
 	chain.InitCommands(commands, flags)
 	decode.InitCommands(commands, flags)
 	blob.InitCommands(commands, flags)
+	peering.InitCommands(commands, flags)
 
 	log.Check(flags.Parse(os.Args[1:]))
 
@@ -0,0 +1,95 @@
+package peering
+
+import (
+	"os"
+	"strings"
+
+	"github.com/iotaledger/wasp/client"
+	"github.com/iotaledger/wasp/tools/wasp-cli/config"
+	"github.com/iotaledger/wasp/tools/wasp-cli/log"
+	"github.com/spf13/pflag"
+)
+
+var node int
+
+func InitCommands(commands map[string]func([]string), flags *pflag.FlagSet) {
+	commands["peering"] = peeringCmd
+	flags.IntVarP(&node, "node", "", 0, "index of the wasp node to manage the trusted peers of")
+}
+
+var subcmds = map[string]func([]string){
+	"info":     infoCmd,
+	"trust":    trustCmd,
+	"distrust": distrustCmd,
+	"list":     listCmd,
+}
+
+func peeringCmd(args []string) {
+	if len(args) < 1 {
+		usage()
+	}
+	subcmd, ok := subcmds[args[0]]
+	if !ok {
+		usage()
+	}
+	subcmd(args[1:])
+}
+
+func usage() {
+	cmdNames := make([]string, 0)
+	for k := range subcmds {
+		cmdNames = append(cmdNames, k)
+	}
+
+	log.Usage("%s peering [%s]\n", os.Args[0], strings.Join(cmdNames, "|"))
+}
+
+func infoCmd(args []string) {
+	if len(args) != 0 {
+		log.Usage("%s peering info\n", os.Args[0])
+	}
+	self, err := nodeClient().PeeringSelfGet()
+	log.Check(err)
+	log.Printf("NetID:  %s\n", self.NetID)
+	log.Printf("PubKey: %s\n", self.PubKey)
+}
+
+func trustCmd(args []string) {
+	if len(args) != 2 {
+		log.Usage("%s peering trust <pubKey> <name>\n", os.Args[0])
+	}
+	trusted, err := nodeClient().PeeringTrustedPost(args[0], args[1])
+	log.Check(err)
+	log.Printf("Peer %s (%s) is trusted now.\n", trusted.Name, trusted.PubKey)
+}
+
+func distrustCmd(args []string) {
+	if len(args) != 1 {
+		log.Usage("%s peering distrust <pubKey>\n", os.Args[0])
+	}
+	distrusted, err := nodeClient().PeeringTrustedDelete(args[0])
+	log.Check(err)
+	log.Printf("Peer %s (%s) is not trusted anymore.\n", distrusted.Name, distrusted.PubKey)
+}
+
+func listCmd(args []string) {
+	if len(args) != 0 {
+		log.Usage("%s peering list\n", os.Args[0])
+	}
+	waspClient := nodeClient()
+	trusted, err := waspClient.PeeringTrustedList()
+	log.Check(err)
+	log.Printf("Total %d trusted peer(s) in wasp node %s\n", len(trusted), waspClient.BaseURL())
+	header := []string{"name", "pubkey"}
+	rows := make([][]string, len(trusted))
+	for i, tp := range trusted {
+		rows[i] = []string{tp.Name, tp.PubKey}
+	}
+	log.PrintTable(header, rows)
+}
+
+func nodeClient() *client.WaspClient {
+	host := config.CommitteeApi([]int{node})[0]
+	log.Verbose("using Wasp host %s\n", host)
+	return client.NewWaspClient(host)
+}